@@ -0,0 +1,16 @@
+// Package fonts embeds the interface font, so Chinese text renders without
+// relying on the fonts installed on the player's system.
+package fonts
+
+import _ "embed"
+
+// Name is the file name of the interface font: the Simplified Chinese face
+// of Noto Sans CJK, licensed under the SIL Open Font License 1.1 (OFL.txt).
+// Collections (.ttc) cannot be read by the text renderer, so the face is
+// kept as a font file of its own.
+const Name = "NotoSansCJKsc-Bold.otf"
+
+// Interface holds the font file named by Name.
+//
+//go:embed NotoSansCJKsc-Bold.otf
+var Interface []byte
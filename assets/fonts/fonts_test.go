@@ -0,0 +1,24 @@
+package fonts
+
+import (
+	"testing"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+func TestInterface(t *testing.T) {
+	f, err := sfnt.Parse(Interface)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", Name, err)
+	}
+	var buf sfnt.Buffer
+	for _, r := range "补丁管理器" {
+		i, err := f.GlyphIndex(&buf, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			t.Errorf("%s has no glyph for %q", Name, r)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// canAssociate reports whether registerFileAssociation is supported.
+const canAssociate = false
+
+func fileAssociationRegistered() bool {
+	return false
+}
+
+// registerFileAssociation is only implemented for Windows.
+func registerFileAssociation() error {
+	return errors.New("file associations are only supported on Windows")
+}
+
+// unregisterFileAssociation is only implemented for Windows.
+func unregisterFileAssociation() error {
+	return errors.New("file associations are only supported on Windows")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+
+	"dnf_patch/internal/deeplink"
+	"dnf_patch/internal/i18n"
+)
+
+// canAssociate reports whether registerFileAssociation is supported.
+const canAssociate = true
+
+// Keys under HKCU\Software\Classes, which take effect for the current user
+// without administrator rights.
+const (
+	classesKey   = `Software\Classes`
+	npkExtKey    = classesKey + `\.npk`
+	npkProgID    = "DNFPatch.npk"
+	npkProgIDKey = classesKey + `\` + npkProgID
+	// The context-menu verb is registered for .npk files whichever program
+	// opens them by default
+	npkVerbKey = classesKey + `\SystemFileAssociations\.npk\shell\DNFPatch.Install`
+	// dnfpatch:// links from web pages
+	linkSchemeKey = classesKey + `\` + deeplink.Scheme
+)
+
+// fileAssociationRegistered reports whether .npk files open with this tool.
+func fileAssociationRegistered() bool {
+	k, err := registry.OpenKey(registry.CURRENT_USER, npkExtKey, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer k.Close()
+	progID, _, err := k.GetStringValue("")
+	return err == nil && progID == npkProgID
+}
+
+// registerFileAssociation makes double-clicked .npk files and dnfpatch://
+// links open with this tool and adds "Install with DNF Patch Tool" to the
+// context menu of .npk files.
+func registerFileAssociation() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	command := fmt.Sprintf(`"%s" "%%1"`, exe)
+	values := []struct{ key, name, value string }{
+		{npkProgIDKey, "", i18n.T("association.fileType")},
+		{npkProgIDKey + `\DefaultIcon`, "", exe + ",0"},
+		{npkProgIDKey + `\shell\open\command`, "", command},
+		{npkExtKey, "", npkProgID},
+		{npkVerbKey, "", i18n.T("association.verb")},
+		{npkVerbKey, "Icon", exe},
+		{npkVerbKey + `\command`, "", command},
+		{linkSchemeKey, "", i18n.T("association.linkType")},
+		{linkSchemeKey, "URL Protocol", ""},
+		{linkSchemeKey + `\DefaultIcon`, "", exe + ",0"},
+		{linkSchemeKey + `\shell\open\command`, "", command},
+	}
+	for _, v := range values {
+		k, _, err := registry.CreateKey(registry.CURRENT_USER, v.key, registry.SET_VALUE)
+		if err != nil {
+			return fmt.Errorf("creating HKCU\\%s: %v", v.key, err)
+		}
+		err = k.SetStringValue(v.name, v.value)
+		k.Close()
+		if err != nil {
+			return fmt.Errorf("writing HKCU\\%s: %v", v.key, err)
+		}
+	}
+	notifyAssociationChanged()
+	return nil
+}
+
+// unregisterFileAssociation removes what registerFileAssociation added. The
+// .npk extension key is only removed while it still points to this tool.
+func unregisterFileAssociation() error {
+	if fileAssociationRegistered() {
+		if err := deleteKeyTree(npkExtKey); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{npkProgIDKey, npkVerbKey, linkSchemeKey} {
+		if err := deleteKeyTree(key); err != nil {
+			return err
+		}
+	}
+	notifyAssociationChanged()
+	return nil
+}
+
+// deleteKeyTree deletes the HKCU key at path with its subkeys; a missing key
+// is not an error.
+func deleteKeyTree(path string) error {
+	k, err := registry.OpenKey(registry.CURRENT_USER, path, registry.ENUMERATE_SUB_KEYS)
+	if err == registry.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	subkeys, err := k.ReadSubKeyNames(-1)
+	k.Close()
+	if err != nil {
+		return err
+	}
+	for _, name := range subkeys {
+		if err := deleteKeyTree(path + `\` + name); err != nil {
+			return err
+		}
+	}
+	if err := registry.DeleteKey(registry.CURRENT_USER, path); err != nil && err != registry.ErrNotExist {
+		return fmt.Errorf("deleting HKCU\\%s: %v", path, err)
+	}
+	return nil
+}
+
+// notifyAssociationChanged tells Explorer to pick up the new association.
+func notifyAssociationChanged() {
+	const shcneAssocChanged, shcnfIDList = 0x08000000, 0
+	shChangeNotify := syscall.NewLazyDLL("shell32.dll").NewProc("SHChangeNotify")
+	shChangeNotify.Call(shcneAssocChanged, shcnfIDList, 0, 0)
+}
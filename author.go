@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/autocategory"
+	"dnf_patch/internal/bundle"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/notes"
+)
+
+// createAuthorFileList shows the files in *files with buttons to add and
+// remove them. Added files must pass check; changed is called whenever the
+// list changes.
+func (p *PatchApp) createAuthorFileList(files *[]string, extensions []string, check func(string) error, changed func()) fyne.CanvasObject {
+	selected := -1
+	list := widget.NewList(
+		func() int { return len(*files) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(filepath.Base((*files)[id]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.OnUnselected = func(widget.ListItemID) { selected = -1 }
+	update := func() {
+		list.UnselectAll()
+		list.Refresh()
+		changed()
+	}
+
+	add := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				p.showError(err)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if err := check(path); err != nil {
+				p.showError(err)
+				return
+			}
+			*files = append(*files, path)
+			update()
+		}, p.window)
+		open.SetFilter(storage.NewExtensionFileFilter(extensions))
+		open.Show()
+	})
+	remove := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		if selected >= 0 && selected < len(*files) {
+			*files = append((*files)[:selected], (*files)[selected+1:]...)
+			update()
+		}
+	})
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(0, 70))
+	return container.NewBorder(nil, nil, nil, container.NewVBox(add, remove), scroll)
+}
+
+// showPackageWizard lets patch authors describe one or more NPK files and
+// saves them as a bundle: a ZIP with the files, their previews and a
+// manifest.json ready to upload. The entries can also be added to a local
+// patches.json.
+func (p *PatchApp) showPackageWizard() {
+	var files, previews []string
+
+	nameEntry := widget.NewEntry()
+	nameEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return errors.New(i18n.T("author.noName"))
+		}
+		return nil
+	}
+	idEntry := widget.NewEntry()
+	idEntry.Validator = func(s string) error {
+		if !bundle.ValidID(s) {
+			return errors.New(i18n.T("author.badID"))
+		}
+		return nil
+	}
+	// The ID follows the name until the author edits it
+	idEdited := false
+	idEntry.OnChanged = func(s string) { idEdited = s != bundle.Slug(nameEntry.Text) }
+	versionEntry := widget.NewEntry()
+	versionEntry.SetText("1.0.0")
+	versionEntry.Validator = func(s string) error {
+		if !bundle.ValidVersion(s) {
+			return errors.New(i18n.T("author.badVersion"))
+		}
+		return nil
+	}
+	authorEntry := widget.NewEntry()
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder(i18n.T("notes.tagsHint"))
+	descriptionEntry := widget.NewMultiLineEntry()
+	descriptionEntry.Wrapping = fyne.TextWrapWord
+	descriptionEntry.SetMinRowsVisible(3)
+
+	repoCheck := widget.NewCheck(i18n.T("author.addToRepo"), nil)
+	repoEntry := widget.NewEntry()
+	repoEntry.SetPlaceHolder("patches.json")
+	repoEntry.Validator = func(s string) error {
+		if repoCheck.Checked && !strings.EqualFold(filepath.Ext(s), ".json") {
+			return errors.New(i18n.T("author.badRepo"))
+		}
+		return nil
+	}
+	browseButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				p.showError(err)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			writer.Close()
+			repoEntry.SetText(writer.URI().Path())
+		}, p.window)
+		save.SetFileName("patches.json")
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		save.Show()
+	})
+	categoryEntry := widget.NewEntry()
+	categoryEntry.SetPlaceHolder(i18n.T("author.category"))
+
+	// The category and tags suggested from the files fill in the entries
+	// until the author edits them
+	var suggested autocategory.Suggestion
+	var suggestions atomic.Int64
+	categoryEdited, tagsEdited := false, false
+	categoryEntry.OnChanged = func(s string) { categoryEdited = s != "" && s != suggested.Category }
+	tagsEntry.OnChanged = func(s string) { tagsEdited = s != "" && s != strings.Join(suggested.Tags, ", ") }
+	suggestion := widget.NewLabel("")
+	suggestion.Wrapping = fyne.TextWrapWord
+	suggestion.Importance = widget.LowImportance
+	suggestion.Hide()
+	suggest := func() {
+		n := suggestions.Add(1)
+		paths := append([]string(nil), files...)
+		go func() {
+			defer p.recoverPanic(i18n.T("op.author"))
+			s, err := p.suggestCategory(paths)
+			if err != nil {
+				slog.Warn("suggesting a category failed", "err", err)
+			}
+			if suggestions.Load() != n {
+				return
+			}
+			suggested = s
+			if !categoryEdited {
+				categoryEntry.SetText(s.Category)
+			}
+			if !tagsEdited {
+				tagsEntry.SetText(strings.Join(s.Tags, ", "))
+			}
+			if s.Category == "" {
+				suggestion.Hide()
+				return
+			}
+			suggestion.SetText(i18n.T("author.suggested", s.Category, strings.Join(s.Tags, i18n.T("common.listSeparator"))))
+			suggestion.Show()
+		}()
+	}
+
+	info := func() bundle.Info {
+		return bundle.Info{
+			ID:          idEntry.Text,
+			Name:        nameEntry.Text,
+			Description: descriptionEntry.Text,
+			Version:     versionEntry.Text,
+			Author:      authorEntry.Text,
+			Tags:        notes.ParseTags(tagsEntry.Text),
+			Files:       append([]string(nil), files...),
+			Previews:    append([]string(nil), previews...),
+		}
+	}
+	problem := widget.NewLabel("")
+	problem.Importance = widget.DangerImportance
+	problem.Wrapping = fyne.TextWrapWord
+	buildButton := widget.NewButtonWithIcon(i18n.T("author.build"), theme.DocumentSaveIcon(), nil)
+	buildButton.Importance = widget.HighImportance
+	validate := func() {
+		err := info().Validate()
+		if err == nil && repoCheck.Checked {
+			if err = repoEntry.Validate(); err == nil && strings.TrimSpace(categoryEntry.Text) == "" {
+				err = errors.New(i18n.T("author.noCategory"))
+			}
+		}
+		if err != nil {
+			problem.SetText(err.Error())
+			problem.Show()
+			buildButton.Disable()
+		} else {
+			problem.Hide()
+			buildButton.Enable()
+		}
+	}
+	nameEntry.OnChanged = func(s string) {
+		if !idEdited {
+			idEntry.SetText(bundle.Slug(s))
+		}
+		validate()
+	}
+	idOnChanged := idEntry.OnChanged
+	idEntry.OnChanged = func(s string) { idOnChanged(s); validate() }
+	for _, e := range []*widget.Entry{versionEntry, repoEntry} {
+		e.OnChanged = func(string) { validate() }
+	}
+	categoryOnChanged := categoryEntry.OnChanged
+	categoryEntry.OnChanged = func(s string) { categoryOnChanged(s); validate() }
+	repoSettings := container.NewVBox(
+		container.NewBorder(nil, nil, nil, browseButton, repoEntry),
+		categoryEntry,
+	)
+	filesChanged := func() {
+		validate()
+		suggest()
+	}
+	repoSettings.Hide()
+	repoCheck.OnChanged = func(on bool) {
+		repoSettings.Hidden = !on
+		repoSettings.Refresh()
+		repoEntry.Validate()
+		validate()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("author.files"), p.createAuthorFileList(&files, []string{".npk", ".NPK"}, bundle.CheckNPK, filesChanged)),
+		widget.NewFormItem(i18n.T("author.name"), nameEntry),
+		widget.NewFormItem(i18n.T("author.id"), idEntry),
+		widget.NewFormItem(i18n.T("author.version"), versionEntry),
+		widget.NewFormItem(i18n.T("author.author"), authorEntry),
+		widget.NewFormItem(i18n.T("author.tags"), container.NewVBox(tagsEntry, suggestion)),
+		widget.NewFormItem(i18n.T("author.description"), descriptionEntry),
+		widget.NewFormItem(i18n.T("author.previews"), p.createAuthorFileList(&previews, []string{".png", ".jpg", ".jpeg"}, bundle.CheckPreview, validate)),
+	)
+	hint := widget.NewLabel(i18n.T("author.hint"))
+	hint.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(
+		hint,
+		container.NewVBox(repoCheck, repoSettings, problem, container.NewHBox(layout.NewSpacer(), buildButton)),
+		nil, nil,
+		container.NewVScroll(form))
+	validate()
+
+	d := dialog.NewCustom(i18n.T("author.title"), i18n.T("common.close"), content, p.window)
+	buildButton.OnTapped = func() {
+		repo := ""
+		if repoCheck.Checked {
+			repo = repoEntry.Text
+		}
+		p.saveBundle(info(), repo, strings.TrimSpace(categoryEntry.Text), d.Hide)
+	}
+	d.Resize(fyne.NewSize(600, 650))
+	d.Show()
+}
+
+// saveBundle asks where to save the bundle of info and builds it, adding
+// its entries to the category of the repository at repo unless repo is "".
+// done is called once the file is chosen.
+func (p *PatchApp) saveBundle(info bundle.Info, repo, category string, done func()) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		dst := writer.URI().Path()
+		writer.Close()
+		done()
+
+		p.updateStatus(i18n.T("author.building", info.Name))
+		go func() {
+			defer p.recoverPanic(i18n.T("op.author"))
+			_, end := p.operations.begin(i18n.T("op.author"))
+			defer end()
+			m, err := bundle.BuildFile(dst, info)
+			if err == nil && repo != "" {
+				err = bundle.AddToRepository(repo, category, m, info)
+			}
+			if err != nil {
+				slog.Error("building bundle failed", "path", dst, "err", err)
+				p.updateStatus(i18n.T("author.failed"))
+				p.showError(err)
+				return
+			}
+			slog.Info("built bundle", "path", dst, "patches", len(m.Patches), "repository", repo)
+			p.updateStatus(i18n.T("author.done", filepath.Base(dst)))
+			p.showBundleBuilt(dst, len(m.Patches), repo)
+		}()
+	}, p.window)
+	save.SetFileName(info.ID + "-" + info.Version + ".zip")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	save.Show()
+}
+
+// showBundleBuilt tells the author where the bundle went, with a button to
+// open its folder.
+func (p *PatchApp) showBundleBuilt(path string, patches int, repo string) {
+	message := i18n.T("author.report", patches, path)
+	if repo != "" {
+		message += "\n" + i18n.T("author.addedToRepo", repo)
+	}
+	label := widget.NewLabel(message)
+	label.Wrapping = fyne.TextWrapBreak
+	open := widget.NewButtonWithIcon(i18n.T("author.openFolder"), theme.FolderOpenIcon(), func() {
+		u, err := url.Parse(storage.NewFileURI(filepath.Dir(path)).String())
+		if err == nil {
+			err = fyne.CurrentApp().OpenURL(u)
+		}
+		if err != nil {
+			slog.Error("opening bundle folder failed", "path", path, "err", err)
+			p.showError(err)
+		}
+	})
+	d := dialog.NewCustom(i18n.T("author.completeTitle"), i18n.T("common.close"), container.NewVBox(label, open), p.window)
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
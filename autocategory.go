@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"dnf_patch/internal/autocategory"
+	"dnf_patch/internal/npk"
+)
+
+// categoryTable reads the table suggesting categories from the IMG paths of
+// patches: the user's own autocategory.json in the data folder, or else the
+// one shipped in patches/.
+func (p *PatchManager) categoryTable() (autocategory.Table, error) {
+	t, err := autocategory.Load(filepath.Join(p.dataDir, "autocategory.json"))
+	if os.IsNotExist(err) {
+		t, err = autocategory.Load(filepath.Join(p.exeDir, "patches", "autocategory.json"))
+	}
+	return t, err
+}
+
+// suggestCategory suggests the category and tags of a patch made of the NPK
+// files at paths.
+func (p *PatchManager) suggestCategory(paths []string) (autocategory.Suggestion, error) {
+	table, err := p.categoryTable()
+	if err != nil {
+		return autocategory.Suggestion{}, err
+	}
+	var imgs []string
+	for _, path := range paths {
+		a, err := npk.Open(path)
+		if err != nil {
+			return autocategory.Suggestion{}, err
+		}
+		for _, e := range a.Entries {
+			imgs = append(imgs, e.Name)
+		}
+	}
+	return table.Suggest(imgs), nil
+}
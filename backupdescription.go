@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+)
+
+// maxDescribedPatches is how many patches the description of a backup taken
+// before an operation names.
+const maxDescribedPatches = 3
+
+// backupDescription returns the description of a manual backup made now:
+// the template of the profile, or the default one, expanded with the
+// patches installed.
+func (p *PatchManager) backupDescription() string {
+	template := p.backups.Settings().DefaultDescription
+	if template == "" {
+		template = i18n.T("backup.defaultTemplate")
+	}
+	c := backup.DescriptionContext{Time: time.Now()}
+	var last time.Time
+	for _, rec := range p.installed.Patches() {
+		c.PatchCount++
+		if rec.InstalledAt.After(last) {
+			last = rec.InstalledAt
+			c.LastPatch = recordName(rec)
+		}
+	}
+	if c.LastPatch == "" {
+		c.LastPatch = i18n.T("backup.noPatches")
+	}
+	return backup.ExpandDescription(template, c)
+}
+
+// describePatches names the patches an operation changes for the
+// description of the backup taken before it.
+func describePatches(names []string) string {
+	if len(names) <= maxDescribedPatches {
+		return strings.Join(names, i18n.T("common.listSeparator"))
+	}
+	return strings.Join(names[:maxDescribedPatches], i18n.T("common.listSeparator")) +
+		i18n.T("common.andMore", len(names)-maxDescribedPatches)
+}
+
+// recordName returns the name of an installed patch, its file name for one
+// not from the catalogue.
+func recordName(rec install.Record) string {
+	if rec.PatchName != "" {
+		return rec.PatchName
+	}
+	return rec.Filename
+}
+
+// vanillaBackupDescription returns the description of the safety backup
+// taken before every patch is removed, naming the patches.
+func (p *PatchManager) vanillaBackupDescription() string {
+	var names []string
+	for _, rec := range p.installed.Patches() {
+		names = append(names, recordName(rec))
+	}
+	if len(names) == 0 {
+		return i18n.T("vanilla.backupDescription")
+	}
+	return i18n.T("vanilla.backupPatches", describePatches(names))
+}
+
+// installDescription returns the description of the backup taken before
+// installing the patches of recs, such as "Before installing Clear Skills
+// v1.2".
+func installDescription(recs []install.Record) string {
+	if len(recs) == 1 && recs[0].Version != "" {
+		return i18n.T("backup.beforeInstall", recordName(recs[0]), recs[0].Version)
+	}
+	names := make([]string, len(recs))
+	for i, rec := range recs {
+		names[i] = recordName(rec)
+		if rec.Version != "" {
+			names[i] += " v" + rec.Version
+		}
+	}
+	return i18n.T("backup.beforeInstallFile", describePatches(names))
+}
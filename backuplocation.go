@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/i18n"
+)
+
+// backupsInGame reports whether the backup path and the game folder
+// overlap. A repair of the game can delete backups kept in it; Create leaves
+// them out of new backups either way.
+func (p *PatchManager) backupsInGame() bool {
+	return p.backups.Overlaps(p.dnfPath)
+}
+
+// warnBackupsInGame warns that the backups are kept in the game folder,
+// offering to move them. Dismissing the warning calls proceed, when set, so
+// the operation that showed it can go on.
+func (p *PatchApp) warnBackupsInGame(proceed func()) {
+	slog.Warn("backup path overlaps the game folder", "backups", p.backups.Dir(""), "game", p.dnfPath)
+	dismiss := i18n.T("common.close")
+	if proceed != nil {
+		dismiss = i18n.T("backup.inGameProceed")
+	}
+	message := container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), nil,
+		wrappedLabel(i18n.T("backup.inGame", p.backups.Dir(""), p.dnfPath)))
+	d := dialog.NewCustomConfirm(i18n.T("backup.inGameTitle"), i18n.T("backup.relocate"), dismiss, message, func(relocate bool) {
+		if relocate {
+			p.relocateBackups()
+		} else if proceed != nil {
+			proceed()
+		}
+	}, p.window)
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
+
+// relocateBackups moves the backups out of the game folder. When the data of
+// a portable install is kept there, the data moves to the user config
+// directory; otherwise the user picks a folder for the backups.
+func (p *PatchApp) relocateBackups() {
+	if p.portable() && fsutil.Within(p.dataDir, p.dnfPath) {
+		p.confirmSwitchDataMode(false)
+		return
+	}
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if uri == nil {
+			return
+		}
+		path := uri.Path()
+		if fsutil.Within(path, p.dnfPath) || fsutil.Within(p.dnfPath, path) {
+			p.showError(errors.New(i18n.T("backup.relocateInGame", path)))
+			return
+		}
+		go func() {
+			defer p.recoverPanic(i18n.T("op.moveBackups"))
+			_, end := p.operations.begin(i18n.T("op.moveBackups"))
+			defer end()
+			p.updateStatus(i18n.T("backup.relocating", path))
+			if err := p.backups.Move(path); err != nil {
+				slog.Error("moving backups failed", "to", path, "err", err)
+				p.showError(err)
+				p.updateStatus(i18n.T("backup.relocateFailed"))
+				return
+			}
+			slog.Info("moved backups", "to", path)
+			p.updateStatus(i18n.T("backup.relocated", path))
+			p.refreshBackupUsage()
+			p.runHealthCheck(false)
+		}()
+	}, p.window)
+}
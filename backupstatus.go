@@ -0,0 +1,154 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// maxAutoFailures is how many auto backups failing in a row bring up the
+// banner warning about them.
+const maxAutoFailures = 3
+
+// createAutoBackupStatus returns the line at the top of the Backups tab
+// telling whether auto backup is on, when it runs next and how the last one
+// went, with a button backing up at once.
+func (p *PatchApp) createAutoBackupStatus() fyne.CanvasObject {
+	p.autoBackupStatus = widget.NewLabel("")
+	p.autoBackupStatus.Truncation = fyne.TextTruncateEllipsis
+	now := widget.NewButtonWithIcon(i18n.T("backup.now"), theme.MediaPlayIcon(), p.backUpNow)
+	now.Importance = widget.LowImportance
+	now.Disable()
+	p.pathActions = append(p.pathActions, now)
+	p.refreshAutoBackupStatus()
+	return container.NewBorder(nil, nil, nil, now, p.autoBackupStatus)
+}
+
+// backUpNow runs the auto backup at once, outside its schedule, or asks for
+// a manual backup when auto backup is off.
+func (p *PatchApp) backUpNow() {
+	if p.backupNow == nil {
+		p.showCreateBackup()
+		return
+	}
+	select {
+	case p.backupNow <- struct{}{}:
+	default:
+		// Asked already
+	}
+}
+
+// refreshAutoBackupStatus shows the state of auto backup in the Backups
+// tab, such as "Auto backup: on · next in 42 minutes · last succeeded
+// 09:12", in the warning colour when the last one failed.
+func (p *PatchApp) refreshAutoBackupStatus() {
+	if p.autoBackupStatus == nil {
+		return
+	}
+	p.autoBackupStatus.Importance = widget.MediumImportance
+	switch {
+	case p.backupsPaused:
+		p.autoBackupStatus.SetText(i18n.T("backup.statusPaused"))
+		return
+	case p.nextBackup.IsZero():
+		p.autoBackupStatus.SetText(i18n.T("backup.statusOff"))
+		return
+	}
+
+	status := i18n.T("backup.statusOn")
+	switch wait := time.Until(p.nextBackup); {
+	case wait < time.Minute:
+		status += " · " + i18n.T("backup.statusDue")
+	case wait < time.Hour:
+		status += " · " + i18n.T("backup.statusNext", intervalName(int(wait.Round(time.Minute)/time.Second)))
+	default:
+		status += " · " + i18n.T("backup.statusNext", intervalName(int(wait.Round(time.Hour)/time.Second)))
+	}
+	last := p.lastAutoBackup()
+	if !p.lastAutoFailure.IsZero() && p.lastAutoFailure.After(last) {
+		p.autoBackupStatus.Importance = widget.WarningImportance
+		status += " · " + i18n.T("backup.statusFailed", p.formatTime(p.lastAutoFailure), p.lastAutoErr)
+	} else if !last.IsZero() {
+		status += " · " + i18n.T("backup.statusSucceeded", p.formatTime(last))
+	}
+	p.autoBackupStatus.SetText(status)
+}
+
+// lastAutoBackup returns when the newest auto backup of the profile was
+// made, zero when there is none.
+func (p *PatchManager) lastAutoBackup() time.Time {
+	var last time.Time
+	for _, b := range p.backups.Backups() {
+		if b.Type == "auto" && b.Timestamp.After(last) {
+			last = b.Timestamp
+		}
+	}
+	return last
+}
+
+// autoBackupFailed records why an auto backup failed for the status line
+// and counts it in the title of the Backups tab until the tab is opened.
+// Once maxAutoFailures have failed in a row, a banner warns about them.
+func (p *PatchApp) autoBackupFailed(err error) {
+	p.lastAutoFailure, p.lastAutoErr = time.Now(), err
+	p.refreshAutoBackupStatus()
+	p.autoFailures++
+	if p.autoFailures >= maxAutoFailures {
+		p.showBackupFailureBanner(err)
+	}
+	if p.tabs.Selected() != p.backupTab {
+		p.backupFailures++
+		p.backupTab.Text = i18n.T("tab.backups") + i18n.T("backup.failureBadge", p.backupFailures)
+		p.tabs.Refresh()
+	}
+}
+
+// clearBackupFailures removes the count of failed auto backups from the
+// title of the Backups tab once it is opened.
+func (p *PatchApp) clearBackupFailures() {
+	if p.backupFailures == 0 {
+		return
+	}
+	p.backupFailures = 0
+	p.backupTab.Text = i18n.T("tab.backups")
+	p.tabs.Refresh()
+}
+
+// backupSucceeded ends the run of failed auto backups after a successful
+// backup, automatic or not, taking down the banner warning about them.
+func (p *PatchApp) backupSucceeded() {
+	p.autoFailures = 0
+	p.failureBanner.Hide()
+	p.refreshAutoBackupStatus()
+}
+
+// createBackupFailureBanner returns the banner warning that auto backups
+// keep failing, hidden until they do.
+func (p *PatchApp) createBackupFailureBanner() *fyne.Container {
+	p.failureBanner = container.NewVBox()
+	p.failureBanner.Hide()
+	return p.failureBanner
+}
+
+// showBackupFailureBanner warns that the last p.autoFailures auto backups
+// failed, the last with err. The banner stays until a backup succeeds or it
+// is dismissed, which starts the count again.
+func (p *PatchApp) showBackupFailureBanner(err error) {
+	label := wrappedLabel(i18n.T("backup.failingBanner", p.autoFailures, err))
+	label.Importance = widget.DangerImportance
+	now := widget.NewButtonWithIcon(i18n.T("backup.now"), theme.MediaPlayIcon(), p.backUpNow)
+	dismiss := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+		p.autoFailures = 0
+		p.failureBanner.Hide()
+	})
+	p.failureBanner.Objects = []fyne.CanvasObject{
+		container.NewBorder(nil, nil, widget.NewIcon(theme.ErrorIcon()), container.NewHBox(now, dismiss), label),
+	}
+	p.failureBanner.Refresh()
+	p.failureBanner.Show()
+}
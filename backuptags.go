@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/notes"
+)
+
+// maxTagChips is how many tags a row of the backup list shows; the rest
+// are counted.
+const maxTagChips = 3
+
+// shownBackups returns the backups of the active profile matching the
+// backup filter, newest first.
+func (p *PatchApp) shownBackups() []backup.Backup {
+	all := p.backups.Backups()
+	var query string
+	if p.backupFilter != nil {
+		query = p.backupFilter.Text
+	}
+	shown := make([]backup.Backup, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].Matches(query) {
+			shown = append(shown, all[i])
+		}
+	}
+	return shown
+}
+
+// backupTags returns the tags given to any backup of the active profile,
+// sorted.
+func (p *PatchApp) backupTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, b := range p.backups.Backups() {
+		for _, tag := range b.Tags {
+			if !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// setTagChips fills box with a badge for each of tags.
+func setTagChips(box *fyne.Container, tags []string) {
+	objects := make([]fyne.CanvasObject, 0, maxTagChips+1)
+	for i, tag := range tags {
+		chip := newCountBadge()
+		if i == maxTagChips {
+			setCountBadge(chip, i18n.T("backup.moreTags", len(tags)-maxTagChips))
+			objects = append(objects, chip)
+			break
+		}
+		setCountBadge(chip, tag)
+		objects = append(objects, chip)
+	}
+	box.Objects = objects
+	box.Refresh()
+}
+
+// editBackupDetails lets the user change the description and tags of b and
+// passes the saved backup to onSaved.
+func (p *PatchApp) editBackupDetails(b backup.Backup, onSaved func(backup.Backup)) {
+	descriptionEntry := widget.NewEntry()
+	descriptionEntry.SetText(b.Description)
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetText(strings.Join(b.Tags, ", "))
+	tagsEntry.SetPlaceHolder(i18n.T("backup.tagsHint"))
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T("backup.description"), descriptionEntry),
+		widget.NewFormItem(i18n.T("backup.tagsLabel"), tagsEntry),
+	}
+	if tags := p.backupTags(); len(tags) > 0 {
+		items[1].HintText = i18n.T("backup.tagsInUse", strings.Join(tags, ", "))
+	}
+
+	d := dialog.NewForm(i18n.T("backup.editTitle"), i18n.T("common.save"), i18n.T("common.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		description := strings.TrimSpace(descriptionEntry.Text)
+		if description == "" {
+			description = b.Description
+		}
+		if err := p.backups.SetDetails(b.ID, description, notes.ParseTags(tagsEntry.Text)); err != nil {
+			slog.Error("saving backup details failed", "backup", b.ID, "err", err)
+			p.showError(err)
+			return
+		}
+		p.backupList.Refresh()
+		if saved, ok := p.backups.Find(b.ID); ok {
+			onSaved(saved)
+		}
+	}, p.window)
+	d.Resize(fyne.NewSize(450, 0))
+	d.Show()
+}
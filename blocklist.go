@@ -0,0 +1,187 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/patchdb"
+)
+
+// blocklistPath is where the user's own blocklist entries are kept.
+func (p *PatchManager) blocklistPath() string {
+	return filepath.Join(p.dataDir, "blocklist.json")
+}
+
+// loadLocalBlocklist reads the user's own blocklist entries.
+func (p *PatchManager) loadLocalBlocklist() {
+	b, err := patchdb.LoadBlocklist(p.blocklistPath())
+	if err != nil {
+		slog.Error("loading blocklist failed", "path", p.blocklistPath(), "err", err)
+	}
+	p.localBlocks = b
+}
+
+// blocked returns the entry of the repository's or the user's blocklist for
+// the patch with the given ID or file hash.
+func (p *PatchManager) blocked(patchID, hash string) (patchdb.BlockEntry, bool) {
+	if e, ok := p.patches.Blocklist.Find(patchID, hash); ok {
+		return e, true
+	}
+	return p.localBlocks.Find(patchID, hash)
+}
+
+// blockedInstalled returns the installed patches that are blocked.
+func (p *PatchManager) blockedInstalled() []install.Record {
+	var records []install.Record
+	for _, rec := range p.installed.Patches() {
+		if _, ok := p.blocked(rec.PatchID, rec.Hash); ok {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// createBlockedWarning returns the warning shown in the details of a blocked
+// patch, or nil when patch is not blocked.
+func (p *PatchApp) createBlockedWarning(patch patchdb.Patch) fyne.CanvasObject {
+	e, ok := p.blocked(patch.ID, patch.Sha256)
+	if !ok {
+		return nil
+	}
+	label := wrappedLabel(i18n.T("blocklist.warning", e.Reason))
+	label.Importance = widget.DangerImportance
+	return container.NewBorder(nil, nil, widget.NewIcon(theme.ErrorIcon()), nil, label)
+}
+
+// confirmBlocked asks once more before installing a blocked patch and calls
+// install if the user insists. Patches that are not blocked are installed
+// right away.
+func (p *PatchApp) confirmBlocked(patch patchdb.Patch, install func()) {
+	e, ok := p.blocked(patch.ID, patch.Sha256)
+	if !ok {
+		install()
+		return
+	}
+	slog.Warn("installing a blocked patch was requested", "patch", patch.ID, "reason", e.Reason)
+	d := dialog.NewConfirm(i18n.T("blocklist.title"), i18n.T("blocklist.confirmInstall", patch.Name, e.Reason), func(ok bool) {
+		if ok {
+			slog.Warn("installing a blocked patch anyway", "patch", patch.ID)
+			install()
+		}
+	}, p.window)
+	d.SetConfirmText(i18n.T("blocklist.installAnyway"))
+	d.SetDismissText(i18n.T("common.cancel"))
+	d.Show()
+}
+
+// createBlockButton returns the button adding patch to the user's own
+// blocklist, or removing it again. onChanged is called after either.
+func (p *PatchApp) createBlockButton(patch patchdb.Patch, onChanged func()) *widget.Button {
+	button := widget.NewButtonWithIcon("", theme.ErrorIcon(), nil)
+	button.Importance = widget.LowImportance
+	update := func() {
+		if _, ok := p.localBlocks.Find(patch.ID, ""); ok {
+			button.SetText(i18n.T("blocklist.remove"))
+		} else {
+			button.SetText(i18n.T("blocklist.add"))
+		}
+	}
+	button.OnTapped = func() {
+		if _, ok := p.localBlocks.Find(patch.ID, ""); ok {
+			p.saveLocalBlocklist(p.localBlocks.Without(patch.ID))
+			update()
+			onChanged()
+			return
+		}
+		reason := widget.NewEntry()
+		reason.SetPlaceHolder(i18n.T("blocklist.reasonHint"))
+		dialog.ShowForm(i18n.T("blocklist.addTitle", patch.Name), i18n.T("blocklist.add"), i18n.T("common.cancel"),
+			[]*widget.FormItem{widget.NewFormItem(i18n.T("blocklist.reason"), reason)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				text := strings.TrimSpace(reason.Text)
+				if text == "" {
+					text = i18n.T("blocklist.noReason")
+				}
+				p.saveLocalBlocklist(append(p.localBlocks, patchdb.BlockEntry{PatchID: patch.ID, Reason: text}))
+				update()
+				onChanged()
+			}, p.window)
+	}
+	update()
+	return button
+}
+
+// saveLocalBlocklist replaces the user's own blocklist and shows the
+// installed patches it now blocks.
+func (p *PatchApp) saveLocalBlocklist(b patchdb.Blocklist) {
+	if err := b.Save(p.blocklistPath()); err != nil {
+		slog.Error("saving blocklist failed", "path", p.blocklistPath(), "err", err)
+		p.showError(err)
+		return
+	}
+	p.localBlocks = b
+	p.refreshBlockedBanner()
+}
+
+// createBlockedBanner returns the banner listing installed patches that are
+// blocked, hidden until there are any.
+func (p *PatchApp) createBlockedBanner() *fyne.Container {
+	p.blockedBanner = container.NewVBox()
+	p.blockedBanner.Hide()
+	return p.blockedBanner
+}
+
+// refreshBlockedBanner lists the blocked installed patches, each with a
+// button to uninstall it.
+func (p *PatchApp) refreshBlockedBanner() {
+	records := p.blockedInstalled()
+	if len(records) == 0 {
+		p.blockedBanner.Hide()
+		return
+	}
+	slog.Warn("blocked patches are installed", "count", len(records))
+	rows := make([]fyne.CanvasObject, 0, len(records))
+	for _, rec := range records {
+		rec := rec
+		e, _ := p.blocked(rec.PatchID, rec.Hash)
+		label := wrappedLabel(i18n.T("blocklist.installed", rec.PatchName, e.Reason))
+		label.Importance = widget.DangerImportance
+		uninstall := widget.NewButtonWithIcon(i18n.T("blocklist.uninstall"), theme.DeleteIcon(), nil)
+		uninstall.OnTapped = func() {
+			uninstall.Disable()
+			go p.uninstallBlocked(rec)
+		}
+		rows = append(rows, container.NewBorder(nil, nil, widget.NewIcon(theme.ErrorIcon()), uninstall, label))
+	}
+	p.blockedBanner.Objects = rows
+	p.blockedBanner.Refresh()
+	p.blockedBanner.Show()
+}
+
+// uninstallBlocked removes a blocked patch from the game in the background.
+func (p *PatchApp) uninstallBlocked(rec install.Record) {
+	defer p.recoverPanic(i18n.T("op.uninstall"))
+	_, end := p.operations.begin(i18n.T("op.uninstall"))
+	defer end()
+	err := p.uninstallPatch(rec)
+	p.historyList.Refresh()
+	p.refreshBlockedBanner()
+	if err != nil {
+		slog.Error("uninstalling blocked patch failed", "patch", rec.PatchID, "file", rec.Filename, "err", err)
+		p.showError(err)
+		return
+	}
+	p.updateStatus(i18n.T("blocklist.uninstalled", rec.PatchName))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/cachedir"
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// cacheLimitOptions are the sizes in MB the cache can be trimmed to.
+var cacheLimitOptions = []int{256, 512, 1024, 2048, 5120}
+
+// defaultCacheLimit is the size in MB the cache is trimmed to unless
+// configured.
+const defaultCacheLimit = 1024
+
+// cacheKept reports whether path in the cache is left alone when the cache is
+// cleared: the copies of installed patches they are reapplied from, the last
+// fetched catalogue, files still being written, and backups or the game
+// folder should either be in the cache folder.
+func (p *PatchManager) cacheKept(path string) bool {
+	switch filepath.Base(path) {
+	case "patches.json", "patches.json.sig":
+		return filepath.Dir(path) == p.cacheDir()
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".part" || ext == ".tmp" {
+		return true
+	}
+	if path == p.patchCache().Dir || path == p.backups.Dir("") {
+		return true
+	}
+	return p.dnfPath != "" && path == p.dnfPath
+}
+
+// cacheFiles returns the files in the cache that clearing it may delete.
+func (p *PatchManager) cacheFiles() ([]cachedir.File, error) {
+	return cachedir.List(p.cacheDir(), p.cacheKept)
+}
+
+// touchCached records that the file at path was used, so trimming the cache
+// deletes it after the files used longer ago. Files outside the cache are
+// left alone.
+func (p *PatchManager) touchCached(path string) {
+	if !fsutil.Within(path, p.cacheDir()) {
+		return
+	}
+	if err := cachedir.Touch(path); err != nil {
+		slog.Warn("recording cache use failed", "path", path, "err", err)
+	}
+}
+
+// createDownloadedLabel creates the label telling that the file of patch is
+// downloaded already. It stays hidden until the file has been checked in
+// the background, and when there is none or it does not match the catalogue.
+func (p *PatchApp) createDownloadedLabel(patch patchdb.Patch) fyne.CanvasObject {
+	label := widget.NewLabel("")
+	label.Hide()
+	if patch.Filename == "" {
+		return label
+	}
+	path := filepath.Join(p.downloadDir(), patch.Filename)
+	go func() {
+		defer p.recoverPanic(i18n.T("op.checkDownload"))
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		if err := patchdb.CheckFile(patch, path); err != nil {
+			slog.Info("cached download does not match the catalogue", "patch", patch.ID, "path", path, "err", err)
+			return
+		}
+		label.SetText(i18n.T("patch.downloaded", formatSize(info.Size())))
+		label.Show()
+	}()
+	return label
+}
+
+// createCacheUI creates the cache settings: how much space the cache takes
+// and buttons to clear it or trim it to a size, deleting the files used
+// longest ago first.
+func (p *PatchApp) createCacheUI() fyne.CanvasObject {
+	usage := widget.NewLabel(i18n.T("cache.measuring"))
+	refresh := func() {
+		go func() {
+			defer p.recoverPanic(i18n.T("op.cache"))
+			files, err := p.cacheFiles()
+			if err != nil {
+				slog.Error("measuring cache failed", "path", p.cacheDir(), "err", err)
+				usage.SetText(i18n.T("cache.measureFailed", err))
+				return
+			}
+			usage.SetText(i18n.T("cache.usage", formatSize(cachedir.Total(files)), len(files)))
+		}()
+	}
+	refresh()
+
+	limits := make([]string, len(cacheLimitOptions))
+	for i, mb := range cacheLimitOptions {
+		limits[i] = formatSize(int64(mb) << 20)
+	}
+	limitSelect := widget.NewSelect(limits, nil)
+	for i, mb := range cacheLimitOptions {
+		if mb == p.config.CacheLimit {
+			limitSelect.SetSelectedIndex(i)
+		}
+	}
+	limitSelect.OnChanged = func(string) {
+		if i := limitSelect.SelectedIndex(); i >= 0 {
+			p.updateConfig(func(c *AppConfig) { c.CacheLimit = cacheLimitOptions[i] })
+		}
+	}
+
+	clearButton := widget.NewButtonWithIcon(i18n.T("cache.clear"), theme.DeleteIcon(), func() {
+		p.trimCache(0, i18n.T("cache.clearConfirm"), refresh)
+	})
+	trimButton := widget.NewButtonWithIcon(i18n.T("cache.trim"), theme.ContentCutIcon(), func() {
+		limit := int64(p.config.CacheLimit) << 20
+		p.trimCache(limit, i18n.T("cache.trimConfirm", formatSize(limit)), refresh)
+	})
+	return container.NewVBox(
+		usage,
+		container.NewHBox(clearButton, trimButton, limitSelect),
+	)
+}
+
+// trimCache deletes the files in the cache used longest ago until the rest
+// take at most limit bytes, once message is confirmed, then calls done. A
+// limit of 0 clears the cache.
+func (p *PatchApp) trimCache(limit int64, message string, done func()) {
+	dialog.ShowConfirm(i18n.T("cache.title"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		go func() {
+			defer p.recoverPanic(i18n.T("op.cache"))
+			defer done()
+			files, err := p.cacheFiles()
+			if err != nil {
+				slog.Error("listing cache failed", "path", p.cacheDir(), "err", err)
+				p.showError(err)
+				return
+			}
+			removed, err := cachedir.Trim(files, limit)
+			slog.Info("trimmed cache", "path", p.cacheDir(), "limit", limit, "removed", len(removed))
+			p.updateStatus(i18n.T("cache.trimmed", len(removed), formatSize(cachedir.Total(removed))))
+			if err != nil {
+				slog.Error("deleting cached files failed", "err", err)
+				p.showError(err)
+			}
+		}()
+	}, p.window)
+}
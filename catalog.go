@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// A fetch of the catalogue that fails at startup is retried catalogRetries
+// times, waiting catalogRetryDelay before the first retry and twice as long
+// before each one after it.
+const (
+	catalogRetries    = 4
+	catalogRetryDelay = 30 * time.Second
+)
+
+// staleOptions are the days after which the catalogue can be shown as out of
+// date.
+var staleOptions = []int{1, 3, 7, 14, 30}
+
+// defaultStaleDays is the age in days from which the catalogue is shown as
+// out of date unless configured.
+const defaultStaleDays = 7
+
+// createCatalogStatusUI creates the line at the top of the Patches tab
+// telling how old the catalogue is, with a button to fetch it again. It is
+// hidden while no repository is configured.
+func (p *PatchApp) createCatalogStatusUI() fyne.CanvasObject {
+	p.catalogStatus = widget.NewLabel("")
+	refresh := widget.NewButtonWithIcon(i18n.T("catalog.refresh"), theme.ViewRefreshIcon(), p.refreshDatabase)
+	refresh.Importance = widget.LowImportance
+	p.catalogBar = container.NewHBox(p.catalogStatus, refresh)
+	p.catalogBar.Hide()
+	return p.catalogBar
+}
+
+// refreshCatalogStatus shows when the catalogue was fetched and whether the
+// repository could not be reached, in the warning colour when the bundled
+// catalogue is shown or the cached one is older than configured.
+func (p *PatchApp) refreshCatalogStatus() {
+	if p.catalogBar == nil {
+		return
+	}
+	if p.config.RepositoryURL == "" {
+		p.catalogBar.Hide()
+		return
+	}
+	var text string
+	stale := true
+	if p.catalogFetched.IsZero() {
+		text = i18n.T("catalog.bundled")
+	} else {
+		text = i18n.T("catalog.updated", i18n.FormatRelative(p.catalogFetched, time.Now()))
+		stale = time.Since(p.catalogFetched) > time.Duration(p.config.StaleDays)*24*time.Hour
+		if p.catalogFetchErr != nil {
+			text = i18n.T("catalog.unreachable", text)
+		}
+	}
+	if stale {
+		p.catalogStatus.Importance = widget.WarningImportance
+	} else {
+		p.catalogStatus.Importance = widget.MediumImportance
+	}
+	p.catalogStatus.SetText(text)
+	p.catalogBar.Show()
+}
+
+// retryCatalog fetches the catalogue again in the background after the fetch
+// at startup failed, waiting longer before each attempt. It stops once a
+// fetch succeeds, including one the user started.
+func (p *PatchApp) retryCatalog() {
+	go func() {
+		defer p.recoverPanic(i18n.T("op.refreshDatabase"))
+		delay := catalogRetryDelay
+		for i := 1; i <= catalogRetries; i++ {
+			time.Sleep(delay)
+			delay *= 2
+			if p.catalogFetchErr == nil {
+				return
+			}
+			slog.Info("retrying repository fetch", "url", p.config.RepositoryURL, "attempt", i)
+			if p.reloadDatabase() && p.catalogFetchErr == nil {
+				return
+			}
+		}
+		slog.Warn("giving up fetching the repository", "url", p.config.RepositoryURL, "attempts", catalogRetries)
+	}()
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"image/color"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// layoutPath is where the user's arrangement of the categories is kept. It
+// applies to whichever catalogue is loaded, so it is not in the cache.
+func (p *PatchManager) layoutPath() string {
+	return filepath.Join(p.dataDir, "categories.json")
+}
+
+// loadLayout reads the user's arrangement of the categories. One that cannot
+// be read is not used, and not saved over either.
+func (p *PatchManager) loadLayout() {
+	l, err := patchdb.LoadLayout(p.layoutPath())
+	if err != nil {
+		slog.Error("loading category layout failed", "path", p.layoutPath(), "err", err)
+	}
+	p.layout, p.layoutErr = l, err
+}
+
+// badgeBackground is the background of the patch counts of the categories.
+var badgeBackground = color.NRGBA{R: secondaryColor.R, G: secondaryColor.G, B: secondaryColor.B, A: 60}
+
+// createCategoriesUI creates the list of categories, which opens the one
+// clicked and has buttons to reorder, rename and delete them. Each shows its
+// icon and how many of its patches there are and are installed, which is
+// kept up to date as patches are installed and uninstalled.
+func (p *PatchApp) createCategoriesUI() fyne.CanvasObject {
+	p.categoryList = widget.NewList(
+		func() int {
+			return len(p.patches.Categories)
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("Template")
+			label.Truncation = fyne.TextTruncateEllipsis
+			buttons := container.NewHBox(
+				newCountBadge(),
+				widget.NewButtonWithIcon("", theme.MoveUpIcon(), nil),
+				widget.NewButtonWithIcon("", theme.MoveDownIcon(), nil),
+				widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil),
+				widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
+			)
+			return container.NewBorder(nil, nil, widget.NewIcon(theme.DocumentIcon()), buttons, label)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			category := p.patches.Categories[id]
+			box := item.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(category.Name)
+			box.Objects[1].(*widget.Icon).SetResource(p.categoryIcon(category))
+			buttons := box.Objects[2].(*fyne.Container).Objects
+			setCountBadge(buttons[0].(*fyne.Container), categoryCount(category, p.installedIDs()))
+			up, down := buttons[1].(*widget.Button), buttons[2].(*widget.Button)
+			up.OnTapped = func() { p.reorderCategory(id, id-1) }
+			down.OnTapped = func() { p.reorderCategory(id, id+1) }
+			setEnabled(up, id > 0)
+			setEnabled(down, id < len(p.patches.Categories)-1)
+			buttons[3].(*widget.Button).OnTapped = func() { p.renameCategory(category.Name) }
+			buttons[4].(*widget.Button).OnTapped = func() { p.deleteCategory(category) }
+		},
+	)
+	p.categoryList.OnSelected = func(id widget.ListItemID) {
+		p.categoryList.Unselect(id)
+		p.showCategory(p.patches.Categories[id].Name)
+	}
+	p.installedChanged = func() {
+		p.categoryList.Refresh()
+		p.refreshDetails()
+	}
+
+	newButton := widget.NewButtonWithIcon(i18n.T("category.new"), theme.ContentAddIcon(), p.createCategory)
+	return container.NewBorder(container.NewHBox(newButton), nil, nil, nil, p.categoryList)
+}
+
+// newCountBadge creates the rounded label showing the patch counts of a
+// category, set with setCountBadge.
+func newCountBadge() *fyne.Container {
+	background := canvas.NewRectangle(badgeBackground)
+	background.CornerRadius = theme.InputRadiusSize()
+	text := canvas.NewText("", theme.ForegroundColor())
+	text.TextSize = theme.CaptionTextSize()
+	return container.NewMax(background, container.NewCenter(container.NewPadded(text)))
+}
+
+// setCountBadge shows text on a badge made by newCountBadge.
+func setCountBadge(badge *fyne.Container, text string) {
+	label := badge.Objects[1].(*fyne.Container).Objects[0].(*fyne.Container).Objects[0].(*canvas.Text)
+	label.Text = text
+	label.Color = theme.ForegroundColor()
+	label.Refresh()
+}
+
+// categoryCount describes how many patches category has and how many of
+// them have their ID in installed.
+func categoryCount(category patchdb.Category, installed map[string]bool) string {
+	n := 0
+	for _, patch := range category.Patches {
+		if installed[patch.ID] {
+			n++
+		}
+	}
+	if n == 0 {
+		return formatCount(len(category.Patches))
+	}
+	return i18n.T("category.countInstalled", formatCount(len(category.Patches)), formatCount(n))
+}
+
+// installedIDs returns the IDs of the patches installed in the active
+// profile.
+func (p *PatchManager) installedIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, rec := range p.installed.Patches() {
+		ids[rec.PatchID] = true
+	}
+	return ids
+}
+
+// categoryIcon returns the icon of category: the theme icon it names, or the
+// image at its URL or path, which is loaded in the background the first time
+// and shown once it is there. The default icon stands in until then, and for
+// categories without an icon or whose icon cannot be loaded.
+func (p *PatchApp) categoryIcon(category patchdb.Category) fyne.Resource {
+	if category.Icon == "" {
+		return theme.DocumentIcon()
+	}
+	if icon := theme.DefaultTheme().Icon(fyne.ThemeIconName(category.Icon)); icon != nil {
+		return icon
+	}
+	if icon := p.cachedImage(category.Icon, "icons", p.categoryList.Refresh); icon != nil {
+		return icon
+	}
+	return theme.DocumentIcon()
+}
+
+// setEnabled enables or disables b.
+func setEnabled(b *widget.Button, enabled bool) {
+	if enabled {
+		b.Enable()
+	} else {
+		b.Disable()
+	}
+}
+
+// showCategory shows the patches of the named category in place of the
+// categories, or the categories when there is no such category any more.
+func (p *PatchApp) showCategory(name string) {
+	i := 0
+	for i < len(p.patches.Categories) && p.patches.Categories[i].Name != name {
+		i++
+	}
+	if i == len(p.patches.Categories) {
+		p.showCategories()
+		return
+	}
+	category := p.patches.Categories[i]
+	p.openCategory = name
+
+	back := widget.NewButtonWithIcon(i18n.T("category.all"), theme.NavigateBackIcon(), p.showCategories)
+	header := container.NewBorder(nil, nil, back, nil,
+		widget.NewLabelWithStyle(category.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	var patches fyne.CanvasObject = createPatchList(category.Patches, p.patchClass, p.showPatchDetails)
+	if len(category.Patches) == 0 {
+		patches = container.NewCenter(widget.NewLabel(i18n.T("category.empty", category.Name)))
+	}
+	p.patchesView.Objects = []fyne.CanvasObject{container.NewBorder(header, nil, nil, nil, patches)}
+	p.patchesView.Refresh()
+}
+
+// showCategories shows the list of categories again.
+func (p *PatchApp) showCategories() {
+	p.openCategory = ""
+	p.patchesView.Objects = []fyne.CanvasObject{p.categoriesView}
+	p.patchesView.Refresh()
+}
+
+// editCategories applies edit to the layout and the catalogue, saves the
+// layout and shows the result. Nothing is changed when the layout could not
+// be read, as saving it would drop what it holds.
+func (p *PatchApp) editCategories(edit func(l *patchdb.Layout, db *patchdb.Database) error) bool {
+	if p.layoutErr != nil {
+		p.showError(p.layoutErr)
+		return false
+	}
+	if err := edit(&p.layout, &p.patches); err != nil {
+		p.showError(err)
+		return false
+	}
+	p.setPatches(p.patches)
+	if err := p.layout.Save(p.layoutPath()); err != nil {
+		slog.Error("saving category layout failed", "path", p.layoutPath(), "err", err)
+		p.showError(err)
+	}
+	p.categoryList.Refresh()
+	if p.openCategory != "" {
+		p.showCategory(p.openCategory)
+	}
+	return true
+}
+
+// reorderCategory moves the category at index from to index to.
+func (p *PatchApp) reorderCategory(from, to int) {
+	p.editCategories(func(l *patchdb.Layout, db *patchdb.Database) error {
+		return l.Reorder(db, from, to)
+	})
+}
+
+// createCategory asks for the name of a new, empty category and adds it
+// after the others.
+func (p *PatchApp) createCategory() {
+	name := widget.NewEntry()
+	dialog.ShowForm(i18n.T("category.new"), i18n.T("common.save"), i18n.T("common.cancel"),
+		[]*widget.FormItem{widget.NewFormItem(i18n.T("common.name"), name)},
+		func(ok bool) {
+			if ok {
+				p.editCategories(func(l *patchdb.Layout, db *patchdb.Database) error {
+					return l.Create(db, name.Text)
+				})
+			}
+		}, p.window)
+}
+
+// renameCategory asks for a new name for the named category.
+func (p *PatchApp) renameCategory(from string) {
+	name := widget.NewEntry()
+	name.SetText(from)
+	dialog.ShowForm(i18n.T("category.rename", from), i18n.T("common.save"), i18n.T("common.cancel"),
+		[]*widget.FormItem{widget.NewFormItem(i18n.T("common.name"), name)},
+		func(ok bool) {
+			to := strings.TrimSpace(name.Text)
+			if !ok || to == from {
+				return
+			}
+			open := p.openCategory == from
+			renamed := p.editCategories(func(l *patchdb.Layout, db *patchdb.Database) error {
+				return l.Rename(db, from, to)
+			})
+			if renamed && open {
+				p.showCategory(to)
+			}
+		}, p.window)
+}
+
+// deleteCategory deletes category once confirmed, asking which category its
+// patches go to when it has any.
+func (p *PatchApp) deleteCategory(category patchdb.Category) {
+	remove := func(target string) {
+		p.editCategories(func(l *patchdb.Layout, db *patchdb.Database) error {
+			return l.Delete(db, category.Name, target)
+		})
+	}
+	if len(category.Patches) == 0 {
+		dialog.ShowConfirm(i18n.T("category.delete", category.Name), i18n.T("category.deleteEmpty", category.Name),
+			func(ok bool) {
+				if ok {
+					remove("")
+				}
+			}, p.window)
+		return
+	}
+
+	var others []string
+	for _, name := range p.patches.CategoryNames() {
+		if name != category.Name {
+			others = append(others, name)
+		}
+	}
+	if len(others) == 0 {
+		// There must be somewhere for the patches to go
+		p.createCategory()
+		return
+	}
+	target := widget.NewSelect(others, nil)
+	target.SetSelectedIndex(0)
+	d := dialog.NewCustomConfirm(i18n.T("category.delete", category.Name), i18n.T("common.delete"), i18n.T("common.cancel"),
+		container.NewVBox(wrappedLabel(i18n.T("category.deleteMove", category.Name, len(category.Patches))), target),
+		func(ok bool) {
+			if ok {
+				remove(target.Selected)
+			}
+		}, p.window)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+}
+
+// createCategorySelect creates the select moving patch to another category.
+func (p *PatchApp) createCategorySelect(patch patchdb.Patch) fyne.CanvasObject {
+	current := p.patches.CategoryOf(patch.ID)
+	var choice *widget.Select
+	choice = widget.NewSelect(p.patches.CategoryNames(), func(name string) {
+		if name == current {
+			return
+		}
+		if !p.editCategories(func(l *patchdb.Layout, db *patchdb.Database) error {
+			return l.MovePatch(db, patch.ID, name)
+		}) {
+			choice.SetSelected(current)
+			return
+		}
+		current = name
+		p.updateStatus(i18n.T("category.moved", patch.Name, name))
+	})
+	choice.SetSelected(current)
+	return container.NewBorder(nil, nil, widget.NewLabel(i18n.T("category.label")), nil, choice)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"hash/fnv"
+	"image/color"
+	"log/slog"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/patchdb"
+)
+
+// classColors are the backgrounds of class badges, picked by the class name
+// so a class keeps its colour.
+var classColors = []color.NRGBA{
+	{R: 231, G: 76, B: 60, A: 110},
+	{R: 52, G: 152, B: 219, A: 110},
+	{R: 46, G: 204, B: 113, A: 110},
+	{R: 155, G: 89, B: 182, A: 110},
+	{R: 241, G: 196, B: 15, A: 110},
+	{R: 230, G: 126, B: 34, A: 110},
+	{R: 26, G: 188, B: 156, A: 110},
+	{R: 236, G: 64, B: 122, A: 110},
+}
+
+// derivedClass is the classes changed by the local file of a patch.
+type derivedClass struct {
+	path    string // of the file they were read from
+	classes []string
+}
+
+// patchClass returns the class shown on the badge of patch: its Class, the
+// class its local file changes, or "多职业" when that changes several. It
+// returns "" when the class is not known.
+func (p *PatchManager) patchClass(patch patchdb.Patch) string {
+	if patch.Class != "" {
+		return patch.Class
+	}
+	v, ok := p.derivedClasses.Load(patch.ID)
+	if !ok {
+		return ""
+	}
+	switch classes := v.(derivedClass).classes; len(classes) {
+	case 0:
+		return ""
+	case 1:
+		return classes[0]
+	default:
+		return i18n.T("class.multiple")
+	}
+}
+
+// deriveClasses reads which classes the local files of the patches without
+// a Class change, in the background, and shows them once done. Files read
+// before are not read again.
+func (p *PatchApp) deriveClasses() {
+	patches := p.patchIndex.Patches()
+	go func() {
+		defer p.recoverPanic(i18n.T("op.classes"))
+		table, err := p.categoryTable()
+		if err != nil {
+			slog.Warn("loading the class table failed", "err", err)
+			return
+		}
+		changed := false
+		for _, patch := range patches {
+			if patch.Class != "" {
+				continue
+			}
+			path := p.catalogSource(patch)
+			if path == "" {
+				continue
+			}
+			if v, ok := p.derivedClasses.Load(patch.ID); ok && v.(derivedClass).path == path {
+				continue
+			}
+			a, err := npk.Open(path)
+			if err != nil {
+				slog.Warn("reading patch file for its class failed", "patch", patch.ID, "path", path, "err", err)
+				continue
+			}
+			imgs := make([]string, len(a.Entries))
+			for i, e := range a.Entries {
+				imgs[i] = e.Name
+			}
+			p.derivedClasses.Store(patch.ID, derivedClass{path, table.Classes(imgs)})
+			changed = true
+		}
+		if changed {
+			p.refreshClasses()
+		}
+	}()
+}
+
+// refreshClasses shows classes that have become known in the patch lists,
+// the open details and the class filter.
+func (p *PatchApp) refreshClasses() {
+	p.updateClassFilter()
+	p.patchesView.Refresh()
+	p.refreshDetails()
+}
+
+// knownClasses returns the classes of the patches in the catalogue, sorted.
+func (p *PatchApp) knownClasses() []string {
+	seen := make(map[string]bool)
+	var classes []string
+	for _, patch := range p.patchIndex.Patches() {
+		if class := p.patchClass(patch); class != "" && !seen[class] {
+			seen[class] = true
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// createClassFilter creates the select limiting the patches shown to those
+// of a class, next to the search entry.
+func (p *PatchApp) createClassFilter() fyne.CanvasObject {
+	p.classFilter = widget.NewSelect(nil, func(string) {
+		p.updatePatchList(p.searchEntry.Text)
+	})
+	p.updateClassFilter()
+	return p.classFilter
+}
+
+// updateClassFilter offers the classes known now in the class filter,
+// keeping the one chosen.
+func (p *PatchApp) updateClassFilter() {
+	if p.classFilter == nil {
+		return
+	}
+	options := append([]string{i18n.T("class.all")}, p.knownClasses()...)
+	selected := p.classFilter.Selected
+	p.classFilter.Options = options
+	if selected == "" {
+		selected = options[0]
+	}
+	p.classFilter.Selected = selected
+	p.classFilter.Refresh()
+}
+
+// selectedClass returns the class the patches shown are limited to, "" for
+// all of them.
+func (p *PatchApp) selectedClass() string {
+	if p.classFilter == nil || p.classFilter.Selected == i18n.T("class.all") {
+		return ""
+	}
+	return p.classFilter.Selected
+}
+
+// createClassUI shows the class of patch in its details. It returns the
+// row with a function bringing it up to date.
+func (p *PatchApp) createClassUI(patch patchdb.Patch) (fyne.CanvasObject, func()) {
+	badge := newClassBadge()
+	row := container.NewHBox(widget.NewLabel(i18n.T("patch.class")), badge)
+	update := func() {
+		setClassBadge(badge, p.patchClass(patch))
+		row.Hidden = badge.Hidden
+		row.Refresh()
+	}
+	update()
+	return row, update
+}
+
+// newClassBadge creates the coloured label showing the class of a patch,
+// set with setClassBadge.
+func newClassBadge() *fyne.Container {
+	background := canvas.NewRectangle(color.Transparent)
+	background.CornerRadius = theme.InputRadiusSize()
+	text := canvas.NewText("", theme.ForegroundColor())
+	text.TextSize = theme.CaptionTextSize()
+	return container.NewMax(background, container.NewCenter(container.NewPadded(text)))
+}
+
+// setClassBadge shows class on a badge made by newClassBadge, hiding it
+// when class is "".
+func setClassBadge(badge *fyne.Container, class string) {
+	if class == "" {
+		badge.Hide()
+		return
+	}
+	background := badge.Objects[0].(*canvas.Rectangle)
+	if class == i18n.T("class.multiple") {
+		background.FillColor = badgeBackground
+	} else {
+		h := fnv.New32a()
+		h.Write([]byte(class))
+		background.FillColor = classColors[h.Sum32()%uint32(len(classColors))]
+	}
+	setCountBadge(badge, class)
+	badge.Show()
+	badge.Refresh()
+}
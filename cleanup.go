@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/cleanup"
+	"dnf_patch/internal/download"
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+)
+
+// cleanupKindIDs names the heading of each kind of leftover in the cleanup
+// dialog.
+var cleanupKindIDs = map[cleanup.Kind]string{
+	cleanup.LegacyBackup:     "cleanup.legacy",
+	cleanup.UnrecordedBackup: "cleanup.unrecorded",
+	cleanup.TempFile:         "cleanup.temp",
+}
+
+// findCleanup collects the legacy backup folders in the game directory, the
+// unrecorded folders in the backup path and the temporary files left in the
+// game and data folders. Files of queued downloads are not included, nor are
+// legacy folders an unfinished operation in the journal still needs.
+func (p *PatchApp) findCleanup() ([]cleanup.Item, error) {
+	var items []cleanup.Item
+	if p.pathUsable() {
+		legacy, err := cleanup.LegacyBackups(p.dnfPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range legacy {
+			pending := false
+			for _, e := range p.journal.Entries() {
+				pending = pending || (e.Saved != "" && fsutil.Within(e.Saved, item.Path))
+			}
+			if !pending {
+				items = append(items, item)
+			}
+		}
+	}
+
+	unrecorded, err := p.backups.Unrecorded()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range unrecorded {
+		item, err := cleanup.Folder(cleanup.UnrecordedBackup, dir)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	queued := make(map[string]bool)
+	for _, item := range p.downloads.Items() {
+		if !item.State.Finished() {
+			queued[strings.ToLower(download.PartPath(item.Path))] = true
+		}
+	}
+	dirs := []string{p.dataDir, p.profileDir(), p.cacheDir(), p.downloadDir(), p.backups.Dir("")}
+	if p.pathUsable() {
+		dirs = append(dirs, p.dnfPath, gamepath.ImagePackPath(p.dnfPath))
+	}
+	temp, err := cleanup.TempFiles(dirs, func(path string) bool { return queued[strings.ToLower(path)] })
+	if err != nil {
+		return nil, err
+	}
+	return append(items, temp...), nil
+}
+
+// legacyInUse returns how many installed patches keep the file they
+// replaced in the legacy backup folder dir, and need it to be uninstalled.
+func (p *PatchManager) legacyInUse(dir string) int {
+	n := 0
+	for _, rec := range p.installed.Patches() {
+		if rec.Original != "" && fsutil.Within(rec.Original, dir) {
+			n++
+		}
+	}
+	return n
+}
+
+// showCleanup looks for leftovers in the background and lists them.
+func (p *PatchApp) showCleanup() {
+	p.updateStatus(i18n.T("cleanup.scanning"))
+	go func() {
+		defer p.recoverPanic(i18n.T("op.cleanup"))
+		items, err := p.findCleanup()
+		if err != nil {
+			slog.Error("looking for leftovers failed", "err", err)
+			p.updateStatus(i18n.T("cleanup.failed"))
+			p.showError(err)
+			return
+		}
+		p.updateStatus(i18n.T("cleanup.found", len(items)))
+		p.showCleanupItems(items)
+	}()
+}
+
+// showCleanupItems lists items by kind with their sizes, all checked but the
+// legacy folders installed patches still need, with an option to add the
+// legacy folders to the backup list before they are deleted.
+func (p *PatchApp) showCleanupItems(items []cleanup.Item) {
+	if len(items) == 0 {
+		dialog.ShowInformation(i18n.T("cleanup.title"), i18n.T("cleanup.none"), p.window)
+		return
+	}
+
+	selected := make(map[int]bool)
+	content := container.NewVBox()
+	for _, kind := range []cleanup.Kind{cleanup.LegacyBackup, cleanup.UnrecordedBackup, cleanup.TempFile} {
+		heading := false
+		for i, item := range items {
+			if item.Kind != kind {
+				continue
+			}
+			if !heading {
+				content.Add(widget.NewLabelWithStyle(i18n.T(cleanupKindIDs[kind]), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+				heading = true
+			}
+			i := i
+			text := fmt.Sprintf("%s  (%s, %s)", item.Path, formatSize(item.Size), p.formatTime(item.Time))
+			check := widget.NewCheck(text, func(on bool) { selected[i] = on })
+			if kind == cleanup.LegacyBackup {
+				if n := p.legacyInUse(item.Path); n > 0 {
+					check.Text += "  " + i18n.T("cleanup.inUse", n)
+					check.Disable()
+				}
+			}
+			if !check.Disabled() {
+				check.SetChecked(true)
+			}
+			content.Add(check)
+		}
+	}
+
+	absorb := widget.NewCheck(i18n.T("cleanup.absorb"), nil)
+	absorb.SetChecked(true)
+	var d dialog.Dialog
+	clean := widget.NewButtonWithIcon(i18n.T("cleanup.clean"), theme.DeleteIcon(), func() {
+		var chosen []cleanup.Item
+		var size int64
+		for i, item := range items {
+			if selected[i] {
+				chosen = append(chosen, item)
+				size += item.Size
+			}
+		}
+		if len(chosen) == 0 {
+			return
+		}
+		var paths []string
+		for _, item := range chosen {
+			paths = append(paths, item.Path)
+		}
+		message := i18n.T("cleanup.confirm", len(chosen), formatSize(size)) + "\n" + p.deletionNote(paths)
+		dialog.ShowConfirm(i18n.T("cleanup.title"), message, func(ok bool) {
+			if ok {
+				d.Hide()
+				go p.cleanUp(chosen, absorb.Checked)
+			}
+		}, p.window)
+	})
+	clean.Importance = widget.DangerImportance
+
+	d = dialog.NewCustom(i18n.T("cleanup.title"), i18n.T("common.close"), container.NewBorder(
+		wrappedLabel(i18n.T("cleanup.hint")), container.NewVBox(absorb, container.NewHBox(clean)), nil, nil,
+		container.NewVScroll(content)), p.window)
+	d.Resize(fyne.NewSize(650, 500))
+	d.Show()
+}
+
+// cleanUp deletes items. When absorb is set, legacy backup folders are first
+// added to the backup list, and kept when that fails.
+func (p *PatchApp) cleanUp(items []cleanup.Item, absorb bool) {
+	defer p.recoverPanic(i18n.T("op.cleanup"))
+	_, end := p.operations.begin(i18n.T("op.cleanup"))
+	defer end()
+
+	var freed int64
+	var failed []string
+	for i, item := range items {
+		p.progress.Set(float64(i) / float64(len(items)))
+		if item.Kind == cleanup.LegacyBackup && absorb {
+			b, err := p.backups.Import(p.dnfPath, item.Path, item.Time, i18n.T("cleanup.legacyDescription", filepath.Base(item.Path)))
+			if err != nil {
+				slog.Error("adding legacy backup failed", "path", item.Path, "err", err)
+				failed = append(failed, fmt.Sprintf("%s: %v", item.Path, err))
+				continue
+			}
+			slog.Info("added legacy backup", "path", item.Path, "backup", b.ID, "files", len(b.Files))
+		}
+		trashed, err := item.Remove(p.config.DeleteToTrash)
+		if err != nil {
+			slog.Error("removing leftover failed", "path", item.Path, "err", err)
+			failed = append(failed, fmt.Sprintf("%s: %v", item.Path, err))
+			continue
+		}
+		slog.Info("removed leftover", "path", item.Path, "size", item.Size, "recycleBin", trashed)
+		if item.Kind != cleanup.LegacyBackup || !absorb {
+			freed += item.Size
+		}
+	}
+	p.progress.Set(1)
+	p.backupList.Refresh()
+
+	if len(failed) > 0 {
+		p.updateStatus(i18n.T("cleanup.someFailed", len(failed)))
+		dialog.ShowInformation(i18n.T("cleanup.title"), strings.Join(failed, "\n"), p.window)
+		return
+	}
+	p.updateStatus(i18n.T("cleanup.done", len(items), formatSize(freed)))
+}
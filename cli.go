@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/instance"
+	"dnf_patch/internal/patchdb"
+)
+
+const cliUsage = `usage: dnfpatch [-profile name] [-game path] [-timeout duration] <command> [arguments]
+
+Without a command the graphical interface is started. Commands stop when
+the timeout (such as 10m) runs out or on Ctrl+C, rolling back what they
+left unfinished where they can.
+
+commands:
+  install <file>...          install patch files into imagepack2
+  list                       list installed patches
+  verify                     compare imagepack2 with the vanilla client
+  backup create [-d desc]    back up the NPK files of imagepack2
+  backup restore [-full] <id>
+                             restore a backup; -full also rewrites the
+                             files that already match it
+  backup list                list backups
+`
+
+// runCLI runs a command-line invocation and returns the process exit code.
+func runCLI(args []string) int {
+	flags := flag.NewFlagSet("dnfpatch", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, cliUsage) }
+	profileName := flags.String("profile", "", "game profile to use instead of the active one")
+	gamePath := flags.String("game", "", "DNF directory to use instead of the profile's path")
+	timeout := flags.Duration("timeout", 0, "give up after this long, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	m := newPatchManager()
+	defer m.recoverCrash()
+	if err := m.initLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot open log file: %v\n", err)
+	}
+	lock, err := m.acquireLock()
+	var running *instance.RunningError
+	switch {
+	case errors.As(err, &running):
+		fmt.Fprintf(os.Stderr, "error: %v; close it first\n", err)
+		return 1
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "warning: cannot take the instance lock: %v\n", err)
+	default:
+		defer lock.Release()
+	}
+	if err := m.openProfile(*profileName); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	for _, e := range m.journal.Entries() {
+		fmt.Fprintf(os.Stderr, "warning: %s %q was interrupted on %s; open the GUI to roll it back or resume it\n",
+			e.Operation, e.Description, e.Started.Format("2006-01-02 15:04"))
+	}
+	if *gamePath != "" {
+		m.dnfPath = *gamePath
+	}
+
+	cmd, cmdArgs := flags.Arg(0), flags.Args()[1:]
+	switch cmd {
+	case "install":
+		err = m.cliInstall(ctx, cmdArgs)
+	case "list":
+		err = m.cliList(os.Stdout)
+	case "verify":
+		err = m.cliVerify(ctx, os.Stdout)
+	case "backup":
+		err = m.cliBackup(ctx, cmdArgs)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		flags.Usage()
+		return 2
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", *timeout, err)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if hint := permissionHint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "hint: %s\n", hint)
+		}
+		return 1
+	}
+	return 0
+}
+
+// openProfile loads the config and the named profile, or the active one
+// when name is empty.
+func (p *PatchManager) openProfile(name string) error {
+	if err := p.loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	if err := p.migrateExeData(); err != nil {
+		return fmt.Errorf("failed to move data: %v", err)
+	}
+	if err := p.migrateProfiles(); err != nil {
+		return fmt.Errorf("failed to migrate profiles: %v", err)
+	}
+
+	id := p.config.ActiveProfile
+	if name != "" {
+		profile := p.findProfileByName(name)
+		if profile == nil {
+			return fmt.Errorf("no profile named %q", name)
+		}
+		id = profile.ID
+	}
+	return p.loadProfile(id)
+}
+
+func (p *PatchManager) requireGamePath() error {
+	if p.dnfPath == "" {
+		return fmt.Errorf("no DNF directory configured, use -game")
+	}
+	if check := gamepath.Inspect(p.dnfPath); !check.Valid() {
+		return fmt.Errorf("%s is not a DNF directory (missing %s)", p.dnfPath, strings.Join(check.Missing(), ", "))
+	}
+	return nil
+}
+
+func printProgress(msg string) {
+	fmt.Println(msg)
+}
+
+// printRestoreProgress prints each file a restore has verified or copied.
+func printRestoreProgress(s backup.RestoreStatus) {
+	switch {
+	case !s.FileDone, s.Phase == backup.Comparing:
+	case s.Phase == backup.Verifying:
+		fmt.Printf("Verified %d/%d: %s\n", s.File, s.Files, s.Path)
+	default:
+		fmt.Printf("Restored %d/%d: %s\n", s.File, s.Files, s.Path)
+	}
+}
+
+func (p *PatchManager) cliInstall(ctx context.Context, files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("install: no patch files given")
+	}
+	if err := p.requireGamePath(); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(file)
+		patch := patchdb.Patch{ID: strings.TrimSuffix(name, filepath.Ext(name)), Name: name}
+		target, err := p.importName(name)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if target != name {
+			fmt.Printf("Installing %s as %s...\n", name, target)
+		} else {
+			fmt.Printf("Installing %s...\n", name)
+		}
+		if overlaps, err := p.findOverlaps(file, target); err == nil {
+			for _, o := range overlaps {
+				fmt.Fprintf(os.Stderr, "warning: %s and the installed %s both change %d IMG files, e.g. %s\n",
+					name, o.Patch.PatchName, len(o.Paths), o.Paths[0])
+			}
+		}
+		rec, err := p.installPatch(ctx, file, f, target, printProgress)
+		f.Close()
+		if err != nil {
+			p.addFailureToHistory(patch, err)
+			return fmt.Errorf("installing %s: %w", name, err)
+		}
+		if target != name {
+			rec.SourceName = name
+			if err := p.installed.Add(rec); err != nil {
+				slog.Error("saving installed patches failed", "patch", target, "err", err)
+			}
+		}
+		p.addToHistory(patch, "Installed")
+		fmt.Printf("Installed %s\n", name)
+	}
+	return nil
+}
+
+func (p *PatchManager) cliList(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tVERSION\tINSTALLED\tSHA256")
+	for _, installed := range p.installed.Patches() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			installed.Filename,
+			installed.Version,
+			installed.InstalledAt.Format("2006-01-02 15:04:05"),
+			installed.Hash)
+	}
+	return tw.Flush()
+}
+
+func (p *PatchManager) cliVerify(ctx context.Context, w io.Writer) error {
+	if err := p.requireGamePath(); err != nil {
+		return err
+	}
+	results, err := p.verifyGameFiles(ctx, nil)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSTATUS\tPATCH")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, r.Status, r.Patch)
+	}
+	return tw.Flush()
+}
+
+func (p *PatchManager) cliBackup(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("backup: expected create, restore or list")
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("backup create", flag.ContinueOnError)
+		description := flags.String("d", "", "backup description, from the profile's template when empty")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if err := p.requireGamePath(); err != nil {
+			return err
+		}
+		if *description == "" {
+			*description = p.backupDescription()
+		}
+		if p.backupsInGame() {
+			fmt.Fprintf(os.Stderr, "warning: backups are kept in %s, which overlaps the game folder %s; a repair of the game can delete them\n", p.backups.Dir(""), p.dnfPath)
+		}
+		fmt.Println("Creating backup...")
+		backup, err := p.createBackup(backup.Options{Description: *description, Type: "manual", Context: ctx})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created backup %s (%d files)\n", backup.ID, len(backup.Files))
+		return nil
+
+	case "restore":
+		flags := flag.NewFlagSet("backup restore", flag.ContinueOnError)
+		full := flags.Bool("full", false, "also rewrite the files that already match the backup")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: backup restore [-full] <id>")
+		}
+		if err := p.requireGamePath(); err != nil {
+			return err
+		}
+		opts := backup.RestoreOptions{Progress: printRestoreProgress}
+		if !*full {
+			opts.CurrentHash = p.hashes.Hash
+		}
+		backup, ok := p.backups.Find(flags.Arg(0))
+		if !ok {
+			return fmt.Errorf("no backup with ID %q", flags.Arg(0))
+		}
+		fmt.Printf("Restoring backup %s...\n", backup.ID)
+		summary, err := p.restoreBackup(ctx, backup, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Backup restored: %d files restored, %d unchanged\n", summary.Restored, summary.Unchanged)
+		for _, err := range summary.LinkErrors {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		return nil
+
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTYPE\tTIME\tFILES\tDESCRIPTION\tTAGS")
+		for _, backup := range p.backups.Backups() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				backup.ID,
+				backup.Type,
+				backup.Timestamp.Format("2006-01-02 15:04:05"),
+				len(backup.Files),
+				backup.Description,
+				strings.Join(backup.Tags, ","))
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("backup: unknown subcommand %q", args[0])
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/deeplink"
+	"dnf_patch/internal/i18n"
+)
+
+// clipboardLink returns the link to suggest for text copied to the
+// clipboard: a dnfpatch:// link, or the http(s) URL of a .npk or .zip file
+// as an install link. It reports false for anything else.
+func clipboardLink(text string) (deeplink.Link, bool) {
+	text = strings.TrimSpace(text)
+	// Copying a paragraph is not copying a link
+	if text == "" || strings.ContainsAny(text, " \t\r\n") {
+		return deeplink.Link{}, false
+	}
+	if !deeplink.IsLink(text) {
+		lower := strings.ToLower(text)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			return deeplink.Link{}, false
+		}
+		text = deeplink.Scheme + "://" + deeplink.Install + "?url=" + url.QueryEscape(text)
+	}
+	link, err := deeplink.Parse(text)
+	return link, err == nil
+}
+
+// createClipboardBar returns the bar suggesting to install a copied link,
+// hidden until there is one.
+func (p *PatchApp) createClipboardBar() *fyne.Container {
+	p.clipboardSeen = make(map[string]bool)
+	p.clipboardBar = container.NewVBox()
+	p.clipboardBar.Hide()
+	fyne.CurrentApp().Lifecycle().SetOnEnteredForeground(p.checkClipboard)
+	return p.clipboardBar
+}
+
+// checkClipboard shows the clipboard bar when the window comes to the front
+// with a patch link on the clipboard that was not suggested before in this
+// session.
+func (p *PatchApp) checkClipboard() {
+	if !p.config.WatchClipboard {
+		return
+	}
+	text := strings.TrimSpace(p.window.Clipboard().Content())
+	if p.clipboardSeen[text] {
+		return
+	}
+	link, ok := clipboardLink(text)
+	if !ok {
+		return
+	}
+	slog.Info("found a patch link on the clipboard", "link", text)
+	// Whatever the user does with it, it is not suggested again
+	p.clipboardSeen[text] = true
+
+	what := link.Filename
+	if link.PatchID != "" {
+		what = link.PatchID
+		if patch, ok := p.catalogPatch(link.PatchID); ok {
+			what = patch.Name
+		}
+	}
+	install := widget.NewButtonWithIcon(i18n.T("patch.install"), theme.DownloadIcon(), func() {
+		p.clipboardBar.Hide()
+		p.followLink(link)
+	})
+	install.Importance = widget.HighImportance
+	p.clipboardBar.Objects = []fyne.CanvasObject{container.NewBorder(nil, nil,
+		widget.NewIcon(theme.ContentPasteIcon()),
+		container.NewHBox(install, widget.NewButton(i18n.T("clipboard.dismiss"), p.clipboardBar.Hide)),
+		wrappedLabel(i18n.T("clipboard.suggest", what)),
+	)}
+	p.clipboardBar.Refresh()
+	p.clipboardBar.Show()
+}
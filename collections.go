@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/collection"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/patchdb"
+)
+
+// memberSource returns the patch file to install m from: the copy in the
+// patch cache or, for catalogue patches, the bundled file. It returns ""
+// when neither exists.
+func (p *PatchManager) memberSource(m collection.Member) string {
+	if m.Hash != "" {
+		cached := p.patchCache().Path(m.Hash, m.Filename)
+		if _, err := os.Stat(cached); err == nil {
+			return cached
+		}
+	}
+	if patch, ok := p.catalogPatch(m.PatchID); ok {
+		bundled := filepath.Join(p.exeDir, "patches", patch.Filename)
+		if _, err := os.Stat(bundled); err == nil {
+			return bundled
+		}
+	}
+	return ""
+}
+
+// applyCollection carries out plan and records c as the active collection.
+// Members without a source are skipped.
+func (p *PatchManager) applyCollection(ctx context.Context, c collection.Collection, plan collection.Plan, progress func(string)) error {
+	for _, rec := range plan.Remove {
+		progress(i18n.T("collection.removing", rec.PatchName))
+		if err := p.uninstallPatch(rec); err != nil {
+			return err
+		}
+	}
+	for _, m := range plan.Install {
+		source := p.memberSource(m)
+		if source == "" {
+			continue
+		}
+		progress(i18n.T("collection.installing", m.PatchName))
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		rec, err := p.installPatch(ctx, source, f, m.Filename, progress)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("installing %s: %w", m.PatchName, err)
+		}
+		rec.PatchID, rec.PatchName = m.PatchID, m.PatchName
+		if err := p.installed.Add(rec); err != nil {
+			slog.Error("saving installed patches failed", "patch", m.PatchID, "err", err)
+		}
+		p.addToHistory(patchdb.Patch{ID: m.PatchID, Name: m.PatchName}, "Installed")
+	}
+	return p.collections.SetActive(c.Name)
+}
+
+// showCollections lists the saved collections of the profile with actions
+// to save, apply, share and delete them.
+func (p *PatchApp) showCollections() {
+	collections := p.collections.Collections()
+	selected := -1
+	active, _ := p.collections.Active()
+
+	members := widget.NewLabel(i18n.T("collection.selectHint"))
+	members.Wrapping = fyne.TextWrapWord
+	list := widget.NewList(
+		func() int { return len(collections) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			c := collections[id]
+			text := i18n.T("collection.item", c.Name, len(c.Members))
+			if c.Name == active.Name {
+				text += " " + i18n.T("collection.active")
+			}
+			item.(*widget.Label).SetText(text)
+		},
+	)
+
+	var d dialog.Dialog
+	reopen := func() {
+		d.Hide()
+		p.showCollections()
+	}
+	applyButton := widget.NewButtonWithIcon(i18n.T("collection.apply"), theme.ConfirmIcon(), func() {
+		d.Hide()
+		p.confirmApplyCollection(collections[selected])
+	})
+	exportButton := widget.NewButtonWithIcon(i18n.T("common.export"), theme.DocumentSaveIcon(), func() {
+		p.exportCollection(collections[selected])
+	})
+	deleteButton := widget.NewButtonWithIcon(i18n.T("common.delete"), theme.DeleteIcon(), func() {
+		c := collections[selected]
+		dialog.ShowConfirm(i18n.T("collection.deleteTitle"), i18n.T("collection.deleteConfirm", c.Name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := p.collections.Delete(c.Name); err != nil {
+				p.showError(err)
+			}
+			reopen()
+		}, p.window)
+	})
+	selectionButtons := []*widget.Button{applyButton, exportButton, deleteButton}
+	for _, b := range selectionButtons {
+		b.Disable()
+	}
+	if !p.pathUsable() {
+		applyButton.Hide()
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		var names []string
+		for _, m := range collections[id].Members {
+			names = append(names, m.PatchName)
+		}
+		members.SetText(strings.Join(names, ", "))
+		for _, b := range selectionButtons {
+			b.Enable()
+		}
+	}
+
+	saveButton := widget.NewButtonWithIcon(i18n.T("collection.saveInstalled"), theme.ContentAddIcon(), func() {
+		p.saveCollection(reopen)
+	})
+	importButton := widget.NewButtonWithIcon(i18n.T("common.import"), theme.FolderOpenIcon(), func() {
+		p.importCollection(reopen)
+	})
+
+	content := container.NewBorder(
+		nil,
+		container.NewVBox(
+			members,
+			container.NewHBox(applyButton, exportButton, deleteButton),
+			widget.NewSeparator(),
+			container.NewHBox(saveButton, importButton),
+		),
+		nil, nil, list)
+	d = dialog.NewCustom(i18n.T("collection.title"), i18n.T("common.close"), content, p.window)
+	d.Resize(fyne.NewSize(550, 450))
+	d.Show()
+}
+
+// saveCollection asks for a name and the installed patches to include and
+// saves them as a collection, then calls done.
+func (p *PatchApp) saveCollection(done func()) {
+	installed := p.installed.Patches()
+	if len(installed) == 0 {
+		dialog.ShowInformation(i18n.T("collection.saveTitle"), i18n.T("collection.nothingToSave"), p.window)
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	if active, ok := p.collections.Active(); ok {
+		nameEntry.SetText(active.Name)
+	}
+	var labels []string
+	byLabel := make(map[string]install.Record)
+	for _, rec := range installed {
+		label := fmt.Sprintf("%s (%s)", rec.PatchName, rec.Filename)
+		labels = append(labels, label)
+		byLabel[label] = rec
+	}
+	patchChecks := widget.NewCheckGroup(labels, nil)
+	patchChecks.SetSelected(labels)
+
+	dialog.ShowForm(i18n.T("collection.saveTitle"), i18n.T("common.save"), i18n.T("common.cancel"), []*widget.FormItem{
+		widget.NewFormItem(i18n.T("common.name"), nameEntry),
+		widget.NewFormItem(i18n.T("tab.patches"), container.NewVScroll(patchChecks)),
+	}, func(ok bool) {
+		name := strings.TrimSpace(nameEntry.Text)
+		if !ok || name == "" {
+			return
+		}
+		var records []install.Record
+		for _, label := range patchChecks.Selected {
+			records = append(records, byLabel[label])
+		}
+		if err := p.collections.Put(collection.FromRecords(name, records)); err != nil {
+			slog.Error("saving collection failed", "name", name, "err", err)
+			p.showError(err)
+			return
+		}
+		// What is installed now is this collection
+		if err := p.collections.SetActive(name); err != nil {
+			slog.Error("saving collection failed", "name", name, "err", err)
+		}
+		done()
+	}, p.window)
+}
+
+// confirmApplyCollection shows what applying c changes and applies it in the
+// background once confirmed.
+func (p *PatchApp) confirmApplyCollection(c collection.Collection) {
+	var previous *collection.Collection
+	if active, ok := p.collections.Active(); ok {
+		previous = &active
+	}
+	plan := collection.PlanSwitch(c, previous, p.installed.Patches())
+
+	var lines, unavailable []string
+	var total int64
+	for _, rec := range plan.Remove {
+		lines = append(lines, "➖ "+rec.PatchName)
+	}
+	for _, m := range plan.Install {
+		source := p.memberSource(m)
+		if source == "" {
+			unavailable = append(unavailable, m.PatchName)
+			continue
+		}
+		if info, err := os.Stat(source); err == nil {
+			total += info.Size()
+		}
+		lines = append(lines, "➕ "+m.PatchName)
+	}
+	if len(lines) == 0 && len(unavailable) == 0 {
+		if err := p.collections.SetActive(c.Name); err != nil {
+			slog.Error("saving collection failed", "name", c.Name, "err", err)
+		}
+		dialog.ShowInformation(i18n.T("collection.applyTitle"), i18n.T("collection.upToDate", c.Name), p.window)
+		return
+	}
+
+	message := i18n.T("collection.changes", c.Name, strings.Join(lines, "\n"))
+	if total > 0 {
+		message += "\n\n" + i18n.T("collection.totalSize", formatSize(total))
+	}
+	if len(unavailable) > 0 {
+		message += "\n\n" + i18n.T("collection.unavailable", strings.Join(unavailable, "\n"))
+	}
+	dialog.ShowConfirm(i18n.T("collection.applyTitle"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		p.progress.Set(0)
+		go func() {
+			defer p.recoverPanic(i18n.T("op.applyCollection"))
+			ctx, end := p.operations.begin(i18n.T("op.applyCollection"))
+			defer end()
+			err := p.applyCollection(ctx, c, plan, p.updateStatus)
+			p.historyList.Refresh()
+			if err != nil {
+				slog.Error("applying collection failed", "name", c.Name, "err", err)
+				p.notify(p.config.Notify.Installs, p.historyTab, i18n.T("collection.applyFailed"), c.Name)
+				p.showError(err)
+				return
+			}
+			p.progress.Set(1)
+			p.notify(p.config.Notify.Installs, p.historyTab, i18n.T("collection.applied"), c.Name)
+		}()
+	}, p.window)
+}
+
+func (p *PatchApp) exportCollection(c collection.Collection) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		data, err := c.JSON()
+		if err == nil {
+			_, err = writer.Write(data)
+		}
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			slog.Error("exporting collection failed", "name", c.Name, "err", err)
+			p.showError(err)
+		}
+	}, p.window)
+	save.SetFileName(c.Name + ".json")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	save.Show()
+}
+
+// importCollection adds a collection shared as JSON, then calls done.
+func (p *PatchApp) importCollection(done func()) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		var c collection.Collection
+		if err == nil {
+			c, err = collection.Parse(data)
+		}
+		if err == nil {
+			err = p.collections.Put(c)
+		}
+		if err != nil {
+			slog.Error("importing collection failed", "path", reader.URI().Path(), "err", err)
+			p.showError(err)
+			return
+		}
+		done()
+	}, p.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	open.Show()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// attachConsole connects the standard streams to the console of the parent
+// process so CLI output is visible, since the GUI build has no console of
+// its own.
+func attachConsole() {
+	if _, err := os.Stdout.Stat(); err == nil {
+		// Output is already redirected somewhere usable
+		return
+	}
+
+	const attachParentProcess = ^uintptr(0) // (DWORD)-1
+	attach := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	if r, _, _ := attach.Call(attachParentProcess); r == 0 {
+		return
+	}
+	if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = f
+		os.Stderr = f
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// crashMarkerName is written next to the crash reports when the UI itself
+// crashed, so the report can be pointed out on the next start.
+const crashMarkerName = "crash.last"
+
+// writeCrashReport appends the panic value, stack trace and recent log lines
+// to crash-YYYYMMDD.txt in the data directory and returns its path.
+func (p *PatchManager) writeCrashReport(value interface{}, stack []byte) (string, error) {
+	path := filepath.Join(p.dataDir, fmt.Sprintf("crash-%s.txt", time.Now().Format("20060102")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "=== %s ===\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(f, "panic: %v\n\n%s\n", value, stack)
+	fmt.Fprintf(f, "--- recent log ---\n%s\n\n", recentLog.String())
+	return path, nil
+}
+
+// recoverCrash writes a crash report for a panic in the main goroutine and
+// leaves a marker so it is shown on the next start, then exits. Deferred in
+// main and runCLI.
+func (p *PatchManager) recoverCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	path, err := p.writeCrashReport(r, debug.Stack())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "panic: %v\n%s\nwriting crash report failed: %v\n", r, debug.Stack(), err)
+		os.Exit(2)
+	}
+	slog.Error("crashed", "panic", r, "report", path)
+	os.WriteFile(filepath.Join(p.dataDir, crashMarkerName), []byte(path), 0644)
+	fmt.Fprintf(os.Stderr, "panic: %v\nA crash report was saved to %s\n", r, path)
+	os.Exit(2)
+}
+
+// recoverPanic is deferred by goroutines doing background work. A panic is
+// reported to the user instead of taking down the whole tool; operations it
+// interrupted stay in the journal.
+func (p *PatchApp) recoverPanic(operation string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	path, err := p.writeCrashReport(r, debug.Stack())
+	if err != nil {
+		slog.Error("writing crash report failed", "err", err)
+	}
+	slog.Error("panic", "operation", operation, "panic", r, "report", path)
+	p.updateStatus(i18n.T("crash.status", operation))
+	p.showCrashReport(i18n.T("crash.failed", operation), path)
+}
+
+// showPreviousCrash points to the crash report left by the last run, if it
+// crashed.
+func (p *PatchApp) showPreviousCrash() {
+	marker := filepath.Join(p.dataDir, crashMarkerName)
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		return
+	}
+	os.Remove(marker)
+	p.showCrashReport(i18n.T("crash.previous"), strings.TrimSpace(string(data)))
+}
+
+func (p *PatchApp) showCrashReport(message, path string) {
+	content := container.NewVBox(widget.NewLabel(message))
+	if path != "" {
+		content.Add(widget.NewLabel(i18n.T("crash.savedTo")))
+		content.Add(widget.NewLabelWithStyle(path, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}))
+		content.Add(widget.NewLabel(i18n.T("crash.attach")))
+	}
+
+	d := dialog.NewCustom(i18n.T("crash.title"), i18n.T("common.close"), content, p.window)
+	if path != "" {
+		d.SetButtons([]fyne.CanvasObject{
+			widget.NewButton(i18n.T("common.openFolder"), func() {
+				u, err := url.Parse(storage.NewFileURI(filepath.Dir(path)).String())
+				if err == nil {
+					err = fyne.CurrentApp().OpenURL(u)
+				}
+				if err != nil {
+					slog.Error("opening crash report folder failed", "path", path, "err", err)
+				}
+			}),
+			widget.NewButton(i18n.T("common.close"), d.Hide),
+		})
+	}
+	d.Show()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/fsutil"
+)
+
+// portableFlagName marks a portable install: while it sits beside the
+// executable all data is kept there instead of the user config directory.
+const portableFlagName = "portable.flag"
+
+// dataDirEntries are moved when switching between portable and installed
+// mode. Logs and crash reports stay behind.
+var dataDirEntries = []string{"profiles", "cache"}
+
+func (p *PatchManager) portable() bool {
+	_, err := os.Stat(filepath.Join(p.exeDir, portableFlagName))
+	return err == nil
+}
+
+// userDataDir returns the data directory of installed mode, falling back to
+// the executable directory when the system has no user config directory.
+func (p *PatchManager) userDataDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return p.exeDir
+	}
+	return filepath.Join(dir, appConfigDirName)
+}
+
+// resolveDataDir picks the data directory for the current mode.
+func (p *PatchManager) resolveDataDir() {
+	if p.portable() {
+		p.dataDir = p.exeDir
+	} else {
+		p.dataDir = p.userDataDir()
+	}
+}
+
+// migrateExeData moves the profiles and cache older versions kept next to the
+// executable into the user data directory.
+func (p *PatchManager) migrateExeData() error {
+	if p.dataDir == p.exeDir {
+		return nil
+	}
+	for _, name := range dataDirEntries {
+		src, dst := filepath.Join(p.exeDir, name), filepath.Join(p.dataDir, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		slog.Info("moving data out of the executable directory", "from", src, "to", dst)
+		if err := fsutil.MoveDir(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// switchDataMode moves config, profiles and the default cache into the data
+// directory of portable or installed mode, rewrites stored paths pointing
+// into the old directory and creates or removes portable.flag. The tool must
+// be restarted afterwards since logs, the journal and the instance lock stay
+// open in the old directory.
+func (p *PatchManager) switchDataMode(portable bool) error {
+	from, to := p.dataDir, p.userDataDir()
+	if portable {
+		to = p.exeDir
+	}
+	flag := filepath.Join(p.exeDir, portableFlagName)
+
+	for _, name := range dataDirEntries {
+		if _, err := os.Stat(filepath.Join(to, name)); err == nil && from != to {
+			return fmt.Errorf("%s already contains %s", to, name)
+		}
+	}
+
+	// Creating the flag first also checks the executable directory is
+	// writable before anything moves
+	if portable {
+		if err := os.WriteFile(flag, nil, 0644); err != nil {
+			return fmt.Errorf("cannot write to %s: %v", p.exeDir, err)
+		}
+	}
+	fail := func(err error) error {
+		if portable {
+			os.Remove(flag)
+		}
+		return err
+	}
+	if from == to {
+		if !portable {
+			os.Remove(flag)
+		}
+		return nil
+	}
+
+	// Backup paths inside the old directory follow the data
+	profileDirs, _ := filepath.Glob(filepath.Join(from, "profiles", "*"))
+	for _, dir := range profileDirs {
+		store := backup.NewStore(dir)
+		if err := store.Load(); err != nil {
+			return fail(err)
+		}
+		if path := store.Settings().BackupPath; filepath.IsAbs(path) {
+			err := store.UpdateSettings(func(s *backup.Settings) {
+				s.BackupPath = rebasePath(path, from, to)
+			})
+			if err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if p.config.CacheDir != "" {
+		p.config.CacheDir = rebasePath(p.config.CacheDir, from, to)
+	}
+
+	oldConfig := p.configPath()
+	for _, name := range dataDirEntries {
+		src := filepath.Join(from, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := fsutil.MoveDir(src, filepath.Join(to, name)); err != nil {
+			return fail(fmt.Errorf("moving %s failed: %v", src, err))
+		}
+	}
+
+	p.dataDir = to
+	if err := p.saveConfig(); err != nil {
+		return fail(err)
+	}
+	os.Remove(oldConfig)
+	if !portable {
+		if err := os.Remove(flag); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	slog.Info("moved data", "from", from, "to", to, "portable", portable)
+	return nil
+}
+
+// rebasePath returns path moved from the directory from into to, or path
+// unchanged when it lies outside from.
+func rebasePath(path, from, to string) string {
+	rel, err := filepath.Rel(from, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(to, rel)
+}
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/bundle"
+	"dnf_patch/internal/download"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// defaultMaxDownloads is how many downloads run at once unless configured.
+const defaultMaxDownloads = 2
+
+// maxDownloadsOptions are the choices offered for concurrent downloads.
+var maxDownloadsOptions = []string{"1", "2", "3", "4", "5"}
+
+// downloadDir is where patch files downloaded from the repository are kept.
+func (p *PatchManager) downloadDir() string {
+	return filepath.Join(p.cacheDir(), "downloads")
+}
+
+// patchURL returns where the file of a catalogue patch is downloaded from:
+// its url or, without one, patches/<filename> next to the repository's
+// patches.json.
+func (p *PatchManager) patchURL(patch patchdb.Patch) (string, error) {
+	if patch.URL != "" {
+		return patch.URL, nil
+	}
+	if p.config.RepositoryURL == "" {
+		return "", fmt.Errorf("%s has no download URL and no repository is configured", patch.Name)
+	}
+	base, err := url.Parse(p.config.RepositoryURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(&url.URL{Path: "patches/" + patch.Filename}).String(), nil
+}
+
+// catalogSource returns a local copy of the file of a catalogue patch: the
+// bundled file, an earlier download or the file imported from a bundle. It
+// returns "" when there is none.
+func (p *PatchManager) catalogSource(patch patchdb.Patch) string {
+	paths := []string{
+		filepath.Join(p.exeDir, "patches", patch.Filename),
+		filepath.Join(p.downloadDir(), patch.Filename),
+	}
+	if bundle.ValidID(patch.ID) {
+		paths = append(paths, filepath.Join(p.localPatchDir(patch.ID), patch.Filename))
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// startDownloads creates the download queue with the configured limits.
+func (p *PatchApp) startDownloads() {
+	client := func() (*http.Client, error) { return p.httpClient() }
+	limiter := download.NewLimiter(int64(p.config.SpeedLimit) << 10)
+	p.downloads = download.NewManager(client, limiter, p.config.MaxDownloads)
+	p.downloads.OnChange = func() {
+		if p.downloadList != nil {
+			p.downloadList.Refresh()
+			p.completedList.Refresh()
+		}
+	}
+	p.downloads.OnFinished = p.downloadFinished
+	p.downloads.OnError = func(err error) {
+		slog.Error("saving downloads failed", "err", err)
+	}
+}
+
+// resumeDownloads loads the downloads of the last run and continues the
+// unfinished ones. It is called once the game path and the patch database
+// are known, so downloads finishing right away can be installed.
+func (p *PatchApp) resumeDownloads() {
+	path := filepath.Join(p.dataDir, "downloads.json")
+	if err := p.downloads.Open(path); err != nil {
+		slog.Error("loading downloads failed", "path", path, "err", err)
+	}
+}
+
+// applyDownloadSettings applies the configured limits to the running
+// downloads and shows them in the downloads tab.
+func (p *PatchApp) applyDownloadSettings() {
+	p.downloads.SetMaxActive(p.config.MaxDownloads)
+	p.downloads.Limiter().SetRate(int64(p.config.SpeedLimit) << 10)
+	if p.downloadsTab != nil {
+		p.downloadsTab.Content = p.createDownloadsUI()
+		p.tabs.Refresh()
+	}
+}
+
+// installCatalogPatch installs a catalogue patch from its local copy, or
+// queues its download and installs it once the download finishes. Blocked
+// patches need another confirmation first.
+func (p *PatchApp) installCatalogPatch(patch patchdb.Patch) {
+	p.confirmBlocked(patch, func() { p.startCatalogInstall(patch) })
+}
+
+func (p *PatchApp) startCatalogInstall(patch patchdb.Patch) {
+	if source := p.catalogSource(patch); source != "" {
+		go p.installFromFile(patch, source)
+		return
+	}
+	p.downloadCatalogPatch(patch)
+}
+
+// downloadCatalogPatch queues the download of a catalogue patch, which is
+// installed once it finishes.
+func (p *PatchApp) downloadCatalogPatch(patch patchdb.Patch) {
+	url, err := p.patchURL(patch)
+	if err != nil {
+		p.showError(err)
+		return
+	}
+	// The tag names the patch to install, also after a restart
+	p.downloads.Add(patch.Name, url, filepath.Join(p.downloadDir(), patch.Filename), patch.ID, patch.Sha256)
+	slog.Info("queued patch download", "patch", patch.ID, "url", url)
+	p.updateStatus(i18n.T("download.queued", patch.Name))
+}
+
+// catalogPatch returns the catalogue patch with the given ID.
+func (p *PatchManager) catalogPatch(id string) (patchdb.Patch, bool) {
+	return p.patchIndex.ByID(id)
+}
+
+// installFromFile installs patch from the file at source in the background.
+func (p *PatchApp) installFromFile(patch patchdb.Patch, source string) {
+	defer p.recoverPanic(i18n.T("op.install"))
+	ctx, end := p.operations.begin(i18n.T("op.install"))
+	defer end()
+	if !p.pathUsable() {
+		p.updateStatus(i18n.T("download.noGamePath", patch.Name))
+		return
+	}
+	if err := patchdb.CheckFile(patch, source); err != nil {
+		slog.Error("checking patch file failed", "patch", patch.ID, "path", source, "err", err)
+		if !errors.Is(err, patchdb.ErrChecksumMismatch) {
+			p.showError(err)
+			return
+		}
+		p.showChecksumMismatch(patch.Name, func() {
+			// Never fall back to the bad copy; a bundled file is left alone
+			if filepath.Dir(source) == p.downloadDir() {
+				os.Remove(source)
+			}
+			p.downloadCatalogPatch(patch)
+		})
+		return
+	}
+	p.touchCached(source)
+	p.updateStatus(i18n.T("patch.installing", patch.Name))
+	f, err := os.Open(source)
+	if err != nil {
+		p.showError(err)
+		return
+	}
+	defer f.Close()
+	rec, err := p.installPatch(ctx, source, f, patch.Filename, p.updateStatus)
+	if err != nil {
+		slog.Error("installing patch failed", "patch", patch.ID, "err", err)
+		p.addFailureToHistory(patch, err)
+		p.historyList.Refresh()
+		if !p.offerRetryWrite(err, func() { p.installFromFile(patch, source) }) {
+			p.showError(err)
+		}
+		return
+	}
+	rec.PatchID, rec.PatchName, rec.Version = patch.ID, patch.Name, patch.Version
+	if err := p.installed.Add(rec); err != nil {
+		slog.Error("saving installed patches failed", "patch", patch.ID, "err", err)
+	}
+	p.addToHistory(patch, "Installed")
+	p.historyList.Refresh()
+	p.refreshBlockedBanner()
+	p.notify(p.config.Notify.Installs, p.historyTab, i18n.T("patch.installCompleted"), patch.Name)
+}
+
+// installDownload installs a finished download: as its catalogue patch when
+// it was downloaded for one, otherwise as a plain patch file.
+func (p *PatchApp) installDownload(item download.Item) {
+	if patch, ok := p.catalogPatch(item.Tag); ok {
+		go p.installFromFile(patch, item.Path)
+		return
+	}
+	go p.installDropped(item.Path)
+}
+
+// downloadFinished announces a finished download and installs the patch it
+// was started for.
+func (p *PatchApp) downloadFinished(item download.Item) {
+	switch item.State {
+	case download.Done:
+		slog.Info("download finished", "name", item.Name, "path", item.Path)
+		p.notify(p.config.Notify.Downloads, p.downloadsTab, i18n.T("download.finished"), item.Name)
+		if patch, ok := p.catalogPatch(item.Tag); ok {
+			p.deriveClasses()
+			p.installFromFile(patch, item.Path)
+		} else if item.Tag == linkTag {
+			p.installDropped(item.Path)
+		}
+	case download.Failed:
+		slog.Error("download failed", "name", item.Name, "url", item.URL, "err", item.Error)
+		if item.Corrupt {
+			// Resuming a download whose file did not match starts over
+			p.showChecksumMismatch(item.Name, func() { p.downloads.Resume(item.ID) })
+			return
+		}
+		p.notify(p.config.Notify.Downloads, p.downloadsTab, i18n.T("download.failed"), fmt.Sprintf("%s: %s", item.Name, item.Error))
+	case download.Cancelled:
+		slog.Info("download cancelled", "name", item.Name)
+	}
+}
+
+// showChecksumMismatch tells that the file of the named patch is not the one
+// the catalogue lists and was not installed, offering to download it again.
+func (p *PatchApp) showChecksumMismatch(name string, redownload func()) {
+	d := dialog.NewConfirm(i18n.T("checksum.title"), i18n.T("checksum.message", name), func(ok bool) {
+		if ok {
+			redownload()
+		}
+	}, p.window)
+	d.SetConfirmText(i18n.T("checksum.redownload"))
+	d.SetDismissText(i18n.T("common.cancel"))
+	d.Show()
+}
+
+// createDownloadsUI lists the running, queued and paused downloads with
+// their progress, speed and remaining time under the limits that apply to
+// all of them, and the completed downloads below.
+func (p *PatchApp) createDownloadsUI() fyne.CanvasObject {
+	maxSelect := widget.NewSelect(maxDownloadsOptions, nil)
+	maxSelect.SetSelected(strconv.Itoa(p.config.MaxDownloads))
+	maxSelect.OnChanged = func(s string) {
+		n, _ := strconv.Atoi(s)
+		p.updateConfig(func(c *AppConfig) { c.MaxDownloads = n })
+		p.downloads.SetMaxActive(n)
+	}
+
+	limitEntry := widget.NewEntry()
+	limitEntry.SetPlaceHolder(i18n.T("download.unlimited"))
+	if p.config.SpeedLimit > 0 {
+		limitEntry.SetText(strconv.Itoa(p.config.SpeedLimit))
+	}
+	limitEntry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); s != "" && (err != nil || n < 0) {
+			return errors.New(i18n.T("download.invalidLimit"))
+		}
+		return nil
+	}
+	limitEntry.OnChanged = func(s string) {
+		if limitEntry.Validate() != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(s))
+		p.updateConfig(func(c *AppConfig) { c.SpeedLimit = n })
+		p.downloads.Limiter().SetRate(int64(n) << 10)
+	}
+
+	limits := widget.NewForm(
+		widget.NewFormItem(i18n.T("download.maxActive"), maxSelect),
+		widget.NewFormItem(i18n.T("download.speedLimit"), limitEntry),
+	)
+
+	var transfers, completed []download.Item
+	p.downloadList = widget.NewList(
+		func() int {
+			transfers = transfers[:0]
+			for _, item := range p.downloads.Items() {
+				if item.State != download.Done && item.State != download.Cancelled {
+					transfers = append(transfers, item)
+				}
+			}
+			return len(transfers)
+		},
+		func() fyne.CanvasObject {
+			name := widget.NewLabel("")
+			name.Truncation = fyne.TextTruncateEllipsis
+			buttons := container.NewHBox(
+				widget.NewButtonWithIcon("", theme.MediaPauseIcon(), nil),
+				widget.NewButtonWithIcon("", theme.MoveUpIcon(), nil),
+				widget.NewButtonWithIcon("", theme.MoveDownIcon(), nil),
+				widget.NewButtonWithIcon("", theme.CancelIcon(), nil),
+			)
+			return container.NewBorder(nil, nil, nil, buttons,
+				container.NewVBox(
+					container.NewBorder(nil, nil, nil, widget.NewLabel(""), name),
+					widget.NewProgressBar(),
+				))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(transfers) {
+				return
+			}
+			item := transfers[id]
+			row := obj.(*fyne.Container)
+			rows := row.Objects[0].(*fyne.Container)
+			top := rows.Objects[0].(*fyne.Container)
+			top.Objects[0].(*widget.Label).SetText(item.Name)
+			top.Objects[1].(*widget.Label).SetText(p.downloadStatus(item))
+			bar := rows.Objects[1].(*widget.ProgressBar)
+			if item.Total > 0 {
+				bar.SetValue(float64(item.Done) / float64(item.Total))
+			} else {
+				bar.SetValue(0)
+			}
+
+			buttons := row.Objects[1].(*fyne.Container).Objects
+			toggle, up, down, cancel := buttons[0].(*widget.Button), buttons[1].(*widget.Button), buttons[2].(*widget.Button), buttons[3].(*widget.Button)
+			if item.State == download.Paused || item.State == download.Failed {
+				toggle.SetIcon(theme.MediaPlayIcon())
+				toggle.OnTapped = func() { p.downloads.Resume(item.ID) }
+			} else {
+				toggle.SetIcon(theme.MediaPauseIcon())
+				toggle.OnTapped = func() { p.downloads.Pause(item.ID) }
+			}
+			up.OnTapped = func() { p.downloads.Move(item.ID, -1) }
+			down.OnTapped = func() { p.downloads.Move(item.ID, 1) }
+			cancel.OnTapped = func() { p.downloads.Cancel(item.ID) }
+		},
+	)
+
+	p.completedList = widget.NewList(
+		func() int {
+			completed = completed[:0]
+			for _, item := range p.downloads.Items() {
+				if item.State == download.Done {
+					completed = append(completed, item)
+				}
+			}
+			return len(completed)
+		},
+		func() fyne.CanvasObject {
+			name := widget.NewLabel("")
+			name.Truncation = fyne.TextTruncateEllipsis
+			buttons := container.NewHBox(
+				widget.NewButtonWithIcon(i18n.T("download.install"), theme.DownloadIcon(), nil),
+				widget.NewButtonWithIcon("", theme.FolderOpenIcon(), nil),
+				widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
+			)
+			return container.NewBorder(nil, nil, nil, container.NewHBox(widget.NewLabel(""), buttons), name)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(completed) {
+				return
+			}
+			item := completed[id]
+			row := obj.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(item.Name)
+			right := row.Objects[1].(*fyne.Container)
+			right.Objects[0].(*widget.Label).SetText(p.downloadStatus(item))
+			buttons := right.Objects[1].(*fyne.Container).Objects
+			install, open, remove := buttons[0].(*widget.Button), buttons[1].(*widget.Button), buttons[2].(*widget.Button)
+			install.OnTapped = func() { p.installDownload(item) }
+			if p.pathUsable() {
+				install.Enable()
+			} else {
+				install.Disable()
+			}
+			open.OnTapped = func() {
+				u, err := url.Parse(storage.NewFileURI(filepath.Dir(item.Path)).String())
+				if err == nil {
+					err = fyne.CurrentApp().OpenURL(u)
+				}
+				if err != nil {
+					slog.Error("opening download folder failed", "path", item.Path, "err", err)
+					p.showError(err)
+				}
+			}
+			remove.OnTapped = func() { p.downloads.Remove(item.ID) }
+		},
+	)
+
+	clear := widget.NewButtonWithIcon(i18n.T("download.clearFinished"), theme.DeleteIcon(), p.downloads.ClearFinished)
+	split := container.NewVSplit(
+		container.NewBorder(widget.NewLabel(i18n.T("download.transfers")), nil, nil, nil, p.downloadList),
+		container.NewBorder(widget.NewLabel(i18n.T("download.completed")), container.NewHBox(clear), nil, nil, p.completedList),
+	)
+	split.Offset = 0.6
+	return container.NewBorder(
+		container.NewVBox(limits, widget.NewSeparator()),
+		nil, nil, nil,
+		split,
+	)
+}
+
+// downloadStatus describes the state, progress, speed and remaining time of
+// a download.
+func (p *PatchApp) downloadStatus(item download.Item) string {
+	switch item.State {
+	case download.Queued:
+		return i18n.T("download.stateQueued")
+	case download.Paused:
+		return i18n.T("download.statePaused", formatSize(item.Done))
+	case download.Done:
+		return i18n.T("download.stateDone", formatSize(item.Done), p.formatTime(item.Finished))
+	case download.Failed:
+		return i18n.T("download.stateFailed", item.Error)
+	case download.Cancelled:
+		return i18n.T("download.stateCancelled")
+	}
+	done := formatSize(item.Done)
+	if item.Total >= 0 {
+		done += " / " + formatSize(item.Total)
+	}
+	status := i18n.T("download.stateActive", done, formatSize(int64(item.Speed)))
+	if remaining := item.Remaining(); remaining >= 0 {
+		status += " · " + i18n.T("download.remaining", remaining.Round(time.Second))
+	}
+	return status
+}
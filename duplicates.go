@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/relpath"
+)
+
+// showFindDuplicates scans imagepack2 for copies of the same patch in the
+// background, with a dialog to cancel the scan, and shows what it found.
+func (p *PatchApp) showFindDuplicates() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("duplicates.title"), i18n.T("path.chooseFirst"), p.window)
+		return
+	}
+
+	opCtx, end := p.operations.begin(i18n.T("op.duplicates"))
+	ctx, cancel := context.WithCancel(opCtx)
+	bar := widget.NewProgressBar()
+	scanning := dialog.NewCustomWithoutButtons(i18n.T("duplicates.title"),
+		container.NewVBox(widget.NewLabel(i18n.T("duplicates.scanning")), bar), p.window)
+	scanning.SetButtons([]fyne.CanvasObject{widget.NewButtonWithIcon(i18n.T("common.cancel"), theme.CancelIcon(), cancel)})
+	scanning.Resize(fyne.NewSize(400, 0))
+	scanning.Show()
+
+	p.updateStatus(i18n.T("duplicates.scanning"))
+	go func() {
+		defer p.recoverPanic(i18n.T("op.duplicates"))
+		defer end()
+		defer cancel()
+		dir := gamepath.ImagePackPath(p.dnfPath)
+		groups, err := npk.FindDuplicates(ctx, dir, p.hashes.Hash, p.npkCache, func(done, total int) {
+			bar.SetValue(float64(done) / float64(total))
+		})
+		scanning.Hide()
+		if cancelled(err) {
+			p.updateStatus(i18n.T("duplicates.cancelled"))
+			return
+		}
+		if err != nil {
+			slog.Error("finding duplicate patches failed", "path", dir, "err", err)
+			p.updateStatus(i18n.T("duplicates.failed"))
+			p.showError(err)
+			return
+		}
+		slog.Info("found duplicate patches", "path", dir, "groups", len(groups))
+		p.updateStatus(i18n.T("duplicates.found", len(groups)))
+		p.showDuplicates(groups)
+	}()
+}
+
+// showDuplicates lists the groups of duplicate files with a check for each
+// file, and buttons to disable or delete the checked ones. In groups of
+// identical files all copies but one are checked, keeping an installed patch
+// where there is one.
+func (p *PatchApp) showDuplicates(groups []npk.Duplicates) {
+	if len(groups) == 0 {
+		dialog.ShowInformation(i18n.T("duplicates.title"), i18n.T("duplicates.none"), p.window)
+		return
+	}
+	installed := make(map[relpath.Path]string)
+	for _, rec := range p.installed.Patches() {
+		installed[rec.Key()] = rec.PatchName
+	}
+
+	// A file can be in both kinds of group, so its checks are kept in step
+	selected := make(map[string]bool)
+	checks := make(map[string][]*widget.Check)
+	var wasted int64
+	content := container.NewVBox()
+	for _, g := range groups {
+		wasted += g.Wasted()
+		kind := i18n.T("duplicates.identical", formatSize(g.Wasted()))
+		if g.Kind == npk.SameIMGs {
+			kind = i18n.T("duplicates.sameIMGs")
+		}
+		content.Add(widget.NewLabelWithStyle(kind, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+
+		keep := 0
+		for i, f := range g.Files {
+			if installed[relpath.Canonical(filepath.Base(f.Path))] != "" {
+				keep = i
+				break
+			}
+		}
+		for i, f := range g.Files {
+			path := f.Path
+			text := fmt.Sprintf("%s  (%s)", filepath.Base(path), formatSize(f.Size))
+			if name := installed[relpath.Canonical(filepath.Base(path))]; name != "" {
+				text += "  " + i18n.T("duplicates.installedAs", name)
+			}
+			check := widget.NewCheck(text, func(on bool) {
+				selected[path] = on
+				for _, other := range checks[path] {
+					other.SetChecked(on)
+				}
+			})
+			checks[path] = append(checks[path], check)
+			if g.Kind == npk.Identical && i != keep {
+				check.SetChecked(true)
+			}
+			content.Add(check)
+		}
+		content.Add(widget.NewSeparator())
+	}
+
+	paths := func() []string {
+		var paths []string
+		for path, on := range selected {
+			if on {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+	var d dialog.Dialog
+	act := func(remove bool) {
+		chosen := paths()
+		if len(chosen) == 0 {
+			return
+		}
+		message := i18n.T("duplicates.confirmDisable", len(chosen))
+		if remove {
+			message = i18n.T("duplicates.confirmDelete", len(chosen)) + "\n" + p.deletionNote(chosen)
+		}
+		dialog.ShowConfirm(i18n.T("duplicates.title"), message, func(ok bool) {
+			if ok {
+				d.Hide()
+				go p.removeDuplicates(chosen, remove)
+			}
+		}, p.window)
+	}
+	disable := widget.NewButtonWithIcon(i18n.T("duplicates.disable"), theme.VisibilityOffIcon(), func() { act(false) })
+	remove := widget.NewButtonWithIcon(i18n.T("duplicates.delete"), theme.DeleteIcon(), func() { act(true) })
+	remove.Importance = widget.DangerImportance
+
+	summary := wrappedLabel(i18n.T("duplicates.summary", len(groups), formatSize(wasted)))
+	d = dialog.NewCustom(i18n.T("duplicates.title"), i18n.T("common.close"), container.NewBorder(
+		summary, container.NewHBox(disable, remove), nil, nil,
+		container.NewVScroll(content)), p.window)
+	d.Resize(fyne.NewSize(600, 500))
+	d.Show()
+}
+
+// removeDuplicates backs up the files at paths and then deletes them or,
+// unless remove is set, disables them by renaming them so the game skips
+// them.
+func (p *PatchApp) removeDuplicates(paths []string, remove bool) {
+	defer p.recoverPanic(i18n.T("op.duplicates"))
+	ctx, end := p.operations.begin(i18n.T("op.duplicates"))
+	defer end()
+
+	p.updateStatus(i18n.T("duplicates.backingUp"))
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	description := i18n.T("duplicates.backupDisabling", describePatches(names))
+	if remove {
+		description = i18n.T("duplicates.backupDescription", describePatches(names))
+	}
+	b, err := p.createBackup(backup.Options{
+		Description: description,
+		Type:        "auto",
+		Include: func(path string) bool {
+			for _, f := range paths {
+				if strings.EqualFold(f, path) {
+					return true
+				}
+			}
+			return false
+		},
+		Context: ctx,
+	})
+	if err != nil {
+		slog.Error("backup before removing duplicates failed", "path", p.dnfPath, "err", err)
+		p.updateStatus(i18n.T("backup.createFailed"))
+		p.showError(fmt.Errorf("backup before removing duplicates failed: %w", err))
+		return
+	}
+	p.backupList.Refresh()
+
+	var failed []string
+	for _, path := range paths {
+		if remove {
+			err = p.deletePath(path)
+		} else {
+			err = fsutil.Rename(path, path+disabledSuffix)
+		}
+		if err != nil {
+			slog.Error("removing duplicate patch failed", "path", path, "err", err)
+			failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+			continue
+		}
+		if err := p.installed.Remove(filepath.Base(path)); err != nil {
+			slog.Error("updating installed patches failed", "patch", path, "err", err)
+		}
+		slog.Info("removed duplicate patch", "path", path, "deleted", remove, "backup", b.ID)
+	}
+	p.historyList.Refresh()
+
+	if len(failed) > 0 {
+		p.updateStatus(i18n.T("duplicates.removeFailed", len(failed)))
+		dialog.ShowInformation(i18n.T("duplicates.title"), strings.Join(failed, "\n"), p.window)
+		return
+	}
+	p.updateStatus(i18n.T("duplicates.removed", len(paths)))
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// canElevate reports whether relaunchElevated is supported.
+const canElevate = false
+
+// relaunchElevated is only implemented for Windows.
+func relaunchElevated() error {
+	return errors.New("relaunching with administrator rights is only supported on Windows")
+}
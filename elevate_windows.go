@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// canElevate reports whether relaunchElevated is supported.
+const canElevate = true
+
+// relaunchElevated starts the tool again with administrator rights, showing
+// the UAC prompt.
+func relaunchElevated() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	verb, err := syscall.UTF16PtrFromString("runas")
+	if err != nil {
+		return err
+	}
+	file, err := syscall.UTF16PtrFromString(exe)
+	if err != nil {
+		return err
+	}
+	dir, err := syscall.UTF16PtrFromString(filepath.Dir(exe))
+	if err != nil {
+		return err
+	}
+
+	const swShowNormal = 1
+	shellExecute := syscall.NewLazyDLL("shell32.dll").NewProc("ShellExecuteW")
+	r, _, callErr := shellExecute.Call(0,
+		uintptr(unsafe.Pointer(verb)),
+		uintptr(unsafe.Pointer(file)),
+		0,
+		uintptr(unsafe.Pointer(dir)),
+		swShowNormal)
+	// ShellExecute returns a value greater than 32 on success, and fails
+	// when the UAC prompt is declined
+	if r <= 32 {
+		return fmt.Errorf("starting as administrator failed: %v", callErr)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// errorLogLines is how many recent log lines "Copy details" adds.
+const errorLogLines = 20
+
+// errorSummary describes err in a sentence the user can act on, falling back
+// to its message for errors without a better explanation.
+func errorSummary(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return i18n.T("error.permission")
+	case errors.Is(err, fs.ErrNotExist):
+		return i18n.T("error.notFound")
+	case errors.As(err, &netErr):
+		return i18n.T("error.network")
+	}
+	return err.Error()
+}
+
+// errorDetails lists err and the errors it wraps, the files involved and the
+// paths in use, for bug reports. It stays in English like the log.
+func (p *PatchApp) errorDetails(err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Error: %v\n", err)
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		fmt.Fprintf(&b, "Caused by: %v (%T)\n", e, e)
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		fmt.Fprintf(&b, "File: %s (%s)\n", pathErr.Path, pathErr.Op)
+	}
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) {
+		fmt.Fprintf(&b, "Files: %s -> %s (%s)\n", linkErr.Old, linkErr.New, linkErr.Op)
+	}
+	fmt.Fprintf(&b, "Game path: %s\n", p.dnfPath)
+	fmt.Fprintf(&b, "Data folder: %s\n", p.dataDir)
+	fmt.Fprintf(&b, "Version: %s (%s/%s)", appVersion, runtime.GOOS, runtime.GOARCH)
+	return b.String()
+}
+
+// showError replaces dialog.ShowError: it shows a summary of err with the
+// full details in an expandable section, and a button copying the details
+// along with the last lines of the log.
+func (p *PatchApp) showError(err error) {
+	details := p.errorDetails(err)
+	detailsLabel := widget.NewLabelWithStyle(details, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	detailsLabel.Wrapping = fyne.TextWrapBreak
+
+	content := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewIcon(theme.ErrorIcon()), nil, wrappedLabel(errorSummary(err))),
+		widget.NewAccordion(widget.NewAccordionItem(i18n.T("error.details"), detailsLabel)),
+	)
+
+	d := dialog.NewCustom(i18n.T("error.title"), i18n.T("common.close"), content, p.window)
+	copyButton := widget.NewButtonWithIcon(i18n.T("error.copy"), theme.ContentCopyIcon(), func() {
+		p.window.Clipboard().SetContent(fmt.Sprintf("%s\n\n--- recent log ---\n%s", details, recentLog.Last(errorLogLines)))
+		p.updateStatus(i18n.T("error.copied"))
+	})
+	d.SetButtons([]fyne.CanvasObject{copyButton, widget.NewButton(i18n.T("common.close"), d.Hide)})
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
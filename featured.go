@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// featuredInterval is how long the carousel shows each featured patch.
+const featuredInterval = 8 * time.Second
+
+// featuredBannerSize is the size of the banner images of featured patches.
+var featuredBannerSize = fyne.NewSize(600, 120)
+
+// featuredItem is a featured patch found in the catalogue.
+type featuredItem struct {
+	patchdb.Featured
+	patch patchdb.Patch
+}
+
+// createFeaturedUI creates the space at the top of the Patches tab filled by
+// refreshFeatured, hidden until there is something to show.
+func (p *PatchApp) createFeaturedUI() fyne.CanvasObject {
+	p.featuredView = container.NewMax()
+	p.featuredView.Hide()
+	return p.featuredView
+}
+
+// refreshFeatured shows the patches the catalogue features, one at a time,
+// or hides the carousel when it features none. Featured IDs missing from
+// the catalogue are left out.
+func (p *PatchApp) refreshFeatured() {
+	if p.stopFeatured != nil {
+		close(p.stopFeatured)
+		p.stopFeatured = nil
+	}
+	var items []featuredItem
+	for _, f := range p.patches.Featured {
+		patch, ok := p.catalogPatch(f.PatchID)
+		if !ok {
+			slog.Warn("featured patch is not in the catalogue", "patch", f.PatchID)
+			continue
+		}
+		items = append(items, featuredItem{f, patch})
+	}
+	if len(items) == 0 {
+		p.featuredView.Objects = nil
+		p.featuredView.Hide()
+		return
+	}
+	p.featuredView.Objects = []fyne.CanvasObject{p.createFeaturedCarousel(items)}
+	p.featuredView.Show()
+	p.featuredView.Refresh()
+}
+
+// createFeaturedCarousel shows items one after the other, moving on every
+// featuredInterval or with the arrows. Clicking one opens its details. A
+// banner that cannot be loaded is left out, and the text takes its place.
+func (p *PatchApp) createFeaturedCarousel(items []featuredItem) fyne.CanvasObject {
+	current := 0
+	open := func() { p.showPatchDetails(items[current].patch) }
+	banner := newTappableImage(nil, featuredBannerSize, open)
+	banner.Hide()
+	title := widget.NewButton("", open)
+	title.Alignment = widget.ButtonAlignLeading
+	title.Importance = widget.LowImportance
+	blurb := wrappedLabel("")
+	position := widget.NewLabel("")
+	prev := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), nil)
+	next := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), nil)
+
+	var show func(i int)
+	show = func(i int) {
+		current = (i + len(items)) % len(items)
+		item := items[current]
+		title.SetText(item.patch.Name)
+		text := item.Blurb
+		if text == "" {
+			text = item.patch.Description
+		}
+		blurb.SetText(text)
+		position.SetText(fmt.Sprintf("%d / %d", current+1, len(items)))
+
+		var res fyne.Resource
+		if item.Banner != "" {
+			res = p.cachedImage(item.Banner, "banners", func() {
+				if items[current].Banner == item.Banner {
+					show(current)
+				}
+			})
+		}
+		if res == nil {
+			banner.Hide()
+			return
+		}
+		banner.SetResource(res)
+		banner.Show()
+	}
+	prev.OnTapped = func() { show(current - 1) }
+	next.OnTapped = func() { show(current + 1) }
+	show(0)
+
+	arrows := container.NewHBox(prev, position, next)
+	if len(items) == 1 {
+		arrows.Hide()
+	} else {
+		stop := make(chan struct{})
+		p.stopFeatured = stop
+		go func() {
+			defer p.recoverPanic(i18n.T("op.featured"))
+			ticker := time.NewTicker(featuredInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					show(current + 1)
+				}
+			}
+		}()
+	}
+
+	heading := widget.NewLabelWithStyle(i18n.T("featured.title"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	background := canvas.NewRectangle(badgeBackground)
+	background.CornerRadius = theme.InputRadiusSize()
+	return container.NewMax(background, container.NewPadded(container.NewVBox(
+		container.NewBorder(nil, nil, nil, arrows, heading),
+		banner,
+		title,
+		blurb,
+	)))
+}
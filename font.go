@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/assets/fonts"
+	"dnf_patch/internal/i18n"
+)
+
+// fontExtensions are the font files the text renderer can read. Collections
+// (.ttc) are not supported.
+var fontExtensions = []string{".ttf", ".otf"}
+
+// bundledFont returns the font embedded from assets/fonts.
+var bundledFont = sync.OnceValue(func() fyne.Resource {
+	return fyne.NewStaticResource(fonts.Name, fonts.Interface)
+})
+
+// loadFont returns the interface font: the file chosen in the settings, or
+// the bundled font when none is chosen or it cannot be read.
+func (p *PatchApp) loadFont() fyne.Resource {
+	if p.config.FontPath != "" {
+		res, err := fyne.LoadResourceFromPath(p.config.FontPath)
+		if err == nil {
+			return res
+		}
+		slog.Error("loading font failed", "path", p.config.FontPath, "err", err)
+		p.updateStatus(i18n.T("status.fontFailed", err))
+	}
+	return bundledFont()
+}
+
+// wrappedLabel returns a label wrapping text at spaces. Runs without spaces,
+// as Chinese text usually is, are broken between characters.
+func wrappedLabel(text string) *widget.Label {
+	label := widget.NewLabel(text)
+	label.Wrapping = fyne.TextWrapWord
+	return label
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	_ "image/jpeg" // preview formats
+	_ "image/png"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/fetchpool"
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// Sizes of the gallery: the selected image, the thumbnails, and the largest
+// a zoom window opens at.
+var (
+	previewSize   = fyne.NewSize(400, 300)
+	thumbnailSize = fyne.NewSize(80, 60)
+	maxZoomSize   = fyne.NewSize(1200, 900)
+)
+
+// tappableImage is an image that calls onTapped when clicked.
+type tappableImage struct {
+	widget.BaseWidget
+	image    *canvas.Image
+	onTapped func()
+}
+
+func newTappableImage(res fyne.Resource, size fyne.Size, onTapped func()) *tappableImage {
+	img := canvas.NewImageFromResource(res)
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(size)
+	t := &tappableImage{image: img, onTapped: onTapped}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *tappableImage) SetResource(res fyne.Resource) {
+	t.image.Image = nil
+	t.image.Resource = res
+	t.image.Refresh()
+}
+
+// SetImage shows the decoded img in place of a resource.
+func (t *tappableImage) SetImage(img image.Image) {
+	t.image.Resource = nil
+	t.image.Image = img
+	t.image.Refresh()
+}
+
+func (t *tappableImage) Tapped(*fyne.PointEvent) {
+	if t.onTapped != nil {
+		t.onTapped()
+	}
+}
+
+func (t *tappableImage) Cursor() desktop.Cursor {
+	return desktop.PointerCursor
+}
+
+func (t *tappableImage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.image)
+}
+
+// loadPreview reads the image of a preview, given as a file path or URI.
+func loadPreview(location string) (fyne.Resource, error) {
+	if strings.Contains(location, "://") {
+		uri, err := storage.ParseURI(location)
+		if err != nil {
+			return nil, err
+		}
+		return storage.LoadResourceFromURI(uri)
+	}
+	return fyne.LoadResourceFromPath(location)
+}
+
+// cachedImage returns the image at location, a URL or a path, or nil until
+// it has been loaded in the background and for images that cannot be
+// loaded. onLoaded is called once an image has loaded. Images on the web are
+// kept in folder of the cache, so they are fetched only once.
+func (p *PatchApp) cachedImage(location, folder string, onLoaded func()) fyne.Resource {
+	if res, ok := p.images.LoadOrStore(location, nil); ok {
+		if res != nil {
+			return res.(fyne.Resource)
+		}
+		return nil
+	}
+	go func() {
+		defer p.recoverPanic(i18n.T("op.loadImage"))
+		res, err := p.loadCachedImage(context.Background(), location, folder)
+		if err != nil {
+			slog.Warn("loading image failed", "location", location, "err", err)
+			return
+		}
+		p.images.Store(location, res)
+		onLoaded()
+	}()
+	return nil
+}
+
+// loadCachedImage reads the image at location, fetching images on the web
+// into folder of the cache unless they are there already. Cancelling ctx
+// stops fetching.
+func (p *PatchManager) loadCachedImage(ctx context.Context, location, folder string) (fyne.Resource, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return loadPreview(location)
+	}
+	sum := sha256.Sum256([]byte(location))
+	name := hex.EncodeToString(sum[:8]) + path.Ext(strings.SplitN(location, "?", 2)[0])
+	cached := filepath.Join(p.cacheDir(), folder, name)
+	if data, err := os.ReadFile(cached); err == nil {
+		p.touchCached(cached)
+		return fyne.NewStaticResource(name, data), nil
+	}
+
+	client, err := p.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	data, err := patchdb.Fetch(ctx, client, location)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err == nil {
+		if err := fsutil.WriteFileAtomic(cached, data, 0644); err != nil {
+			slog.Warn("caching image failed", "path", cached, "err", err)
+		}
+	}
+	return fyne.NewStaticResource(name, data), nil
+}
+
+// Previews are fetched previewWorkers at a time, and their decoded images are
+// kept in memory while they take up to previewMemory bytes.
+const (
+	previewWorkers = 3
+	previewMemory  = 128 << 20
+)
+
+// newPreviewPool returns the pool the gallery loads previews through.
+func (p *PatchApp) newPreviewPool() *fetchpool.Pool[image.Image] {
+	return fetchpool.New(p.loadPreviewImage, previewWorkers, previewMemory, imageMemory)
+}
+
+// loadPreviewImage reads and decodes the preview at location, a URL or a
+// path. Previews on the web are kept in the previews folder of the cache.
+func (p *PatchManager) loadPreviewImage(ctx context.Context, location string) (image.Image, error) {
+	res, err := p.loadCachedImage(ctx, location, "previews")
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(res.Content()))
+	return img, err
+}
+
+// imageMemory is roughly the memory img takes once decoded.
+func imageMemory(img image.Image) int64 {
+	b := img.Bounds()
+	return int64(b.Dx()) * int64(b.Dy()) * 4
+}
+
+// previewTitle is the description of preview i, or its number when it has
+// none.
+func previewTitle(previews []patchdb.Preview, i int) string {
+	if d := strings.TrimSpace(previews[i].Description); d != "" {
+		return d
+	}
+	return i18n.T("patch.previewNumber", i+1, len(previews))
+}
+
+// createPreviewUI shows the previews of a patch as a gallery: the selected
+// image with previous and next buttons above a strip of thumbnails. Clicking
+// the image opens it at full size. The images are loaded in the background
+// through the preview pool until ctx is cancelled, and those that cannot be
+// loaded show a placeholder.
+func (p *PatchApp) createPreviewUI(ctx context.Context, previews []patchdb.Preview) fyne.CanvasObject {
+	if len(previews) == 0 {
+		return widget.NewLabel(i18n.T("patch.noPreviews"))
+	}
+
+	var mu sync.Mutex // guards current, images and failed, set as the previews load
+	images := make([]image.Image, len(previews))
+	failed := make([]bool, len(previews))
+	showImage := func(t *tappableImage, i int) {
+		mu.Lock()
+		img, broken := images[i], failed[i]
+		mu.Unlock()
+		switch {
+		case img != nil:
+			t.SetImage(img)
+		case broken:
+			t.SetResource(theme.BrokenImageIcon())
+		default:
+			t.SetResource(nil)
+		}
+	}
+
+	current := 0
+	title := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	title.Wrapping = fyne.TextWrapWord
+	state := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+	main := newTappableImage(nil, previewSize, func() {
+		mu.Lock()
+		i, img := current, images[current]
+		mu.Unlock()
+		if img != nil {
+			p.showZoomedPreview(previewTitle(previews, i), img)
+		}
+	})
+	prev := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), nil)
+	next := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), nil)
+
+	show := func(i int) {
+		mu.Lock()
+		current = i
+		loaded, broken := images[i] != nil, failed[i]
+		mu.Unlock()
+		title.SetText(previewTitle(previews, i))
+		showImage(main, i)
+		switch {
+		case loaded:
+			state.Hide()
+		case broken:
+			state.SetText(i18n.T("patch.previewUnavailable"))
+			state.Show()
+		default:
+			state.SetText(i18n.T("patch.previewLoading"))
+			state.Show()
+		}
+		if i == 0 {
+			prev.Disable()
+		} else {
+			prev.Enable()
+		}
+		if i == len(previews)-1 {
+			next.Disable()
+		} else {
+			next.Enable()
+		}
+	}
+	prev.OnTapped = func() { show(current - 1) }
+	next.OnTapped = func() { show(current + 1) }
+
+	thumbnails := container.NewHBox()
+	thumbs := make([]*tappableImage, len(previews))
+	for i := range previews {
+		i := i
+		thumbs[i] = newTappableImage(nil, thumbnailSize, func() { show(i) })
+		thumbnails.Add(thumbs[i])
+	}
+	show(0)
+
+	for i, preview := range previews {
+		go func(i int, location string) {
+			defer p.recoverPanic(i18n.T("op.loadImage"))
+			img, err := p.previews.Get(ctx, location)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				slog.Warn("loading preview failed", "url", location, "err", err)
+			}
+			mu.Lock()
+			images[i], failed[i] = img, err != nil
+			shown := current == i
+			mu.Unlock()
+			showImage(thumbs[i], i)
+			if shown {
+				show(i)
+			}
+		}(i, preview.URL)
+	}
+
+	gallery := container.NewVBox(
+		title,
+		container.NewBorder(nil, nil, container.NewCenter(prev), container.NewCenter(next),
+			container.NewStack(main, container.NewCenter(state))),
+	)
+	if len(previews) > 1 {
+		gallery.Add(container.NewHScroll(thumbnails))
+	}
+	return gallery
+}
+
+// showZoomedPreview opens preview at its native resolution in a window of
+// its own, which scrolls when the image is larger than the screen allows.
+func (p *PatchApp) showZoomedPreview(title string, preview image.Image) {
+	img := canvas.NewImageFromImage(preview)
+	img.FillMode = canvas.ImageFillOriginal
+	b := preview.Bounds()
+	size := fyne.NewSize(float32(b.Dx()), float32(b.Dy())).Min(maxZoomSize)
+
+	w := fyne.CurrentApp().NewWindow(title)
+	w.SetContent(container.NewScroll(img))
+	w.Resize(size)
+	w.CenterOnScreen()
+	w.Show()
+}
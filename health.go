@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+)
+
+// minFreeSpace is the free space below which the health check warns, enough
+// for a full backup of imagepack2 and a few patches.
+const minFreeSpace = 2 << 30
+
+// healthProblem is a problem found by the health check and the action that
+// fixes it.
+type healthProblem struct {
+	message string
+	action  string
+	fix     func()
+}
+
+// checkHealth verifies the active profile, game path, patch database and its
+// signature, backup folder and where it lies, free disk space and repository
+// and returns the problems it found.
+func (p *PatchApp) checkHealth() []healthProblem {
+	var problems []healthProblem
+	openSettings := func() { p.tabs.Select(p.settingsTab) }
+
+	if p.configNewer != nil {
+		problems = append(problems, healthProblem{i18n.T("health.configNewer", p.configPath()), i18n.T("common.openFolder"), p.openDataFolder})
+	}
+	if p.profileErr != nil {
+		problems = append(problems, healthProblem{i18n.T("health.profileFailed", p.profileErr), i18n.T("health.retry"), p.reloadProfile})
+	}
+
+	// The path banner already asks for a game path that went missing
+	if !p.pathBanner.Visible() {
+		switch {
+		case p.dnfPath == "":
+			problems = append(problems, healthProblem{i18n.T("health.noGamePath"), i18n.T("common.browse"), p.browseDNFPath})
+		case !gamepath.Inspect(p.dnfPath).Valid():
+			problems = append(problems, healthProblem{i18n.T("health.gamePathInvalid", p.dnfPath), i18n.T("common.browse"), p.browseDNFPath})
+		}
+	}
+
+	if p.databaseErr != nil {
+		problems = append(problems, healthProblem{i18n.T("health.databaseFailed", p.databaseErr), i18n.T("health.retry"), p.refreshDatabase})
+	} else if len(p.patches.Categories) == 0 {
+		problems = append(problems, healthProblem{i18n.T("health.databaseEmpty"), i18n.T("health.retry"), p.refreshDatabase})
+	}
+
+	if p.metadataErr != nil {
+		problems = append(problems, healthProblem{i18n.T("health.metadataRejected", p.metadataErr), i18n.T("health.openSettings"), openSettings})
+	}
+
+	backupDir := p.backups.Dir("")
+	if p.backupsInGame() {
+		problems = append(problems, healthProblem{i18n.T("health.backupInGame", backupDir, p.dnfPath), i18n.T("backup.relocate"), p.relocateBackups})
+	}
+	if err := checkWritable(backupDir); err != nil {
+		slog.Warn("backup folder is not writable", "path", backupDir, "err", err)
+		problems = append(problems, healthProblem{i18n.T("health.backupNotWritable", backupDir), i18n.T("health.openSettings"), openSettings})
+	} else if free, err := fsutil.FreeSpace(backupDir); err != nil {
+		slog.Warn("checking free space failed", "path", backupDir, "err", err)
+	} else if free < minFreeSpace {
+		problems = append(problems, healthProblem{i18n.T("health.lowSpace", formatSize(int64(free)), backupDir), i18n.T("health.openSettings"), openSettings})
+	}
+
+	if p.config.RepositoryURL != "" {
+		if err := p.checkRepository(p.config.RepositoryURL); err != nil {
+			slog.Warn("repository is not reachable", "url", p.config.RepositoryURL, "err", err)
+			problems = append(problems, healthProblem{i18n.T("health.repositoryUnreachable", p.config.RepositoryURL), i18n.T("health.retry"), p.refreshDatabase})
+		}
+	}
+	return problems
+}
+
+// checkWritable creates dir if needed and writes a temporary file into it.
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkRepository sends a HEAD request for the repository at repoURL.
+func (p *PatchManager) checkRepository(repoURL string) error {
+	client, err := p.httpClient()
+	if err != nil {
+		return err
+	}
+	client.Timeout = 10 * time.Second
+	resp, err := client.Head(repoURL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// Some servers only answer GET, which still means they are up
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("%s: %s", repoURL, resp.Status)
+	}
+	return nil
+}
+
+// runHealthCheck checks for problems in the background and lists them in the
+// health banner. When manual is set, a clean result is reported as well.
+func (p *PatchApp) runHealthCheck(manual bool) {
+	go func() {
+		defer p.recoverPanic(i18n.T("op.healthCheck"))
+		problems := p.checkHealth()
+		if len(problems) > 0 {
+			slog.Warn("health check found problems", "count", len(problems))
+		}
+		p.showHealthProblems(problems)
+		if manual && len(problems) == 0 {
+			dialog.ShowInformation(i18n.T("health.title"), i18n.T("health.ok"), p.window)
+		}
+	}()
+}
+
+// createHealthBanner returns the banner listing the problems of the last
+// health check, hidden until there are any.
+func (p *PatchApp) createHealthBanner() *fyne.Container {
+	p.healthBanner = container.NewVBox()
+	p.healthBanner.Hide()
+	return p.healthBanner
+}
+
+func (p *PatchApp) showHealthProblems(problems []healthProblem) {
+	if len(problems) == 0 {
+		p.healthBanner.Hide()
+		return
+	}
+
+	rows := make([]fyne.CanvasObject, 0, len(problems))
+	for i, problem := range problems {
+		buttons := container.NewHBox(widget.NewButton(problem.action, problem.fix))
+		if i == 0 {
+			buttons.Add(widget.NewButtonWithIcon("", theme.CancelIcon(), p.healthBanner.Hide))
+		}
+		rows = append(rows, container.NewBorder(nil, nil,
+			widget.NewIcon(theme.WarningIcon()), buttons,
+			wrappedLabel(problem.message)))
+	}
+	p.healthBanner.Objects = rows
+	p.healthBanner.Refresh()
+	p.healthBanner.Show()
+}
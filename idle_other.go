@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "time"
+
+// idleTime is only implemented for Windows.
+func idleTime() (time.Duration, bool) {
+	return 0, false
+}
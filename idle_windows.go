@@ -0,0 +1,29 @@
+package main
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	procGetLastInputInfo = user32.NewProc("GetLastInputInfo")
+	procGetTickCount     = syscall.NewLazyDLL("kernel32.dll").NewProc("GetTickCount")
+)
+
+// lastInputInfo is the LASTINPUTINFO of GetLastInputInfo.
+type lastInputInfo struct {
+	size uint32
+	time uint32 // tick count of the last input
+}
+
+// idleTime returns how long the user has not touched the keyboard or mouse.
+func idleTime() (time.Duration, bool) {
+	info := lastInputInfo{size: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	if ok, _, _ := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0, false
+	}
+	now, _, _ := procGetTickCount.Call()
+	// Both tick counts wrap around after 49.7 days
+	return time.Duration(uint32(now)-info.time) * time.Millisecond, true
+}
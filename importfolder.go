@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/bundle"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/importdir"
+)
+
+// chooseFolderToImport asks for a folder and offers the patch files under it
+// for install.
+func (p *PatchApp) chooseFolderToImport() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("importFolder.title"), i18n.T("path.selectFirstShort"), p.window)
+		return
+	}
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if uri != nil {
+			p.scanImportFolder(uri.Path())
+		}
+	}, p.window)
+}
+
+// scanImportFolder looks for the patch files under dir in the background,
+// checking which are installed already, and lists them.
+func (p *PatchApp) scanImportFolder(dir string) {
+	p.updateStatus(i18n.T("importFolder.scanning", dir))
+	go func() {
+		defer p.recoverPanic(i18n.T("op.importFolder"))
+		ctx := context.Background()
+		files, err := importdir.Find(ctx, dir)
+		if err != nil {
+			slog.Error("looking for patch files failed", "dir", dir, "err", err)
+			p.updateStatus(i18n.T("import.failed", err))
+			p.showError(err)
+			return
+		}
+		p.updateStatus(i18n.T("importFolder.found", len(files)))
+		if len(files) == 0 {
+			dialog.ShowInformation(i18n.T("importFolder.title"), i18n.T("importFolder.none", dir), p.window)
+			return
+		}
+		imagePack := gamepath.ImagePackPath(p.dnfPath)
+		installed := make([]bool, len(files))
+		for i, f := range files {
+			ok, err := importdir.Installed(ctx, f.Path, imagePack, p.hashes.Hash)
+			if err != nil {
+				slog.Warn("comparing patch file with the installed one failed", "path", f.Path, "err", err)
+			}
+			installed[i] = ok
+		}
+		p.showImportFolder(dir, files, installed)
+	}()
+}
+
+// showImportFolder lists files with their sizes, checked unless installed
+// says they are installed already, and installs the checked ones once
+// confirmed.
+func (p *PatchApp) showImportFolder(dir string, files []importdir.File, installed []bool) {
+	checks := make([]*widget.Check, len(files))
+	summary := widget.NewLabel("")
+	update := func() {
+		n, size := 0, int64(0)
+		for i, check := range checks {
+			if check != nil && check.Checked {
+				n++
+				size += files[i].Size
+			}
+		}
+		summary.SetText(i18n.T("importFolder.selected", n, formatSize(size)))
+	}
+	list := container.NewVBox()
+	for i, f := range files {
+		text := fmt.Sprintf("%s (%s)", f.Rel, formatSize(f.Size))
+		if installed[i] {
+			text = i18n.T("importFolder.installed", text)
+		}
+		checks[i] = widget.NewCheck(text, func(bool) { update() })
+		checks[i].SetChecked(!installed[i])
+		list.Add(checks[i])
+	}
+	update()
+	checkAll := func(checked bool) {
+		for _, check := range checks {
+			check.SetChecked(checked)
+		}
+	}
+	buttons := container.NewHBox(
+		widget.NewButton(i18n.T("common.selectAll"), func() { checkAll(true) }),
+		widget.NewButton(i18n.T("common.selectNone"), func() { checkAll(false) }),
+	)
+
+	header := container.NewVBox(wrappedLabel(i18n.T("importFolder.message", len(files), dir)), buttons)
+	d := dialog.NewCustomConfirm(i18n.T("importFolder.title"), i18n.T("patch.install"), i18n.T("common.cancel"),
+		container.NewBorder(header, summary, nil, nil, container.NewVScroll(list)),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			var selected []importdir.File
+			for i, check := range checks {
+				if check.Checked {
+					selected = append(selected, files[i])
+				}
+			}
+			if len(selected) > 0 {
+				go p.importFiles(selected)
+			}
+		}, p.window)
+	d.Resize(fyne.NewSize(600, 500))
+	d.Show()
+}
+
+// importFiles installs files one after the other like patch files dropped
+// into the watch folder, going on past those that fail, and tells the user
+// how it went. Patch bundles are imported as bundles. Giving up on the
+// password of an archive stops the import.
+func (p *PatchApp) importFiles(files []importdir.File) {
+	defer p.recoverPanic(i18n.T("op.importFolder"))
+	ctx, end := p.operations.begin(i18n.T("op.importFolder"))
+	defer end()
+	p.progress.Set(0)
+
+	var installed int
+	var failed []string
+	for i, f := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		p.updateStatus(i18n.T("importFolder.installing", i+1, len(files), f.Rel))
+		if bundle.IsBundle(f.Path) {
+			p.installBundle(f.Path)
+			installed++
+		} else if _, err := p.installPatchFile(ctx, f.Path, p.updateStatus); errors.Is(err, context.Canceled) {
+			break
+		} else if err != nil {
+			slog.Error("importing patch file failed", "path", f.Path, "err", err)
+			failed = append(failed, fmt.Sprintf("%s: %v", f.Rel, installError(err)))
+		} else {
+			installed++
+		}
+		p.progress.Set(float64(i+1) / float64(len(files)))
+	}
+	p.historyList.Refresh()
+	p.refreshBlockedBanner()
+
+	p.updateStatus(i18n.T("importFolder.done", installed, len(files)))
+	p.notify(p.config.Notify.Installs, p.historyTab, i18n.T("importFolder.title"), i18n.T("importFolder.done", installed, len(files)))
+	if len(failed) > 0 {
+		dialog.ShowInformation(i18n.T("importFolder.title"), i18n.T("importFolder.failed", strings.Join(failed, "\n")), p.window)
+	}
+}
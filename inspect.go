@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/patchdb"
+)
+
+// npkGroupDepth is how many folders deep entries are grouped, enough to
+// tell sprite/character/swordman from sprite/character/gunner.
+const npkGroupDepth = 3
+
+// previewFrames is how many frames of an IMG entry are previewed.
+const previewFrames = 4
+
+// createNPKContents shows the entry table of the NPK at path: a summary, the
+// folders it touches, suspicious entries and the full list, previewing the
+// selected entry. Checked entries can be extracted.
+func (p *PatchApp) createNPKContents(path string, a *npk.Archive) fyne.CanvasObject {
+	summary := widget.NewLabel(i18n.T("inspect.summary", len(a.Entries), formatSize(a.Size)))
+
+	var folders []string
+	for _, g := range a.Groups(npkGroupDepth) {
+		folders = append(folders, fmt.Sprintf("%s (%d)", g.Folder, g.Count))
+	}
+	foldersLabel := widget.NewLabel(strings.Join(folders, "\n"))
+	foldersLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(summary, foldersLabel)
+	if suspicious := a.Suspicious(); len(suspicious) > 0 {
+		var lines []string
+		for _, e := range suspicious {
+			lines = append(lines, fmt.Sprintf("%s: %s", e.Name, e.Problem))
+		}
+		warning := widget.NewLabel(i18n.T("inspect.suspicious", len(suspicious), strings.Join(lines, "\n")))
+		warning.Wrapping = fyne.TextWrapBreak
+		content.Add(warning)
+	}
+
+	checked := map[int]bool{}
+	extractButton := widget.NewButtonWithIcon(i18n.T("inspect.extractSelected"), theme.DownloadIcon(), nil)
+	extractButton.Disable()
+	updateExtract := func() {
+		extractButton.SetText(i18n.T("inspect.extractCount", len(checked)))
+		if len(checked) == 0 {
+			extractButton.Disable()
+		} else {
+			extractButton.Enable()
+		}
+	}
+
+	list := widget.NewList(
+		func() int { return len(a.Entries) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), widget.NewLabel(""), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			e := a.Entries[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(e.Name)
+			check := row.Objects[1].(*widget.Check)
+			check.OnChanged = nil
+			check.SetChecked(checked[id])
+			check.OnChanged = func(on bool) {
+				if on {
+					checked[id] = true
+				} else {
+					delete(checked, id)
+				}
+				updateExtract()
+			}
+			row.Objects[2].(*widget.Label).SetText(formatSize(int64(e.Size)))
+		},
+	)
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(500, 250))
+
+	selectAll := widget.NewCheck(i18n.T("common.selectAll"), func(on bool) {
+		for id := range a.Entries {
+			if on {
+				checked[id] = true
+			} else {
+				delete(checked, id)
+			}
+		}
+		list.Refresh()
+		updateExtract()
+	})
+	extractButton.OnTapped = func() {
+		var entries []npk.Entry
+		for id := range a.Entries {
+			if checked[id] {
+				entries = append(entries, a.Entries[id])
+			}
+		}
+		p.extractEntries(path, entries)
+	}
+	content.Add(container.NewHBox(selectAll, extractButton))
+
+	preview := container.NewStack(widget.NewLabel(i18n.T("inspect.selectToPreview")))
+	list.OnSelected = func(id widget.ListItemID) {
+		e := a.Entries[id]
+		preview.Objects = []fyne.CanvasObject{widget.NewLabel(i18n.T("inspect.loadingPreview"))}
+		preview.Refresh()
+		go func() {
+			preview.Objects = []fyne.CanvasObject{createIMGPreview(path, e)}
+			preview.Refresh()
+		}()
+	}
+
+	return container.NewBorder(content, preview, nil, nil, scroll)
+}
+
+// createIMGPreview renders the first frames of the IMG entry e of the NPK at
+// path, or explains why it cannot.
+func createIMGPreview(path string, e npk.Entry) fyne.CanvasObject {
+	images, err := decodeEntry(path, e)
+	if len(images) == 0 {
+		return widget.NewLabel(i18n.T("inspect.previewUnavailable", err))
+	}
+	frames := container.NewHBox()
+	for _, img := range images {
+		frame := canvas.NewImageFromImage(img)
+		frame.FillMode = canvas.ImageFillContain
+		frame.ScaleMode = canvas.ImageScalePixels
+		frame.SetMinSize(fyne.NewSize(96, 96))
+		frames.Add(frame)
+	}
+	return frames
+}
+
+func decodeEntry(path string, e npk.Entry) ([]image.Image, error) {
+	data, err := npk.ReadEntry(path, e)
+	if err != nil {
+		return nil, err
+	}
+	images, err := npk.DecodeIMG(data, previewFrames)
+	if err != nil {
+		slog.Info("decoding IMG failed", "npk", path, "entry", e.Name, "err", err)
+	}
+	return images, err
+}
+
+// createNPKPreview shows the first entry of the NPK at path that can be
+// decoded, standing in for missing screenshots. Only the first few entries
+// are tried.
+func createNPKPreview(path string, a *npk.Archive) (fyne.CanvasObject, bool) {
+	for i, e := range a.Entries {
+		if i == 10 {
+			break
+		}
+		if e.Problem != "" {
+			continue
+		}
+		if images, _ := decodeEntry(path, e); len(images) > 0 {
+			return container.NewVBox(createIMGPreview(path, e), widget.NewLabel(e.Name)), true
+		}
+	}
+	return nil, false
+}
+
+// formatSize formats a byte count for display.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// formatCount formats a count for display with thousands separators, as in
+// 4,200.
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// patchSize formats the catalogued size of patch, which may be unknown.
+func patchSize(patch patchdb.Patch) string {
+	if patch.SizeBytes <= 0 {
+		return i18n.T("patch.sizeUnknown")
+	}
+	return formatSize(patch.SizeBytes)
+}
+
+// installedNPK returns the NPK of patch in the game directory, if it is
+// there to inspect.
+func (p *PatchApp) installedNPK(filename string) (string, *npk.Archive, bool) {
+	if filename == "" || !p.pathUsable() {
+		return "", nil, false
+	}
+	path := filepath.Join(gamepath.ImagePackPath(p.dnfPath), filename)
+	if _, err := os.Stat(path); err != nil {
+		return "", nil, false
+	}
+	a, err := npk.Open(path)
+	if err != nil {
+		slog.Warn("reading NPK failed", "path", path, "err", err)
+		return "", nil, false
+	}
+	return path, a, true
+}
+
+// inspectNPK lets the user pick any NPK file and shows its entries.
+func (p *PatchApp) inspectNPK() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+
+		path := reader.URI().Path()
+		a, err := npk.Open(path)
+		if err != nil {
+			slog.Error("inspecting NPK failed", "path", path, "err", err)
+			if errors.Is(err, npk.ErrNotNPK) {
+				err = errors.New(i18n.T("inspect.notNPK", filepath.Base(path)))
+			}
+			p.showError(err)
+			return
+		}
+		d := dialog.NewCustom(filepath.Base(path), i18n.T("common.close"), p.createNPKContents(path, a), p.window)
+		d.Resize(fyne.NewSize(600, 500))
+		d.Show()
+	}, p.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".npk", ".NPK"}))
+	if p.pathUsable() {
+		if dir, err := storage.ListerForURI(storage.NewFileURI(gamepath.ImagePackPath(p.dnfPath))); err == nil {
+			open.SetLocation(dir)
+		}
+	}
+	open.Show()
+}
+
+// extractEntries asks for a folder and extracts entries of the NPK at path
+// into it.
+func (p *PatchApp) extractEntries(path string, entries []npk.Entry) {
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if dir == nil {
+			return
+		}
+		dest := dir.Path()
+		p.updateStatus(i18n.T("inspect.extracting", len(entries)))
+		p.progress.Set(0)
+		go func() {
+			defer p.recoverPanic(i18n.T("op.extract"))
+			_, end := p.operations.begin(i18n.T("op.extract"))
+			defer end()
+			if err := npk.Extract(path, entries, dest, p.setProgress); err != nil {
+				slog.Error("extracting IMG files failed", "npk", path, "dest", dest, "err", err)
+				p.updateStatus(i18n.T("inspect.extractFailed"))
+				p.showError(err)
+				return
+			}
+			p.updateStatus(i18n.T("inspect.extractedStatus", len(entries), dest))
+			dialog.ShowInformation(i18n.T("inspect.extractedTitle"), i18n.T("inspect.extracted", len(entries), dest), p.window)
+		}()
+	}, p.window)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/journal"
+	"dnf_patch/internal/patchdb"
+)
+
+// patchCache returns the cache of installed patch files used to reapply
+// patches after the game client overwrites them.
+func (p *PatchManager) patchCache() install.Cache {
+	return install.Cache{Dir: filepath.Join(p.cacheDir(), "patches")}
+}
+
+// checkInstalledPatches looks for patches reverted by a client update in the
+// background. When quiet is set nothing is shown unless problems are found.
+func (p *PatchApp) checkInstalledPatches(quiet bool) {
+	if !p.pathUsable() || len(p.installed.Patches()) == 0 {
+		if !quiet {
+			dialog.ShowInformation(i18n.T("installed.title"), i18n.T("installed.none"), p.window)
+		}
+		return
+	}
+
+	p.updateStatus(i18n.T("installed.checking"))
+	gameDir, installed, cache := p.dnfPath, p.installed.Patches(), p.patchCache()
+	go func() {
+		defer p.recoverPanic(i18n.T("op.checkInstalled"))
+		reverted, err := install.FindReverted(gameDir, installed, cache)
+		if err != nil {
+			slog.Error("checking installed patches failed", "path", gameDir, "err", err)
+			p.updateStatus(i18n.T("installed.checkFailed", err))
+			if !quiet {
+				p.showError(err)
+			}
+			return
+		}
+		if len(reverted) == 0 {
+			p.updateStatus(i18n.T("installed.intact"))
+			if !quiet {
+				dialog.ShowInformation(i18n.T("installed.title"), i18n.T("installed.intact"), p.window)
+			}
+			return
+		}
+		slog.Warn("installed patches were reverted", "path", gameDir, "count", len(reverted))
+		p.updateStatus(i18n.T("installed.reverted", len(reverted)))
+		p.showRevertedPatches(reverted)
+	}()
+}
+
+func (p *PatchApp) showRevertedPatches(reverted []install.Reverted) {
+	list := widget.NewList(
+		func() int { return len(reverted) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(
+				widget.NewLabel("Template"),
+				widget.NewLabel("Template"),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			nameLabel := box.Objects[0].(*widget.Label)
+			stateLabel := box.Objects[1].(*widget.Label)
+
+			r := reverted[id]
+			nameLabel.SetText(r.Patch.Filename)
+			state := i18n.T("installed.overwritten")
+			if r.Missing {
+				state = i18n.T("installed.missing")
+			}
+			if !r.Cached {
+				state += i18n.T("installed.notCached")
+			}
+			stateLabel.SetText(state)
+		},
+	)
+
+	content := container.NewBorder(
+		widget.NewLabel(i18n.T("installed.revertedHint")),
+		nil, nil, nil,
+		list,
+	)
+
+	d := dialog.NewCustomConfirm(i18n.T("installed.revertedTitle"),
+		i18n.T("installed.reapplyAll"),
+		i18n.T("common.close"),
+		content,
+		func(reapply bool) {
+			if reapply {
+				go p.reapplyPatches(reverted)
+			}
+		},
+		p.window)
+	d.Resize(fyne.NewSize(500, 400))
+	d.Show()
+}
+
+// reapplyPatches reinstalls reverted patches from the local patch cache
+// after backing up the files the client update put in their place.
+func (p *PatchApp) reapplyPatches(reverted []install.Reverted) {
+	defer p.recoverPanic(i18n.T("op.reapply"))
+	ctx, end := p.operations.begin(i18n.T("op.reapply"))
+	defer end()
+	imagepackPath := gamepath.ImagePackPath(p.dnfPath)
+
+	var toBackup []string
+	var reinstalled []install.Record
+	for _, r := range reverted {
+		if r.Cached && !r.Missing {
+			toBackup = append(toBackup, filepath.Join(imagepackPath, r.Patch.Filename))
+			reinstalled = append(reinstalled, r.Patch)
+		}
+	}
+
+	var backupID string
+	if len(toBackup) > 0 {
+		p.updateStatus(i18n.T("installed.backingUp"))
+		include := func(path string) bool {
+			for _, f := range toBackup {
+				if strings.EqualFold(f, path) {
+					return true
+				}
+			}
+			return false
+		}
+		b, err := p.createBackup(backup.Options{
+			Description: installDescription(reinstalled),
+			Type:        "auto",
+			Include:     include,
+			Context:     ctx,
+		})
+		if err != nil {
+			slog.Error("backup before reapplying patches failed", "path", p.dnfPath, "err", err)
+			p.updateStatus(i18n.T("backup.createFailed"))
+			p.showError(fmt.Errorf("backup before reapplying patches failed: %w", err))
+			return
+		}
+		p.backupList.Refresh()
+		backupID = b.ID
+	}
+
+	journalID, err := p.journal.Begin(journal.Entry{
+		Operation:   journal.Reapply,
+		GameDir:     p.dnfPath,
+		Description: i18n.T("installed.journalDescription", len(reverted)),
+		BackupID:    backupID,
+	})
+	if err != nil {
+		slog.Error("writing journal failed", "err", err)
+		p.showError(fmt.Errorf("writing journal failed: %w", err))
+		return
+	}
+
+	var failed []string
+	for i, r := range reverted {
+		p.progress.Set(float64(i) / float64(len(reverted)))
+		if !r.Cached {
+			slog.Warn("reverted patch is not cached", "patch", r.Patch.PatchID, "file", r.Patch.Filename)
+			failed = append(failed, i18n.T("installed.notInCache", r.Patch.Filename))
+			continue
+		}
+
+		p.updateStatus(i18n.T("installed.reapplying", r.Patch.Filename))
+		if err := install.Reapply(p.dnfPath, r.Patch, p.patchCache(), p.config.VerifyWrites); err != nil {
+			slog.Error("reapplying patch failed", "patch", r.Patch.PatchID, "file", r.Patch.Filename, "err", err)
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Patch.Filename, err))
+			continue
+		}
+
+		installed := r.Patch
+		installed.InstalledAt = time.Now().UTC()
+		if err := p.installed.Add(installed); err != nil {
+			slog.Error("saving installed patches failed", "patch", installed.PatchID, "err", err)
+		}
+		p.addToHistory(patchdb.Patch{ID: installed.PatchID, Name: installed.PatchName, Version: installed.Version}, "Reapplied")
+	}
+	p.journal.End(journalID)
+	p.progress.Set(1)
+	p.historyList.Refresh()
+
+	if len(failed) > 0 {
+		p.updateStatus(i18n.T("installed.reapplyFailedStatus", len(failed)))
+		dialog.ShowInformation(i18n.T("installed.reapplyTitle"),
+			i18n.T("installed.reapplyFailed", strings.Join(failed, "\n")),
+			p.window)
+		return
+	}
+	p.updateStatus(i18n.T("installed.reapplied", len(reverted)))
+}
+
+// uninstallPatch removes the patch of rec from the game and forgets it.
+func (p *PatchManager) uninstallPatch(rec install.Record) error {
+	if err := install.Uninstall(p.dnfPath, rec); err != nil {
+		return err
+	}
+	if err := p.installed.Remove(rec.Filename); err != nil {
+		slog.Error("saving installed patches failed", "patch", rec.PatchID, "err", err)
+	}
+	p.addToHistory(patchdb.Patch{ID: rec.PatchID, Name: rec.PatchName, Version: rec.Version}, "Uninstalled")
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// installState is whether a catalogue patch can be installed now.
+type installState int
+
+const (
+	installReady       installState = iota
+	installBlocked                  // on the repository's or the user's blocklist
+	installNoPath                   // no usable game folder
+	installNoFile                   // no local copy and nowhere to download it from
+	installSameVersion              // installed at this version already; installing again reinstalls it
+)
+
+// installability returns whether patch can be installed now and, unless it
+// can be without further ado, the reason to show under the install button.
+func (p *PatchApp) installability(patch patchdb.Patch) (installState, string) {
+	if e, ok := p.blocked(patch.ID, patch.Sha256); ok {
+		return installBlocked, i18n.T("installState.blocked", e.Reason)
+	}
+	if !p.pathUsable() {
+		return installNoPath, i18n.T("installState.noPath")
+	}
+	if p.catalogSource(patch) == "" {
+		if _, err := p.patchURL(patch); err != nil {
+			return installNoFile, i18n.T("installState.noFile", err)
+		}
+	}
+	for _, rec := range p.installed.Patches() {
+		if rec.PatchID == patch.ID && rec.Version == patch.Version {
+			return installSameVersion, i18n.T("installState.installed", patch.Version)
+		}
+	}
+	return installReady, ""
+}
+
+// updateInstallButton shows on button and reason whether patch can be
+// installed, offering to reinstall it when the same version is installed.
+func (p *PatchApp) updateInstallButton(patch patchdb.Patch, button *widget.Button, reason *widget.Label) {
+	state, text := p.installability(patch)
+	if state == installSameVersion {
+		button.SetText(i18n.T("patch.reinstall"))
+		button.SetIcon(theme.ViewRefreshIcon())
+	} else {
+		button.SetText(i18n.T("patch.install"))
+		button.SetIcon(theme.DownloadIcon())
+	}
+	setEnabled(button, state == installReady || state == installSameVersion)
+	reason.SetText(text)
+	if text == "" {
+		reason.Hide()
+	} else {
+		reason.Show()
+	}
+}
+
+// refreshDetails brings the install button of the open patch details up to
+// date with the game folder and the installed patches.
+func (p *PatchApp) refreshDetails() {
+	if refresh := p.detailsRefresh; refresh != nil {
+		refresh()
+	}
+}
@@ -0,0 +1,145 @@
+// Package autocategory suggests the category and tags of a patch, and tells
+// the character classes it changes, from the paths of the IMG files in its
+// NPK files, such as sprite/character/swordman/… for a Slayer patch. The
+// table of path prefixes is a JSON file shipped with DNF Patch so that it
+// can be extended without a new release.
+package autocategory
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"dnf_patch/internal/schema"
+)
+
+// minTagShare is the share of the matched IMG paths a rule needs for its
+// tags to be suggested, so that a few stray files do not add tags.
+const minTagShare = 0.1
+
+// tableFile is the version of the table file written by this build.
+var tableFile = schema.File{
+	Version:    1,
+	Migrations: []schema.Migration{schema.Unchanged},
+}
+
+// Rule maps the IMG paths starting with Prefix to a category, tags and
+// the character class they belong to.
+type Rule struct {
+	Prefix   string   `json:"prefix"` // matched without regard to case, with / between folders
+	Category string   `json:"category"`
+	Tags     []string `json:"tags,omitempty"`
+	Class    string   `json:"class,omitempty"` // "" for paths of no one class
+}
+
+// Table is the rules, of which the one with the longest matching prefix
+// applies to each path.
+type Table struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Rules         []Rule `json:"rules"`
+}
+
+// Load reads the table at path. Errors reading the file are returned as
+// they are, so os.IsNotExist applies.
+func Load(path string) (Table, error) {
+	data, err := tableFile.Read(path)
+	if err != nil {
+		return Table{}, err
+	}
+	var t Table
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+// Suggestion is the category and tags suggested for a patch.
+type Suggestion struct {
+	Category string   // "" when no path matched
+	Tags     []string // most common first
+}
+
+// Suggest returns the category of the most paths and the tags of the rules
+// matching a fair share of them. Backslashes in paths are taken as folder
+// separators.
+func (t Table) Suggest(paths []string) Suggestion {
+	counts, matched := t.count(paths)
+	if matched == 0 {
+		return Suggestion{}
+	}
+
+	categories := make(map[string]int)
+	for i, n := range counts {
+		categories[t.Rules[i].Category] += n
+	}
+	var s Suggestion
+	most := 0
+	for category, n := range categories {
+		if category != "" && (n > most || n == most && category < s.Category) {
+			s.Category, most = category, n
+		}
+	}
+
+	// The rules in order of how many paths they matched, then as listed
+	order := make([]int, len(t.Rules))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return counts[order[a]] > counts[order[b]] })
+	seen := make(map[string]bool)
+	for _, i := range order {
+		if counts[i] == 0 || float64(counts[i]) < minTagShare*float64(matched) {
+			break
+		}
+		for _, tag := range t.Rules[i].Tags {
+			if !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				s.Tags = append(s.Tags, tag)
+			}
+		}
+	}
+	return s
+}
+
+// Classes returns the classes of the rules matching paths, those of the
+// most paths first.
+func (t Table) Classes(paths []string) []string {
+	counts, _ := t.count(paths)
+	classes := make(map[string]int)
+	for i, n := range counts {
+		if n > 0 && t.Rules[i].Class != "" {
+			classes[t.Rules[i].Class] += n
+		}
+	}
+	list := make([]string, 0, len(classes))
+	for class := range classes {
+		list = append(list, class)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if classes[list[i]] != classes[list[j]] {
+			return classes[list[i]] > classes[list[j]]
+		}
+		return list[i] < list[j]
+	})
+	return list
+}
+
+// count returns how many of paths each rule applies to, and how many paths
+// a rule applies to at all.
+func (t Table) count(paths []string) ([]int, int) {
+	counts := make([]int, len(t.Rules))
+	matched := 0
+	for _, p := range paths {
+		p = strings.ToLower(strings.ReplaceAll(p, "\\", "/"))
+		best := -1
+		for i, r := range t.Rules {
+			prefix := strings.ToLower(r.Prefix)
+			if prefix != "" && strings.HasPrefix(p, prefix) && (best < 0 || len(prefix) > len(t.Rules[best].Prefix)) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			counts[best]++
+			matched++
+		}
+	}
+	return counts, matched
+}
@@ -0,0 +1,86 @@
+package autocategory
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	table := Table{Rules: []Rule{
+		{Prefix: "sprite/character/swordman/", Category: "Slayer", Tags: []string{"Slayer"}},
+		{Prefix: "sprite/character/swordman/effect/", Category: "Slayer", Tags: []string{"Slayer", "effects"}},
+		{Prefix: "sprite/interface", Category: "UI", Tags: []string{"UI"}},
+		{Prefix: "sounds/", Category: "Sounds", Tags: []string{"sounds"}},
+	}}
+	tests := []struct {
+		name  string
+		paths []string
+		want  Suggestion
+	}{
+		{"none", []string{"sprite/map/town.img"}, Suggestion{}},
+		{"longest prefix", []string{
+			`Sprite\Character\Swordman\Effect\wave.img`,
+			"sprite/character/swordman/effect/moon.img",
+			"sprite/character/swordman/equipment/coat.img",
+		}, Suggestion{"Slayer", []string{"Slayer", "effects"}}},
+		{"most paths", []string{
+			"sprite/interface/hud.img",
+			"sprite/interface2/inventory.img",
+			"sounds/click.ogg",
+		}, Suggestion{"UI", []string{"UI", "sounds"}}},
+		{"stray files", append(repeat("sprite/interface/hud.img", 20), "sounds/click.ogg"),
+			Suggestion{"UI", []string{"UI"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := table.Suggest(tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClasses(t *testing.T) {
+	table := Table{Rules: []Rule{
+		{Prefix: "sprite/character/swordman/", Category: "Slayer", Class: "Slayer"},
+		{Prefix: "sprite/character/fighter/", Category: "Fighter", Class: "Fighter"},
+		{Prefix: "sprite/interface", Category: "UI"},
+	}}
+	tests := []struct {
+		paths []string
+		want  []string
+	}{
+		{[]string{"sprite/interface/hud.img"}, []string{}},
+		{[]string{"sprite/character/swordman/a.img", "sprite/interface/hud.img"}, []string{"Slayer"}},
+		{[]string{"sprite/character/fighter/a.img", "sprite/character/swordman/a.img", "sprite/character/fighter/b.img"}, []string{"Fighter", "Slayer"}},
+	}
+	for _, tt := range tests {
+		if got := table.Classes(tt.paths); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Classes(%v) = %v, want %v", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func repeat(s string, n int) []string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = s
+	}
+	return list
+}
+
+func TestShippedTable(t *testing.T) {
+	table, err := Load(filepath.Join("..", "..", "patches", "autocategory.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := table.Suggest([]string{"sprite/character/swordman/equipment/avatar/skin/sm_body0000.img"})
+	if got.Category != "鬼剑士" {
+		t.Errorf("Suggest() of a Slayer skin = %+v", got)
+	}
+	paths := []string{"sprite/character/atgunner/skin.img"}
+	if got, want := table.Classes(paths), []string{"女神枪手"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Classes(%v) = %v, want %v", paths, got, want)
+	}
+}
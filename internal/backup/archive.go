@@ -0,0 +1,351 @@
+package backup
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/relpath"
+)
+
+// The compression presets of Settings.Compression, fastest first. NPK files
+// hold compressed images already, so compressing them again saves little
+// for the time it takes; see BenchmarkCreate.
+const (
+	CompressionStore    = "store"    // files copied as they are
+	CompressionFast     = "fast"     // fastest level of Settings.Archive
+	CompressionBalanced = "balanced" // default level
+	CompressionMax      = "max"      // best compression
+)
+
+// Compressions are the compression presets, fastest first.
+var Compressions = []string{CompressionStore, CompressionFast, CompressionBalanced, CompressionMax}
+
+// The formats of the files of a backup, recorded in Backup.Format. Every
+// format ever written stays restorable.
+const (
+	FormatFolder = ""        // the files as they are in the backup folder
+	FormatZip    = "zip"     // the files in archiveName in the backup folder
+	FormatTarZst = "tar.zst" // the files in tarZstName in the backup folder
+)
+
+// Archives are the formats compressed backups can be written in, in
+// Settings.Archive.
+var Archives = []string{FormatZip, FormatTarZst}
+
+// compression returns the format and level of the backups of a compression
+// preset and archive format: FormatFolder for CompressionStore, and for
+// presets it does not know so that settings from a newer version still make
+// backups. An unknown archive format is FormatZip.
+func compression(preset, archive string) (format string, level int) {
+	levels := map[string]int{
+		CompressionFast:     flate.BestSpeed,
+		CompressionBalanced: flate.DefaultCompression,
+		CompressionMax:      flate.BestCompression,
+	}
+	if archive == FormatTarZst {
+		format, levels = FormatTarZst, map[string]int{
+			CompressionFast:     int(zstd.SpeedFastest),
+			CompressionBalanced: int(zstd.SpeedDefault),
+			CompressionMax:      int(zstd.SpeedBestCompression),
+		}
+	}
+	level, ok := levels[preset]
+	if !ok {
+		return FormatFolder, 0
+	}
+	if format == "" {
+		format = FormatZip
+	}
+	return format, level
+}
+
+// archiveName and tarZstName are the archives in the backup folder holding
+// the files of FormatZip and FormatTarZst backups, by their paths.
+const (
+	archiveName = "files.zip"
+	tarZstName  = "files.tar.zst"
+)
+
+// archiveWriter writes the files of a FormatZip or FormatTarZst backup.
+type archiveWriter struct {
+	path string
+	f    *os.File
+	zw   *zip.Writer   // nil for FormatTarZst
+	enc  *zstd.Encoder // nil for FormatZip
+	tw   *tar.Writer   // writing to enc
+}
+
+// createArchive creates the archive of format at path, compressing files at
+// level.
+func createArchive(path, format string, level int) (*archiveWriter, error) {
+	f, err := fsutil.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &archiveWriter{path: path, f: f}
+	if format == FormatTarZst {
+		if a.enc, err = zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.EncoderLevel(level))); err != nil {
+			f.Close()
+			return nil, err
+		}
+		a.tw = tar.NewWriter(a.enc)
+		return a, nil
+	}
+	a.zw = zip.NewWriter(f)
+	a.zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+	return a, nil
+}
+
+// add writes the file at src to the archive as rel, stopping once ctx is
+// cancelled.
+func (a *archiveWriter) add(ctx context.Context, src string, rel relpath.Path, modified time.Time) error {
+	source, err := os.Open(fsutil.LongPath(src))
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	var w io.Writer
+	if a.tw != nil {
+		info, err := source.Stat()
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: string(rel), Size: info.Size(), Mode: 0644, ModTime: modified}
+		if err := a.tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		w = a.tw
+	} else if w, err = a.zw.CreateHeader(&zip.FileHeader{Name: string(rel), Method: zip.Deflate, Modified: modified}); err != nil {
+		return err
+	}
+	if _, err := fsutil.Copy(ctx, w, source, nil); err != nil {
+		return &fsutil.CopyError{Src: src, Dst: a.path, Err: err}
+	}
+	return nil
+}
+
+// close finishes the archive and flushes it to disk.
+func (a *archiveWriter) close() error {
+	var err error
+	if a.tw != nil {
+		if err = a.tw.Close(); err == nil {
+			err = a.enc.Close()
+		}
+	} else {
+		err = a.zw.Close()
+	}
+	if err == nil {
+		err = a.f.Sync()
+	}
+	if closeErr := a.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// backupFiles reads the files of a backup in whichever format it has.
+type backupFiles struct {
+	dir     string
+	zr      *zip.ReadCloser            // nil unless FormatZip
+	entries map[relpath.Path]*zip.File // in zr
+	tar     *tarStream                 // nil unless FormatTarZst
+}
+
+// openFiles opens the files of backup, which must be closed.
+func (s *Store) openFiles(backup Backup) (*backupFiles, error) {
+	files := &backupFiles{dir: s.Dir(backup.ID)}
+	switch backup.Format {
+	case FormatFolder:
+		return files, nil
+	case FormatZip:
+		zr, err := zip.OpenReader(fsutil.LongPath(filepath.Join(files.dir, archiveName)))
+		if err != nil {
+			return nil, err
+		}
+		files.zr = zr
+		files.entries = make(map[relpath.Path]*zip.File, len(zr.File))
+		for _, f := range zr.File {
+			files.entries[relpath.Canonical(f.Name)] = f
+		}
+		return files, nil
+	case FormatTarZst:
+		stream, err := openTarStream(filepath.Join(files.dir, tarZstName))
+		if err != nil {
+			return nil, err
+		}
+		files.tar = stream
+		return files, nil
+	}
+	return nil, fmt.Errorf("backup %s is of the unknown format %q, made by a newer version", backup.ID, backup.Format)
+}
+
+// archived reports whether the files are in an archive rather than the
+// backup folder.
+func (f *backupFiles) archived() bool {
+	return f.zr != nil || f.tar != nil
+}
+
+// open opens the file of the backup at path.
+func (f *backupFiles) open(path relpath.Path) (io.ReadCloser, error) {
+	if !f.archived() {
+		return os.Open(fsutil.LongPath(path.In(f.dir)))
+	}
+	r, _, _, err := f.entry(path)
+	return r, err
+}
+
+// entry opens the archived file at path, returning its size and when it
+// was last modified.
+func (f *backupFiles) entry(path relpath.Path) (io.ReadCloser, int64, time.Time, error) {
+	if f.tar != nil {
+		hdr, err := f.tar.next(path)
+		if err != nil {
+			return nil, 0, time.Time{}, err
+		}
+		return io.NopCloser(f.tar.tr), hdr.Size, hdr.ModTime, nil
+	}
+	entry, ok := f.entries[path]
+	if !ok {
+		return nil, 0, time.Time{}, fmt.Errorf("%s is missing from %s: %w", path, filepath.Join(f.dir, archiveName), os.ErrNotExist)
+	}
+	r, err := entry.Open()
+	return r, int64(entry.UncompressedSize64), entry.Modified, err
+}
+
+// copyTo copies the file of the backup at path to dst as
+// fsutil.CopyFileContext does.
+func (f *backupFiles) copyTo(ctx context.Context, path relpath.Path, dst string, progress func(written, total int64)) error {
+	if !f.archived() {
+		return fsutil.CopyFileContext(ctx, path.In(f.dir), dst, progress)
+	}
+	if err := f.extract(ctx, path, dst, progress); err != nil {
+		return &fsutil.CopyError{Src: string(path), Dst: dst, Err: err}
+	}
+	return nil
+}
+
+// extract writes the archived file at path to dst, removing what was
+// written when it fails.
+func (f *backupFiles) extract(ctx context.Context, path relpath.Path, dst string, progress func(written, total int64)) error {
+	src, size, modified, err := f.entry(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(fsutil.LongPath(filepath.Dir(dst)), 0755); err != nil {
+		return err
+	}
+	out, err := fsutil.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	var report func(int64)
+	if progress != nil {
+		report = func(written int64) { progress(written, size) }
+	}
+	_, err = fsutil.Copy(ctx, out, src, report)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(fsutil.LongPath(dst), modified, modified)
+	}
+	if err != nil {
+		os.Remove(fsutil.LongPath(dst))
+	}
+	return err
+}
+
+// Close closes the archive of the backup, if it has one.
+func (f *backupFiles) Close() error {
+	switch {
+	case f.zr != nil:
+		return f.zr.Close()
+	case f.tar != nil:
+		return f.tar.Close()
+	}
+	return nil
+}
+
+// tarStream reads the files of a FormatTarZst backup. A tar archive can
+// only be read from the start, so it reads on from the last file asked for
+// and starts over when an earlier one is asked for. Verify and Restore ask
+// for the files in the order they were archived, reading it once each.
+type tarStream struct {
+	path string
+	f    *os.File
+	dec  *zstd.Decoder
+	tr   *tar.Reader // nil until read from the start
+}
+
+func openTarStream(path string) (*tarStream, error) {
+	f, err := os.Open(fsutil.LongPath(path))
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &tarStream{path: path, f: f, dec: dec}, nil
+}
+
+// next moves to the file at path, whose content tr then reads.
+func (t *tarStream) next(path relpath.Path) (*tar.Header, error) {
+	fromStart := t.tr == nil
+	for {
+		if t.tr == nil {
+			if err := t.rewind(); err != nil {
+				return nil, err
+			}
+		}
+		hdr, err := t.tr.Next()
+		if err == io.EOF && !fromStart {
+			t.tr, fromStart = nil, true
+			continue
+		}
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s is missing from %s: %w", path, t.path, os.ErrNotExist)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", t.path, err)
+		}
+		if relpath.Canonical(hdr.Name) == path {
+			return hdr, nil
+		}
+	}
+}
+
+// rewind starts reading the archive from the start.
+func (t *tarStream) rewind() error {
+	if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := t.dec.Reset(t.f); err != nil {
+		return err
+	}
+	t.tr = tar.NewReader(t.dec)
+	return nil
+}
+
+func (t *tarStream) Close() error {
+	t.dec.Close()
+	return t.f.Close()
+}
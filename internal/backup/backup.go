@@ -0,0 +1,900 @@
+// Package backup creates, verifies and restores backups of the NPK files of
+// a DNF installation.
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/relpath"
+	"dnf_patch/internal/schema"
+)
+
+type File struct {
+	Path relpath.Path `json:"path"`
+	Hash string       `json:"hash"`
+	Size int64        `json:"size"`
+}
+
+type Backup struct {
+	ID          string    `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Description string    `json:"description"`
+	Files       []File    `json:"files"`
+	Links       []Link    `json:"links,omitempty"`
+	Type        string    `json:"type"` // auto, manual
+	GameVersion string    `json:"gameVersion"`
+	Tags        []string  `json:"tags,omitempty"`   // the user's own, such as "good-state"
+	TotalSize   int64     `json:"totalSize"`        // bytes the backup takes on disk
+	Format      string    `json:"format,omitempty"` // how Files are stored, see FormatFolder
+}
+
+// Matches reports whether the description, a tag or the ID of b contains
+// query, ignoring case. An empty query matches every backup.
+func (b Backup) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	for _, s := range append([]string{b.Description, b.ID}, b.Tags...) {
+		if strings.Contains(strings.ToLower(s), query) {
+			return true
+		}
+	}
+	return false
+}
+
+type Settings struct {
+	AutoBackup         bool   `json:"autoBackup"`
+	BackupInterval     int    `json:"backupInterval"` // in seconds
+	MaxBackups         int    `json:"maxBackups"`
+	BackupPath         string `json:"backupPath"`
+	CompressionEnabled bool   `json:"compressionEnabled"`           // never used; see Compression
+	Compression        string `json:"compression,omitempty"`        // preset of new backups, see Compressions; "" for CompressionStore
+	Archive            string `json:"archive,omitempty"`            // format of compressed backups, see Archives; "" for FormatZip
+	IdleMinutes        int    `json:"idleMinutes,omitempty"`        // auto backups wait until the user is idle this long, 0 not to wait
+	DefaultDescription string `json:"defaultDescription,omitempty"` // template of manual backup descriptions, see ExpandDescription
+	Schedule           string `json:"schedule,omitempty"`           // when auto backups run, see Schedules; "" for ScheduleInterval
+	ScheduleTime       string `json:"scheduleTime,omitempty"`       // time of day of daily and weekly backups, such as "04:00"
+	ScheduleDay        int    `json:"scheduleDay,omitempty"`        // day of weekly backups, a time.Weekday
+}
+
+type Database struct {
+	Backups  []Backup `json:"backups"`
+	Settings Settings `json:"settings"`
+	// LastAutoBackup is when an auto backup last ran, successful or not,
+	// which the schedule counts from, see Settings.NextRun
+	LastAutoBackup time.Time `json:"lastAutoBackup"`
+}
+
+// The range of Settings.MaxBackups.
+const (
+	MinMaxBackups = 1
+	MaxMaxBackups = 100
+)
+
+// ClampMaxBackups returns n within MinMaxBackups and MaxMaxBackups. A count
+// that is not positive, which older versions saved from a mistyped entry,
+// becomes the default rather than 1, so that pruning does not remove every
+// backup but one.
+func ClampMaxBackups(n int) int {
+	switch {
+	case n < MinMaxBackups:
+		return DefaultSettings().MaxBackups
+	case n > MaxMaxBackups:
+		return MaxMaxBackups
+	}
+	return n
+}
+
+// DefaultSettings returns the settings of a profile without backup.json.
+func DefaultSettings() Settings {
+	return Settings{
+		AutoBackup:         true,
+		BackupInterval:     3600, // 1 hour
+		MaxBackups:         10,
+		BackupPath:         "backups",
+		CompressionEnabled: true,
+		Compression:        CompressionStore,
+		Schedule:           ScheduleInterval,
+		ScheduleTime:       DefaultScheduleTime,
+		ScheduleDay:        int(time.Sunday),
+	}
+}
+
+// Progress is called after each file with the number of files handled so
+// far and the total.
+type Progress func(done, total int)
+
+// Phase is the step of a restore a RestoreStatus reports on.
+type Phase int
+
+const (
+	Comparing Phase = iota // hashing the game files to find those to skip
+	Verifying              // hashing the backup files
+	Restoring              // copying them into the game
+)
+
+// RestoreStatus tells how far a restore has got in its current phase.
+type RestoreStatus struct {
+	Phase    Phase
+	File     int    // the file being handled, counting from 1
+	Files    int    // the number of files
+	Path     string // the file being handled, relative to the game folder
+	Done     int64  // bytes handled in this phase so far
+	Total    int64  // bytes to handle in this phase
+	FileDone bool   // Path has just been completed
+	Same     bool   // Comparing: Path already matches the backup
+}
+
+// RestoreProgress is called repeatedly while a file is read or copied and
+// once more when it is complete.
+type RestoreProgress func(RestoreStatus)
+
+// RestoreOptions adjust a restore.
+type RestoreOptions struct {
+	Progress RestoreProgress
+	// Verify hashes every file again once it is copied into the game.
+	Verify bool
+	// Retry is asked whether to copy a file that failed verification once
+	// more; nil gives up on the first mismatch.
+	Retry func(path string, err error) bool
+	// CurrentHash, when set, returns the SHA-256 of a file in the game,
+	// such as HashCache.Hash does. Files that already match the backup
+	// are then neither verified nor copied. nil restores every file.
+	CurrentHash func(path string) (string, error)
+}
+
+// RestoreSummary counts the files of a restore.
+type RestoreSummary struct {
+	Restored  int // copied into the game
+	Unchanged int // skipped because the game file already matched
+	// LinkErrors explain the links of the backup that were not recreated.
+	LinkErrors []error
+}
+
+// Store holds the backups of one profile. The database is kept in
+// backup/backup.json below dir and the backed up files below
+// dir/Settings.BackupPath. A Store is safe for concurrent use, so the
+// auto-backup goroutine and the UI can share it.
+type Store struct {
+	mu       sync.Mutex
+	db       Database
+	dir      string
+	creating map[string]bool // IDs of backups being written
+	newer    error           // from Load when backup.json is of a newer version
+	// Delete removes the folders of pruned backups; os.RemoveAll when nil.
+	// It is set before the store is used.
+	Delete func(path string) error
+}
+
+// NewStore returns an empty store for the profile directory dir.
+func NewStore(dir string) *Store {
+	return &Store{
+		db:       Database{Settings: DefaultSettings()},
+		dir:      dir,
+		creating: make(map[string]bool),
+	}
+}
+
+func (s *Store) databasePath() string {
+	return filepath.Join(s.dir, "backup", "backup.json")
+}
+
+// databaseFile is the format of backup.json. Version 1 only added the
+// version itself.
+var databaseFile = schema.File{
+	Version:    1,
+	Migrations: []schema.Migration{schema.Unchanged},
+}
+
+type databaseDocument struct {
+	SchemaVersion int `json:"schemaVersion"`
+	Database
+}
+
+// Load reads backup.json, keeping the default settings when it does not
+// exist yet.
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.newer = nil
+	data, err := databaseFile.Read(s.databasePath())
+	if os.IsNotExist(err) {
+		s.db = Database{Settings: DefaultSettings()}
+		return nil
+	}
+	if errors.As(err, new(*schema.NewerError)) {
+		s.newer = err
+	}
+	if err != nil {
+		return err
+	}
+	var doc databaseDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	db := doc.Database
+	db.Settings.MaxBackups = ClampMaxBackups(db.Settings.MaxBackups)
+	for i := range db.Backups {
+		db.Backups[i].Files = dedupe(db.Backups[i].Files)
+		// Backups made before their size was recorded
+		if db.Backups[i].TotalSize == 0 && db.Backups[i].Format == FormatFolder {
+			db.Backups[i].TotalSize = totalSize(db.Backups[i].Files)
+		}
+	}
+	s.db = db
+	return nil
+}
+
+// totalSize returns the bytes of files.
+func totalSize(files []File) int64 {
+	var n int64
+	for _, f := range files {
+		n += f.Size
+	}
+	return n
+}
+
+// dedupe keeps the first of the files with the same path. Backups made before
+// paths were canonical can list one file under several spellings.
+func dedupe(files []File) []File {
+	seen := make(map[relpath.Path]bool, len(files))
+	out := files[:0]
+	for _, f := range files {
+		if !seen[f.Path] {
+			seen[f.Path] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// save writes backup.json, unless it was written by a newer version. The
+// caller must hold s.mu.
+func (s *Store) save() error {
+	if s.newer != nil {
+		return s.newer
+	}
+	data, err := json.MarshalIndent(databaseDocument{databaseFile.Version, s.db}, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.databasePath()), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.databasePath(), data, 0644)
+}
+
+// Backups returns a copy of the recorded backups, oldest first.
+func (s *Store) Backups() []Backup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Backup(nil), s.db.Backups...)
+}
+
+func (s *Store) Settings() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Settings
+}
+
+// UpdateSettings applies change to the settings and saves them.
+func (s *Store) UpdateSettings(change func(settings *Settings)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(&s.db.Settings)
+	return s.save()
+}
+
+// LastAutoBackup returns when an auto backup last ran, zero before the
+// first.
+func (s *Store) LastAutoBackup() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.LastAutoBackup
+}
+
+// SetLastAutoBackup records that an auto backup ran at t and saves it.
+func (s *Store) SetLastAutoBackup(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.db.LastAutoBackup = t
+	return s.save()
+}
+
+// TotalSize returns the bytes of all backups.
+func (s *Store) TotalSize() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, b := range s.db.Backups {
+		n += b.TotalSize
+	}
+	return n
+}
+
+// SetDetails changes the description and tags of the backup with the given
+// ID and saves them.
+func (s *Store) SetDetails(id, description string, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.db.Backups {
+		if s.db.Backups[i].ID == id {
+			s.db.Backups[i].Description = description
+			s.db.Backups[i].Tags = tags
+			return s.save()
+		}
+	}
+	return fmt.Errorf("backup %s not found", id)
+}
+
+// Dir returns the directory holding the files of the backup with the given
+// ID.
+func (s *Store) Dir(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backupDir(id)
+}
+
+// backupDir is Dir for callers holding s.mu.
+func (s *Store) backupDir(id string) string {
+	return filepath.Join(s.resolve(s.db.Settings.BackupPath), id)
+}
+
+// resolve returns where the backup path path lies: path itself when it is
+// absolute, otherwise below the profile directory.
+func (s *Store) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
+
+// Overlaps reports whether the backup path is inside gameDir or gameDir
+// inside the backup path. Backups there can be deleted by a repair of the
+// game, and backing up the game could take in earlier backups, which Create
+// leaves out.
+func (s *Store) Overlaps(gameDir string) bool {
+	if gameDir == "" {
+		return false
+	}
+	root := s.Dir("")
+	return fsutil.Within(root, gameDir) || fsutil.Within(gameDir, root)
+}
+
+// Move moves the backup folders to path, relative to the profile directory
+// or absolute, and records it as Settings.BackupPath. It fails while a
+// backup is being created and when path is inside the current backup path,
+// or holds files already.
+func (s *Store) Move(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.creating) > 0 {
+		return errors.New("a backup is being created")
+	}
+	from, to := s.backupDir(""), s.resolve(path)
+	if fsutil.Within(to, from) || fsutil.Within(from, to) {
+		return fmt.Errorf("%s cannot hold the backups of %s", to, from)
+	}
+	if entries, err := os.ReadDir(to); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", to)
+	}
+	if _, err := os.Stat(from); err == nil {
+		// Windows cannot rename a folder onto an empty one
+		os.Remove(to)
+		if err := fsutil.MoveDir(from, to); err != nil {
+			return err
+		}
+	}
+	s.db.Settings.BackupPath = path
+	return s.save()
+}
+
+// Find returns the backup with the given ID.
+func (s *Store) Find(id string) (Backup, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, backup := range s.db.Backups {
+		if backup.ID == id {
+			return backup, true
+		}
+	}
+	return Backup{}, false
+}
+
+// Options describe a backup to create.
+type Options struct {
+	Description string
+	Type        string // auto, manual
+	GameVersion string
+	// Include selects the NPK files to back up by path; nil backs up all
+	// of them.
+	Include func(path string) bool
+	// FollowLinks backs up what the links below imagepack2 point to
+	// rather than only recording them. imagepack2 itself is always
+	// followed.
+	FollowLinks bool
+	Progress    Progress
+	// Context cancels the backup, also in the middle of a file; nil never
+	// cancels it.
+	Context context.Context
+}
+
+// Create backs up the NPK files of the game at gameDir, records the backup
+// and removes the oldest ones beyond Settings.MaxBackups. The files are
+// copied, or archived in the format of Settings.Archive when
+// Settings.Compression compresses them. The store is only locked while the
+// backup is recorded, not while the files are copied. The files of a failed
+// or cancelled backup are removed.
+func (s *Store) Create(gameDir string, opts Options) (_ Backup, err error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Collect files to backup
+	sources, links, err := collect(ctx, gameDir, s.Dir(""), opts)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backup := Backup{
+		Timestamp:   time.Now().UTC(),
+		Description: opts.Description,
+		Links:       links,
+		Type:        opts.Type,
+		GameVersion: opts.GameVersion,
+	}
+	backupDir, err := s.reserveDir(&backup)
+	if err != nil {
+		return Backup{}, err
+	}
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.creating, backup.ID)
+		if err != nil {
+			os.RemoveAll(fsutil.LongPath(backupDir))
+		}
+	}()
+
+	var archive *archiveWriter
+	settings := s.Settings()
+	if format, level := compression(settings.Compression, settings.Archive); format != FormatFolder {
+		backup.Format = format
+		name := archiveName
+		if format == FormatTarZst {
+			name = tarZstName
+		}
+		if archive, err = createArchive(filepath.Join(backupDir, name), format, level); err != nil {
+			return Backup{}, err
+		}
+		// Closed before the folder of a failed backup is removed
+		defer archive.f.Close()
+	}
+
+	for i, src := range sources {
+		if err := ctx.Err(); err != nil {
+			return Backup{}, err
+		}
+
+		hash, err := fsutil.HashFile(src.path)
+		if err != nil {
+			return Backup{}, err
+		}
+		info, err := os.Stat(fsutil.LongPath(src.path))
+		if err != nil {
+			return Backup{}, err
+		}
+		if archive != nil {
+			err = archive.add(ctx, src.path, src.rel, info.ModTime())
+		} else {
+			err = fsutil.CopyFileContext(ctx, src.path, src.rel.In(backupDir), nil)
+		}
+		if err != nil {
+			return Backup{}, err
+		}
+
+		backup.Files = append(backup.Files, File{
+			Path: src.rel,
+			Hash: hash,
+			Size: info.Size(),
+		})
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(sources))
+		}
+	}
+
+	backup.TotalSize = totalSize(backup.Files)
+	if archive != nil {
+		if err := archive.close(); err != nil {
+			return Backup{}, err
+		}
+		if info, err := os.Stat(fsutil.LongPath(archive.path)); err == nil {
+			backup.TotalSize = info.Size()
+		}
+	}
+
+	// A backup that cannot be recorded is dropped like any failed one,
+	// and the pruned ones are only removed once it is recorded
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recorded := s.db.Backups
+	s.db.Backups = append(recorded[:len(recorded):len(recorded)], backup)
+	pruned := s.prune()
+	if err := s.save(); err != nil {
+		s.db.Backups = recorded
+		return Backup{}, err
+	}
+	s.remove(pruned)
+	return backup, nil
+}
+
+// reserveDir picks an unused ID for backup from its timestamp and creates
+// its directory, so concurrent backups never share one.
+func (s *Store) reserveDir(backup *Backup) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := fmt.Sprintf("backup_%s", backup.Timestamp.Local().Format("20060102_150405"))
+	backup.ID = base
+	for n := 2; ; n++ {
+		dir := s.backupDir(backup.ID)
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			s.creating[backup.ID] = true
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		backup.ID = fmt.Sprintf("%s_%d", base, n)
+	}
+}
+
+// Unrecorded returns the folders in the backup path that no recorded backup
+// owns: backups the tool stopped writing, or whose records were deleted.
+func (s *Store) Unrecorded() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unrecorded()
+}
+
+// unrecorded is Unrecorded for callers holding s.mu.
+func (s *Store) unrecorded() ([]string, error) {
+	root := s.backupDir("")
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[string]bool)
+	for _, backup := range s.db.Backups {
+		recorded[backup.ID] = true
+	}
+	var dirs []string
+	for _, entry := range entries {
+		id := entry.Name()
+		if entry.IsDir() && strings.HasPrefix(id, "backup_") && !recorded[id] && !s.creating[id] {
+			dirs = append(dirs, filepath.Join(root, id))
+		}
+	}
+	return dirs, nil
+}
+
+// RemoveIncomplete deletes the folders of backups that were never recorded
+// because the tool stopped while writing them.
+func (s *Store) RemoveIncomplete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dirs, err := s.unrecorded()
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import records the NPK files in dir, a backup_<time> folder older versions
+// of the tool left in the game directory, as a backup of the imagepack2 of
+// the game at gameDir taken at timestamp. The files are copied; dir is left
+// alone.
+func (s *Store) Import(gameDir, dir string, timestamp time.Time, description string) (_ Backup, err error) {
+	entries, err := os.ReadDir(fsutil.LongPath(dir))
+	if err != nil {
+		return Backup{}, err
+	}
+	relDir, err := filepath.Rel(gameDir, gamepath.ImagePackPath(gameDir))
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backup := Backup{Timestamp: timestamp.UTC(), Description: description, Type: "auto"}
+	backupDir, err := s.reserveDir(&backup)
+	if err != nil {
+		return Backup{}, err
+	}
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.creating, backup.ID)
+		if err != nil {
+			os.RemoveAll(fsutil.LongPath(backupDir))
+		}
+	}()
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".npk") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		relPath := relpath.Canonical(filepath.Join(relDir, entry.Name()))
+		hash, err := fsutil.HashFile(path)
+		if err != nil {
+			return Backup{}, err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return Backup{}, err
+		}
+		if err := fsutil.CopyFile(path, relPath.In(backupDir)); err != nil {
+			return Backup{}, err
+		}
+		backup.Files = append(backup.Files, File{Path: relPath, Hash: hash, Size: info.Size()})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recorded := s.db.Backups
+	s.db.Backups = append(recorded[:len(recorded):len(recorded)], backup)
+	sort.SliceStable(s.db.Backups, func(i, j int) bool {
+		return s.db.Backups[i].Timestamp.Before(s.db.Backups[j].Timestamp)
+	})
+	if err := s.save(); err != nil {
+		s.db.Backups = recorded
+		return Backup{}, err
+	}
+	return backup, nil
+}
+
+// prune drops the records of the oldest backups beyond Settings.MaxBackups,
+// clamped by ClampMaxBackups, and returns them, for remove once the records
+// are saved. The caller must hold s.mu.
+func (s *Store) prune() []Backup {
+	limit := ClampMaxBackups(s.db.Settings.MaxBackups)
+	if len(s.db.Backups) <= limit {
+		return nil
+	}
+
+	// Sort backups by time, keeping the list oldest first
+	sort.SliceStable(s.db.Backups, func(i, j int) bool {
+		return s.db.Backups[i].Timestamp.Before(s.db.Backups[j].Timestamp)
+	})
+
+	excess := len(s.db.Backups) - limit
+	oldBackups := s.db.Backups[:excess]
+	s.db.Backups = append([]Backup(nil), s.db.Backups[excess:]...)
+	return oldBackups
+}
+
+// remove deletes the folders of backups no longer recorded with s.Delete.
+func (s *Store) remove(backups []Backup) {
+	remove := s.Delete
+	if remove == nil {
+		remove = os.RemoveAll
+	}
+	for _, backup := range backups {
+		remove(s.backupDir(backup.ID))
+	}
+}
+
+// Verify checks the files of backup against the recorded hashes.
+func (s *Store) Verify(backup Backup) error {
+	stored, err := s.openFiles(backup)
+	if err != nil {
+		return fmt.Errorf("backup verification failed: %v", err)
+	}
+	defer stored.Close()
+	return verify(context.Background(), stored, backup.Files, s.sizes(backup), nil)
+}
+
+func verify(ctx context.Context, stored *backupFiles, files []File, sizes []int64, progress RestoreProgress) error {
+	t := newRestoreTracker(Verifying, sizes, progress)
+	for i, file := range files {
+		hash, err := hashStored(ctx, stored, file.Path, t.file(i, string(file.Path)))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("backup verification failed: %v", err)
+		}
+		if hash != file.Hash {
+			return fmt.Errorf("backup file corrupted: %s", file.Path)
+		}
+		t.fileDone(i, string(file.Path), false)
+	}
+	return nil
+}
+
+// hashStored returns the SHA-256 of the file of a backup at path.
+func hashStored(ctx context.Context, stored *backupFiles, path relpath.Path, progress func(read int64)) (string, error) {
+	r, err := stored.open(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := fsutil.Copy(ctx, h, r, progress); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sizes returns the sizes of the files of backup, taking them from the
+// backup folder for backups that did not record them.
+func (s *Store) sizes(backup Backup) []int64 {
+	sizes := make([]int64, len(backup.Files))
+	for i, file := range backup.Files {
+		sizes[i] = file.Size
+		if sizes[i] == 0 {
+			if info, err := os.Stat(fsutil.LongPath(file.Path.In(s.Dir(backup.ID)))); err == nil {
+				sizes[i] = info.Size()
+			}
+		}
+	}
+	return sizes
+}
+
+// changed returns the files, and their sizes, whose copy in the game at
+// gameDir differs from the backup or cannot be read.
+func changed(ctx context.Context, gameDir string, files []File, sizes []int64, opts RestoreOptions) ([]File, []int64, error) {
+	var changedFiles []File
+	var changedSizes []int64
+	t := newRestoreTracker(Comparing, sizes, opts.Progress)
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		path := file.Path.In(gameDir)
+		same := false
+		if info, err := os.Stat(fsutil.LongPath(path)); err == nil && info.Size() == sizes[i] {
+			hash, err := opts.CurrentHash(path)
+			same = err == nil && strings.EqualFold(hash, file.Hash)
+		}
+		if !same {
+			changedFiles = append(changedFiles, file)
+			changedSizes = append(changedSizes, sizes[i])
+		}
+		t.fileDone(i, string(file.Path), same)
+	}
+	return changedFiles, changedSizes, nil
+}
+
+// Restore verifies backup and copies its files back into the game at
+// gameDir, stopping once ctx is cancelled. Nothing is written when
+// verification fails. A file that does not match its hash after copying
+// fails the restore with a *fsutil.MismatchError unless opts.Retry asks
+// to copy it again. Missing links of the backup are recreated last; those
+// that cannot be are reported in the summary without failing the restore.
+func (s *Store) Restore(ctx context.Context, gameDir string, backup Backup, opts RestoreOptions) (RestoreSummary, error) {
+	files, sizes := backup.Files, s.sizes(backup)
+	if opts.CurrentHash != nil {
+		var err error
+		if files, sizes, err = changed(ctx, gameDir, files, sizes, opts); err != nil {
+			return RestoreSummary{}, err
+		}
+	}
+	summary := RestoreSummary{Unchanged: len(backup.Files) - len(files)}
+	stored, err := s.openFiles(backup)
+	if err != nil {
+		return summary, fmt.Errorf("backup verification failed: %v", err)
+	}
+	defer stored.Close()
+	if err := verify(ctx, stored, files, sizes, opts.Progress); err != nil {
+		return summary, err
+	}
+
+	t := newRestoreTracker(Restoring, sizes, opts.Progress)
+	for i, file := range files {
+		copied := t.file(i, string(file.Path))
+		var report func(written, total int64)
+		if copied != nil {
+			report = func(written, total int64) { copied(written) }
+		}
+		dst := file.Path.In(gameDir)
+		for {
+			err := stored.copyTo(ctx, file.Path, dst, report)
+			if err == nil && opts.Verify {
+				err = fsutil.VerifyFile(ctx, dst, file.Hash)
+			}
+			var mismatch *fsutil.MismatchError
+			if errors.As(err, &mismatch) && opts.Retry != nil && opts.Retry(string(file.Path), err) {
+				continue
+			}
+			if err != nil {
+				return summary, err
+			}
+			break
+		}
+		summary.Restored++
+		t.fileDone(i, string(file.Path), false)
+	}
+
+	for _, l := range backup.Links {
+		if err := restoreLink(gameDir, l); err != nil {
+			summary.LinkErrors = append(summary.LinkErrors, err)
+		}
+	}
+	return summary, nil
+}
+
+// restoreTracker turns the bytes handled of each file into the
+// RestoreStatus of a phase.
+type restoreTracker struct {
+	phase    Phase
+	sizes    []int64
+	total    int64
+	before   int64 // bytes of the files completed
+	progress RestoreProgress
+}
+
+func newRestoreTracker(phase Phase, sizes []int64, progress RestoreProgress) *restoreTracker {
+	t := &restoreTracker{phase: phase, sizes: sizes, progress: progress}
+	for _, size := range sizes {
+		t.total += size
+	}
+	return t
+}
+
+// file returns the callback reporting the bytes handled of file i, or nil
+// when there is no progress to report to.
+func (t *restoreTracker) file(i int, path string) func(done int64) {
+	if t.progress == nil {
+		return nil
+	}
+	return func(done int64) {
+		t.report(i, path, t.before+done, false, false)
+	}
+}
+
+func (t *restoreTracker) fileDone(i int, path string, same bool) {
+	t.before += t.sizes[i]
+	if t.progress != nil {
+		t.report(i, path, t.before, true, same)
+	}
+}
+
+func (t *restoreTracker) report(i int, path string, done int64, fileDone, same bool) {
+	t.progress(RestoreStatus{
+		Phase:    t.phase,
+		File:     i + 1,
+		Files:    len(t.sizes),
+		Path:     path,
+		Done:     done,
+		Total:    t.total,
+		FileDone: fileDone,
+		Same:     same,
+	})
+}
@@ -0,0 +1,775 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/schema"
+)
+
+// newGame creates a game directory with the given NPK files in imagepack2.
+func newGame(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, "imagepack2", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateRestoreRoundTrip(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"sprite_interface.NPK":   "original interface",
+		"sprite_character.npk":   "original character",
+		"sounds/sound_skill.npk": "original sound",
+		"readme.txt":             "not an NPK",
+	})
+	store := NewStore(t.TempDir())
+
+	var progress []string
+	backup, err := store.Create(game, Options{
+		Description: "before patching",
+		Type:        "manual",
+		Progress: func(done, total int) {
+			progress = append(progress, fmt.Sprintf("%d/%d", done, total))
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(backup.Files) != 3 {
+		t.Fatalf("backed up %d files, want the 3 NPK files", len(backup.Files))
+	}
+	if got, want := strings.Join(progress, " "), "1/3 2/3 3/3"; got != want {
+		t.Errorf("progress = %s, want %s", got, want)
+	}
+	if want := int64(len("original interface") + len("original character") + len("original sound")); backup.TotalSize != want || store.TotalSize() != want {
+		t.Errorf("TotalSize = %d, store %d, want %d", backup.TotalSize, store.TotalSize(), want)
+	}
+
+	// The database is persisted and can be reloaded
+	reloaded := NewStore(store.dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := reloaded.Find(backup.ID); !ok {
+		t.Fatalf("backup %s missing after reload", backup.ID)
+	}
+
+	// Simulate a patch install and a deleted file
+	interfacePath := filepath.Join(game, "imagepack2", "sprite_interface.NPK")
+	writeFile(t, interfacePath, "patched interface")
+	soundPath := filepath.Join(game, "imagepack2", "sounds", "sound_skill.npk")
+	if err := os.RemoveAll(filepath.Dir(soundPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reloaded.Restore(context.Background(), game, backup, RestoreOptions{Verify: true}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := readFile(t, interfacePath); got != "original interface" {
+		t.Errorf("restored interface = %q, want the original", got)
+	}
+	if got := readFile(t, soundPath); got != "original sound" {
+		t.Errorf("restored sound = %q, want the original", got)
+	}
+}
+
+func TestRestoreProgress(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"a.npk": "12345",
+		"b.npk": "1234567890",
+	})
+	store := NewStore(t.TempDir())
+	backup, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var completed []string
+	last := map[Phase]RestoreStatus{}
+	_, err = store.Restore(context.Background(), game, backup, RestoreOptions{Progress: func(s RestoreStatus) {
+		if prev, ok := last[s.Phase]; ok && s.Done < prev.Done {
+			t.Errorf("progress went back from %d to %d bytes", prev.Done, s.Done)
+		}
+		if s.Phase == Verifying && last[Restoring].Total > 0 {
+			t.Error("verification reported after copying started")
+		}
+		last[s.Phase] = s
+		if s.FileDone {
+			completed = append(completed, fmt.Sprintf("%d:%d/%d:%s", s.Phase, s.File, s.Files, filepath.Base(s.Path)))
+		}
+	}})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got, want := strings.Join(completed, " "), "1:1/2:a.npk 1:2/2:b.npk 2:1/2:a.npk 2:2/2:b.npk"; got != want {
+		t.Errorf("completed files = %s, want %s", got, want)
+	}
+	for _, phase := range []Phase{Verifying, Restoring} {
+		if s := last[phase]; s.Done != 15 || s.Total != 15 {
+			t.Errorf("phase %d ended at %d/%d bytes, want 15/15", phase, s.Done, s.Total)
+		}
+	}
+}
+
+func TestRestoreSkipsUnchanged(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"same.npk":     "same",
+		"patched.npk":  "original",
+		"resized.npk":  "original",
+		"deleted.npk":  "original",
+		"intact.npk":   "original",
+		"sounds/a.npk": "same",
+	})
+	store := NewStore(t.TempDir())
+	backup, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	imagepack := filepath.Join(game, "imagepack2")
+	writeFile(t, filepath.Join(imagepack, "patched.npk"), "patched!")
+	writeFile(t, filepath.Join(imagepack, "resized.npk"), "longer patch")
+	if err := os.Remove(filepath.Join(imagepack, "deleted.npk")); err != nil {
+		t.Fatal(err)
+	}
+	// A corrupted backup copy of an unchanged file does not stop the restore
+	writeFile(t, filepath.Join(store.Dir(backup.ID), "imagepack2", "same.npk"), "XXXX")
+
+	hashes := fsutil.NewHashCache()
+	var skipped []string
+	summary, err := store.Restore(context.Background(), game, backup, RestoreOptions{
+		CurrentHash: hashes.Hash,
+		Progress: func(s RestoreStatus) {
+			if s.Same {
+				skipped = append(skipped, filepath.Base(s.Path))
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if summary.Unchanged != 3 || summary.Restored != 3 {
+		t.Errorf("summary = %+v, want 3 unchanged and 3 restored", summary)
+	}
+	if got, want := strings.Join(skipped, " "), "intact.npk same.npk a.npk"; got != want {
+		t.Errorf("skipped %s, want %s", got, want)
+	}
+	for _, name := range []string{"patched.npk", "resized.npk", "deleted.npk"} {
+		if got := readFile(t, filepath.Join(imagepack, name)); got != "original" {
+			t.Errorf("%s = %q after restoring, want the original", name, got)
+		}
+	}
+
+	// Forcing a full restore copies every file and trips over the damaged copy
+	if _, err := store.Restore(context.Background(), game, backup, RestoreOptions{}); err == nil {
+		t.Error("full Restore() with a corrupted backup file succeeded")
+	}
+}
+
+// TestLongGamePath backs up and restores a game nested deeper than the 260
+// characters of MAX_PATH.
+func TestLongGamePath(t *testing.T) {
+	game := t.TempDir()
+	for len(game) <= 300 {
+		game = filepath.Join(game, strings.Repeat("WeGame", 8))
+	}
+	path := filepath.Join(game, "imagepack2", "sprite_interface.npk")
+	if err := os.MkdirAll(fsutil.LongPath(filepath.Dir(path)), 0755); err != nil {
+		t.Skipf("cannot create a %d character path: %v", len(path), err)
+	}
+	if err := os.WriteFile(fsutil.LongPath(path), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(t.TempDir())
+
+	backup, err := store.Create(game, Options{Description: "deep", Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := os.WriteFile(fsutil.LongPath(path), []byte("patched"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Restore(context.Background(), game, backup, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := readFile(t, fsutil.LongPath(path)); got != "original" {
+		t.Errorf("restored file = %q, want the original", got)
+	}
+}
+
+func TestCreateFiltered(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"a.npk": "a",
+		"b.npk": "b",
+	})
+	store := NewStore(t.TempDir())
+
+	backup, err := store.Create(game, Options{
+		Type:    "auto",
+		Include: func(path string) bool { return filepath.Base(path) == "b.npk" },
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(backup.Files) != 1 || backup.Files[0].Path != "imagepack2/b.npk" {
+		t.Errorf("Files = %+v, want only imagepack2/b.npk", backup.Files)
+	}
+}
+
+func TestCreateCancelled(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"a.npk": "a",
+		"b.npk": "b",
+	})
+	store := NewStore(t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := store.Create(game, Options{
+		Type:     "auto",
+		Context:  ctx,
+		Progress: func(done, total int) { cancel() },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Create() error = %v, want context.Canceled", err)
+	}
+	if backups := store.Backups(); len(backups) != 0 {
+		t.Errorf("Backups() = %+v after cancelling, want none", backups)
+	}
+	entries, err := os.ReadDir(store.Dir(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("backup folder has %d entries after cancelling, want the partial backup removed", len(entries))
+	}
+}
+
+func TestRestoreRejectsCorruptedBackup(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"a.npk": "original a",
+		"b.npk": "original b",
+	})
+	store := NewStore(t.TempDir())
+
+	backup, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := store.Verify(backup); err != nil {
+		t.Fatalf("Verify() of an intact backup = %v", err)
+	}
+
+	// Patch the game, then damage one of the backed up files
+	writeFile(t, filepath.Join(game, "imagepack2", "a.npk"), "patched a")
+	writeFile(t, filepath.Join(game, "imagepack2", "b.npk"), "patched b")
+	writeFile(t, filepath.Join(store.Dir(backup.ID), "imagepack2", "b.npk"), "bit rot")
+
+	_, err = store.Restore(context.Background(), game, backup, RestoreOptions{})
+	if err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Fatalf("Restore() error = %v, want a corruption error", err)
+	}
+
+	// Verification happens before anything is written
+	if got := readFile(t, filepath.Join(game, "imagepack2", "a.npk")); got != "patched a" {
+		t.Errorf("a.npk = %q, want it left untouched", got)
+	}
+}
+
+func TestVerifyMissingFile(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	store := NewStore(t.TempDir())
+
+	backup, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := os.RemoveAll(store.Dir(backup.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Verify(backup); err == nil {
+		t.Error("Verify() succeeded with the backup files deleted")
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if settings := store.Settings(); settings != DefaultSettings() {
+		t.Errorf("Settings() = %+v, want the defaults", settings)
+	}
+}
+
+// TestLoadMigratesMixedCase loads a backup made before paths were canonical,
+// listing the same file under two spellings and holding it in its original
+// casing, and restores it into the casing found in the game folder.
+func TestLoadMigratesMixedCase(t *testing.T) {
+	game := newGame(t, map[string]string{"Sprite.NPK": "original"})
+	dir := t.TempDir()
+	store := NewStore(dir)
+	b, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := os.Rename(filepath.Join(store.Dir(b.ID), "imagepack2", "sprite.npk"), filepath.Join(store.Dir(b.ID), "imagepack2", "Sprite.NPK")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(store.databasePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := fmt.Sprintf(`"files": [{"path": "ImagePack2\\Sprite.NPK", "hash": %q, "size": 8},`, b.Files[0].Hash)
+	data = []byte(strings.Replace(string(data), `"files": [`, old, 1))
+	writeFile(t, store.databasePath(), string(data))
+
+	reloaded := NewStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	backups := reloaded.Backups()
+	if len(backups) != 1 || len(backups[0].Files) != 1 || backups[0].Files[0].Path != "imagepack2/sprite.npk" {
+		t.Fatalf("Backups() = %+v, want one file at imagepack2/sprite.npk", backups)
+	}
+
+	writeFile(t, filepath.Join(game, "imagepack2", "Sprite.NPK"), "patched")
+	if _, err := reloaded.Restore(context.Background(), game, backups[0], RestoreOptions{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(game, "imagepack2", "Sprite.NPK")); got != "original" {
+		t.Errorf("restored Sprite.NPK = %q, want original", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(game, "imagepack2")); len(entries) != 1 {
+		t.Errorf("imagepack2 holds %d files, want the restored file in its existing casing only", len(entries))
+	}
+}
+
+// TestLoadEverySchema loads the backup.json fixtures of every format it has
+// had, checks that each reads the same and that a reload of the upgraded file
+// does too.
+func TestLoadEverySchema(t *testing.T) {
+	for v := 0; v <= databaseFile.Version; v++ {
+		data, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("backup.v%d.json", v)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir := t.TempDir()
+		store := NewStore(dir)
+		if err := os.MkdirAll(filepath.Dir(store.databasePath()), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, store.databasePath(), string(data))
+
+		for _, pass := range []string{"fixture", "upgraded"} {
+			store := NewStore(dir)
+			if err := store.Load(); err != nil {
+				t.Fatalf("v%d %s: Load() error = %v", v, pass, err)
+			}
+			backups := store.Backups()
+			if len(backups) != 1 || backups[0].ID != "20240102_100000" || len(backups[0].Files) != 1 ||
+				backups[0].Files[0].Path != "imagepacks2/sprite_interface.npk" || backups[0].TotalSize != 2 {
+				t.Errorf("v%d %s: Backups() = %+v", v, pass, backups)
+			}
+			if settings := store.Settings(); !settings.AutoBackup || settings.MaxBackups != 5 {
+				t.Errorf("v%d %s: Settings() = %+v", v, pass, settings)
+			}
+		}
+	}
+}
+
+func TestRefusesNewerSchema(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := os.MkdirAll(filepath.Dir(store.databasePath()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	doc := `{"schemaVersion": 99, "backups": [], "settings": {"maxBackups": 5}}`
+	writeFile(t, store.databasePath(), doc)
+
+	var newer *schema.NewerError
+	if err := store.Load(); !errors.As(err, &newer) {
+		t.Fatalf("Load() error = %v, want a NewerError", err)
+	}
+	if err := store.UpdateSettings(func(s *Settings) { s.MaxBackups = 3 }); !errors.As(err, &newer) {
+		t.Errorf("UpdateSettings() error = %v, want the NewerError again", err)
+	}
+	if got := readFile(t, store.databasePath()); got != doc {
+		t.Errorf("backup.json = %s, want it left as it was", got)
+	}
+}
+
+func TestPruneKeepsNewest(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	store := NewStore(t.TempDir())
+	if err := store.UpdateSettings(func(s *Settings) { s.MaxBackups = 2 }); err != nil {
+		t.Fatal(err)
+	}
+
+	var created []Backup
+	for i := 0; i < 3; i++ {
+		backup, err := store.Create(game, Options{Description: fmt.Sprint(i), Type: "manual"})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		created = append(created, backup)
+	}
+
+	backups := store.Backups()
+	if len(backups) != 2 || backups[0].ID != created[1].ID || backups[1].ID != created[2].ID {
+		t.Fatalf("Backups() = %+v, want the last two oldest first", backups)
+	}
+	if _, err := os.Stat(store.Dir(created[0].ID)); !os.IsNotExist(err) {
+		t.Errorf("files of the pruned backup still exist: %v", err)
+	}
+}
+
+func TestPruneUsesDelete(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	store := NewStore(t.TempDir())
+	if err := store.UpdateSettings(func(s *Settings) { s.MaxBackups = 1 }); err != nil {
+		t.Fatal(err)
+	}
+	var deleted []string
+	store.Delete = func(path string) error {
+		deleted = append(deleted, path)
+		return os.RemoveAll(path)
+	}
+
+	first, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(game, Options{Type: "manual"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0] != store.Dir(first.ID) {
+		t.Errorf("deleted %v, want the folder of %s", deleted, first.ID)
+	}
+}
+
+func TestClampMaxBackups(t *testing.T) {
+	for n, want := range map[int]int{-3: 10, 0: 10, 1: 1, 25: 25, 100: 100, 101: 100} {
+		if got := ClampMaxBackups(n); got != want {
+			t.Errorf("ClampMaxBackups(%d) = %d, want %d", n, got, want)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "backup"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "backup", "backup.json"), `{"schemaVersion": 1, "backups": [], "settings": {"maxBackups": 0}}`)
+	store := NewStore(dir)
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Settings().MaxBackups; got != 10 {
+		t.Errorf("MaxBackups = %d after loading 0, want the default", got)
+	}
+
+	// Settings changed without clamping still keep backups
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	if err := store.UpdateSettings(func(s *Settings) { s.MaxBackups = -3 }); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := store.Create(game, Options{Type: "manual"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(store.Backups()); got != 2 {
+		t.Errorf("%d backups kept with MaxBackups -3, want 2", got)
+	}
+}
+
+func TestCreateUnsavedIsDropped(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	dir := t.TempDir()
+	store := NewStore(dir)
+	if err := store.UpdateSettings(func(s *Settings) { s.MaxBackups = 1 }); err != nil {
+		t.Fatal(err)
+	}
+	first, err := store.Create(game, Options{Type: "auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// backup.json cannot be written over a folder
+	path := filepath.Join(dir, "backup", "backup.json")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Create(game, Options{Type: "auto"}); err == nil {
+		t.Fatal("Create() succeeded without saving the backup")
+	}
+	if backups := store.Backups(); len(backups) != 1 || backups[0].ID != first.ID {
+		t.Errorf("Backups() = %+v, want only the first backup", backups)
+	}
+	entries, err := os.ReadDir(store.Dir(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != first.ID {
+		t.Errorf("backup folder has %v, want the first backup kept and the failed one removed", entries)
+	}
+}
+
+// TestConcurrentCreateAndSettings runs backups while the settings are
+// changed, as the auto-backup goroutine does while the user edits them.
+// Run with -race.
+func TestConcurrentCreateAndSettings(t *testing.T) {
+	game := newGame(t, map[string]string{
+		"a.npk": "a",
+		"b.npk": "b",
+	})
+	store := NewStore(t.TempDir())
+
+	const backups = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, backups*2)
+	for i := 0; i < backups; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Create(game, Options{Type: "auto"}); err != nil {
+				errs <- err
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			err := store.UpdateSettings(func(s *Settings) {
+				s.AutoBackup = i%2 == 0
+				s.BackupInterval = 1800 * (i + 1)
+			})
+			if err != nil {
+				errs <- err
+			}
+			store.Backups()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	ids := make(map[string]bool)
+	for _, backup := range store.Backups() {
+		if ids[backup.ID] {
+			t.Errorf("backup ID %s used twice", backup.ID)
+		}
+		ids[backup.ID] = true
+		if err := store.Verify(backup); err != nil {
+			t.Errorf("Verify(%s) = %v", backup.ID, err)
+		}
+	}
+	if len(ids) != backups {
+		t.Errorf("recorded %d backups, want %d", len(ids), backups)
+	}
+
+	// Every save wrote a consistent database
+	reloaded := NewStore(store.dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := len(reloaded.Backups()); got != backups {
+		t.Errorf("reloaded %d backups, want %d", got, backups)
+	}
+}
+
+func TestRemoveIncomplete(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	store := NewStore(t.TempDir())
+
+	backup, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// A backup the tool never finished recording
+	partial := store.Dir("backup_20240101_120000")
+	if err := os.MkdirAll(filepath.Join(partial, "imagepack2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RemoveIncomplete(); err != nil {
+		t.Fatalf("RemoveIncomplete() error = %v", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("incomplete backup still exists: %v", err)
+	}
+	if err := store.Verify(backup); err != nil {
+		t.Errorf("recorded backup damaged: %v", err)
+	}
+}
+
+func TestUnrecorded(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	store := NewStore(t.TempDir())
+	if _, err := store.Create(game, Options{Type: "manual"}); err != nil {
+		t.Fatal(err)
+	}
+	deleted := store.Dir("backup_20240101_120000")
+	if err := os.MkdirAll(deleted, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, err := store.Unrecorded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 1 || dirs[0] != deleted {
+		t.Errorf("Unrecorded() = %v, want [%s]", dirs, deleted)
+	}
+}
+
+func TestImport(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "patched"})
+	legacy := filepath.Join(game, "backup_20230101_080000")
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(legacy, "a.npk"), "original")
+	writeFile(t, filepath.Join(legacy, "notes.txt"), "not an NPK")
+	store := NewStore(t.TempDir())
+
+	timestamp := time.Date(2023, 1, 1, 8, 0, 0, 0, time.Local)
+	backup, err := store.Import(game, legacy, timestamp, "legacy")
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(backup.Files) != 1 || !backup.Timestamp.Equal(timestamp) {
+		t.Fatalf("imported backup = %+v", backup)
+	}
+	if _, err := store.Restore(context.Background(), game, backup, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(game, "imagepack2", "a.npk")); got != "original" {
+		t.Errorf("restored a.npk = %q, want the legacy copy", got)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Errorf("legacy folder was touched: %v", err)
+	}
+}
+
+func TestSetDetails(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	dir := t.TempDir()
+	store := NewStore(dir)
+	backup, err := store.Create(game, Options{Description: "Manual backup", Type: "manual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDetails(backup.ID, "Before the raid", []string{"good-state"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetDetails("backup_missing", "x", nil); err == nil {
+		t.Error("SetDetails() of a missing backup succeeded")
+	}
+
+	reloaded := NewStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := reloaded.Find(backup.ID)
+	if got.Description != "Before the raid" || len(got.Tags) != 1 || got.Tags[0] != "good-state" {
+		t.Errorf("backup = %q %v after reloading, want the new description and tags", got.Description, got.Tags)
+	}
+	for query, want := range map[string]bool{"": true, "RAID": true, "good": true, backup.ID: true, "pre-season": false} {
+		if got.Matches(query) != want {
+			t.Errorf("Matches(%q) = %v, want %v", query, !want, want)
+		}
+	}
+}
+
+func TestExpandDescription(t *testing.T) {
+	c := DescriptionContext{
+		Time:       time.Date(2025, 3, 5, 21, 7, 0, 0, time.UTC),
+		PatchCount: 12,
+		LastPatch:  "Dark UI",
+	}
+	got := ExpandDescription("{date} {time}: {patchcount} patches, last {lastpatch} {unknown}", c)
+	if want := "2025-03-05 21:07: 12 patches, last Dark UI {unknown}"; got != want {
+		t.Errorf("ExpandDescription() = %q, want %q", got, want)
+	}
+}
+
+func TestBackupPathInGame(t *testing.T) {
+	game := newGame(t, map[string]string{"a.npk": "a"})
+	store := NewStore(filepath.Join(game, "imagepack2", "tool"))
+	if !store.Overlaps(game) {
+		t.Fatal("Overlaps() = false for backups below imagepack2")
+	}
+	for i := 0; i < 2; i++ {
+		backup, err := store.Create(game, Options{Type: "manual"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := backedUpPaths(backup); len(got) != 1 {
+			t.Fatalf("backup %d has %v, want only a.npk and no earlier backups", i+1, got)
+		}
+	}
+
+	moved := filepath.Join(t.TempDir(), "backups")
+	if err := store.Move(moved); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if store.Overlaps(game) || store.Dir("") != moved {
+		t.Errorf("Dir() = %s after Move(), want %s outside the game", store.Dir(""), moved)
+	}
+	for _, backup := range store.Backups() {
+		if err := store.Verify(backup); err != nil {
+			t.Errorf("moved backup damaged: %v", err)
+		}
+	}
+	if err := store.Move(filepath.Join(moved, "nested")); err == nil {
+		t.Error("Move() into the backup path succeeded")
+	}
+}
+
+func TestOverlapsGameInBackupPath(t *testing.T) {
+	dir := t.TempDir()
+	game := filepath.Join(dir, "DNF")
+	store := NewStore(t.TempDir())
+	if store.Overlaps(game) {
+		t.Error("Overlaps() = true for separate folders")
+	}
+	if err := store.UpdateSettings(func(s *Settings) { s.BackupPath = dir }); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Overlaps(game) {
+		t.Error("Overlaps() = false for a game inside the backup path")
+	}
+}
@@ -0,0 +1,27 @@
+package backup
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DescriptionContext is what the tokens of a description template stand
+// for.
+type DescriptionContext struct {
+	Time       time.Time // {date} and {time}, in its own zone
+	PatchCount int       // {patchcount}, the patches installed
+	LastPatch  string    // {lastpatch}, the name of the patch installed last
+}
+
+// ExpandDescription replaces the tokens {date}, {time}, {patchcount} and
+// {lastpatch} in template with what they stand for in c. Other text in
+// braces is left as it is.
+func ExpandDescription(template string, c DescriptionContext) string {
+	return strings.NewReplacer(
+		"{date}", c.Time.Format("2006-01-02"),
+		"{time}", c.Time.Format("15:04"),
+		"{patchcount}", strconv.Itoa(c.PatchCount),
+		"{lastpatch}", c.LastPatch,
+	).Replace(template)
+}
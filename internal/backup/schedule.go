@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The schedules of auto backups in Settings.Schedule.
+const (
+	ScheduleInterval = "interval" // every Settings.BackupInterval seconds
+	ScheduleDaily    = "daily"    // every day at Settings.ScheduleTime
+	ScheduleWeekly   = "weekly"   // every Settings.ScheduleDay at Settings.ScheduleTime
+)
+
+// Schedules are the schedules of auto backups.
+var Schedules = []string{ScheduleInterval, ScheduleDaily, ScheduleWeekly}
+
+// Intervals are the intervals of ScheduleInterval offered in the settings,
+// in seconds, shortest first. Others can be entered as custom intervals.
+var Intervals = []int{1800, 3600, 7200, 14400, 28800, 43200, 86400}
+
+// The range of custom intervals, in seconds.
+const (
+	MinInterval = 5 * 60
+	MaxInterval = 7 * 24 * 3600
+)
+
+// IntervalIndex returns the index of seconds in Intervals, or -1 for a
+// custom interval.
+func IntervalIndex(seconds int) int {
+	for i, interval := range Intervals {
+		if interval == seconds {
+			return i
+		}
+	}
+	return -1
+}
+
+// NearestInterval returns the index of the interval in Intervals closest to
+// seconds, the shorter one of two as close.
+func NearestInterval(seconds int) int {
+	nearest := 0
+	for i, interval := range Intervals {
+		if abs(interval-seconds) < abs(Intervals[nearest]-seconds) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ParseIntervalMinutes parses a custom interval given in whole minutes,
+// returning it in seconds. It must lie between MinInterval and MaxInterval.
+func ParseIntervalMinutes(s string) (int, error) {
+	minutes, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a whole number of minutes", s)
+	}
+	if seconds := minutes * 60; seconds >= MinInterval && seconds <= MaxInterval {
+		return seconds, nil
+	}
+	return 0, fmt.Errorf("an interval of %d minutes is not between %d and %d", minutes, MinInterval/60, MaxInterval/60)
+}
+
+// DefaultScheduleTime is the time of day of daily and weekly backups until
+// one is chosen, when the computer is least likely to be in use.
+const DefaultScheduleTime = "04:00"
+
+// ParseScheduleTime parses a time of day written as hours and minutes, such
+// as "04:00" or "4:30", returning the minutes since midnight.
+func ParseScheduleTime(s string) (int, error) {
+	var hours, minutes int
+	if n, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil || n != 2 {
+		return 0, fmt.Errorf("%q is not a time of day such as 04:00", s)
+	}
+	if hours < 0 || hours > 23 || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("%q is not a time of day such as 04:00", s)
+	}
+	return hours*60 + minutes, nil
+}
+
+// Scheduled reports whether s runs auto backups.
+func (s Settings) Scheduled() bool {
+	switch s.Schedule {
+	case ScheduleDaily, ScheduleWeekly:
+		return s.AutoBackup
+	}
+	return s.AutoBackup && s.BackupInterval > 0
+}
+
+// NextRun returns when the auto backup after one run at last is due by the
+// schedule of s, in the time zone of now. Without a last run it is the
+// first time due after now, or an interval after it. A time before now was
+// missed while the tool was closed, and the backup is due at once. An
+// invalid time of day counts as DefaultScheduleTime.
+func (s Settings) NextRun(last, now time.Time) time.Time {
+	from := last
+	if from.IsZero() {
+		from = now
+	}
+	switch s.Schedule {
+	case ScheduleDaily, ScheduleWeekly:
+	default:
+		return from.Add(time.Duration(s.BackupInterval) * time.Second)
+	}
+
+	minutes, err := ParseScheduleTime(s.ScheduleTime)
+	if err != nil {
+		minutes, _ = ParseScheduleTime(DefaultScheduleTime)
+	}
+	from = from.In(now.Location())
+	for day := 0; ; day++ {
+		// time.Date normalizes the day, and the hour across DST changes
+		next := time.Date(from.Year(), from.Month(), from.Day()+day, 0, minutes, 0, 0, now.Location())
+		if !next.After(from) {
+			continue
+		}
+		if s.Schedule == ScheduleDaily || next.Weekday() == time.Weekday((s.ScheduleDay%7+7)%7) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRun(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 3, day, hour, minute, 0, 0, loc) }
+	// 2024-03-06 is a Wednesday
+	now := at(6, 10, 0)
+	tests := []struct {
+		name     string
+		settings Settings
+		last     time.Time
+		want     time.Time
+	}{
+		{"interval", Settings{BackupInterval: 3600}, at(6, 9, 30), at(6, 10, 30)},
+		{"interval without last run", Settings{BackupInterval: 3600}, time.Time{}, at(6, 11, 0)},
+		{"interval missed", Settings{BackupInterval: 3600}, at(5, 9, 0), at(5, 10, 0)},
+		{"old settings", Settings{Schedule: "", BackupInterval: 1800}, at(6, 9, 0), at(6, 9, 30)},
+		{"daily", Settings{Schedule: ScheduleDaily, ScheduleTime: "04:00"}, at(6, 4, 1), at(7, 4, 0)},
+		{"daily without last run", Settings{Schedule: ScheduleDaily, ScheduleTime: "23:15"}, time.Time{}, at(6, 23, 15)},
+		{"daily missed", Settings{Schedule: ScheduleDaily, ScheduleTime: "04:00"}, at(4, 8, 0), at(5, 4, 0)},
+		{"daily at the run", Settings{Schedule: ScheduleDaily, ScheduleTime: "04:00"}, at(6, 4, 0), at(7, 4, 0)},
+		{"daily invalid time", Settings{Schedule: ScheduleDaily, ScheduleTime: "25:00"}, at(6, 5, 0), at(7, 4, 0)},
+		{"weekly", Settings{Schedule: ScheduleWeekly, ScheduleTime: "04:00", ScheduleDay: int(time.Sunday)}, at(6, 4, 0), at(10, 4, 0)},
+		{"weekly same day", Settings{Schedule: ScheduleWeekly, ScheduleTime: "12:00", ScheduleDay: int(time.Wednesday)}, at(6, 9, 0), at(6, 12, 0)},
+		{"weekly missed", Settings{Schedule: ScheduleWeekly, ScheduleTime: "04:00", ScheduleDay: int(time.Monday)}, at(1, 0, 0), at(4, 4, 0)},
+		{"weekly invalid day", Settings{Schedule: ScheduleWeekly, ScheduleTime: "04:00", ScheduleDay: 9}, at(6, 4, 0), at(12, 4, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.NextRun(tt.last, now); !got.Equal(tt.want) {
+				t.Errorf("NextRun(%v) = %v, want %v", tt.last, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	for s, want := range map[string]int{"04:00": 240, "4:30": 270, "23:59": 1439, "00:00": 0} {
+		if got, err := ParseScheduleTime(s); err != nil || got != want {
+			t.Errorf("ParseScheduleTime(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "4", "24:00", "12:60", "-1:00", "noon"} {
+		if _, err := ParseScheduleTime(s); err == nil {
+			t.Errorf("ParseScheduleTime(%q) succeeded", s)
+		}
+	}
+}
+
+func TestLastAutoBackup(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	if !store.LastAutoBackup().IsZero() {
+		t.Error("LastAutoBackup() of a new store is set")
+	}
+	last := time.Date(2024, 3, 6, 4, 0, 0, 0, time.UTC)
+	if err := store.SetLastAutoBackup(last); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewStore(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.LastAutoBackup(); !got.Equal(last) {
+		t.Errorf("LastAutoBackup() = %v after reloading, want %v", got, last)
+	}
+}
+
+func TestIntervalIndex(t *testing.T) {
+	for i, seconds := range Intervals {
+		if got := IntervalIndex(seconds); got != i {
+			t.Errorf("IntervalIndex(%d) = %d, want %d", seconds, got, i)
+		}
+		if i > 0 && seconds <= Intervals[i-1] {
+			t.Errorf("Intervals = %v, want them shortest first", Intervals)
+		}
+		if seconds < MinInterval || seconds > MaxInterval {
+			t.Errorf("interval %d is outside the range of custom intervals", seconds)
+		}
+	}
+	if got := IntervalIndex(2700); got != -1 {
+		t.Errorf("IntervalIndex(2700) = %d, want -1 for a custom interval", got)
+	}
+}
+
+func TestNearestInterval(t *testing.T) {
+	for seconds, want := range map[int]int{0: 0, 60: 0, 1800: 0, 2700: 0, 3000: 1, 10800: 2, 86400: 6, 1 << 30: 6} {
+		if got := NearestInterval(seconds); got != want {
+			t.Errorf("NearestInterval(%d) = %d, want %d", seconds, got, want)
+		}
+	}
+}
+
+func TestParseIntervalMinutes(t *testing.T) {
+	for s, want := range map[string]int{"45": 2700, " 5 ": 300, "10080": 604800} {
+		if got, err := ParseIntervalMinutes(s); err != nil || got != want {
+			t.Errorf("ParseIntervalMinutes(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "4", "10081", "-30", "1.5", "hour"} {
+		if _, err := ParseIntervalMinutes(s); err == nil {
+			t.Errorf("ParseIntervalMinutes(%q) succeeded", s)
+		}
+	}
+}
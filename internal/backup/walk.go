@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/relpath"
+)
+
+// Link is a symbolic link, junction or other reparse point found below the
+// imagepack2 folder. Backups record it instead of following it, and restores
+// recreate it.
+type Link struct {
+	Path   relpath.Path `json:"path"`
+	Target string       `json:"target"` // as stored in the link, empty when unreadable
+}
+
+// isLink reports whether mode is that of a symbolic link or, on Windows, a
+// junction or another reparse point, which Go may report as irregular.
+func isLink(mode fs.FileMode) bool {
+	return mode&(fs.ModeSymlink|fs.ModeIrregular) != 0
+}
+
+// source is an NPK file to back up.
+type source struct {
+	path string       // where to read it, with the links leading to it resolved
+	rel  relpath.Path // where it belongs
+}
+
+// collect returns the NPK files below the imagepack2 folder of gameDir and
+// the links found there. The folder itself is followed when it is a link,
+// such as a junction onto another drive; the links below it only with
+// opts.FollowLinks, and never twice into the same folder. Of the files whose
+// paths differ only in case, which a case-sensitive file system can hold,
+// the first is taken. The folder skip, where the backups are kept, is left
+// out should it be below imagepack2.
+func collect(ctx context.Context, gameDir, skip string, opts Options) ([]source, []Link, error) {
+	root := gamepath.ImagePackPath(gameDir)
+	rootRel, err := filepath.Rel(gameDir, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sources []source
+	var links []Link
+	if resolved, err := filepath.EvalSymlinks(skip); err == nil {
+		skip = resolved
+	}
+	// Backups kept above imagepack2 are not in the way
+	excluded := func(dir string) bool { return !fsutil.Within(real, skip) && fsutil.Within(dir, skip) }
+
+	visited := map[string]bool{real: true}
+	seen := make(map[relpath.Path]bool)
+	var walk func(dir, dirRel string) error
+	walk = func(dir, dirRel string) error {
+		return filepath.WalkDir(fsutil.LongPath(dir), func(walked string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			below, err := filepath.Rel(fsutil.LongPath(dir), walked)
+			if err != nil {
+				return err
+			}
+			path, rel := filepath.Join(dir, below), filepath.Join(dirRel, below)
+			if d.IsDir() && excluded(path) {
+				return filepath.SkipDir
+			}
+
+			if below != "." && isLink(d.Type()) {
+				target, _ := os.Readlink(fsutil.LongPath(path))
+				if !opts.FollowLinks {
+					links = append(links, Link{Path: relpath.Canonical(rel), Target: target})
+					return nil
+				}
+				resolved, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					// Dangling, or a reparse point Go cannot resolve
+					links = append(links, Link{Path: relpath.Canonical(rel), Target: target})
+					return nil
+				}
+				info, err := os.Stat(fsutil.LongPath(resolved))
+				if err != nil {
+					return err
+				}
+				if info.IsDir() {
+					if visited[resolved] {
+						links = append(links, Link{Path: relpath.Canonical(rel), Target: target})
+						return nil
+					}
+					visited[resolved] = true
+					return walk(resolved, rel)
+				}
+				path, d = resolved, fs.FileInfoToDirEntry(info)
+			}
+
+			key := relpath.Canonical(rel)
+			if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".npk") && !seen[key] &&
+				(opts.Include == nil || opts.Include(filepath.Join(gameDir, rel))) {
+				seen[key] = true
+				sources = append(sources, source{path: path, rel: key})
+			}
+			return nil
+		})
+	}
+	if err := walk(real, rootRel); err != nil {
+		return nil, nil, err
+	}
+	return sources, links, nil
+}
+
+// restoreLink recreates l below gameDir if it is missing. A link that is
+// there already is left alone, and so is a file or folder in its place,
+// which is reported as an error.
+func restoreLink(gameDir string, l Link) error {
+	path := l.Path.In(gameDir)
+	info, err := os.Lstat(fsutil.LongPath(path))
+	if err == nil {
+		if isLink(info.Mode()) {
+			return nil
+		}
+		return fmt.Errorf("%s was a link to %s but is now a regular file or folder; it is left as it is", l.Path, l.Target)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	if l.Target == "" {
+		return fmt.Errorf("%s was a link whose target could not be read; it is not recreated", l.Path)
+	}
+	if err := os.MkdirAll(fsutil.LongPath(filepath.Dir(path)), 0755); err != nil {
+		return err
+	}
+	if err := os.Symlink(l.Target, fsutil.LongPath(path)); err != nil {
+		return fmt.Errorf("recreating the link %s to %s failed: %w", l.Path, l.Target, err)
+	}
+	return nil
+}
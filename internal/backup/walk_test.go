@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// symlink creates a link at path to target, skipping the test where the
+// platform or the user's rights do not allow it.
+func symlink(t *testing.T, target, path string) {
+	t.Helper()
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("cannot create symbolic links: %v", err)
+	}
+}
+
+// newLinkedGame returns a game whose imagepack2 is a link to a folder
+// elsewhere, holding a linked NPK file, a linked folder and a link back to
+// itself.
+func newLinkedGame(t *testing.T) string {
+	t.Helper()
+	game := t.TempDir()
+	elsewhere := t.TempDir()
+	packs := filepath.Join(elsewhere, "packs")
+	extra := filepath.Join(elsewhere, "extra")
+	for _, dir := range []string{packs, extra} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(packs, "real.npk"), "real")
+	writeFile(t, filepath.Join(extra, "linked.npk"), "linked")
+	writeFile(t, filepath.Join(extra, "nested.npk"), "nested")
+
+	symlink(t, packs, filepath.Join(game, "imagepack2"))
+	symlink(t, filepath.Join(extra, "linked.npk"), filepath.Join(packs, "linked.npk"))
+	symlink(t, extra, filepath.Join(packs, "extra"))
+	symlink(t, packs, filepath.Join(packs, "loop"))
+	return game
+}
+
+func backedUpPaths(b Backup) []string {
+	var paths []string
+	for _, f := range b.Files {
+		paths = append(paths, string(f.Path))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func linkPaths(b Backup) []string {
+	var paths []string
+	for _, l := range b.Links {
+		paths = append(paths, string(l.Path))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestCreateRecordsLinks(t *testing.T) {
+	game := newLinkedGame(t)
+	store := NewStore(t.TempDir())
+
+	b, err := store.Create(game, Options{Type: "manual"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got, want := backedUpPaths(b), []string{"imagepack2/real.npk"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("backed up %v, want %v through the linked imagepack2", got, want)
+	}
+	if got, want := linkPaths(b), []string{"imagepack2/extra", "imagepack2/linked.npk", "imagepack2/loop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("links = %v, want %v", got, want)
+	}
+
+	// The links are recreated when they are gone, and left alone otherwise
+	imagepack, err := filepath.EvalSymlinks(filepath.Join(game, "imagepack2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(imagepack, "extra")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(imagepack, "linked.npk")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(imagepack, "linked.npk"), "replaced")
+	summary, err := store.Restore(context.Background(), game, b, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := readFile(t, filepath.Join(imagepack, "extra", "nested.npk")); got != "nested" {
+		t.Errorf("nested.npk through the recreated link = %q", got)
+	}
+	if len(summary.LinkErrors) != 1 {
+		t.Errorf("LinkErrors = %v, want the link replaced by a file", summary.LinkErrors)
+	}
+	if got := readFile(t, filepath.Join(imagepack, "linked.npk")); got != "replaced" {
+		t.Errorf("file in place of a link = %q, want it left alone", got)
+	}
+}
+
+func TestCreateFollowLinks(t *testing.T) {
+	game := newLinkedGame(t)
+	store := NewStore(t.TempDir())
+
+	b, err := store.Create(game, Options{Type: "manual", FollowLinks: true})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	want := []string{"imagepack2/extra/linked.npk", "imagepack2/extra/nested.npk", "imagepack2/linked.npk", "imagepack2/real.npk"}
+	if got := backedUpPaths(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("backed up %v, want %v", got, want)
+	}
+	if got, want := linkPaths(b), []string{"imagepack2/loop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("links = %v, want only the loop %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(store.Dir(b.ID), "imagepack2", "linked.npk")); got != "linked" {
+		t.Errorf("backed up linked.npk = %q, want the content of its target", got)
+	}
+}
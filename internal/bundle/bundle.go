@@ -0,0 +1,278 @@
+// Package bundle builds distributable patch bundles for patch authors: a ZIP
+// with the NPK files, their preview images and a manifest.json describing
+// them with the patch schema of patches.json.
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/patchdb"
+)
+
+// ManifestName is the name of the manifest in a bundle.
+const ManifestName = "manifest.json"
+
+// previewDir is the folder of the preview images in a bundle.
+const previewDir = "previews/"
+
+// Manifest describes the patches of a bundle, one per NPK file.
+type Manifest struct {
+	Patches []patchdb.Patch `json:"patches"`
+}
+
+// Info is what the author tells about a patch, and its files.
+type Info struct {
+	ID          string
+	Name        string
+	Description string
+	Version     string
+	Author      string
+	Tags        []string
+	Files       []string // NPK files
+	Previews    []string // image files
+}
+
+var (
+	semver   = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+	validID  = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+	slugSkip = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// ValidVersion reports whether v is a semantic version such as 1.2.0.
+func ValidVersion(v string) bool {
+	return semver.MatchString(v)
+}
+
+// ValidID reports whether id can be used as a patch ID: lower-case letters,
+// digits, dots, dashes and underscores.
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
+
+// Slug turns a patch or file name into an ID. Names without any ASCII letter
+// or digit give "".
+func Slug(name string) string {
+	return strings.Trim(slugSkip.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// CheckNPK reports whether path is an NPK file that can be read.
+func CheckNPK(path string) error {
+	if !strings.EqualFold(filepath.Ext(path), ".npk") {
+		return fmt.Errorf("%s is not an NPK file", filepath.Base(path))
+	}
+	_, err := npk.Open(path)
+	return err
+}
+
+// CheckPreview reports whether path is an existing PNG or JPEG image.
+func CheckPreview(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg":
+	default:
+		return fmt.Errorf("%s is not a PNG or JPEG image", filepath.Base(path))
+	}
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("%s is a folder", path)
+	}
+	return err
+}
+
+// Validate checks info before a bundle is built.
+func (info Info) Validate() error {
+	if strings.TrimSpace(info.Name) == "" {
+		return errors.New("the patch has no name")
+	}
+	if !ValidID(info.ID) {
+		return fmt.Errorf("invalid patch ID %q", info.ID)
+	}
+	if !ValidVersion(info.Version) {
+		return fmt.Errorf("%q is not a version such as 1.0.0", info.Version)
+	}
+	if len(info.Files) == 0 {
+		return errors.New("no NPK files were added")
+	}
+	seen := make(map[string]bool)
+	for _, path := range append(append([]string(nil), info.Files...), info.Previews...) {
+		name := strings.ToLower(filepath.Base(path))
+		if seen[name] {
+			return fmt.Errorf("%s was added twice", filepath.Base(path))
+		}
+		seen[name] = true
+	}
+	for _, path := range info.Files {
+		if err := CheckNPK(path); err != nil {
+			return err
+		}
+	}
+	for _, path := range info.Previews {
+		if err := CheckPreview(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Patches returns the patch entries for info, with the sizes and checksums
+// of the files. With several files each gets its own entry, named after the
+// patch and the file.
+func (info Info) Patches() ([]patchdb.Patch, error) {
+	var previews []patchdb.Preview
+	for _, path := range info.Previews {
+		previews = append(previews, patchdb.Preview{URL: previewDir + filepath.Base(path)})
+	}
+	var patches []patchdb.Patch
+	for _, path := range info.Files {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := fsutil.HashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		filename := filepath.Base(path)
+		patch := patchdb.Patch{
+			ID:          info.ID,
+			Name:        strings.TrimSpace(info.Name),
+			Description: strings.TrimSpace(info.Description),
+			Filename:    filename,
+			Version:     info.Version,
+			Author:      strings.TrimSpace(info.Author),
+			Tags:        info.Tags,
+			Previews:    previews,
+			LastUpdated: time.Now().UTC().Truncate(24 * time.Hour).Format(time.RFC3339),
+			SizeBytes:   stat.Size(),
+			Sha256:      hash,
+		}
+		if len(info.Files) > 1 {
+			base := strings.TrimSuffix(filename, filepath.Ext(filename))
+			if slug := Slug(base); slug != "" {
+				patch.ID += "-" + slug
+			}
+			patch.Name += " (" + base + ")"
+		}
+		patches = append(patches, patch)
+	}
+	return patches, nil
+}
+
+// Build writes the bundle of info to w and returns its manifest.
+func Build(w io.Writer, info Info) (Manifest, error) {
+	if err := info.Validate(); err != nil {
+		return Manifest{}, err
+	}
+	patches, err := info.Patches()
+	if err != nil {
+		return Manifest{}, err
+	}
+	m := Manifest{Patches: patches}
+
+	zw := zip.NewWriter(w)
+	for _, path := range info.Files {
+		if err := addFile(zw, filepath.Base(path), path); err != nil {
+			return Manifest{}, err
+		}
+	}
+	for _, path := range info.Previews {
+		if err := addFile(zw, previewDir+filepath.Base(path), path); err != nil {
+			return Manifest{}, err
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	mw, err := zw.Create(ManifestName)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if _, err := mw.Write(data); err != nil {
+		return Manifest{}, err
+	}
+	return m, zw.Close()
+}
+
+// BuildFile writes the bundle of info to path.
+func BuildFile(path string, info Info) (Manifest, error) {
+	f, err := fsutil.Create(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	m, err := Build(f, info)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return m, err
+}
+
+func addFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	// NPK files are mostly compressed images already; storing them
+	// keeps packaging fast
+	method := zip.Deflate
+	if strings.EqualFold(filepath.Ext(name), ".npk") {
+		method = zip.Store
+	}
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// AddToRepository adds the patches of m to the category of the local
+// patches.json at repoPath, replacing entries with the same ID, and copies
+// the NPK files to patches/ and the previews to previews/ next to it, where
+// the tool looks for them. The file is created if it does not exist.
+func AddToRepository(repoPath, category string, m Manifest, info Info) error {
+	db, err := patchdb.Load(repoPath)
+	if os.IsNotExist(err) {
+		db, err = patchdb.Database{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(repoPath)
+	for _, path := range info.Files {
+		if err := fsutil.CopyFile(path, filepath.Join(dir, "patches", filepath.Base(path))); err != nil {
+			return err
+		}
+	}
+	for _, path := range info.Previews {
+		if err := fsutil.CopyFile(path, filepath.Join(dir, "previews", filepath.Base(path))); err != nil {
+			return err
+		}
+	}
+
+	var patches []patchdb.Patch
+	for _, patch := range m.Patches {
+		// Previews are loaded from the local repository folder
+		patch.Previews = nil
+		for _, preview := range info.Previews {
+			patch.Previews = append(patch.Previews, patchdb.Preview{URL: filepath.Join(dir, "previews", filepath.Base(preview))})
+		}
+		patches = append(patches, patch)
+	}
+	db.Merge(category, patches)
+	return db.Save(repoPath)
+}
@@ -0,0 +1,181 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dnf_patch/internal/patchdb"
+)
+
+// emptyNPK is an NPK file without entries.
+var emptyNPK = append([]byte("NeoplePack_Bill\x00\x00\x00\x00\x00"), make([]byte, 32)...)
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidVersion(t *testing.T) {
+	for v, want := range map[string]bool{
+		"1.0.0":         true,
+		"0.12.3-beta.1": true,
+		"2.0.0+build.5": true,
+		"1.0":           false,
+		"v1.0.0":        false,
+		"01.0.0":        false,
+		"":              false,
+		"1.0.0 ":        false,
+	} {
+		if got := ValidVersion(v); got != want {
+			t.Errorf("ValidVersion(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	for name, want := range map[string]string{
+		"Clear Skill Effects": "clear-skill-effects",
+		"(HD) UI_v2":          "hd-ui-v2",
+		"高清":                  "",
+	} {
+		if got := Slug(name); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	npkPath := writeFile(t, dir, "a.npk", emptyNPK)
+	bad := writeFile(t, dir, "b.npk", []byte("not an npk"))
+	preview := writeFile(t, dir, "a.png", []byte("png"))
+	good := Info{ID: "a", Name: "A", Version: "1.0.0", Files: []string{npkPath}, Previews: []string{preview}}
+	if err := good.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, change := range map[string]func(*Info){
+		"no name":         func(i *Info) { i.Name = " " },
+		"bad id":          func(i *Info) { i.ID = "A B" },
+		"bad version":     func(i *Info) { i.Version = "1.0" },
+		"no files":        func(i *Info) { i.Files = nil },
+		"not an npk":      func(i *Info) { i.Files = []string{bad} },
+		"missing preview": func(i *Info) { i.Previews = []string{filepath.Join(dir, "missing.png")} },
+		"preview type":    func(i *Info) { i.Previews = []string{npkPath} },
+		"duplicate":       func(i *Info) { i.Files = []string{npkPath, npkPath} },
+	} {
+		info := good
+		change(&info)
+		if err := info.Validate(); err == nil {
+			t.Errorf("%s: Validate succeeded", name)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	info := Info{
+		ID:       "ui",
+		Name:     "UI",
+		Version:  "1.2.0",
+		Tags:     []string{"ui"},
+		Files:    []string{writeFile(t, dir, "Main HUD.npk", emptyNPK), writeFile(t, dir, "icons.npk", emptyNPK)},
+		Previews: []string{writeFile(t, dir, "shot.jpg", []byte("jpg"))},
+	}
+	var buf bytes.Buffer
+	m, err := Build(&buf, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Patches) != 2 {
+		t.Fatalf("got %d patches, want 2", len(m.Patches))
+	}
+	first := m.Patches[0]
+	if first.ID != "ui-main-hud" || first.Name != "UI (Main HUD)" || first.Filename != "Main HUD.npk" {
+		t.Errorf("first patch = %q %q %q", first.ID, first.Name, first.Filename)
+	}
+	if first.SizeBytes != int64(len(emptyNPK)) || len(first.Sha256) != 64 {
+		t.Errorf("size %d, checksum %q", first.SizeBytes, first.Sha256)
+	}
+	if len(first.Previews) != 1 || first.Previews[0].URL != "previews/shot.jpg" {
+		t.Errorf("previews = %v", first.Previews)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		names[f.Name] = f
+	}
+	for _, name := range []string{"Main HUD.npk", "icons.npk", "previews/shot.jpg", ManifestName} {
+		if names[name] == nil {
+			t.Errorf("%s is missing from the bundle", name)
+		}
+	}
+	rc, err := names[ManifestName].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	var got Manifest
+	if err := json.NewDecoder(rc).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Patches) != 2 || got.Patches[1].ID != "ui-icons" {
+		t.Errorf("manifest = %+v", got)
+	}
+}
+
+func TestAddToRepository(t *testing.T) {
+	dir := t.TempDir()
+	info := Info{
+		ID:       "hud",
+		Name:     "HUD",
+		Version:  "1.0.0",
+		Files:    []string{writeFile(t, dir, "hud.npk", emptyNPK)},
+		Previews: []string{writeFile(t, dir, "hud.png", []byte("png"))},
+	}
+	m, err := Build(io.Discard, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(dir, "repo", "patches.json")
+	if err := AddToRepository(repo, "UI", m, info); err != nil {
+		t.Fatal(err)
+	}
+	info.Version = "1.1.0"
+	if m, err = Build(io.Discard, info); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToRepository(repo, "UI", m, info); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := patchdb.Load(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Categories) != 1 || len(db.Categories[0].Patches) != 1 {
+		t.Fatalf("repository = %+v", db)
+	}
+	patch := db.Categories[0].Patches[0]
+	if patch.Version != "1.1.0" {
+		t.Errorf("version = %q, want the replaced entry", patch.Version)
+	}
+	for _, path := range []string{filepath.Join(dir, "repo", "patches", "hud.npk"), patch.Previews[0].URL} {
+		if _, err := os.Stat(path); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package bundle
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/patchdb"
+)
+
+// maxManifestSize bounds the manifest read from a bundle.
+const maxManifestSize = 1 << 20
+
+// ErrNoManifest is returned by Open for ZIP files without a manifest.json.
+var ErrNoManifest = errors.New("the archive has no " + ManifestName)
+
+// Bundle is an opened patch bundle whose manifest was checked against its
+// files.
+type Bundle struct {
+	Manifest
+	r     *zip.ReadCloser
+	files map[string]*zip.File
+}
+
+// IsBundle reports whether path is a ZIP file with a manifest.json.
+func IsBundle(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return false
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reads the bundle at path and checks that every file its manifest
+// refers to is in it, with the size and checksum the manifest gives, so
+// nothing needs to be written before a broken bundle is found.
+func Open(path string) (*Bundle, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{r: r, files: make(map[string]*zip.File)}
+	for _, f := range r.File {
+		b.files[f.Name] = f
+	}
+	if err := b.readManifest(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if err := b.check(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Close closes the bundle file.
+func (b *Bundle) Close() error {
+	return b.r.Close()
+}
+
+func (b *Bundle) readManifest() error {
+	f := b.files[ManifestName]
+	if f == nil {
+		return ErrNoManifest
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxManifestSize))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return fmt.Errorf("reading %s: %v", ManifestName, err)
+	}
+	if len(b.Patches) == 0 {
+		return fmt.Errorf("%s lists no patches", ManifestName)
+	}
+	return nil
+}
+
+func (b *Bundle) check() error {
+	filenames := make(map[string]bool)
+	for _, patch := range b.Patches {
+		if !ValidID(patch.ID) || strings.TrimSpace(patch.Name) == "" {
+			return fmt.Errorf("%s: patch %q needs an ID and a name", ManifestName, patch.ID)
+		}
+		// NPK files only ever go to imagepack2, so a path is refused
+		if patch.Filename != filepath.Base(patch.Filename) || strings.ContainsAny(patch.Filename, `/\`) ||
+			!strings.EqualFold(filepath.Ext(patch.Filename), ".npk") {
+			return fmt.Errorf("%s: %q is not an NPK file name", patch.ID, patch.Filename)
+		}
+		if filenames[strings.ToLower(patch.Filename)] {
+			return fmt.Errorf("%s: %s is listed twice", patch.ID, patch.Filename)
+		}
+		filenames[strings.ToLower(patch.Filename)] = true
+
+		f := b.files[patch.Filename]
+		if f == nil {
+			return fmt.Errorf("%s: %s is missing from the bundle", patch.ID, patch.Filename)
+		}
+		if err := checkFile(patch, f); err != nil {
+			return fmt.Errorf("%s: %w", patch.Filename, err)
+		}
+		for _, preview := range patch.Previews {
+			if !strings.Contains(preview.URL, "://") && b.files[preview.URL] == nil {
+				return fmt.Errorf("%s: preview %s is missing from the bundle", patch.ID, preview.URL)
+			}
+		}
+	}
+	return nil
+}
+
+// checkFile compares the file of patch in the bundle with the size and
+// checksum in the manifest.
+func checkFile(patch patchdb.Patch, f *zip.File) error {
+	if err := patchdb.CheckSize(patch, int64(f.UncompressedSize64)); err != nil {
+		return err
+	}
+	if patch.Sha256 == "" {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return err
+	}
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), patch.Sha256) {
+		return patchdb.ErrChecksumMismatch
+	}
+	return nil
+}
+
+// Extract writes the file at name in the bundle, such as a patch's NPK or
+// one of its previews, to dst.
+func (b *Bundle) Extract(name, dst string) error {
+	f := b.files[name]
+	if f == nil {
+		return fmt.Errorf("%s is missing from the bundle", name)
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := fsutil.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = fsutil.Copy(context.Background(), out, src, nil)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
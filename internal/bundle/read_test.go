@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dnf_patch/internal/patchdb"
+)
+
+// writeZip writes a ZIP holding files to dir/name.
+func writeZip(t *testing.T, dir, name string, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	info := Info{
+		ID:       "hud",
+		Name:     "HUD",
+		Version:  "1.0.0",
+		Files:    []string{writeFile(t, dir, "hud.npk", emptyNPK)},
+		Previews: []string{writeFile(t, dir, "hud.png", []byte("png"))},
+	}
+	path := filepath.Join(dir, "hud-1.0.0.zip")
+	if _, err := BuildFile(path, info); err != nil {
+		t.Fatal(err)
+	}
+	if !IsBundle(path) {
+		t.Error("IsBundle() = false for a built bundle")
+	}
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if len(b.Patches) != 1 || b.Patches[0].ID != "hud" || b.Patches[0].Version != "1.0.0" {
+		t.Errorf("manifest = %+v", b.Manifest)
+	}
+	dst := filepath.Join(dir, "out", "hud.npk")
+	if err := b.Extract("hud.npk", dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != string(emptyNPK) {
+		t.Errorf("extracted %q", data)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	dir := t.TempDir()
+	checksum := strings.Repeat("0", 64)
+	for name, files := range map[string]map[string]string{
+		"no manifest":     {"a.npk": "npk"},
+		"no patches":      {ManifestName: `{"patches": []}`},
+		"missing file":    {ManifestName: `{"patches": [{"id": "a", "name": "A", "filename": "a.npk"}]}`},
+		"path":            {ManifestName: `{"patches": [{"id": "a", "name": "A", "filename": "../a.npk"}]}`, "../a.npk": "npk"},
+		"not an npk":      {ManifestName: `{"patches": [{"id": "a", "name": "A", "filename": "a.dll"}]}`, "a.dll": "dll"},
+		"no name":         {ManifestName: `{"patches": [{"id": "a", "filename": "a.npk"}]}`, "a.npk": "npk"},
+		"checksum":        {ManifestName: `{"patches": [{"id": "a", "name": "A", "filename": "a.npk", "sha256": "` + checksum + `"}]}`, "a.npk": "npk"},
+		"missing preview": {ManifestName: `{"patches": [{"id": "a", "name": "A", "filename": "a.npk", "previews": [{"url": "previews/a.png"}]}]}`, "a.npk": "npk"},
+		"listed twice":    {ManifestName: `{"patches": [{"id": "a", "name": "A", "filename": "a.npk"}, {"id": "b", "name": "B", "filename": "A.npk"}]}`, "a.npk": "npk"},
+	} {
+		path := writeZip(t, dir, "bundle.zip", files)
+		b, err := Open(path)
+		if err == nil {
+			b.Close()
+			t.Errorf("%s: Open succeeded", name)
+		}
+		if name == "checksum" && !errors.Is(err, patchdb.ErrChecksumMismatch) {
+			t.Errorf("checksum: Open error = %v, want %v", err, patchdb.ErrChecksumMismatch)
+		}
+		if name == "no manifest" && (err != ErrNoManifest || IsBundle(path)) {
+			t.Errorf("no manifest: Open error = %v, IsBundle = %v", err, IsBundle(path))
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !linux
+
+package cachedir
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns when the file described by info was last modified, as
+// access times are not read on this system.
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,15 @@
+package cachedir
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns when the file described by info was last accessed.
+func accessTime(info os.FileInfo) time.Time {
+	if d, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return time.Unix(0, d.LastAccessTime.Nanoseconds())
+	}
+	return info.ModTime()
+}
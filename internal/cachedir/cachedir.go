@@ -0,0 +1,100 @@
+// Package cachedir measures the cache folder and frees space in it, deleting
+// the least recently used files first and leaving alone those it is told to
+// keep.
+package cachedir
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// File is a file in the cache.
+type File struct {
+	Path     string
+	Size     int64
+	Accessed time.Time // when the file was last read or written
+}
+
+// List returns the files under dir, leaving out those keep claims and
+// everything in the folders it claims. A missing dir holds no files.
+func List(dir string, keep func(path string) bool) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(fsutil.LongPath(dir), func(long string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && long == fsutil.LongPath(dir) {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(fsutil.LongPath(dir), long)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, rel)
+		if rel != "." && keep(path) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: path, Size: info.Size(), Accessed: accessTime(info)})
+		return nil
+	})
+	return files, err
+}
+
+// Total returns the size of files.
+func Total(files []File) int64 {
+	var n int64
+	for _, f := range files {
+		n += f.Size
+	}
+	return n
+}
+
+// Trim deletes the least recently accessed of files until the rest add up to
+// at most limit bytes, and returns those it deleted. A limit of 0 deletes
+// them all. Files that cannot be deleted are skipped and their errors
+// returned together.
+func Trim(files []File, limit int64) ([]File, error) {
+	sorted := append([]File(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Accessed.Before(sorted[j].Accessed) })
+	total := Total(sorted)
+	var removed []File
+	var errs []error
+	for _, f := range sorted {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(fsutil.LongPath(f.Path)); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			continue
+		}
+		total -= f.Size
+		removed = append(removed, f)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Touch marks the file at path as accessed now, as reads are not recorded on
+// every file system. Its modification time is kept.
+func Touch(path string) error {
+	info, err := os.Stat(fsutil.LongPath(path))
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(fsutil.LongPath(path), time.Now(), info.ModTime())
+}
@@ -0,0 +1,83 @@
+package cachedir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string, accessed time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, accessed, accessed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(dir, "downloads", "a.npk"), "12345", day)
+	writeFile(t, filepath.Join(dir, "icons", "b.png"), "123", day)
+	writeFile(t, filepath.Join(dir, "patches", "c.npk"), "kept", day)
+	writeFile(t, filepath.Join(dir, "patches.json"), "kept", day)
+
+	keep := func(path string) bool {
+		return path == filepath.Join(dir, "patches") || path == filepath.Join(dir, "patches.json")
+	}
+	files, err := List(dir, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || Total(files) != 8 {
+		t.Fatalf("List() = %+v, want a.npk and b.png", files)
+	}
+	if files[0].Path != filepath.Join(dir, "downloads", "a.npk") || !files[0].Accessed.Equal(day) {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+
+	files, err = List(filepath.Join(dir, "missing"), keep)
+	if err != nil || len(files) != 0 {
+		t.Errorf("List(missing) = %v, %v, want nothing", files, err)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(dir, "old.npk"), "12345", day)
+	writeFile(t, filepath.Join(dir, "used.npk"), "12345", day.Add(time.Hour))
+	writeFile(t, filepath.Join(dir, "new.npk"), "12345", day.Add(2*time.Hour))
+	if err := Touch(filepath.Join(dir, "used.npk")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := List(dir, func(string) bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := Trim(files, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || filepath.Base(removed[0].Path) != "old.npk" || filepath.Base(removed[1].Path) != "new.npk" {
+		t.Fatalf("Trim() removed %+v, want old.npk then new.npk", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "used.npk")); err != nil {
+		t.Errorf("the file used last was removed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "used.npk")); err == nil && !info.ModTime().Equal(day.Add(time.Hour)) {
+		t.Errorf("Touch() changed the modification time to %v", info.ModTime())
+	}
+
+	files, _ = List(dir, func(string) bool { return false })
+	if removed, err := Trim(files, 0); err != nil || len(removed) != 1 {
+		t.Errorf("Trim(0) = %+v, %v, want the last file removed", removed, err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package cleanup finds what older versions of the tool and interrupted
+// operations leave behind: backup_<time> folders in the game directory and
+// temporary files.
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// Kind tells what an Item is.
+type Kind int
+
+const (
+	// LegacyBackup is a backup_<time> folder installs used to create in the
+	// game directory for the files they replaced.
+	LegacyBackup Kind = iota
+	// UnrecordedBackup is a folder in the backup path no recorded backup
+	// owns.
+	UnrecordedBackup
+	// TempFile is a .tmp file of an interrupted write or a .part file of a
+	// download that is no longer queued.
+	TempFile
+)
+
+// legacyPrefix starts the names of legacy backup folders, followed by the
+// time in legacyLayout.
+const (
+	legacyPrefix = "backup_"
+	legacyLayout = "20060102_150405"
+)
+
+// Item is something the cleanup can delete.
+type Item struct {
+	Kind Kind
+	Path string
+	Size int64
+	Time time.Time // when the folder or file was written
+}
+
+// Remove deletes the file or folder of item, moving it to the recycle bin
+// when toTrash is set and the volume has one. trashed reports whether it
+// was.
+func (item Item) Remove(toTrash bool) (trashed bool, err error) {
+	return fsutil.Delete(item.Path, toTrash)
+}
+
+// LegacyBackups returns the backup_<time> folders in gameDir, oldest first.
+func LegacyBackups(gameDir string) ([]Item, error) {
+	entries, err := os.ReadDir(fsutil.LongPath(gameDir))
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), legacyPrefix) {
+			continue
+		}
+		item, err := Folder(LegacyBackup, filepath.Join(gameDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if t, err := time.ParseInLocation(legacyLayout, strings.TrimPrefix(entry.Name(), legacyPrefix), time.Local); err == nil {
+			item.Time = t
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Time.Before(items[j].Time) })
+	return items, nil
+}
+
+// Folder returns the item of the folder at path, with the size of all the
+// files in it.
+func Folder(kind Kind, path string) (Item, error) {
+	info, err := os.Stat(fsutil.LongPath(path))
+	if err != nil {
+		return Item{}, err
+	}
+	item := Item{Kind: kind, Path: path, Time: info.ModTime()}
+	err = filepath.Walk(fsutil.LongPath(path), func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			item.Size += info.Size()
+		}
+		return nil
+	})
+	return item, err
+}
+
+// TempFiles returns the .tmp and .part files directly in dirs that keep
+// does not claim. Missing folders are skipped.
+func TempFiles(dirs []string, keep func(path string) bool) ([]Item, error) {
+	var items []Item
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(fsutil.LongPath(dir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.Type().IsRegular() || (ext != ".tmp" && ext != ".part") || seen[strings.ToLower(path)] || keep(path) {
+				continue
+			}
+			seen[strings.ToLower(path)] = true
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, Item{Kind: TempFile, Path: path, Size: info.Size(), Time: info.ModTime()})
+		}
+	}
+	return items, nil
+}
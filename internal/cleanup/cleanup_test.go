@@ -0,0 +1,70 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLegacyBackups(t *testing.T) {
+	game := t.TempDir()
+	writeFile(t, filepath.Join(game, "backup_20240301_101500", "a.npk"), "12345")
+	writeFile(t, filepath.Join(game, "backup_20240301_101500", "sub", "b.npk"), "123")
+	writeFile(t, filepath.Join(game, "backup_20230101_000000", "c.npk"), "1")
+	writeFile(t, filepath.Join(game, "backup_notes.txt"), "a file, not a folder")
+	writeFile(t, filepath.Join(game, "ImagePacks2", "a.npk"), "patched")
+
+	items, err := LegacyBackups(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("LegacyBackups() = %+v, want 2 folders", items)
+	}
+	if filepath.Base(items[1].Path) != "backup_20240301_101500" || items[1].Size != 8 {
+		t.Errorf("newest = %+v", items[1])
+	}
+	if want := time.Date(2024, 3, 1, 10, 15, 0, 0, time.Local); !items[1].Time.Equal(want) {
+		t.Errorf("time = %v, want %v", items[1].Time, want)
+	}
+
+	if _, err := items[0].Remove(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(items[0].Path); !os.IsNotExist(err) {
+		t.Errorf("removed folder still exists: %v", err)
+	}
+}
+
+func TestTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.json.123.tmp"), "{")
+	writeFile(t, filepath.Join(dir, "hud.npk.part"), "12")
+	writeFile(t, filepath.Join(dir, "queued.npk.part"), "1")
+	writeFile(t, filepath.Join(dir, "hud.npk"), "npk")
+	writeFile(t, filepath.Join(dir, "sub", "deep.tmp"), "")
+
+	keep := func(path string) bool { return filepath.Base(path) == "queued.npk.part" }
+	items, err := TempFiles([]string{dir, dir, filepath.Join(dir, "missing")}, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, filepath.Base(item.Path))
+	}
+	if len(names) != 2 || names[0] != "config.json.123.tmp" || names[1] != "hud.npk.part" {
+		t.Errorf("TempFiles() = %v", names)
+	}
+}
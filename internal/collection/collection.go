@@ -0,0 +1,226 @@
+// Package collection keeps named sets of patches, such as one for streaming
+// and one for personal play, and works out what switching between them
+// installs and removes.
+package collection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/install"
+)
+
+// Member is a patch of a collection. Hash identifies the exact file, so it
+// can be installed again from the patch cache.
+type Member struct {
+	PatchID   string `json:"patchId"`
+	PatchName string `json:"patchName"`
+	Filename  string `json:"filename"`
+	Hash      string `json:"hash"`
+}
+
+type Collection struct {
+	Name    string   `json:"name"`
+	Members []Member `json:"members"`
+}
+
+// FromRecords returns a collection of the given installed patches.
+func FromRecords(name string, records []install.Record) Collection {
+	c := Collection{Name: name, Members: []Member{}}
+	for _, rec := range records {
+		c.Members = append(c.Members, Member{
+			PatchID:   rec.PatchID,
+			PatchName: rec.PatchName,
+			Filename:  rec.Filename,
+			Hash:      rec.Hash,
+		})
+	}
+	return c
+}
+
+// Parse decodes a collection exported with JSON.
+func Parse(data []byte) (Collection, error) {
+	var c Collection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Collection{}, err
+	}
+	if strings.TrimSpace(c.Name) == "" {
+		return Collection{}, errors.New("the collection has no name")
+	}
+	for _, m := range c.Members {
+		// Shared files must not name anything outside imagepack2
+		if m.Filename == "" || m.Filename == ".." || strings.ContainsAny(m.Filename, `/\:`) {
+			return Collection{}, fmt.Errorf("invalid file name %q in collection %s", m.Filename, c.Name)
+		}
+	}
+	return c, nil
+}
+
+// JSON encodes c for sharing.
+func (c Collection) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "    ")
+}
+
+// Plan is what applying a collection changes.
+type Plan struct {
+	Install []Member         // members not installed yet
+	Remove  []install.Record // installed members of the previous collection left out of the new one
+}
+
+// Empty reports whether applying the plan changes nothing.
+func (p Plan) Empty() bool {
+	return len(p.Install) == 0 && len(p.Remove) == 0
+}
+
+// PlanSwitch works out how to go from the installed patches, set up by the
+// previous collection, which may be nil, to c.
+func PlanSwitch(c Collection, previous *Collection, installed []install.Record) Plan {
+	var plan Plan
+	wanted := make(map[string]bool, len(c.Members))
+	for _, m := range c.Members {
+		wanted[m.PatchID] = true
+		if !isInstalled(m, installed) {
+			plan.Install = append(plan.Install, m)
+		}
+	}
+	if previous == nil {
+		return plan
+	}
+	for _, m := range previous.Members {
+		if wanted[m.PatchID] {
+			continue
+		}
+		for _, rec := range installed {
+			if rec.PatchID == m.PatchID && strings.EqualFold(rec.Filename, m.Filename) {
+				plan.Remove = append(plan.Remove, rec)
+			}
+		}
+	}
+	return plan
+}
+
+func isInstalled(m Member, installed []install.Record) bool {
+	for _, rec := range installed {
+		if strings.EqualFold(rec.Filename, m.Filename) && (rec.Hash == m.Hash || m.Hash == "" && rec.PatchID == m.PatchID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is the list of saved collections of a profile and the name of the
+// one last applied, kept in a JSON file. It is safe for concurrent use.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data storeData
+}
+
+type storeData struct {
+	Active      string       `json:"active"`
+	Collections []Collection `json:"collections"`
+}
+
+// NewStore returns an empty store kept at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = storeData{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.data)
+}
+
+// Collections returns a copy of the saved collections.
+func (s *Store) Collections() []Collection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Collection(nil), s.data.Collections...)
+}
+
+// Active returns the collection applied last, if it is still saved.
+func (s *Store) Active() (Collection, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(s.data.Active)
+}
+
+// SetActive records the name of the collection applied last.
+func (s *Store) SetActive(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Active = name
+	return s.save()
+}
+
+// Find returns the collection with the given name.
+func (s *Store) Find(name string) (Collection, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(name)
+}
+
+// find is Find for callers holding s.mu.
+func (s *Store) find(name string) (Collection, bool) {
+	for _, c := range s.data.Collections {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Collection{}, false
+}
+
+// Put saves c, replacing the collection of the same name.
+func (s *Store) Put(c Collection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.Collections {
+		if s.data.Collections[i].Name == c.Name {
+			s.data.Collections[i] = c
+			return s.save()
+		}
+	}
+	s.data.Collections = append(s.data.Collections, c)
+	return s.save()
+}
+
+// Delete removes the collection with the given name.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.Collections {
+		if s.data.Collections[i].Name == name {
+			s.data.Collections = append(s.data.Collections[:i:i], s.data.Collections[i+1:]...)
+			return s.save()
+		}
+	}
+	return nil
+}
+
+// save writes the store. The caller must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.data, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(s.path, data, 0644)
+}
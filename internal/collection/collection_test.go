@@ -0,0 +1,80 @@
+package collection
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dnf_patch/internal/install"
+)
+
+func TestPlanSwitch(t *testing.T) {
+	installed := []install.Record{
+		{PatchID: "clean-ui", Filename: "sprite_interface.npk", Hash: "ui"},
+		{PatchID: "effects", Filename: "sprite_skill.npk", Hash: "fx"},
+		{PatchID: "manual", Filename: "manual.npk", Hash: "m"},
+	}
+	streaming := FromRecords("Streaming", installed[:2])
+	play := Collection{Name: "Play", Members: []Member{
+		{PatchID: "effects", Filename: "sprite_skill.npk", Hash: "fx"},
+		{PatchID: "cosmetics", Filename: "sprite_avatar.npk", Hash: "av"},
+	}}
+
+	plan := PlanSwitch(play, &streaming, installed)
+	if want := play.Members[1:]; !reflect.DeepEqual(plan.Install, want) {
+		t.Errorf("Install = %+v, want %+v", plan.Install, want)
+	}
+	// Patches installed outside the collections are left alone
+	if want := installed[:1]; !reflect.DeepEqual(plan.Remove, want) {
+		t.Errorf("Remove = %+v, want %+v", plan.Remove, want)
+	}
+
+	if plan := PlanSwitch(play, nil, installed); len(plan.Remove) != 0 {
+		t.Errorf("Remove without a previous collection = %+v, want none", plan.Remove)
+	}
+	if plan := PlanSwitch(streaming, &streaming, installed); !plan.Empty() {
+		t.Errorf("applying the active collection again = %+v, want an empty plan", plan)
+	}
+}
+
+func TestStoreAndJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collections.json")
+	store := NewStore(path)
+	c := Collection{Name: "Streaming", Members: []Member{{PatchID: "clean-ui", Filename: "sprite_interface.npk", Hash: "ui"}}}
+	if err := store.Put(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(Collection{Name: "Play"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("Play"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetActive("Streaming"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewStore(path)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := reloaded.Collections(); len(got) != 1 || !reflect.DeepEqual(got[0], c) {
+		t.Errorf("Collections() = %+v, want only %+v", got, c)
+	}
+	if active, ok := reloaded.Active(); !ok || active.Name != "Streaming" {
+		t.Errorf("Active() = %+v, %v, want Streaming", active, ok)
+	}
+
+	data, err := c.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Parse(data); err != nil || !reflect.DeepEqual(got, c) {
+		t.Errorf("Parse(JSON()) = %+v, %v", got, err)
+	}
+	for _, bad := range []string{`{"members": []}`, `{"name": "x", "members": [{"filename": "..\\\\..\\\\evil.npk"}]}`, `{"name": "x", "members": [{"filename": "../evil.npk"}]}`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%s) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package config reads and writes config.json, the settings of DNF Patch,
+// upgrading configs written by older versions. The settings themselves are
+// declared by the caller; this package only knows the file.
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/schema"
+)
+
+// File is the format of config.json. Version 1 only added the version
+// itself; the game path of configs from before profiles is moved into a
+// profile by the caller.
+var File = schema.File{
+	Version:    1,
+	Migrations: []schema.Migration{schema.Unchanged},
+}
+
+// Load reads the config at path into v, upgrading it from older formats.
+// When there is none, the one older versions kept at legacyPath is moved to
+// path and read instead; without either, v is left as it is. A config of a
+// newer version is refused with a *schema.NewerError and not written over.
+func Load(path, legacyPath string, v any) error {
+	data, err := File.Read(path)
+	if os.IsNotExist(err) {
+		if legacyPath == path {
+			return nil
+		}
+		data, err = moveLegacy(legacyPath, path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// moveLegacy moves the config at legacyPath to path, upgrading it, and
+// returns it.
+func moveLegacy(legacyPath, path string) ([]byte, error) {
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		return nil, err
+	}
+	if data, _, err = File.Upgrade(data); err != nil {
+		if newer, ok := err.(*schema.NewerError); ok {
+			newer.Path = legacyPath
+		}
+		return nil, err
+	}
+	if err := write(path, data); err != nil {
+		return nil, err
+	}
+	return data, os.Remove(legacyPath)
+}
+
+// Save writes v to path. Its schemaVersion field should be File.Version.
+func Save(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return write(path, data)
+}
+
+func write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dnf_patch/internal/schema"
+)
+
+// settings holds the fields of config.json the tests look at.
+type settings struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	DNFPath       string   `json:"dnfPath"`
+	RecentPaths   []string `json:"recentPaths"`
+	Language      string   `json:"language"`
+	MaxDownloads  int      `json:"maxDownloads"`
+}
+
+var fixture = settings{
+	SchemaVersion: 1,
+	DNFPath:       `C:\WeGameApps\地下城与勇士`,
+	RecentPaths:   []string{`C:\WeGameApps\地下城与勇士`},
+	Language:      "zh-CN",
+	MaxDownloads:  3,
+}
+
+func copyFixture(t *testing.T, version int, path string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("config.v%d.json", version)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLoadEverySchema loads the config.json fixtures of every format it has
+// had, checks that each reads the same and that a reload of the upgraded file
+// does too.
+func TestLoadEverySchema(t *testing.T) {
+	for v := 0; v <= File.Version; v++ {
+		path := filepath.Join(t.TempDir(), "config.json")
+		copyFixture(t, v, path)
+		for _, pass := range []string{"fixture", "upgraded"} {
+			var got settings
+			if err := Load(path, path, &got); err != nil {
+				t.Fatalf("v%d %s: Load() error = %v", v, pass, err)
+			}
+			if !reflect.DeepEqual(got, fixture) {
+				t.Errorf("v%d %s: Load() = %+v, want %+v", v, pass, got, fixture)
+			}
+		}
+		if _, err := os.Stat(schema.BackupPath(path)); (v < File.Version) != (err == nil) {
+			t.Errorf("v%d: copy before upgrading: %v", v, err)
+		}
+	}
+}
+
+func TestLoadMovesLegacyConfig(t *testing.T) {
+	legacyPath := filepath.Join(t.TempDir(), "config.json")
+	path := filepath.Join(t.TempDir(), "DNFPatch", "config.json")
+	copyFixture(t, 0, legacyPath)
+
+	var got settings
+	if err := Load(path, legacyPath, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, fixture) {
+		t.Errorf("Load() = %+v, want %+v", got, fixture)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("legacy config left behind: %v", err)
+	}
+	if v, err := schema.Version(mustRead(t, path)); err != nil || v != File.Version {
+		t.Errorf("moved config is of version %d, %v, want %d", v, err, File.Version)
+	}
+}
+
+func TestLoadWithoutConfig(t *testing.T) {
+	dir := t.TempDir()
+	got := settings{Language: "auto"}
+	if err := Load(filepath.Join(dir, "config.json"), filepath.Join(dir, "legacy.json"), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Language != "auto" {
+		t.Errorf("Load() changed the defaults to %+v", got)
+	}
+}
+
+func TestRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	doc := `{"schemaVersion": 99, "language": "en"}`
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := settings{Language: "auto"}
+	var newer *schema.NewerError
+	if err := Load(path, path, &got); !errors.As(err, &newer) {
+		t.Fatalf("Load() error = %v, want a NewerError", err)
+	}
+	if newer.Path != path || got.Language != "auto" {
+		t.Errorf("Load() = %+v, %v", got, newer)
+	}
+	if data := mustRead(t, path); string(data) != doc {
+		t.Errorf("config.json = %s, want it left as it was", data)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "DNFPatch", "config.json")
+	if err := Save(path, fixture); err != nil {
+		t.Fatal(err)
+	}
+	var got settings
+	if err := Load(path, path, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, fixture) {
+		t.Errorf("Load() after Save() = %+v, want %+v", got, fixture)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,115 @@
+// Package deeplink parses dnfpatch:// links, which let web pages offer to
+// install or show a patch with one click. Links come from any web page, so
+// everything in them is checked before it is used.
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Scheme is the URL scheme of the links.
+const Scheme = "dnfpatch"
+
+// maxLength bounds a link; anything longer is not one this tool produces.
+const maxLength = 2048
+
+// Actions a link can ask for.
+const (
+	Install = "install" // download and install a patch
+	Open    = "open"    // show the details of a catalogue patch
+)
+
+var (
+	validID       = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+	validFilename = regexp.MustCompile(`^[^\\/:*?"<>|\x00-\x1f]{1,200}\.(?i:npk|zip)$`)
+	validSource   = regexp.MustCompile(`^[A-Za-z0-9.-]{1,253}$`)
+)
+
+// ErrInvalid is wrapped by the errors of Parse.
+var ErrInvalid = errors.New("invalid dnfpatch link")
+
+// Link is a parsed link. Exactly one of PatchID and URL is set.
+type Link struct {
+	Action  string
+	PatchID string // a patch of the catalogue
+	URL     string // a patch file to download, http or https
+	// Filename is the name the file at URL is saved as.
+	Filename string
+	// Source is the site the link says it comes from, the host of URL when
+	// it does not say. It is only shown to the user, never trusted.
+	Source string
+}
+
+// IsLink reports whether s looks like a dnfpatch:// link, as opposed to a
+// file path.
+func IsLink(s string) bool {
+	return strings.HasPrefix(strings.ToLower(s), Scheme+":")
+}
+
+// Parse checks and decodes a link:
+//
+//	dnfpatch://install?id=<patch id>[&source=<site>]
+//	dnfpatch://install?url=<https URL of a .npk or .zip file>[&source=<site>]
+//	dnfpatch://open?id=<patch id>[&source=<site>]
+func Parse(raw string) (Link, error) {
+	if len(raw) > maxLength {
+		return Link{}, fmt.Errorf("%w: longer than %d characters", ErrInvalid, maxLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if !strings.EqualFold(u.Scheme, Scheme) {
+		return Link{}, fmt.Errorf("%w: scheme %q", ErrInvalid, u.Scheme)
+	}
+	// Browsers hand over dnfpatch://install/?… and dnfpatch:install?… too
+	action := strings.ToLower(strings.Trim(u.Host+u.Opaque+u.Path, "/"))
+	if action != Install && action != Open {
+		return Link{}, fmt.Errorf("%w: unknown action %q", ErrInvalid, action)
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return Link{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for key, values := range query {
+		if len(values) > 1 {
+			return Link{}, fmt.Errorf("%w: %s given more than once", ErrInvalid, key)
+		}
+	}
+
+	link := Link{Action: action, PatchID: query.Get("id"), URL: query.Get("url"), Source: query.Get("source")}
+	switch {
+	case link.PatchID != "" && link.URL != "":
+		return Link{}, fmt.Errorf("%w: both id and url given", ErrInvalid)
+	case link.PatchID != "":
+		if !validID.MatchString(link.PatchID) {
+			return Link{}, fmt.Errorf("%w: patch id %q", ErrInvalid, link.PatchID)
+		}
+	case link.URL != "":
+		if action != Install {
+			return Link{}, fmt.Errorf("%w: %s needs a patch id", ErrInvalid, action)
+		}
+		file, err := url.Parse(link.URL)
+		if err != nil || (file.Scheme != "http" && file.Scheme != "https") || file.Host == "" || file.User != nil {
+			return Link{}, fmt.Errorf("%w: download URL %q", ErrInvalid, link.URL)
+		}
+		link.Filename = path.Base(file.Path)
+		if !validFilename.MatchString(link.Filename) {
+			return Link{}, fmt.Errorf("%w: %q is not a patch file", ErrInvalid, link.Filename)
+		}
+		if link.Source == "" {
+			link.Source = file.Hostname()
+		}
+	default:
+		return Link{}, fmt.Errorf("%w: no patch id or url", ErrInvalid)
+	}
+	if link.Source != "" && !validSource.MatchString(link.Source) {
+		return Link{}, fmt.Errorf("%w: source %q", ErrInvalid, link.Source)
+	}
+	return link, nil
+}
@@ -0,0 +1,67 @@
+package deeplink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		raw  string
+		want Link
+	}{
+		{"dnfpatch://install?id=dark-ui&source=example.com",
+			Link{Action: Install, PatchID: "dark-ui", Source: "example.com"}},
+		{"DNFPatch://Install/?id=dark-ui",
+			Link{Action: Install, PatchID: "dark-ui"}},
+		{"dnfpatch:open?id=dark_ui.v2",
+			Link{Action: Open, PatchID: "dark_ui.v2"}},
+		{"dnfpatch://install?url=https%3A%2F%2Fcdn.example.com%2Fp%2FDark%20UI.npk",
+			Link{Action: Install, URL: "https://cdn.example.com/p/Dark UI.npk", Filename: "Dark UI.npk", Source: "cdn.example.com"}},
+		{"dnfpatch://install?url=http%3A%2F%2Fexample.com%2Fpack.ZIP&source=forum.example.org",
+			Link{Action: Install, URL: "http://example.com/pack.ZIP", Filename: "pack.ZIP", Source: "forum.example.org"}},
+	} {
+		got, err := Parse(tt.raw)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"https://install?id=dark-ui",
+		"dnfpatch://uninstall?id=dark-ui",
+		"dnfpatch://install",
+		"dnfpatch://install?id=",
+		"dnfpatch://install?id=../../etc",
+		"dnfpatch://install?id=a&id=b",
+		"dnfpatch://install?id=a&url=https%3A%2F%2Fexample.com%2Fa.npk",
+		"dnfpatch://open?url=https%3A%2F%2Fexample.com%2Fa.npk",
+		"dnfpatch://install?url=file%3A%2F%2F%2FC%3A%2Fa.npk",
+		"dnfpatch://install?url=https%3A%2F%2Fexample.com%2Fsetup.exe",
+		"dnfpatch://install?url=https%3A%2F%2Fexample.com%2F",
+		"dnfpatch://install?url=https%3A%2F%2Fuser%3Apass%40example.com%2Fa.npk",
+		"dnfpatch://install?id=dark-ui&source=%3Cscript%3E",
+		"dnfpatch://install?id=dark-ui&source=" + strings.Repeat("a", maxLength),
+	} {
+		if link, err := Parse(raw); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %+v, %v, want an invalid link", raw, link, err)
+		}
+	}
+}
+
+func TestIsLink(t *testing.T) {
+	for s, want := range map[string]bool{
+		"dnfpatch://install?id=x": true,
+		"DNFPATCH:open?id=x":      true,
+		`C:\patches\dark.npk`:     false,
+		"/home/me/dnfpatch.npk":   false,
+	} {
+		if got := IsLink(s); got != want {
+			t.Errorf("IsLink(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+// Package download fetches patch files over HTTP in a queue, running a
+// limited number of transfers at once under a shared speed limit. Paused and
+// interrupted downloads keep their partial file and continue where they
+// stopped.
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// ErrChecksum is the error of a download whose file does not match its
+// expected SHA-256 hash.
+var ErrChecksum = errors.New("the downloaded file does not match its checksum")
+
+type State int
+
+const (
+	Queued State = iota
+	Active
+	Paused
+	Done
+	Failed
+	Cancelled
+)
+
+var stateNames = []string{"queued", "active", "paused", "done", "failed", "cancelled"}
+
+func (s State) String() string {
+	if s >= 0 && int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *State) UnmarshalText(text []byte) error {
+	for i, name := range stateNames {
+		if name == string(text) {
+			*s = State(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown download state %q", text)
+}
+
+// Finished reports whether the download has stopped for good. Failed
+// downloads can still be resumed.
+func (s State) Finished() bool {
+	return s == Done || s == Failed || s == Cancelled
+}
+
+// Item is a snapshot of a download.
+type Item struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Path  string `json:"path"`          // where the file is saved once complete
+	Tag   string `json:"tag,omitempty"` // for the caller, kept with the download
+	State State  `json:"state"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"` // -1 while unknown
+	// Finished is when the download completed, failed or was cancelled.
+	Finished time.Time `json:"finished,omitempty"`
+	Error    string    `json:"error,omitempty"` // why it failed
+	// Sha256 is the expected hash of the complete file, if known. Corrupt
+	// is set when the download failed because the file did not match it.
+	Sha256  string `json:"sha256,omitempty"`
+	Corrupt bool   `json:"corrupt,omitempty"`
+
+	Speed float64 `json:"-"` // bytes per second, averaged over the last few seconds
+}
+
+// Remaining estimates how long the download still takes at its current
+// speed. It returns -1 when that is unknown.
+func (i Item) Remaining() time.Duration {
+	if i.State != Active || i.Total < 0 || i.Speed <= 0 {
+		return -1
+	}
+	return time.Duration(float64(i.Total-i.Done) / i.Speed * float64(time.Second))
+}
+
+// PartPath returns where the incomplete file of a download to path is kept.
+func PartPath(path string) string {
+	return path + ".part"
+}
+
+// reportInterval is how often progress of a transfer is reported.
+const reportInterval = 250 * time.Millisecond
+
+// Manager runs the queued downloads in order, at most MaxActive at a time.
+// Its methods are safe for concurrent use.
+type Manager struct {
+	// OnChange, if set, is called whenever a download is added, makes
+	// progress or changes state. It is called without locks held, from the
+	// goroutine doing the work.
+	OnChange func()
+	// OnFinished, if set, is called once with each download that completed,
+	// failed or was cancelled.
+	OnFinished func(Item)
+	// OnError, if set, is called when the downloads cannot be saved.
+	OnError func(err error)
+
+	client  func() (*http.Client, error)
+	limiter *Limiter
+
+	mu        sync.Mutex
+	maxActive int
+	active    int
+	items     []*entry // in priority order
+	seq       int
+	path      string // where the downloads are saved, empty for nowhere
+}
+
+type entry struct {
+	Item
+	cancel context.CancelFunc
+	pause  bool // cancelled to be paused rather than dropped
+}
+
+// NewManager returns a manager fetching with the clients returned by client,
+// so proxy settings apply to downloads started after a change, and limited
+// by limiter.
+func NewManager(client func() (*http.Client, error), limiter *Limiter, maxActive int) *Manager {
+	return &Manager{client: client, limiter: limiter, maxActive: max(1, maxActive)}
+}
+
+// Open loads the downloads saved at path and from then on saves them there
+// whenever one is added or changes state. Downloads that were running or
+// queued when they were saved are queued again and continue from their
+// partial file.
+func (m *Manager) Open(path string) error {
+	m.mu.Lock()
+	defer m.changed()
+	defer m.mu.Unlock()
+	m.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var items []Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("reading %s: %v", path, err)
+	}
+	for _, item := range items {
+		if item.State == Active {
+			item.State = Queued
+		}
+		m.items = append(m.items, &entry{Item: item})
+	}
+	m.schedule()
+	return nil
+}
+
+// save writes the downloads, except cancelled ones, to m.path. The caller
+// must hold m.mu.
+func (m *Manager) save() {
+	if m.path == "" {
+		return
+	}
+	items := []Item{}
+	for _, e := range m.items {
+		if e.State != Cancelled {
+			items = append(items, e.Item)
+		}
+	}
+	data, err := json.MarshalIndent(items, "", "    ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(m.path), 0755)
+	}
+	if err == nil {
+		err = fsutil.WriteFileAtomic(m.path, data, 0644)
+	}
+	if err != nil && m.OnError != nil {
+		m.OnError(err)
+	}
+}
+
+// Limiter returns the speed limit shared by the downloads.
+func (m *Manager) Limiter() *Limiter {
+	return m.limiter
+}
+
+// MaxActive returns how many downloads run at once.
+func (m *Manager) MaxActive() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.maxActive
+}
+
+// SetMaxActive changes how many downloads run at once. Lowering it lets the
+// running downloads finish.
+func (m *Manager) SetMaxActive(n int) {
+	m.mu.Lock()
+	m.maxActive = max(1, n)
+	m.schedule()
+	m.mu.Unlock()
+	m.changed()
+}
+
+// Add queues the download of url to path and returns its ID. tag is kept
+// with the download for the caller. A non-empty sha256 is checked against the
+// complete file, which is discarded when it does not match.
+func (m *Manager) Add(name, url, path, tag, sha256 string) string {
+	m.mu.Lock()
+	m.seq++
+	e := &entry{Item: Item{
+		ID:     fmt.Sprintf("%d-%d", time.Now().UnixNano(), m.seq),
+		Name:   name,
+		URL:    url,
+		Path:   path,
+		Tag:    tag,
+		Total:  -1,
+		Sha256: sha256,
+	}}
+	m.items = append(m.items, e)
+	m.schedule()
+	m.save()
+	m.mu.Unlock()
+	m.changed()
+	return e.ID
+}
+
+// Items returns the downloads in priority order.
+func (m *Manager) Items() []Item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := make([]Item, len(m.items))
+	for i, e := range m.items {
+		items[i] = e.Item
+	}
+	return items
+}
+
+// Move moves the download with the given ID by delta places in the queue,
+// towards the front when delta is negative. Queued downloads start in queue
+// order.
+func (m *Manager) Move(id string, delta int) {
+	m.mu.Lock()
+	i := m.index(id)
+	if i < 0 {
+		m.mu.Unlock()
+		return
+	}
+	j := min(max(i+delta, 0), len(m.items)-1)
+	e := m.items[i]
+	m.items = append(m.items[:i], m.items[i+1:]...)
+	m.items = append(m.items[:j], append([]*entry{e}, m.items[j:]...)...)
+	m.save()
+	m.mu.Unlock()
+	m.changed()
+}
+
+// Pause stops the download with the given ID, keeping what was downloaded
+// so far, or holds it back from starting.
+func (m *Manager) Pause(id string) {
+	m.mu.Lock()
+	i := m.index(id)
+	if i < 0 {
+		m.mu.Unlock()
+		return
+	}
+	switch e := m.items[i]; e.State {
+	case Active:
+		// run finishes it
+		e.pause = true
+		e.cancel()
+	case Queued:
+		e.State = Paused
+		m.save()
+	}
+	m.mu.Unlock()
+	m.changed()
+}
+
+// Resume queues a paused or failed download again. It continues from its
+// partial file.
+func (m *Manager) Resume(id string) {
+	m.mu.Lock()
+	i := m.index(id)
+	if i < 0 {
+		m.mu.Unlock()
+		return
+	}
+	if e := m.items[i]; e.State == Paused || e.State == Failed {
+		e.State, e.Error, e.Corrupt = Queued, "", false
+		m.schedule()
+		m.save()
+	}
+	m.mu.Unlock()
+	m.changed()
+}
+
+// Cancel stops the download with the given ID, or drops it from the queue,
+// and removes its partial file.
+func (m *Manager) Cancel(id string) {
+	m.mu.Lock()
+	i := m.index(id)
+	if i < 0 {
+		m.mu.Unlock()
+		return
+	}
+	e := m.items[i]
+	switch e.State {
+	case Active:
+		// run finishes it
+		e.pause = false
+		e.cancel()
+		m.mu.Unlock()
+		return
+	case Queued, Paused, Failed:
+		e.State, e.Finished = Cancelled, time.Now().UTC()
+		os.Remove(PartPath(e.Path))
+		item := e.Item
+		m.save()
+		m.mu.Unlock()
+		m.finished(item)
+		return
+	}
+	m.mu.Unlock()
+}
+
+// Remove drops a finished download from the list. The downloaded file is
+// kept.
+func (m *Manager) Remove(id string) {
+	m.mu.Lock()
+	if i := m.index(id); i >= 0 && m.items[i].State.Finished() {
+		m.items = append(m.items[:i], m.items[i+1:]...)
+		m.save()
+	}
+	m.mu.Unlock()
+	m.changed()
+}
+
+// ClearFinished removes the finished downloads from the list.
+func (m *Manager) ClearFinished() {
+	m.mu.Lock()
+	items := m.items[:0]
+	for _, e := range m.items {
+		if !e.State.Finished() {
+			items = append(items, e)
+		}
+	}
+	m.items = items
+	m.save()
+	m.mu.Unlock()
+	m.changed()
+}
+
+// index returns the position of the download with the given ID, or -1. The
+// caller must hold m.mu.
+func (m *Manager) index(id string) int {
+	for i, e := range m.items {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// schedule starts queued downloads while fewer than maxActive run. The
+// caller must hold m.mu.
+func (m *Manager) schedule() {
+	for _, e := range m.items {
+		if m.active >= m.maxActive {
+			return
+		}
+		if e.State != Queued {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		e.State = Active
+		e.cancel = cancel
+		e.pause = false
+		m.active++
+		go m.run(ctx, e)
+	}
+}
+
+func (m *Manager) run(ctx context.Context, e *entry) {
+	err := m.fetch(ctx, e)
+
+	m.mu.Lock()
+	switch {
+	case err == nil:
+		e.State = Done
+	case ctx.Err() != nil && e.pause:
+		e.State = Paused
+	case ctx.Err() != nil:
+		e.State = Cancelled
+		os.Remove(PartPath(e.Path))
+	default:
+		e.State, e.Error = Failed, err.Error()
+		e.Corrupt = errors.Is(err, ErrChecksum)
+	}
+	e.cancel()
+	e.Speed = 0
+	if e.State.Finished() {
+		e.Finished = time.Now().UTC()
+	}
+	item := e.Item
+	m.active--
+	m.schedule()
+	m.save()
+	m.mu.Unlock()
+
+	if item.State == Paused {
+		m.changed()
+		return
+	}
+	m.finished(item)
+}
+
+// fetch downloads e into its partial file, continuing after the bytes
+// already there when the server supports it, and renames it once complete.
+func (m *Manager) fetch(ctx context.Context, e *entry) error {
+	client, err := m.client()
+	if err != nil {
+		return err
+	}
+	part := PartPath(e.Path)
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) == offset:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is as long as the file or longer, so it
+		// belongs to another version; start over
+		os.Remove(part)
+		return m.fetch(ctx, e)
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("downloading %s: %s", e.URL, resp.Status)
+	}
+
+	m.mu.Lock()
+	e.Done = offset
+	e.Total = -1
+	if resp.ContentLength >= 0 {
+		e.Total = offset + resp.ContentLength
+	}
+	m.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, m.limiter.Reader(ctx, &progressReader{r: resp.Body, m: m, e: e}))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && e.Total >= 0 && e.Done != e.Total {
+		err = fmt.Errorf("downloading %s: got %d of %d bytes", e.URL, e.Done, e.Total)
+	}
+	if err != nil {
+		return err
+	}
+	if e.Sha256 != "" {
+		hash, err := fsutil.HashFile(part)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(hash, e.Sha256) {
+			// Resuming would only append to the bad bytes
+			os.Remove(part)
+			return fmt.Errorf("downloading %s: %w", e.URL, ErrChecksum)
+		}
+	}
+	return os.Rename(part, e.Path)
+}
+
+// contentRangeStart returns the first byte of a partial response, or -1.
+func contentRangeStart(resp *http.Response) int64 {
+	// Content-Range: bytes 100-199/200
+	r := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// progressReader counts the bytes of a download and reports its progress
+// and speed every reportInterval.
+type progressReader struct {
+	r        io.Reader
+	m        *Manager
+	e        *entry
+	reported time.Time
+	bytes    int64 // since reported
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.reported.IsZero() {
+		r.reported = time.Now()
+	}
+	r.bytes += int64(n)
+
+	r.m.mu.Lock()
+	r.e.Done += int64(n)
+	elapsed := time.Since(r.reported)
+	report := elapsed >= reportInterval
+	if report {
+		speed := float64(r.bytes) / elapsed.Seconds()
+		if r.e.Speed == 0 {
+			r.e.Speed = speed
+		} else {
+			r.e.Speed = 0.7*r.e.Speed + 0.3*speed
+		}
+		r.reported, r.bytes = time.Now(), 0
+	}
+	r.m.mu.Unlock()
+
+	if report {
+		r.m.changed()
+	}
+	return n, err
+}
+
+func (m *Manager) changed() {
+	if m.OnChange != nil {
+		m.OnChange()
+	}
+}
+
+func (m *Manager) finished(item Item) {
+	m.changed()
+	if m.OnFinished != nil {
+		m.OnFinished(item)
+	}
+}
@@ -0,0 +1,303 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// content is the file served for every path.
+func content(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+// newServer serves /<name> with content of the given size, holding requests
+// for paths in hold until release is closed. Range requests are supported.
+func newServer(t *testing.T, size int, hold map[string]bool, release chan struct{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if hold[r.URL.Path] {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(content(size)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newManager(maxActive int) *Manager {
+	client := func() (*http.Client, error) { return http.DefaultClient, nil }
+	return NewManager(client, NewLimiter(0), maxActive)
+}
+
+// waitFinished collects the finished downloads of m until there are n.
+func waitFinished(t *testing.T, m *Manager, n int) func() []Item {
+	t.Helper()
+	var mu sync.Mutex
+	var finished []Item
+	done := make(chan struct{})
+	m.OnFinished = func(item Item) {
+		mu.Lock()
+		defer mu.Unlock()
+		finished = append(finished, item)
+		if len(finished) == n {
+			close(done)
+		}
+	}
+	return func() []Item {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("downloads did not finish")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return finished
+	}
+}
+
+func TestDownload(t *testing.T) {
+	srv := newServer(t, 100<<10, nil, nil)
+	dir := t.TempDir()
+	m := newManager(2)
+	wait := waitFinished(t, m, 2)
+
+	m.Add("a", srv.URL+"/a.npk", filepath.Join(dir, "a.npk"), "", "")
+	m.Add("missing", srv.URL+"/missing", filepath.Join(dir, "missing.npk"), "", "")
+	for _, item := range wait() {
+		switch item.Name {
+		case "a":
+			if item.State != Done || item.Done != 100<<10 || item.Total != 100<<10 {
+				t.Errorf("a = %+v, want done with all bytes", item)
+			}
+		case "missing":
+			if item.State != Failed || item.Error == "" {
+				t.Errorf("missing = %+v, want failed", item)
+			}
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "a.npk")); err != nil || info.Size() != 100<<10 {
+		t.Errorf("a.npk = %v, %v", info, err)
+	}
+	parts, _ := filepath.Glob(filepath.Join(dir, "*.part"))
+	if len(parts) != 0 {
+		t.Errorf("left over %v", parts)
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	release := make(chan struct{})
+	srv := newServer(t, 10, map[string]bool{"/first": true}, release)
+	dir := t.TempDir()
+	m := newManager(1)
+	wait := waitFinished(t, m, 4)
+
+	var ids []string
+	for _, name := range []string{"first", "b", "c", "d"} {
+		ids = append(ids, m.Add(name, srv.URL+"/"+name, filepath.Join(dir, name), "", ""))
+	}
+	// first is running; d jumps ahead of b and c, c is dropped
+	m.Move(ids[3], -2)
+	m.Cancel(ids[2])
+	close(release)
+
+	var order []string
+	for _, item := range wait() {
+		order = append(order, item.Name+":"+item.State.String())
+	}
+	if got, want := strings.Join(order, " "), "c:cancelled first:done d:done b:done"; got != want {
+		t.Errorf("finished %s, want %s", got, want)
+	}
+
+	m.ClearFinished()
+	if items := m.Items(); len(items) != 0 {
+		t.Errorf("Items() after ClearFinished = %+v", items)
+	}
+}
+
+func TestCancelActive(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := newServer(t, 10, map[string]bool{"/slow": true}, release)
+	dir := t.TempDir()
+	m := newManager(1)
+	wait := waitFinished(t, m, 1)
+
+	id := m.Add("slow", srv.URL+"/slow", filepath.Join(dir, "slow"), "", "")
+	m.Cancel(id)
+	if item := wait()[0]; item.State != Cancelled {
+		t.Errorf("slow = %+v, want cancelled", item)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "slow")); !os.IsNotExist(err) {
+		t.Errorf("cancelled download was saved: %v", err)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	const rate = 200 << 10
+	l := NewLimiter(rate)
+	start := time.Now()
+	n, err := io.Copy(io.Discard, l.Reader(context.Background(), bytes.NewReader(make([]byte, rate/2))))
+	if err != nil || n != rate/2 {
+		t.Fatalf("Copy() = %d, %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("reading half a second worth of bytes took %v", elapsed)
+	}
+
+	// Lifting the limit takes effect right away
+	l.SetRate(0)
+	start = time.Now()
+	io.Copy(io.Discard, l.Reader(context.Background(), bytes.NewReader(make([]byte, 10*rate))))
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("reading without a limit took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.SetRate(1)
+	if _, err := io.Copy(io.Discard, l.Reader(ctx, bytes.NewReader(make([]byte, 100)))); err != context.Canceled {
+		t.Errorf("Copy() with a cancelled context error = %v", err)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	const size = 200 << 10
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		http.ServeContent(w, r, "a", time.Time{}, bytes.NewReader(content(size)))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.npk")
+	state := filepath.Join(dir, "downloads.json")
+	m := newManager(1)
+	m.Limiter().SetRate(size)
+	if err := m.Open(state); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	id := m.Add("a", srv.URL+"/a", path, "patch-a", "")
+	time.Sleep(300 * time.Millisecond)
+	m.Pause(id)
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Items()[0].State != Paused {
+		if time.Now().After(deadline) {
+			t.Fatalf("download = %+v, want paused", m.Items()[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	info, err := os.Stat(PartPath(path))
+	if err != nil || info.Size() == 0 || info.Size() == size {
+		t.Fatalf("partial file after pausing = %v, %v", info, err)
+	}
+
+	// A restart picks the paused download up again
+	restarted := newManager(1)
+	wait := waitFinished(t, restarted, 1)
+	if err := restarted.Open(state); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if items := restarted.Items(); len(items) != 1 || items[0].State != Paused || items[0].Tag != "patch-a" {
+		t.Fatalf("reopened downloads = %+v", items)
+	}
+	restarted.Resume(id)
+	if item := wait()[0]; item.State != Done || item.Finished.IsZero() {
+		t.Fatalf("resumed download = %+v", item)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(data, content(size)) {
+		t.Errorf("downloaded file differs from the served one (%d bytes, %v)", len(data), err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != fmt.Sprintf("bytes=%d-", info.Size()) {
+		t.Errorf("requested ranges %q, want the rest of the file on resume", ranges)
+	}
+}
+
+func TestOpenRequeuesInterrupted(t *testing.T) {
+	const size = 1000
+	srv := newServer(t, size, nil, nil)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "b.npk")
+	// Left behind by a crash half way through
+	if err := os.WriteFile(PartPath(path), content(size)[:400], 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(dir, "downloads.json")
+	saved := fmt.Sprintf(`[{"id": "1", "name": "b", "url": %q, "path": %q, "state": "active", "total": %d}]`, srv.URL+"/b", path, size)
+	if err := os.WriteFile(state, []byte(saved), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newManager(1)
+	wait := waitFinished(t, m, 1)
+	if err := m.Open(state); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if item := wait()[0]; item.State != Done || item.Done != size {
+		t.Fatalf("download = %+v", item)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, content(size)) {
+		t.Error("resumed file differs from the served one")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	const size = 1000
+	srv := newServer(t, size, nil, nil)
+	dir := t.TempDir()
+	m := newManager(2)
+	wait := waitFinished(t, m, 2)
+
+	sum := sha256.Sum256(content(size))
+	m.Add("good", srv.URL+"/good", filepath.Join(dir, "good"), "", strings.ToUpper(hex.EncodeToString(sum[:])))
+	m.Add("bad", srv.URL+"/bad", filepath.Join(dir, "bad"), "", strings.Repeat("0", 64))
+	for _, item := range wait() {
+		switch item.Name {
+		case "good":
+			if item.State != Done || item.Corrupt {
+				t.Errorf("good = %+v, want done", item)
+			}
+		case "bad":
+			if item.State != Failed || !item.Corrupt {
+				t.Errorf("bad = %+v, want failed as corrupt", item)
+			}
+		}
+	}
+	for _, name := range []string{"bad", "bad.part"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was kept: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package download
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// chunkSize bounds a single read of a limited reader so a slow limit is
+// enforced smoothly instead of in large bursts.
+const chunkSize = 16 << 10
+
+// Limiter is a token bucket shared by the downloads it limits, so the limit
+// applies to all of them together. A rate of 0 means no limit. It is safe
+// for concurrent use and the rate can be changed at any time.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   int64   // bytes per second
+	tokens float64 // negative while readers owe bytes
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing rate bytes per second.
+func NewLimiter(rate int64) *Limiter {
+	return &Limiter{rate: rate, last: time.Now()}
+}
+
+// Rate returns the limit in bytes per second, 0 for none.
+func (l *Limiter) Rate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// SetRate changes the limit. Readers waiting for the old limit finish their
+// current wait first.
+func (l *Limiter) SetRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.tokens = 0
+	l.last = time.Now()
+}
+
+// take accounts for n bytes that were read and waits until they are paid
+// for. At most one second worth of bytes is saved up for bursts.
+func (l *Limiter) take(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	rate := float64(l.rate)
+	l.tokens = min(rate, l.tokens+now.Sub(l.last).Seconds()*rate) - float64(n)
+	l.last = now
+	wait := time.Duration(-l.tokens / rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Reader returns r limited by l. Reads fail with the error of ctx once it is
+// done.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &limitedReader{ctx: ctx, r: r, l: l}
+}
+
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *Limiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.take(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,177 @@
+// Package fetchpool loads values such as preview images through a small pool
+// of workers, sharing the load of a key between everyone asking for it and
+// keeping the values loaded last in memory.
+package fetchpool
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LoadFunc loads the value of key. It should give up once ctx is cancelled.
+type LoadFunc[T any] func(ctx context.Context, key string) (T, error)
+
+// Pool loads values with at most a fixed number of loads running at once. It
+// is safe for concurrent use.
+type Pool[T any] struct {
+	Retries    int           // further attempts after a load fails
+	RetryDelay time.Duration // wait before each retry
+
+	load    LoadFunc[T]
+	workers chan struct{}
+	cost    func(T) int64
+
+	mu      sync.Mutex
+	pending map[string]*call[T]
+	lru     *list.List // of *entry[T], most recently used first
+	entries map[string]*list.Element
+	total   int64 // cost of the values in lru
+	maxCost int64
+}
+
+// call is a load of a key that one or more Gets wait for.
+type call[T any] struct {
+	done    chan struct{}
+	value   T
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// entry is a value kept in memory.
+type entry[T any] struct {
+	key   string
+	value T
+	cost  int64
+}
+
+// New returns a pool running load on at most workers keys at once, which
+// retries a failed load once after a second. It keeps the values used last
+// in memory while their costs add up to at most maxCost; cost may be nil to
+// count each value as 1.
+func New[T any](load LoadFunc[T], workers int, maxCost int64, cost func(T) int64) *Pool[T] {
+	if cost == nil {
+		cost = func(T) int64 { return 1 }
+	}
+	return &Pool[T]{
+		Retries:    1,
+		RetryDelay: time.Second,
+		load:       load,
+		workers:    make(chan struct{}, workers),
+		cost:       cost,
+		pending:    make(map[string]*call[T]),
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		maxCost:    maxCost,
+	}
+}
+
+// Get returns the value of key, from memory when it is there and otherwise
+// once a worker has loaded it. Gets of a key being loaded wait for that
+// load, which is cancelled when all of them are; a Get returns ctx.Err() as
+// soon as its own ctx is cancelled. Failed loads are not remembered.
+func (p *Pool[T]) Get(ctx context.Context, key string) (T, error) {
+	p.mu.Lock()
+	if e, ok := p.entries[key]; ok {
+		p.lru.MoveToFront(e)
+		p.mu.Unlock()
+		return e.Value.(*entry[T]).value, nil
+	}
+	c, ok := p.pending[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(context.Background())
+		c = &call[T]{done: make(chan struct{}), cancel: cancel}
+		p.pending[key] = c
+		go p.run(loadCtx, key, c)
+	}
+	c.waiters++
+	p.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		p.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			if p.pending[key] == c {
+				delete(p.pending, key)
+			}
+		}
+		p.mu.Unlock()
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// run loads key for c, retrying as configured, and keeps the value.
+func (p *Pool[T]) run(ctx context.Context, key string, c *call[T]) {
+	defer c.cancel()
+	var value T
+	var err error
+	for attempt := 0; attempt <= p.Retries; attempt++ {
+		if attempt > 0 {
+			if err = sleep(ctx, p.RetryDelay); err != nil {
+				break
+			}
+		}
+		select {
+		case p.workers <- struct{}{}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			break
+		}
+		value, err = p.load(ctx, key)
+		<-p.workers
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	p.mu.Lock()
+	if p.pending[key] == c {
+		delete(p.pending, key)
+	}
+	if err == nil {
+		p.keep(key, value)
+	}
+	p.mu.Unlock()
+	c.value, c.err = value, err
+	close(c.done)
+}
+
+// keep adds value to the values in memory, dropping those used longest ago
+// while they cost more than allowed. p.mu must be held.
+func (p *Pool[T]) keep(key string, value T) {
+	if e, ok := p.entries[key]; ok {
+		p.total -= e.Value.(*entry[T]).cost
+		p.lru.Remove(e)
+	}
+	cost := p.cost(value)
+	p.entries[key] = p.lru.PushFront(&entry[T]{key, value, cost})
+	p.total += cost
+	for p.total > p.maxCost && p.lru.Len() > 0 {
+		last := p.lru.Back()
+		e := last.Value.(*entry[T])
+		p.lru.Remove(last)
+		delete(p.entries, e.key)
+		p.total -= e.cost
+	}
+}
+
+// sleep waits for d or until ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
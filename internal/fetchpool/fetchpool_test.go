@@ -0,0 +1,117 @@
+package fetchpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetSharesLoads(t *testing.T) {
+	var loads, running, most atomic.Int32
+	release := make(chan struct{})
+	pool := New(func(ctx context.Context, key string) (string, error) {
+		loads.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+		}
+		<-release
+		return "image " + key, nil
+	}, 2, 10, nil)
+
+	var wg sync.WaitGroup
+	keys := []string{"a", "a", "a", "b", "c", "d"}
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if v, err := pool.Get(context.Background(), key); err != nil || v != "image "+key {
+				t.Errorf("Get(%q) = %q, %v", key, v, err)
+			}
+		}(key)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if loads.Load() != 4 {
+		t.Errorf("%d loads, want one per key", loads.Load())
+	}
+	if most.Load() > 2 {
+		t.Errorf("%d loads ran at once, want at most 2", most.Load())
+	}
+
+	if _, err := pool.Get(context.Background(), "a"); err != nil || loads.Load() != 4 {
+		t.Errorf("Get() of a kept value loaded it again: %v", err)
+	}
+}
+
+func TestGetEvictsLeastRecentlyUsed(t *testing.T) {
+	var loads atomic.Int32
+	pool := New(func(ctx context.Context, key string) (string, error) {
+		loads.Add(1)
+		return key, nil
+	}, 1, 2, nil)
+	get := func(key string) {
+		t.Helper()
+		if _, err := pool.Get(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get("a")
+	get("b")
+	get("a")
+	get("c") // drops b, used longest ago
+	get("a")
+	if loads.Load() != 3 {
+		t.Fatalf("%d loads, want a kept", loads.Load())
+	}
+	get("b")
+	if loads.Load() != 4 {
+		t.Errorf("%d loads, want b loaded again", loads.Load())
+	}
+}
+
+func TestGetRetriesOnce(t *testing.T) {
+	var loads atomic.Int32
+	pool := New(func(ctx context.Context, key string) (string, error) {
+		if loads.Add(1) == 1 || key == "broken" {
+			return "", errors.New("connection reset")
+		}
+		return key, nil
+	}, 1, 10, nil)
+	pool.RetryDelay = time.Millisecond
+
+	if v, err := pool.Get(context.Background(), "a"); err != nil || v != "a" {
+		t.Errorf("Get() = %q, %v, want the retry to succeed", v, err)
+	}
+	loads.Store(10)
+	if _, err := pool.Get(context.Background(), "broken"); err == nil || loads.Load() != 12 {
+		t.Errorf("Get() = %v after %d loads, want an error after 2", err, loads.Load()-10)
+	}
+}
+
+func TestGetCancels(t *testing.T) {
+	cancelled := make(chan struct{})
+	pool := New(func(ctx context.Context, key string) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	}, 1, 10, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := pool.Get(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get() = %v, want it cancelled", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the load was not cancelled")
+	}
+}
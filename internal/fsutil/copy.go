@@ -0,0 +1,130 @@
+package fsutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// copyBufferSize is the size of the buffers Copy reads through; NPK files
+// run to hundreds of megabytes.
+const copyBufferSize = 1 << 20
+
+var copyBuffers = sync.Pool{New: func() interface{} {
+	b := make([]byte, copyBufferSize)
+	return &b
+}}
+
+// CopyError is returned by CopyFile and CopyFileContext when copying Src to
+// Dst fails.
+type CopyError struct {
+	Src, Dst string
+	Err      error
+}
+
+func (e *CopyError) Error() string {
+	return fmt.Sprintf("copying %s to %s: %v", e.Src, e.Dst, e.Err)
+}
+
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// Copy copies src to dst like io.Copy through a shared buffer, stopping
+// with the error of ctx once it is cancelled. progress, which may be nil,
+// is called with the bytes written so far after each chunk.
+func Copy(ctx context.Context, dst io.Writer, src io.Reader, progress func(written int64)) (int64, error) {
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+
+	var written int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		n, err := src.Read(*buf)
+		if n > 0 {
+			m, werr := dst.Write((*buf)[:n])
+			written += int64(m)
+			if werr == nil && m < n {
+				werr = io.ErrShortWrite
+			}
+			if werr != nil {
+				return written, werr
+			}
+			if progress != nil {
+				progress(written)
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// CopyFile copies src to dst as CopyFileContext does, without cancellation
+// or progress.
+func CopyFile(src, dst string) error {
+	return CopyFileContext(context.Background(), src, dst, nil)
+}
+
+// CopyFileContext copies src to dst, creating the parent directories of dst
+// and retrying while another program holds dst. The copy is flushed to disk
+// before dst is closed and gets the modification time of src. progress,
+// which may be nil, is called with the bytes written and the size of src.
+// When the copy fails or ctx is cancelled the partial dst is removed; the
+// error is a *CopyError.
+func CopyFileContext(ctx context.Context, src, dst string, progress func(written, total int64)) error {
+	if err := copyFile(ctx, src, dst, progress); err != nil {
+		return &CopyError{Src: src, Dst: dst, Err: err}
+	}
+	return nil
+}
+
+func copyFile(ctx context.Context, src, dst string, progress func(written, total int64)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	source, err := os.Open(LongPath(src))
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(LongPath(filepath.Dir(dst)), 0755); err != nil {
+		return err
+	}
+	destination, err := Create(dst)
+	if err != nil {
+		return err
+	}
+
+	var report func(int64)
+	if progress != nil {
+		report = func(written int64) { progress(written, info.Size()) }
+	}
+	_, err = Copy(ctx, destination, source, report)
+	if err == nil {
+		err = destination.Sync()
+	}
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(LongPath(dst), info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		os.Remove(LongPath(dst))
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package fsutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "sprite.npk")
+	data := bytes.Repeat([]byte("npk"), copyBufferSize)
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "backup", "imagepack2", "sprite.npk")
+	var calls int
+	var last, total int64
+	err := CopyFileContext(context.Background(), src, dst, func(written, size int64) {
+		calls++
+		last, total = written, size
+	})
+	if err != nil {
+		t.Fatalf("CopyFileContext() error = %v", err)
+	}
+	if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("copied %d bytes, %v; want %d", len(got), err, len(data))
+	}
+	if info, err := os.Stat(dst); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("copy modified at %v, %v; want %v", info.ModTime(), err, modTime)
+	}
+	if calls < 3 || last != int64(len(data)) || total != int64(len(data)) {
+		t.Errorf("progress called %d times, last with %d of %d", calls, last, total)
+	}
+}
+
+func TestCopyFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.npk")
+	dst := filepath.Join(dir, "copy.npk")
+	err := CopyFile(missing, dst)
+	var copyErr *CopyError
+	if !errors.As(err, &copyErr) || copyErr.Src != missing || copyErr.Dst != dst || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CopyFile(missing) error = %#v, want a *CopyError wrapping ErrNotExist", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), missing) || !strings.Contains(err.Error(), dst) {
+		t.Errorf("CopyFile(missing) error = %v, want both paths in the message", err)
+	}
+
+	// The parent of the destination is a file
+	src := filepath.Join(dir, "sprite.npk")
+	if err := os.WriteFile(src, []byte("npk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyFile(src, filepath.Join(src, "copy.npk")); !errors.As(err, &copyErr) {
+		t.Errorf("CopyFile(into a file) error = %v, want a *CopyError", err)
+	}
+}
+
+func TestCopyFileCancelled(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "sprite.npk")
+	if err := os.WriteFile(src, bytes.Repeat([]byte("npk"), copyBufferSize), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dst := filepath.Join(dir, "before.npk")
+	if err := CopyFileContext(ctx, src, dst, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("CopyFileContext(cancelled) error = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("cancelled copy created the destination: %v", err)
+	}
+
+	// Cancelled after the first chunk, the partial copy is removed
+	ctx, cancel = context.WithCancel(context.Background())
+	dst = filepath.Join(dir, "during.npk")
+	err := CopyFileContext(ctx, src, dst, func(written, total int64) { cancel() })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CopyFileContext(cancelled while copying) error = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("cancelled copy left the destination behind: %v", err)
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestCopy(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), copyBufferSize*2+10)
+	var out bytes.Buffer
+	n, err := Copy(context.Background(), &out, bytes.NewReader(data), nil)
+	if err != nil || n != int64(len(data)) || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("Copy() = %d, %v; want %d", n, err, len(data))
+	}
+
+	n, err = Copy(context.Background(), &failingWriter{n: 1}, bytes.NewReader(data), nil)
+	if err == nil || err.Error() != "disk full" || n != copyBufferSize {
+		t.Errorf("Copy(failing writer) = %d, %v; want %d, disk full", n, err, copyBufferSize)
+	}
+
+	readErr := errors.New("read failed")
+	r := io.MultiReader(bytes.NewReader(data[:10]), &errReader{readErr})
+	if _, err := Copy(context.Background(), io.Discard, r, nil); err != readErr {
+		t.Errorf("Copy(failing reader) error = %v, want %v", err, readErr)
+	}
+}
+
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,146 @@
+// Package fsutil contains the file helpers shared by the other packages.
+package fsutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HashFile returns the hex-encoded SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	return HashFileContext(context.Background(), path, nil)
+}
+
+// HashFileContext is HashFile stopping once ctx is cancelled. progress, if
+// not nil, is called with the number of bytes read so far.
+func HashFileContext(ctx context.Context, path string, progress func(read int64)) (string, error) {
+	f, err := os.Open(LongPath(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := Copy(ctx, h, f, progress); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MismatchError is returned by VerifyFile when a file does not read back
+// with the expected hash, such as a copy damaged by a failing disk.
+type MismatchError struct {
+	Path      string
+	Want, Got string // hex-encoded SHA-256
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s does not match what was written: SHA-256 %s, expected %s", e.Path, e.Got, e.Want)
+}
+
+// VerifyFile hashes the file at path and returns a *MismatchError unless its
+// SHA-256 is want, compared case-insensitively.
+func VerifyFile(ctx context.Context, path, want string) error {
+	got, err := HashFileContext(ctx, path, nil)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, want) {
+		return &MismatchError{Path: path, Want: want, Got: got}
+	}
+	return nil
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it
+// into place, so a crash never leaves a truncated file behind.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(LongPath(filepath.Dir(path)), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Within reports whether path is dir or inside it. On Windows case is
+// ignored.
+func Within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// MoveDir moves the directory src to dst, copying it when a rename is not
+// possible, for example across drives.
+func MoveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		return CopyFile(path, filepath.Join(dst, rel))
+	})
+	if err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// IsReadOnly reports whether path is an existing file without write
+// permission, which on Windows means its read-only attribute is set.
+func IsReadOnly(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode().Perm()&0200 == 0
+}
+
+// ClearReadOnly gives the owner write permission on path, clearing the
+// read-only attribute on Windows.
+func ClearReadOnly(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(path, info.Mode().Perm()|0200)
+}
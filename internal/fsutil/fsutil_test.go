@@ -0,0 +1,123 @@
+package fsutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveDir(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	if err := os.MkdirAll(filepath.Join(src, "backup"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "backup", "backup.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(root, "nested", "dst")
+	if err := MoveDir(src, dst); err != nil {
+		t.Fatalf("MoveDir() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "backup", "backup.json")); err != nil || string(data) != "{}" {
+		t.Errorf("moved file = %q, %v", data, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sprite.npk")
+	if err := os.WriteFile(path, []byte("patch"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyFile(context.Background(), path, strings.ToUpper(hash)); err != nil {
+		t.Errorf("VerifyFile() of an intact file = %v", err)
+	}
+	if err := os.WriteFile(path, []byte("damaged"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var mismatch *MismatchError
+	if err := VerifyFile(context.Background(), path, hash); !errors.As(err, &mismatch) || mismatch.Want != hash || mismatch.Path != path {
+		t.Errorf("VerifyFile() of a changed file = %v, want a *MismatchError", err)
+	}
+}
+
+func TestClearReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sprite.npk")
+	if err := os.WriteFile(path, []byte("npk"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if !IsReadOnly(path) {
+		t.Fatal("IsReadOnly() = false for a file written with 0444")
+	}
+	if err := ClearReadOnly(path); err != nil {
+		t.Fatalf("ClearReadOnly() error = %v", err)
+	}
+	if IsReadOnly(path) {
+		t.Error("IsReadOnly() = true after ClearReadOnly()")
+	}
+	if IsReadOnly(filepath.Dir(path)) {
+		t.Error("IsReadOnly() = true for a directory")
+	}
+}
+
+// longTempDir returns a directory whose path exceeds the 260 characters of
+// MAX_PATH, skipping the test where it cannot be created.
+func longTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for len(dir) <= 300 {
+		dir = filepath.Join(dir, strings.Repeat("WeGame", 8))
+	}
+	if err := os.MkdirAll(LongPath(dir), 0755); err != nil {
+		t.Skipf("cannot create a %d character path: %v", len(dir), err)
+	}
+	return dir
+}
+
+func TestLongPaths(t *testing.T) {
+	dir := longTempDir(t)
+	src := filepath.Join(dir, "ImagePacks2", "sprite_interface.npk")
+	dst := filepath.Join(dir, "backup", "ImagePacks2", "sprite_interface.npk")
+
+	if err := os.MkdirAll(LongPath(filepath.Dir(src)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(src, []byte("npk"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("CopyFile() error = %v", err)
+	}
+	srcHash, err := HashFile(src)
+	if err != nil {
+		t.Fatalf("HashFile() error = %v", err)
+	}
+	if dstHash, err := HashFile(dst); err != nil || dstHash != srcHash {
+		t.Errorf("HashFile(copy) = %s, %v, want %s", dstHash, err, srcHash)
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	free, err := FreeSpace(t.TempDir())
+	if err != nil {
+		t.Fatalf("FreeSpace() error = %v", err)
+	}
+	if free == 0 {
+		t.Error("FreeSpace() = 0 for the temp directory")
+	}
+	if _, err := FreeSpace(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("FreeSpace() of a missing directory succeeded")
+	}
+}
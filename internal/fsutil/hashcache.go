@@ -0,0 +1,49 @@
+package fsutil
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// HashCache remembers the SHA-256 of files by path, size and modification
+// time, so scanning the same folder again only reads the files that changed.
+// It is safe for concurrent use.
+type HashCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedHash
+}
+
+type cachedHash struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+func NewHashCache() *HashCache {
+	return &HashCache{entries: map[string]cachedHash{}}
+}
+
+// Hash returns the hex-encoded SHA-256 of the file at path, hashing it only
+// when it is new or changed since the last call.
+func (c *HashCache) Hash(path string) (string, error) {
+	info, err := os.Stat(LongPath(path))
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	cached, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.hash, nil
+	}
+
+	hash, err := HashFile(path)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[path] = cachedHash{size: info.Size(), modTime: info.ModTime(), hash: hash}
+	c.mu.Unlock()
+	return hash, nil
+}
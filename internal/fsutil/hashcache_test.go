@@ -0,0 +1,46 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHashCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.npk")
+	if err := os.WriteFile(path, []byte("patch"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewHashCache()
+	first, err := c.Hash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := HashFile(path); first != want {
+		t.Fatalf("Hash = %s, want %s", first, want)
+	}
+
+	// Same size and time: the cached hash is returned without reading
+	modTime := time.Now().Add(-time.Hour)
+	if err := os.WriteFile(path, []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	c.entries[path] = cachedHash{size: 5, modTime: modTime, hash: first}
+	if got, _ := c.Hash(path); got != first {
+		t.Errorf("Hash of unchanged file = %s, want the cached %s", got, first)
+	}
+
+	// A new modification time makes it hash the file again
+	modTime = modTime.Add(time.Minute)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := HashFile(path)
+	if got, _ := c.Hash(path); got != want || got == first {
+		t.Errorf("Hash of changed file = %s, want %s", got, want)
+	}
+}
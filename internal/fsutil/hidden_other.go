@@ -0,0 +1,11 @@
+//go:build !windows
+
+package fsutil
+
+import "io/fs"
+
+// Hidden reports whether the file described by info is hidden: its name
+// starts with a dot.
+func Hidden(info fs.FileInfo) bool {
+	return len(info.Name()) > 1 && info.Name()[0] == '.'
+}
@@ -0,0 +1,19 @@
+package fsutil
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// Hidden reports whether the file described by info is hidden: its name
+// starts with a dot or it has the hidden or system attribute, as desktop.ini
+// and Thumbs.db do.
+func Hidden(info fs.FileInfo) bool {
+	if len(info.Name()) > 1 && info.Name()[0] == '.' {
+		return true
+	}
+	if d, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		return d.FileAttributes&(syscall.FILE_ATTRIBUTE_HIDDEN|syscall.FILE_ATTRIBUTE_SYSTEM) != 0
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isInUse reports whether err means another process holds the file.
+func isInUse(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ETXTBSY)
+}
+
+// lockingProcesses is only implemented for Windows.
+func lockingProcesses(path string) []string {
+	return nil
+}
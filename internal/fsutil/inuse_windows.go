@@ -0,0 +1,91 @@
+package fsutil
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+	errorMoreData         syscall.Errno = 234
+)
+
+// isInUse reports whether err means another process holds the file.
+func isInUse(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation)
+}
+
+var (
+	rstrtmgr            = syscall.NewLazyDLL("rstrtmgr.dll")
+	rmStartSession      = rstrtmgr.NewProc("RmStartSession")
+	rmRegisterResources = rstrtmgr.NewProc("RmRegisterResources")
+	rmGetList           = rstrtmgr.NewProc("RmGetList")
+	rmEndSession        = rstrtmgr.NewProc("RmEndSession")
+)
+
+const (
+	cchRmSessionKey = 32
+	cchRmMaxAppName = 255
+	cchRmMaxSvcName = 63
+)
+
+// rmProcessInfo mirrors RM_PROCESS_INFO.
+type rmProcessInfo struct {
+	ProcessID        uint32
+	ProcessStartTime syscall.Filetime
+	AppName          [cchRmMaxAppName + 1]uint16
+	ServiceShortName [cchRmMaxSvcName + 1]uint16
+	ApplicationType  uint32
+	AppStatus        uint32
+	TSSessionID      uint32
+	Restartable      int32
+}
+
+// lockingProcesses asks the Restart Manager which programs hold path.
+func lockingProcesses(path string) []string {
+	if rstrtmgr.Load() != nil {
+		return nil
+	}
+
+	var session uint32
+	var key [cchRmSessionKey + 1]uint16
+	if r, _, _ := rmStartSession.Call(uintptr(unsafe.Pointer(&session)), 0, uintptr(unsafe.Pointer(&key[0]))); r != 0 {
+		return nil
+	}
+	defer rmEndSession.Call(uintptr(session))
+
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil
+	}
+	files := []*uint16{name}
+	if r, _, _ := rmRegisterResources.Call(uintptr(session), 1, uintptr(unsafe.Pointer(&files[0])), 0, 0, 0, 0); r != 0 {
+		return nil
+	}
+
+	infos := make([]rmProcessInfo, 4)
+	for {
+		var needed, reasons uint32
+		count := uint32(len(infos))
+		r, _, _ := rmGetList.Call(uintptr(session),
+			uintptr(unsafe.Pointer(&needed)),
+			uintptr(unsafe.Pointer(&count)),
+			uintptr(unsafe.Pointer(&infos[0])),
+			uintptr(unsafe.Pointer(&reasons)))
+		if syscall.Errno(r) == errorMoreData {
+			infos = make([]rmProcessInfo, needed)
+			continue
+		}
+		if r != 0 {
+			return nil
+		}
+
+		names := make([]string, 0, count)
+		for _, info := range infos[:count] {
+			names = append(names, syscall.UTF16ToString(info.AppName[:]))
+		}
+		return names
+	}
+}
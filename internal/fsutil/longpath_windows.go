@@ -0,0 +1,21 @@
+package fsutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// LongPath returns path in the \\?\ extended-length form so it may exceed
+// MAX_PATH (260 characters). Relative and already extended paths are
+// returned unchanged.
+func LongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) || !filepath.IsAbs(path) {
+		return path
+	}
+	// Extended paths are not normalised by Windows
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) {
+		return `\\?\UNC\` + path[2:]
+	}
+	return `\\?\` + path
+}
@@ -0,0 +1,21 @@
+package fsutil
+
+import "testing"
+
+func TestLongPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`C:\Program Files\DNF\ImagePacks2`, `\\?\C:\Program Files\DNF\ImagePacks2`},
+		{`C:/WeGame/DNF/../DNF/ImagePacks2`, `\\?\C:\WeGame\DNF\ImagePacks2`},
+		{`\\server\share\DNF`, `\\?\UNC\server\share\DNF`},
+		{`\\?\C:\DNF`, `\\?\C:\DNF`},
+		{`ImagePacks2\sprite.npk`, `ImagePacks2\sprite.npk`},
+	}
+	for _, tt := range tests {
+		if got := LongPath(tt.path); got != tt.want {
+			t.Errorf("LongPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
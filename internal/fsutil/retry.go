@@ -0,0 +1,93 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// retryDelays are the waits between attempts to open or rename a file that
+// another program, typically antivirus or the game launcher, briefly holds.
+var retryDelays = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// InUseError is returned when a file stayed in use by another program after
+// all retries.
+type InUseError struct {
+	Path      string
+	Processes []string // programs holding the file, when they could be found
+	Err       error
+}
+
+func (e *InUseError) Error() string {
+	if len(e.Processes) == 0 {
+		return fmt.Sprintf("%s is in use by another program", e.Path)
+	}
+	return fmt.Sprintf("%s is in use by another program (%s)", e.Path, strings.Join(e.Processes, ", "))
+}
+
+func (e *InUseError) Unwrap() error {
+	return e.Err
+}
+
+// retryInUse calls op until it succeeds, fails with an error other than a
+// sharing violation, or runs out of attempts, in which case the error names
+// the programs holding path.
+func retryInUse(path string, op func() error) error {
+	err := op()
+	for _, delay := range retryDelays {
+		if err == nil || !isInUse(err) {
+			return err
+		}
+		time.Sleep(delay)
+		err = op()
+	}
+	if err != nil && isInUse(err) {
+		return &InUseError{Path: path, Processes: lockingProcesses(path), Err: err}
+	}
+	return err
+}
+
+// Create is os.Create, retrying while another program holds the file.
+func Create(path string) (*os.File, error) {
+	var f *os.File
+	err := retryInUse(path, func() error {
+		var err error
+		f, err = os.Create(LongPath(path))
+		return err
+	})
+	return f, err
+}
+
+// Rename is os.Rename, retrying while another program holds either file.
+func Rename(oldpath, newpath string) error {
+	return retryInUse(newpath, func() error {
+		return os.Rename(LongPath(oldpath), LongPath(newpath))
+	})
+}
+
+// InUse reports whether another program holds path so that it cannot be
+// opened for writing, as Windows does with a running executable. It does not
+// retry.
+func InUse(path string) bool {
+	f, err := os.OpenFile(LongPath(path), os.O_WRONLY, 0)
+	if err != nil {
+		return isInUse(err)
+	}
+	f.Close()
+	return false
+}
+
+// Permanent reports whether err is one that trying again shortly cannot
+// fix: a missing permission or file, or a full disk. Anything else, such as
+// a file another program holds, may go away by itself.
+func Permanent(err error) bool {
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, fs.ErrNotExist) || IsDiskFull(err)
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"syscall"
+	"testing"
+)
+
+var busyErrno, diskFullErrno = syscall.EBUSY, syscall.ENOSPC
+
+// holdFile cannot hold a file exclusively outside Windows, so the test is
+// skipped there.
+func holdFile(t *testing.T, path string) func() {
+	t.Skip("files cannot be opened exclusively on this platform")
+	return nil
+}
@@ -0,0 +1,116 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// busyError is a sharing violation of the current platform.
+var busyError = &os.PathError{Op: "open", Path: "sprite.npk", Err: busyErrno}
+
+func withShortRetries(t *testing.T) {
+	t.Helper()
+	saved := retryDelays
+	retryDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond}
+	t.Cleanup(func() { retryDelays = saved })
+}
+
+func TestRetryInUse(t *testing.T) {
+	withShortRetries(t)
+
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantInUse bool
+	}{
+		{name: "succeeds after retries", failures: 3, err: busyError, wantCalls: 4},
+		{name: "gives up", failures: 10, err: busyError, wantCalls: 5, wantInUse: true},
+		{name: "other errors are not retried", failures: 10, err: os.ErrNotExist, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryInUse("sprite.npk", func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", calls, tt.wantCalls)
+			}
+			var inUse *InUseError
+			if errors.As(err, &inUse) != tt.wantInUse {
+				t.Errorf("retryInUse() error = %v, want InUseError %v", err, tt.wantInUse)
+			}
+		})
+	}
+}
+
+func TestCreateRetriesWhileHeld(t *testing.T) {
+	withShortRetries(t)
+	path := filepath.Join(t.TempDir(), "sprite.npk")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release := holdFile(t, path)
+	_, err := Create(path)
+	var inUse *InUseError
+	if !errors.As(err, &inUse) || inUse.Path != path {
+		t.Fatalf("Create() on a held file error = %v, want an InUseError", err)
+	}
+
+	// Released while Create is still retrying
+	retryDelays = []time.Duration{50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	time.AfterFunc(30*time.Millisecond, release)
+	f, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create() after the file was released error = %v", err)
+	}
+	f.Close()
+}
+
+func TestInUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "DNF.exe")
+	if err := os.WriteFile(path, []byte("exe"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if InUse(path) {
+		t.Error("InUse() of a free file = true")
+	}
+	if InUse(filepath.Join(t.TempDir(), "missing.exe")) {
+		t.Error("InUse() of a missing file = true")
+	}
+
+	release := holdFile(t, path)
+	defer release()
+	if !InUse(path) {
+		t.Error("InUse() of a held file = false")
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&os.PathError{Op: "open", Path: "sprite.npk", Err: os.ErrPermission}, true},
+		{fmt.Errorf("backing up: %w", &os.PathError{Op: "stat", Path: "imagepack2", Err: os.ErrNotExist}), true},
+		{&os.PathError{Op: "write", Path: "sprite.npk", Err: diskFullErrno}, true},
+		{&InUseError{Path: "sprite.npk", Err: busyError}, false},
+		{errors.New("unexpected EOF"), false},
+	}
+	for _, tt := range tests {
+		if got := Permanent(tt.err); got != tt.want {
+			t.Errorf("Permanent(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"sync"
+	"syscall"
+	"testing"
+)
+
+var busyErrno, diskFullErrno = errorSharingViolation, syscall.Errno(112) // ERROR_DISK_FULL
+
+// holdFile opens path without sharing, the way antivirus scanners do, until
+// the returned function is called.
+func holdFile(t *testing.T, path string) func() {
+	t.Helper()
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ, 0, nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var once sync.Once
+	release := func() { once.Do(func() { syscall.CloseHandle(h) }) }
+	t.Cleanup(release)
+	return release
+}
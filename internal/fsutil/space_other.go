@@ -0,0 +1,24 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"errors"
+	"syscall"
+)
+
+// FreeSpace returns the bytes available to the user on the volume holding
+// path.
+func FreeSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// IsDiskFull reports whether err means the volume or the user's quota is
+// full.
+func IsDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT)
+}
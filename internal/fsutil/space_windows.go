@@ -0,0 +1,26 @@
+package fsutil
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// FreeSpace returns the bytes available to the user on the volume holding
+// path.
+func FreeSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+// IsDiskFull reports whether err means the volume is full.
+func IsDiskFull(err error) bool {
+	return errors.Is(err, windows.ERROR_DISK_FULL) || errors.Is(err, windows.ERROR_HANDLE_DISK_FULL)
+}
@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrNoTrash is returned by Trash where files cannot be moved to the
+// recycle bin: on other systems than Windows and on volumes without one,
+// such as network drives.
+var ErrNoTrash = errors.New("the recycle bin is not available")
+
+// Delete removes the file or folder at path. When toTrash is set it is moved
+// to the recycle bin instead if the volume has one; trashed reports whether
+// it was.
+func Delete(path string, toTrash bool) (trashed bool, err error) {
+	if toTrash {
+		err := Trash(path)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, ErrNoTrash) {
+			return false, err
+		}
+	}
+	return false, os.RemoveAll(LongPath(path))
+}
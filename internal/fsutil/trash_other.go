@@ -0,0 +1,13 @@
+//go:build !windows
+
+package fsutil
+
+// CanTrash reports whether path can be moved to the recycle bin.
+func CanTrash(path string) bool {
+	return false
+}
+
+// Trash moves the file or folder at path to the recycle bin.
+func Trash(path string) error {
+	return ErrNoTrash
+}
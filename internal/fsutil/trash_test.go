@@ -0,0 +1,31 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	for _, toTrash := range []bool{false, true} {
+		dir := filepath.Join(t.TempDir(), "backup_20240101_120000")
+		if err := os.MkdirAll(filepath.Join(dir, "imagepack2"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "imagepack2", "a.npk"), []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		trashed, err := Delete(dir, toTrash)
+		if err != nil {
+			t.Fatalf("Delete(%v) error = %v", toTrash, err)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("Delete(%v) left the folder: %v", toTrash, err)
+		}
+		if trashed && (!toTrash || runtime.GOOS != "windows") {
+			t.Errorf("Delete(%v) trashed the folder on %s", toTrash, runtime.GOOS)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package fsutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	shell32           = syscall.NewLazyDLL("shell32.dll")
+	shFileOperation   = shell32.NewProc("SHFileOperationW")
+	shQueryRecycleBin = shell32.NewProc("SHQueryRecycleBinW")
+)
+
+const (
+	foDelete          = 0x0003
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+
+	// SHFileOperation takes no \\?\ paths, so longer ones cannot be
+	// trashed
+	maxPath = 260
+)
+
+// shFileOpStruct mirrors SHFILEOPSTRUCTW as laid out on 64-bit Windows. The
+// 32-bit headers pack it without alignment, so CanTrash refuses there.
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// shQueryRBInfo mirrors SHQUERYRBINFO.
+type shQueryRBInfo struct {
+	cbSize      uint32
+	i64Size     int64
+	i64NumItems int64
+}
+
+// CanTrash reports whether path can be moved to the recycle bin: its volume
+// has one and the path is short enough for the shell.
+func CanTrash(path string) bool {
+	if unsafe.Sizeof(uintptr(0)) != 8 || shell32.Load() != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) >= maxPath {
+		return false
+	}
+	root, err := syscall.UTF16PtrFromString(filepath.VolumeName(abs) + `\`)
+	if err != nil {
+		return false
+	}
+	info := shQueryRBInfo{}
+	info.cbSize = uint32(unsafe.Sizeof(info))
+	r, _, _ := shQueryRecycleBin.Call(uintptr(unsafe.Pointer(root)), uintptr(unsafe.Pointer(&info)))
+	return r == 0
+}
+
+// Trash moves the file or folder at path to the recycle bin.
+func Trash(path string) error {
+	if !CanTrash(path) {
+		return ErrNoTrash
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	// pFrom is a list ending in an empty string
+	from, err := syscall.UTF16FromString(abs)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofNoErrorUI | fofSilent,
+	}
+	if r, _, _ := shFileOperation.Call(uintptr(unsafe.Pointer(&op))); r != 0 {
+		return fmt.Errorf("moving %s to the recycle bin failed with error %#x", path, r)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("moving %s to the recycle bin was aborted", path)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// Package gamepath finds DNF installations and checks whether a directory
+// holds the game.
+package gamepath
+
+import (
+	"bytes"
+	"context"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+)
+
+const (
+	DefaultPath   = "C:\\Wegame\\WeGame\\games\\DNF"
+	WinePath      = "~/.wine/drive_c/Wegame/WeGame/games/DNF"
+	ImagePack2Dir = "imagepack2"
+)
+
+// ImagePackDirNames lists the spellings of the NPK folder used by the
+// different DNF clients. WeGame ships "imagepack2" while the standalone
+// client uses "ImagePacks2"; the real casing is resolved on disk.
+var ImagePackDirNames = []string{ImagePack2Dir, "ImagePacks2"}
+
+// Common DNF installation paths
+var commonPaths = []string{
+	"C:\\Wegame\\WeGame\\games\\DNF",
+	"D:\\Wegame\\WeGame\\games\\DNF",
+	"E:\\Wegame\\WeGame\\games\\DNF",
+	"C:\\Program Files\\Wegame\\WeGame\\games\\DNF",
+	"C:\\Program Files (x86)\\Wegame\\WeGame\\games\\DNF",
+	"D:\\Program Files\\Wegame\\WeGame\\games\\DNF",
+	"D:\\Program Files (x86)\\Wegame\\WeGame\\games\\DNF",
+}
+
+// Find returns every valid DNF installation it can find. Probing drive
+// letters may block for a long time on dead network drives, so it must not
+// be called from the UI goroutine. Once ctx is done it stops probing and
+// returns what it found so far.
+func Find(ctx context.Context) []string {
+	var found []string
+	add := func(path string) {
+		for _, f := range found {
+			if strings.EqualFold(f, path) {
+				return
+			}
+		}
+		found = append(found, path)
+	}
+
+	// For Windows, check common paths then try to find through drive letters
+	if runtime.GOOS == "windows" {
+		for _, path := range commonPaths {
+			if _, err := os.Stat(path); err == nil {
+				if IsValid(path) {
+					add(path)
+				}
+			}
+		}
+
+		for _, drive := range "CDEFGHIJKLMNOPQRSTUVWXYZ" {
+			if ctx.Err() != nil {
+				break
+			}
+			root := string(drive) + ":\\"
+			for _, path := range FindInDirectory(root) {
+				add(path)
+			}
+		}
+		return found
+	}
+
+	// Elsewhere the game runs under Wine or Proton
+	for _, driveC := range WinePrefixes() {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, path := range FindInDirectory(driveC) {
+			add(path)
+		}
+	}
+
+	return found
+}
+
+// WinePrefixes returns the drive_c directories of the Wine prefixes found in
+// the usual places, including Steam (Proton) compatdata prefixes.
+func WinePrefixes() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var prefixes []string
+	if prefix := os.Getenv("WINEPREFIX"); prefix != "" {
+		prefixes = append(prefixes, prefix)
+	}
+	prefixes = append(prefixes, filepath.Join(home, ".wine"))
+
+	steamRoots := []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	}
+	for _, root := range steamRoots {
+		matches, _ := filepath.Glob(filepath.Join(root, "steamapps", "compatdata", "*", "pfx"))
+		prefixes = append(prefixes, matches...)
+	}
+
+	var drives []string
+	for _, prefix := range prefixes {
+		driveC := filepath.Join(prefix, "drive_c")
+		if info, err := os.Stat(driveC); err == nil && info.IsDir() {
+			drives = append(drives, driveC)
+		}
+	}
+	return drives
+}
+
+// FindInDirectory returns the DNF installations found at the usual
+// locations below root.
+func FindInDirectory(root string) []string {
+	var found []string
+
+	// Folder names are resolved case-insensitively for case-sensitive
+	// file systems such as ext4 under Wine.
+	candidates := []string{
+		// WeGame
+		JoinFold(root, "Wegame", "WeGame", "games", "DNF"),
+		JoinFold(root, "Program Files", "Wegame", "WeGame", "games", "DNF"),
+		JoinFold(root, "Program Files (x86)", "Wegame", "WeGame", "games", "DNF"),
+		// Standalone and private-server clients
+		JoinFold(root, "DNF"),
+		JoinFold(root, "地下城与勇士"),
+		JoinFold(root, "Program Files", "地下城与勇士"),
+		JoinFold(root, "Program Files (x86)", "地下城与勇士"),
+	}
+
+	for _, path := range candidates {
+		if IsValid(path) {
+			found = append(found, path)
+		}
+	}
+
+	return found
+}
+
+// Check describes which of the files identifying a DNF installation were
+// found in a directory.
+type Check struct {
+	Path         string
+	HasExe       bool
+	ImagePackDir string // as spelled on disk, empty when missing
+	HasScript    bool
+}
+
+// Valid reports whether the directory holds the game itself: DNF.exe plus
+// at least one of the data indicators.
+func (c Check) Valid() bool {
+	return c.HasExe && (c.ImagePackDir != "" || c.HasScript)
+}
+
+// Missing lists the indicators that were not found.
+func (c Check) Missing() []string {
+	var missing []string
+	if !c.HasExe {
+		missing = append(missing, "DNF.exe")
+	}
+	if c.ImagePackDir == "" {
+		missing = append(missing, ImagePack2Dir)
+	}
+	if !c.HasScript {
+		missing = append(missing, "Script.pvf")
+	}
+	return missing
+}
+
+// Inspect looks for DNF.exe, the NPK folder and Script.pvf in path. Names
+// are matched case-insensitively since standalone clients use ImagePacks2.
+func Inspect(path string) Check {
+	check := Check{Path: path}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return check
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case strings.EqualFold(name, "DNF.exe") && !entry.IsDir():
+			check.HasExe = true
+		case strings.EqualFold(name, "Script.pvf") && !entry.IsDir():
+			check.HasScript = true
+		case entry.IsDir() && check.ImagePackDir == "":
+			for _, dir := range ImagePackDirNames {
+				if strings.EqualFold(name, dir) {
+					check.ImagePackDir = name
+				}
+			}
+		}
+	}
+
+	return check
+}
+
+// IsValid reports whether path holds a DNF installation.
+func IsValid(path string) bool {
+	return Inspect(path).Valid()
+}
+
+// Running reports whether the game at path is running, which Windows shows
+// by refusing to open DNF.exe for writing.
+func Running(path string) bool {
+	name, ok := FindEntryFold(path, "DNF.exe")
+	return ok && fsutil.InUse(filepath.Join(path, name))
+}
+
+// DetectVersion reads the file version from the version resource of
+// DNF.exe, returning "" when it cannot be determined.
+func DetectVersion(path string) string {
+	name, ok := FindEntryFold(path, "DNF.exe")
+	if !ok {
+		return ""
+	}
+	f, err := pe.Open(filepath.Join(path, name))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	rsrc := f.Section(".rsrc")
+	if rsrc == nil {
+		return ""
+	}
+	data, err := rsrc.Data()
+	if err != nil {
+		return ""
+	}
+
+	// VS_FIXEDFILEINFO starts with the 0xFEEF04BD signature followed by the
+	// struct version and the file version as two DWORDs.
+	i := bytes.Index(data, []byte{0xBD, 0x04, 0xEF, 0xFE})
+	if i < 0 || i+16 > len(data) {
+		return ""
+	}
+	ms := binary.LittleEndian.Uint32(data[i+8:])
+	ls := binary.LittleEndian.Uint32(data[i+12:])
+	return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xFFFF, ls>>16, ls&0xFFFF)
+}
+
+// FindEntryFold looks for a directory entry matching any of names
+// case-insensitively and returns its name as spelled on disk.
+func FindEntryFold(dir string, names ...string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, name := range names {
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), name) {
+				return entry.Name(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// JoinFold joins elem onto root like filepath.Join, but uses the casing found
+// on disk for every element that exists under a different case.
+func JoinFold(root string, elem ...string) string {
+	path := root
+	for _, e := range elem {
+		if _, err := os.Stat(filepath.Join(path, e)); err != nil {
+			if name, ok := FindEntryFold(path, e); ok {
+				e = name
+			}
+		}
+		path = filepath.Join(path, e)
+	}
+	return path
+}
+
+// ImagePackPath returns the NPK folder of the game at dnfPath using the
+// casing present on disk, falling back to ImagePack2Dir when it is missing.
+func ImagePackPath(dnfPath string) string {
+	if name, ok := FindEntryFold(dnfPath, ImagePackDirNames...); ok {
+		return filepath.Join(dnfPath, name)
+	}
+	return filepath.Join(dnfPath, ImagePack2Dir)
+}
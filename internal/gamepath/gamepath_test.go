@@ -0,0 +1,146 @@
+package gamepath
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []string
+		dirs         []string
+		valid        bool
+		imagePackDir string
+		missing      []string
+	}{
+		{
+			name:    "empty directory",
+			valid:   false,
+			missing: []string{"DNF.exe", "imagepack2", "Script.pvf"},
+		},
+		{
+			name:         "backup dump with only imagepack2",
+			dirs:         []string{"imagepack2"},
+			valid:        false,
+			imagePackDir: "imagepack2",
+			missing:      []string{"DNF.exe", "Script.pvf"},
+		},
+		{
+			name:    "executable without game data",
+			files:   []string{"DNF.exe"},
+			valid:   false,
+			missing: []string{"imagepack2", "Script.pvf"},
+		},
+		{
+			name:         "wegame layout",
+			files:        []string{"DNF.exe", "Script.pvf"},
+			dirs:         []string{"imagepack2"},
+			valid:        true,
+			imagePackDir: "imagepack2",
+		},
+		{
+			name:         "standalone layout",
+			files:        []string{"DNF.exe"},
+			dirs:         []string{"ImagePacks2"},
+			valid:        true,
+			imagePackDir: "ImagePacks2",
+			missing:      []string{"Script.pvf"},
+		},
+		{
+			name:    "mixed case executable and script",
+			files:   []string{"dnf.EXE", "script.PVF"},
+			valid:   true,
+			missing: []string{"imagepack2"},
+		},
+		{
+			name:    "imagepack2 is a file",
+			files:   []string{"DNF.exe", "imagepack2"},
+			valid:   false,
+			missing: []string{"imagepack2", "Script.pvf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.dirs {
+				if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			check := Inspect(dir)
+			if check.Valid() != tt.valid {
+				t.Errorf("Valid() = %v, want %v", check.Valid(), tt.valid)
+			}
+			if IsValid(dir) != tt.valid {
+				t.Errorf("IsValid() = %v, want %v", IsValid(dir), tt.valid)
+			}
+			if check.ImagePackDir != tt.imagePackDir {
+				t.Errorf("ImagePackDir = %q, want %q", check.ImagePackDir, tt.imagePackDir)
+			}
+			if !reflect.DeepEqual(check.Missing(), tt.missing) {
+				t.Errorf("Missing() = %v, want %v", check.Missing(), tt.missing)
+			}
+		})
+	}
+}
+
+func TestInspectMissingDirectory(t *testing.T) {
+	if IsValid(filepath.Join(t.TempDir(), "does-not-exist")) {
+		t.Error("nonexistent directory reported as valid")
+	}
+}
+
+func TestFindLauncher(t *testing.T) {
+	touch := func(path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := t.TempDir()
+	wegame := filepath.Join(root, "WeGame", "games", "DNF")
+	touch(filepath.Join(wegame, "DNF.exe"))
+	touch(filepath.Join(root, "WeGame", "WeGame.exe"))
+	l, err := FindLauncher(wegame, "42")
+	want := Launcher{
+		Exe:    filepath.Join(root, "WeGame", "WeGame.exe"),
+		Args:   []string{"/StartFor=42"},
+		Dir:    filepath.Join(root, "WeGame"),
+		WeGame: true,
+	}
+	if err != nil || !reflect.DeepEqual(l, want) {
+		t.Errorf("FindLauncher(wegame) = %+v, %v, want %+v", l, err, want)
+	}
+
+	standalone := filepath.Join(root, "DNF")
+	touch(filepath.Join(standalone, "dnf.exe"))
+	l, err = FindLauncher(standalone, "42")
+	want = Launcher{Exe: filepath.Join(standalone, "dnf.exe"), Dir: standalone}
+	if err != nil || !reflect.DeepEqual(l, want) {
+		t.Errorf("FindLauncher(standalone) = %+v, %v, want %+v", l, err, want)
+	}
+
+	// A WeGame layout without wegame.exe falls back to DNF.exe
+	orphan := filepath.Join(root, "Other", "games", "DNF")
+	touch(filepath.Join(orphan, "DNF.exe"))
+	if l, err := FindLauncher(orphan, "42"); err != nil || l.WeGame {
+		t.Errorf("FindLauncher(orphan) = %+v, %v, want DNF.exe", l, err)
+	}
+
+	if _, err := FindLauncher(filepath.Join(root, "missing"), "42"); err != ErrNoExecutable {
+		t.Errorf("FindLauncher(missing) error = %v, want ErrNoExecutable", err)
+	}
+}
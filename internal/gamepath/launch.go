@@ -0,0 +1,45 @@
+package gamepath
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultWeGameAppID is the ID WeGame knows DNF by, passed to wegame.exe to
+// start the game.
+const DefaultWeGameAppID = "2"
+
+// ErrNoExecutable is returned by FindLauncher when there is nothing to start
+// the game with.
+var ErrNoExecutable = errors.New("neither WeGame nor DNF.exe was found")
+
+// Launcher is the program that starts the game.
+type Launcher struct {
+	Exe    string
+	Args   []string
+	Dir    string // working directory
+	WeGame bool   // Exe is wegame.exe
+}
+
+// FindLauncher returns how to start the game at path. WeGame installs, which
+// keep the game in <WeGame>\games\DNF, are started through wegame.exe with
+// appID so the player is logged in; standalone clients run DNF.exe.
+func FindLauncher(path, appID string) (Launcher, error) {
+	games := filepath.Dir(path)
+	if strings.EqualFold(filepath.Base(games), "games") {
+		root := filepath.Dir(games)
+		if name, ok := FindEntryFold(root, "wegame.exe"); ok {
+			return Launcher{
+				Exe:    filepath.Join(root, name),
+				Args:   []string{"/StartFor=" + appID},
+				Dir:    root,
+				WeGame: true,
+			}, nil
+		}
+	}
+	if name, ok := FindEntryFold(path, "DNF.exe"); ok {
+		return Launcher{Exe: filepath.Join(path, name), Dir: path}, nil
+	}
+	return Launcher{}, ErrNoExecutable
+}
@@ -0,0 +1,106 @@
+// Package i18n translates the user interface. Messages are looked up by ID
+// in the catalog of the current language, falling back to English.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Languages lists the supported languages, English first.
+var Languages = []string{"en", "zh-CN"}
+
+var catalogs = map[string]map[string]string{
+	"en":    en,
+	"zh-CN": zhCN,
+}
+
+var (
+	mu      sync.RWMutex
+	current = "en"
+)
+
+// SetLanguage switches to lang, one of Languages, or to the language of the
+// operating system when lang is "auto" or not supported.
+func SetLanguage(lang string) {
+	if _, ok := catalogs[lang]; !ok {
+		lang = Match(systemLocale())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Language returns the current language.
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Match returns the supported language closest to a locale name such as
+// "zh_CN.UTF-8" or "zh-Hans-CN", defaulting to English.
+func Match(locale string) string {
+	locale = strings.ToLower(locale)
+	if locale == "zh" || strings.HasPrefix(locale, "zh-") || strings.HasPrefix(locale, "zh_") {
+		return "zh-CN"
+	}
+	return "en"
+}
+
+// T returns the message with the given ID in the current language, formatted
+// with args as by fmt.Sprintf when there are any. A message missing from the
+// catalog is taken from the English one, and an unknown ID is returned as is.
+func T(id string, args ...interface{}) string {
+	msg, ok := catalogs[Language()][id]
+	if !ok {
+		msg, ok = en[id]
+	}
+	if !ok {
+		msg = id
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// FormatDateTime formats t in the local time zone the way the current
+// language writes dates.
+func FormatDateTime(t time.Time) string {
+	return t.Local().Format(T("format.dateTime"))
+}
+
+// FormatDate formats the date of t in the local time zone the way the
+// current language writes dates.
+func FormatDate(t time.Time) string {
+	return t.Local().Format(T("format.date"))
+}
+
+// FormatRelative formats how long before now t was, such as "3 hours ago".
+// Times more than a month ago are formatted by FormatDate, and times after
+// now, from a clock that was ahead, count as just now.
+func FormatRelative(t, now time.Time) string {
+	switch d := now.Sub(t); {
+	case d < time.Minute:
+		return T("time.justNow")
+	case d < time.Hour:
+		return Plural(int(d/time.Minute), "time.minuteAgo", "time.minutesAgo")
+	case d < 24*time.Hour:
+		return Plural(int(d/time.Hour), "time.hourAgo", "time.hoursAgo")
+	case d < 30*24*time.Hour:
+		return Plural(int(d/(24*time.Hour)), "time.dayAgo", "time.daysAgo")
+	}
+	return FormatDate(t)
+}
+
+// Plural returns the message one for n == 1 and many, formatted with n,
+// otherwise.
+func Plural(n int, one, many string) string {
+	if n == 1 {
+		return T(one)
+	}
+	return T(many, n)
+}
@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	for locale, want := range map[string]string{
+		"zh_CN.UTF-8": "zh-CN",
+		"zh-Hans-CN":  "zh-CN",
+		"zh":          "zh-CN",
+		"en_US.UTF-8": "en",
+		"de-DE":       "en",
+		"":            "en",
+	} {
+		if got := Match(locale); got != want {
+			t.Errorf("Match(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage("en")
+	en["test.only.en"] = "Only %s"
+	defer delete(en, "test.only.en")
+
+	SetLanguage("zh-CN")
+	if got := T("test.only.en", "English"); got != "Only English" {
+		t.Errorf("T() of a message without translation = %q, want the English one", got)
+	}
+	if got := T("test.unknown"); got != "test.unknown" {
+		t.Errorf("T() of an unknown ID = %q, want the ID", got)
+	}
+	if got := T("tab.settings"); got == en["tab.settings"] {
+		t.Errorf("T(tab.settings) = %q in zh-CN, want a translation", got)
+	}
+}
+
+// TestCatalogs checks that every translation belongs to an English message
+// and takes the same arguments.
+func TestCatalogs(t *testing.T) {
+	verbs := func(msg string) string {
+		var b strings.Builder
+		for i := 0; i < len(msg)-1; i++ {
+			if msg[i] == '%' {
+				i++
+				b.WriteByte(msg[i])
+			}
+		}
+		return b.String()
+	}
+	for lang, catalog := range catalogs {
+		for id, msg := range catalog {
+			english, ok := en[id]
+			if !ok {
+				t.Errorf("%s: %s is not in the English catalog", lang, id)
+				continue
+			}
+			if verbs(msg) != verbs(english) {
+				t.Errorf("%s: %s = %q, takes other arguments than %q", lang, id, msg, english)
+			}
+		}
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	defer SetLanguage("en")
+	at := time.Date(2024, 3, 5, 14, 7, 9, 0, time.Local)
+	SetLanguage("zh-CN")
+	if got := FormatDateTime(at); got != "2024年3月5日 14:07:09" {
+		t.Errorf("FormatDateTime() = %q in zh-CN", got)
+	}
+	SetLanguage("en")
+	if got := FormatDateTime(at); got != "Mar 5, 2024 14:07:09" {
+		t.Errorf("FormatDateTime() = %q in en", got)
+	}
+}
+
+func TestFormatDateTimeLocal(t *testing.T) {
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.FixedZone("UTC+8", 8*60*60)
+	at := time.Date(2024, 3, 5, 20, 7, 9, 0, time.UTC)
+	if got := FormatDateTime(at); got != "Mar 6, 2024 04:07:09" {
+		t.Errorf("FormatDateTime() = %q, want the time in the local zone", got)
+	}
+}
+
+func TestFormatRelative(t *testing.T) {
+	defer SetLanguage("en")
+	now := time.Date(2024, 3, 5, 14, 0, 0, 0, time.Local)
+	tests := []struct {
+		ago    time.Duration
+		en, zh string
+	}{
+		{-time.Minute, "just now", "刚刚"},
+		{30 * time.Second, "just now", "刚刚"},
+		{time.Minute, "1 minute ago", "1 分钟前"},
+		{59 * time.Minute, "59 minutes ago", "59 分钟前"},
+		{3 * time.Hour, "3 hours ago", "3 小时前"},
+		{25 * time.Hour, "1 day ago", "1 天前"},
+		{40 * 24 * time.Hour, "Jan 25, 2024", "2024年1月25日"},
+	}
+	for _, tt := range tests {
+		SetLanguage("en")
+		if got := FormatRelative(now.Add(-tt.ago), now); got != tt.en {
+			t.Errorf("FormatRelative(%v ago) = %q, want %q", tt.ago, got, tt.en)
+		}
+		SetLanguage("zh-CN")
+		if got := FormatRelative(now.Add(-tt.ago), now); got != tt.zh {
+			t.Errorf("FormatRelative(%v ago) = %q in zh-CN, want %q", tt.ago, got, tt.zh)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package i18n
+
+import "os"
+
+// systemLocale returns the locale set in the environment.
+func systemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
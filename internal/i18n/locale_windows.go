@@ -0,0 +1,12 @@
+package i18n
+
+import "golang.org/x/sys/windows"
+
+// systemLocale returns the first display language of the user.
+func systemLocale() string {
+	languages, err := windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+	if err != nil || len(languages) == 0 {
+		return ""
+	}
+	return languages[0]
+}
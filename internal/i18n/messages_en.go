@@ -0,0 +1,803 @@
+package i18n
+
+// en is the English catalog, which lists every message.
+var en = map[string]string{
+	"app.windowTitle": "DNF Patch Import Tool",
+	"app.title":       "DNF Patch Manager",
+	"app.subtitle":    "Manage your DNF patches with ease",
+
+	"about.title":   "About DNF Patch",
+	"about.version": "Version",
+	"about.built":   "Built",
+	"about.unknown": "unknown",
+
+	"association.registered":    ".npk files and dnfpatch:// links open with DNF Patch.",
+	"association.remove":        "Remove association",
+	"association.notRegistered": ".npk files and dnfpatch:// links do not open with DNF Patch.",
+	"association.add":           "Open .npk files and links with DNF Patch",
+	"association.hint":          "Also adds \"%s\" to the context menu of .npk files in Explorer.",
+	"association.verb":          "Install with DNF Patch Tool",
+	"association.fileType":      "DNF NPK patch",
+	"association.linkType":      "URL:DNF Patch link",
+
+	"backup.autoDescription":        "Auto backup",
+	"backup.manualDescription":      "Manual backup",
+	"backup.type.auto":              "auto",
+	"backup.type.manual":            "manual",
+	"backup.autoFailed":             "Auto backup failed",
+	"backup.autoFinished":           "Auto backup finished",
+	"backup.autoFinishedMessage":    "Backed up %d files",
+	"backup.enableAuto":             "Enable Auto Backup",
+	"backup.compression":            "Compression:",
+	"backup.compression.store":      "Store (fastest)",
+	"backup.compression.fast":       "Fast",
+	"backup.compression.balanced":   "Balanced",
+	"backup.compression.max":        "Max (smallest)",
+	"backup.archive":                "Format:",
+	"backup.archive.zip":            "ZIP (deflate)",
+	"backup.archive.tar.zst":        "zstd (.tar.zst)",
+	"backup.compressionHint":        "NPK files are mostly compressed already: compressing backups saves about a fifth of the space. ZIP takes several times longer, while zstd is about as fast as not compressing. Existing backups keep their format and can always be restored.",
+	"backup.settings":               "Backup Settings",
+	"backup.interval":               "Backup Interval:",
+	"backup.intervalCustom":         "Custom…",
+	"backup.intervalCustomValue":    "Custom: %s",
+	"backup.intervalCustomTitle":    "Custom interval",
+	"backup.intervalMinutes":        "Minutes",
+	"backup.intervalRange":          "A whole number of minutes from %d to %d",
+	"backup.max":                    "Max Backups:",
+	"backup.maxInvalid":             "Enter a whole number from %d to %d",
+	"backup.idle":                   "Wait until idle for:",
+	"backup.idleOff":                "Don't wait",
+	"backup.schedule":               "Schedule:",
+	"backup.schedule.interval":      "At an interval",
+	"backup.schedule.daily":         "Daily",
+	"backup.schedule.weekly":        "Weekly",
+	"backup.scheduleTime":           "At:",
+	"backup.scheduleDay":            "On:",
+	"backup.weekday.0":              "Sunday",
+	"backup.weekday.1":              "Monday",
+	"backup.weekday.2":              "Tuesday",
+	"backup.weekday.3":              "Wednesday",
+	"backup.weekday.4":              "Thursday",
+	"backup.weekday.5":              "Friday",
+	"backup.weekday.6":              "Saturday",
+	"backup.next":                   "Next auto backup: %s",
+	"backup.nextNone":               "No auto backup is scheduled.",
+	"backup.statusOff":              "Auto backup: off",
+	"backup.statusPaused":           "Auto backup: paused until DNF Patch quits",
+	"backup.statusOn":               "Auto backup: on",
+	"backup.statusNext":             "next in %s",
+	"backup.statusDue":              "due now",
+	"backup.statusSucceeded":        "last succeeded %s",
+	"backup.statusFailed":           "last failed %s: %v",
+	"backup.now":                    "Back up now",
+	"backup.failureBadge":           " ⚠ %d",
+	"backup.autoRetrying":           "Auto backup failed, trying again in %s: %v",
+	"backup.failingBanner":          "The last %d auto backups failed, the last with: %v. Check that the backup folder is writable and its drive has space left.",
+	"backup.scheduleHint":           "The schedule counts from the last auto backup. One that was due while DNF Patch was closed runs shortly after it starts.",
+	"backup.template":               "Description template:",
+	"backup.templateHint":           "Manual backups are described by this template: {date}, {time}, {patchcount} and {lastpatch} are replaced by the date, the time, the number of patches installed and the patch installed last.",
+	"backup.defaultTemplate":        "Manual backup {date} {time}, {patchcount} patches",
+	"backup.beforeInstall":          "Before installing %s v%s",
+	"backup.beforeInstallFile":      "Before installing %s",
+	"backup.noPatches":              "none",
+	"backup.description":            "Description",
+	"backup.editDescription":        "Edit",
+	"backup.editTitle":              "Edit backup",
+	"backup.tags":                   "Tags: %s",
+	"backup.tagsLabel":              "Tags",
+	"backup.tagsHint":               "Comma-separated, e.g. good-state, pre-season",
+	"backup.tagsInUse":              "In use: %s",
+	"backup.moreTags":               "+%d",
+	"backup.filter":                 "Filter by description or tag",
+	"backup.id":                     "Backup ID: %s",
+	"backup.type":                   "Type: %s",
+	"backup.time":                   "Time: %s",
+	"backup.files":                  "Files: %d",
+	"backup.size":                   "Size: %s",
+	"backup.usage":                  "Backups take %s",
+	"backup.usageFree":              "Backups take %s · %s free on their drive",
+	"backup.inGameTitle":            "Backups inside the game folder",
+	"backup.inGame":                 "Backups are kept in %s, which overlaps the game folder %s. Repairing or reinstalling the game, for example through WeGame, can delete every backup there. Move the backups to a folder outside the game. Until then new backups leave the earlier ones out.",
+	"backup.inGameProceed":          "Back up anyway",
+	"backup.relocate":               "Move backups…",
+	"backup.relocateInGame":         "%s overlaps the game folder as well. Choose a folder outside the game.",
+	"backup.relocating":             "Moving backups to %s...",
+	"backup.relocateFailed":         "Moving the backups failed!",
+	"backup.relocated":              "Backups moved to %s.",
+	"backup.restoring":              "Restoring backup...",
+	"backup.restoreFailed":          "Backup restoration failed!",
+	"backup.restoreCancelled":       "Backup restoration cancelled. It can be resumed on the next start.",
+	"backup.restoredSummary":        "Backup restored: %d files restored, %d unchanged.",
+	"backup.comparingFile":          "Comparing game files %d%%, file %d/%d: %s",
+	"backup.verifyingFile":          "Verifying backup %d%%, file %d/%d: %s",
+	"backup.forceFull":              "Force full restore (also rewrite files that already match)",
+	"backup.linkErrors":             "⚠️ Some links of the backup were not recreated:\n%v",
+	"backup.restoringFile":          "Restoring backup %d%%, file %d/%d: %s",
+	"backup.restored":               "Backup restored successfully!",
+	"backup.restore":                "Restore",
+	"backup.restoreTitle":           "Restore Backup",
+	"backup.restoreConfirm":         "Are you sure you want to restore this backup? Current files will be overwritten.",
+	"backup.details":                "Backup Details",
+	"backup.create":                 "Create Backup",
+	"backup.descriptionPlaceholder": "Backup description",
+	"backup.descriptionPrompt":      "Enter backup description:",
+	"backup.creating":               "Creating backup...",
+	"backup.createFailed":           "Backup creation failed!",
+	"backup.created":                "Backup created successfully!",
+
+	"cleanup.title":             "Clean Up",
+	"cleanup.hint":              "These were left behind by older versions, deleted backups and interrupted operations. Checked items are deleted.",
+	"cleanup.scanning":          "Looking for leftover files...",
+	"cleanup.failed":            "❌ Looking for leftover files failed",
+	"cleanup.found":             "Found %d leftover items",
+	"cleanup.none":              "Nothing to clean up.",
+	"cleanup.legacy":            "Backup folders in the game directory",
+	"cleanup.unrecorded":        "Backups missing from the backup list",
+	"cleanup.temp":              "Temporary files",
+	"cleanup.inUse":             "needed to uninstall %d patches",
+	"cleanup.absorb":            "Add the backup folders of the game directory to the backup list instead of losing them",
+	"cleanup.clean":             "Clean up checked",
+	"cleanup.confirm":           "Delete %d items (%s)?",
+	"cleanup.legacyDescription": "Imported from %s",
+	"cleanup.someFailed":        "❌ %d items could not be cleaned up",
+	"cleanup.done":              "✅ Cleaned up %d items, freeing %s",
+
+	"collection.removing":      "Removing %s...",
+	"collection.installing":    "Installing %s...",
+	"collection.selectHint":    "Select a collection to see its patches.",
+	"collection.item":          "%s (%d patches)",
+	"collection.active":        "✓ active",
+	"collection.apply":         "Apply",
+	"collection.deleteTitle":   "Delete Collection",
+	"collection.deleteConfirm": "Delete the collection %s? Its patches stay installed.",
+	"collection.saveInstalled": "Save installed patches…",
+	"collection.title":         "Patch Collections",
+	"collection.saveTitle":     "Save Collection",
+	"collection.nothingToSave": "There are no installed patches to save.",
+	"collection.applyTitle":    "Apply Collection",
+	"collection.upToDate":      "All patches of %s are already installed.",
+	"collection.changes":       "Applying %s changes:\n\n%s",
+	"collection.totalSize":     "Total size to install: %s",
+	"collection.unavailable":   "Not available locally, install them yourself:\n%s",
+	"collection.applyFailed":   "❌ Applying a collection failed",
+	"collection.applied":       "✨ Collection applied",
+
+	"common.success":       "Success",
+	"common.close":         "Close",
+	"common.cancel":        "Cancel",
+	"common.use":           "Use",
+	"common.create":        "Create",
+	"common.browse":        "Browse",
+	"common.select":        "Select",
+	"common.copy":          "Copy",
+	"common.openFolder":    "Open folder",
+	"common.export":        "Export…",
+	"common.selectAll":     "Select all",
+	"common.selectNone":    "Select none",
+	"common.add":           "Add…",
+	"common.remove":        "Remove",
+	"common.andMore":       "…and %d more",
+	"common.listSeparator": ", ",
+	"common.delete":        "Delete",
+	"common.name":          "Name",
+	"common.save":          "Save",
+	"common.import":        "Import…",
+
+	"crash.status":   "❌ %s failed unexpectedly",
+	"crash.failed":   "%s failed unexpectedly.",
+	"crash.previous": "DNF Patch closed unexpectedly last time.",
+	"crash.savedTo":  "A crash report was saved to:",
+	"crash.attach":   "Please attach it when reporting the problem.",
+	"crash.title":    "Unexpected Error",
+
+	"dataMode.toInstalled": "Move config, profiles, backups metadata and the cache to the user profile (%s)?",
+	"dataMode.toPortable":  "Keep all data next to the executable in %s?",
+	"dataMode.title":       "Move Data",
+	"dataMode.restart":     "DNF Patch closes afterwards and has to be restarted.",
+	"dataMode.movedTitle":  "Data Moved",
+	"dataMode.moved":       "Data now lives in %s. Please start DNF Patch again.",
+
+	"format.time":            "15:04:05",
+	"format.date":            "Jan 2, 2006",
+	"database.loaded":        "Patch database loaded, %s patches",
+	"database.loading":       "Loading the patch database, %s patches read...",
+	"database.refreshing":    "Refreshing the patch database...",
+	"database.refreshed":     "✅ Patch database refreshed, %d categories",
+	"database.refreshFailed": "❌ Refreshing the patch database failed: %v",
+
+	"duplicates.title":             "Find Duplicates",
+	"duplicates.scanning":          "Looking for duplicate patches...",
+	"duplicates.cancelled":         "Duplicate search cancelled",
+	"duplicates.failed":            "❌ Duplicate search failed",
+	"duplicates.found":             "Found %d groups of duplicate patches",
+	"duplicates.none":              "No duplicate patches were found in ImagePacks2.",
+	"duplicates.summary":           "%d groups of NPK files hold the same content. Removing the copies frees up to %s. The checked files are backed up before they are disabled or deleted.",
+	"duplicates.identical":         "Identical copies (%s redundant)",
+	"duplicates.sameIMGs":          "Different files changing the same IMGs",
+	"duplicates.installedAs":       "installed as %s",
+	"duplicates.disable":           "Disable checked",
+	"duplicates.delete":            "Delete checked",
+	"duplicates.confirmDisable":    "Back up and disable %d files? The game skips disabled files, and they can be restored from the backup.",
+	"duplicates.confirmDelete":     "Back up and delete %d files? They can be restored from the backup.",
+	"duplicates.backingUp":         "Backing up the duplicate files...",
+	"duplicates.backupDescription": "Before deleting duplicates %s",
+	"duplicates.backupDisabling":   "Before disabling duplicates %s",
+	"duplicates.removeFailed":      "❌ %d duplicate files could not be removed",
+	"duplicates.removed":           "✅ Removed %d duplicate files",
+
+	"download.queued":         "%s is queued for download",
+	"download.finished":       "Download finished",
+	"download.failed":         "Download failed",
+	"download.noGamePath":     "%s was downloaded but not installed: select the game folder first",
+	"download.maxActive":      "Simultaneous downloads",
+	"download.speedLimit":     "Speed limit (KB/s)",
+	"download.unlimited":      "Unlimited",
+	"download.invalidLimit":   "Enter a number of KB/s, or nothing for no limit",
+	"download.clearFinished":  "Clear finished",
+	"download.stateQueued":    "Queued",
+	"download.stateActive":    "%s · %s/s",
+	"download.stateDone":      "Done, %s · %s",
+	"download.stateFailed":    "Failed: %s",
+	"download.stateCancelled": "Cancelled",
+	"download.statePaused":    "Paused at %s",
+	"download.remaining":      "%s left",
+	"download.transfers":      "Downloads",
+	"download.completed":      "Completed",
+	"download.install":        "Install",
+	"checksum.title":          "Checksum mismatch",
+	"checksum.message":        "%s does not match the checksum in the patch catalogue, so it was not installed.\n\nThe file may have been corrupted in transfer or on disk, or tampered with. Download it again from the repository?",
+	"checksum.redownload":     "Download again",
+
+	"error.title":      "Error",
+	"error.details":    "Details",
+	"error.copy":       "Copy details",
+	"error.copied":     "Error details copied to the clipboard",
+	"error.permission": "Access to a file was denied. Close the game and try again, or run DNF Patch as administrator.",
+	"error.notFound":   "A file or folder could not be found. Check the game path and that the files still exist.",
+	"error.network":    "The server could not be reached. Check your connection and the proxy setting.",
+
+	"format.dateTime": "Jan 2, 2006 15:04:05",
+
+	"health.title":                 "Health Check",
+	"health.ok":                    "No problems found.",
+	"health.noGamePath":            "No game folder is selected.",
+	"health.gamePathInvalid":       "%s does not look like a DNF installation.",
+	"health.databaseFailed":        "The patch database could not be loaded: %v",
+	"health.databaseEmpty":         "The patch database is empty.",
+	"health.configNewer":           "%s was saved by a newer version of DNF Patch. The default settings are used and changes are not saved until DNF Patch is updated.",
+	"health.profileFailed":         "The profile could not be loaded: %v",
+	"health.metadataRejected":      "The patch list from the repository was not used because its signature could not be verified (%v). The last verified copy is shown instead; check the repository and its signing keys.",
+	"health.backupNotWritable":     "The backup folder %s is not writable.",
+	"health.backupInGame":          "Backups are kept in %s, which overlaps the game folder %s. A repair of the game can delete them.",
+	"health.lowSpace":              "Only %s free on the drive of %s.",
+	"health.repositoryUnreachable": "The repository %s cannot be reached.",
+	"health.retry":                 "Retry",
+	"health.openSettings":          "Open settings",
+
+	"history.check": "Check installed patches",
+	"history.title": "Installation History",
+
+	"import.failed":        "❌ Import failed: %v",
+	"import.done":          "✨ Patch imported successfully!",
+	"import.renameTitle":   "Rename the patch file",
+	"import.renameConfirm": "The file name “%s” cannot be used as is in ImagePacks2. Install it as “%s”?",
+
+	"importFolder.title":      "Import folder",
+	"importFolder.scanning":   "Looking for patch files in %s…",
+	"importFolder.found":      "Found %d patch files",
+	"importFolder.none":       "There are no .npk or .zip files in %s or its subfolders.",
+	"importFolder.message":    "%d patch files were found in %s. Choose the ones to install:",
+	"importFolder.installed":  "%s — already installed",
+	"importFolder.selected":   "%d selected (%s)",
+	"importFolder.installing": "Installing %d of %d: %s",
+	"importFolder.done":       "Installed %d of %d patch files",
+	"importFolder.failed":     "These files could not be installed:\n\n%s",
+
+	"bundle.title":   "Install %s",
+	"bundle.patch":   "%s %s",
+	"bundle.confirm": "Install the patches of this bundle into %s?",
+
+	"inspect.summary":            "%d IMG entries, %s",
+	"inspect.suspicious":         "⚠️ %d suspicious entries:\n%s",
+	"inspect.extractSelected":    "Extract selected…",
+	"inspect.extractCount":       "Extract %d selected…",
+	"inspect.selectToPreview":    "Select an entry to preview it",
+	"inspect.loadingPreview":     "Loading preview…",
+	"inspect.previewUnavailable": "Preview unavailable: %v",
+	"inspect.notNPK":             "%s is not an NPK file",
+	"inspect.extracting":         "Extracting %d IMG files...",
+	"inspect.extractFailed":      "❌ Extraction failed",
+	"inspect.extractedStatus":    "✅ Extracted %d IMG files to %s",
+	"inspect.extractedTitle":     "Extracted",
+	"inspect.extracted":          "Extracted %d IMG files to %s.",
+
+	"install.backedUp":  "📦 Created backup successfully",
+	"install.importing": "📥 Importing patch...",
+	"install.verifying": "🔍 Verifying the written patch...",
+
+	"installed.title":               "Installed Patches",
+	"installed.none":                "There are no installed patches to check.",
+	"installed.checking":            "🔍 Checking installed patches...",
+	"installed.checkFailed":         "⚠️ Failed to check installed patches: %v",
+	"installed.intact":              "All installed patches are intact.",
+	"installed.reverted":            "⚠️ %d installed patches were reverted",
+	"installed.overwritten":         "overwritten",
+	"installed.missing":             "missing",
+	"installed.notCached":           ", not in cache",
+	"installed.revertedHint":        "These patches were reverted, probably by a game client update:",
+	"installed.revertedTitle":       "Patches Reverted",
+	"installed.reapplyAll":          "Reapply all",
+	"installed.backingUp":           "📦 Backing up files restored by the client update...",
+	"installed.journalDescription":  "%d reverted patches",
+	"installed.notInCache":          "%s: not in the local patch cache",
+	"installed.reapplying":          "📥 Reapplying %s...",
+	"installed.reapplyFailedStatus": "⚠️ %d patches could not be reapplied",
+	"installed.reapplyTitle":        "Reapply Patches",
+	"installed.reapplyFailed":       "Some patches could not be reapplied:\n\n%s",
+	"installed.reapplied":           "✨ Reapplied %d patches",
+
+	"instance.runningTitle": "Already Running",
+	"instance.running":      "DNF Patch is already running.\n\n%v\n\nPlease switch to it or close it first.",
+	"link.title":            "Patch link",
+	"link.invalid":          "This link cannot be opened. It may be damaged or not meant for DNF Patch.",
+	"link.unknownSource":    "A web page",
+	"link.unknownPatch":     "The patch \"%s\" from this link is not in the patch catalogue. Refresh the patch database or check the repository setting.",
+	"link.confirmInstall":   "%s asks to install %s %s.\n\nOnly continue if you trust this site. Install it?",
+	"link.confirmDownload":  "%s asks to download and install %s from\n%s\n\nThis file is not from the patch catalogue and is not checked. Only continue if you trust this site. Install it?",
+	"clipboard.suggest":     "Install patch from copied link? (%s)",
+	"clipboard.dismiss":     "Dismiss",
+
+	"interval.minutes": "%d minutes",
+	"interval.hour":    "1 hour",
+	"interval.hours":   "%d hours",
+
+	"language.auto":  "System default",
+	"language.zh-CN": "简体中文",
+	"language.en":    "English",
+
+	"journal.state.saved":   "It had saved the file the patch replaces.",
+	"journal.state.writing": "It was writing the patch file.",
+	"journal.state.written": "It had written the patch file but not yet recorded it.",
+	"journal.state.copying": "It was copying files.",
+
+	"launch.title":         "Launch DNF",
+	"launch.busy":          "Wait for the running operations to finish before starting the game.",
+	"launch.running":       "The game is already running.",
+	"launch.activated":     "The game is already running; its window was brought to the front",
+	"launch.started":       "Starting the game...",
+	"launch.startedWeGame": "Starting the game through WeGame...",
+
+	"log.openFolder": "Open log folder",
+	"log.errorsOnly": "Errors only",
+	"log.copied":     "Copied %d log lines",
+
+	"menu.tools":       "Tools",
+	"menu.installNPK":  "Install NPK file…",
+	"menu.inspectNPK":  "Inspect NPK…",
+	"menu.mergeNPKs":   "Merge NPKs…",
+	"menu.author":      "Package a patch…",
+	"menu.collections": "Patch collections…",
+	"menu.verify":      "Verify game files…",
+	"menu.report":      "Export installed report…",
+	"menu.duplicates":  "Find duplicates…",
+	"menu.cleanup":     "Clean up…",
+	"menu.removeAll":   "Remove all patches…",
+	"menu.quit":        "Quit",
+	"menu.healthCheck": "Run health check",
+	"menu.about":       "About DNF Patch",
+	"menu.help":        "Help",
+
+	"author.title":         "Package a Patch",
+	"author.hint":          "Describe your NPK files and save them as a bundle: a ZIP with the files, the previews and a manifest.json that players and repositories can use.",
+	"author.files":         "NPK files",
+	"author.name":          "Name",
+	"author.id":            "ID",
+	"author.version":       "Version",
+	"author.author":        "Author",
+	"author.tags":          "Tags",
+	"author.description":   "Description",
+	"author.previews":      "Previews",
+	"author.noName":        "enter a name",
+	"author.badID":         "use lower-case letters, digits, dots and dashes",
+	"author.badVersion":    "use a version such as 1.0.0",
+	"author.badRepo":       "choose a patches.json file",
+	"author.noCategory":    "enter the repository category",
+	"author.category":      "Category in the repository",
+	"author.suggested":     "Suggested from the IMG paths in the files: %s, tagged %s. Edit the category and tags to change them.",
+	"author.addToRepo":     "Also add the patch to a local repository",
+	"author.build":         "Save bundle…",
+	"author.building":      "Packaging %s...",
+	"author.failed":        "❌ Packaging failed",
+	"author.done":          "✅ Saved %s",
+	"author.report":        "Packaged %d patches into %s.",
+	"author.addedToRepo":   "They were added to %s.",
+	"author.openFolder":    "Open folder",
+	"author.completeTitle": "Bundle Saved",
+
+	"merge.install":       "Install the merged pack and disable the originals",
+	"merge.merge":         "Merge…",
+	"merge.hint":          "When several files contain the same IMG, the one further down the list wins.",
+	"merge.title":         "Merge NPKs",
+	"merge.outsideGame":   "save the merged pack outside the game folder to install it",
+	"merge.merging":       "Merging %d NPK files...",
+	"merge.failed":        "❌ Merge failed",
+	"merge.installFailed": "❌ Installing the merged pack failed",
+	"merge.done":          "✅ Merged %d NPK files into %s",
+	"merge.report":        "Merged %d files into %s.",
+	"merge.conflict":      "%s: %s replaces %s",
+	"merge.conflicts":     "%d IMGs were in more than one file",
+	"merge.completeTitle": "Merge Complete",
+
+	"op.detect":           "Detecting the game",
+	"op.autoBackup":       "Auto backup",
+	"op.backup":           "Creating a backup",
+	"op.restore":          "Restoring a backup",
+	"op.install":          "Installing a patch",
+	"op.refreshDatabase":  "Refreshing the patch database",
+	"op.loadDatabase":     "Loading the patch database",
+	"op.loadProfile":      "Loading the profile",
+	"op.refreshTimes":     "Refreshing times",
+	"op.featured":         "Showing featured patches",
+	"op.loadImage":        "Loading an image",
+	"op.healthCheck":      "Running the health check",
+	"op.importDownloaded": "Importing a downloaded patch",
+	"op.verify":           "Verifying game files",
+	"op.report":           "Building the report",
+	"op.duplicates":       "Finding duplicate patches",
+	"op.cleanup":          "Cleaning up",
+	"op.cache":            "Managing the cache",
+	"op.checkDownload":    "Checking a downloaded patch",
+	"op.extract":          "Extracting IMG files",
+	"op.merge":            "Merging NPKs",
+	"op.importBundle":     "Importing a patch bundle",
+	"op.importFolder":     "Importing a folder of patches",
+	"op.author":           "Packaging a patch",
+	"op.classes":          "Reading patch classes",
+	"op.removeAll":        "Removing all patches",
+	"op.applyCollection":  "Applying a collection",
+	"op.checkInstalled":   "Checking installed patches",
+	"op.reapply":          "Reapplying patches",
+	"op.uninstall":        "Uninstalling a patch",
+	"op.moveBackups":      "Moving backups",
+
+	"operation.install": "install",
+	"operation.backup":  "backup",
+	"operation.restore": "restore",
+	"operation.reapply": "reapply",
+
+	"overlap.item":          "%s (%d IMGs)",
+	"overlap.message":       "%s changes %d IMG files that %d installed patches change as well.\nThe patch installed last wins for these files.",
+	"overlap.title":         "Overlapping Patches",
+	"overlap.installAnyway": "Install anyway",
+	"overlap.installNPK":    "Install NPK",
+
+	"featured.title": "Featured",
+
+	"reliability.installedOnce": "Installed once",
+	"reliability.installed":     "Installed %d times",
+	"reliability.failedOnce":    "failed once",
+	"reliability.failed":        "failed %d times",
+	"reliability.failures":      "%s, %s, last failure %s: %s",
+
+	"installState.blocked":   "On the blocklist: %s",
+	"installState.noPath":    "Choose the game folder to install patches.",
+	"installState.noFile":    "The patch file is not available: %v",
+	"installState.installed": "Version %s is installed already.",
+
+	"category.new":            "New category",
+	"category.rename":         "Rename %s",
+	"category.delete":         "Delete %s",
+	"category.deleteEmpty":    "Delete the empty category %s?",
+	"category.deleteMove":     "%s lists %d patches. Move them to:",
+	"category.all":            "All categories",
+	"category.empty":          "%s has no patches yet. Move patches here from their details.",
+	"category.countInstalled": "%s · %s installed",
+	"category.label":          "Category",
+	"category.moved":          "Moved %s to %s",
+
+	"catalog.updated":     "Catalogue updated %s",
+	"catalog.unreachable": "%s · the repository could not be reached",
+	"catalog.bundled":     "Showing the bundled catalogue: the repository could not be reached",
+	"catalog.refresh":     "Refresh",
+
+	"whatsNew.title":   "New & updated (%d)",
+	"whatsNew.added":   "New",
+	"whatsNew.updated": "%s → %s",
+	"whatsNew.clear":   "Mark as seen",
+
+	"zipPassword.title":         "Password required",
+	"zipPassword.message":       "%s is protected with a password. Enter it to install the patches inside.",
+	"zipPassword.wrong":         "That password does not open %s. Check it and try again.",
+	"zipPassword.password":      "Password",
+	"zipPassword.remember":      "Remember this password for later sessions",
+	"zipPassword.open":          "Open",
+	"zipPassword.cancelled":     "Installing %s was cancelled",
+	"zipPassword.failed":        "wrong archive password, the archive could not be decrypted",
+	"zipPassword.forget":        "Forget remembered passwords (%d)",
+	"zipPassword.forgetTitle":   "Forget passwords",
+	"zipPassword.forgetConfirm": "Forget the remembered ZIP passwords? Passwords used this session are still tried until the app is closed.",
+
+	"zipEntries.title":      "Choose files to install",
+	"zipEntries.message":    "%s contains %d NPK files. Archives often hold several variants of a patch that should not be installed together; choose the ones to install:",
+	"zipEntries.selected":   "%d of %d selected (%s)",
+	"zipEntries.encrypted":  "%s — password protected",
+	"zipEntries.readme":     "Readme",
+	"zipEntries.extracting": "Extracting %s…",
+
+	"class.all":      "All classes",
+	"class.multiple": "Multi-class",
+	"class.none":     "No patches for %s are known. Classes are read from the patch files you have downloaded, or given by the repository.",
+
+	"cache.title":         "Cache",
+	"cache.measuring":     "Measuring…",
+	"cache.measureFailed": "Could not measure the cache: %v",
+	"cache.usage":         "%s in %d files. Copies of installed patches and backups are not counted and are never deleted.",
+	"cache.clear":         "Clear",
+	"cache.trim":          "Trim to",
+	"cache.clearConfirm":  "Delete all downloads, images and other cached files? Installed patches and backups are kept.",
+	"cache.trimConfirm":   "Delete the cached files used longest ago until the cache takes at most %s? Installed patches and backups are kept.",
+	"cache.trimmed":       "Deleted %d cached files (%s)",
+
+	"patch.description":        "Description: %s",
+	"patch.version":            "Version: %s",
+	"patch.author":             "Author: %s",
+	"patch.class":              "Class:",
+	"patch.tags":               "Tags: %s",
+	"patch.install":            "Install Patch",
+	"patch.reinstall":          "Reinstall",
+	"patch.details":            "Patch Details",
+	"patch.search":             "Search patches...",
+	"patch.noResults":          "No patches match \"%s\".",
+	"patch.rating":             "%.1f (%d ratings)",
+	"patch.noPreviews":         "No previews available",
+	"patch.previewNumber":      "Preview %d of %d",
+	"patch.previewUnavailable": "Preview unavailable",
+	"patch.previewLoading":     "Loading preview…",
+	"patch.downloads":          "Downloads: %d",
+	"patch.size":               "Size: %s",
+	"patch.downloaded":         "Downloaded (%s)",
+	"patch.sizeUnknown":        "unknown",
+	"patch.contents":           "Contents (%d IMGs)",
+	"patch.installing":         "Installing patch: %s",
+	"patch.installCompleted":   "Patch installation completed!",
+	"patch.installConfirm":     "Install %s %s into %s?",
+	"notes.title":              "My notes",
+	"notes.empty":              "No notes yet. Only you can see what you write here.",
+	"notes.edit":               "Edit",
+	"notes.editTitle":          "My notes on %s",
+	"notes.text":               "Note",
+	"notes.textHint":           "e.g. clashes with the weapon glow patch",
+	"notes.tags":               "My tags",
+	"notes.tagsHint":           "Comma-separated, e.g. favourite, testing",
+	"notes.tagsInUse":          "In use: %s",
+	"blocklist.title":          "Blocked patch",
+	"blocklist.warning":        "This patch is on the blocklist: %s",
+	"blocklist.confirmInstall": "%s is on the blocklist because: %s\n\nInstalling it may crash the game. Install it anyway?",
+	"blocklist.installAnyway":  "Install anyway",
+	"blocklist.add":            "Block",
+	"blocklist.remove":         "Unblock",
+	"blocklist.addTitle":       "Block %s",
+	"blocklist.reason":         "Reason",
+	"blocklist.reasonHint":     "e.g. crashes since the last client update",
+	"blocklist.noReason":       "blocked by you",
+	"blocklist.installed":      "The installed patch %s is on the blocklist: %s",
+	"blocklist.uninstall":      "Uninstall",
+	"blocklist.uninstalled":    "Uninstalled %s",
+
+	"path.savedInvalid":     "The saved DNF directory %s is no longer valid. Please select it again.",
+	"path.detecting":        "🔍 Detecting game installation…",
+	"path.notFound":         "No DNF installation found, please browse to the game directory",
+	"path.detectedAt":       "DNF detected at %s",
+	"path.foundSeveral":     "Found %d DNF installations",
+	"path.selectTitle":      "Select DNF Installation",
+	"path.selectMessage":    "Several DNF installations were found:",
+	"path.detected":         "DNF detected",
+	"path.detectedVersion":  "DNF detected: version %s",
+	"path.notGame":          "Not a DNF directory, missing: %s",
+	"path.useAnywayTitle":   "Use This Directory?",
+	"path.useAnyway":        "%s does not look like a DNF installation (missing %s).\n\nUse it anyway?",
+	"path.placeholder":      "Enter DNF directory path, e.g. %s",
+	"path.detectAgain":      "Detect again",
+	"path.label":            "DNF Installation Directory:",
+	"path.selectFirst":      "Select the DNF directory first, then install %s again.",
+	"path.chooseFirst":      "Choose a valid DNF directory first.",
+	"path.selectFirstShort": "Select the DNF directory first.",
+
+	"permission.readOnlyTitle": "Read-only File",
+	"permission.readOnly":      "%s is marked read-only.\n\nClear the read-only attribute of the files in %s and try again?",
+	"permission.denied":        "Access to %s was denied.\n\nThe game is probably installed under Program Files, where changing files needs administrator rights.",
+	"permission.adminTitle":    "Administrator Rights Needed",
+	"permission.elevate":       "Restart DNF Patch as administrator and continue there?",
+
+	"profile.defaultName":     "Default",
+	"profile.label":           "Profile:",
+	"profile.new":             "New Profile",
+	"profile.path":            "Path",
+	"profile.notes":           "Notes",
+	"profile.title":           "Game Profile",
+	"profile.invalidName":     "Invalid Name",
+	"profile.nameEmpty":       "Profile name must not be empty.",
+	"profile.nameTaken":       "A profile named %s already exists.",
+	"profile.namePlaceholder": "e.g. Test client",
+	"profile.deleteTitle":     "Delete Profile",
+	"profile.deleteConfirm":   "Delete this profile? Its history and backups stay on disk but will no longer be shown.",
+
+	"quit.title":         "Quit DNF Patch",
+	"quit.running":       "Still running: %s.\n\nWait for it to finish, cancel it and quit, or quit anyway? Quitting anyway may leave partial files, which are offered for recovery on the next start.",
+	"quit.wait":          "Wait",
+	"quit.cancelAndQuit": "Cancel and quit",
+	"quit.anyway":        "Quit anyway",
+	"quit.waiting":       "Waiting for running operations to clean up...",
+
+	"recovery.resuming":           "Resuming",
+	"recovery.rollingBack":        "Rolling back",
+	"recovery.ignoring":           "Ignoring",
+	"recovery.interrupted":        "The %s “%s” started %s did not finish.",
+	"recovery.gamePath":           "Game path: %s",
+	"recovery.title":              "Interrupted Operation",
+	"recovery.rollBack":           "Roll back",
+	"recovery.resume":             "Resume",
+	"recovery.ignore":             "Ignore",
+	"recovery.failedError":        "%s failed: %v",
+	"recovery.failed":             "❌ %s failed",
+	"recovery.rollingBackInstall": "Rolling back the install of %s...",
+	"recovery.removingBackup":     "Removing the incomplete backup...",
+	"recovery.restoringReapply":   "Restoring the files from before reapplying patches...",
+	"recovery.rolledBack":         "✅ Rolled back the interrupted operation",
+	"recovery.finished":           "✅ Finished the interrupted operation",
+
+	"report.title":    "Installed Patches Report",
+	"report.hint":     "Lists the installed patches and the unknown files in imagepack2, ready to paste when asking for help.",
+	"report.building": "Building the installed patches report...",
+	"report.built":    "Report built: %d installed patches, %d unknown files",
+	"report.format":   "Format",
+	"report.markdown": "Markdown",
+	"report.text":     "Plain text",
+	"report.copy":     "Copy to clipboard",
+	"report.copied":   "Report copied to the clipboard",
+	"report.save":     "Save…",
+	"report.saved":    "Report saved to %s",
+
+	"settings.proxyPlaceholder":  "http://127.0.0.1:7890 (empty uses the system proxy)",
+	"settings.repositoryURL":     "Repository URL",
+	"settings.repositoryKeys":    "Signing keys",
+	"settings.keysHint":          "Optional: ed25519 public keys (base64), one per line",
+	"settings.proxy":             "Proxy",
+	"settings.staleAfter":        "Mark the catalogue out of date after",
+	"settings.staleDay":          "1 day",
+	"settings.staleDays":         "%d days",
+	"settings.language":          "Language",
+	"settings.languageRestart":   "The new language is used for new windows and messages straight away and everywhere after restarting DNF Patch.",
+	"settings.timeFormat":        "Times",
+	"settings.theme":             "Theme",
+	"settings.font":              "Font",
+	"settings.minimizeToTray":    "Minimize to the tray when the window is closed",
+	"settings.fontPlaceholder":   "Font file (.ttf or .otf, empty uses the bundled font)",
+	"settings.confirmInstall":    "Ask before installing a patch",
+	"settings.confirmRestore":    "Ask before restoring a backup",
+	"settings.modeInstalled":     "Installed",
+	"settings.modePortable":      "Portable",
+	"settings.toPortable":        "Switch to portable mode",
+	"settings.toInstalled":       "Move data to the user profile",
+	"settings.dataFolder":        "Data folder",
+	"settings.downloadCache":     "Download cache",
+	"settings.cacheUsage":        "Cache usage",
+	"settings.deleteToTrash":     "Move deleted files to the recycle bin",
+	"settings.verifyWrites":      "Check installed and restored files after writing them (slower)",
+	"settings.transliterate":     "Turn full-width letters and digits in imported file names into ASCII",
+	"settings.zipPasswords":      "ZIP passwords",
+	"settings.watchPlaceholder":  "e.g. Downloads\\DNF (empty turns watching off)",
+	"settings.watchFolder":       "Folder",
+	"settings.watchNewFiles":     "New files",
+	"settings.watchClipboard":    "Suggest installing patch links I copy",
+	"settings.notifyWhen":        "Show a desktop notification when:",
+	"settings.notifyBackups":     "An auto backup finishes (failures are always announced)",
+	"settings.notifyDownloads":   "A patch is downloaded into the watch folder",
+	"settings.notifyInstalls":    "Unattended or batch installs finish",
+	"settings.notifyUpdates":     "Updates of installed patches are available",
+	"settings.reset":             "Reset to defaults",
+	"settings.resetTitle":        "Reset Settings",
+	"settings.resetConfirm":      "Restore all settings to their defaults? Game profiles are kept.",
+	"settings.cardRepository":    "Repository",
+	"settings.cardInterface":     "Interface",
+	"settings.cardConfirmations": "Confirmations",
+	"settings.cardStorage":       "Storage",
+	"settings.cardWatchFolder":   "Watch Folder",
+	"settings.cardNotifications": "Notifications",
+	"settings.cardAssociation":   "File Association",
+
+	"startup.loadingPatches": "Loading the patch database...",
+	"startup.loadingProfile": "Loading the profile...",
+
+	"status.saveConfigFailed":         "⚠️ Failed to save config: %v",
+	"status.saveBackupSettingsFailed": "⚠️ Failed to save backup settings: %v",
+	"status.fontFailed":               "⚠️ Failed to load the font, using the bundled font: %v",
+	"status.ready":                    "Ready",
+
+	"tab.backups":   "Backups",
+	"tab.downloads": "Downloads",
+	"tab.patches":   "Patches",
+	"tab.history":   "History",
+	"tab.log":       "Log",
+	"tab.settings":  "Settings",
+
+	"theme.system": "System",
+	"theme.light":  "Light",
+	"theme.dark":   "Dark",
+
+	"time.justNow":    "just now",
+	"time.minuteAgo":  "1 minute ago",
+	"time.minutesAgo": "%d minutes ago",
+	"time.hourAgo":    "1 hour ago",
+	"time.hoursAgo":   "%d hours ago",
+	"time.dayAgo":     "1 day ago",
+	"time.daysAgo":    "%d days ago",
+
+	"timeFormat.absolute": "Date and time",
+	"timeFormat.relative": "Relative (3 hours ago)",
+
+	"toolbar.import":       "Import patch",
+	"toolbar.importFolder": "Import folder…",
+	"toolbar.backup":       "Create backup",
+	"toolbar.launch":       "Launch DNF",
+	"toolbar.refresh":      "Refresh database",
+	"toolbar.checkUpdates": "Check updates",
+	"toolbar.settings":     "Settings",
+	"toolbar.about":        "About",
+
+	"trash.recycle":     "They are moved to the recycle bin.",
+	"trash.permanent":   "They are deleted permanently; moving them to the recycle bin is turned off in the settings.",
+	"trash.unavailable": "The recycle bin is not available for some of them, so they are deleted permanently.",
+
+	"tray.open":           "Open DNF Patch",
+	"tray.backupNow":      "Create backup now",
+	"tray.pauseBackups":   "Pause auto backup",
+	"tray.quit":           "Quit",
+	"tray.backupsPaused":  "Auto backup paused",
+	"tray.backupsResumed": "Auto backup resumed",
+	"tray.noGamePath":     "No usable game path is set",
+
+	"update.title":       "Update Available",
+	"update.message":     "A new version (%s) is available. Current version: %s\n\nChangelog:\n%s",
+	"update.downloading": "Downloading update for %s...",
+	"update.available":   "Patch updates available",
+	"update.none":        "All installed patches are up to date",
+
+	"vanilla.checking":          "🔍 Checking game files...",
+	"vanilla.backingUp":         "📦 Creating a safety backup...",
+	"vanilla.backupDescription": "Before removing all patches",
+	"vanilla.backupPatches":     "Before removing all patches: %s",
+	"vanilla.restoring":         "♻️ Restoring vanilla files...",
+	"vanilla.title":             "Remove All Patches",
+	"vanilla.confirm":           "Every file changed by a patch will be put back to the vanilla client and all installed patches removed.\n\nA safety backup of the changed files is created first. Continue?",
+	"vanilla.failed":            "❌ Removing all patches failed",
+	"vanilla.done":              "✅ Restored %d files to vanilla",
+	"vanilla.report":            "%d files were restored or removed.",
+	"vanilla.safetyBackup":      "The files from before are in backup %s.",
+	"vanilla.left":              "%d files could not be reverted because no vanilla copy was found. Repair the game in WeGame to fix them:",
+	"vanilla.openReport":        "Open report",
+
+	"verify.vanilla":  "✅ vanilla",
+	"verify.patched":  "🧩 patched",
+	"verify.modified": "⚠️ modified",
+	"verify.missing":  "❌ missing",
+	"verify.title":    "Verify Game Files",
+	"verify.running":  "🔍 Verifying game files...",
+	"verify.failed":   "❌ Verifying game files failed",
+	"verify.done":     "✅ Verified %d game files",
+	"verify.summary":  "%d vanilla, %d changed by installed patches, %d modified by something else, %d missing",
+
+	"verifyWrite.title": "Verification Failed",
+	"verifyWrite.retry": "%s does not match what was written, perhaps because of a failing disk:\n\n%v\n\nWrite it again?",
+
+	"watch.downloaded":    "New patch downloaded",
+	"watch.newPatchTitle": "New Patch",
+	"watch.newPatch":      "%s was added to %s.\n\nInstall it now?",
+	"watch.sizeMismatch":  "⚠️ Its size differs a lot from the catalogue. The download may be incomplete or a different file.",
+	"watch.installFailed": "❌ Installing a patch failed",
+	"watch.installed":     "✨ Patch installed",
+
+	"watchAction.ask":     "Ask",
+	"watchAction.install": "Install",
+}
@@ -0,0 +1,803 @@
+package i18n
+
+// zhCN is the Simplified Chinese catalog.
+var zhCN = map[string]string{
+	"app.windowTitle": "DNF 补丁导入工具",
+	"app.title":       "DNF 补丁管理器",
+	"app.subtitle":    "轻松管理你的 DNF 补丁",
+
+	"about.title":   "关于 DNF Patch",
+	"about.version": "版本",
+	"about.built":   "构建时间",
+	"about.unknown": "未知",
+
+	"association.registered":    ".npk 文件和 dnfpatch:// 链接使用 DNF Patch 打开。",
+	"association.remove":        "取消关联",
+	"association.notRegistered": ".npk 文件和 dnfpatch:// 链接未关联到 DNF Patch。",
+	"association.add":           "使用 DNF Patch 打开 .npk 文件和链接",
+	"association.hint":          "同时在资源管理器中 .npk 文件的右键菜单添加“%s”。",
+	"association.verb":          "使用 DNF Patch Tool 安装",
+	"association.fileType":      "DNF NPK 补丁",
+	"association.linkType":      "URL:DNF Patch 链接",
+
+	"backup.autoDescription":        "自动备份",
+	"backup.manualDescription":      "手动备份",
+	"backup.type.auto":              "自动",
+	"backup.type.manual":            "手动",
+	"backup.autoFailed":             "自动备份失败",
+	"backup.autoFinished":           "自动备份完成",
+	"backup.autoFinishedMessage":    "已备份 %d 个文件",
+	"backup.enableAuto":             "启用自动备份",
+	"backup.compression":            "压缩：",
+	"backup.compression.store":      "不压缩（最快）",
+	"backup.compression.fast":       "快速",
+	"backup.compression.balanced":   "均衡",
+	"backup.compression.max":        "最大（最小）",
+	"backup.archive":                "格式：",
+	"backup.archive.zip":            "ZIP（deflate）",
+	"backup.archive.tar.zst":        "zstd（.tar.zst）",
+	"backup.compressionHint":        "NPK 文件大多已经压缩过：压缩备份大约只能节省五分之一的空间。ZIP 耗时要多几倍，zstd 则与不压缩差不多快。已有备份保持原来的格式，始终可以还原。",
+	"backup.settings":               "备份设置",
+	"backup.interval":               "备份间隔：",
+	"backup.intervalCustom":         "自定义…",
+	"backup.intervalCustomValue":    "自定义：%s",
+	"backup.intervalCustomTitle":    "自定义间隔",
+	"backup.intervalMinutes":        "分钟",
+	"backup.intervalRange":          "%d 到 %d 之间的整数分钟",
+	"backup.max":                    "最大备份数：",
+	"backup.maxInvalid":             "请输入 %d 到 %d 之间的整数",
+	"backup.idle":                   "等待电脑空闲：",
+	"backup.idleOff":                "不等待",
+	"backup.schedule":               "备份计划：",
+	"backup.schedule.interval":      "按固定间隔",
+	"backup.schedule.daily":         "每天",
+	"backup.schedule.weekly":        "每周",
+	"backup.scheduleTime":           "时间：",
+	"backup.scheduleDay":            "星期：",
+	"backup.weekday.0":              "星期日",
+	"backup.weekday.1":              "星期一",
+	"backup.weekday.2":              "星期二",
+	"backup.weekday.3":              "星期三",
+	"backup.weekday.4":              "星期四",
+	"backup.weekday.5":              "星期五",
+	"backup.weekday.6":              "星期六",
+	"backup.next":                   "下次自动备份：%s",
+	"backup.nextNone":               "没有计划的自动备份。",
+	"backup.statusOff":              "自动备份：关闭",
+	"backup.statusPaused":           "自动备份：已暂停，直到 DNF Patch 退出",
+	"backup.statusOn":               "自动备份：开启",
+	"backup.statusNext":             "%s后执行",
+	"backup.statusDue":              "即将执行",
+	"backup.statusSucceeded":        "上次成功于 %s",
+	"backup.statusFailed":           "上次失败于 %s：%v",
+	"backup.now":                    "立即备份",
+	"backup.failureBadge":           " ⚠ %d",
+	"backup.autoRetrying":           "自动备份失败，将在 %s后重试：%v",
+	"backup.failingBanner":          "最近 %d 次自动备份均失败，最后一次的错误：%v。请检查备份目录是否可写入、所在磁盘是否有剩余空间。",
+	"backup.scheduleHint":           "计划从上次自动备份起计算。DNF Patch 关闭期间错过的备份会在启动后不久执行。",
+	"backup.template":               "描述模板：",
+	"backup.templateHint":           "手动备份的描述按此模板生成：{date}、{time}、{patchcount} 和 {lastpatch} 分别替换为日期、时间、已安装补丁数和最后安装的补丁。",
+	"backup.defaultTemplate":        "手动备份 {date} {time}，{patchcount} 个补丁",
+	"backup.beforeInstall":          "安装 %s v%s 之前",
+	"backup.beforeInstallFile":      "安装 %s 之前",
+	"backup.noPatches":              "无",
+	"backup.description":            "描述",
+	"backup.editDescription":        "编辑",
+	"backup.editTitle":              "编辑备份",
+	"backup.tags":                   "标签：%s",
+	"backup.tagsLabel":              "标签",
+	"backup.tagsHint":               "用逗号分隔，例如：稳定, 赛季前",
+	"backup.tagsInUse":              "已有标签：%s",
+	"backup.moreTags":               "+%d",
+	"backup.filter":                 "按描述或标签筛选",
+	"backup.id":                     "备份 ID：%s",
+	"backup.type":                   "类型：%s",
+	"backup.time":                   "时间：%s",
+	"backup.files":                  "文件数：%d",
+	"backup.size":                   "大小：%s",
+	"backup.usage":                  "备份共占用 %s",
+	"backup.usageFree":              "备份共占用 %s · 所在磁盘剩余 %s",
+	"backup.inGameTitle":            "备份位于游戏目录中",
+	"backup.inGame":                 "备份保存在 %s，与游戏目录 %s 重叠。修复或重装游戏（例如通过 WeGame）可能会删除其中的所有备份。请将备份移到游戏目录以外的文件夹。在此之前，新备份不会包含之前的备份。",
+	"backup.inGameProceed":          "仍然备份",
+	"backup.relocate":               "移动备份…",
+	"backup.relocateInGame":         "%s 同样与游戏目录重叠，请选择游戏目录以外的文件夹。",
+	"backup.relocating":             "正在将备份移动到 %s...",
+	"backup.relocateFailed":         "移动备份失败！",
+	"backup.relocated":              "备份已移动到 %s。",
+	"backup.restoring":              "正在恢复备份...",
+	"backup.restoreFailed":          "备份恢复失败！",
+	"backup.restoreCancelled":       "已取消恢复备份，下次启动时可以继续。",
+	"backup.restoredSummary":        "备份已恢复：恢复了 %d 个文件，%d 个未改变。",
+	"backup.comparingFile":          "正在比对游戏文件 %d%%，文件 %d/%d：%s",
+	"backup.verifyingFile":          "正在校验备份 %d%%，文件 %d/%d：%s",
+	"backup.forceFull":              "强制完整恢复（已一致的文件也重新写入）",
+	"backup.linkErrors":             "⚠️ 部分备份中的链接未能重建：\n%v",
+	"backup.restoringFile":          "正在恢复备份 %d%%，文件 %d/%d：%s",
+	"backup.restored":               "备份恢复成功！",
+	"backup.restore":                "恢复",
+	"backup.restoreTitle":           "恢复备份",
+	"backup.restoreConfirm":         "确定要恢复此备份吗？当前文件将被覆盖。",
+	"backup.details":                "备份详情",
+	"backup.create":                 "创建备份",
+	"backup.descriptionPlaceholder": "备份描述",
+	"backup.descriptionPrompt":      "请输入备份描述：",
+	"backup.creating":               "正在创建备份...",
+	"backup.createFailed":           "备份创建失败！",
+	"backup.created":                "备份创建成功！",
+
+	"cleanup.title":             "清理",
+	"cleanup.hint":              "以下内容由旧版本、已删除的备份和中断的操作遗留。勾选的项目将被删除。",
+	"cleanup.scanning":          "正在查找遗留文件...",
+	"cleanup.failed":            "❌ 查找遗留文件失败",
+	"cleanup.found":             "找到 %d 个遗留项目",
+	"cleanup.none":              "没有需要清理的内容。",
+	"cleanup.legacy":            "游戏目录中的备份文件夹",
+	"cleanup.unrecorded":        "不在备份列表中的备份",
+	"cleanup.temp":              "临时文件",
+	"cleanup.inUse":             "卸载 %d 个补丁时需要",
+	"cleanup.absorb":            "将游戏目录中的备份文件夹加入备份列表，而不是直接删除",
+	"cleanup.clean":             "清理勾选的项目",
+	"cleanup.confirm":           "删除 %d 个项目（%s）？",
+	"cleanup.legacyDescription": "从 %s 导入",
+	"cleanup.someFailed":        "❌ %d 个项目无法清理",
+	"cleanup.done":              "✅ 已清理 %d 个项目，释放 %s",
+
+	"collection.removing":      "正在移除 %s...",
+	"collection.installing":    "正在安装 %s...",
+	"collection.selectHint":    "选择一个合集以查看其中的补丁。",
+	"collection.item":          "%s（%d 个补丁）",
+	"collection.active":        "✓ 当前",
+	"collection.apply":         "应用",
+	"collection.deleteTitle":   "删除合集",
+	"collection.deleteConfirm": "删除合集 %s？其中的补丁会保持安装。",
+	"collection.saveInstalled": "保存已安装的补丁…",
+	"collection.title":         "补丁合集",
+	"collection.saveTitle":     "保存合集",
+	"collection.nothingToSave": "没有可保存的已安装补丁。",
+	"collection.applyTitle":    "应用合集",
+	"collection.upToDate":      "%s 中的所有补丁均已安装。",
+	"collection.changes":       "应用 %s 将进行以下更改：\n\n%s",
+	"collection.totalSize":     "需安装的总大小：%s",
+	"collection.unavailable":   "本地没有以下补丁，请自行安装：\n%s",
+	"collection.applyFailed":   "❌ 应用合集失败",
+	"collection.applied":       "✨ 合集已应用",
+
+	"common.success":       "成功",
+	"common.close":         "关闭",
+	"common.cancel":        "取消",
+	"common.use":           "使用",
+	"common.create":        "创建",
+	"common.browse":        "浏览",
+	"common.select":        "选择",
+	"common.copy":          "复制",
+	"common.openFolder":    "打开文件夹",
+	"common.export":        "导出…",
+	"common.selectAll":     "全选",
+	"common.selectNone":    "全不选",
+	"common.add":           "添加…",
+	"common.remove":        "移除",
+	"common.andMore":       "…以及另外 %d 个",
+	"common.listSeparator": "、",
+	"common.delete":        "删除",
+	"common.name":          "名称",
+	"common.save":          "保存",
+	"common.import":        "导入…",
+
+	"crash.status":   "❌ %s意外失败",
+	"crash.failed":   "%s意外失败。",
+	"crash.previous": "DNF Patch 上次意外关闭。",
+	"crash.savedTo":  "崩溃报告已保存到：",
+	"crash.attach":   "报告问题时请附上此文件。",
+	"crash.title":    "意外错误",
+
+	"dataMode.toInstalled": "将配置、游戏配置、备份信息和缓存移到用户目录（%s）？",
+	"dataMode.toPortable":  "将所有数据保存在程序所在目录 %s？",
+	"dataMode.title":       "移动数据",
+	"dataMode.restart":     "完成后 DNF Patch 会关闭，需要重新启动。",
+	"dataMode.movedTitle":  "数据已移动",
+	"dataMode.moved":       "数据现在位于 %s。请重新启动 DNF Patch。",
+
+	"format.time":            "15:04:05",
+	"format.date":            "2006年1月2日",
+	"database.loaded":        "补丁数据库已加载，共 %s 个补丁",
+	"database.loading":       "正在加载补丁数据库，已读取 %s 个补丁...",
+	"database.refreshing":    "正在刷新补丁数据库...",
+	"database.refreshed":     "✅ 补丁数据库已刷新，共 %d 个分类",
+	"database.refreshFailed": "❌ 刷新补丁数据库失败：%v",
+
+	"duplicates.title":             "查找重复补丁",
+	"duplicates.scanning":          "正在查找重复的补丁...",
+	"duplicates.cancelled":         "已取消查找重复补丁",
+	"duplicates.failed":            "❌ 查找重复补丁失败",
+	"duplicates.found":             "找到 %d 组重复的补丁",
+	"duplicates.none":              "ImagePacks2 中没有重复的补丁。",
+	"duplicates.summary":           "%d 组 NPK 文件内容相同，删除副本最多可释放 %s。勾选的文件会先备份，再禁用或删除。",
+	"duplicates.identical":         "完全相同的副本（多余 %s）",
+	"duplicates.sameIMGs":          "修改相同 IMG 的不同文件",
+	"duplicates.installedAs":       "已安装为 %s",
+	"duplicates.disable":           "禁用勾选的文件",
+	"duplicates.delete":            "删除勾选的文件",
+	"duplicates.confirmDisable":    "备份并禁用 %d 个文件？游戏会跳过已禁用的文件，可以从备份中恢复。",
+	"duplicates.confirmDelete":     "备份并删除 %d 个文件？可以从备份中恢复。",
+	"duplicates.backingUp":         "正在备份重复的文件...",
+	"duplicates.backupDescription": "删除重复补丁 %s 之前",
+	"duplicates.backupDisabling":   "禁用重复补丁 %s 之前",
+	"duplicates.removeFailed":      "❌ %d 个重复文件无法删除",
+	"duplicates.removed":           "✅ 已删除 %d 个重复文件",
+
+	"download.queued":         "%s 已加入下载队列",
+	"download.finished":       "下载完成",
+	"download.failed":         "下载失败",
+	"download.noGamePath":     "%s 已下载但未安装：请先选择游戏目录",
+	"download.maxActive":      "同时下载数",
+	"download.speedLimit":     "限速（KB/s）",
+	"download.unlimited":      "不限速",
+	"download.invalidLimit":   "请输入 KB/s 数值，留空表示不限速",
+	"download.clearFinished":  "清除已完成",
+	"download.stateQueued":    "排队中",
+	"download.stateActive":    "%s · %s/s",
+	"download.stateDone":      "已完成，%s · %s",
+	"download.stateFailed":    "失败：%s",
+	"download.stateCancelled": "已取消",
+	"download.statePaused":    "已暂停于 %s",
+	"download.remaining":      "剩余 %s",
+	"download.transfers":      "下载",
+	"download.completed":      "已完成",
+	"download.install":        "安装",
+	"checksum.title":          "校验和不匹配",
+	"checksum.message":        "%s 与补丁库中的校验和不一致，因此未安装。\n\n文件可能在传输或保存时损坏，也可能被篡改。是否从仓库重新下载？",
+	"checksum.redownload":     "重新下载",
+
+	"error.title":      "错误",
+	"error.details":    "详细信息",
+	"error.copy":       "复制详细信息",
+	"error.copied":     "错误详细信息已复制到剪贴板",
+	"error.permission": "文件访问被拒绝。请关闭游戏后重试，或以管理员身份运行 DNF Patch。",
+	"error.notFound":   "找不到文件或文件夹。请检查游戏路径以及文件是否仍然存在。",
+	"error.network":    "无法连接服务器。请检查网络连接和代理设置。",
+
+	"format.dateTime": "2006年1月2日 15:04:05",
+
+	"health.title":                 "健康检查",
+	"health.ok":                    "未发现问题。",
+	"health.noGamePath":            "尚未选择游戏目录。",
+	"health.gamePathInvalid":       "%s 看起来不是 DNF 安装目录。",
+	"health.databaseFailed":        "无法加载补丁数据库：%v",
+	"health.databaseEmpty":         "补丁数据库为空。",
+	"health.configNewer":           "%s 由更新版本的 DNF Patch 保存。当前使用默认设置，更新 DNF Patch 之前不会保存更改。",
+	"health.profileFailed":         "无法加载游戏配置：%v",
+	"health.metadataRejected":      "仓库的补丁列表签名无法验证（%v），因此未被使用，当前显示的是上次验证通过的副本；请检查仓库及其签名公钥。",
+	"health.backupNotWritable":     "备份目录 %s 不可写入。",
+	"health.backupInGame":          "备份保存在 %s，与游戏目录 %s 重叠，修复游戏时可能会被删除。",
+	"health.lowSpace":              "仅剩 %s 可用空间（%s 所在磁盘）。",
+	"health.repositoryUnreachable": "无法连接补丁仓库 %s。",
+	"health.retry":                 "重试",
+	"health.openSettings":          "打开设置",
+
+	"history.check": "检查已安装的补丁",
+	"history.title": "安装历史",
+
+	"import.failed":        "❌ 导入失败：%v",
+	"import.done":          "✨ 补丁导入成功！",
+	"import.renameTitle":   "重命名补丁文件",
+	"import.renameConfirm": "文件名“%s”无法直接用于 ImagePacks2。是否以“%s”安装？",
+
+	"importFolder.title":      "导入文件夹",
+	"importFolder.scanning":   "正在查找 %s 中的补丁文件…",
+	"importFolder.found":      "找到 %d 个补丁文件",
+	"importFolder.none":       "%s 及其子文件夹中没有 .npk 或 .zip 文件。",
+	"importFolder.message":    "找到 %d 个补丁文件（%s）。请选择要安装的文件：",
+	"importFolder.installed":  "%s — 已安装",
+	"importFolder.selected":   "已选择 %d 个（%s）",
+	"importFolder.installing": "正在安装第 %d/%d 个：%s",
+	"importFolder.done":       "已安装 %d/%d 个补丁文件",
+	"importFolder.failed":     "以下文件无法安装：\n\n%s",
+
+	"bundle.title":   "安装 %s",
+	"bundle.patch":   "%s %s",
+	"bundle.confirm": "将此补丁包中的补丁安装到 %s？",
+
+	"inspect.summary":            "%d 个 IMG 条目，%s",
+	"inspect.suspicious":         "⚠️ %d 个可疑条目：\n%s",
+	"inspect.extractSelected":    "提取所选…",
+	"inspect.extractCount":       "提取所选的 %d 个…",
+	"inspect.selectToPreview":    "选择一个条目以预览",
+	"inspect.loadingPreview":     "正在加载预览…",
+	"inspect.previewUnavailable": "无法预览：%v",
+	"inspect.notNPK":             "%s 不是 NPK 文件",
+	"inspect.extracting":         "正在提取 %d 个 IMG 文件...",
+	"inspect.extractFailed":      "❌ 提取失败",
+	"inspect.extractedStatus":    "✅ 已将 %d 个 IMG 文件提取到 %s",
+	"inspect.extractedTitle":     "提取完成",
+	"inspect.extracted":          "已将 %d 个 IMG 文件提取到 %s。",
+
+	"install.backedUp":  "📦 备份创建成功",
+	"install.importing": "📥 正在导入补丁...",
+	"install.verifying": "🔍 正在校验写入的补丁...",
+
+	"installed.title":               "已安装的补丁",
+	"installed.none":                "没有需要检查的已安装补丁。",
+	"installed.checking":            "🔍 正在检查已安装的补丁...",
+	"installed.checkFailed":         "⚠️ 检查已安装的补丁失败：%v",
+	"installed.intact":              "所有已安装的补丁均完好。",
+	"installed.reverted":            "⚠️ %d 个已安装的补丁被还原",
+	"installed.overwritten":         "被覆盖",
+	"installed.missing":             "缺失",
+	"installed.notCached":           "，不在缓存中",
+	"installed.revertedHint":        "以下补丁被还原，可能是游戏客户端更新所致：",
+	"installed.revertedTitle":       "补丁被还原",
+	"installed.reapplyAll":          "全部重新应用",
+	"installed.backingUp":           "📦 正在备份客户端更新恢复的文件...",
+	"installed.journalDescription":  "%d 个被还原的补丁",
+	"installed.notInCache":          "%s：不在本地补丁缓存中",
+	"installed.reapplying":          "📥 正在重新应用 %s...",
+	"installed.reapplyFailedStatus": "⚠️ %d 个补丁无法重新应用",
+	"installed.reapplyTitle":        "重新应用补丁",
+	"installed.reapplyFailed":       "部分补丁无法重新应用：\n\n%s",
+	"installed.reapplied":           "✨ 已重新应用 %d 个补丁",
+
+	"instance.runningTitle": "已在运行",
+	"instance.running":      "DNF Patch 已在运行。\n\n%v\n\n请切换到该窗口或先将其关闭。",
+	"link.title":            "补丁链接",
+	"link.invalid":          "无法打开此链接，链接可能已损坏或并非用于 DNF Patch。",
+	"link.unknownSource":    "某个网页",
+	"link.unknownPatch":     "补丁库中没有此链接中的补丁“%s”。请刷新补丁数据库或检查仓库设置。",
+	"link.confirmInstall":   "%s 请求安装 %s %s。\n\n请仅在信任该网站时继续。是否安装？",
+	"link.confirmDownload":  "%s 请求下载并安装 %s，下载地址：\n%s\n\n该文件不在补丁库中，未经校验。请仅在信任该网站时继续。是否安装？",
+	"clipboard.suggest":     "是否安装复制的链接中的补丁？（%s）",
+	"clipboard.dismiss":     "忽略",
+
+	"interval.minutes": "%d 分钟",
+	"interval.hour":    "1 小时",
+	"interval.hours":   "%d 小时",
+
+	"language.auto":  "跟随系统",
+	"language.zh-CN": "简体中文",
+	"language.en":    "English",
+
+	"journal.state.saved":   "已保存被补丁替换的文件。",
+	"journal.state.writing": "正在写入补丁文件。",
+	"journal.state.written": "补丁文件已写入，但尚未记录。",
+	"journal.state.copying": "正在复制文件。",
+
+	"launch.title":         "启动 DNF",
+	"launch.busy":          "请等待正在进行的操作完成后再启动游戏。",
+	"launch.running":       "游戏已在运行。",
+	"launch.activated":     "游戏已在运行，已切换到游戏窗口",
+	"launch.started":       "正在启动游戏...",
+	"launch.startedWeGame": "正在通过 WeGame 启动游戏...",
+
+	"log.openFolder": "打开日志文件夹",
+	"log.errorsOnly": "仅显示错误",
+	"log.copied":     "已复制 %d 行日志",
+
+	"menu.tools":       "工具",
+	"menu.installNPK":  "安装 NPK 文件…",
+	"menu.inspectNPK":  "查看 NPK…",
+	"menu.mergeNPKs":   "合并 NPK…",
+	"menu.author":      "打包补丁…",
+	"menu.collections": "补丁合集…",
+	"menu.verify":      "校验游戏文件…",
+	"menu.report":      "导出已安装报告…",
+	"menu.duplicates":  "查找重复补丁…",
+	"menu.cleanup":     "清理…",
+	"menu.removeAll":   "移除所有补丁…",
+	"menu.quit":        "退出",
+	"menu.healthCheck": "运行健康检查",
+	"menu.about":       "关于 DNF Patch",
+	"menu.help":        "帮助",
+
+	"author.title":         "打包补丁",
+	"author.hint":          "填写 NPK 文件的信息并保存为补丁包：包含文件、预览图和 manifest.json 的 ZIP，可供玩家和补丁仓库使用。",
+	"author.files":         "NPK 文件",
+	"author.name":          "名称",
+	"author.id":            "ID",
+	"author.version":       "版本",
+	"author.author":        "作者",
+	"author.tags":          "标签",
+	"author.description":   "描述",
+	"author.previews":      "预览图",
+	"author.noName":        "请输入名称",
+	"author.badID":         "只能使用小写字母、数字、点和短横线",
+	"author.badVersion":    "请使用 1.0.0 这样的版本号",
+	"author.badRepo":       "请选择 patches.json 文件",
+	"author.noCategory":    "请输入仓库分类",
+	"author.category":      "仓库中的分类",
+	"author.suggested":     "根据文件中的 IMG 路径推荐：分类 %s，标签 %s。可直接修改分类和标签。",
+	"author.addToRepo":     "同时添加到本地补丁仓库",
+	"author.build":         "保存补丁包…",
+	"author.building":      "正在打包 %s...",
+	"author.failed":        "❌ 打包失败",
+	"author.done":          "✅ 已保存 %s",
+	"author.report":        "已将 %d 个补丁打包到 %s。",
+	"author.addedToRepo":   "并已添加到 %s。",
+	"author.openFolder":    "打开文件夹",
+	"author.completeTitle": "补丁包已保存",
+
+	"merge.install":       "安装合并后的补丁包并停用原文件",
+	"merge.merge":         "合并…",
+	"merge.hint":          "多个文件包含同一个 IMG 时，以列表中靠后的为准。",
+	"merge.title":         "合并 NPK",
+	"merge.outsideGame":   "要安装合并后的补丁包，请将其保存在游戏目录之外",
+	"merge.merging":       "正在合并 %d 个 NPK 文件...",
+	"merge.failed":        "❌ 合并失败",
+	"merge.installFailed": "❌ 安装合并后的补丁包失败",
+	"merge.done":          "✅ 已将 %d 个 NPK 文件合并为 %s",
+	"merge.report":        "已将 %d 个文件合并为 %s。",
+	"merge.conflict":      "%s：%s 替换了 %s",
+	"merge.conflicts":     "%d 个 IMG 出现在多个文件中",
+	"merge.completeTitle": "合并完成",
+
+	"op.detect":           "检测游戏",
+	"op.autoBackup":       "自动备份",
+	"op.backup":           "创建备份",
+	"op.restore":          "恢复备份",
+	"op.install":          "安装补丁",
+	"op.refreshDatabase":  "刷新补丁数据库",
+	"op.loadDatabase":     "加载补丁数据库",
+	"op.loadProfile":      "加载游戏配置",
+	"op.refreshTimes":     "刷新时间显示",
+	"op.featured":         "展示精选补丁",
+	"op.loadImage":        "加载图片",
+	"op.healthCheck":      "运行健康检查",
+	"op.importDownloaded": "导入下载的补丁",
+	"op.verify":           "校验游戏文件",
+	"op.report":           "生成已安装补丁报告",
+	"op.duplicates":       "查找重复补丁",
+	"op.cleanup":          "清理",
+	"op.cache":            "管理缓存",
+	"op.checkDownload":    "检查已下载的补丁",
+	"op.extract":          "提取 IMG 文件",
+	"op.merge":            "合并 NPK",
+	"op.importBundle":     "导入补丁包",
+	"op.importFolder":     "导入补丁文件夹",
+	"op.author":           "打包补丁",
+	"op.classes":          "识别补丁职业",
+	"op.removeAll":        "移除所有补丁",
+	"op.applyCollection":  "应用合集",
+	"op.checkInstalled":   "检查已安装的补丁",
+	"op.reapply":          "重新应用补丁",
+	"op.uninstall":        "卸载补丁",
+	"op.moveBackups":      "移动备份",
+
+	"operation.install": "安装",
+	"operation.backup":  "备份",
+	"operation.restore": "恢复",
+	"operation.reapply": "重新应用",
+
+	"overlap.item":          "%s（%d 个 IMG）",
+	"overlap.message":       "%s 修改的 %d 个 IMG 文件也被 %d 个已安装的补丁修改。\n这些文件以最后安装的补丁为准。",
+	"overlap.title":         "补丁冲突",
+	"overlap.installAnyway": "仍然安装",
+	"overlap.installNPK":    "安装 NPK",
+
+	"featured.title": "精选补丁",
+
+	"reliability.installedOnce": "已安装 1 次",
+	"reliability.installed":     "已安装 %d 次",
+	"reliability.failedOnce":    "失败 1 次",
+	"reliability.failed":        "失败 %d 次",
+	"reliability.failures":      "%s，%s，最近一次失败于 %s：%s",
+
+	"installState.blocked":   "在屏蔽列表中：%s",
+	"installState.noPath":    "请先选择游戏目录再安装补丁。",
+	"installState.noFile":    "无法获取补丁文件：%v",
+	"installState.installed": "已安装 %s 版本。",
+
+	"category.new":            "新建分类",
+	"category.rename":         "重命名 %s",
+	"category.delete":         "删除 %s",
+	"category.deleteEmpty":    "删除空分类 %s？",
+	"category.deleteMove":     "%s 中有 %d 个补丁，将它们移至：",
+	"category.all":            "全部分类",
+	"category.empty":          "%s 中还没有补丁。可在补丁详情中将补丁移到这里。",
+	"category.countInstalled": "%s · 已安装 %s",
+	"category.label":          "分类",
+	"category.moved":          "已将 %s 移至 %s",
+
+	"catalog.updated":     "补丁目录更新于%s",
+	"catalog.unreachable": "%s · 无法连接补丁仓库",
+	"catalog.bundled":     "正在使用内置的补丁目录：无法连接补丁仓库",
+	"catalog.refresh":     "刷新",
+
+	"whatsNew.title":   "新增与更新（%d）",
+	"whatsNew.added":   "新增",
+	"whatsNew.updated": "%s → %s",
+	"whatsNew.clear":   "标记为已读",
+
+	"zipPassword.title":         "需要密码",
+	"zipPassword.message":       "%s 受密码保护。请输入密码以安装其中的补丁。",
+	"zipPassword.wrong":         "该密码无法打开 %s。请检查后重试。",
+	"zipPassword.password":      "密码",
+	"zipPassword.remember":      "记住此密码供以后使用",
+	"zipPassword.open":          "打开",
+	"zipPassword.cancelled":     "已取消安装 %s",
+	"zipPassword.failed":        "压缩包密码错误，无法解密",
+	"zipPassword.forget":        "清除已记住的密码（%d）",
+	"zipPassword.forgetTitle":   "清除密码",
+	"zipPassword.forgetConfirm": "要清除已记住的 ZIP 密码吗？本次会话中使用过的密码在关闭程序前仍会被尝试。",
+
+	"zipEntries.title":      "选择要安装的文件",
+	"zipEntries.message":    "%s 中有 %d 个 NPK 文件。压缩包中常有同一补丁的多个版本，不应同时安装；请选择要安装的文件：",
+	"zipEntries.selected":   "已选择 %d / %d 个（%s）",
+	"zipEntries.encrypted":  "%s — 受密码保护",
+	"zipEntries.readme":     "说明",
+	"zipEntries.extracting": "正在解压 %s…",
+
+	"class.all":      "全部职业",
+	"class.multiple": "多职业",
+	"class.none":     "没有已知适用于 %s 的补丁。职业从已下载的补丁文件中识别，或由补丁仓库提供。",
+
+	"cache.title":         "缓存",
+	"cache.measuring":     "正在统计…",
+	"cache.measureFailed": "无法统计缓存：%v",
+	"cache.usage":         "%s，共 %d 个文件。已安装补丁的副本和备份不计入，也不会被删除。",
+	"cache.clear":         "清空",
+	"cache.trim":          "缩减到",
+	"cache.clearConfirm":  "删除所有下载、图片和其他缓存文件？已安装的补丁和备份会保留。",
+	"cache.trimConfirm":   "按最久未使用的顺序删除缓存文件，直到缓存不超过 %s？已安装的补丁和备份会保留。",
+	"cache.trimmed":       "已删除 %d 个缓存文件（%s）",
+
+	"patch.description":        "描述：%s",
+	"patch.version":            "版本：%s",
+	"patch.author":             "作者：%s",
+	"patch.class":              "职业：",
+	"patch.tags":               "标签：%s",
+	"patch.install":            "安装补丁",
+	"patch.reinstall":          "重新安装",
+	"patch.details":            "补丁详情",
+	"patch.search":             "搜索补丁...",
+	"patch.noResults":          "没有与“%s”匹配的补丁。",
+	"patch.rating":             "%.1f（%d 个评分）",
+	"patch.noPreviews":         "暂无预览",
+	"patch.previewNumber":      "预览 %d / %d",
+	"patch.previewUnavailable": "预览不可用",
+	"patch.previewLoading":     "正在加载预览…",
+	"patch.downloads":          "下载次数：%d",
+	"patch.size":               "大小：%s",
+	"patch.downloaded":         "已下载（%s）",
+	"patch.sizeUnknown":        "未知",
+	"patch.contents":           "内容（%d 个 IMG）",
+	"patch.installing":         "正在安装补丁：%s",
+	"patch.installCompleted":   "补丁安装完成！",
+	"patch.installConfirm":     "将 %s %s 安装到 %s？",
+	"notes.title":              "我的备注",
+	"notes.empty":              "暂无备注。这里的内容只有你自己能看到。",
+	"notes.edit":               "编辑",
+	"notes.editTitle":          "我对 %s 的备注",
+	"notes.text":               "备注",
+	"notes.textHint":           "例如：和武器光效补丁冲突",
+	"notes.tags":               "我的标签",
+	"notes.tagsHint":           "用逗号分隔，例如：常用, 测试中",
+	"notes.tagsInUse":          "已有标签：%s",
+	"blocklist.title":          "已屏蔽的补丁",
+	"blocklist.warning":        "此补丁在屏蔽列表中：%s",
+	"blocklist.confirmInstall": "%s 在屏蔽列表中，原因：%s\n\n安装后游戏可能崩溃。仍要安装吗？",
+	"blocklist.installAnyway":  "仍然安装",
+	"blocklist.add":            "屏蔽",
+	"blocklist.remove":         "取消屏蔽",
+	"blocklist.addTitle":       "屏蔽 %s",
+	"blocklist.reason":         "原因",
+	"blocklist.reasonHint":     "例如：上次客户端更新后会崩溃",
+	"blocklist.noReason":       "由你屏蔽",
+	"blocklist.installed":      "已安装的补丁 %s 在屏蔽列表中：%s",
+	"blocklist.uninstall":      "卸载",
+	"blocklist.uninstalled":    "已卸载 %s",
+
+	"path.savedInvalid":     "保存的 DNF 目录 %s 已失效，请重新选择。",
+	"path.detecting":        "🔍 正在检测游戏安装位置…",
+	"path.notFound":         "未找到 DNF 安装，请手动选择游戏目录",
+	"path.detectedAt":       "在 %s 检测到 DNF",
+	"path.foundSeveral":     "找到 %d 个 DNF 安装",
+	"path.selectTitle":      "选择 DNF 安装",
+	"path.selectMessage":    "找到多个 DNF 安装：",
+	"path.detected":         "已检测到 DNF",
+	"path.detectedVersion":  "已检测到 DNF：版本 %s",
+	"path.notGame":          "不是 DNF 目录，缺少：%s",
+	"path.useAnywayTitle":   "使用此目录？",
+	"path.useAnyway":        "%s 看起来不是 DNF 安装目录（缺少 %s）。\n\n仍然使用吗？",
+	"path.placeholder":      "输入 DNF 目录路径，例如 %s",
+	"path.detectAgain":      "重新检测",
+	"path.label":            "DNF 安装目录：",
+	"path.selectFirst":      "请先选择 DNF 目录，然后重新安装 %s。",
+	"path.chooseFirst":      "请先选择有效的 DNF 目录。",
+	"path.selectFirstShort": "请先选择 DNF 目录。",
+
+	"permission.readOnlyTitle": "只读文件",
+	"permission.readOnly":      "%s 被标记为只读。\n\n清除 %s 中文件的只读属性并重试？",
+	"permission.denied":        "访问 %s 被拒绝。\n\n游戏可能安装在 Program Files 下，修改其中的文件需要管理员权限。",
+	"permission.adminTitle":    "需要管理员权限",
+	"permission.elevate":       "以管理员身份重新启动 DNF Patch 并在那里继续？",
+
+	"profile.defaultName":     "默认",
+	"profile.label":           "游戏配置：",
+	"profile.new":             "新建配置",
+	"profile.path":            "路径",
+	"profile.notes":           "备注",
+	"profile.title":           "游戏配置",
+	"profile.invalidName":     "名称无效",
+	"profile.nameEmpty":       "配置名称不能为空。",
+	"profile.nameTaken":       "名为 %s 的配置已存在。",
+	"profile.namePlaceholder": "例如 测试服",
+	"profile.deleteTitle":     "删除配置",
+	"profile.deleteConfirm":   "删除此配置？其历史和备份会保留在磁盘上，但不再显示。",
+
+	"quit.title":         "退出 DNF Patch",
+	"quit.running":       "仍在运行：%s。\n\n等待其完成、取消后退出，还是直接退出？直接退出可能留下不完整的文件，下次启动时会提示恢复。",
+	"quit.wait":          "等待",
+	"quit.cancelAndQuit": "取消并退出",
+	"quit.anyway":        "直接退出",
+	"quit.waiting":       "正在等待运行中的操作清理...",
+
+	"recovery.resuming":           "继续",
+	"recovery.rollingBack":        "回滚",
+	"recovery.ignoring":           "忽略",
+	"recovery.interrupted":        "%s“%s”（开始于 %s）未完成。",
+	"recovery.gamePath":           "游戏路径：%s",
+	"recovery.title":              "中断的操作",
+	"recovery.rollBack":           "回滚",
+	"recovery.resume":             "继续",
+	"recovery.ignore":             "忽略",
+	"recovery.failedError":        "%s失败：%v",
+	"recovery.failed":             "❌ %s失败",
+	"recovery.rollingBackInstall": "正在回滚 %s 的安装...",
+	"recovery.removingBackup":     "正在删除不完整的备份...",
+	"recovery.restoringReapply":   "正在恢复重新应用补丁之前的文件...",
+	"recovery.rolledBack":         "✅ 已回滚中断的操作",
+	"recovery.finished":           "✅ 已完成中断的操作",
+
+	"report.title":    "已安装补丁报告",
+	"report.hint":     "列出已安装的补丁和 imagepack2 中的未知文件，可在求助时直接粘贴。",
+	"report.building": "正在生成已安装补丁报告...",
+	"report.built":    "报告已生成：%d 个已安装补丁，%d 个未知文件",
+	"report.format":   "格式",
+	"report.markdown": "Markdown",
+	"report.text":     "纯文本",
+	"report.copy":     "复制到剪贴板",
+	"report.copied":   "报告已复制到剪贴板",
+	"report.save":     "保存…",
+	"report.saved":    "报告已保存到 %s",
+
+	"settings.proxyPlaceholder":  "http://127.0.0.1:7890（留空则使用系统代理）",
+	"settings.repositoryURL":     "仓库地址",
+	"settings.repositoryKeys":    "签名公钥",
+	"settings.keysHint":          "可选：ed25519 公钥（base64），每行一个",
+	"settings.proxy":             "代理",
+	"settings.staleAfter":        "补丁目录过期提醒",
+	"settings.staleDay":          "1 天后",
+	"settings.staleDays":         "%d 天后",
+	"settings.language":          "语言",
+	"settings.languageRestart":   "新语言会立即用于新打开的窗口和消息，重启 DNF Patch 后全部生效。",
+	"settings.timeFormat":        "时间显示",
+	"settings.theme":             "主题",
+	"settings.font":              "字体",
+	"settings.minimizeToTray":    "关闭窗口时最小化到托盘",
+	"settings.fontPlaceholder":   "字体文件（.ttf 或 .otf，留空使用内置字体）",
+	"settings.confirmInstall":    "安装补丁前询问",
+	"settings.confirmRestore":    "恢复备份前询问",
+	"settings.modeInstalled":     "安装版",
+	"settings.modePortable":      "便携版",
+	"settings.toPortable":        "切换到便携模式",
+	"settings.toInstalled":       "将数据移到用户目录",
+	"settings.dataFolder":        "数据目录",
+	"settings.downloadCache":     "下载缓存",
+	"settings.cacheUsage":        "缓存占用",
+	"settings.deleteToTrash":     "将删除的文件移到回收站",
+	"settings.verifyWrites":      "写入后校验安装和恢复的文件（较慢）",
+	"settings.transliterate":     "将导入文件名中的全角字母和数字转换为半角",
+	"settings.zipPasswords":      "ZIP 密码",
+	"settings.watchPlaceholder":  "例如 Downloads\\DNF（留空则关闭监视）",
+	"settings.watchFolder":       "文件夹",
+	"settings.watchNewFiles":     "新文件",
+	"settings.watchClipboard":    "复制补丁链接时提示安装",
+	"settings.notifyWhen":        "在以下情况显示桌面通知：",
+	"settings.notifyBackups":     "自动备份完成（失败时总会通知）",
+	"settings.notifyDownloads":   "有补丁下载到监视文件夹",
+	"settings.notifyInstalls":    "无人值守或批量安装完成",
+	"settings.notifyUpdates":     "已安装的补丁有更新",
+	"settings.reset":             "恢复默认设置",
+	"settings.resetTitle":        "重置设置",
+	"settings.resetConfirm":      "将所有设置恢复为默认值？游戏配置会保留。",
+	"settings.cardRepository":    "仓库",
+	"settings.cardInterface":     "界面",
+	"settings.cardConfirmations": "确认",
+	"settings.cardStorage":       "存储",
+	"settings.cardWatchFolder":   "监视文件夹",
+	"settings.cardNotifications": "通知",
+	"settings.cardAssociation":   "文件关联",
+
+	"startup.loadingPatches": "正在加载补丁数据库...",
+	"startup.loadingProfile": "正在加载游戏配置...",
+
+	"status.saveConfigFailed":         "⚠️ 保存配置失败：%v",
+	"status.saveBackupSettingsFailed": "⚠️ 保存备份设置失败：%v",
+	"status.fontFailed":               "⚠️ 加载字体失败，已使用内置字体：%v",
+	"status.ready":                    "就绪",
+
+	"tab.backups":   "备份",
+	"tab.downloads": "下载",
+	"tab.patches":   "补丁",
+	"tab.history":   "历史",
+	"tab.log":       "日志",
+	"tab.settings":  "设置",
+
+	"theme.system": "跟随系统",
+	"theme.light":  "浅色",
+	"theme.dark":   "深色",
+
+	"time.justNow":    "刚刚",
+	"time.minuteAgo":  "1 分钟前",
+	"time.minutesAgo": "%d 分钟前",
+	"time.hourAgo":    "1 小时前",
+	"time.hoursAgo":   "%d 小时前",
+	"time.dayAgo":     "1 天前",
+	"time.daysAgo":    "%d 天前",
+
+	"timeFormat.absolute": "日期和时间",
+	"timeFormat.relative": "相对时间（3 小时前）",
+
+	"toolbar.import":       "导入补丁",
+	"toolbar.importFolder": "导入文件夹…",
+	"toolbar.backup":       "创建备份",
+	"toolbar.launch":       "启动 DNF",
+	"toolbar.refresh":      "刷新数据库",
+	"toolbar.checkUpdates": "检查更新",
+	"toolbar.settings":     "设置",
+	"toolbar.about":        "关于",
+
+	"trash.recycle":     "它们将被移到回收站。",
+	"trash.permanent":   "它们将被永久删除；设置中已关闭移到回收站。",
+	"trash.unavailable": "部分项目无法使用回收站，将被永久删除。",
+
+	"tray.open":           "打开 DNF Patch",
+	"tray.backupNow":      "立即创建备份",
+	"tray.pauseBackups":   "暂停自动备份",
+	"tray.quit":           "退出",
+	"tray.backupsPaused":  "自动备份已暂停",
+	"tray.backupsResumed": "自动备份已恢复",
+	"tray.noGamePath":     "未设置可用的游戏路径",
+
+	"update.title":       "有可用更新",
+	"update.message":     "新版本（%s）已发布。当前版本：%s\n\n更新日志：\n%s",
+	"update.downloading": "正在下载 %s 的更新...",
+	"update.available":   "有补丁更新",
+	"update.none":        "已安装的补丁均为最新版本",
+
+	"vanilla.checking":          "🔍 正在检查游戏文件...",
+	"vanilla.backingUp":         "📦 正在创建安全备份...",
+	"vanilla.backupDescription": "移除所有补丁之前",
+	"vanilla.backupPatches":     "移除所有补丁（%s）之前",
+	"vanilla.restoring":         "♻️ 正在恢复原版文件...",
+	"vanilla.title":             "移除所有补丁",
+	"vanilla.confirm":           "所有被补丁修改的文件都将恢复为原版客户端文件，并移除所有已安装的补丁。\n\n会先为被修改的文件创建安全备份。继续吗？",
+	"vanilla.failed":            "❌ 移除所有补丁失败",
+	"vanilla.done":              "✅ 已将 %d 个文件恢复为原版",
+	"vanilla.report":            "已恢复或删除 %d 个文件。",
+	"vanilla.safetyBackup":      "之前的文件保存在备份 %s 中。",
+	"vanilla.left":              "%d 个文件因找不到原版副本而无法恢复。请在 WeGame 中修复游戏：",
+	"vanilla.openReport":        "打开报告",
+
+	"verify.vanilla":  "✅ 原版",
+	"verify.patched":  "🧩 已打补丁",
+	"verify.modified": "⚠️ 已修改",
+	"verify.missing":  "❌ 缺失",
+	"verify.title":    "校验游戏文件",
+	"verify.running":  "🔍 正在校验游戏文件...",
+	"verify.failed":   "❌ 校验游戏文件失败",
+	"verify.done":     "✅ 已校验 %d 个游戏文件",
+	"verify.summary":  "%d 个原版，%d 个被已安装的补丁修改，%d 个被其他程序修改，%d 个缺失",
+
+	"verifyWrite.title": "校验失败",
+	"verifyWrite.retry": "%s 与写入的内容不一致，可能是磁盘故障：\n\n%v\n\n要重新写入吗？",
+
+	"watch.downloaded":    "已下载新补丁",
+	"watch.newPatchTitle": "新补丁",
+	"watch.newPatch":      "%s 已添加到 %s。\n\n现在安装吗？",
+	"watch.sizeMismatch":  "⚠️ 文件大小与目录中的记录相差较大，可能下载不完整或不是同一个文件。",
+	"watch.installFailed": "❌ 补丁安装失败",
+	"watch.installed":     "✨ 补丁已安装",
+
+	"watchAction.ask":     "询问",
+	"watchAction.install": "直接安装",
+}
@@ -0,0 +1,126 @@
+// Package importdir finds the patch files in a folder tree, such as one of
+// themed patches sorted into subfolders, and tells which are installed
+// already.
+package importdir
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/patchzip"
+	"dnf_patch/internal/zipcrypt"
+)
+
+// File is a patch file found under a folder.
+type File struct {
+	Path string
+	Rel  string // Path relative to the folder searched
+	Size int64
+}
+
+// Find returns the .npk and .zip files under dir and its subfolders, sorted
+// by their relative paths. Files of other types are left out, as are hidden
+// and system files and everything in hidden and system folders. Cancelling
+// ctx stops the search.
+func Find(ctx context.Context, dir string) ([]File, error) {
+	var files []File
+	root := fsutil.LongPath(dir)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if fsutil.Hidden(info) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.Type().IsRegular() || (ext != ".npk" && ext != ".zip") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: filepath.Join(dir, rel), Rel: rel, Size: info.Size()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool {
+		return strings.ToLower(files[i].Rel) < strings.ToLower(files[j].Rel)
+	})
+	return files, err
+}
+
+// Installed reports whether the NPK at path, or every NPK in it when it is
+// a ZIP archive, is in imagePack already with the same contents. hash
+// returns the hex-encoded SHA-256 of a file in imagePack. A ZIP archive
+// without NPK files is not installed, and neither is one with encrypted NPK
+// files, which cannot be compared without their password.
+func Installed(ctx context.Context, path, imagePack string, hash func(path string) (string, error)) (bool, error) {
+	same := func(name string, open func() (io.ReadCloser, error)) (bool, error) {
+		installed, err := hash(filepath.Join(imagePack, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		rc, err := open()
+		if err != nil {
+			return false, err
+		}
+		defer rc.Close()
+		h := sha256.New()
+		if _, err := fsutil.Copy(ctx, h, rc, nil); err != nil {
+			return false, err
+		}
+		return hex.EncodeToString(h.Sum(nil)) == installed, nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return same(filepath.Base(path), func() (io.ReadCloser, error) { return os.Open(fsutil.LongPath(path)) })
+	}
+	r, err := zip.OpenReader(fsutil.LongPath(path))
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	found := false
+	for _, file := range r.File {
+		// Installed under the base name, as installs of archives do
+		name := patchzip.Name(file)
+		if file.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(name), ".npk") {
+			continue
+		}
+		if zipcrypt.Encrypted(file) {
+			return false, nil
+		}
+		ok, err := same(name, file.Open)
+		if err != nil || !ok {
+			return false, err
+		}
+		found = true
+	}
+	return found, nil
+}
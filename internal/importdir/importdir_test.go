@@ -0,0 +1,94 @@
+package importdir
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dnf_patch/internal/fsutil"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Dark", "ui.NPK"), "12345")
+	writeFile(t, filepath.Join(dir, "Dark", "readme.txt"), "not a patch")
+	writeFile(t, filepath.Join(dir, "skills", "effects", "fire.npk"), "123")
+	writeFile(t, filepath.Join(dir, "pack.zip"), "zip")
+	writeFile(t, filepath.Join(dir, ".git", "objects", "x.npk"), "hidden folder")
+	writeFile(t, filepath.Join(dir, ".old.npk"), "hidden file")
+
+	files, err := Find(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, f := range files {
+		rels = append(rels, f.Rel)
+	}
+	want := []string{filepath.Join("Dark", "ui.NPK"), "pack.zip", filepath.Join("skills", "effects", "fire.npk")}
+	if !reflect.DeepEqual(rels, want) {
+		t.Fatalf("Find() = %v, want %v", rels, want)
+	}
+	if files[0].Path != filepath.Join(dir, "Dark", "ui.NPK") || files[0].Size != 5 {
+		t.Errorf("files[0] = %+v", files[0])
+	}
+}
+
+func TestInstalled(t *testing.T) {
+	dir, game := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(game, "ui.npk"), "dark ui")
+	writeFile(t, filepath.Join(game, "font.npk"), "big font")
+	writeFile(t, filepath.Join(dir, "ui.npk"), "dark ui")
+	writeFile(t, filepath.Join(dir, "v2", "ui.npk"), "dark ui 2")
+	writeFile(t, filepath.Join(dir, "new.npk"), "new")
+	writeZip(t, filepath.Join(dir, "both.zip"), map[string]string{"x/ui.npk": "dark ui", "font.npk": "big font", "readme.txt": "hi"})
+	writeZip(t, filepath.Join(dir, "partly.zip"), map[string]string{"ui.npk": "dark ui", "new.npk": "new"})
+	writeZip(t, filepath.Join(dir, "empty.zip"), map[string]string{"readme.txt": "hi"})
+
+	for name, want := range map[string]bool{
+		"ui.npk":                      true,
+		filepath.Join("v2", "ui.npk"): false,
+		"new.npk":                     false,
+		"both.zip":                    true,
+		"partly.zip":                  false,
+		"empty.zip":                   false,
+	} {
+		got, err := Installed(context.Background(), filepath.Join(dir, name), game, fsutil.HashFile)
+		if err != nil || got != want {
+			t.Errorf("Installed(%s) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+}
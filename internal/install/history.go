@@ -0,0 +1,117 @@
+package install
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"dnf_patch/internal/schema"
+)
+
+// StatusFailed is the status of the entries of failed installs.
+const StatusFailed = "Failed"
+
+type HistoryEntry struct {
+	PatchID   string    `json:"patchId"`
+	PatchName string    `json:"patchName"`
+	Version   string    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"` // why a failed install failed
+}
+
+// historyFile is the format of install_history.json, which was a list of
+// entries before it was versioned. Version 2 added why installs failed.
+var historyFile = schema.File{
+	Version:    2,
+	Migrations: []schema.Migration{schema.Wrap("entries"), schema.Unchanged},
+}
+
+type historyDocument struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Entries       []HistoryEntry `json:"entries"`
+}
+
+// History is the install history of a profile, oldest entry first. It is
+// safe for concurrent use.
+type History struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	path    string
+	newer   error // from Load when the file is of a newer version
+}
+
+// NewHistory returns an empty history stored at path.
+func NewHistory(path string) *History {
+	return &History{entries: []HistoryEntry{}, path: path}
+}
+
+func (h *History) Load() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.newer = nil
+	data, err := historyFile.Read(h.path)
+	if os.IsNotExist(err) {
+		h.entries = []HistoryEntry{}
+		return nil
+	}
+	if errors.As(err, new(*schema.NewerError)) {
+		h.newer = err
+	}
+	if err != nil {
+		return err
+	}
+	var doc historyDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	h.entries = doc.Entries
+	if h.entries == nil {
+		h.entries = []HistoryEntry{}
+	}
+	return nil
+}
+
+// save writes the history, unless it was written by a newer version. The
+// caller must hold h.mu.
+func (h *History) save() error {
+	if h.newer != nil {
+		return h.newer
+	}
+	data, err := json.MarshalIndent(historyDocument{historyFile.Version, h.entries}, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0644)
+}
+
+// Entries returns a copy of the history, oldest entry first.
+func (h *History) Entries() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]HistoryEntry(nil), h.entries...)
+}
+
+// Add appends an entry for the given patch and saves the history. It
+// returns the entry, which is kept even when saving fails.
+func (h *History) Add(patchID, patchName, version, status string) (HistoryEntry, error) {
+	return h.add(HistoryEntry{PatchID: patchID, PatchName: patchName, Version: version, Status: status})
+}
+
+// AddFailure appends an entry for an install of the given patch that failed
+// with cause and saves the history, like Add.
+func (h *History) AddFailure(patchID, patchName, version string, cause error) (HistoryEntry, error) {
+	return h.add(HistoryEntry{PatchID: patchID, PatchName: patchName, Version: version, Status: StatusFailed, Error: cause.Error()})
+}
+
+func (h *History) add(e HistoryEntry) (HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e.Timestamp = time.Now().UTC()
+	h.entries = append(h.entries, e)
+	return e, h.save()
+}
@@ -0,0 +1,385 @@
+// Package install writes patch files into a DNF installation and keeps the
+// registry of installed patches and the install history.
+package install
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/relpath"
+	"dnf_patch/internal/schema"
+)
+
+// Record describes a patch file written into imagepack2.
+type Record struct {
+	PatchID     string    `json:"patchId"`
+	PatchName   string    `json:"patchName"`
+	Version     string    `json:"version"`
+	Filename    string    `json:"filename"`
+	Hash        string    `json:"hash"`
+	InstalledAt time.Time `json:"installedAt"`
+	// Original is the copy of the file the patch replaced and Added is set
+	// when there was none. Records of older versions have neither.
+	Original string `json:"original,omitempty"`
+	Added    bool   `json:"added,omitempty"`
+	// SourceName is the name of the imported file when Filename is the
+	// normalized form of it, see NormalizeName.
+	SourceName string `json:"sourceName,omitempty"`
+}
+
+// Key returns the file name of rec in canonical form, under which the
+// registry tells its records apart.
+func (rec Record) Key() relpath.Path {
+	return relpath.Canonical(rec.Filename)
+}
+
+// ErrOriginalUnknown is returned by Uninstall for records that do not say
+// what the patch replaced.
+var ErrOriginalUnknown = errors.New("the file the patch replaced was not recorded")
+
+// registryFile is the format of installed.json, which was a list of records
+// before it was versioned. Version 2 added the name files were imported
+// under.
+var registryFile = schema.File{
+	Version:    2,
+	Migrations: []schema.Migration{schema.Wrap("patches"), schema.Unchanged},
+}
+
+type registryDocument struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Patches       []Record `json:"patches"`
+}
+
+// Registry is the list of installed patches of a profile, stored as JSON.
+// It is safe for concurrent use.
+type Registry struct {
+	// OnChange, if set, is called after the records are loaded, added or
+	// removed. It is called without locks held, from the goroutine making
+	// the change.
+	OnChange func()
+
+	mu      sync.Mutex
+	patches []Record
+	path    string
+	newer   error // from Load when the file is of a newer version
+}
+
+// NewRegistry returns an empty registry stored at path.
+func NewRegistry(path string) *Registry {
+	return &Registry{patches: []Record{}, path: path}
+}
+
+func (r *Registry) Load() error {
+	defer r.changed()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.newer = nil
+	data, err := registryFile.Read(r.path)
+	if os.IsNotExist(err) {
+		r.patches = []Record{}
+		return nil
+	}
+	if errors.As(err, new(*schema.NewerError)) {
+		r.newer = err
+	}
+	if err != nil {
+		return err
+	}
+	var doc registryDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	r.patches = dedupe(doc.Patches)
+	if r.patches == nil {
+		r.patches = []Record{}
+	}
+	return nil
+}
+
+// dedupe keeps the most recently installed record of every file. Registries
+// written before file names were compared in canonical form can hold one
+// record per spelling of the same file.
+func dedupe(patches []Record) []Record {
+	latest := make(map[relpath.Path]int, len(patches))
+	out := patches[:0]
+	for _, rec := range patches {
+		i, ok := latest[rec.Key()]
+		if !ok {
+			latest[rec.Key()] = len(out)
+			out = append(out, rec)
+		} else if !rec.InstalledAt.Before(out[i].InstalledAt) {
+			out[i] = rec
+		}
+	}
+	return out
+}
+
+// save writes the registry, unless it was written by a newer version. The
+// caller must hold r.mu.
+func (r *Registry) save() error {
+	if r.newer != nil {
+		return r.newer
+	}
+	data, err := json.MarshalIndent(registryDocument{registryFile.Version, r.patches}, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
+
+func (r *Registry) changed() {
+	if r.OnChange != nil {
+		r.OnChange()
+	}
+}
+
+// Patches returns a copy of the installed patches.
+func (r *Registry) Patches() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record(nil), r.patches...)
+}
+
+// Add records rec, replacing any previous record of the same file, and
+// saves the registry.
+func (r *Registry) Add(rec Record) error {
+	defer r.changed()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	patches := make([]Record, 0, len(r.patches)+1)
+	for _, installed := range r.patches {
+		if installed.Key() != rec.Key() {
+			patches = append(patches, installed)
+		}
+	}
+	r.patches = append(patches, rec)
+	return r.save()
+}
+
+// Remove drops the record of filename, if any, and saves the registry.
+func (r *Registry) Remove(filename string) error {
+	defer r.changed()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := relpath.Canonical(filename)
+	patches := make([]Record, 0, len(r.patches))
+	for _, installed := range r.patches {
+		if installed.Key() != key {
+			patches = append(patches, installed)
+		}
+	}
+	if len(patches) == len(r.patches) {
+		return nil
+	}
+	r.patches = patches
+	return r.save()
+}
+
+// Cache keeps copies of installed patch files, named by hash, so they can be
+// reapplied after the game client overwrites them.
+type Cache struct {
+	Dir string
+}
+
+// Path returns where a patch file with the given hash is cached.
+func (c Cache) Path(hash, filename string) string {
+	return filepath.Join(c.Dir, hash+strings.ToLower(filepath.Ext(filename)))
+}
+
+// Store copies the file at path with the given hash into the cache.
+func (c Cache) Store(path, hash string) error {
+	cachePath := c.Path(hash, path)
+	if _, err := os.Stat(cachePath); err == nil {
+		return nil
+	}
+	return fsutil.CopyFile(path, cachePath)
+}
+
+// Has reports whether the file of rec is cached.
+func (c Cache) Has(rec Record) bool {
+	_, err := os.Stat(c.Path(rec.Hash, rec.Filename))
+	return err == nil
+}
+
+// Install writes the patch read from src into the imagepack2 folder of the
+// game at gameDir as filename, backing up any file it replaces into a
+// backup_<time> folder of the game. Cancelling ctx stops writing the patch.
+// progress receives status messages as the install proceeds. The returned
+// record carries the hash of the written file; the caller fills in the
+// catalogue details.
+func Install(ctx context.Context, gameDir string, src io.Reader, filename string, progress func(string)) (Record, error) {
+	pending, err := Prepare(gameDir, filename, progress)
+	if err != nil {
+		return Record{}, err
+	}
+	return pending.Write(ctx, src, progress)
+}
+
+// Pending is an install whose replaced file, if any, has been saved but whose
+// patch has not been written yet.
+type Pending struct {
+	Target string // the file the patch is written to
+	Saved  string // copy of the file Target replaces, empty when there was none
+	// Verify makes Write read Target back and compare it with the bytes
+	// written, failing with a *fsutil.MismatchError when they differ.
+	Verify bool
+}
+
+// Prepare is the first half of Install: it creates the imagepack2 folder and
+// saves the file the patch will replace.
+func Prepare(gameDir, filename string, progress func(string)) (Pending, error) {
+	// Check imagepack2 directory
+	imagepackPath := gamepath.ImagePackPath(gameDir)
+	if err := os.MkdirAll(fsutil.LongPath(imagepackPath), 0755); err != nil {
+		return Pending{}, err
+	}
+	pending := Pending{Target: filepath.Join(imagepackPath, filename)}
+
+	// Backup existing file if it exists
+	if _, err := os.Stat(fsutil.LongPath(pending.Target)); err == nil {
+		backupDir := filepath.Join(gameDir, "backup_"+time.Now().Format("20060102_150405"))
+		pending.Saved = filepath.Join(backupDir, filename)
+		if err := fsutil.CopyFile(pending.Target, pending.Saved); err != nil {
+			return Pending{}, fmt.Errorf("backup failed: %w", err)
+		}
+		progress(i18n.T("install.backedUp"))
+	}
+
+	return pending, nil
+}
+
+// Write is the second half of Install: it writes the patch read from src to
+// the target.
+func (p Pending) Write(ctx context.Context, src io.Reader, progress func(string)) (Record, error) {
+	filename := filepath.Base(p.Target)
+
+	// Create target file
+	target, err := fsutil.Create(p.Target)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	progress(i18n.T("install.importing"))
+	h := sha256.New()
+	_, err = fsutil.Copy(ctx, target, io.TeeReader(src, h), nil)
+	if err == nil {
+		err = target.Sync()
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	if p.Verify {
+		progress(i18n.T("install.verifying"))
+		if err := fsutil.VerifyFile(ctx, p.Target, hash); err != nil {
+			return Record{}, err
+		}
+	}
+	return Record{
+		PatchID:     strings.TrimSuffix(filename, filepath.Ext(filename)),
+		PatchName:   filename,
+		Filename:    filename,
+		Hash:        hash,
+		InstalledAt: time.Now().UTC(),
+		Original:    p.Saved,
+		Added:       p.Saved == "",
+	}, nil
+}
+
+// Rollback undoes a failed or interrupted Write by putting the saved file
+// back, or removing the target when the patch added a new file.
+func (p Pending) Rollback() error {
+	if p.Saved == "" {
+		if err := os.Remove(fsutil.LongPath(p.Target)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return fsutil.CopyFile(p.Saved, p.Target)
+}
+
+// Uninstall removes the patch of rec from the game at gameDir, putting back
+// the file it replaced or removing the file it added.
+func Uninstall(gameDir string, rec Record) error {
+	target := filepath.Join(gamepath.ImagePackPath(gameDir), rec.Filename)
+	switch {
+	case rec.Original != "":
+		if err := fsutil.CopyFile(rec.Original, target); err != nil {
+			return fmt.Errorf("putting back the original %s: %w", rec.Filename, err)
+		}
+	case rec.Added:
+		if err := os.Remove(fsutil.LongPath(target)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	default:
+		return fmt.Errorf("%s: %w", rec.Filename, ErrOriginalUnknown)
+	}
+	return nil
+}
+
+// Reapply copies the cached file of rec back into the game at gameDir and,
+// with verify set, checks the copy against the hash of rec.
+func Reapply(gameDir string, rec Record, cache Cache, verify bool) error {
+	target := filepath.Join(gamepath.ImagePackPath(gameDir), rec.Filename)
+	if err := fsutil.CopyFile(cache.Path(rec.Hash, rec.Filename), target); err != nil {
+		return err
+	}
+	if verify {
+		return fsutil.VerifyFile(context.Background(), target, rec.Hash)
+	}
+	return nil
+}
+
+// Reverted is an installed patch whose file no longer matches the registry,
+// typically because a client update restored the original.
+type Reverted struct {
+	Patch   Record
+	Missing bool // the file is gone rather than replaced
+	Cached  bool // a copy is available in the local patch cache
+}
+
+// FindReverted compares the installed patches against the imagepack2 folder
+// of the game at gameDir and returns those that were replaced or removed.
+func FindReverted(gameDir string, installed []Record, cache Cache) ([]Reverted, error) {
+	imagepackPath := gamepath.ImagePackPath(gameDir)
+	var reverted []Reverted
+
+	for _, rec := range installed {
+		r := Reverted{Patch: rec, Cached: cache.Has(rec)}
+
+		hash, err := fsutil.HashFile(filepath.Join(imagepackPath, rec.Filename))
+		if os.IsNotExist(err) {
+			r.Missing = true
+			reverted = append(reverted, r)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hash != rec.Hash {
+			reverted = append(reverted, r)
+		}
+	}
+
+	return reverted, nil
+}
@@ -0,0 +1,394 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/schema"
+)
+
+func newGame(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ImagePacks2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func install(t *testing.T, game, filename, content string) Record {
+	t.Helper()
+	rec, err := Install(context.Background(), game, strings.NewReader(content), filename, func(string) {})
+	if err != nil {
+		t.Fatalf("Install(%s) error = %v", filename, err)
+	}
+	return rec
+}
+
+func TestInstallBacksUpReplacedFile(t *testing.T) {
+	game := newGame(t)
+	target := filepath.Join(game, "ImagePacks2", "sprite_interface.npk")
+	if err := os.WriteFile(target, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := install(t, game, "sprite_interface.npk", "patched")
+	if rec.PatchID != "sprite_interface" || rec.Hash == "" {
+		t.Errorf("record = %+v", rec)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "patched" {
+		t.Errorf("target = %q, want the patch written into the existing ImagePacks2", data)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(game, "backup_*", "sprite_interface.npk"))
+	if len(backups) != 1 {
+		t.Fatalf("found %d backups of the replaced file, want 1", len(backups))
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "original" {
+		t.Errorf("backup = %q, want the original file", data)
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	game := newGame(t)
+	replaced := filepath.Join(game, "ImagePacks2", "sprite_interface.npk")
+	if err := os.WriteFile(replaced, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range []Record{
+		install(t, game, "sprite_interface.npk", "patched"),
+		install(t, game, "added.npk", "new file"),
+	} {
+		if err := Uninstall(game, rec); err != nil {
+			t.Fatalf("Uninstall(%s) error = %v", rec.Filename, err)
+		}
+	}
+	if data, _ := os.ReadFile(replaced); string(data) != "original" {
+		t.Errorf("sprite_interface.npk = %q after Uninstall, want the original", data)
+	}
+	if _, err := os.Stat(filepath.Join(game, "ImagePacks2", "added.npk")); !os.IsNotExist(err) {
+		t.Errorf("added.npk still exists after Uninstall: %v", err)
+	}
+
+	err := Uninstall(game, Record{Filename: "old.npk"})
+	if !errors.Is(err, ErrOriginalUnknown) {
+		t.Errorf("Uninstall() of a record from an older version error = %v, want ErrOriginalUnknown", err)
+	}
+}
+
+func TestFindRevertedAndReapply(t *testing.T) {
+	game := newGame(t)
+	cache := Cache{Dir: filepath.Join(t.TempDir(), "patches")}
+
+	intact := install(t, game, "intact.npk", "intact patch")
+	overwritten := install(t, game, "overwritten.npk", "overwritten patch")
+	removed := install(t, game, "removed.npk", "removed patch")
+	for _, rec := range []Record{intact, overwritten} {
+		path := filepath.Join(game, "ImagePacks2", rec.Filename)
+		if err := cache.Store(path, rec.Hash); err != nil {
+			t.Fatalf("Store(%s) error = %v", rec.Filename, err)
+		}
+	}
+
+	// Simulate a client update
+	if err := os.WriteFile(filepath.Join(game, "ImagePacks2", "overwritten.npk"), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(game, "ImagePacks2", "removed.npk")); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := FindReverted(game, []Record{intact, overwritten, removed}, cache)
+	if err != nil {
+		t.Fatalf("FindReverted() error = %v", err)
+	}
+	if len(reverted) != 2 {
+		t.Fatalf("FindReverted() = %+v, want the overwritten and removed patches", reverted)
+	}
+	if r := reverted[0]; r.Patch.Filename != "overwritten.npk" || r.Missing || !r.Cached {
+		t.Errorf("reverted[0] = %+v, want overwritten.npk present and cached", r)
+	}
+	if r := reverted[1]; r.Patch.Filename != "removed.npk" || !r.Missing || r.Cached {
+		t.Errorf("reverted[1] = %+v, want removed.npk missing and not cached", r)
+	}
+
+	if err := Reapply(game, overwritten, cache, true); err != nil {
+		t.Fatalf("Reapply() error = %v", err)
+	}
+	reverted, err = FindReverted(game, []Record{intact, overwritten}, cache)
+	if err != nil {
+		t.Fatalf("FindReverted() error = %v", err)
+	}
+	if len(reverted) != 0 {
+		t.Errorf("FindReverted() after Reapply = %+v, want none", reverted)
+	}
+}
+
+func TestVerifiedWrites(t *testing.T) {
+	game := newGame(t)
+	pending, err := Prepare(game, "sprite.npk", func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending.Verify = true
+	rec, err := pending.Write(context.Background(), strings.NewReader("patch"), func(string) {})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if hash, err := fsutil.HashFile(pending.Target); err != nil || hash != rec.Hash {
+		t.Errorf("record hash = %s, file hash = %s, %v", rec.Hash, hash, err)
+	}
+
+	// A cached copy damaged since it was stored is caught after copying
+	cache := Cache{Dir: filepath.Join(t.TempDir(), "patches")}
+	if err := cache.Store(pending.Target, rec.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cache.Path(rec.Hash, rec.Filename), []byte("damaged"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var mismatch *fsutil.MismatchError
+	if err := Reapply(game, rec, cache, true); !errors.As(err, &mismatch) {
+		t.Errorf("Reapply() of a damaged copy = %v, want a *fsutil.MismatchError", err)
+	}
+	if err := Reapply(game, rec, cache, false); err != nil {
+		t.Errorf("Reapply() without verification = %v", err)
+	}
+}
+
+func TestRegistryAddReplacesSameFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.json")
+	registry := NewRegistry(path)
+	if err := registry.Add(Record{PatchID: "a", Filename: "Sprite.NPK", Hash: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Add(Record{PatchID: "b", Filename: "sprite.npk", Hash: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewRegistry(path)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if patches := reloaded.Patches(); len(patches) != 1 || patches[0].PatchID != "b" {
+		t.Errorf("Patches() = %+v, want only the second record", patches)
+	}
+}
+
+func TestRegistryLoadMergesMixedCase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.json")
+	data := `[
+		{"patchId": "old", "filename": "Sprite.NPK", "installedAt": "2024-01-01T00:00:00Z"},
+		{"patchId": "other", "filename": "b.npk", "installedAt": "2024-01-01T00:00:00Z"},
+		{"patchId": "new", "filename": "./sprite.npk", "installedAt": "2024-03-01T00:00:00Z"},
+		{"patchId": "older", "filename": "SPRITE.npk", "installedAt": "2023-06-01T00:00:00Z"}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(path)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var ids []string
+	for _, rec := range registry.Patches() {
+		ids = append(ids, rec.PatchID)
+	}
+	if got, want := strings.Join(ids, ","), "new,other"; got != want {
+		t.Errorf("Patches() = %s, want %s", got, want)
+	}
+	if err := registry.Remove(`.\SPRITE.NPK`); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if patches := registry.Patches(); len(patches) != 1 || patches[0].PatchID != "other" {
+		t.Errorf("Patches() = %+v, want only b.npk after removing sprite.npk", patches)
+	}
+}
+
+func TestRegistryRemove(t *testing.T) {
+	registry := NewRegistry(filepath.Join(t.TempDir(), "installed.json"))
+	for _, name := range []string{"a.npk", "b.npk"} {
+		if err := registry.Add(Record{PatchID: name, Filename: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := registry.Remove("A.NPK"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if patches := registry.Patches(); len(patches) != 1 || patches[0].Filename != "b.npk" {
+		t.Errorf("Patches() = %+v, want only b.npk", patches)
+	}
+}
+
+func TestRegistryOnChange(t *testing.T) {
+	registry := NewRegistry(filepath.Join(t.TempDir(), "installed.json"))
+	var seen []int
+	// Reading the registry from the callback must not deadlock
+	registry.OnChange = func() { seen = append(seen, len(registry.Patches())) }
+	if err := registry.Add(Record{PatchID: "a", Filename: "a.npk"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Remove("a.npk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 0, 0}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("OnChange saw %v patches, want %v", seen, want)
+	}
+}
+
+// TestConcurrentAdd records installs and history entries from several
+// goroutines. Run with -race.
+func TestConcurrentAdd(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewRegistry(filepath.Join(dir, "installed.json"))
+	history := NewHistory(filepath.Join(dir, "install_history.json"))
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("patch%d.npk", i)
+			if err := registry.Add(Record{PatchID: name, Filename: name}); err != nil {
+				t.Error(err)
+			}
+			if _, err := history.Add(name, name, "1.0", "Installed"); err != nil {
+				t.Error(err)
+			}
+			registry.Patches()
+			history.Entries()
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(registry.Patches()); got != n {
+		t.Errorf("registry has %d patches, want %d", got, n)
+	}
+	reloaded := NewHistory(filepath.Join(dir, "install_history.json"))
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := len(reloaded.Entries()); got != n {
+		t.Errorf("reloaded %d history entries, want %d", got, n)
+	}
+}
+
+func TestPendingRollback(t *testing.T) {
+	game := newGame(t)
+	existing := filepath.Join(game, "ImagePacks2", "existing.npk")
+	if err := os.WriteFile(existing, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"existing.npk", "new.npk"} {
+		pending, err := Prepare(game, name, func(string) {})
+		if err != nil {
+			t.Fatalf("Prepare(%s) error = %v", name, err)
+		}
+		// A crash half way through writing the patch
+		if err := os.WriteFile(pending.Target, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := pending.Rollback(); err != nil {
+			t.Fatalf("Rollback(%s) error = %v", name, err)
+		}
+	}
+
+	if data, _ := os.ReadFile(existing); string(data) != "original" {
+		t.Errorf("existing.npk = %q after rollback, want the original", data)
+	}
+	if _, err := os.Stat(filepath.Join(game, "ImagePacks2", "new.npk")); !os.IsNotExist(err) {
+		t.Errorf("new.npk still exists after rollback: %v", err)
+	}
+}
+
+// copyFixture copies testdata/name to a temporary folder as file, returning
+// its path there.
+func copyFixture(t *testing.T, name, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), file)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadEverySchema loads the history and registry fixtures of every
+// format they have had, checks that each reads the same and that a reload
+// of the upgraded file does too.
+func TestLoadEverySchema(t *testing.T) {
+	for v := 0; v <= historyFile.Version; v++ {
+		path := copyFixture(t, fmt.Sprintf("install_history.v%d.json", v), "install_history.json")
+		for _, pass := range []string{"fixture", "upgraded"} {
+			history := NewHistory(path)
+			if err := history.Load(); err != nil {
+				t.Fatalf("v%d %s: Load() error = %v", v, pass, err)
+			}
+			var got []string
+			for _, e := range history.Entries() {
+				got = append(got, e.PatchID+"@"+e.Version+" "+e.Timestamp.Format("2006-01-02"))
+			}
+			if want := "dark-ui@1.0 2024-01-02,big-font@2.1 2024-02-03"; strings.Join(got, ",") != want {
+				t.Errorf("v%d %s: Entries() = %v, want %s", v, pass, got, want)
+			}
+		}
+	}
+
+	for v := 0; v <= registryFile.Version; v++ {
+		path := copyFixture(t, fmt.Sprintf("installed.v%d.json", v), "installed.json")
+		for _, pass := range []string{"fixture", "upgraded"} {
+			registry := NewRegistry(path)
+			if err := registry.Load(); err != nil {
+				t.Fatalf("v%d %s: Load() error = %v", v, pass, err)
+			}
+			var got []string
+			for _, rec := range registry.Patches() {
+				got = append(got, fmt.Sprintf("%s:%s@%s original=%q added=%v", rec.Key(), rec.PatchID, rec.Version, rec.Original, rec.Added))
+			}
+			sort.Strings(got)
+			want := []string{
+				`sprite_font.npk:big-font@2.1 original="backup_20240203/sprite_font.npk" added=false`,
+				`sprite_interface.npk:dark-ui@1.1 original="" added=true`,
+			}
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("v%d %s: Patches() = %q, want %q", v, pass, got, want)
+			}
+		}
+	}
+}
+
+func TestRegistryRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installed.json")
+	doc := `{"schemaVersion": 99, "patches": [], "renamed": []}`
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := NewRegistry(path)
+	var newer *schema.NewerError
+	if err := registry.Load(); !errors.As(err, &newer) {
+		t.Fatalf("Load() error = %v, want a NewerError", err)
+	}
+	if err := registry.Add(Record{PatchID: "a", Filename: "a.npk"}); !errors.As(err, &newer) {
+		t.Errorf("Add() error = %v, want the NewerError again", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != doc {
+		t.Errorf("installed.json = %s, want it left as it was", data)
+	}
+}
@@ -0,0 +1,47 @@
+package install
+
+import (
+	"strings"
+	"unicode"
+)
+
+// reservedNames are the device names Windows does not allow as file names,
+// with or without an extension.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// NormalizeName returns filename as it can be written to imagepack2 and read
+// by the game: with the characters NTFS does not allow replaced by
+// underscores, without spaces around the name and its extension or dots at
+// its end, and prefixed with an underscore when it is a reserved device name.
+// With transliterate set, full-width letters, digits, punctuation and spaces
+// become their ASCII forms first. It returns "" when nothing is left.
+func NormalizeName(filename string, transliterate bool) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case transliterate && r >= '！' && r <= '～':
+			return r - '！' + '!'
+		case transliterate && r == '　':
+			return ' '
+		case r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, filename)
+
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		name = strings.TrimSpace(name[:i]) + "." + strings.TrimSpace(name[i+1:])
+	}
+	name = strings.TrimRightFunc(strings.TrimSpace(name), func(r rune) bool { return r == '.' || unicode.IsSpace(r) })
+	if name == "" {
+		return ""
+	}
+	stem, _, _ := strings.Cut(name, ".")
+	if reservedNames[strings.ToUpper(strings.TrimSpace(stem))] {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,26 @@
+package install
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	for _, tt := range []struct {
+		in, want      string
+		transliterate bool
+	}{
+		{"sprite_interface.npk", "sprite_interface.npk", true},
+		{"  dark ui .npk  ", "dark ui.npk", true},
+		{"effects?<new>.NPK", "effects__new_.NPK", true},
+		{"skill:fire\t.npk", "skill_fire_.npk", true},
+		{"ｓｐｒｉｔｅ＿ｆｏｎｔ．ｎｐｋ", "sprite_font.npk", true},
+		{"ｓｐｒｉｔｅ．ｎｐｋ", "ｓｐｒｉｔｅ．ｎｐｋ", false},
+		{"暗色　界面.npk", "暗色 界面.npk", true},
+		{"patch.npk...", "patch.npk", true},
+		{"con.npk", "_con.npk", true},
+		{"console.npk", "console.npk", true},
+		{" . ", "", true},
+	} {
+		if got := NormalizeName(tt.in, tt.transliterate); got != tt.want {
+			t.Errorf("NormalizeName(%q, %v) = %q, want %q", tt.in, tt.transliterate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package install
+
+import (
+	"strings"
+	"sync"
+)
+
+// PatchStats is how installing a patch went in the past.
+type PatchStats struct {
+	Installs    int          // successful installs, including reapplies
+	Failures    int          // failed installs
+	Last        HistoryEntry // the latest install or failure
+	LastFailure HistoryEntry // the latest failure, zero when there was none
+}
+
+// Stats tallies the installs in a history by patch ID. It is safe for
+// concurrent use.
+type Stats struct {
+	mu      sync.Mutex
+	patches map[string]PatchStats
+}
+
+// NewStats tallies entries, oldest first.
+func NewStats(entries []HistoryEntry) *Stats {
+	s := &Stats{patches: make(map[string]PatchStats)}
+	for _, e := range entries {
+		s.Add(e)
+	}
+	return s
+}
+
+// Add counts e, an entry added to the history after the others. Entries of
+// uninstalls and of files not in the catalogue are not counted.
+func (s *Stats) Add(e HistoryEntry) {
+	if e.PatchID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ps := s.patches[e.PatchID]
+	switch {
+	case strings.EqualFold(e.Status, StatusFailed):
+		ps.Failures++
+		ps.LastFailure = e
+	case strings.EqualFold(e.Status, "Installed"), strings.EqualFold(e.Status, "Reapplied"):
+		ps.Installs++
+	default:
+		return
+	}
+	ps.Last = e
+	s.patches[e.PatchID] = ps
+}
+
+// Get returns the tally of the patch with the given ID, and whether it was
+// ever installed or failed to.
+func (s *Stats) Get(patchID string) (PatchStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.patches[patchID]
+	return ps, ok
+}
@@ -0,0 +1,39 @@
+package install
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	history := NewHistory(filepath.Join(t.TempDir(), "install_history.json"))
+	add := func(e HistoryEntry, err error) HistoryEntry {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	add(history.Add("dark-ui", "Dark UI", "1.0", "Installed"))
+	add(history.AddFailure("dark-ui", "Dark UI", "1.1", errors.New("checksum mismatch")))
+	add(history.Add("dark-ui", "Dark UI", "1.1", "Uninstalled"))
+	add(history.Add("", "loose.npk", "", "Failed"))
+
+	stats := NewStats(history.Entries())
+	got, ok := stats.Get("dark-ui")
+	if !ok || got.Installs != 1 || got.Failures != 1 {
+		t.Fatalf("Get() = %+v, %v, want 1 install and 1 failure", got, ok)
+	}
+	if got.LastFailure.Error != "checksum mismatch" || got.LastFailure.Version != "1.1" || got.Last != got.LastFailure {
+		t.Errorf("Get() = %+v, want the failure of 1.1 last", got)
+	}
+
+	stats.Add(add(history.Add("dark-ui", "Dark UI", "1.1", "Reapplied")))
+	if got, _ := stats.Get("dark-ui"); got.Installs != 2 || got.Last.Status != "Reapplied" || got.LastFailure.Version != "1.1" {
+		t.Errorf("Get() after a reapply = %+v, want 2 installs and the failure kept", got)
+	}
+	if _, ok := stats.Get("big-font"); ok {
+		t.Error("Get() found a patch never installed")
+	}
+}
@@ -0,0 +1,202 @@
+// Package instance keeps a second copy of the tool from running against the
+// same data directory. The first instance holds a lock file recording its PID
+// and a local port; later instances ask it to come to the front, and to open
+// the files they were started with, instead.
+package instance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pingTimeout bounds how long a second instance waits for the first one.
+const pingTimeout = 2 * time.Second
+
+// An activate request is activateRequest alone, or openRequest followed by
+// one file path per line and an empty line.
+const (
+	activateRequest = "activate\n"
+	openRequest     = "open\n"
+	activateReply   = "ok\n"
+)
+
+// RunningError is returned by Acquire when a live process holds the lock.
+type RunningError struct {
+	PID int
+	// Activated is set when the running instance acknowledged the request
+	// to bring its window to the front.
+	Activated bool
+}
+
+func (e *RunningError) Error() string {
+	return fmt.Sprintf("another instance is already running (PID %d)", e.PID)
+}
+
+// Lock is the single-instance lock held by this process.
+type Lock struct {
+	path     string
+	listener net.Listener
+
+	mu         sync.Mutex
+	onActivate func(files []string)
+}
+
+// Acquire takes the lock file at path. A lock left by a process that is no
+// longer running is taken over. When a live process holds it, Acquire asks
+// that process to activate and open files, and returns a *RunningError.
+func Acquire(path string, files ...string) (*Lock, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// One retry covers removing a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n%d\n", os.Getpid(), port)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				listener.Close()
+				return nil, err
+			}
+			l := &Lock{path: path, listener: listener}
+			go l.serve()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			listener.Close()
+			return nil, err
+		}
+
+		pid, holderPort, err := readLock(path)
+		if err == nil && processAlive(pid) {
+			listener.Close()
+			return nil, &RunningError{PID: pid, Activated: activate(holderPort, files)}
+		}
+		// Unreadable, or its process is gone after a crash
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			listener.Close()
+			return nil, err
+		}
+	}
+	listener.Close()
+	return nil, fmt.Errorf("could not take the lock %s", path)
+}
+
+func readLock(path string) (pid, port int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("malformed lock file %s", path)
+	}
+	if pid, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if port, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	return pid, port, nil
+}
+
+// activate asks the instance listening on port to come to the front and
+// open files, and reports whether it did.
+func activate(port int, files []string) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), pingTimeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(pingTimeout))
+
+	request := activateRequest
+	if len(files) > 0 {
+		request = openRequest + strings.Join(files, "\n") + "\n\n"
+	}
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return false
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	return err == nil && reply == activateReply
+}
+
+// OnActivate sets the function run with the files to open, if any, when
+// another instance asks this one to come to the front. Until it is set such
+// requests are declined, so the CLI never claims to have shown a window.
+func (l *Lock) OnActivate(f func(files []string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onActivate = f
+}
+
+func (l *Lock) serve() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go l.handle(conn)
+	}
+}
+
+func (l *Lock) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(pingTimeout))
+
+	r := bufio.NewReader(conn)
+	request, err := r.ReadString('\n')
+	if err != nil {
+		return
+	}
+	var files []string
+	switch request {
+	case activateRequest:
+	case openRequest:
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if line = strings.TrimRight(line, "\r\n"); line == "" {
+				break
+			}
+			files = append(files, line)
+		}
+	default:
+		return
+	}
+	l.mu.Lock()
+	onActivate := l.onActivate
+	l.mu.Unlock()
+	if onActivate == nil {
+		return
+	}
+	onActivate(files)
+	conn.Write([]byte(activateReply))
+}
+
+// Release stops answering other instances and removes the lock file.
+func (l *Lock) Release() error {
+	l.listener.Close()
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSecondInstanceActivatesFirst(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnfpatch.lock")
+	first, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer first.Release()
+
+	// Without a window to show the request is declined
+	_, err = Acquire(path)
+	var running *RunningError
+	if !errors.As(err, &running) || running.PID != os.Getpid() || running.Activated {
+		t.Fatalf("second Acquire() error = %v, want a RunningError that did not activate", err)
+	}
+
+	activated := make(chan []string, 1)
+	first.OnActivate(func(files []string) { activated <- files })
+	_, err = Acquire(path)
+	if !errors.As(err, &running) || !running.Activated {
+		t.Fatalf("third Acquire() error = %v, want a RunningError that activated", err)
+	}
+	select {
+	case files := <-activated:
+		if len(files) != 0 {
+			t.Errorf("asked to open %q, want no files", files)
+		}
+	default:
+		t.Error("the first instance was not asked to activate")
+	}
+
+	files := []string{`C:\Users\me\Downloads\skills.npk`, `D:\patches\ui pack.npk`}
+	_, err = Acquire(path, files...)
+	if !errors.As(err, &running) || !running.Activated {
+		t.Fatalf("Acquire() with files error = %v, want a RunningError that activated", err)
+	}
+	select {
+	case got := <-activated:
+		if !reflect.DeepEqual(got, files) {
+			t.Errorf("asked to open %q, want %q", got, files)
+		}
+	default:
+		t.Error("the first instance was not asked to open the files")
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	again, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() after Release() error = %v", err)
+	}
+	again.Release()
+}
+
+func TestStaleLockIsTakenOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnfpatch.lock")
+	// A PID above any real pid_max, left behind by a crashed instance
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n1\n", 1<<30)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v, want the stale lock taken over", err)
+	}
+	defer l.Release()
+	if pid, _, err := readLock(path); err != nil || pid != os.Getpid() {
+		t.Errorf("lock holds PID %d (%v), want %d", pid, err, os.Getpid())
+	}
+}
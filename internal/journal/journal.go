@@ -0,0 +1,200 @@
+// Package journal records file operations before they modify the game so an
+// operation interrupted by a crash can be found, and rolled back or resumed,
+// on the next start. Every change to the journal is also appended to a trail
+// of JSON lines, which keeps finished operations as well.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+)
+
+type Operation string
+
+const (
+	Install Operation = "install"
+	Backup  Operation = "backup"
+	Restore Operation = "restore"
+	Reapply Operation = "reapply"
+)
+
+// State is the last step an operation reached before it was interrupted.
+type State string
+
+const (
+	Saved   State = "saved"   // install: the file Target replaces is copied to Saved
+	Writing State = "writing" // install: Target is being written
+	Written State = "written" // install: Target is complete, the registry not yet updated
+	Copying State = "copying" // backup, restore: files are being copied
+)
+
+// Entry describes an operation in progress. Only the fields relevant to the
+// operation are set.
+type Entry struct {
+	ID          string    `json:"id"`
+	Operation   Operation `json:"operation"`
+	Started     time.Time `json:"started"`
+	GameDir     string    `json:"gameDir"`
+	Description string    `json:"description"`
+	Source      string    `json:"source,omitempty"`     // install: the patch file being installed
+	Target      string    `json:"target,omitempty"`     // install: the file being written
+	Saved       string    `json:"saved,omitempty"`      // install: copy of the file Target replaces
+	BackupID    string    `json:"backupId,omitempty"`   // restore: the backup; reapply: the backup taken first
+	BackupType  string    `json:"backupType,omitempty"` // backup: auto or manual
+	State       State     `json:"state,omitempty"`
+	// Deferred is set on operations handed over to an elevated instance,
+	// which resumes them without asking.
+	Deferred bool `json:"deferred,omitempty"`
+}
+
+// Journal is the list of operations in progress, kept in a JSON file. It is
+// safe for concurrent use.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	trail   string
+	entries []Entry
+	seq     int
+}
+
+// Open loads the journal at path. Entries found there belong to operations
+// that never finished. The trail is kept next to it, see TrailPath.
+func Open(path string) (*Journal, error) {
+	j := &Journal{path: path, trail: strings.TrimSuffix(path, filepath.Ext(path)) + ".log"}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return j, err
+	}
+	if err := json.Unmarshal(data, &j.entries); err != nil {
+		return j, fmt.Errorf("reading %s: %v", path, err)
+	}
+	return j, nil
+}
+
+// Begin records e before the operation touches any file and returns its ID.
+func (j *Journal) Begin(e Entry) (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.seq++
+	e.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), j.seq)
+	e.Started = time.Now().UTC()
+	j.entries = append(j.entries, e)
+	if err := j.save(); err != nil {
+		j.entries = j.entries[:len(j.entries)-1]
+		return "", err
+	}
+	j.record("begin", e)
+	return e.ID, nil
+}
+
+// Step records that the operation with the given ID reached state, before
+// the step that state names is taken.
+func (j *Journal) Step(id string, state State) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := range j.entries {
+		if j.entries[i].ID == id {
+			previous := j.entries[i].State
+			j.entries[i].State = state
+			if err := j.save(); err != nil {
+				j.entries[i].State = previous
+				return err
+			}
+			j.record("step", j.entries[i])
+			return nil
+		}
+	}
+	return nil
+}
+
+// End removes the entry with the given ID once its operation has finished or
+// been dealt with.
+func (j *Journal) End(id string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i, e := range j.entries {
+		if e.ID == id {
+			j.entries = append(j.entries[:i:i], j.entries[i+1:]...)
+			j.record("end", e)
+			return j.save()
+		}
+	}
+	return nil
+}
+
+// TrailPath returns the file the trail of journal changes is appended to.
+func (j *Journal) TrailPath() string {
+	return j.trail
+}
+
+// Entries returns a copy of the recorded entries.
+func (j *Journal) Entries() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Entry(nil), j.entries...)
+}
+
+// maxTrailSize is the size beyond which the trail is moved to TrailPath()
+// + ".1", replacing the previous one, and started afresh.
+const maxTrailSize = 1 << 20
+
+// trailEvent is a line of the trail.
+type trailEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"` // begin, step or end
+	Entry
+}
+
+// record appends event for e to the trail. The trail only helps to tell
+// what happened, so failing to write it does not fail the operation. The
+// caller must hold j.mu.
+func (j *Journal) record(event string, e Entry) {
+	line, err := json.Marshal(trailEvent{Time: time.Now(), Event: event, Entry: e})
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(j.trail); err == nil && info.Size()+int64(len(line)) >= maxTrailSize {
+		os.Rename(j.trail, j.trail+".1")
+	}
+	if err := os.MkdirAll(filepath.Dir(j.trail), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(j.trail, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// save writes the journal, removing the file when it is empty. The caller
+// must hold j.mu.
+func (j *Journal) save() error {
+	if len(j.entries) == 0 {
+		if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(j.entries, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(j.path, data, 0644)
+}
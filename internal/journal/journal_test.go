@@ -0,0 +1,101 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterruptedEntrySurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	finished, err := j.Begin(Entry{Operation: Backup, Description: "Manual backup"})
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if _, err := j.Begin(Entry{Operation: Install, Target: "sprite.npk", Saved: "backup/sprite.npk"}); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := j.End(finished); err != nil {
+		t.Fatalf("End() error = %v", err)
+	}
+
+	// The install never ended, as after a crash
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	entries := reopened.Entries()
+	if len(entries) != 1 || entries[0].Operation != Install || entries[0].Saved != "backup/sprite.npk" {
+		t.Fatalf("Entries() = %+v, want the interrupted install", entries)
+	}
+
+	if err := reopened.End(entries[0].ID); err != nil {
+		t.Fatalf("End() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("journal file still exists with no entries: %v", err)
+	}
+}
+
+func TestOpenMissing(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal.json"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(j.Entries()) != 0 {
+		t.Errorf("Entries() = %+v, want none", j.Entries())
+	}
+}
+
+func TestStepAndTrail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	id, err := j.Begin(Entry{Operation: Install, Target: "sprite.npk", State: Saved})
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := j.Step(id, Writing); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if entries := reopened.Entries(); len(entries) != 1 || entries[0].State != Writing {
+		t.Fatalf("Entries() = %+v, want the install in state %q", entries, Writing)
+	}
+	if err := j.End(id); err != nil {
+		t.Fatalf("End() error = %v", err)
+	}
+
+	data, err := os.ReadFile(j.TrailPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e trailEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("trail line %q: %v", line, err)
+		}
+		if e.ID != id || e.Target != "sprite.npk" {
+			t.Errorf("trail event = %+v, want the install", e)
+		}
+		events = append(events, e.Event+":"+string(e.State))
+	}
+	if want := []string{"begin:saved", "step:writing", "end:writing"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("trail = %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,129 @@
+// Package notes keeps the user's own notes and tags on catalogue patches,
+// keyed by patch ID. They stay on this computer and are never written into
+// the shared patches.json.
+package notes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// Note is what the user wrote about a patch.
+type Note struct {
+	Text string   `json:"text,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Empty reports whether there is nothing worth keeping in n.
+func (n Note) Empty() bool {
+	return strings.TrimSpace(n.Text) == "" && len(n.Tags) == 0
+}
+
+// Texts returns the text and tags of n, for searching.
+func (n Note) Texts() []string {
+	if n.Text == "" {
+		return n.Tags
+	}
+	return append([]string{n.Text}, n.Tags...)
+}
+
+// ParseTags splits a comma-separated list of tags, dropping empty and
+// repeated ones. Tags differing only in case are the same tag.
+func ParseTags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' }) {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// Store is the notes of all patches, kept in a JSON file. It is safe for
+// concurrent use.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	notes map[string]Note
+}
+
+// NewStore returns an empty store kept at path.
+func NewStore(path string) *Store {
+	return &Store{path: path, notes: make(map[string]Note)}
+}
+
+func (s *Store) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notes = make(map[string]Note)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.notes)
+}
+
+// Get returns the note on the patch with the given ID.
+func (s *Store) Get(patchID string) Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notes[patchID]
+}
+
+// Set replaces the note on the patch with the given ID. An empty note
+// removes it.
+func (s *Store) Set(patchID string, n Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n.Text = strings.TrimSpace(n.Text)
+	if n.Empty() {
+		delete(s.notes, patchID)
+	} else {
+		s.notes[patchID] = n
+	}
+	return s.save()
+}
+
+// Tags returns every tag in use, sorted, for suggestions.
+func (s *Store) Tags() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tags []string
+	seen := make(map[string]bool)
+	for _, n := range s.notes {
+		for _, tag := range n.Tags {
+			if !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return strings.ToLower(tags[i]) < strings.ToLower(tags[j]) })
+	return tags
+}
+
+// save writes the store. The caller must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.notes, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(s.path, data, 0644)
+}
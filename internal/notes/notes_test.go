@@ -0,0 +1,54 @@
+package notes
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.json")
+	s := NewStore(path)
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+	if err := s.Set("dark-ui", Note{Text: "  clashes with the weapon glow patch\n", Tags: []string{"ui", "Favourite"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("glow", Note{Tags: []string{"favourite"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := NewStore(path)
+	if err := reopened.Load(); err != nil {
+		t.Fatal(err)
+	}
+	want := Note{Text: "clashes with the weapon glow patch", Tags: []string{"ui", "Favourite"}}
+	if got := reopened.Get("dark-ui"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+	if got, want := reopened.Tags(), []string{"Favourite", "ui"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %q, want %q", got, want)
+	}
+
+	// Clearing a note forgets the patch
+	if err := reopened.Set("glow", Note{Text: "  "}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Get("glow"); !got.Empty() {
+		t.Errorf("cleared note = %+v", got)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags(" ui, Favourite,,ui ，broken , favourite")
+	if want := []string{"ui", "Favourite", "broken"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTags() = %q, want %q", got, want)
+	}
+	if got := ParseTags(" , "); got != nil {
+		t.Errorf("ParseTags() of nothing = %q", got)
+	}
+}
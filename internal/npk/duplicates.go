@@ -0,0 +1,142 @@
+package npk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// DuplicateKind tells how the files of a Duplicates group match.
+type DuplicateKind int
+
+const (
+	// Identical files have the same content.
+	Identical DuplicateKind = iota
+	// SameIMGs files differ but hold the same IMG paths, like two versions
+	// of one patch.
+	SameIMGs
+)
+
+// DuplicateFile is a file of a Duplicates group.
+type DuplicateFile struct {
+	Path string
+	Size int64
+	Hash string
+}
+
+// Duplicates is a group of NPK files with the same content, sorted by name.
+type Duplicates struct {
+	Kind  DuplicateKind
+	Files []DuplicateFile
+}
+
+// Wasted returns the bytes that keeping only the largest file would free.
+func (d Duplicates) Wasted() int64 {
+	var total, largest int64
+	for _, f := range d.Files {
+		total += f.Size
+		if f.Size > largest {
+			largest = f.Size
+		}
+	}
+	return total - largest
+}
+
+// FindDuplicates groups the NPK files in dir that are identical, and the
+// distinct files that hold the same set of IMG paths. Files are hashed with
+// hash and their entry tables read through cache, so a second scan only
+// reads what changed. Groups are sorted by Wasted, largest first. ctx
+// cancels the scan between files; progress may be nil.
+func FindDuplicates(ctx context.Context, dir string, hash func(path string) (string, error), cache *Cache, progress func(done, total int)) ([]Duplicates, error) {
+	entries, err := os.ReadDir(fsutil.LongPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	var files []DuplicateFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.EqualFold(filepath.Ext(entry.Name()), ".npk") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, DuplicateFile{Path: filepath.Join(dir, entry.Name()), Size: info.Size()})
+	}
+
+	byHash := make(map[string][]DuplicateFile)
+	var hashes []string
+	for i := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		h, err := hash(files[i].Path)
+		if err != nil {
+			return nil, err
+		}
+		files[i].Hash = h
+		if byHash[h] == nil {
+			hashes = append(hashes, h)
+		}
+		byHash[h] = append(byHash[h], files[i])
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+
+	var groups []Duplicates
+	byIMGs := make(map[string][]DuplicateFile)
+	var keys []string
+	for _, h := range hashes {
+		same := byHash[h]
+		if len(same) > 1 {
+			groups = append(groups, Duplicates{Kind: Identical, Files: same})
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Files that are not NPKs or hold nothing cannot be compared
+		a, err := cache.Open(same[0].Path, h)
+		if err != nil || len(a.Entries) == 0 {
+			continue
+		}
+		key := imgSet(a)
+		if byIMGs[key] == nil {
+			keys = append(keys, key)
+		}
+		byIMGs[key] = append(byIMGs[key], same[0])
+	}
+	for _, key := range keys {
+		if same := byIMGs[key]; len(same) > 1 {
+			groups = append(groups, Duplicates{Kind: SameIMGs, Files: same})
+		}
+	}
+
+	for _, g := range groups {
+		sort.Slice(g.Files, func(i, j int) bool {
+			return strings.ToLower(g.Files[i].Path) < strings.ToLower(g.Files[j].Path)
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Wasted() > groups[j].Wasted() })
+	return groups, nil
+}
+
+// imgSet returns the IMG paths of a as one string, compared
+// case-insensitively as the game does.
+func imgSet(a *Archive) string {
+	names := make([]string, 0, len(a.Entries))
+	seen := make(map[string]bool, len(a.Entries))
+	for _, e := range a.Entries {
+		name := strings.ToLower(e.Name)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, "\n")
+}
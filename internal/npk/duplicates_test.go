@@ -0,0 +1,61 @@
+package npk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dnf_patch/internal/fsutil"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	hud := build(map[string]string{"sprite/interface/hud.img": "HUD1"}, "sprite/interface/hud.img")
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hud.npk", hud)
+	write("hud - copy.NPK", hud)
+	write("hud_v2.npk", build(map[string]string{"Sprite/Interface/HUD.img": "HUD2"}, "Sprite/Interface/HUD.img"))
+	write("coat.npk", build(nil, "sprite/character/coat.img"))
+	write("notes.txt", hud)
+
+	hashes := fsutil.NewHashCache()
+	groups, err := FindDuplicates(context.Background(), dir, hashes.Hash, NewCache(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	var kinds []DuplicateKind
+	for _, g := range groups {
+		var names []string
+		for _, f := range g.Files {
+			names = append(names, filepath.Base(f.Path))
+		}
+		got = append(got, names)
+		kinds = append(kinds, g.Kind)
+	}
+	want := [][]string{{"hud - copy.NPK", "hud.npk"}, {"hud - copy.NPK", "hud_v2.npk"}}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(kinds, []DuplicateKind{Identical, SameIMGs}) {
+		t.Errorf("FindDuplicates() = %v %v, want %v", got, kinds, want)
+	}
+	if w := groups[0].Wasted(); w != int64(len(hud)) {
+		t.Errorf("Wasted() = %d, want %d", w, len(hud))
+	}
+}
+
+func TestFindDuplicatesCancelled(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.npk"), build(nil, "a.img"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FindDuplicates(ctx, dir, fsutil.HashFile, NewCache(), nil); err != context.Canceled {
+		t.Errorf("FindDuplicates() error = %v, want %v", err, context.Canceled)
+	}
+}
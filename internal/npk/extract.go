@@ -0,0 +1,78 @@
+package npk
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// Extract copies the IMG data of entries from the NPK file at path into
+// loose files under dest, keeping their sprite/... folders. Data is streamed
+// from the NPK, checked against the sizes in its header, and entries whose
+// path would leave dest are refused. progress, if set, is called after each
+// entry.
+func Extract(path string, entries []Entry, dest string, progress func(done, total int)) error {
+	f, err := os.Open(fsutil.LongPath(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		target, err := extractPath(dest, e.Name)
+		if err != nil {
+			return err
+		}
+		if int64(e.Offset)+int64(e.Size) > info.Size() {
+			return fmt.Errorf("%s: data outside the file", e.Name)
+		}
+		if err := extractEntry(f, e, target); err != nil {
+			return fmt.Errorf("extracting %s: %w", e.Name, err)
+		}
+		if progress != nil {
+			progress(i+1, len(entries))
+		}
+	}
+	return nil
+}
+
+// extractPath returns where the entry name is extracted under dest, refusing
+// names that escape it.
+func extractPath(dest, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) ||
+		filepath.IsAbs(filepath.FromSlash(name)) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%s: path leaves the destination folder", name)
+	}
+	return target, nil
+}
+
+func extractEntry(src io.ReaderAt, e Entry, target string) error {
+	if err := os.MkdirAll(fsutil.LongPath(filepath.Dir(target)), 0755); err != nil {
+		return err
+	}
+	out, err := fsutil.Create(target)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.NewSectionReader(src, int64(e.Offset), int64(e.Size)))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n != int64(e.Size) {
+		err = fmt.Errorf("wrote %d bytes, the header says %d", n, e.Size)
+	}
+	if err != nil {
+		os.Remove(fsutil.LongPath(target))
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package npk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeNPK(t *testing.T, files map[string]string, names ...string) (string, *Archive) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "patch.npk")
+	if err := os.WriteFile(path, build(files, names...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, a
+}
+
+func TestExtract(t *testing.T) {
+	path, a := writeNPK(t, map[string]string{
+		"sprite/character/swordman/coat.img": "coat IMG",
+		"sprite/interface/hud.img":           "hud IMG",
+	}, "sprite/character/swordman/coat.img", "sprite/interface/hud.img")
+	dest := t.TempDir()
+
+	var calls int
+	if err := Extract(path, a.Entries, dest, func(done, total int) { calls++ }); err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("progress called %d times, want 2", calls)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "sprite", "character", "swordman", "coat.img"))
+	if err != nil || string(data) != "coat IMG" {
+		t.Errorf("extracted coat.img = %q, %v", data, err)
+	}
+}
+
+func TestExtractRefusesEscapingPaths(t *testing.T) {
+	path, a := writeNPK(t, map[string]string{"../../evil.img": "evil"}, "../../evil.img")
+	dest := filepath.Join(t.TempDir(), "out")
+
+	err := Extract(path, a.Entries, dest, nil)
+	if err == nil || !strings.Contains(err.Error(), "leaves the destination") {
+		t.Fatalf("Extract() error = %v, want the path refused", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "..", "evil.img")); !os.IsNotExist(err) {
+		t.Errorf("escaping entry was written: %v", err)
+	}
+}
+
+func TestExtractChecksSizes(t *testing.T) {
+	path, a := writeNPK(t, map[string]string{"sprite/a.img": "IMG"}, "sprite/a.img")
+	entry := a.Entries[0]
+	entry.Size += 100 // larger than the file
+
+	if err := Extract(path, []Entry{entry}, t.TempDir(), nil); err == nil {
+		t.Error("Extract() of an entry past the end of the file succeeded")
+	}
+}
@@ -0,0 +1,304 @@
+package npk
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// IMG files hold the frames of a sprite. Version 2, the common one, starts
+// with the magic "Neople Img File\0", the size of the frame index, a reserved
+// field, the version and the frame count. Each index record gives the pixel
+// format, compression and size of a frame, or links to an earlier frame. The
+// frame data follows the index in order.
+const (
+	imgMagic      = "Neople Img File\x00"
+	imgHeaderSize = len(imgMagic) + 16
+
+	// Limits guarding against corrupted headers
+	maxFrameSide   = 4096
+	maxFrameLength = 64 << 20
+)
+
+// Pixel formats of IMG frames.
+const (
+	formatARGB1555 = 0x0E
+	formatARGB4444 = 0x0F
+	formatARGB8888 = 0x10
+	formatLink     = 0x11
+	formatDXT1     = 0x12
+	formatDXT3     = 0x13
+	formatDXT5     = 0x14
+)
+
+const (
+	compressionNone = 0x05
+	compressionZlib = 0x06
+)
+
+// ErrUnsupportedIMG is returned for IMG files this package cannot decode.
+var ErrUnsupportedIMG = errors.New("unsupported IMG format")
+
+type frameInfo struct {
+	format, compression int32
+	width, height       int32
+	length              int32
+	link                int32
+}
+
+// DecodeIMG decodes up to max frames of the IMG file in data.
+func DecodeIMG(data []byte, max int) ([]image.Image, error) {
+	if len(data) < imgHeaderSize || string(data[:len(imgMagic)]) != imgMagic {
+		return nil, fmt.Errorf("%w: not a NImgF file", ErrUnsupportedIMG)
+	}
+	header := data[len(imgMagic):imgHeaderSize]
+	indexSize := int64(binary.LittleEndian.Uint32(header[0:4]))
+	version := binary.LittleEndian.Uint32(header[8:12])
+	count := int64(binary.LittleEndian.Uint32(header[12:16]))
+	if version != 2 {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedIMG, version)
+	}
+	if int64(imgHeaderSize)+indexSize > int64(len(data)) || count > indexSize/8 {
+		return nil, errors.New("truncated IMG index")
+	}
+
+	index := bytes.NewReader(data[imgHeaderSize : int64(imgHeaderSize)+indexSize])
+	frames := make([]frameInfo, 0, count)
+	for i := int64(0); i < count; i++ {
+		var f frameInfo
+		if err := binary.Read(index, binary.LittleEndian, &f.format); err != nil {
+			return nil, errors.New("truncated IMG index")
+		}
+		if f.format == formatLink {
+			if err := binary.Read(index, binary.LittleEndian, &f.link); err != nil {
+				return nil, errors.New("truncated IMG index")
+			}
+			frames = append(frames, f)
+			continue
+		}
+		// compression, width, height, length, then key point and canvas size
+		var fields [8]int32
+		if err := binary.Read(index, binary.LittleEndian, &fields); err != nil {
+			return nil, errors.New("truncated IMG index")
+		}
+		f.compression, f.width, f.height, f.length = fields[0], fields[1], fields[2], fields[3]
+		frames = append(frames, f)
+	}
+
+	var images []image.Image
+	offset := int64(imgHeaderSize) + indexSize
+	for i, f := range frames {
+		if len(images) == max {
+			break
+		}
+		if f.format == formatLink {
+			if f.link < 0 || int(f.link) >= i || int(f.link) >= len(images) {
+				return images, fmt.Errorf("frame %d links to missing frame %d", i, f.link)
+			}
+			images = append(images, images[f.link])
+			continue
+		}
+		if f.length < 0 || f.length > maxFrameLength || offset+int64(f.length) > int64(len(data)) {
+			return images, fmt.Errorf("frame %d: truncated data", i)
+		}
+		img, err := decodeFrame(f, data[offset:offset+int64(f.length)])
+		if err != nil {
+			return images, fmt.Errorf("frame %d: %w", i, err)
+		}
+		images = append(images, img)
+		offset += int64(f.length)
+	}
+	return images, nil
+}
+
+func decodeFrame(f frameInfo, data []byte) (image.Image, error) {
+	if f.width <= 0 || f.height <= 0 || f.width > maxFrameSide || f.height > maxFrameSide {
+		return nil, fmt.Errorf("invalid size %dx%d", f.width, f.height)
+	}
+	w, h := int(f.width), int(f.height)
+
+	var size int
+	switch f.format {
+	case formatARGB1555, formatARGB4444:
+		size = w * h * 2
+	case formatARGB8888:
+		size = w * h * 4
+	case formatDXT1:
+		size = blocks(w) * blocks(h) * 8
+	case formatDXT3, formatDXT5:
+		size = blocks(w) * blocks(h) * 16
+	default:
+		return nil, fmt.Errorf("%w: pixel format %#x", ErrUnsupportedIMG, f.format)
+	}
+
+	switch f.compression {
+	case compressionNone:
+	case compressionZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		pixels := make([]byte, size)
+		_, err = io.ReadFull(zr, pixels)
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decompressing: %v", err)
+		}
+		data = pixels
+	default:
+		return nil, fmt.Errorf("%w: compression %#x", ErrUnsupportedIMG, f.compression)
+	}
+	if len(data) < size {
+		return nil, errors.New("truncated pixels")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	switch f.format {
+	case formatARGB1555:
+		for i := 0; i < w*h; i++ {
+			v := binary.LittleEndian.Uint16(data[i*2:])
+			img.Pix[i*4+0] = expand5(v >> 10)
+			img.Pix[i*4+1] = expand5(v >> 5)
+			img.Pix[i*4+2] = expand5(v)
+			img.Pix[i*4+3] = uint8(v>>15) * 0xFF
+		}
+	case formatARGB4444:
+		for i := 0; i < w*h; i++ {
+			v := binary.LittleEndian.Uint16(data[i*2:])
+			img.Pix[i*4+0] = uint8(v>>8&0xF) * 0x11
+			img.Pix[i*4+1] = uint8(v>>4&0xF) * 0x11
+			img.Pix[i*4+2] = uint8(v&0xF) * 0x11
+			img.Pix[i*4+3] = uint8(v>>12) * 0x11
+		}
+	case formatARGB8888:
+		// Stored as BGRA
+		for i := 0; i < w*h; i++ {
+			img.Pix[i*4+0] = data[i*4+2]
+			img.Pix[i*4+1] = data[i*4+1]
+			img.Pix[i*4+2] = data[i*4+0]
+			img.Pix[i*4+3] = data[i*4+3]
+		}
+	default:
+		decodeDXT(img, int(f.format), data)
+	}
+	return img, nil
+}
+
+func expand5(v uint16) uint8 {
+	v &= 0x1F
+	return uint8(v<<3 | v>>2)
+}
+
+func blocks(n int) int {
+	return (n + 3) / 4
+}
+
+// decodeDXT decodes DXT1, DXT3 or DXT5 compressed pixels into img.
+func decodeDXT(img *image.NRGBA, format int, data []byte) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	blockSize := 16
+	if format == formatDXT1 {
+		blockSize = 8
+	}
+
+	for by := 0; by < blocks(h); by++ {
+		for bx := 0; bx < blocks(w); bx++ {
+			block := data[(by*blocks(w)+bx)*blockSize:]
+			var alpha [16]uint8
+			for i := range alpha {
+				alpha[i] = 0xFF
+			}
+			switch format {
+			case formatDXT3:
+				for i := range alpha {
+					alpha[i] = (block[i/2] >> (4 * uint(i%2)) & 0xF) * 0x11
+				}
+				block = block[8:]
+			case formatDXT5:
+				alpha = dxt5Alpha(block[:8])
+				block = block[8:]
+			}
+
+			colors := dxtColors(block[:4], format == formatDXT1)
+			indices := binary.LittleEndian.Uint32(block[4:8])
+			for i := 0; i < 16; i++ {
+				x, y := bx*4+i%4, by*4+i/4
+				if x >= w || y >= h {
+					continue
+				}
+				c := colors[indices>>(2*uint(i))&3]
+				if c.A != 0 {
+					c.A = alpha[i]
+				}
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// dxtColors returns the four colours of a DXT colour block. DXT1 blocks
+// whose first colour is not greater than the second have three colours and
+// transparency.
+func dxtColors(block []byte, dxt1 bool) [4]color.NRGBA {
+	c0 := binary.LittleEndian.Uint16(block[0:2])
+	c1 := binary.LittleEndian.Uint16(block[2:4])
+	var colors [4]color.NRGBA
+	colors[0], colors[1] = rgb565(c0), rgb565(c1)
+	mix := func(a, b uint8, wa, wb, d int) uint8 {
+		return uint8((int(a)*wa + int(b)*wb) / d)
+	}
+	if c0 > c1 || !dxt1 {
+		colors[2] = color.NRGBA{
+			mix(colors[0].R, colors[1].R, 2, 1, 3),
+			mix(colors[0].G, colors[1].G, 2, 1, 3),
+			mix(colors[0].B, colors[1].B, 2, 1, 3), 0xFF}
+		colors[3] = color.NRGBA{
+			mix(colors[0].R, colors[1].R, 1, 2, 3),
+			mix(colors[0].G, colors[1].G, 1, 2, 3),
+			mix(colors[0].B, colors[1].B, 1, 2, 3), 0xFF}
+	} else {
+		colors[2] = color.NRGBA{
+			mix(colors[0].R, colors[1].R, 1, 1, 2),
+			mix(colors[0].G, colors[1].G, 1, 1, 2),
+			mix(colors[0].B, colors[1].B, 1, 1, 2), 0xFF}
+		colors[3] = color.NRGBA{}
+	}
+	return colors
+}
+
+func rgb565(v uint16) color.NRGBA {
+	r, g, b := v>>11&0x1F, v>>5&0x3F, v&0x1F
+	return color.NRGBA{uint8(r<<3 | r>>2), uint8(g<<2 | g>>4), uint8(b<<3 | b>>2), 0xFF}
+}
+
+// dxt5Alpha decodes the interpolated alpha values of a DXT5 block.
+func dxt5Alpha(block []byte) [16]uint8 {
+	a0, a1 := int(block[0]), int(block[1])
+	var table [8]uint8
+	table[0], table[1] = uint8(a0), uint8(a1)
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			table[i+1] = uint8(((7-i)*a0 + i*a1) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			table[i+1] = uint8(((5-i)*a0 + i*a1) / 5)
+		}
+		table[6], table[7] = 0, 0xFF
+	}
+
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(block[2+i]) << (8 * uint(i))
+	}
+	var alpha [16]uint8
+	for i := range alpha {
+		alpha[i] = table[bits>>(3*uint(i))&7]
+	}
+	return alpha
+}
@@ -0,0 +1,102 @@
+package npk
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image/color"
+	"testing"
+)
+
+// imgFrame is a frame to encode with buildIMG.
+type imgFrame struct {
+	format, compression int32
+	width, height       int32
+	pixels              []byte
+	link                int32
+}
+
+func buildIMG(version uint32, frames ...imgFrame) []byte {
+	var index, data bytes.Buffer
+	for _, f := range frames {
+		binary.Write(&index, binary.LittleEndian, f.format)
+		if f.format == formatLink {
+			binary.Write(&index, binary.LittleEndian, f.link)
+			continue
+		}
+		pixels := f.pixels
+		if f.compression == compressionZlib {
+			var compressed bytes.Buffer
+			zw := zlib.NewWriter(&compressed)
+			zw.Write(pixels)
+			zw.Close()
+			pixels = compressed.Bytes()
+		}
+		binary.Write(&index, binary.LittleEndian, [8]int32{
+			f.compression, f.width, f.height, int32(len(pixels)), 0, 0, f.width, f.height})
+		data.Write(pixels)
+	}
+
+	var img bytes.Buffer
+	img.WriteString(imgMagic)
+	binary.Write(&img, binary.LittleEndian, [4]uint32{uint32(index.Len()), 0, version, uint32(len(frames))})
+	img.Write(index.Bytes())
+	img.Write(data.Bytes())
+	return img.Bytes()
+}
+
+func TestDecodeIMG(t *testing.T) {
+	data := buildIMG(2,
+		// 2x1 BGRA: opaque red, half transparent blue
+		imgFrame{format: formatARGB8888, compression: compressionNone, width: 2, height: 1,
+			pixels: []byte{0, 0, 0xFF, 0xFF, 0xFF, 0, 0, 0x80}},
+		// 1x1 ARGB4444 green, compressed
+		imgFrame{format: formatARGB4444, compression: compressionZlib, width: 1, height: 1,
+			pixels: []byte{0xF0, 0xF0}},
+		imgFrame{format: formatLink, link: 0},
+		// 4x4 DXT1 block of solid white
+		imgFrame{format: formatDXT1, compression: compressionNone, width: 4, height: 4,
+			pixels: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}},
+	)
+
+	images, err := DecodeIMG(data, 10)
+	if err != nil {
+		t.Fatalf("DecodeIMG() error = %v", err)
+	}
+	if len(images) != 4 {
+		t.Fatalf("decoded %d frames, want 4", len(images))
+	}
+	checks := []struct {
+		frame, x, y int
+		want        color.NRGBA
+	}{
+		{0, 0, 0, color.NRGBA{0xFF, 0, 0, 0xFF}},
+		{0, 1, 0, color.NRGBA{0, 0, 0xFF, 0x80}},
+		{1, 0, 0, color.NRGBA{0, 0xFF, 0, 0xFF}},
+		{2, 0, 0, color.NRGBA{0xFF, 0, 0, 0xFF}},
+		{3, 3, 3, color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, c := range checks {
+		if got := color.NRGBAModel.Convert(images[c.frame].At(c.x, c.y)); got != c.want {
+			t.Errorf("frame %d at (%d,%d) = %v, want %v", c.frame, c.x, c.y, got, c.want)
+		}
+	}
+
+	if images, err := DecodeIMG(data, 1); err != nil || len(images) != 1 {
+		t.Errorf("DecodeIMG(max 1) = %d frames, %v", len(images), err)
+	}
+}
+
+func TestDecodeIMGUnsupported(t *testing.T) {
+	frame := imgFrame{format: formatARGB8888, compression: compressionNone, width: 1, height: 1, pixels: make([]byte, 4)}
+	if _, err := DecodeIMG(buildIMG(5, frame), 1); !errors.Is(err, ErrUnsupportedIMG) {
+		t.Errorf("DecodeIMG(version 5) error = %v, want ErrUnsupportedIMG", err)
+	}
+
+	// A frame claiming to be far larger than its data
+	huge := buildIMG(2, imgFrame{format: formatARGB8888, compression: compressionNone, width: 4000, height: 4000, pixels: make([]byte, 4)})
+	if _, err := DecodeIMG(huge, 1); err == nil {
+		t.Error("DecodeIMG() of a truncated frame succeeded")
+	}
+}
@@ -0,0 +1,151 @@
+package npk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// Conflict is an IMG path found in more than one merged NPK. The entry of
+// the later file is kept.
+type Conflict struct {
+	Name     string
+	Replaced string // the NPK whose entry was dropped
+	Kept     string // the NPK whose entry was kept
+}
+
+// source is an entry to copy into a merged NPK.
+type source struct {
+	file  int // index into the merged files
+	entry Entry
+}
+
+// Merge combines the entries of the NPK files in sources into a new NPK at
+// dst. When several files contain the same IMG path, compared
+// case-insensitively, the entry of the later file wins; these are returned
+// as conflicts. progress, if set, is called after each entry is copied.
+func Merge(dst string, sources []string, progress func(done, total int)) ([]Conflict, error) {
+	files := make([]*os.File, len(sources))
+	defer func() {
+		for _, f := range files {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}()
+
+	var order []string // lower-cased names in order of first appearance
+	merged := map[string]source{}
+	var conflicts []Conflict
+	for i, path := range sources {
+		f, err := os.Open(fsutil.LongPath(path))
+		if err != nil {
+			return nil, err
+		}
+		files[i] = f
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		a, err := Read(f, info.Size())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+
+		for _, e := range a.Entries {
+			if len(e.Name) == 0 || len(e.Name) >= nameSize {
+				return nil, fmt.Errorf("%s: invalid entry name %q", filepath.Base(path), e.Name)
+			}
+			if int64(e.Offset)+int64(e.Size) > info.Size() {
+				return nil, fmt.Errorf("%s: %s: data outside the file", filepath.Base(path), e.Name)
+			}
+			key := strings.ToLower(e.Name)
+			if previous, ok := merged[key]; ok {
+				conflicts = append(conflicts, Conflict{Name: e.Name, Replaced: sources[previous.file], Kept: path})
+			} else {
+				order = append(order, key)
+			}
+			merged[key] = source{file: i, entry: e}
+		}
+	}
+
+	entries := make([]source, len(order))
+	for i, key := range order {
+		entries[i] = merged[key]
+	}
+	if err := write(dst, files, entries, progress); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// write writes a new NPK holding entries, copying their data from files,
+// to a temporary file renamed to dst once complete.
+func write(dst string, files []*os.File, entries []source, progress func(done, total int)) (err error) {
+	tableEnd := headerSize + len(entries)*entrySize
+	offset := int64(tableEnd + hashSize)
+
+	var header bytes.Buffer
+	header.WriteString(magic)
+	binary.Write(&header, binary.LittleEndian, uint32(len(entries)))
+	for _, s := range entries {
+		binary.Write(&header, binary.LittleEndian, uint32(offset))
+		binary.Write(&header, binary.LittleEndian, s.entry.Size)
+		var name [nameSize]byte
+		copy(name[:], s.entry.Name)
+		for i := range name {
+			name[i] ^= nameKey[i]
+		}
+		header.Write(name[:])
+		offset += int64(s.entry.Size)
+	}
+	if offset > 1<<32-1 {
+		return fmt.Errorf("merged NPK would be %d bytes, larger than the format allows", offset)
+	}
+	// The game checks a SHA-256 of the header and table, cut to a multiple
+	// of 17 bytes
+	sum := sha256.Sum256(header.Bytes()[:tableEnd/17*17])
+	header.Write(sum[:])
+
+	if err := os.MkdirAll(fsutil.LongPath(filepath.Dir(dst)), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(fsutil.LongPath(filepath.Dir(dst)), filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(header.Bytes()); err != nil {
+		return err
+	}
+	for i, s := range entries {
+		section := io.NewSectionReader(files[s.file], int64(s.entry.Offset), int64(s.entry.Size))
+		n, err := io.Copy(tmp, section)
+		if err != nil {
+			return err
+		}
+		if n != int64(s.entry.Size) {
+			return fmt.Errorf("%s: read %d bytes, the header says %d", s.entry.Name, n, s.entry.Size)
+		}
+		if progress != nil {
+			progress(i+1, len(entries))
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return fsutil.Rename(tmp.Name(), dst)
+}
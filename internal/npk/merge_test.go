@@ -0,0 +1,81 @@
+package npk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeRoundTrip(t *testing.T) {
+	first, _ := writeNPK(t, map[string]string{
+		"sprite/character/swordman/coat.img": "old coat",
+		"sprite/interface/hud.img":           "hud",
+	}, "sprite/character/swordman/coat.img", "sprite/interface/hud.img")
+	second, _ := writeNPK(t, map[string]string{
+		"Sprite/Character/Swordman/Coat.img": "new coat",
+		"sprite/character/gunner/hat.img":    "hat",
+	}, "Sprite/Character/Swordman/Coat.img", "sprite/character/gunner/hat.img")
+
+	dst := filepath.Join(t.TempDir(), "merged.npk")
+	var done int
+	conflicts, err := Merge(dst, []string{first, second}, func(d, total int) { done = d })
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if done != 3 {
+		t.Errorf("progress reached %d, want 3", done)
+	}
+	if len(conflicts) != 1 || conflicts[0].Replaced != first || conflicts[0].Kept != second {
+		t.Errorf("conflicts = %+v, want the coat replaced by the second file", conflicts)
+	}
+
+	merged, err := Open(dst)
+	if err != nil {
+		t.Fatalf("Open(merged) error = %v", err)
+	}
+	want := map[string]string{
+		"Sprite/Character/Swordman/Coat.img": "new coat",
+		"sprite/interface/hud.img":           "hud",
+		"sprite/character/gunner/hat.img":    "hat",
+	}
+	if len(merged.Entries) != len(want) {
+		t.Fatalf("merged has %d entries, want %d", len(merged.Entries), len(want))
+	}
+	for _, e := range merged.Entries {
+		if e.Problem != "" {
+			t.Errorf("%s: %s", e.Name, e.Problem)
+		}
+		data, err := ReadEntry(dst, e)
+		if err != nil {
+			t.Fatalf("ReadEntry(%s) error = %v", e.Name, err)
+		}
+		if string(data) != want[e.Name] {
+			t.Errorf("%s = %q, want %q", e.Name, data, want[e.Name])
+		}
+	}
+
+	// Merging the result again changes nothing
+	again := filepath.Join(t.TempDir(), "again.npk")
+	if _, err := Merge(again, []string{dst}, nil); err != nil {
+		t.Fatalf("Merge(merged) error = %v", err)
+	}
+	a, _ := os.ReadFile(dst)
+	b, _ := os.ReadFile(again)
+	if string(a) != string(b) {
+		t.Error("re-merging a merged NPK produced a different file")
+	}
+}
+
+func TestMergeRejectsDamagedInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.npk")
+	if err := os.WriteFile(path, []byte("not an npk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "merged.npk")
+	if _, err := Merge(dst, []string{path}, nil); err == nil {
+		t.Error("Merge() of a damaged file succeeded")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("merged file written despite the error: %v", err)
+	}
+}
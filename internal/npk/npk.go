@@ -0,0 +1,208 @@
+// Package npk reads the entry table of NPK archives, the packages DNF keeps
+// its IMG sprite files in.
+//
+// An NPK file starts with the magic "NeoplePack_Bill\0" and an entry count,
+// followed by one 264 byte record per entry: the offset and size of the IMG
+// data and its 256 byte path, XORed with a fixed key. A SHA-256 of the
+// header follows the table, then the IMG data.
+package npk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	magic      = "NeoplePack_Bill\x00"
+	headerSize = len(magic) + 4
+	nameSize   = 256
+	entrySize  = 8 + nameSize
+	hashSize   = 32
+)
+
+// nameKey is XORed with entry names.
+var nameKey = func() [nameSize]byte {
+	var key [nameSize]byte
+	s := "puchikon@neople dungeon and fighter "
+	for len(s) < nameSize-1 {
+		s += "DNF"
+	}
+	copy(key[:], s[:nameSize-1])
+	return key
+}()
+
+// ErrNotNPK is returned for files without the NPK magic.
+var ErrNotNPK = errors.New("not an NPK file")
+
+// Entry is an IMG file inside an NPK.
+type Entry struct {
+	Name   string
+	Offset uint32
+	Size   uint32
+	// Problem describes what is suspicious about the entry, empty when
+	// nothing is.
+	Problem string
+}
+
+// Archive is the entry table of an NPK file.
+type Archive struct {
+	Entries []Entry
+	Size    int64 // size of the NPK file
+}
+
+// Read parses the entry table of the NPK in r, which is size bytes long.
+// The entry count is checked against size before anything is allocated, so
+// truncated or corrupted files fail cleanly.
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotNPK
+		}
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, ErrNotNPK
+	}
+
+	count := int64(binary.LittleEndian.Uint32(header[len(magic):]))
+	tableEnd := int64(headerSize) + count*entrySize
+	if tableEnd > size {
+		return nil, fmt.Errorf("truncated NPK: %d entries need %d bytes, the file has %d", count, tableEnd, size)
+	}
+
+	table := make([]byte, count*entrySize)
+	if _, err := r.ReadAt(table, int64(headerSize)); err != nil {
+		return nil, fmt.Errorf("reading NPK entry table: %v", err)
+	}
+
+	dataStart := tableEnd + hashSize
+	a := &Archive{Entries: make([]Entry, count), Size: size}
+	for i := range a.Entries {
+		record := table[i*entrySize : (i+1)*entrySize]
+		e := Entry{
+			Offset: binary.LittleEndian.Uint32(record[0:4]),
+			Size:   binary.LittleEndian.Uint32(record[4:8]),
+			Name:   decodeName(record[8:]),
+		}
+		e.Problem = check(e, dataStart, size)
+		a.Entries[i] = e
+	}
+	return a, nil
+}
+
+// Open reads the entry table of the NPK file at path.
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Read(f, info.Size())
+}
+
+// ReadEntry reads the IMG data of e from the NPK file at path.
+func ReadEntry(path string, e Entry) ([]byte, error) {
+	if e.Problem != "" {
+		return nil, fmt.Errorf("%s: %s", e.Name, e.Problem)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data := make([]byte, e.Size)
+	if _, err := f.ReadAt(data, int64(e.Offset)); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", e.Name, err)
+	}
+	return data, nil
+}
+
+func decodeName(encoded []byte) string {
+	var name [nameSize]byte
+	for i := range name {
+		name[i] = encoded[i] ^ nameKey[i]
+	}
+	if end := bytes.IndexByte(name[:], 0); end >= 0 {
+		return string(name[:end])
+	}
+	return string(name[:])
+}
+
+// check returns why e looks wrong, or "".
+func check(e Entry, dataStart, size int64) string {
+	name := e.Name
+	switch {
+	case name == "":
+		return "empty name"
+	case !utf8.ValidString(name) || strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "unreadable name"
+	case strings.HasPrefix(name, "/") || strings.Contains(name, "\\") || strings.Contains(name, ":"):
+		return "absolute or Windows path"
+	case path.Clean(name) != name || strings.HasPrefix(name, "../"):
+		return "path leaves the archive"
+	case !strings.HasSuffix(strings.ToLower(name), ".img"):
+		return "not an IMG file"
+	case int64(e.Offset) < dataStart || int64(e.Offset)+int64(e.Size) > size:
+		return "data outside the file"
+	}
+	return ""
+}
+
+// Suspicious returns the entries with a problem.
+func (a *Archive) Suspicious() []Entry {
+	var entries []Entry
+	for _, e := range a.Entries {
+		if e.Problem != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Group counts the entries under one folder.
+type Group struct {
+	Folder string // such as sprite/character/swordman
+	Count  int
+}
+
+// Groups counts the entries per folder, up to depth path segments deep,
+// largest first. It shows at a glance which characters or interface parts
+// a patch touches.
+func (a *Archive) Groups(depth int) []Group {
+	counts := map[string]int{}
+	for _, e := range a.Entries {
+		folders := strings.Split(path.Dir(e.Name), "/")
+		if len(folders) > depth {
+			folders = folders[:depth]
+		}
+		counts[strings.Join(folders, "/")]++
+	}
+
+	groups := make([]Group, 0, len(counts))
+	for folder, count := range counts {
+		groups = append(groups, Group{Folder: folder, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Folder < groups[j].Folder
+	})
+	return groups
+}
@@ -0,0 +1,118 @@
+package npk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// build encodes an NPK holding the named IMG files.
+func build(files map[string]string, names ...string) []byte {
+	var header, data bytes.Buffer
+	header.WriteString(magic)
+	binary.Write(&header, binary.LittleEndian, uint32(len(names)))
+
+	offset := headerSize + len(names)*entrySize + hashSize
+	for _, name := range names {
+		content := files[name]
+		binary.Write(&header, binary.LittleEndian, uint32(offset+data.Len()))
+		binary.Write(&header, binary.LittleEndian, uint32(len(content)))
+		var encoded [nameSize]byte
+		copy(encoded[:], name)
+		for i := range encoded {
+			encoded[i] ^= nameKey[i]
+		}
+		header.Write(encoded[:])
+		data.WriteString(content)
+	}
+	header.Write(make([]byte, hashSize))
+	return append(header.Bytes(), data.Bytes()...)
+}
+
+func TestRead(t *testing.T) {
+	files := map[string]string{
+		"sprite/character/swordman/equipment/avatar/coat.img":  "IMG1",
+		"sprite/character/swordman/equipment/avatar/pants.img": "IMG2",
+		"sprite/interface/hud.img":                             "IMG3",
+		"../../windows/system32/evil.img":                      "IMG4",
+	}
+	data := build(files,
+		"sprite/character/swordman/equipment/avatar/coat.img",
+		"sprite/character/swordman/equipment/avatar/pants.img",
+		"sprite/interface/hud.img",
+		"../../windows/system32/evil.img")
+
+	a, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(a.Entries) != 4 {
+		t.Fatalf("read %d entries, want 4", len(a.Entries))
+	}
+	coat := a.Entries[0]
+	if got := string(data[coat.Offset : coat.Offset+coat.Size]); got != "IMG1" {
+		t.Errorf("coat data = %q, want IMG1", got)
+	}
+
+	suspicious := a.Suspicious()
+	if len(suspicious) != 1 || !strings.HasPrefix(suspicious[0].Name, "../") {
+		t.Errorf("Suspicious() = %+v, want the path leaving the archive", suspicious)
+	}
+
+	groups := a.Groups(3)
+	if groups[0].Folder != "sprite/character/swordman" || groups[0].Count != 2 {
+		t.Errorf("Groups(3)[0] = %+v, want 2 entries in sprite/character/swordman", groups[0])
+	}
+}
+
+func TestReadDamaged(t *testing.T) {
+	valid := build(map[string]string{"sprite/a.img": "IMG"}, "sprite/a.img")
+
+	// A huge entry count in a tiny file must not allocate the table
+	huge := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(huge[len(magic):], 0xFFFFFFFF)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not an NPK", []byte("PK\x03\x04 a zip file, not an NPK")},
+		{"truncated table", valid[:headerSize+entrySize/2]},
+		{"huge entry count", huge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Read() succeeded, want an error")
+			}
+		})
+	}
+
+	// Truncated data leaves the table readable but flags the entry
+	truncated := valid[:len(valid)-1]
+	a, err := Read(bytes.NewReader(truncated), int64(len(truncated)))
+	if err != nil {
+		t.Fatalf("Read() with truncated data error = %v", err)
+	}
+	if a.Entries[0].Problem == "" {
+		t.Error("entry with data past the end of the file not flagged")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sprite_interface.npk")
+	if err := os.WriteFile(path, build(map[string]string{"sprite/a.img": "IMG"}, "sprite/a.img"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(a.Entries) != 1 || a.Entries[0].Name != "sprite/a.img" || a.Entries[0].Problem != "" {
+		t.Errorf("Entries = %+v", a.Entries)
+	}
+}
@@ -0,0 +1,61 @@
+package npk
+
+import (
+	"sort"
+	"sync"
+
+	"dnf_patch/internal/relpath"
+)
+
+// Overlap returns the entries of a that b contains as well, compared
+// in canonical form as the game does, sorted by name.
+func Overlap(a, b *Archive) []string {
+	names := make(map[relpath.Path]bool, len(b.Entries))
+	for _, e := range b.Entries {
+		names[relpath.Canonical(e.Name)] = true
+	}
+
+	var overlap []string
+	seen := map[relpath.Path]bool{}
+	for _, e := range a.Entries {
+		key := relpath.Canonical(e.Name)
+		if names[key] && !seen[key] {
+			seen[key] = true
+			overlap = append(overlap, e.Name)
+		}
+	}
+	sort.Strings(overlap)
+	return overlap
+}
+
+// Cache keeps parsed entry tables by the hash of their file, so comparing
+// against the same installed patches again does not re-read them. It is safe
+// for concurrent use.
+type Cache struct {
+	mu     sync.Mutex
+	tables map[string]*Archive
+}
+
+func NewCache() *Cache {
+	return &Cache{tables: map[string]*Archive{}}
+}
+
+// Open returns the entry table of the file at path whose content hash is
+// hash, parsing it only the first time.
+func (c *Cache) Open(path, hash string) (*Archive, error) {
+	c.mu.Lock()
+	a, ok := c.tables[hash]
+	c.mu.Unlock()
+	if ok {
+		return a, nil
+	}
+
+	a, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.tables[hash] = a
+	c.mu.Unlock()
+	return a, nil
+}
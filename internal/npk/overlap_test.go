@@ -0,0 +1,59 @@
+package npk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func archive(t *testing.T, names ...string) *Archive {
+	t.Helper()
+	data := build(nil, names...)
+	a, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestOverlap(t *testing.T) {
+	installed := archive(t,
+		"sprite/character/swordman/equipment/avatar/coat.img",
+		"sprite/interface/hud.img")
+	patch := archive(t,
+		"sprite/character/swordman/equipment/avatar/pants.img",
+		`Sprite\Interface\HUD.img`,
+		"sprite/character/swordman/equipment/avatar/coat.img")
+
+	got := Overlap(patch, installed)
+	want := []string{`Sprite\Interface\HUD.img`, "sprite/character/swordman/equipment/avatar/coat.img"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Overlap() = %q, want %q", got, want)
+	}
+	if got := Overlap(patch, archive(t, "sound/skill.img")); len(got) != 0 {
+		t.Errorf("Overlap() with a disjoint archive = %q, want none", got)
+	}
+}
+
+func TestCacheParsesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patch.npk")
+	if err := os.WriteFile(path, build(nil, "sprite/a.img"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCache()
+	first, err := cache.Open(path, "hash")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// Served from the cache even though the file is gone
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	second, err := cache.Open(path, "hash")
+	if err != nil || second != first {
+		t.Errorf("second Open() = %p, %v, want the cached %p", second, err, first)
+	}
+}
@@ -0,0 +1,70 @@
+package patchdb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// BlockEntry marks a patch known to break the game, by its ID or by the
+// SHA-256 of its file, which also catches copies installed from elsewhere.
+type BlockEntry struct {
+	PatchID string `json:"patchId,omitempty"`
+	Sha256  string `json:"sha256,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// Blocklist is the blocklist section of patches.json, or the user's own.
+type Blocklist []BlockEntry
+
+// Find returns the entry blocking the patch with the given ID or file hash.
+// Either may be empty.
+func (b Blocklist) Find(patchID, hash string) (BlockEntry, bool) {
+	for _, e := range b {
+		if e.PatchID != "" && e.PatchID == patchID || e.Sha256 != "" && strings.EqualFold(e.Sha256, hash) {
+			return e, true
+		}
+	}
+	return BlockEntry{}, false
+}
+
+// Without returns b without the entries for patchID.
+func (b Blocklist) Without(patchID string) Blocklist {
+	var kept Blocklist
+	for _, e := range b {
+		if e.PatchID != patchID {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// LoadBlocklist reads a blocklist saved with Save. A missing file is an
+// empty blocklist.
+func LoadBlocklist(path string) (Blocklist, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Blocklist
+	err = json.Unmarshal(data, &b)
+	return b, err
+}
+
+// Save writes b to path.
+func (b Blocklist) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,43 @@
+package patchdb
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	db, err := Parse([]byte(`{"categories": [], "blocklist": [
+		{"patchId": "old-glow", "reason": "crashes the current client"},
+		{"sha256": "ABCDEF", "reason": "broken upload"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		id, hash string
+		want     string
+	}{
+		{"old-glow", "", "crashes the current client"},
+		{"renamed", "abcdef", "broken upload"},
+		{"dark-ui", "123456", ""},
+		{"", "", ""},
+	} {
+		e, ok := db.Blocklist.Find(tt.id, tt.hash)
+		if ok != (tt.want != "") || e.Reason != tt.want {
+			t.Errorf("Find(%q, %q) = %+v, %v, want %q", tt.id, tt.hash, e, ok, tt.want)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "blocklist.json")
+	if b, err := LoadBlocklist(path); err != nil || b != nil {
+		t.Fatalf("LoadBlocklist() of a missing file = %v, %v", b, err)
+	}
+	if err := db.Blocklist.Without("old-glow").Save(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadBlocklist(path)
+	if want := (Blocklist{{Sha256: "ABCDEF", Reason: "broken upload"}}); err != nil || !reflect.DeepEqual(b, want) {
+		t.Errorf("LoadBlocklist() = %+v, %v, want %+v", b, err, want)
+	}
+}
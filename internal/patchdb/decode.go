@@ -0,0 +1,164 @@
+package patchdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// progressEvery is how many patches Decode reads between progress reports.
+const progressEvery = 200
+
+// Decode reads a patches.json document from r one patch at a time, so a
+// catalogue with tens of thousands of patches is never held twice, once as
+// text and once decoded. progress, which may be nil, is called with the
+// number of patches read so far every few hundred patches and once at the
+// end. Decoding stops between patches once ctx is cancelled.
+func Decode(ctx context.Context, r io.Reader, progress func(patches int)) (Database, error) {
+	d := &decoder{Decoder: json.NewDecoder(r), ctx: ctx, progress: progress}
+	var db Database
+	err := d.object(func(key string) error {
+		switch {
+		case strings.EqualFold(key, "categories"):
+			return d.array(func() error {
+				c, err := d.category()
+				db.Categories = append(db.Categories, c)
+				return err
+			})
+		case strings.EqualFold(key, "featured"):
+			return d.Decode(&db.Featured)
+		case strings.EqualFold(key, "blocklist"):
+			return d.Decode(&db.Blocklist)
+		}
+		return d.skip()
+	})
+	if err != nil {
+		return Database{}, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return Database{}, errors.New("patch catalogue: unexpected data after the top-level object")
+	}
+	if progress != nil {
+		progress(d.patches)
+	}
+	return db, nil
+}
+
+// LoadContext is Load reading the file with Decode.
+func LoadContext(ctx context.Context, path string, progress func(patches int)) (Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Database{}, err
+	}
+	defer f.Close()
+	return Decode(ctx, f, progress)
+}
+
+// decoder walks a patches.json document token by token.
+type decoder struct {
+	*json.Decoder
+	ctx      context.Context
+	progress func(patches int)
+	patches  int // read so far
+}
+
+// category reads a category, decoding its patches one at a time.
+func (d *decoder) category() (Category, error) {
+	var c Category
+	err := d.object(func(key string) error {
+		switch {
+		case strings.EqualFold(key, "name"):
+			return d.Decode(&c.Name)
+		case strings.EqualFold(key, "icon"):
+			return d.Decode(&c.Icon)
+		case strings.EqualFold(key, "patches"):
+			return d.array(func() error {
+				var patch Patch
+				if err := d.Decode(&patch); err != nil {
+					return err
+				}
+				c.Patches = append(c.Patches, patch)
+				return d.read()
+			})
+		}
+		return d.skip()
+	})
+	return c, err
+}
+
+// read counts a patch, reporting progress and checking for cancellation
+// every progressEvery patches.
+func (d *decoder) read() error {
+	d.patches++
+	if d.patches%progressEvery != 0 {
+		return nil
+	}
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	if d.progress != nil {
+		d.progress(d.patches)
+	}
+	return nil
+}
+
+// object reads an object, calling field with each key while the decoder is
+// at its value. field must read the value. Like json.Unmarshal, it takes null
+// for an empty object.
+func (d *decoder) object(field func(key string) error) error {
+	if open, err := d.open('{'); err != nil || !open {
+		return err
+	}
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(tok.(string)); err != nil {
+			return err
+		}
+	}
+	_, err := d.Token()
+	return err
+}
+
+// array reads an array, calling elem while the decoder is at each element.
+// elem must read the element. null is taken for an empty array.
+func (d *decoder) array(elem func() error) error {
+	if open, err := d.open('['); err != nil || !open {
+		return err
+	}
+	for d.More() {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	_, err := d.Token()
+	return err
+}
+
+// open reads the delimiter opening an object or array, reporting false for
+// null.
+func (d *decoder) open(delim json.Delim) (bool, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != delim {
+		return false, fmt.Errorf("patch catalogue: found %v where %v was expected at offset %d", tok, delim, d.InputOffset())
+	}
+	return true, nil
+}
+
+// skip reads a value of a field the catalogue does not use.
+func (d *decoder) skip() error {
+	var v json.RawMessage
+	return d.Decode(&v)
+}
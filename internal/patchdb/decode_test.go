@@ -0,0 +1,131 @@
+package patchdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMatchesUnmarshal(t *testing.T) {
+	doc := `{
+		"Categories": [
+			{"name": "UI", "icon": "ui.png", "patches": [{"id": "dark-ui", "tags": ["ui"], "extra": {"a": [1, 2]}}]},
+			{"name": "Empty", "patches": null}
+		],
+		"featured": [{"patchId": "dark-ui", "banner": "https://example.com/dark.png", "blurb": "Patch of the month"}],
+		"blocklist": [{"patchId": "bad", "reason": "malware"}],
+		"version": 2
+	}`
+	var want Database
+	if err := json.Unmarshal([]byte(doc), &want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(context.Background(), strings.NewReader(doc), nil)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v, want %+v as from json.Unmarshal", got, want)
+	}
+}
+
+func TestDecodeRejectsMalformed(t *testing.T) {
+	for _, doc := range []string{
+		`[]`,
+		`{"categories": {}}`,
+		`{"categories": [{"patches": [`,
+		`{"categories": []} {}`,
+	} {
+		if _, err := Decode(context.Background(), strings.NewReader(doc), nil); err == nil {
+			t.Errorf("Decode(%s) succeeded", doc)
+		}
+	}
+}
+
+func TestDecodeProgress(t *testing.T) {
+	path := writeCatalogue(t, 2*progressEvery+5)
+	var reported []int
+	db, err := LoadContext(context.Background(), path, func(n int) { reported = append(reported, n) })
+	if err != nil {
+		t.Fatalf("LoadContext() error = %v", err)
+	}
+	if got, want := reported, []int{progressEvery, 2 * progressEvery, 2*progressEvery + 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %v, want %v", got, want)
+	}
+	if n := len(db.Categories[0].Patches) + len(db.Categories[1].Patches); n != 2*progressEvery+5 {
+		t.Errorf("read %d patches, want %d", n, 2*progressEvery+5)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadContext(ctx, path, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadContext() error = %v, want context.Canceled", err)
+	}
+}
+
+// writeCatalogue writes a catalogue of n generated patches in two categories
+// and returns its path.
+func writeCatalogue(tb testing.TB, n int) string {
+	tb.Helper()
+	var db Database
+	for c := 0; c < 2; c++ {
+		db.Categories = append(db.Categories, Category{Name: fmt.Sprintf("Category %d", c)})
+	}
+	for i := 0; i < n; i++ {
+		c := &db.Categories[i%2]
+		c.Patches = append(c.Patches, Patch{
+			ID:          fmt.Sprintf("patch-%d", i),
+			Name:        fmt.Sprintf("Patch %d", i),
+			Description: strings.Repeat("A generated patch for the benchmark. ", 4),
+			Filename:    fmt.Sprintf("patch_%d.npk", i),
+			Version:     "1.0.0",
+			Author:      "bench",
+			Tags:        []string{"ui", "generated"},
+			Rating:      Rating{Average: 4.5, Count: i},
+			Previews:    []Preview{{URL: fmt.Sprintf("https://example.com/%d.png", i)}},
+			Downloads:   i,
+			LastUpdated: "2024-01-01",
+			SizeBytes:   int64(i) * 1024,
+			Sha256:      strings.Repeat("ab", 32),
+		})
+	}
+	path := filepath.Join(tb.TempDir(), "patches.json")
+	if err := db.Save(path); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+// BenchmarkLoad compares reading a catalogue of 50,000 patches whole with
+// json.Unmarshal, as Load did before, against streaming it with Decode.
+func BenchmarkLoad(b *testing.B) {
+	path := writeCatalogue(b, 50000)
+
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var db Database
+			if err := json.Unmarshal(data, &db); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := LoadContext(context.Background(), path, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package patchdb
+
+import "strings"
+
+// Index looks up the patches of a Database by ID, file name and tag. It
+// points into the database it was built from, so it must be rebuilt after
+// patches are added to or removed from that database.
+type Index struct {
+	patches    []*Patch // in catalogue order, one per ID
+	byID       map[string]*Patch
+	byFilename map[string][]*Patch // keyed by lower-case file name
+	byTag      map[string][]*Patch // keyed by lower-case tag
+	duplicates []string
+}
+
+// NewIndex indexes the patches of db. When several patches share an ID the
+// first one wins; the others are left out and their ID is reported once by
+// Duplicates. A nil *Index, like an empty one, finds nothing.
+func NewIndex(db Database) *Index {
+	idx := &Index{
+		byID:       make(map[string]*Patch),
+		byFilename: make(map[string][]*Patch),
+		byTag:      make(map[string][]*Patch),
+	}
+	reported := make(map[string]bool)
+	for c := range db.Categories {
+		patches := db.Categories[c].Patches
+		for i := range patches {
+			patch := &patches[i]
+			if _, ok := idx.byID[patch.ID]; ok {
+				if !reported[patch.ID] {
+					reported[patch.ID] = true
+					idx.duplicates = append(idx.duplicates, patch.ID)
+				}
+				continue
+			}
+			idx.byID[patch.ID] = patch
+			idx.patches = append(idx.patches, patch)
+			if patch.Filename != "" {
+				name := strings.ToLower(patch.Filename)
+				idx.byFilename[name] = append(idx.byFilename[name], patch)
+			}
+			for _, tag := range patch.Tags {
+				tag = strings.ToLower(tag)
+				idx.byTag[tag] = append(idx.byTag[tag], patch)
+			}
+		}
+	}
+	return idx
+}
+
+// Duplicates returns the IDs that more than one patch uses, each once.
+func (idx *Index) Duplicates() []string {
+	if idx == nil {
+		return nil
+	}
+	return idx.duplicates
+}
+
+// Len returns the number of indexed patches.
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.patches)
+}
+
+// ByID returns the patch with the given ID.
+func (idx *Index) ByID(id string) (Patch, bool) {
+	if idx == nil {
+		return Patch{}, false
+	}
+	patch, ok := idx.byID[id]
+	if !ok {
+		return Patch{}, false
+	}
+	return *patch, true
+}
+
+// ByFilename returns the patches installed as the file name, compared
+// case-insensitively.
+func (idx *Index) ByFilename(name string) []Patch {
+	if idx == nil {
+		return nil
+	}
+	return values(idx.byFilename[strings.ToLower(name)])
+}
+
+// ByTag returns the patches with the tag, compared case-insensitively.
+func (idx *Index) ByTag(tag string) []Patch {
+	if idx == nil {
+		return nil
+	}
+	return values(idx.byTag[strings.ToLower(tag)])
+}
+
+// Patches returns the indexed patches in catalogue order.
+func (idx *Index) Patches() []Patch {
+	if idx == nil {
+		return nil
+	}
+	return values(idx.patches)
+}
+
+// Search is Database.Search over the indexed patches, so a patch listed
+// twice under the same ID is only found once.
+func (idx *Index) Search(query string, extra func(Patch) []string) []Patch {
+	if idx == nil {
+		return nil
+	}
+	return search(idx.patches, query, extra)
+}
+
+func values(patches []*Patch) []Patch {
+	if len(patches) == 0 {
+		return nil
+	}
+	out := make([]Patch, len(patches))
+	for i, patch := range patches {
+		out[i] = *patch
+	}
+	return out
+}
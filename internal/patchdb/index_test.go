@@ -0,0 +1,50 @@
+package patchdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	db := loadTestCatalogue(t)
+	db.Categories[0].Patches[0].Filename = "dark_ui.NPK"
+	db.Categories[1].Patches[0].Filename = "dark_ui.npk"
+	db.Merge("Local", []Patch{
+		{ID: "dark-ui", Name: "Someone else's Dark UI"},
+		{ID: "big-font", Name: "Copied Big Font"},
+	})
+	db.Merge("Mirror", []Patch{{ID: "dark-ui"}})
+	idx := NewIndex(db)
+
+	if got := idx.Len(); got != 4 || len(idx.Patches()) != 4 {
+		t.Errorf("Len() = %d, Patches() has %d, want 4", got, len(idx.Patches()))
+	}
+	if patch, ok := idx.ByID("dark-ui"); !ok || patch.Name != "Dark UI" {
+		t.Errorf("ByID(dark-ui) = %+v, %v, want the first patch with the ID", patch, ok)
+	}
+	if _, ok := idx.ByID("costume"); ok {
+		t.Error("ByID() found an unknown patch")
+	}
+	if got, want := idx.Duplicates(), []string{"dark-ui", "big-font"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicates() = %v, want %v", got, want)
+	}
+	if got, want := patchIDs(idx.ByFilename("DARK_UI.npk")), []string{"dark-ui", "silent-skills"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByFilename() = %v, want %v", got, want)
+	}
+	if got, want := patchIDs(idx.ByTag("effects")), []string{"silent-skills", "clear-skills"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByTag() = %v, want %v", got, want)
+	}
+	if got, want := patchIDs(idx.Search("ui", nil)), []string{"big-font", "dark-ui"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %v, want %v without the duplicates", got, want)
+	}
+}
+
+func TestNilIndex(t *testing.T) {
+	var idx *Index
+	if _, ok := idx.ByID("dark-ui"); ok {
+		t.Error("ByID() on a nil index found a patch")
+	}
+	if idx.ByFilename("a.npk") != nil || idx.ByTag("ui") != nil || idx.Search("ui", nil) != nil || idx.Len() != 0 || idx.Patches() != nil {
+		t.Error("nil index found patches")
+	}
+}
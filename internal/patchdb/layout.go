@@ -0,0 +1,295 @@
+package patchdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/schema"
+)
+
+// Layout is how the user arranged the categories of the catalogue: the order
+// they are shown in, the names they were given, the categories the user made
+// and the patches moved between them. It is kept apart from the catalogue so
+// it survives fetching a new one, and is applied to each with Apply.
+//
+// The editing methods change both the layout and a catalogue it has already
+// been applied to, checking their arguments before changing either.
+type Layout struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Order lists the categories in the order they are shown. Categories
+	// missing from it follow in catalogue order; those listed that are not
+	// in the catalogue are the user's own and shown even when empty.
+	Order []string `json:"order,omitempty"`
+	// Renamed maps catalogue names to the names shown.
+	Renamed map[string]string `json:"renamed,omitempty"`
+	// Moved maps patch IDs to the category they were moved to.
+	Moved map[string]string `json:"moved,omitempty"`
+}
+
+var layoutFile = schema.File{
+	Version:    1,
+	Migrations: []schema.Migration{schema.Unchanged},
+}
+
+// LoadLayout reads a layout saved with Save. A missing file is an empty
+// layout, which leaves the catalogue as it is.
+func LoadLayout(path string) (Layout, error) {
+	data, err := layoutFile.Read(path)
+	if os.IsNotExist(err) {
+		return Layout{}, nil
+	}
+	if err != nil {
+		return Layout{}, err
+	}
+	var l Layout
+	err = json.Unmarshal(data, &l)
+	return l, err
+}
+
+// Save writes l to path.
+func (l Layout) Save(path string) error {
+	l.SchemaVersion = layoutFile.Version
+	data, err := json.MarshalIndent(l, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
+
+// Apply arranges the categories of db as l describes: it renames them, moves
+// the patches, adds the user's empty categories and puts them in order.
+// Categories given the same name are merged, and categories left empty by
+// moving their patches away are dropped unless they are listed in Order.
+// Applying a layout again changes nothing.
+func (l Layout) Apply(db *Database) {
+	byName := make(map[string]int)
+	var categories []Category
+	add := func(c Category) {
+		if i, ok := byName[c.Name]; ok {
+			categories[i].Patches = append(categories[i].Patches, c.Patches...)
+			return
+		}
+		byName[c.Name] = len(categories)
+		categories = append(categories, c)
+	}
+	for _, c := range db.Categories {
+		if name, ok := l.Renamed[c.Name]; ok {
+			c.Name = name
+		}
+		add(c)
+	}
+
+	var moved []Category
+	for i := range categories {
+		c := &categories[i]
+		var kept []Patch
+		for _, patch := range c.Patches {
+			if to, ok := l.Moved[patch.ID]; ok && to != c.Name {
+				moved = append(moved, Category{Name: to, Patches: []Patch{patch}})
+				continue
+			}
+			kept = append(kept, patch)
+		}
+		if len(kept) < len(c.Patches) {
+			c.Patches = kept
+		}
+	}
+	for _, name := range l.Order {
+		add(Category{Name: name})
+	}
+	for _, c := range moved {
+		add(c)
+	}
+
+	listed := make(map[string]bool)
+	db.Categories = db.Categories[:0]
+	for _, name := range l.Order {
+		if i, ok := byName[name]; ok && !listed[name] {
+			listed[name] = true
+			db.Categories = append(db.Categories, categories[i])
+		}
+	}
+	for _, c := range categories {
+		if !listed[c.Name] && len(c.Patches) > 0 {
+			db.Categories = append(db.Categories, c)
+		}
+	}
+}
+
+// Create adds an empty category called name after the others.
+func (l *Layout) Create(db *Database, name string) error {
+	name = strings.TrimSpace(name)
+	if err := db.checkNewName(name); err != nil {
+		return err
+	}
+	db.Categories = append(db.Categories, Category{Name: name})
+	l.Order = db.CategoryNames()
+	return nil
+}
+
+// Rename gives the category called from the name to.
+func (l *Layout) Rename(db *Database, from, to string) error {
+	to = strings.TrimSpace(to)
+	i := db.category(from)
+	if i < 0 {
+		return fmt.Errorf("there is no category named %q", from)
+	}
+	if err := db.checkNewName(to); err != nil {
+		return err
+	}
+	db.Categories[i].Name = to
+
+	if l.Renamed == nil {
+		l.Renamed = make(map[string]string)
+	}
+	chained := false
+	for original, name := range l.Renamed {
+		if name == from {
+			l.Renamed[original], chained = to, true
+		}
+	}
+	if !chained {
+		l.Renamed[from] = to
+	}
+	for original, name := range l.Renamed {
+		if original == name {
+			delete(l.Renamed, original)
+		}
+	}
+	for id, name := range l.Moved {
+		if name == from {
+			l.Moved[id] = to
+		}
+	}
+	l.Order = db.CategoryNames()
+	return nil
+}
+
+// Reorder moves the category at index from to index to.
+func (l *Layout) Reorder(db *Database, from, to int) error {
+	n := len(db.Categories)
+	if from < 0 || from >= n || to < 0 || to >= n {
+		return fmt.Errorf("cannot move category %d to %d of %d", from, to, n)
+	}
+	c := db.Categories[from]
+	if from < to {
+		copy(db.Categories[from:to], db.Categories[from+1:to+1])
+	} else {
+		copy(db.Categories[to+1:from+1], db.Categories[to:from])
+	}
+	db.Categories[to] = c
+	l.Order = db.CategoryNames()
+	return nil
+}
+
+// MovePatch moves the patch with the given ID to the named category.
+func (l *Layout) MovePatch(db *Database, id, category string) error {
+	to := db.category(category)
+	if to < 0 {
+		return fmt.Errorf("there is no category named %q", category)
+	}
+	from, j := db.find(id)
+	if from < 0 {
+		return fmt.Errorf("there is no patch %q", id)
+	}
+	if l.Moved == nil {
+		l.Moved = make(map[string]string)
+	}
+	l.Moved[id] = category
+	if from == to {
+		return nil
+	}
+	patch := db.Categories[from].Patches[j]
+	db.Categories[from].Patches = append(db.Categories[from].Patches[:j:j], db.Categories[from].Patches[j+1:]...)
+	db.Categories[to].Patches = append(db.Categories[to].Patches, patch)
+	l.Order = db.CategoryNames()
+	return nil
+}
+
+// Delete removes the named category, moving its patches to target. target
+// may be empty for a category without patches.
+func (l *Layout) Delete(db *Database, name, target string) error {
+	i := db.category(name)
+	if i < 0 {
+		return fmt.Errorf("there is no category named %q", name)
+	}
+	patches := db.Categories[i].Patches
+	if len(patches) > 0 {
+		if target == name || db.category(target) < 0 {
+			return fmt.Errorf("there is no other category named %q to move the patches of %q to", target, name)
+		}
+	}
+	for _, patch := range patches {
+		if err := l.MovePatch(db, patch.ID, target); err != nil {
+			return err
+		}
+	}
+	i = db.category(name)
+	db.Categories = append(db.Categories[:i:i], db.Categories[i+1:]...)
+	for original, shown := range l.Renamed {
+		if shown == name {
+			delete(l.Renamed, original)
+		}
+	}
+	l.Order = db.CategoryNames()
+	return nil
+}
+
+// CategoryNames returns the names of the categories of db in order.
+func (db Database) CategoryNames() []string {
+	names := make([]string, len(db.Categories))
+	for i, c := range db.Categories {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// CategoryOf returns the name of the category listing the patch with the
+// given ID, or "" when there is none.
+func (db Database) CategoryOf(id string) string {
+	if i, _ := db.find(id); i >= 0 {
+		return db.Categories[i].Name
+	}
+	return ""
+}
+
+// category returns the index of the named category, or -1.
+func (db Database) category(name string) int {
+	for i, c := range db.Categories {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// find returns the indexes of the category and the patch with the given ID,
+// or -1 and -1.
+func (db Database) find(id string) (int, int) {
+	for i, c := range db.Categories {
+		for j, patch := range c.Patches {
+			if patch.ID == id {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// checkNewName reports why a category cannot be given name.
+func (db Database) checkNewName(name string) error {
+	if name == "" {
+		return fmt.Errorf("a category needs a name")
+	}
+	if db.category(name) >= 0 {
+		return fmt.Errorf("there is already a category named %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package patchdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"dnf_patch/internal/schema"
+)
+
+// arrangement returns the categories of db with the IDs of their patches.
+func arrangement(db Database) map[string][]string {
+	got := make(map[string][]string)
+	for _, c := range db.Categories {
+		got[c.Name] = patchIDs(c.Patches)
+	}
+	return got
+}
+
+func TestLayoutEdits(t *testing.T) {
+	db := loadTestCatalogue(t)
+	var l Layout
+	if err := l.Create(&db, " Favourites "); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := l.MovePatch(&db, "clear-skills", "Favourites"); err != nil {
+		t.Fatalf("MovePatch() error = %v", err)
+	}
+	if err := l.Rename(&db, "UI", "Interface"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if err := l.Reorder(&db, 2, 0); err != nil {
+		t.Fatalf("Reorder() error = %v", err)
+	}
+
+	want := []string{"Favourites", "Interface", "Skills"}
+	if got := db.CategoryNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("categories = %v, want %v", got, want)
+	}
+	wantPatches := map[string][]string{
+		"Favourites": {"clear-skills"},
+		"Interface":  {"dark-ui", "big-font"},
+		"Skills":     {"silent-skills"},
+	}
+	if got := arrangement(db); !reflect.DeepEqual(got, wantPatches) {
+		t.Errorf("patches = %v, want %v", got, wantPatches)
+	}
+
+	// A fresh copy of the catalogue comes out the same
+	fresh := loadTestCatalogue(t)
+	l.Apply(&fresh)
+	if got := fresh.CategoryNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("applied categories = %v, want %v", got, want)
+	}
+	if got := arrangement(fresh); !reflect.DeepEqual(got, wantPatches) {
+		t.Errorf("applied patches = %v, want %v", got, wantPatches)
+	}
+	l.Apply(&fresh)
+	if got := arrangement(fresh); !reflect.DeepEqual(got, wantPatches) {
+		t.Errorf("patches after applying twice = %v, want %v", got, wantPatches)
+	}
+}
+
+func TestLayoutRenameTwice(t *testing.T) {
+	db := loadTestCatalogue(t)
+	var l Layout
+	for _, name := range []string{"Interface", "Screens"} {
+		from := db.Categories[0].Name
+		if err := l.Rename(&db, from, name); err != nil {
+			t.Fatalf("Rename(%q, %q) error = %v", from, name, err)
+		}
+	}
+	if want := map[string]string{"UI": "Screens"}; !reflect.DeepEqual(l.Renamed, want) {
+		t.Errorf("Renamed = %v, want %v", l.Renamed, want)
+	}
+	if err := l.Rename(&db, "Screens", "UI"); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Renamed) != 0 {
+		t.Errorf("Renamed = %v after renaming back, want none", l.Renamed)
+	}
+}
+
+func TestLayoutRejects(t *testing.T) {
+	db := loadTestCatalogue(t)
+	var l Layout
+	for name, err := range map[string]error{
+		"Create existing":      l.Create(&db, "UI"),
+		"Create unnamed":       l.Create(&db, "  "),
+		"Rename missing":       l.Rename(&db, "Maps", "Towns"),
+		"Rename onto existing": l.Rename(&db, "UI", "Skills"),
+		"Reorder out of range": l.Reorder(&db, 0, 2),
+		"MovePatch missing":    l.MovePatch(&db, "dark-ui", "Maps"),
+		"Delete into itself":   l.Delete(&db, "UI", "UI"),
+		"Delete nowhere":       l.Delete(&db, "UI", ""),
+	} {
+		if err == nil {
+			t.Errorf("%s succeeded", name)
+		}
+	}
+	if got := arrangement(db); !reflect.DeepEqual(got, arrangement(loadTestCatalogue(t))) {
+		t.Errorf("rejected edits changed the catalogue to %v", got)
+	}
+}
+
+func TestLayoutDelete(t *testing.T) {
+	db := loadTestCatalogue(t)
+	var l Layout
+	if err := l.Rename(&db, "UI", "Interface"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(&db, "Interface", "Skills"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	want := map[string][]string{"Skills": {"silent-skills", "clear-skills", "dark-ui", "big-font"}}
+	if got := arrangement(db); !reflect.DeepEqual(got, want) {
+		t.Errorf("patches = %v, want %v", got, want)
+	}
+
+	fresh := loadTestCatalogue(t)
+	l.Apply(&fresh)
+	if got := arrangement(fresh); !reflect.DeepEqual(got, want) {
+		t.Errorf("applied patches = %v, want %v", got, want)
+	}
+
+	if err := l.Create(&db, "Empty"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(&db, "Empty", ""); err != nil {
+		t.Errorf("Delete() of an empty category error = %v", err)
+	}
+}
+
+func TestLayoutSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.json")
+	if l, err := LoadLayout(path); err != nil || !reflect.DeepEqual(l, Layout{}) {
+		t.Fatalf("LoadLayout() of a missing file = %+v, %v, want an empty layout", l, err)
+	}
+	want := Layout{
+		SchemaVersion: 1,
+		Order:         []string{"Skills", "UI"},
+		Renamed:       map[string]string{"Effects": "Skills"},
+		Moved:         map[string]string{"dark-ui": "Skills"},
+	}
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadLayout(path); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadLayout() = %+v, %v, want %+v", got, err, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"schemaVersion": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLayout(path); !errors.As(err, new(*schema.NewerError)) {
+		t.Errorf("LoadLayout() of a newer file error = %v, want a NewerError", err)
+	}
+}
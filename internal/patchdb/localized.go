@@ -0,0 +1,196 @@
+package patchdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Translation is a text in one language.
+type Translation struct {
+	Lang string // language code, such as "zh-CN" or "en"
+	Text string
+}
+
+// Localized is a text a catalogue gives in several languages, in the order
+// it lists them. In JSON it is an object keyed by language code:
+//
+//	{"zh-CN": "暗黑界面", "en": "Dark UI"}
+type Localized []Translation
+
+// Pick returns the text in lang, or in another variant of the same language
+// when there is none, such as "zh" for "zh-CN". Failing that it returns the
+// first text.
+func (l Localized) Pick(lang string) string {
+	for _, t := range l {
+		if strings.EqualFold(t.Lang, lang) && t.Text != "" {
+			return t.Text
+		}
+	}
+	for _, t := range l {
+		if strings.EqualFold(primaryLanguage(t.Lang), primaryLanguage(lang)) && t.Text != "" {
+			return t.Text
+		}
+	}
+	for _, t := range l {
+		if t.Text != "" {
+			return t.Text
+		}
+	}
+	return ""
+}
+
+// primaryLanguage returns the language of a code without its region or
+// script, such as "zh" for "zh-CN" or "zh_Hans".
+func primaryLanguage(lang string) string {
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		return lang[:i]
+	}
+	return lang
+}
+
+// UnmarshalJSON reads an object keyed by language code, keeping the order
+// of its keys.
+func (l *Localized) UnmarshalJSON(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		*l = nil
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("localized text must be an object keyed by language, not %v", tok)
+	}
+	var texts Localized
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			return err
+		}
+		var text string
+		if err := d.Decode(&text); err != nil {
+			return fmt.Errorf("localized text for %v: %w", key, err)
+		}
+		texts = append(texts, Translation{Lang: key.(string), Text: text})
+	}
+	*l = texts
+	return nil
+}
+
+// MarshalJSON writes l as an object keyed by language code, in order.
+func (l Localized) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, t := range l {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(t.Lang)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(t.Text)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// localizedField reads a field that is either a plain string or a Localized
+// object, returning the string, or the first text and all of them.
+func localizedField(data json.RawMessage) (string, Localized, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		var s string
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &s); err != nil {
+				return "", nil, err
+			}
+		}
+		return s, nil, nil
+	}
+	var l Localized
+	if err := json.Unmarshal(data, &l); err != nil {
+		return "", nil, err
+	}
+	return l.Pick(""), l, nil
+}
+
+// UnmarshalJSON reads a patch whose name and description are either plain
+// strings or objects keyed by language code. Until Database.Localize picks
+// the texts in the language of the user, Name and Description are the first
+// texts.
+func (p *Patch) UnmarshalJSON(data []byte) error {
+	type plain Patch
+	var v struct {
+		plain
+		Name        json.RawMessage `json:"name"`
+		Description json.RawMessage `json:"description"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = Patch(v.plain)
+	var err error
+	if p.Name, p.Names, err = localizedField(v.Name); err != nil {
+		return fmt.Errorf("name of patch %q: %w", p.ID, err)
+	}
+	if p.Description, p.Descriptions, err = localizedField(v.Description); err != nil {
+		return fmt.Errorf("description of patch %q: %w", p.ID, err)
+	}
+	return nil
+}
+
+// MarshalJSON writes a patch back the way it was read: its name and
+// description as objects when they were given in several languages.
+func (p Patch) MarshalJSON() ([]byte, error) {
+	type plain Patch
+	v := struct {
+		plain
+		Name        interface{} `json:"name"`
+		Description interface{} `json:"description"`
+	}{plain(p), p.Name, p.Description}
+	if p.Names != nil {
+		v.Name = p.Names
+	}
+	if p.Descriptions != nil {
+		v.Description = p.Descriptions
+	}
+	return json.Marshal(v)
+}
+
+// Localize sets the names and descriptions of the patches given in several
+// languages to their texts in lang, see Localized.Pick.
+func (db *Database) Localize(lang string) {
+	for c := range db.Categories {
+		for i := range db.Categories[c].Patches {
+			patch := &db.Categories[c].Patches[i]
+			if patch.Names != nil {
+				patch.Name = patch.Names.Pick(lang)
+			}
+			if patch.Descriptions != nil {
+				patch.Description = patch.Descriptions.Pick(lang)
+			}
+		}
+	}
+}
+
+// texts returns every name and description of patch, in all languages.
+func (p *Patch) texts() []string {
+	texts := []string{p.Name, p.Description}
+	for _, l := range []Localized{p.Names, p.Descriptions} {
+		for _, t := range l {
+			texts = append(texts, t.Text)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,74 @@
+package patchdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const localizedCatalogue = `{
+    "categories": [
+        {
+            "name": "UI",
+            "patches": [
+                {
+                    "id": "dark-ui",
+                    "name": {"zh-CN": "暗黑界面", "en": "Dark UI"},
+                    "description": {"zh": "更暗的界面"},
+                    "version": "1.0"
+                },
+                {"id": "big-font", "name": "大字体", "description": "Bigger text"}
+            ]
+        }
+    ]
+}`
+
+func TestLocalized(t *testing.T) {
+	db, err := Parse([]byte(localizedCatalogue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark := &db.Categories[0].Patches[0]
+	if dark.Name != "暗黑界面" || dark.Version != "1.0" {
+		t.Errorf("Parse() = %+v, want the first name before Localize", dark)
+	}
+	want := Localized{{"zh-CN", "暗黑界面"}, {"en", "Dark UI"}}
+	if !reflect.DeepEqual(dark.Names, want) {
+		t.Errorf("Names = %v, want %v in order", dark.Names, want)
+	}
+
+	db.Localize("en")
+	if dark.Name != "Dark UI" || dark.Description != "更暗的界面" {
+		t.Errorf("Localize(en) = %q, %q, want the English name and the only description", dark.Name, dark.Description)
+	}
+	db.Localize("zh-TW")
+	if dark.Name != "暗黑界面" || dark.Description != "更暗的界面" {
+		t.Errorf("Localize(zh-TW) = %q, %q, want the texts of another Chinese variant", dark.Name, dark.Description)
+	}
+	if plain := db.Categories[0].Patches[1]; plain.Name != "大字体" || plain.Names != nil {
+		t.Errorf("plain name = %q, %v, want it as it is", plain.Name, plain.Names)
+	}
+
+	// An English query finds the patch shown with its Chinese name
+	if got := patchIDs(NewIndex(db).Search("dark", nil)); !reflect.DeepEqual(got, []string{"dark-ui"}) {
+		t.Errorf("Search(dark) = %v, want dark-ui", got)
+	}
+
+	data, err := json.Marshal(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"name":{"zh-CN":"暗黑界面","en":"Dark UI"}`, `"description":{"zh":"更暗的界面"}`, `"name":"大字体"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("Marshal() = %s, want %s", data, s)
+		}
+	}
+}
+
+func TestLocalizedInvalid(t *testing.T) {
+	_, err := Parse([]byte(`{"categories": [{"name": "UI", "patches": [{"id": "dark-ui", "name": {"en": 1}}]}]}`))
+	if err == nil || !strings.Contains(err.Error(), "dark-ui") {
+		t.Errorf("Parse() of a name that is not text = %v, want an error naming the patch", err)
+	}
+}
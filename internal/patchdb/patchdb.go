@@ -0,0 +1,276 @@
+// Package patchdb reads the patch catalogue (patches.json) and searches it.
+package patchdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+)
+
+type Rating struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+type Preview struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type UpdateInfo struct {
+	LatestVersion string `json:"latestVersion"`
+	UpdateURL     string `json:"updateUrl"`
+	Changelog     string `json:"changelog"`
+}
+
+type Patch struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Filename    string     `json:"filename"`
+	URL         string     `json:"url,omitempty"` // defaults to patches/<filename> in the repository
+	Version     string     `json:"version"`
+	Author      string     `json:"author"`
+	Tags        []string   `json:"tags"`
+	Rating      Rating     `json:"rating"`
+	Previews    []Preview  `json:"previews"`
+	UpdateInfo  UpdateInfo `json:"updateInfo"`
+	Downloads   int        `json:"downloads"`
+	LastUpdated string     `json:"lastUpdated"`
+	SizeBytes   int64      `json:"sizeBytes,omitempty"` // 0 when unknown
+	Sha256      string     `json:"sha256,omitempty"`    // hex, empty when unknown
+	Class       string     `json:"class,omitempty"`     // the character class changed, for patches whose files are not at hand
+
+	// Names and Descriptions are the name and description in each language
+	// the catalogue gives them in, nil when it gives a plain string. Name
+	// and Description hold the texts in the language of the user, see
+	// Database.Localize.
+	Names        Localized `json:"-"`
+	Descriptions Localized `json:"-"`
+}
+
+type Category struct {
+	Name string `json:"name"`
+	// Icon is the name of a theme icon, such as "settings", or the URL or
+	// path of an image. Empty for the default icon.
+	Icon    string  `json:"icon,omitempty"`
+	Patches []Patch `json:"patches"`
+}
+
+// Featured is a patch the repository highlights at the top of the Patches
+// tab.
+type Featured struct {
+	PatchID string `json:"patchId"`
+	Banner  string `json:"banner,omitempty"` // URL or path of a wide image
+	Blurb   string `json:"blurb,omitempty"`  // why it is featured, in place of its description
+}
+
+type Database struct {
+	Categories []Category `json:"categories"`
+	Featured   []Featured `json:"featured,omitempty"`
+	Blocklist  Blocklist  `json:"blocklist,omitempty"`
+}
+
+// Parse decodes a patches.json document.
+func Parse(data []byte) (Database, error) {
+	return Decode(context.Background(), bytes.NewReader(data), nil)
+}
+
+// Load reads the patches.json file at path.
+func Load(path string) (Database, error) {
+	return LoadContext(context.Background(), path, nil)
+}
+
+// Save writes db to path as JSON.
+func (db Database) Save(path string) error {
+	data, err := json.MarshalIndent(db, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
+
+// Merge adds patches to the named category, creating it when there is none
+// and replacing the patches with the same ID.
+func (db *Database) Merge(category string, patches []Patch) {
+	i := 0
+	for i < len(db.Categories) && db.Categories[i].Name != category {
+		i++
+	}
+	if i == len(db.Categories) {
+		db.Categories = append(db.Categories, Category{Name: category})
+	}
+	c := &db.Categories[i]
+	for _, patch := range patches {
+		replaced := false
+		for j := range c.Patches {
+			if c.Patches[j].ID == patch.ID {
+				c.Patches[j], replaced = patch, true
+			}
+		}
+		if !replaced {
+			c.Patches = append(c.Patches, patch)
+		}
+	}
+}
+
+// Fetch downloads the patches.json document at url using client, giving up
+// when ctx is cancelled.
+func Fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// FillSizes sets the size of the patches that do not have one to the size of
+// their file in dir, for catalogues written by hand next to the patch files.
+func (db Database) FillSizes(dir string) {
+	for _, category := range db.Categories {
+		for i, patch := range category.Patches {
+			if patch.SizeBytes > 0 || patch.Filename == "" {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(dir, patch.Filename)); err == nil {
+				category.Patches[i].SizeBytes = info.Size()
+			}
+		}
+	}
+}
+
+// ErrSizeMismatch is returned by CheckSize when a download is much smaller
+// or larger than the catalogue says.
+var ErrSizeMismatch = errors.New("the file size does not match the catalogue")
+
+// sizeTolerance is how far, as a fraction of the catalogued size, a file may
+// differ before CheckSize reports it. Catalogue sizes are often rounded.
+const sizeTolerance = 0.1
+
+// CheckSize compares the actual size of a patch file, or the Content-Length
+// announced for it, against the catalogued size of patch.
+func CheckSize(patch Patch, actual int64) error {
+	if patch.SizeBytes <= 0 || actual < 0 {
+		return nil
+	}
+	diff := float64(actual - patch.SizeBytes)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > sizeTolerance*float64(patch.SizeBytes) {
+		return fmt.Errorf("%s is %d bytes, expected about %d: %w", patch.Name, actual, patch.SizeBytes, ErrSizeMismatch)
+	}
+	return nil
+}
+
+// ErrChecksumMismatch is returned by CheckFile when a patch file is not the
+// one the catalogue describes.
+var ErrChecksumMismatch = errors.New("the file does not match the catalogue checksum")
+
+// CheckFile compares the SHA-256 of the file at path with the catalogued
+// hash of patch. Patches without a catalogued hash always pass.
+func CheckFile(patch Patch, path string) error {
+	if patch.Sha256 == "" {
+		return nil
+	}
+	hash, err := fsutil.HashFile(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(hash, patch.Sha256) {
+		return fmt.Errorf("%s has SHA-256 %s, expected %s: %w", patch.Name, hash, patch.Sha256, ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// updatedLayouts are the forms of LastUpdated understood by Updated.
+var updatedLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006/01/02"}
+
+// Updated returns when the patch was last updated, from its LastUpdated.
+// Times without a zone are taken as local. It reports false when
+// LastUpdated is empty or not a date.
+func (p Patch) Updated() (time.Time, bool) {
+	s := strings.TrimSpace(p.LastUpdated)
+	for _, layout := range updatedLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Filter returns the patches whose name, description or tags contain query,
+// best rated first. An empty query matches nothing.
+func (db Database) Filter(query string) []Patch {
+	return db.Search(query, nil)
+}
+
+// Search is Filter also matching the texts extra returns for a patch, such
+// as the user's own notes and tags. extra may be nil.
+func (db Database) Search(query string, extra func(Patch) []string) []Patch {
+	var patches []*Patch
+	for c := range db.Categories {
+		for i := range db.Categories[c].Patches {
+			patches = append(patches, &db.Categories[c].Patches[i])
+		}
+	}
+	return search(patches, query, extra)
+}
+
+func search(patches []*Patch, query string, extra func(Patch) []string) []Patch {
+	if query == "" {
+		return nil
+	}
+
+	query = strings.ToLower(query)
+	var results []Patch
+
+	for _, patch := range patches {
+		if containsTag(patch.texts(), query) ||
+			containsTag(patch.Tags, query) ||
+			extra != nil && containsTag(extra(*patch), query) {
+			results = append(results, *patch)
+		}
+	}
+
+	// Sort by rating and downloads
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rating.Average == results[j].Rating.Average {
+			return results[i].Downloads > results[j].Downloads
+		}
+		return results[i].Rating.Average > results[j].Rating.Average
+	})
+
+	return results
+}
+
+func containsTag(tags []string, query string) bool {
+	for _, tag := range tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package patchdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCatalogue = `{
+	"categories": [
+		{
+			"name": "UI",
+			"patches": [
+				{"id": "dark-ui", "name": "Dark UI", "description": "Darker windows", "tags": ["ui"], "rating": {"average": 4.5}, "downloads": 10},
+				{"id": "big-font", "name": "Big Font", "description": "Larger interface text", "tags": ["ui", "font"], "rating": {"average": 4.5}, "downloads": 50}
+			]
+		},
+		{
+			"name": "Skills",
+			"patches": [
+				{"id": "silent-skills", "name": "Silent Skills", "description": "Removes screen shake", "tags": ["effects"], "rating": {"average": 3.0}, "downloads": 500},
+				{"id": "clear-skills", "name": "Clear Skills", "description": "Transparent effects", "tags": ["Effects", "pvp"], "rating": {"average": 4.8}, "downloads": 5}
+			]
+		}
+	]
+}`
+
+func loadTestCatalogue(t *testing.T) Database {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "patches.json")
+	if err := os.WriteFile(path, []byte(testCatalogue), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return db
+}
+
+func patchIDs(patches []Patch) []string {
+	var ids []string
+	for _, patch := range patches {
+		ids = append(ids, patch.ID)
+	}
+	return ids
+}
+
+func TestFilter(t *testing.T) {
+	db := loadTestCatalogue(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "empty query", query: "", want: nil},
+		{name: "no match", query: "costume", want: nil},
+		{name: "name", query: "dark", want: []string{"dark-ui"}},
+		{name: "description", query: "screen shake", want: []string{"silent-skills"}},
+		{name: "tag is case-insensitive", query: "EFFECTS", want: []string{"clear-skills", "silent-skills"}},
+		{name: "equal ratings sort by downloads", query: "ui", want: []string{"big-font", "dark-ui"}},
+		{name: "across categories by rating", query: "e", want: []string{"clear-skills", "big-font", "dark-ui", "silent-skills"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := patchIDs(db.Filter(tt.query)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchExtra(t *testing.T) {
+	db := loadTestCatalogue(t)
+	notes := map[string][]string{"silent-skills": {"Clashes with the weapon glow patch", "mine"}}
+	extra := func(p Patch) []string { return notes[p.ID] }
+
+	if got, want := patchIDs(db.Search("weapon glow", extra)), []string{"silent-skills"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() by note = %v, want %v", got, want)
+	}
+	if got, want := patchIDs(db.Search("MINE", extra)), []string{"silent-skills"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() by own tag = %v, want %v", got, want)
+	}
+	if got := patchIDs(db.Search("weapon glow", nil)); got != nil {
+		t.Errorf("Search() without extra = %v", got)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patches.json")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of truncated JSON succeeded")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	db := loadTestCatalogue(t)
+	db.Merge("UI", []Patch{{ID: "big-font", Name: "Bigger Font"}, {ID: "round-ui"}})
+	db.Merge("Local", []Patch{{ID: "mine"}})
+
+	if got, want := patchIDs(db.Categories[0].Patches), []string{"dark-ui", "big-font", "round-ui"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UI patches = %v, want %v", got, want)
+	}
+	if name := db.Categories[0].Patches[1].Name; name != "Bigger Font" {
+		t.Errorf("replaced patch name = %q", name)
+	}
+	if len(db.Categories) != 3 || db.Categories[2].Name != "Local" || len(db.Categories[2].Patches) != 1 {
+		t.Errorf("categories after Merge = %+v", db.Categories)
+	}
+
+	path := filepath.Join(t.TempDir(), "repo", "patches.json")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved.Categories[2], db.Categories[2]) {
+		t.Errorf("saved %+v, want %+v", saved.Categories[2], db.Categories[2])
+	}
+}
+
+func TestFillSizes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "local.npk"), make([]byte, 1234), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := Database{Categories: []Category{{Patches: []Patch{
+		{ID: "local", Filename: "local.npk"},
+		{ID: "known", Filename: "local.npk", SizeBytes: 99},
+		{ID: "missing", Filename: "missing.npk"},
+	}}}}
+	db.FillSizes(dir)
+
+	var sizes []int64
+	for _, patch := range db.Categories[0].Patches {
+		sizes = append(sizes, patch.SizeBytes)
+	}
+	if want := []int64{1234, 99, 0}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("sizes after FillSizes = %v, want %v", sizes, want)
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	patch := Patch{Name: "Dark UI", SizeBytes: 1000}
+	for _, tt := range []struct {
+		patch    Patch
+		actual   int64
+		mismatch bool
+	}{
+		{patch, 1000, false},
+		{patch, 1050, false},
+		{patch, 500, true},
+		{patch, 3000, true},
+		{patch, -1, false},             // no Content-Length
+		{Patch{Name: "x"}, 500, false}, // size not catalogued
+	} {
+		err := CheckSize(tt.patch, tt.actual)
+		if got := errors.Is(err, ErrSizeMismatch); got != tt.mismatch {
+			t.Errorf("CheckSize(%d, %d) = %v, want mismatch %v", tt.patch.SizeBytes, tt.actual, err, tt.mismatch)
+		}
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dark.npk")
+	if err := os.WriteFile(path, []byte("patch"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of "patch"
+	const sum = "a4895eb44afc336fecbba6e520cd67e178dace0276655d102fceffa8e5f70570"
+	for _, tt := range []struct {
+		sha256   string
+		mismatch bool
+	}{
+		{sum, false},
+		{strings.ToUpper(sum), false},
+		{strings.Repeat("0", 64), true},
+		{"", false}, // not catalogued
+	} {
+		err := CheckFile(Patch{Name: "Dark UI", Sha256: tt.sha256}, path)
+		if got := errors.Is(err, ErrChecksumMismatch); got != tt.mismatch || (err != nil && !tt.mismatch) {
+			t.Errorf("CheckFile(%q) = %v, want mismatch %v", tt.sha256, err, tt.mismatch)
+		}
+	}
+	if err := CheckFile(Patch{Sha256: sum}, path+".missing"); err == nil || errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("CheckFile() of a missing file = %v", err)
+	}
+}
+
+func TestUpdated(t *testing.T) {
+	for _, tt := range []struct {
+		lastUpdated string
+		want        time.Time
+		ok          bool
+	}{
+		{"2024-03-05T10:00:00Z", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), true},
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local), true},
+		{"2024/03/05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local), true},
+		{" 2024-03-05 10:00:00", time.Date(2024, 3, 5, 10, 0, 0, 0, time.Local), true},
+		{"", time.Time{}, false},
+		{"last week", time.Time{}, false},
+	} {
+		got, ok := Patch{LastUpdated: tt.lastUpdated}.Updated()
+		if !got.Equal(tt.want) || ok != tt.ok {
+			t.Errorf("Updated(%q) = %v, %v, want %v, %v", tt.lastUpdated, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package patchdb
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsigned is returned by Verify when there is no signature to check.
+var ErrUnsigned = errors.New("the patch database is not signed")
+
+// ErrBadSignature is returned by Verify when the signature was not made by
+// any of the accepted keys.
+var ErrBadSignature = errors.New("the patch database signature is not valid")
+
+// ParseKeys decodes ed25519 public keys written in base64, one per line.
+// Blank lines and lines starting with # are skipped.
+func ParseKeys(text string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid repository key %q", line)
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
+
+// Verify checks that sig, the base64 detached ed25519 signature published as
+// patches.json.sig, signs data with one of keys. Accepting several keys lets
+// a repository rotate its key without breaking older releases.
+func Verify(data, sig []byte, keys []ed25519.PublicKey) error {
+	sig = bytes.TrimSpace(sig)
+	if len(sig) == 0 {
+		return ErrUnsigned
+	}
+	raw, err := base64.StdEncoding.DecodeString(string(sig))
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: the signature is malformed", ErrBadSignature)
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, data, raw) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
@@ -0,0 +1,47 @@
+package patchdb
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	oldPub, oldPriv, _ := ed25519.GenerateKey(nil)
+	newPub, newPriv, _ := ed25519.GenerateKey(nil)
+	data := []byte(testCatalogue)
+	sign := func(key ed25519.PrivateKey, data []byte) []byte {
+		return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+	}
+
+	keys, err := ParseKeys("# current\n" + base64.StdEncoding.EncodeToString(newPub) +
+		"\n\n  " + base64.StdEncoding.EncodeToString(oldPub) + "  \n")
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("ParseKeys() = %v, %v", keys, err)
+	}
+	for _, tt := range []struct {
+		name string
+		data []byte
+		sig  []byte
+		want error
+	}{
+		{"current key", data, sign(newPriv, data), nil},
+		{"previous key", data, sign(oldPriv, data), nil},
+		{"unsigned", data, nil, ErrUnsigned},
+		{"tampered", append([]byte(" "), data...), sign(newPriv, data), ErrBadSignature},
+		{"malformed", data, []byte("not a signature"), ErrBadSignature},
+	} {
+		if err := Verify(tt.data, tt.sig, keys); !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+			t.Errorf("%s: Verify() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	_, stranger, _ := ed25519.GenerateKey(nil)
+	if err := Verify(data, sign(stranger, data), keys[:1]); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify() with an unknown key = %v", err)
+	}
+	if _, err := ParseKeys("dGVzdA=="); err == nil {
+		t.Error("ParseKeys() accepted a key of the wrong length")
+	}
+}
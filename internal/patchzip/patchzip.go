@@ -0,0 +1,172 @@
+// Package patchzip reads patch archives: the NPK files in them, the readme
+// explaining them, and extracting the NPK files to install.
+package patchzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/zipcrypt"
+)
+
+// maxReadme is how much of a readme is read.
+const maxReadme = 64 << 10
+
+// Entry is an NPK file in a patch archive.
+type Entry struct {
+	File      *zip.File
+	Name      string // base name, which it is installed under
+	Size      int64  // uncompressed
+	Encrypted bool
+}
+
+// Entries returns the NPK files in r, in the order they are stored.
+func Entries(r *zip.Reader) []Entry {
+	var entries []Entry
+	for _, f := range r.File {
+		name := Name(f)
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(name), ".npk") {
+			continue
+		}
+		entries = append(entries, Entry{
+			File:      f,
+			Name:      name,
+			Size:      int64(f.UncompressedSize64),
+			Encrypted: zipcrypt.Encrypted(f),
+		})
+	}
+	return entries
+}
+
+// Name returns the base name of f. Names not in UTF-8 are taken to be in
+// GBK, which archivers on Chinese Windows use. Only the base name is used so
+// entries cannot point outside the folder they are extracted to.
+func Name(f *zip.File) string {
+	name := f.Name
+	if f.NonUTF8 {
+		if decoded, err := simplifiedchinese.GBK.NewDecoder().String(name); err == nil {
+			name = decoded
+		}
+	}
+	return path.Base(strings.ReplaceAll(name, "\\", "/"))
+}
+
+// Readme returns the text of the readme of r: readme.txt or another .txt
+// file whose name starts with readme or contains 说明, the one nearest the
+// top if there are several. An encrypted readme is read with the first of
+// passwords that opens it. It returns "" when there is no readme that can be
+// read.
+func Readme(r *zip.Reader, passwords []string) string {
+	var readme *zip.File
+	depth := 0
+	for _, f := range r.File {
+		name := strings.ToLower(Name(f))
+		if f.FileInfo().IsDir() || path.Ext(name) != ".txt" ||
+			!(strings.HasPrefix(name, "readme") || strings.Contains(name, "说明")) {
+			continue
+		}
+		if d := strings.Count(f.Name, "/"); readme == nil || d < depth {
+			readme, depth = f, d
+		}
+	}
+	if readme == nil {
+		return ""
+	}
+
+	if !zipcrypt.Encrypted(readme) {
+		passwords = []string{""}
+	}
+	for _, pw := range passwords {
+		rc, err := zipcrypt.Open(readme, pw)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, maxReadme))
+		rc.Close()
+		if err == nil {
+			return Decode(data)
+		}
+	}
+	return ""
+}
+
+// Decode returns text in UTF-8. UTF-8 and UTF-16 with a byte order mark
+// are recognised, as is UTF-8 without one; anything else is taken to be
+// GBK.
+func Decode(text []byte) string {
+	switch {
+	case bytes.HasPrefix(text, []byte{0xEF, 0xBB, 0xBF}):
+		text = text[3:]
+	case bytes.HasPrefix(text, []byte{0xFF, 0xFE}), bytes.HasPrefix(text, []byte{0xFE, 0xFF}):
+		bigEndian := text[0] == 0xFE
+		units := make([]uint16, (len(text)-2)/2)
+		for i := range units {
+			hi, lo := text[2+2*i+1], text[2+2*i]
+			if bigEndian {
+				hi, lo = lo, hi
+			}
+			units[i] = uint16(hi)<<8 | uint16(lo)
+		}
+		return normalizeLines(string(utf16.Decode(units)))
+	case !utf8.Valid(text):
+		if decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(text); err == nil {
+			text = decoded
+		}
+	}
+	return normalizeLines(strings.ToValidUTF8(string(text), "�"))
+}
+
+// normalizeLines turns Windows line endings into plain newlines.
+func normalizeLines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// Extract writes e to a new file in dir, decrypting it with password when
+// it is encrypted, and checks that the result is an NPK file. It returns the
+// path of the file, which the caller removes. Nothing is left behind when it
+// fails.
+func Extract(ctx context.Context, e Entry, password, dir string) (string, error) {
+	rc, err := zipcrypt.Open(e.File, password)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	f, err := os.CreateTemp(fsutil.LongPath(dir), "*.npk.tmp")
+	if err != nil {
+		return "", err
+	}
+	fail := func(err error) (string, error) {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	size, err := fsutil.Copy(ctx, f, rc, nil)
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := npk.Read(f, size); err != nil {
+		if errors.Is(err, npk.ErrNotNPK) {
+			return fail(fmt.Errorf("%s is not an NPK file", e.Name))
+		}
+		return fail(fmt.Errorf("%s: %w", e.Name, err))
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,112 @@
+package patchzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// emptyNPK is an NPK file without entries.
+var emptyNPK = "NeoplePack_Bill\x00\x00\x00\x00\x00" + strings.Repeat("\x00", 32)
+
+// gbk encodes s in GBK.
+func gbk(t *testing.T, s string) string {
+	t.Helper()
+	encoded, err := simplifiedchinese.GBK.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+// archive builds a ZIP of files, name and contents in turn. Names that are
+// not valid UTF-8 are stored without the UTF-8 flag.
+func archive(t *testing.T, files ...string) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: files[i], Method: zip.Deflate, NonUTF8: !utf8.ValidString(files[i])})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[i+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestEntries(t *testing.T) {
+	r := archive(t,
+		"variants/", "",
+		"variants/红色版.npk", emptyNPK,
+		gbk(t, "蓝色版.NPK"), emptyNPK,
+		"readme.txt", "pick one",
+	)
+	entries := Entries(r)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "红色版.npk,蓝色版.NPK" {
+		t.Fatalf("Entries() = %s, want both variants with their names decoded", got)
+	}
+	if entries[0].Size != int64(len(emptyNPK)) || entries[0].Encrypted {
+		t.Errorf("Entries()[0] = %+v, want the uncompressed size and not encrypted", entries[0])
+	}
+}
+
+func TestReadme(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"none", []string{"ui.npk", emptyNPK}, ""},
+		{"top", []string{"docs/README.txt", "deep", "ReadMe.txt", "top\r\nline"}, "top\nline"},
+		{"chinese", []string{gbk(t, "安装说明.txt"), gbk(t, "红色版更亮")}, "红色版更亮"},
+		{"utf16", []string{"readme.txt", "\xff\xfeh\x00i\x00"}, "hi"},
+		{"not text", []string{"readme.md", "# no"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Readme(archive(t, tt.files...), nil); got != tt.want {
+				t.Errorf("Readme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	r := archive(t, "good.npk", emptyNPK, "bad.npk", "PK not really")
+	entries := Entries(r)
+
+	path, err := Extract(context.Background(), entries[0], "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != emptyNPK {
+		t.Errorf("Extract() wrote %q, want the entry", data)
+	}
+	os.Remove(path)
+
+	if _, err := Extract(context.Background(), entries[1], "", dir); err == nil || !strings.Contains(err.Error(), "bad.npk is not an NPK file") {
+		t.Errorf("Extract() of a file that is not an NPK = %v", err)
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("Extract() left %d files behind", len(left))
+	}
+}
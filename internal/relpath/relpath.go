@@ -0,0 +1,61 @@
+// Package relpath holds paths relative to the game folder in a canonical
+// form, so that the spellings Windows treats as the same file, such as
+// ImagePacks2\a.NPK and imagepacks2/A.npk, compare equal.
+package relpath
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/gamepath"
+)
+
+// Path is a relative path in canonical form: cleaned, in lower case and with
+// forward slashes. The empty Path stands for the folder itself.
+type Path string
+
+// Canonical returns p, written with either kind of slash, in canonical form.
+func Canonical(p string) Path {
+	p = path.Clean(strings.ReplaceAll(p, `\`, "/"))
+	if p == "." {
+		return ""
+	}
+	return Path(strings.ToLower(p))
+}
+
+// Rel returns the path of target relative to base in canonical form.
+func Rel(base, target string) (Path, error) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", err
+	}
+	return Canonical(rel), nil
+}
+
+// Base returns the last element of p.
+func (p Path) Base() string {
+	return path.Base(string(p))
+}
+
+// In returns p below dir using the casing found on disk for every element
+// that exists, so it names the same file on case-sensitive file systems such
+// as the ext4 of a Wine prefix.
+func (p Path) In(dir string) string {
+	if p == "" {
+		return dir
+	}
+	return gamepath.JoinFold(dir, strings.Split(string(p), "/")...)
+}
+
+// UnmarshalJSON reads p from a JSON string, bringing paths saved before they
+// were canonical into canonical form.
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*p = Canonical(s)
+	return nil
+}
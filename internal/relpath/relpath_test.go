@@ -0,0 +1,57 @@
+package relpath
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]Path{
+		`ImagePacks2\sprite_interface.NPK`: "imagepacks2/sprite_interface.npk",
+		"imagepacks2/Sprite_Interface.npk": "imagepacks2/sprite_interface.npk",
+		"./ImagePacks2//sounds/../a.NPK":   "imagepacks2/a.npk",
+		`ImagePack2\Sounds\`:               "imagepack2/sounds",
+		"":                                 "",
+		".":                                "",
+	}
+	for in, want := range tests {
+		if got := Canonical(in); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestUnmarshalMigratesOldPaths(t *testing.T) {
+	var files []struct {
+		Path Path `json:"path"`
+	}
+	data := `[{"path": "ImagePacks2\\a.NPK"}, {"path": "imagepacks2/A.npk"}]`
+	if err := json.Unmarshal([]byte(data), &files); err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Path != "imagepacks2/a.npk" || files[0].Path != files[1].Path {
+		t.Errorf("paths = %q, %q, want both canonical and equal", files[0].Path, files[1].Path)
+	}
+}
+
+func TestIn(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ImagePacks2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ImagePacks2", "Sprite.NPK"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := Canonical("imagepacks2/sprite.npk").In(dir), filepath.Join(dir, "ImagePacks2", "Sprite.NPK"); got != want {
+		t.Errorf("In() = %s, want the casing on disk %s", got, want)
+	}
+	if got, want := Canonical("imagepacks2/new.npk").In(dir), filepath.Join(dir, "ImagePacks2", "new.npk"); got != want {
+		t.Errorf("In() of a missing file = %s, want %s", got, want)
+	}
+	if got := Path("").In(dir); got != dir {
+		t.Errorf("In() of the empty path = %s", got)
+	}
+}
@@ -0,0 +1,169 @@
+// Package report writes the list of installed patches of a game folder as a
+// document to share when asking for help.
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Patch is an installed patch.
+type Patch struct {
+	Name        string
+	Version     string
+	Author      string
+	Filename    string
+	Hash        string
+	InstalledAt time.Time
+}
+
+// File is an NPK file no installed patch accounts for, added or changed by
+// hand or by another tool.
+type File struct {
+	Name string
+	Size int64
+	Hash string
+}
+
+// Report describes the patches of a game folder.
+type Report struct {
+	AppVersion  string
+	GameDir     string
+	GameVersion string // "" when unknown
+	Created     time.Time
+	Patches     []Patch
+	Unknown     []File
+	// UnknownError says why the unknown files could not be found, in which
+	// case Unknown is empty.
+	UnknownError string
+}
+
+// Format is a kind of document a report is written as.
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	Text     Format = "text"
+)
+
+// Extension returns the file name extension for documents in format f.
+func (f Format) Extension() string {
+	if f == Markdown {
+		return ".md"
+	}
+	return ".txt"
+}
+
+const dateLayout = "2006-01-02 15:04"
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format(dateLayout)
+}
+
+// Write writes r to w as a document in format f.
+func (r Report) Write(w io.Writer, f Format) error {
+	if f == Markdown {
+		return r.WriteMarkdown(w)
+	}
+	return r.WriteText(w)
+}
+
+// cell escapes s for a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// code formats s as inline code in a Markdown table cell.
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+}
+
+// WriteMarkdown writes r to w as Markdown, with a table for the installed
+// patches and one for the unknown files.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# DNF Patch report\n\n")
+	fmt.Fprintf(b, "- DNF Patch: %s\n", orUnknown(r.AppVersion))
+	fmt.Fprintf(b, "- Game path: %s\n", code(r.GameDir))
+	fmt.Fprintf(b, "- Game version: %s\n", orUnknown(r.GameVersion))
+	fmt.Fprintf(b, "- Created: %s\n", date(r.Created))
+
+	fmt.Fprintf(b, "\n## Installed patches (%d)\n\n", len(r.Patches))
+	if len(r.Patches) == 0 {
+		fmt.Fprintf(b, "None.\n")
+	} else {
+		fmt.Fprintf(b, "| Name | Version | Author | File | SHA-256 | Installed |\n")
+		fmt.Fprintf(b, "| --- | --- | --- | --- | --- | --- |\n")
+		for _, p := range r.Patches {
+			fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s |\n",
+				cell(p.Name), cell(p.Version), cell(p.Author), code(p.Filename), code(p.Hash), date(p.InstalledAt))
+		}
+	}
+
+	fmt.Fprintf(b, "\n## Unknown files in imagepack2 (%d)\n\n", len(r.Unknown))
+	switch {
+	case r.UnknownError != "":
+		fmt.Fprintf(b, "Not checked: %s\n", cell(r.UnknownError))
+	case len(r.Unknown) == 0:
+		fmt.Fprintf(b, "None.\n")
+	default:
+		fmt.Fprintf(b, "| File | Size | SHA-256 |\n")
+		fmt.Fprintf(b, "| --- | --- | --- |\n")
+		for _, f := range r.Unknown {
+			fmt.Fprintf(b, "| %s | %d | %s |\n", code(f.Name), f.Size, code(f.Hash))
+		}
+	}
+	return b.Flush()
+}
+
+// WriteText writes r to w as plain text for places that do not render
+// Markdown.
+func (r Report) WriteText(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "DNF Patch report\n\n")
+	fmt.Fprintf(b, "DNF Patch:    %s\n", orUnknown(r.AppVersion))
+	fmt.Fprintf(b, "Game path:    %s\n", r.GameDir)
+	fmt.Fprintf(b, "Game version: %s\n", orUnknown(r.GameVersion))
+	fmt.Fprintf(b, "Created:      %s\n", date(r.Created))
+
+	fmt.Fprintf(b, "\nInstalled patches (%d):\n", len(r.Patches))
+	if len(r.Patches) == 0 {
+		fmt.Fprintf(b, "  None\n")
+	}
+	for _, p := range r.Patches {
+		fmt.Fprintf(b, "- %s %s by %s\n", p.Name, orUnknown(p.Version), orUnknown(p.Author))
+		fmt.Fprintf(b, "    File:      %s\n", p.Filename)
+		fmt.Fprintf(b, "    SHA-256:   %s\n", orUnknown(p.Hash))
+		fmt.Fprintf(b, "    Installed: %s\n", date(p.InstalledAt))
+	}
+
+	fmt.Fprintf(b, "\nUnknown files in imagepack2 (%d):\n", len(r.Unknown))
+	switch {
+	case r.UnknownError != "":
+		fmt.Fprintf(b, "  Not checked: %s\n", r.UnknownError)
+	case len(r.Unknown) == 0:
+		fmt.Fprintf(b, "  None\n")
+	}
+	for _, f := range r.Unknown {
+		fmt.Fprintf(b, "- %s (%d bytes)\n", f.Name, f.Size)
+		fmt.Fprintf(b, "    SHA-256:   %s\n", orUnknown(f.Hash))
+	}
+	return b.Flush()
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReport() Report {
+	installed := time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local)
+	return Report{
+		AppVersion:  "1.2.0",
+		GameDir:     `C:\Games\DNF`,
+		GameVersion: "2024.05",
+		Created:     installed,
+		Patches: []Patch{
+			{Name: "Clear | Skills", Version: "1.0", Author: "someone", Filename: "sprite_skill.npk", Hash: "abc", InstalledAt: installed},
+			{Name: "Old patch", Filename: "sprite_old.npk"},
+		},
+		Unknown: []File{{Name: "extra.npk", Size: 42, Hash: "def"}},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := testReport().Write(&b, Markdown); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"- Game path: `C:\\Games\\DNF`\n",
+		"- Game version: 2024.05\n",
+		"## Installed patches (2)\n",
+		"| Clear \\| Skills | 1.0 | someone | `sprite_skill.npk` | `abc` | 2024-05-01 12:30 |\n",
+		"| Old patch |  |  | `sprite_old.npk` |  | unknown |\n",
+		"## Unknown files in imagepack2 (1)\n",
+		"| `extra.npk` | 42 | `def` |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	r := testReport()
+	r.Unknown, r.UnknownError = nil, "no vanilla hashes"
+	var b bytes.Buffer
+	if err := r.Write(&b, Text); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"Game path:    C:\\Games\\DNF\n",
+		"- Clear | Skills 1.0 by someone\n",
+		"    File:      sprite_skill.npk\n",
+		"- Old patch unknown by unknown\n",
+		"    Installed: unknown\n",
+		"Unknown files in imagepack2 (0):\n  Not checked: no vanilla hashes\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package schema versions the JSON files DNF Patch keeps, upgrading files
+// written by older versions one version at a time and refusing files written
+// by newer ones rather than dropping the fields it does not know.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// Key is the field of the top-level object holding the version of a file.
+const Key = "schemaVersion"
+
+// Migration upgrades a document by one version.
+type Migration func(doc []byte) ([]byte, error)
+
+// File describes a versioned JSON file.
+type File struct {
+	// Version is the version this build writes.
+	Version int
+	// Migrations[v] upgrades a document of version v to v+1, so there is one
+	// for every version before Version. Version 0 stands for the files
+	// written before they were versioned, which may be top-level arrays.
+	Migrations []Migration
+}
+
+// NewerError is returned for a file written by a newer version of DNF Patch.
+// The file is left as it is, and should not be saved over.
+type NewerError struct {
+	Path      string
+	Version   int // of the file
+	Supported int // the newest this build reads
+}
+
+func (e *NewerError) Error() string {
+	return fmt.Sprintf("%s was saved by a newer version of DNF Patch (format %d, this version reads up to %d); update DNF Patch to use it, it is left untouched until then",
+		e.Path, e.Version, e.Supported)
+}
+
+// BackupPath returns where Read keeps the copy of the file at path from
+// before it was upgraded.
+func BackupPath(path string) string {
+	return path + ".pre-migrate"
+}
+
+// Read reads the file at path and brings it to f.Version. A file of an older
+// version is copied to BackupPath, upgraded and written back before it is
+// returned; one of a newer version is refused with a *NewerError. Errors
+// reading the file are returned as they are, so os.IsNotExist applies.
+func (f File) Read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, changed, err := f.Upgrade(data)
+	if err != nil {
+		if newer, ok := err.(*NewerError); ok {
+			newer.Path = path
+		}
+		return nil, err
+	}
+	if !changed {
+		return data, nil
+	}
+	if err := fsutil.WriteFileAtomic(BackupPath(path), data, 0644); err != nil {
+		return nil, fmt.Errorf("keeping %s before upgrading it: %w", path, err)
+	}
+	if err := fsutil.WriteFileAtomic(path, upgraded, 0644); err != nil {
+		return nil, fmt.Errorf("upgrading %s: %w", path, err)
+	}
+	return upgraded, nil
+}
+
+// Upgrade brings doc to f.Version, reporting whether it had to be changed.
+func (f File) Upgrade(doc []byte) ([]byte, bool, error) {
+	v, err := Version(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	if v > f.Version {
+		return nil, false, &NewerError{Version: v, Supported: f.Version}
+	}
+	if v == f.Version {
+		return doc, false, nil
+	}
+	for ; v < f.Version; v++ {
+		if doc, err = f.Migrations[v](doc); err != nil {
+			return nil, false, fmt.Errorf("upgrading from format %d: %w", v, err)
+		}
+	}
+	doc, err = stamp(doc, f.Version)
+	return doc, true, err
+}
+
+// Version returns the version of doc, 0 when it has none.
+func Version(doc []byte) (int, error) {
+	doc = bytes.TrimSpace(doc)
+	if len(doc) == 0 || doc[0] != '{' {
+		return 0, nil
+	}
+	var header struct {
+		Version int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(doc, &header); err != nil {
+		return 0, err
+	}
+	return header.Version, nil
+}
+
+// stamp sets the version of the object doc.
+func stamp(doc []byte, version int) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = make(map[string]json.RawMessage)
+	}
+	fields[Key] = json.RawMessage(fmt.Sprint(version))
+	return json.MarshalIndent(fields, "", "    ")
+}
+
+// Wrap returns a migration moving a top-level array into the field key of an
+// object, the usual first step for the files that used to be arrays.
+func Wrap(key string) Migration {
+	return func(doc []byte) ([]byte, error) {
+		var items json.RawMessage = bytes.TrimSpace(doc)
+		if len(items) == 0 || items[0] != '[' {
+			return nil, fmt.Errorf("expected a list, found %.20q", items)
+		}
+		return json.Marshal(map[string]json.RawMessage{key: items})
+	}
+}
+
+// Unchanged is the migration of a version that only added fields.
+func Unchanged(doc []byte) ([]byte, error) {
+	return doc, nil
+}
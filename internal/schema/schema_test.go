@@ -0,0 +1,91 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testFile has been an array, then an object, then renamed a field.
+var testFile = File{
+	Version: 2,
+	Migrations: []Migration{
+		Wrap("items"),
+		func(doc []byte) ([]byte, error) {
+			return []byte(strings.Replace(string(doc), `"items"`, `"entries"`, 1)), nil
+		},
+	},
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"unversioned", `[1, 2]`, `{"entries":[1,2],"schemaVersion":2}`},
+		{"version 1", `{"schemaVersion": 1, "items": [1, 2]}`, `{"entries":[1,2],"schemaVersion":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.json")
+			if err := os.WriteFile(path, []byte(tt.doc), 0644); err != nil {
+				t.Fatal(err)
+			}
+			data, err := testFile.Read(path)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if got := strings.Join(strings.Fields(string(data)), ""); got != tt.want {
+				t.Errorf("Read() = %s, want %s", got, tt.want)
+			}
+			if saved, _ := os.ReadFile(path); string(saved) != string(data) {
+				t.Errorf("file = %s, want the upgraded document written back", saved)
+			}
+			if kept, _ := os.ReadFile(BackupPath(path)); string(kept) != tt.doc {
+				t.Errorf("%s = %s, want the original %s", BackupPath(path), kept, tt.doc)
+			}
+		})
+	}
+}
+
+func TestReadCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.json")
+	doc := `{"schemaVersion": 2, "entries": []}`
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := testFile.Read(path); err != nil || string(data) != doc {
+		t.Errorf("Read() = %s, %v, want the file as it is", data, err)
+	}
+	if _, err := os.Stat(BackupPath(path)); !os.IsNotExist(err) {
+		t.Errorf("current file was copied to %s", BackupPath(path))
+	}
+	if _, err := testFile.Read(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("Read() of a missing file error = %v, want it not to exist", err)
+	}
+}
+
+func TestReadNewer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.json")
+	doc := `{"schemaVersion": 3, "entries": [], "added": true}`
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := testFile.Read(path)
+	var newer *NewerError
+	if !errors.As(err, &newer) || newer.Path != path || newer.Version != 3 || newer.Supported != 2 {
+		t.Fatalf("Read() error = %#v, want a NewerError for version 3", err)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != doc {
+		t.Errorf("newer file was changed to %s", saved)
+	}
+}
+
+func TestWrapRejectsObjects(t *testing.T) {
+	if _, err := Wrap("items")([]byte(`{"items": []}`)); err == nil {
+		t.Error("Wrap() accepted an object")
+	}
+}
@@ -0,0 +1,84 @@
+package vanilla
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/relpath"
+)
+
+// Step is a change Apply makes to bring one file back to the vanilla client.
+type Step struct {
+	Name     string // file name inside the NPK folder
+	Patch    string // the installed patch that wrote the file, if any
+	Original string // vanilla copy to put back; empty removes the file
+	Hash     string // expected hash of Original
+}
+
+// Plan works out the steps bringing the files in results back to m. find
+// returns the path of a saved copy of the named file with the given hash, or
+// "" when there is none. Files that cannot be brought back, because no copy
+// is found or because they were added by something other than an installed
+// patch, are returned as left.
+func Plan(results []Result, m Manifest, find func(name, hash string) string) (steps []Step, left []Result) {
+	expected := make(map[relpath.Path]string, len(m.Files))
+	for name, hash := range m.Files {
+		expected[relpath.Canonical(name)] = strings.ToLower(hash)
+	}
+
+	for _, r := range results {
+		if r.Status == Vanilla {
+			continue
+		}
+		hash, ok := expected[relpath.Canonical(r.Name)]
+		if !ok {
+			// Not part of the client: only files of installed patches are
+			// known to be safe to remove
+			if r.Status == Patched {
+				steps = append(steps, Step{Name: r.Name, Patch: r.Patch})
+			} else {
+				left = append(left, r)
+			}
+			continue
+		}
+		original := find(r.Name, hash)
+		if original == "" {
+			left = append(left, r)
+			continue
+		}
+		steps = append(steps, Step{Name: r.Name, Patch: r.Patch, Original: original, Hash: hash})
+	}
+	return steps, left
+}
+
+// Apply carries out steps in the NPK folder dir. Each original is checked
+// against its hash before it is copied. progress, which may be nil, is called
+// after each step.
+func Apply(dir string, steps []Step, progress func(done, total int)) error {
+	for i, step := range steps {
+		target := filepath.Join(dir, step.Name)
+		if step.Original == "" {
+			if err := os.Remove(fsutil.LongPath(target)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		} else {
+			hash, err := fsutil.HashFile(step.Original)
+			if err != nil {
+				return err
+			}
+			if !strings.EqualFold(hash, step.Hash) {
+				return fmt.Errorf("the saved copy of %s at %s is not the vanilla file", step.Name, step.Original)
+			}
+			if err := fsutil.CopyFile(step.Original, target); err != nil {
+				return err
+			}
+		}
+		if progress != nil {
+			progress(i+1, len(steps))
+		}
+	}
+	return nil
+}
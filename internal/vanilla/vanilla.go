@@ -0,0 +1,144 @@
+// Package vanilla checks the NPK files of a game against the hashes of an
+// unmodified client of the same version.
+package vanilla
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/relpath"
+)
+
+// Manifest lists the SHA-256 of every NPK file shipped with a game version,
+// keyed by the file name inside the NPK folder.
+type Manifest struct {
+	Version string            `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+// Parse decodes a vanilla hashes manifest.
+func Parse(data []byte) (Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, err
+	}
+	if len(m.Files) == 0 {
+		return Manifest{}, fmt.Errorf("the manifest lists no files")
+	}
+	return m, nil
+}
+
+// Load reads the manifest at path.
+func Load(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return Parse(data)
+}
+
+type Status string
+
+const (
+	Vanilla  Status = "vanilla"
+	Patched  Status = "patched"  // matches an installed patch
+	Modified Status = "modified" // changed or added by something unknown
+	Missing  Status = "missing"
+)
+
+// Result is the state of one file of the NPK folder.
+type Result struct {
+	Name   string
+	Status Status
+	Patch  string // the installed patch the file matches, for Patched
+}
+
+// Verify hashes the NPK files in dir and compares them against m and the
+// installed patches, stopping between files once ctx is cancelled.
+// progress, which may be nil, is called after each file. Results are sorted
+// by name.
+func Verify(ctx context.Context, dir string, m Manifest, installed []install.Record, progress func(done, total int)) ([]Result, error) {
+	entries, err := os.ReadDir(fsutil.LongPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	expected := make(map[relpath.Path]string, len(m.Files))
+	for name, hash := range m.Files {
+		expected[relpath.Canonical(name)] = strings.ToLower(hash)
+	}
+	patches := make(map[relpath.Path]install.Record, len(installed))
+	for _, rec := range installed {
+		patches[rec.Key()] = rec
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.EqualFold(filepath.Ext(entry.Name()), ".npk") {
+			files = append(files, entry.Name())
+		}
+	}
+
+	var results []Result
+	found := make(map[relpath.Path]bool, len(files))
+	for i, name := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		key := relpath.Canonical(name)
+		found[key] = true
+		hash, err := fsutil.HashFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		r := Result{Name: name, Status: Modified}
+		if rec, ok := patches[key]; ok && rec.Hash == hash {
+			r.Status, r.Patch = Patched, rec.PatchName
+		} else if want, ok := expected[key]; ok && want == hash {
+			r.Status = Vanilla
+		}
+		results = append(results, r)
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+
+	for name := range m.Files {
+		if !found[relpath.Canonical(name)] {
+			results = append(results, Result{Name: name, Status: Missing})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+	return results, nil
+}
+
+// Count returns how many results have each status.
+func Count(results []Result) map[Status]int {
+	counts := make(map[Status]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// WriteCSV writes results as CSV with a header row.
+func WriteCSV(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"file", "status", "patch"})
+	for _, r := range results {
+		cw.Write([]string{r.Name, string(r.Status), r.Patch})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,153 @@
+package vanilla
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dnf_patch/internal/install"
+)
+
+func hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sprite_interface.npk": "vanilla",
+		"Sprite_Skill.NPK":     "patched",
+		"sprite_map.npk":       "corrupted",
+		"extra.npk":            "added",
+		"notes.txt":            "not an NPK",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := Manifest{Version: "1.0", Files: map[string]string{
+		"sprite_interface.npk": strings.ToUpper(hash("vanilla")),
+		"sprite_skill.npk":     hash("original skills"),
+		"sprite_map.npk":       hash("original map"),
+		"sprite_sound.npk":     hash("sounds"),
+	}}
+	installed := []install.Record{{PatchName: "Clear Skills", Filename: "sprite_skill.npk", Hash: hash("patched")}}
+
+	var calls int
+	results, err := Verify(context.Background(), dir, m, installed, func(done, total int) {
+		calls++
+		if total != 4 {
+			t.Errorf("progress total = %d, want the 4 NPK files", total)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	want := []Result{
+		{Name: "extra.npk", Status: Modified},
+		{Name: "sprite_interface.npk", Status: Vanilla},
+		{Name: "sprite_map.npk", Status: Modified},
+		{Name: "Sprite_Skill.NPK", Status: Patched, Patch: "Clear Skills"},
+		{Name: "sprite_sound.npk", Status: Missing},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Verify() = %+v, want %+v", results, want)
+	}
+	if calls != 4 {
+		t.Errorf("progress called %d times, want 4", calls)
+	}
+	if got := Count(results); got[Modified] != 2 || got[Missing] != 1 {
+		t.Errorf("Count() = %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, results[3:4]); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "file,status,patch\nSprite_Skill.NPK,patched,Clear Skills\n" {
+		t.Errorf("WriteCSV() = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(`{"version": "1.0", "files": {"a.npk": "00"}}`))
+	if err != nil || m.Version != "1.0" || m.Files["a.npk"] != "00" {
+		t.Errorf("Parse() = %+v, %v", m, err)
+	}
+	for _, data := range []string{`{`, `{"version": "1.0"}`} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%s) succeeded", data)
+		}
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	dir, saved := t.TempDir(), t.TempDir()
+	for name, content := range map[string]string{
+		"sprite_skill.npk": "patched skills",
+		"sprite_map.npk":   "modified map",
+		"added.npk":        "added by a patch",
+		"unknown.npk":      "added by something else",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original := filepath.Join(saved, "sprite_skill.npk")
+	if err := os.WriteFile(original, []byte("original skills"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := Manifest{Files: map[string]string{
+		"sprite_skill.npk": hash("original skills"),
+		"sprite_map.npk":   hash("original map"),
+	}}
+	installed := []install.Record{
+		{PatchName: "Clear Skills", Filename: "sprite_skill.npk", Hash: hash("patched skills")},
+		{PatchName: "Extra", Filename: "added.npk", Hash: hash("added by a patch")},
+	}
+	results, err := Verify(context.Background(), dir, m, installed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, left := Plan(results, m, func(name, h string) string {
+		if name == "sprite_skill.npk" && h == hash("original skills") {
+			return original
+		}
+		return ""
+	})
+	wantSteps := []Step{
+		{Name: "added.npk", Patch: "Extra"},
+		{Name: "sprite_skill.npk", Patch: "Clear Skills", Original: original, Hash: hash("original skills")},
+	}
+	if !reflect.DeepEqual(steps, wantSteps) {
+		t.Errorf("Plan() steps = %+v, want %+v", steps, wantSteps)
+	}
+	if len(left) != 2 || left[0].Name != "sprite_map.npk" || left[1].Name != "unknown.npk" {
+		t.Errorf("Plan() left = %+v, want sprite_map.npk and unknown.npk", left)
+	}
+
+	if err := Apply(dir, steps, nil); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "sprite_skill.npk")); string(data) != "original skills" {
+		t.Errorf("sprite_skill.npk = %q after Apply, want the original", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "added.npk")); !os.IsNotExist(err) {
+		t.Errorf("added.npk still exists after Apply: %v", err)
+	}
+
+	// A copy that no longer matches is refused
+	os.WriteFile(original, []byte("damaged"), 0644)
+	if err := Apply(dir, steps[1:], nil); err == nil {
+		t.Error("Apply() with a damaged copy succeeded")
+	}
+}
@@ -0,0 +1,66 @@
+package watch
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// maxSeen bounds the hashes kept by Seen.
+const maxSeen = 1000
+
+// Seen is the set of hashes of the files a watcher handed over, stored as
+// JSON so files are not imported again after a restart. It is safe for
+// concurrent use.
+type Seen struct {
+	mu     sync.Mutex
+	path   string
+	hashes []string
+}
+
+// LoadSeen reads the set stored at path; a missing file is an empty set.
+func LoadSeen(path string) (*Seen, error) {
+	s := &Seen{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(data, &s.hashes)
+}
+
+// Has reports whether a file with the given hash was handed over.
+func (s *Seen) Has(hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range s.hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Add records hash, forgetting the oldest ones beyond maxSeen, and saves
+// the set.
+func (s *Seen) Add(hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes = append(s.hashes, hash)
+	if len(s.hashes) > maxSeen {
+		s.hashes = append([]string(nil), s.hashes[len(s.hashes)-maxSeen:]...)
+	}
+	data, err := json.Marshal(s.hashes)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(s.path, data, 0644)
+}
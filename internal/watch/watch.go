@@ -0,0 +1,191 @@
+// Package watch reports patch files dropped into a folder, such as the one a
+// browser downloads them to.
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"dnf_patch/internal/fsutil"
+)
+
+// Extensions lists the file types the watcher reports.
+var Extensions = []string{".npk", ".zip"}
+
+// IsPatchFile reports whether path has one of Extensions.
+func IsPatchFile(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range Extensions {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watcher reports patch files that appear in a folder once their size has
+// stopped changing, one at a time and never the same content twice. It keeps
+// trying while the folder is unavailable, for example on a disconnected
+// drive, and picks up the files that arrived in the meantime.
+type Watcher struct {
+	dir     string
+	seen    *Seen
+	onReady func(path string)
+	onError func(err error)
+	since   time.Time
+	settle  time.Duration // how long a file must stay unchanged
+	retry   time.Duration // wait before watching an unavailable folder again
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// fileState is the size and modification time of a file last time it was
+// looked at.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	checked bool
+}
+
+// New returns a watcher of dir recording handed over files in seen. onReady
+// is called from the watcher goroutine with each new file; onError, which
+// may be nil, when the folder can no longer be watched. Files older than the
+// watcher are ignored.
+func New(dir string, seen *Seen, onReady func(path string), onError func(err error)) *Watcher {
+	return &Watcher{
+		dir:     dir,
+		seen:    seen,
+		onReady: onReady,
+		onError: onError,
+		since:   time.Now(),
+		settle:  time.Second,
+		retry:   5 * time.Second,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start watches the folder in the background until Stop is called.
+func (w *Watcher) Start() {
+	go w.run()
+}
+
+// Stop stops the watcher and waits for it to finish handing over a file.
+func (w *Watcher) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	var lastErr string
+	for {
+		err := w.watch()
+		if err != nil && err.Error() != lastErr && w.onError != nil {
+			w.onError(err)
+		}
+		if err != nil {
+			lastErr = err.Error()
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.retry):
+		}
+	}
+}
+
+// watch watches the folder until it becomes unavailable or the watcher is
+// stopped.
+func (w *Watcher) watch() error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	if err := fw.Add(w.dir); err != nil {
+		return fmt.Errorf("watching %s: %w", w.dir, err)
+	}
+
+	pending := make(map[string]fileState)
+	w.scan(pending)
+	ticker := time.NewTicker(w.settle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return nil
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return errors.New("the folder watcher was closed")
+			}
+			if filepath.Clean(ev.Name) == filepath.Clean(w.dir) && ev.Has(fsnotify.Remove|fsnotify.Rename) {
+				return fmt.Errorf("%s was removed", w.dir)
+			}
+			if (ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write)) && IsPatchFile(ev.Name) {
+				pending[ev.Name] = fileState{}
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return errors.New("the folder watcher was closed")
+			}
+			return err
+		case <-ticker.C:
+			if _, err := os.Stat(fsutil.LongPath(w.dir)); err != nil {
+				return err
+			}
+			w.check(pending)
+		}
+	}
+}
+
+// scan adds the patch files that appeared since the watcher was created to
+// pending, covering the time the folder was not watched.
+func (w *Watcher) scan(pending map[string]fileState) {
+	entries, err := os.ReadDir(fsutil.LongPath(w.dir))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && info.Mode().IsRegular() && IsPatchFile(entry.Name()) && info.ModTime().After(w.since) {
+			pending[filepath.Join(w.dir, entry.Name())] = fileState{}
+		}
+	}
+}
+
+// check hands over the pending files that did not change since the last
+// tick.
+func (w *Watcher) check(pending map[string]fileState) {
+	for path, last := range pending {
+		info, err := os.Stat(fsutil.LongPath(path))
+		if err != nil {
+			// Renamed or deleted, e.g. a finished browser download
+			delete(pending, path)
+			continue
+		}
+		now := fileState{size: info.Size(), modTime: info.ModTime(), checked: true}
+		if !last.checked || now != last {
+			pending[path] = now
+			continue
+		}
+
+		hash, err := fsutil.HashFile(path)
+		if err != nil {
+			// Still held by the program writing it
+			continue
+		}
+		delete(pending, path)
+		if w.seen.Has(hash) {
+			continue
+		}
+		w.seen.Add(hash)
+		w.onReady(path)
+	}
+}
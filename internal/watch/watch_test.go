@@ -0,0 +1,106 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newWatcher(t *testing.T, dir string) (*Watcher, chan string) {
+	t.Helper()
+	seen, err := LoadSeen(filepath.Join(t.TempDir(), "seen.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready := make(chan string, 10)
+	w := New(dir, seen, func(path string) { ready <- filepath.Base(path) }, nil)
+	w.settle = 20 * time.Millisecond
+	w.retry = 20 * time.Millisecond
+	w.Start()
+	t.Cleanup(w.Stop)
+	return w, ready
+}
+
+func expect(t *testing.T, ready chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-ready:
+		if got != want {
+			t.Errorf("handed over %s, want %s", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s was not handed over", want)
+	}
+}
+
+func expectNothing(t *testing.T, ready chan string) {
+	t.Helper()
+	select {
+	case got := <-ready:
+		t.Errorf("handed over %s, want nothing", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "old.npk"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	_, ready := newWatcher(t, dir)
+	time.Sleep(50 * time.Millisecond)
+
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a patch"), 0644)
+	if err := os.WriteFile(filepath.Join(dir, "skills.npk"), []byte("skills"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, ready, "skills.npk")
+
+	// The same content under another name is not imported again
+	os.WriteFile(filepath.Join(dir, "skills (1).npk"), []byte("skills"), 0644)
+	os.WriteFile(filepath.Join(dir, "pack.ZIP"), []byte("zip"), 0644)
+	expect(t, ready, "pack.ZIP")
+	expectNothing(t, ready)
+}
+
+func TestWatcherSurvivesMissingFolder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Downloads")
+	_, ready := newWatcher(t, dir)
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.npk"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, ready, "a.npk")
+
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	os.Mkdir(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "b.npk"), []byte("b"), 0644)
+	expect(t, ready, "b.npk")
+}
+
+func TestSeenPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.json")
+	seen, err := LoadSeen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := seen.Add("abc"); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadSeen(path)
+	if err != nil {
+		t.Fatalf("LoadSeen() error = %v", err)
+	}
+	if !reloaded.Has("abc") || reloaded.Has("def") {
+		t.Error("reloaded set does not match what was added")
+	}
+}
@@ -0,0 +1,173 @@
+// Package whatsnew finds the patches added to the catalogue, or given a new
+// version, since the user last cleared the list. The versions of the patches
+// are recorded at each refresh of the catalogue and compared with the ones
+// recorded at the refresh before.
+package whatsnew
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/schema"
+)
+
+// feedFile is the version of the file written by this build.
+var feedFile = schema.File{
+	Version:    1,
+	Migrations: []schema.Migration{schema.Unchanged},
+}
+
+// Change is a patch added to the catalogue or given a new version.
+type Change struct {
+	PatchID  string    `json:"patchId"`
+	Version  string    `json:"version"`
+	Previous string    `json:"previous,omitempty"` // the version before, "" for a patch added
+	Found    time.Time `json:"found"`              // the refresh that found it
+}
+
+// Added reports whether the patch was added rather than updated.
+func (c Change) Added() bool {
+	return c.Previous == ""
+}
+
+// feed is the file of a Feed.
+type feed struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	Taken         time.Time         `json:"taken"`    // when Versions were recorded, zero before the first refresh
+	Versions      map[string]string `json:"versions"` // patch ID to version
+	Changes       []Change          `json:"changes"`  // oldest first
+}
+
+// Feed is the changes to the catalogue not cleared yet, kept in a JSON file.
+// It is safe for concurrent use.
+type Feed struct {
+	mu   sync.Mutex
+	path string
+	f    feed
+	err  error // why the file could not be read, which keeps it from being saved over
+}
+
+// New returns an empty feed kept at path.
+func New(path string) *Feed {
+	return &Feed{path: path}
+}
+
+// Load reads the feed. A missing file is an empty feed. A file that cannot
+// be read is not saved over.
+func (f *Feed) Load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.f, f.err = feed{}, nil
+	data, err := feedFile.Read(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &f.f)
+	}
+	f.err = err
+	return err
+}
+
+// Update compares versions, the patch IDs in the catalogue with their
+// versions, with those of the refresh before and adds the patches added and
+// updated since then. It returns the changes found. The first refresh only
+// records the versions, as everything would be new otherwise. A patch
+// updated twice keeps the version from before the first update, and one
+// taken out of the catalogue is dropped from the changes.
+func (f *Feed) Update(versions map[string]string, now time.Time) ([]Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var found []Change
+	if !f.f.Taken.IsZero() {
+		earlier := make(map[string]Change)
+		for _, c := range f.f.Changes {
+			earlier[c.PatchID] = c
+		}
+		var changes []Change
+		for _, c := range f.f.Changes {
+			if _, ok := versions[c.PatchID]; ok {
+				changes = append(changes, c)
+			}
+		}
+		ids := make([]string, 0, len(versions))
+		for id := range versions {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			version := versions[id]
+			old, ok := f.f.Versions[id]
+			if ok && old == version {
+				continue
+			}
+			c := Change{PatchID: id, Version: version, Previous: old, Found: now}
+			if e, ok := earlier[id]; ok {
+				c.Previous = e.Previous
+				changes = removeChange(changes, id)
+				if !c.Added() && c.Previous == version {
+					// Back to the version before, so nothing changed
+					continue
+				}
+			}
+			changes = append(changes, c)
+			found = append(found, c)
+		}
+		f.f.Changes = changes
+	}
+	f.f.Versions = versions
+	f.f.Taken = now
+	return found, f.save()
+}
+
+// removeChange returns changes without the one of the patch with the given
+// ID.
+func removeChange(changes []Change, id string) []Change {
+	for i, c := range changes {
+		if c.PatchID == id {
+			return append(changes[:i:i], changes[i+1:]...)
+		}
+	}
+	return changes
+}
+
+// Changes returns the changes not cleared yet, oldest first.
+func (f *Feed) Changes() []Change {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Change(nil), f.f.Changes...)
+}
+
+// Clear drops the changes, keeping the versions to compare the next refresh
+// with.
+func (f *Feed) Clear() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.f.Changes = nil
+	return f.save()
+}
+
+// save writes the feed. The caller must hold f.mu.
+func (f *Feed) save() error {
+	f.f.SchemaVersion = feedFile.Version
+	data, err := json.MarshalIndent(f.f, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(f.path, data, 0644)
+}
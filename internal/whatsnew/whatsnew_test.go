@@ -0,0 +1,82 @@
+package whatsnew
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whatsnew.json")
+	f := New(path)
+	if err := f.Load(); err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	update := func(versions map[string]string, now time.Time) []Change {
+		t.Helper()
+		found, err := f.Update(versions, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	if found := update(map[string]string{"dark-ui": "1.0", "big-font": "2.0"}, day(1)); len(found) != 0 {
+		t.Errorf("first Update() = %v, want nothing new", found)
+	}
+	found := update(map[string]string{"dark-ui": "1.1", "big-font": "2.0", "clear-skills": "1.0"}, day(2))
+	want := []Change{
+		{PatchID: "clear-skills", Version: "1.0", Found: day(2)},
+		{PatchID: "dark-ui", Version: "1.1", Previous: "1.0", Found: day(2)},
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Update() = %+v, want %+v", found, want)
+	}
+
+	// Kept until cleared, across restarts
+	f = New(path)
+	if err := f.Load(); err != nil {
+		t.Fatal(err)
+	}
+	update(map[string]string{"dark-ui": "1.2", "big-font": "2.0"}, day(3))
+	want = []Change{{PatchID: "dark-ui", Version: "1.2", Previous: "1.0", Found: day(3)}}
+	if got := f.Changes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() after a second update and a removal = %+v, want %+v", got, want)
+	}
+	update(map[string]string{"dark-ui": "1.0", "big-font": "2.0"}, day(4))
+	if got := f.Changes(); len(got) != 0 {
+		t.Errorf("Changes() after going back to the old version = %+v, want none", got)
+	}
+
+	update(map[string]string{"dark-ui": "1.0", "big-font": "2.1"}, day(5))
+	if err := f.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Changes(); len(got) != 0 {
+		t.Errorf("Changes() after Clear() = %+v", got)
+	}
+	if found := update(map[string]string{"dark-ui": "1.0", "big-font": "2.1"}, day(6)); len(found) != 0 {
+		t.Errorf("Update() after Clear() = %+v, want the versions kept", found)
+	}
+}
+
+func TestFeedNewer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whatsnew.json")
+	data := []byte(`{"schemaVersion": 99, "changes": []}`)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := New(path)
+	if err := f.Load(); err == nil {
+		t.Fatal("Load() of a newer file succeeded")
+	}
+	if _, err := f.Update(map[string]string{"dark-ui": "1.0"}, time.Now()); err == nil {
+		t.Error("Update() saved over a newer file")
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(data) {
+		t.Errorf("file = %s, want it untouched", got)
+	}
+}
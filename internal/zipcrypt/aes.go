@@ -0,0 +1,113 @@
+package zipcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+)
+
+const (
+	aesIterations  = 1000 // of PBKDF2
+	aesVerifierLen = 2
+	aesAuthLen     = 10
+)
+
+// aesReader decrypts AES encrypted contents, checking their authentication
+// code at the end.
+type aesReader struct {
+	r       io.Reader // the encrypted contents
+	raw     io.Reader // the authentication code after them
+	block   cipher.Block
+	mac     hash.Hash
+	counter uint64
+	stream  [aes.BlockSize]byte
+	used    int  // of stream
+	checked bool // the authentication code
+}
+
+// newAESReader reads the salt and the password verifier from raw, the size
+// bytes of an entry, and checks password against them.
+func newAESReader(raw io.Reader, size int64, keyLen int, password string) (io.Reader, error) {
+	saltLen := keyLen / 2
+	if size < int64(saltLen+aesVerifierLen+aesAuthLen) {
+		return nil, errors.New("zipcrypt: AES entry too short")
+	}
+	head := make([]byte, saltLen+aesVerifierLen)
+	if _, err := io.ReadFull(raw, head); err != nil {
+		return nil, err
+	}
+	key := pbkdf2([]byte(password), head[:saltLen], aesIterations, 2*keyLen+aesVerifierLen)
+	if subtle.ConstantTimeCompare(key[2*keyLen:], head[saltLen:]) != 1 {
+		return nil, ErrPassword
+	}
+	block, err := aes.NewCipher(key[:keyLen])
+	if err != nil {
+		return nil, err
+	}
+	return &aesReader{
+		r:     io.LimitReader(raw, size-int64(len(head))-aesAuthLen),
+		raw:   raw,
+		block: block,
+		mac:   hmac.New(sha1.New, key[keyLen:2*keyLen]),
+		used:  aes.BlockSize,
+	}, nil
+}
+
+func (r *aesReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.mac.Write(b[:n])
+	for i := range b[:n] {
+		if r.used == aes.BlockSize {
+			// The counter is little-endian and starts at 1, unlike
+			// cipher.NewCTR's
+			r.counter++
+			var block [aes.BlockSize]byte
+			binary.LittleEndian.PutUint64(block[:], r.counter)
+			r.block.Encrypt(r.stream[:], block[:])
+			r.used = 0
+		}
+		b[i] ^= r.stream[r.used]
+		r.used++
+	}
+	if err == io.EOF && !r.checked {
+		r.checked = true
+		var code [aesAuthLen]byte
+		if _, err := io.ReadFull(r.raw, code[:]); err != nil {
+			return n, err
+		}
+		if !hmac.Equal(code[:], r.mac.Sum(nil)[:aesAuthLen]) {
+			return n, ErrPassword
+		}
+	}
+	return n, err
+}
+
+// pbkdf2 derives a key of keyLen bytes from password and salt with
+// HMAC-SHA1, as RFC 8018 describes.
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha1.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,214 @@
+// Package zipcrypt reads the entries of ZIP archives protected with a
+// password, which archive/zip cannot: those encrypted with the traditional
+// PKWARE scheme most tools use by default and those encrypted with AES the
+// way WinZip and 7-Zip do.
+package zipcrypt
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	// ErrPassword is returned for a password that does not decrypt an
+	// entry.
+	ErrPassword = errors.New("wrong password")
+	// ErrUnsupported is returned for entries encrypted or compressed in a
+	// way this package cannot read.
+	ErrUnsupported = errors.New("unsupported encryption")
+)
+
+const (
+	flagEncrypted        = 0x1
+	flagDataDescriptor   = 0x8
+	flagStrongEncryption = 0x40
+	methodAES            = 99
+	extraAES             = 0x9901
+	headerLen            = 12 // of the traditional scheme
+)
+
+// Encrypted reports whether f is protected with a password.
+func Encrypted(f *zip.File) bool {
+	return f.Flags&flagEncrypted != 0
+}
+
+// Check reports whether password looks right for the encrypted entry f,
+// returning ErrPassword when it is not. It reads only the start of the
+// entry; a traditionally encrypted one still accepts about one wrong
+// password in 256, which Open's reader reports once the contents do not
+// match their checksum.
+func Check(f *zip.File, password string) error {
+	rc, err := Open(f, password)
+	if err != nil {
+		return err
+	}
+	return rc.Close()
+}
+
+// Open returns a reader of the contents of f, decrypted with password when
+// it is encrypted and decompressed. A wrong password gives ErrPassword,
+// either right away or from Read.
+func Open(f *zip.File, password string) (io.ReadCloser, error) {
+	if !Encrypted(f) {
+		return f.Open()
+	}
+	if f.Flags&flagStrongEncryption != 0 {
+		return nil, ErrUnsupported
+	}
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	method, checkCRC := f.Method, true
+	var data io.Reader
+	if f.Method == methodAES {
+		var aes aesExtra
+		if aes, err = parseAESExtra(f.Extra); err == nil {
+			method, checkCRC = aes.method, aes.version == 1
+			data, err = newAESReader(raw, int64(f.CompressedSize64), aes.keyLen, password)
+		}
+	} else {
+		data, err = newLegacyReader(raw, f, password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = io.NopCloser(data)
+	case zip.Deflate:
+		rc = flate.NewReader(data)
+	default:
+		return nil, fmt.Errorf("%w: compression method %d", ErrUnsupported, method)
+	}
+	if !checkCRC {
+		return rc, nil
+	}
+	return &checksumReader{rc: rc, hash: crc32.NewIEEE(), want: f.CRC32}, nil
+}
+
+// checksumReader returns ErrPassword at the end of the contents of rc when
+// they do not match the CRC-32 want, and when they cannot be inflated.
+type checksumReader struct {
+	rc   io.ReadCloser
+	hash hash.Hash32
+	want uint32
+}
+
+func (r *checksumReader) Read(b []byte) (int, error) {
+	n, err := r.rc.Read(b)
+	r.hash.Write(b[:n])
+	if err == io.EOF && r.hash.Sum32() != r.want {
+		return n, ErrPassword
+	}
+	var corrupt flate.CorruptInputError
+	if errors.As(err, &corrupt) {
+		// Contents decrypted with a wrong password rarely inflate
+		return n, fmt.Errorf("%w: %v", ErrPassword, err)
+	}
+	return n, err
+}
+
+func (r *checksumReader) Close() error {
+	return r.rc.Close()
+}
+
+// legacyKeys is the state of the traditional PKWARE cipher.
+type legacyKeys [3]uint32
+
+func newLegacyKeys(password string) *legacyKeys {
+	k := &legacyKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+func (k *legacyKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *legacyKeys) decrypt(b []byte) {
+	for i, c := range b {
+		t := k[2] | 2
+		b[i] = c ^ byte((t*(t^1))>>8)
+		k.update(b[i])
+	}
+}
+
+// legacyReader decrypts traditionally encrypted contents.
+type legacyReader struct {
+	r    io.Reader
+	keys *legacyKeys
+}
+
+// newLegacyReader reads the encryption header of f from raw and checks
+// password against it.
+func newLegacyReader(raw io.Reader, f *zip.File, password string) (io.Reader, error) {
+	keys := newLegacyKeys(password)
+	var header [headerLen]byte
+	if _, err := io.ReadFull(raw, header[:]); err != nil {
+		return nil, err
+	}
+	keys.decrypt(header[:])
+	// The last byte repeats the top of the CRC, or of the time when the
+	// sizes follow the contents; tools differ in which they use then
+	check := header[headerLen-1]
+	if check != byte(f.CRC32>>24) && (f.Flags&flagDataDescriptor == 0 || check != byte(f.ModifiedTime>>8)) {
+		return nil, ErrPassword
+	}
+	return &legacyReader{r: raw, keys: keys}, nil
+}
+
+func (r *legacyReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.keys.decrypt(b[:n])
+	return n, err
+}
+
+// aesExtra is the AES extra field of an entry.
+type aesExtra struct {
+	version uint16 // 1 for AE-1, whose CRC is checked, 2 for AE-2
+	keyLen  int
+	method  uint16 // compression
+}
+
+func parseAESExtra(extra []byte) (aesExtra, error) {
+	for len(extra) >= 4 {
+		id, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if id != extraAES || size < 7 || !bytes.Equal(field[2:4], []byte("AE")) {
+			continue
+		}
+		strength := field[4]
+		if strength < 1 || strength > 3 {
+			return aesExtra{}, fmt.Errorf("%w: AES strength %d", ErrUnsupported, strength)
+		}
+		return aesExtra{
+			version: binary.LittleEndian.Uint16(field),
+			keyLen:  8 + 8*int(strength),
+			method:  binary.LittleEndian.Uint16(field[5:]),
+		}, nil
+	}
+	return aesExtra{}, fmt.Errorf("%w: AES entry without its extra field", ErrUnsupported)
+}
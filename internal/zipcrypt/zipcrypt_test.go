@@ -0,0 +1,178 @@
+package zipcrypt
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var uiContents = strings.Repeat("NeoplePack_Bill dark ui sprites\n", 200)
+
+func openFixture(t *testing.T) map[string]*zip.File {
+	t.Helper()
+	r, err := zip.OpenReader("testdata/legacy.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	files := make(map[string]*zip.File)
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+	return files
+}
+
+func readAll(f *zip.File, password string) (string, error) {
+	rc, err := Open(f, password)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return string(data), err
+}
+
+func TestOpenLegacy(t *testing.T) {
+	files := openFixture(t)
+	for name, want := range map[string]string{"ui.npk": uiContents, "font.npk": "tiny"} {
+		f := files[name]
+		if !Encrypted(f) {
+			t.Fatalf("Encrypted(%s) = false", name)
+		}
+		if got, err := readAll(f, "forum"); err != nil || got != want {
+			t.Errorf("Open(%s) read %d bytes, %v", name, len(got), err)
+		}
+	}
+}
+
+func TestOpenLegacyWrongPassword(t *testing.T) {
+	f := openFixture(t)["ui.npk"]
+	wrong := 0
+	for i := 0; i < 1000; i++ {
+		password := "guess" + strings.Repeat("x", i)
+		if err := Check(f, password); err != nil {
+			if !errors.Is(err, ErrPassword) {
+				t.Fatalf("Check(%q) = %v, want ErrPassword", password, err)
+			}
+			wrong++
+			continue
+		}
+		// Passed the header check by chance; reading must still fail
+		if _, err := readAll(f, password); !errors.Is(err, ErrPassword) {
+			t.Errorf("reading with %q = %v, want ErrPassword", password, err)
+		}
+	}
+	if wrong < 950 {
+		t.Errorf("Check() rejected only %d of 1000 wrong passwords", wrong)
+	}
+}
+
+func TestOpenUnencrypted(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	fw, _ := w.Create("plain.npk")
+	fw.Write([]byte("plain"))
+	w.Close()
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Encrypted(r.File[0]) {
+		t.Error("Encrypted() = true for a plain entry")
+	}
+	if got, err := readAll(r.File[0], ""); err != nil || got != "plain" {
+		t.Errorf("Open() = %q, %v", got, err)
+	}
+}
+
+// writeAES adds name to w encrypted with AES-256 the way WinZip does, as an
+// AE-2 entry whose contents are deflated.
+func writeAES(t *testing.T, w *zip.Writer, name, contents, password string) {
+	t.Helper()
+	var deflated bytes.Buffer
+	fw, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	fw.Write([]byte(contents))
+	fw.Close()
+
+	const keyLen = 32
+	salt := bytes.Repeat([]byte{7}, keyLen/2)
+	key := pbkdf2([]byte(password), salt, aesIterations, 2*keyLen+aesVerifierLen)
+	block, _ := aes.NewCipher(key[:keyLen])
+	data := deflated.Bytes()
+	for i := 0; i < len(data); i += aes.BlockSize {
+		var counter, stream [aes.BlockSize]byte
+		binary.LittleEndian.PutUint64(counter[:], uint64(i/aes.BlockSize+1))
+		block.Encrypt(stream[:], counter[:])
+		for j := i; j < len(data) && j < i+aes.BlockSize; j++ {
+			data[j] ^= stream[j-i]
+		}
+	}
+	mac := hmac.New(sha1.New, key[keyLen:2*keyLen])
+	mac.Write(data)
+
+	extra := []byte{0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, 8, 0} // AE-2, AES-256, deflated
+	raw, err := w.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             methodAES,
+		Flags:              flagEncrypted,
+		Extra:              extra,
+		CompressedSize64:   uint64(len(salt) + aesVerifierLen + len(data) + aesAuthLen),
+		UncompressedSize64: uint64(len(contents)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.Write(salt)
+	raw.Write(key[2*keyLen:])
+	raw.Write(data)
+	raw.Write(mac.Sum(nil)[:aesAuthLen])
+}
+
+func TestOpenAES(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	writeAES(t, w, "ui.npk", uiContents, "forum")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := r.File[0]
+	if got, err := readAll(f, "forum"); err != nil || got != uiContents {
+		t.Errorf("Open() read %d bytes, %v", len(got), err)
+	}
+	if err := Check(f, "Forum"); !errors.Is(err, ErrPassword) {
+		t.Errorf("Check() with a wrong password = %v, want ErrPassword", err)
+	}
+
+	// A damaged entry fails its authentication code
+	data := buf.Bytes()
+	data[bytes.Index(data, []byte("ui.npk"))+len("ui.npk")+40] ^= 1
+	r, _ = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if _, err := readAll(r.File[0], "forum"); err == nil {
+		t.Error("reading a damaged entry succeeded")
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 6070
+	for iterations, want := range map[int]string{
+		1: "0c60c80f961f0e71f3a9b524af6012062fe037a6",
+		2: "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957",
+	} {
+		if got := hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), iterations, 20)); got != want {
+			t.Errorf("pbkdf2(%d) = %s, want %s", iterations, got, want)
+		}
+	}
+}
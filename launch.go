@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log/slog"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+)
+
+// wegameAppID returns the ID wegame.exe is asked to start.
+func (p *PatchManager) wegameAppID() string {
+	if p.config.WeGameAppID != "" {
+		return p.config.WeGameAppID
+	}
+	return gamepath.DefaultWeGameAppID
+}
+
+// updateLaunchButton enables the launch button only while the game path is
+// usable and no operation is changing the game files.
+func (p *PatchApp) updateLaunchButton() {
+	if p.launchButton == nil {
+		return
+	}
+	if p.pathUsable() && len(p.operations.names()) == 0 {
+		p.launchButton.Enable()
+	} else {
+		p.launchButton.Disable()
+	}
+}
+
+// launchGame starts the game through WeGame or DNF.exe. When the game is
+// already running its window is brought to the front instead.
+func (p *PatchApp) launchGame() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("launch.title"), i18n.T("path.chooseFirst"), p.window)
+		return
+	}
+	if len(p.operations.names()) > 0 {
+		dialog.ShowInformation(i18n.T("launch.title"), i18n.T("launch.busy"), p.window)
+		return
+	}
+	if gameRunning(p.dnfPath) {
+		if activateGame(p.dnfPath) {
+			p.updateStatus(i18n.T("launch.activated"))
+		} else {
+			dialog.ShowInformation(i18n.T("launch.title"), i18n.T("launch.running"), p.window)
+		}
+		return
+	}
+
+	l, err := gamepath.FindLauncher(p.dnfPath, p.wegameAppID())
+	if err != nil {
+		slog.Error("finding the game launcher failed", "path", p.dnfPath, "err", err)
+		p.showError(err)
+		return
+	}
+	cmd, err := launchCommand(l)
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		slog.Error("launching the game failed", "exe", l.Exe, "err", err)
+		p.showError(err)
+		return
+	}
+	// Wait releases the process once the launcher exits
+	go cmd.Wait()
+	slog.Info("launched the game", "exe", l.Exe, "args", l.Args)
+	if l.WeGame {
+		p.updateStatus(i18n.T("launch.startedWeGame"))
+	} else {
+		p.updateStatus(i18n.T("launch.started"))
+	}
+}
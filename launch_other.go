@@ -0,0 +1,38 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"dnf_patch/internal/gamepath"
+)
+
+// gameRunning reports whether the game at path is running. Elsewhere than on
+// Windows this is only known from the files the game holds open.
+func gameRunning(path string) bool {
+	return gamepath.Running(path)
+}
+
+// activateGame is only implemented for Windows.
+func activateGame(path string) bool {
+	return false
+}
+
+// launchCommand returns the command that runs l under Wine, in the prefix
+// the game is installed in.
+func launchCommand(l gamepath.Launcher) (*exec.Cmd, error) {
+	wine, err := exec.LookPath("wine")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(wine, append([]string{l.Exe}, l.Args...)...)
+	cmd.Dir = l.Dir
+	if i := strings.Index(l.Exe, string(filepath.Separator)+"drive_c"+string(filepath.Separator)); i >= 0 {
+		cmd.Env = append(os.Environ(), "WINEPREFIX="+l.Exe[:i])
+	}
+	return cmd, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"dnf_patch/internal/gamepath"
+)
+
+var (
+	procShowWindow          = user32.NewProc("ShowWindow")
+	procSetForegroundWindow = user32.NewProc("SetForegroundWindow")
+	enumGameWindowsCallback = syscall.NewCallback(enumGameWindowsProc)
+)
+
+// The search of gameWindow, which enumGameWindowsProc fills in.
+var (
+	gameWindowsMu    sync.Mutex
+	gameWindowsPIDs  map[uint32]bool
+	gameWindowsFound uintptr
+)
+
+const swRestore = 9
+
+// gameProcesses returns the IDs of the processes running the DNF.exe of the
+// game at path.
+func gameProcesses(path string) []uint32 {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var pids []uint32
+	entry := windows.ProcessEntry32{Size: uint32(unsafe.Sizeof(windows.ProcessEntry32{}))}
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		if !strings.EqualFold(windows.UTF16ToString(entry.ExeFile[:]), "DNF.exe") {
+			continue
+		}
+		process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, entry.ProcessID)
+		if err != nil {
+			continue
+		}
+		buf := make([]uint16, windows.MAX_LONG_PATH)
+		size := uint32(len(buf))
+		err = windows.QueryFullProcessImageName(process, 0, &buf[0], &size)
+		windows.CloseHandle(process)
+		if err == nil && strings.EqualFold(filepath.Dir(windows.UTF16ToString(buf[:size])), filepath.Clean(path)) {
+			pids = append(pids, entry.ProcessID)
+		}
+	}
+	return pids
+}
+
+func enumGameWindowsProc(hwnd, _ uintptr) uintptr {
+	var pid uint32
+	procGetWindowThreadPID.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if !gameWindowsPIDs[pid] {
+		return 1
+	}
+	if visible, _, _ := procIsWindowVisible.Call(hwnd); visible == 0 {
+		return 1
+	}
+	gameWindowsFound = hwnd
+	return 0
+}
+
+// gameWindow returns the handle of a visible window of the processes pids,
+// or 0.
+func gameWindow(pids []uint32) uintptr {
+	gameWindowsMu.Lock()
+	defer gameWindowsMu.Unlock()
+
+	gameWindowsPIDs, gameWindowsFound = make(map[uint32]bool), 0
+	for _, pid := range pids {
+		gameWindowsPIDs[pid] = true
+	}
+	procEnumWindows.Call(enumGameWindowsCallback, 0)
+	return gameWindowsFound
+}
+
+// gameRunning reports whether the game at path is running.
+func gameRunning(path string) bool {
+	return len(gameProcesses(path)) > 0 || gamepath.Running(path)
+}
+
+// activateGame brings the window of the running game at path to the front.
+// It reports false when no window of the game is shown, such as while it is
+// still starting.
+func activateGame(path string) bool {
+	hwnd := gameWindow(gameProcesses(path))
+	if hwnd == 0 {
+		return false
+	}
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		procShowWindow.Call(hwnd, swRestore)
+	}
+	procSetForegroundWindow.Call(hwnd)
+	return true
+}
+
+// launchCommand returns the command that runs l.
+func launchCommand(l gamepath.Launcher) (*exec.Cmd, error) {
+	cmd := exec.Command(l.Exe, l.Args...)
+	cmd.Dir = l.Dir
+	return cmd, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"dnf_patch/internal/deeplink"
+	"dnf_patch/internal/i18n"
+)
+
+// linkTag marks downloads started from a dnfpatch:// link with a direct URL,
+// which are installed like a dropped file once they finish.
+const linkTag = "link"
+
+// openArg opens what the tool was started with: a patch file or a
+// dnfpatch:// link.
+func (p *PatchApp) openArg(arg string) {
+	if deeplink.IsLink(arg) {
+		p.openLink(arg)
+		return
+	}
+	p.importPath(arg)
+}
+
+// openLink asks whether to follow a dnfpatch:// link, naming the site it
+// says it comes from, and then installs or shows the patch. Links that do
+// not parse or name an unknown patch are refused.
+func (p *PatchApp) openLink(raw string) {
+	link, err := deeplink.Parse(raw)
+	if err != nil {
+		slog.Warn("refusing link", "link", raw, "err", err)
+		dialog.ShowInformation(i18n.T("link.title"), i18n.T("link.invalid"), p.window)
+		return
+	}
+	source := link.Source
+	if source == "" {
+		source = i18n.T("link.unknownSource")
+	}
+	slog.Info("opening link", "action", link.Action, "patch", link.PatchID, "url", link.URL, "source", link.Source)
+
+	switch {
+	case link.URL != "":
+		p.confirmLink(i18n.T("link.confirmDownload", source, link.Filename, link.URL), func() { p.followLink(link) })
+	case link.Action == deeplink.Open:
+		p.followLink(link)
+	default:
+		patch, ok := p.catalogPatch(link.PatchID)
+		if !ok {
+			p.followLink(link) // explains the unknown patch
+			return
+		}
+		p.confirmLink(i18n.T("link.confirmInstall", source, patch.Name, patch.Version), func() { p.followLink(link) })
+	}
+}
+
+// followLink does what a parsed link asks for, once the user agreed to it.
+func (p *PatchApp) followLink(link deeplink.Link) {
+	if link.URL != "" {
+		path := filepath.Join(p.downloadDir(), link.Filename)
+		p.downloads.Add(link.Filename, link.URL, path, linkTag, "")
+		p.updateStatus(i18n.T("download.queued", link.Filename))
+		return
+	}
+
+	patch, ok := p.catalogPatch(link.PatchID)
+	if !ok {
+		slog.Warn("link names an unknown patch", "patch", link.PatchID)
+		dialog.ShowInformation(i18n.T("link.title"), i18n.T("link.unknownPatch", link.PatchID), p.window)
+		return
+	}
+	if link.Action == deeplink.Open {
+		p.showPatchDetails(patch)
+		return
+	}
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("link.title"), i18n.T("path.selectFirst", patch.Name), p.window)
+		return
+	}
+	p.installCatalogPatch(patch)
+}
+
+// confirmLink asks before following a link, since any web page can offer
+// one.
+func (p *PatchApp) confirmLink(msg string, install func()) {
+	d := dialog.NewConfirm(i18n.T("link.title"), msg, func(ok bool) {
+		if ok {
+			install()
+		}
+	}, p.window)
+	d.SetConfirmText(i18n.T("patch.install"))
+	d.SetDismissText(i18n.T("common.cancel"))
+	d.Show()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/bundle"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// localCategory is the catalogue category of the patches imported from
+// bundles.
+const localCategory = "Local"
+
+// localPatchesPath is where the patches imported from bundles are listed,
+// in the format of patches.json.
+func (p *PatchManager) localPatchesPath() string {
+	return filepath.Join(p.dataDir, "local_patches.json")
+}
+
+// localPatchDir is where the files of the imported patch with the given ID
+// are kept, so it is reinstalled like a catalogue patch.
+func (p *PatchManager) localPatchDir(id string) string {
+	return filepath.Join(p.dataDir, "local", id)
+}
+
+// addLocalPatches adds the patches imported from bundles to db.
+func (p *PatchManager) addLocalPatches(db *patchdb.Database) {
+	local, err := patchdb.Load(p.localPatchesPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("loading local patches failed", "path", p.localPatchesPath(), "err", err)
+		}
+		return
+	}
+	for _, category := range local.Categories {
+		db.Merge(category.Name, category.Patches)
+	}
+}
+
+// saveLocalPatches lists patches as imported, replacing earlier imports of
+// the same patches.
+func (p *PatchManager) saveLocalPatches(patches []patchdb.Patch) error {
+	local, err := patchdb.Load(p.localPatchesPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	local.Merge(localCategory, patches)
+	return local.Save(p.localPatchesPath())
+}
+
+// importBundle shows the patches of the bundle at path as its manifest
+// describes them and installs them once the user agrees. Bundles whose
+// manifest does not match their files are refused before anything is
+// written.
+func (p *PatchApp) importBundle(path string) {
+	b, err := bundle.Open(path)
+	if err != nil {
+		slog.Error("reading patch bundle failed", "path", path, "err", err)
+		p.showError(err)
+		return
+	}
+	patches := b.Patches
+	b.Close()
+
+	content := container.NewVBox()
+	for _, patch := range patches {
+		content.Add(widget.NewLabelWithStyle(i18n.T("bundle.patch", patch.Name, patch.Version), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		content.Add(widget.NewLabel(i18n.T("patch.author", patch.Author)))
+		if patch.Description != "" {
+			content.Add(wrappedLabel(patch.Description))
+		}
+		content.Add(widget.NewSeparator())
+	}
+	content.Add(wrappedLabel(i18n.T("bundle.confirm", p.dnfPath)))
+
+	d := dialog.NewCustomConfirm(i18n.T("bundle.title", filepath.Base(path)), i18n.T("patch.install"), i18n.T("common.cancel"),
+		container.NewVScroll(content), func(ok bool) {
+			if ok {
+				go p.installBundle(path)
+			}
+		}, p.window)
+	d.Resize(fyne.NewSize(500, 400))
+	d.Show()
+}
+
+// installBundle copies the files of the bundle at path to the data folder,
+// lists its patches in the Local category and installs them like catalogue
+// patches, so they can be updated and uninstalled the same way.
+func (p *PatchApp) installBundle(path string) {
+	defer p.recoverPanic(i18n.T("op.importBundle"))
+	_, end := p.operations.begin(i18n.T("op.importBundle"))
+	defer end()
+
+	patches, sources, err := p.extractBundle(path)
+	if err == nil {
+		err = p.saveLocalPatches(patches)
+	}
+	if err != nil {
+		slog.Error("importing patch bundle failed", "path", path, "err", err)
+		p.updateStatus(i18n.T("import.failed", err))
+		p.showError(err)
+		return
+	}
+	slog.Info("imported patch bundle", "path", path, "patches", len(patches))
+	p.patches.Merge(localCategory, patches)
+	p.layout.Apply(&p.patches)
+	p.setPatches(p.patches)
+	p.categoryList.Refresh()
+	p.refreshFeatured()
+	p.deriveClasses()
+	for i, patch := range patches {
+		p.installFromFile(patch, sources[i])
+	}
+}
+
+// extractBundle copies the NPK files and previews of the bundle at path to
+// the folders of its patches. It returns the patches, with the previews
+// pointing at the copies, and the paths of their NPK files.
+func (p *PatchManager) extractBundle(path string) ([]patchdb.Patch, []string, error) {
+	b, err := bundle.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer b.Close()
+
+	var patches []patchdb.Patch
+	var sources []string
+	for _, patch := range b.Patches {
+		dir := p.localPatchDir(patch.ID)
+		source := filepath.Join(dir, patch.Filename)
+		if err := b.Extract(patch.Filename, source); err != nil {
+			return nil, nil, err
+		}
+		var previews []patchdb.Preview
+		for _, preview := range patch.Previews {
+			if !strings.Contains(preview.URL, "://") {
+				dst := filepath.Join(dir, "previews", filepath.Base(filepath.FromSlash(preview.URL)))
+				if err := b.Extract(preview.URL, dst); err != nil {
+					return nil, nil, err
+				}
+				preview.URL = dst
+			}
+			previews = append(previews, preview)
+		}
+		patch.Previews = previews
+		patches = append(patches, patch)
+		sources = append(sources, source)
+	}
+	return patches, sources, nil
+}
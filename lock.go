@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"dnf_patch/internal/deeplink"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/instance"
+)
+
+// lockPath returns the single-instance lock, kept in the data directory
+// next to the history and backup files two instances would both write.
+func (p *PatchManager) lockPath() string {
+	return filepath.Join(p.dataDir, "dnfpatch.lock")
+}
+
+// acquireLock takes the single-instance lock. When another instance holds
+// it, that instance is asked to come to the front and open files, and the
+// returned error is an *instance.RunningError.
+func (p *PatchManager) acquireLock(files ...string) (*instance.Lock, error) {
+	if err := os.MkdirAll(p.dataDir, 0755); err != nil {
+		return nil, err
+	}
+	lock, err := instance.Acquire(p.lockPath(), files...)
+	var running *instance.RunningError
+	if errors.As(err, &running) {
+		slog.Info("another instance is running", "pid", running.PID, "activated", running.Activated)
+	}
+	return lock, err
+}
+
+// raiseOnActivate brings the window to the front when a second instance is
+// started, and opens the patch files and links it was started with.
+func (p *PatchApp) raiseOnActivate(lock *instance.Lock) {
+	lock.OnActivate(func(files []string) {
+		p.window.Show()
+		p.window.RequestFocus()
+		for _, file := range files {
+			p.openArg(file)
+		}
+	})
+}
+
+// openArgs returns args when they are all existing .npk files or dnfpatch://
+// links, as when the tool is started by double-clicking a file or following
+// a link, and nil otherwise. Links are checked when they are opened.
+func openArgs(args []string) []string {
+	var files []string
+	for _, arg := range args {
+		if deeplink.IsLink(arg) {
+			// The running instance is handed one argument per line
+			if !strings.ContainsAny(arg, "\r\n") {
+				files = append(files, arg)
+			}
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil || info.IsDir() || !strings.EqualFold(filepath.Ext(arg), ".npk") {
+			return nil
+		}
+		if abs, err := filepath.Abs(arg); err == nil {
+			arg = abs
+		}
+		files = append(files, arg)
+	}
+	return files
+}
+
+// showAlreadyRunning tells the user another instance could not be brought to
+// the front, then quits.
+func (p *PatchApp) showAlreadyRunning(err error) {
+	d := dialog.NewInformation(i18n.T("instance.runningTitle"), i18n.T("instance.running", err), p.window)
+	d.SetOnClosed(fyne.CurrentApp().Quit)
+	d.Show()
+	p.window.Resize(fyne.NewSize(400, 200))
+	p.window.CenterOnScreen()
+	p.window.ShowAndRun()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+
+	"dnf_patch/internal/i18n"
+)
+
+const (
+	logFileName  = "dnfpatch.log"
+	logMaxSize   = 2 << 20 // rotate after 2 MB
+	logMaxBackup = 3       // keep dnfpatch.log.1 … dnfpatch.log.3
+	logRecent    = 200     // lines kept in memory for crash reports
+)
+
+// recentLog keeps the last lines written to the log so a crash report can
+// include them.
+var recentLog = &recentLines{max: logRecent}
+
+// recentLines is an io.Writer keeping the last max lines written to it.
+type recentLines struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+}
+
+func (r *recentLines) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(b), "\n"), "\n") {
+		r.lines = append(r.lines, line)
+	}
+	if len(r.lines) > r.max {
+		r.lines = append([]string(nil), r.lines[len(r.lines)-r.max:]...)
+	}
+	return len(b), nil
+}
+
+func (r *recentLines) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.lines, "\n")
+}
+
+// Last returns the last n lines.
+func (r *recentLines) Last(n int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.lines) > n {
+		return strings.Join(r.lines[len(r.lines)-n:], "\n")
+	}
+	return strings.Join(r.lines, "\n")
+}
+
+// rotatingWriter is an io.Writer appending to a log file that is rotated
+// once it grows beyond maxSize, keeping the last maxBackups files.
+type rotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingWriter(path string, maxSize int64, maxBackups int) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return w.open()
+}
+
+func (w *rotatingWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size+int64(len(b)) > w.maxSize && w.size > 0 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// logDir returns the directory holding the log files.
+func (p *PatchManager) logDir() string {
+	return filepath.Join(p.dataDir, "logs")
+}
+
+// initLogging makes slog's default logger write to the rotating log file.
+func (p *PatchManager) initLogging() error {
+	if err := os.MkdirAll(p.logDir(), 0755); err != nil {
+		return err
+	}
+	w, err := newRotatingWriter(filepath.Join(p.logDir(), logFileName), logMaxSize, logMaxBackup)
+	if err != nil {
+		return err
+	}
+	out := io.MultiWriter(w, recentLog)
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo})
+	slog.SetDefault(slog.New(&panelHandler{Handler: handler, log: statusLog}))
+	return nil
+}
+
+func (p *PatchApp) openLogFolder() {
+	u, err := url.Parse(storage.NewFileURI(p.logDir()).String())
+	if err == nil {
+		err = fyne.CurrentApp().OpenURL(u)
+	}
+	if err != nil {
+		slog.Error("opening log folder failed", "path", p.logDir(), "err", err)
+		p.showError(err)
+	}
+}
+
+// openDataFolder shows the folder holding the config and profiles.
+func (p *PatchApp) openDataFolder() {
+	u, err := url.Parse(storage.NewFileURI(p.dataDir).String())
+	if err == nil {
+		err = fyne.CurrentApp().OpenURL(u)
+	}
+	if err != nil {
+		slog.Error("opening data folder failed", "path", p.dataDir, "err", err)
+		p.showError(err)
+	}
+}
+
+func (p *PatchApp) createMainMenu() *fyne.MainMenu {
+	// Replaces the Quit item Fyne adds, which would quit without asking
+	quit := fyne.NewMenuItem(i18n.T("menu.quit"), p.quit)
+	quit.IsQuit = true
+	return fyne.NewMainMenu(
+		fyne.NewMenu(i18n.T("menu.tools"),
+			fyne.NewMenuItem(i18n.T("menu.installNPK"), p.chooseNPKToInstall),
+			fyne.NewMenuItem(i18n.T("menu.inspectNPK"), p.inspectNPK),
+			fyne.NewMenuItem(i18n.T("menu.mergeNPKs"), p.showMergeNPKs),
+			fyne.NewMenuItem(i18n.T("menu.author"), p.showPackageWizard),
+			fyne.NewMenuItem(i18n.T("menu.collections"), p.showCollections),
+			fyne.NewMenuItem(i18n.T("menu.verify"), p.showVerifyGameFiles),
+			fyne.NewMenuItem(i18n.T("menu.report"), p.showExportReport),
+			fyne.NewMenuItem(i18n.T("menu.duplicates"), p.showFindDuplicates),
+			fyne.NewMenuItem(i18n.T("menu.cleanup"), p.showCleanup),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(i18n.T("menu.removeAll"), p.showRemoveAllPatches),
+			fyne.NewMenuItemSeparator(),
+			quit,
+		),
+		fyne.NewMenu(i18n.T("menu.help"),
+			fyne.NewMenuItem(i18n.T("log.openFolder"), p.openLogFolder),
+			fyne.NewMenuItem(i18n.T("menu.healthCheck"), func() { p.runHealthCheck(true) }),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(i18n.T("menu.about"), p.showAbout),
+		),
+	)
+}
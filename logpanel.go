@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// logPanelSize bounds the scrollback of the log panel.
+const logPanelSize = 1000
+
+// logEntry is a line of the log panel.
+type logEntry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string // the message followed by its attributes
+}
+
+func (e logEntry) String() string {
+	return fmt.Sprintf("%s %-5s %s", e.Time.Format("15:04:05"), e.Level, e.Message)
+}
+
+// panelLog keeps the recent log records shown in the log panel. It is safe
+// for concurrent use.
+type panelLog struct {
+	mu       sync.Mutex
+	entries  []logEntry
+	onChange func()
+}
+
+// statusLog receives everything written to the log file.
+var statusLog = &panelLog{}
+
+func (l *panelLog) add(e logEntry) {
+	l.mu.Lock()
+	l.entries = append(l.entries, e)
+	if len(l.entries) > logPanelSize {
+		l.entries = append([]logEntry(nil), l.entries[len(l.entries)-logPanelSize:]...)
+	}
+	onChange := l.onChange
+	l.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// Entries returns a copy of the entries, oldest first.
+func (l *panelLog) Entries() []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), l.entries...)
+}
+
+// SetOnChange sets the function called after each new entry.
+func (l *panelLog) SetOnChange(f func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = f
+}
+
+// panelHandler is a slog.Handler passing records on to the log file handler
+// and adding them to a panelLog.
+type panelHandler struct {
+	slog.Handler
+	log   *panelLog
+	attrs []slog.Attr
+}
+
+func (h *panelHandler) Handle(ctx context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	h.log.add(logEntry{Time: r.Time, Level: r.Level, Message: b.String()})
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *panelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &panelHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		log:     h.log,
+		attrs:   append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+func (h *panelHandler) WithGroup(name string) slog.Handler {
+	return &panelHandler{Handler: h.Handler.WithGroup(name), log: h.log, attrs: h.attrs}
+}
+
+// statusLevel guesses the severity of a status message from its icon.
+func statusLevel(msg string) slog.Level {
+	switch {
+	case strings.HasPrefix(msg, "❌"):
+		return slog.LevelError
+	case strings.HasPrefix(msg, "⚠️"):
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// levelIcon returns the icon shown for level in the log panel.
+func levelIcon(level slog.Level) fyne.Resource {
+	switch {
+	case level >= slog.LevelError:
+		return theme.ErrorIcon()
+	case level >= slog.LevelWarn:
+		return theme.WarningIcon()
+	}
+	return theme.InfoIcon()
+}
+
+// createLogUI shows the scrollback of status messages and log records.
+func (p *PatchApp) createLogUI() fyne.CanvasObject {
+	var (
+		mu         sync.Mutex
+		shown      []logEntry
+		errorsOnly bool
+	)
+	visible := func() []logEntry {
+		mu.Lock()
+		defer mu.Unlock()
+		return shown
+	}
+
+	list := widget.NewList(
+		func() int { return len(visible()) },
+		func() fyne.CanvasObject {
+			message := widget.NewLabel("")
+			message.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil,
+				container.NewHBox(widget.NewIcon(theme.InfoIcon()), widget.NewLabel("00:00:00")),
+				nil, message)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entries := visible()
+			if id >= len(entries) {
+				return
+			}
+			e := entries[id]
+			row := item.(*fyne.Container)
+			left := row.Objects[1].(*fyne.Container)
+			left.Objects[0].(*widget.Icon).SetResource(levelIcon(e.Level))
+			left.Objects[1].(*widget.Label).SetText(e.Time.Format(i18n.T("format.time")))
+			row.Objects[0].(*widget.Label).SetText(e.Message)
+		},
+	)
+	refresh := func() {
+		var entries []logEntry
+		all := statusLog.Entries()
+		mu.Lock()
+		for _, e := range all {
+			if !errorsOnly || e.Level >= slog.LevelError {
+				entries = append(entries, e)
+			}
+		}
+		shown = entries
+		mu.Unlock()
+		list.Refresh()
+		list.ScrollToBottom()
+	}
+	statusLog.SetOnChange(refresh)
+
+	errorsCheck := widget.NewCheck(i18n.T("log.errorsOnly"), func(b bool) {
+		mu.Lock()
+		errorsOnly = b
+		mu.Unlock()
+		refresh()
+	})
+	copyButton := widget.NewButtonWithIcon(i18n.T("common.copy"), theme.ContentCopyIcon(), func() {
+		var lines []string
+		for _, e := range visible() {
+			lines = append(lines, e.String())
+		}
+		p.window.Clipboard().SetContent(strings.Join(lines, "\n"))
+		p.statusText.Set(i18n.T("log.copied", len(lines)))
+	})
+	openButton := widget.NewButtonWithIcon(i18n.T("log.openFolder"), theme.FolderOpenIcon(), p.openLogFolder)
+
+	refresh()
+	return container.NewBorder(container.NewHBox(errorsCheck, copyButton, openButton), nil, nil, nil, list)
+}
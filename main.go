@@ -1,165 +1,359 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"image"
 	"image/color"
 	"io"
-	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
-	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-)
 
-const (
-	defaultDNFPath = "C:\\Wegame\\WeGame\\games\\DNF"
-	imagePack2Dir  = "imagepack2"
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/bundle"
+	"dnf_patch/internal/collection"
+	"dnf_patch/internal/download"
+	"dnf_patch/internal/fetchpool"
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/instance"
+	"dnf_patch/internal/journal"
+	"dnf_patch/internal/notes"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/patchdb"
+	"dnf_patch/internal/patchzip"
+	"dnf_patch/internal/watch"
+	"dnf_patch/internal/whatsnew"
 )
 
-type PatchRating struct {
-	Average float64 `json:"average"`
-	Count   int     `json:"count"`
+type AppConfig struct {
+	SchemaVersion  int            `json:"schemaVersion"`     // see config.File
+	DNFPath        string         `json:"dnfPath,omitempty"` // migrated into Profiles
+	RecentPaths    []string       `json:"recentPaths"`
+	Profiles       []GameProfile  `json:"profiles"`
+	ActiveProfile  string         `json:"activeProfile"`
+	RepositoryURL  string         `json:"repositoryUrl"`
+	RepositoryKeys []string       `json:"repositoryKeys,omitempty"` // base64 ed25519 keys patches.json must be signed with
+	Proxy          string         `json:"proxy"`
+	Language       string         `json:"language"`   // auto, zh-CN, en
+	Theme          string         `json:"theme"`      // system, light, dark
+	TimeFormat     string         `json:"timeFormat"` // absolute, relative
+	FontPath       string         `json:"fontPath"`   // font file used instead of the bundled one
+	MinimizeToTray bool           `json:"minimizeToTray"`
+	ConfirmInstall bool           `json:"confirmInstall"`
+	ConfirmRestore bool           `json:"confirmRestore"`
+	CacheDir       string         `json:"cacheDir"`
+	CacheLimit     int            `json:"cacheLimit"`     // MB the cache is trimmed to
+	StaleDays      int            `json:"staleDays"`      // days after which the catalogue is shown as out of date
+	WatchDir       string         `json:"watchDir"`       // folder to import new patch files from
+	WatchAction    string         `json:"watchAction"`    // ask, install
+	WatchClipboard bool           `json:"watchClipboard"` // suggest installing copied patch links
+	DeleteToTrash  bool           `json:"deleteToTrash"`  // move deleted backups and files to the recycle bin
+	VerifyWrites   bool           `json:"verifyWrites"`   // hash installed and restored files again after writing them
+	Transliterate  bool           `json:"transliterate"`  // turn full-width characters in imported file names into ASCII
+	ZipPasswords   []string       `json:"zipPasswords"`   // passwords of patch archives the user chose to remember, most recent first
+	Notify         NotifySettings `json:"notify"`
+	MaxDownloads   int            `json:"maxDownloads"`
+	SpeedLimit     int            `json:"speedLimit"` // KB/s shared by all downloads, 0 for none
+	Window         WindowState    `json:"window"`
+	WeGameAppID    string         `json:"wegameAppId,omitempty"` // passed to wegame.exe to start DNF
 }
 
-type PatchPreview struct {
-	URL         string `json:"url"`
-	Description string `json:"description"`
-}
+// maxRecentPaths bounds the recently used game paths offered by pathEntry.
+const maxRecentPaths = 5
 
-type UpdateInfo struct {
-	LatestVersion string `json:"latestVersion"`
-	UpdateURL     string `json:"updateUrl"`
-	Changelog     string `json:"changelog"`
-}
-
-type Patch struct {
-	ID          string        `json:"id"`
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	Filename    string        `json:"filename"`
-	Version     string        `json:"version"`
-	Author      string        `json:"author"`
-	Tags        []string      `json:"tags"`
-	Rating      PatchRating   `json:"rating"`
-	Previews    []PatchPreview `json:"previews"`
-	UpdateInfo  UpdateInfo    `json:"updateInfo"`
-	Downloads   int           `json:"downloads"`
-	LastUpdated string        `json:"lastUpdated"`
-}
+var (
+	primaryColor   = color.NRGBA{R: 255, G: 107, B: 107, A: 255}  // 主色调：现代感的珊瑚红
+	secondaryColor = color.NRGBA{R: 78, G: 205, B: 196, A: 255}   // 次要色调：清新的青绿色
+	accentColor    = color.NRGBA{R: 255, G: 230, B: 109, A: 255}  // 强调色：明亮的黄色
+	textColor      = color.NRGBA{R: 255, G: 255, B: 255, A: 230}  // 文本颜色：柔和的白色
+	bgColor        = color.NRGBA{R: 45, G: 52, B: 54, A: 255}     // 背景色：深色渐变起始
+)
 
-type InstallHistory struct {
-	PatchID    string    `json:"patchId"`
-	PatchName  string    `json:"patchName"`
-	Version    string    `json:"version"`
-	Timestamp  time.Time `json:"timestamp"`
-	Status     string    `json:"status"`
+// PatchManager ties the game path, patch database, history, backups and
+// installed-patches registry of the active profile to the internal packages
+// doing the work. It does not depend on any widgets so the CLI can use it as
+// well.
+type PatchManager struct {
+	dnfPath     string
+	patches     patchdb.Database
+	patchIndex  *patchdb.Index // lookups into patches, see setPatches
+	history     *install.History
+	stats       *install.Stats // of history, kept up to date by addToHistory
+	backups     *backup.Store
+	config      AppConfig
+	exeDir      string // bundled files and portable.flag
+	dataDir     string // config, profiles, cache and logs
+	npkCache    *npk.Cache
+	hashes      *fsutil.HashCache // hashes of game files by size and time
+	installed   *install.Registry
+	journal     *journal.Journal
+	collections *collection.Store
+	metadataErr error // why the repository's patches.json was rejected
+	configNewer error // set when config.json is of a newer version, which is not saved over
+	notes       *notes.Store
+	localBlocks patchdb.Blocklist // the user's own blocklist entries
+	layout      patchdb.Layout    // the user's arrangement of the categories
+	layoutErr   error             // why the layout could not be read, which keeps it from being saved
+	// installedChanged, if set, is called when patches are installed or
+	// uninstalled in the active profile, from the goroutine doing it
+	installedChanged func()
+	derivedClasses   sync.Map // patch ID to the derivedClass of its local file, see deriveClasses
+
+	// whatsNew keeps the patches added or updated in the repository, nil
+	// when they are not kept
+	whatsNew *whatsnew.Feed
+
+	catalogFetched  time.Time // when the catalogue shown was fetched, zero for the bundled one
+	catalogFetchErr error     // why fetching it failed when an older copy is shown
+
+	sessionPasswords zipPasswords // that opened archives this session
+	// askZipPassword, if set, asks for the password of an archive, see
+	// promptZipPassword. Without it encrypted archives cannot be installed.
+	askZipPassword func(ctx context.Context, archive string, wrong bool) (password string, remember bool, ok bool)
+	// askZipEntries, if set, asks which files of an archive to install, see
+	// promptZipEntries. Without it they are all installed.
+	askZipEntries func(ctx context.Context, archive string, entries []patchzip.Entry, readme string) ([]patchzip.Entry, bool)
 }
 
-type PatchCategory struct {
-	Name    string   `json:"name"`
-	Patches []Patch  `json:"patches"`
+type PatchApp struct {
+	*PatchManager
+	window         fyne.Window
+	status         *widget.Label
+	progressBar    *widget.ProgressBar
+	statusText     binding.String // bound to status, safe to set from any goroutine
+	progress       binding.Float  // bound to progressBar
+	pathEntry      *widget.SelectEntry
+	pathBanner     *fyne.Container
+	pathBannerText *widget.Label
+	detectButton   *widget.Button
+	pathIcon       *widget.Icon
+	pathInfo       *widget.Label
+	validateTimer  *time.Timer
+	pathActions    []*widget.Button
+	launchButton   *widget.Button // enabled by updateLaunchButton
+	searchEntry    *widget.Entry
+	historyList    *widget.List
+	backupList     *widget.List
+	backupFilter   *widget.Entry // limits backupList to the matching backups
+	backupUsage    *widget.Label // in the backup settings, see refreshBackupUsage
+	downloadList   *widget.List
+	completedList  *widget.List
+	categoryList   *widget.List
+	categoriesView fyne.CanvasObject // categoryList with its buttons
+	patchesView    *fyne.Container   // categoriesView, a category or search results
+	openCategory   string            // shown in patchesView, "" when it is not a category
+	images         sync.Map          // locations of icons and banners to their fyne.Resource, nil while loading or when they fail
+	previews       *fetchpool.Pool[image.Image]
+	stopBackups    chan struct{}
+	featuredView   *fyne.Container // the featured patches, see refreshFeatured
+	whatsNewView   *fyne.Container // the patches added or updated, see refreshWhatsNew
+	catalogBar     *fyne.Container // how old the catalogue is, see refreshCatalogStatus
+	catalogStatus  *widget.Label   // in catalogBar
+	detailsRefresh func()          // updates the install button of the open patch details, see refreshDetails
+	classFilter    *widget.Select  // next to searchEntry, see createClassFilter
+	stopFeatured   chan struct{}   // stops the featured carousel
+	operations     *operations
+	downloads      *download.Manager
+	healthBanner   *fyne.Container
+	blockedBanner  *fyne.Container
+	clipboardBar   *fyne.Container
+	clipboardSeen  map[string]bool // clipboard texts already suggested this session
+	databaseErr    error           // from the last load of the patch database
+	profileErr     error           // from the last load of the active profile
+	backupsPaused  bool            // from the tray, until DNF Patch quits
+	profileSelect  *widget.Select
+	tabs           *container.AppTabs
+	patchesTab     *container.TabItem
+	historyTab     *container.TabItem
+	backupTab      *container.TabItem
+	downloadsTab   *container.TabItem
+	logTab         *container.TabItem
+	settingsTab    *container.TabItem
+	lock           *instance.Lock // nil when the lock could not be taken
+	watcher        *watch.Watcher
+
+	// nextBackup is when the next auto backup is due, zero when none is
+	// scheduled, shown by nextBackupLabel in the backup settings
+	nextBackup      time.Time
+	nextBackupLabel *widget.Label
+
+	// The state of auto backup shown in the Backups tab, see
+	// refreshAutoBackupStatus
+	autoBackupStatus *widget.Label
+	backupNow        chan struct{} // asks the auto-backup goroutine to back up at once, nil when it is not running
+	lastAutoFailure  time.Time     // when an auto backup last failed this session
+	lastAutoErr      error         // why it failed
+	backupFailures   int           // auto backups failed since the Backups tab was last opened
+	// autoFailures counts the auto backups failed in a row, which
+	// failureBanner warns about from maxAutoFailures on
+	autoFailures  int
+	failureBanner *fyne.Container
 }
 
-type PatchDatabase struct {
-	Categories []PatchCategory `json:"categories"`
+// loadPatchDatabase fetches patches.json from the configured repository,
+// falling back to the last fetched copy and then to the bundled file.
+// Patches without a size get the size of their bundled file. The patches
+// imported from bundles are added even when the catalogue cannot be read,
+// and the categories are arranged as the user left them.
+// Cancelling ctx stops fetching and reading the catalogue. progress, which
+// may be nil, is called with the number of patches read so far.
+func (p *PatchManager) loadPatchDatabase(ctx context.Context, progress func(patches int)) (patchdb.Database, error) {
+	db, err := p.readPatchDatabase(ctx, progress)
+	if err == nil {
+		db.FillSizes(filepath.Join(p.exeDir, "patches"))
+	} else {
+		db = patchdb.Database{}
+	}
+	p.addLocalPatches(&db)
+	p.layout.Apply(&db)
+	return db, err
 }
 
-type BackupFile struct {
-	Path string `json:"path"`
-	Hash string `json:"hash"`
-	Size int64  `json:"size"`
+// setPatches replaces the patch catalogue, in the language of the user, and
+// rebuilds its index. IDs used by more than one patch are logged; lookups by
+// ID find the first of them.
+func (p *PatchManager) setPatches(db patchdb.Database) {
+	db.Localize(i18n.Language())
+	p.patches = db
+	p.patchIndex = patchdb.NewIndex(db)
+	for _, id := range p.patchIndex.Duplicates() {
+		slog.Warn("patch catalogue lists an ID more than once", "patch", id)
+	}
 }
 
-type Backup struct {
-	ID          string       `json:"id"`
-	Timestamp   time.Time    `json:"timestamp"`
-	Description string       `json:"description"`
-	Files       []BackupFile `json:"files"`
-	Type        string       `json:"type"` // auto, manual
-	GameVersion string       `json:"gameVersion"`
-}
+func (p *PatchManager) readPatchDatabase(ctx context.Context, progress func(patches int)) (patchdb.Database, error) {
+	p.metadataErr = nil
+	p.catalogFetched, p.catalogFetchErr = time.Time{}, nil
+	cachedPath := filepath.Join(p.cacheDir(), "patches.json")
+	if p.config.RepositoryURL != "" {
+		var db patchdb.Database
+		data, sig, err := p.fetchSignedRepository(ctx, p.config.RepositoryURL)
+		if err == nil {
+			if err = p.verifyRepository(data, sig); err != nil {
+				p.metadataErr = err
+			}
+		}
+		if err == nil {
+			db, err = patchdb.Decode(ctx, bytes.NewReader(data), progress)
+		}
+		if err == nil {
+			if err := os.MkdirAll(filepath.Dir(cachedPath), 0755); err == nil {
+				fsutil.WriteFileAtomic(cachedPath, data, 0644)
+				if sig != nil {
+					fsutil.WriteFileAtomic(cachedPath+".sig", sig, 0644)
+				}
+			}
+			p.catalogFetched = time.Now()
+			p.recordCatalog(db)
+			return db, nil
+		}
+		slog.Error("fetching repository failed", "url", p.config.RepositoryURL, "err", err)
+		p.catalogFetchErr = err
+
+		// Only a copy that passed the checks of the current keys is used
+		if data, err := os.ReadFile(cachedPath); err == nil {
+			sig, _ := os.ReadFile(cachedPath + ".sig")
+			if err := p.verifyRepository(data, sig); err != nil {
+				slog.Warn("ignoring cached patch database", "path", cachedPath, "err", err)
+			} else if db, err := patchdb.Decode(ctx, bytes.NewReader(data), progress); err == nil {
+				// The copy is written whenever the catalogue is fetched
+				if info, err := os.Stat(cachedPath); err == nil {
+					p.catalogFetched = info.ModTime()
+				}
+				return db, nil
+			}
+		}
+	}
 
-type BackupSettings struct {
-	AutoBackup        bool   `json:"autoBackup"`
-	BackupInterval    int    `json:"backupInterval"` // in seconds
-	MaxBackups        int    `json:"maxBackups"`
-	BackupPath        string `json:"backupPath"`
-	CompressionEnabled bool  `json:"compressionEnabled"`
+	// Read the bundled patches.json, which is trusted like the program
+	return patchdb.LoadContext(ctx, filepath.Join(p.exeDir, "patches", "patches.json"), progress)
 }
 
-type BackupDatabase struct {
-	Backups  []Backup       `json:"backups"`
-	Settings BackupSettings `json:"settings"`
+// repositoryKeys returns the public keys the repository's patches.json must
+// be signed with: the configured ones and those in the bundled
+// patches/patches.keys. Without any, unsigned metadata is accepted.
+func (p *PatchManager) repositoryKeys() ([]ed25519.PublicKey, error) {
+	text := strings.Join(p.config.RepositoryKeys, "\n")
+	if bundled, err := os.ReadFile(filepath.Join(p.exeDir, "patches", "patches.keys")); err == nil {
+		text += "\n" + string(bundled)
+	}
+	return patchdb.ParseKeys(text)
 }
 
-var (
-	primaryColor   = color.NRGBA{R: 255, G: 107, B: 107, A: 255}  // 主色调：现代感的珊瑚红
-	secondaryColor = color.NRGBA{R: 78, G: 205, B: 196, A: 255}   // 次要色调：清新的青绿色
-	accentColor    = color.NRGBA{R: 255, G: 230, B: 109, A: 255}  // 强调色：明亮的黄色
-	textColor      = color.NRGBA{R: 255, G: 255, B: 255, A: 230}  // 文本颜色：柔和的白色
-	bgColor        = color.NRGBA{R: 45, G: 52, B: 54, A: 255}     // 背景色：深色渐变起始
-
-	// Common DNF installation paths
-	commonPaths = []string{
-		"C:\\Wegame\\WeGame\\games\\DNF",
-		"D:\\Wegame\\WeGame\\games\\DNF",
-		"E:\\Wegame\\WeGame\\games\\DNF",
-		"C:\\Program Files\\Wegame\\WeGame\\games\\DNF",
-		"C:\\Program Files (x86)\\Wegame\\WeGame\\games\\DNF",
-		"D:\\Program Files\\Wegame\\WeGame\\games\\DNF",
-		"D:\\Program Files (x86)\\Wegame\\WeGame\\games\\DNF",
+// verifyRepository checks the signature of the repository's patches.json
+// when repository keys are configured.
+func (p *PatchManager) verifyRepository(data, sig []byte) error {
+	keys, err := p.repositoryKeys()
+	if err != nil || len(keys) == 0 {
+		return err
 	}
-)
-
-type PatchApp struct {
-	window         fyne.Window
-	dnfPath        string
-	status         *widget.Label
-	progressBar    *widget.ProgressBar
-	pathEntry      *widget.Entry
-	patches        PatchDatabase
-	searchEntry    *widget.Entry
-	history        []InstallHistory
-	historyFile    string
-	backups        BackupDatabase
-	backupTimer    *time.Timer
+	return patchdb.Verify(data, sig, keys)
 }
 
-func loadPatchDatabase() (PatchDatabase, error) {
-	var db PatchDatabase
-	
-	// Get the executable directory
-	ex, err := os.Executable()
+// fetchSignedRepository fetches patches.json and, when repository keys are
+// configured, its detached signature patches.json.sig. A missing signature
+// is returned as nil.
+func (p *PatchManager) fetchSignedRepository(ctx context.Context, repoURL string) (data, sig []byte, err error) {
+	data, err = p.fetchRepository(ctx, repoURL)
 	if err != nil {
-		return db, err
+		return nil, nil, err
 	}
-	exPath := filepath.Dir(ex)
-	
-	// Read patches.json
-	data, err := ioutil.ReadFile(filepath.Join(exPath, "patches", "patches.json"))
+	if keys, err := p.repositoryKeys(); err == nil && len(keys) == 0 {
+		return data, nil, nil
+	}
+	sig, err = p.fetchRepository(ctx, repoURL+".sig")
 	if err != nil {
-		return db, err
+		slog.Warn("fetching repository signature failed", "url", repoURL+".sig", "err", err)
 	}
+	return data, sig, nil
+}
 
-	err = json.Unmarshal(data, &db)
-	return db, err
+// httpClient returns a client honouring the configured proxy.
+func (p *PatchManager) httpClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if p.config.Proxy != "" {
+		proxyURL, err := url.Parse(p.config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %v", p.config.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
 }
 
-func createPatchList(patches []Patch, onSelect func(patch Patch)) *widget.List {
+func (p *PatchManager) fetchRepository(ctx context.Context, repoURL string) ([]byte, error) {
+	client, err := p.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	return patchdb.Fetch(ctx, client, repoURL)
+}
+
+func createPatchList(patches []patchdb.Patch, classOf func(patch patchdb.Patch) string, onSelect func(patch patchdb.Patch)) *widget.List {
 	items := make([]string, len(patches))
 	for i, patch := range patches {
 		items[i] = patch.Name
@@ -168,15 +362,20 @@ func createPatchList(patches []Patch, onSelect func(patch Patch)) *widget.List {
 	list := widget.NewList(
 		func() int { return len(patches) },
 		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.FileIcon()),
-				widget.NewLabel("Template"),
-			)
+			label := widget.NewLabel("Template")
+			label.Truncation = fyne.TextTruncateEllipsis
+			rating := newRatingWidget(patchdb.Rating{}, theme.IconInlineSize()*0.75, false)
+			size := widget.NewLabel("")
+			return container.NewBorder(nil, nil, widget.NewIcon(theme.FileIcon()), container.NewHBox(newClassBadge(), size, rating), label)
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
 			box := item.(*fyne.Container)
-			label := box.Objects[1].(*widget.Label)
+			label := box.Objects[0].(*widget.Label)
 			label.SetText(patches[id].Name)
+			right := box.Objects[2].(*fyne.Container)
+			setClassBadge(right.Objects[0].(*fyne.Container), classOf(patches[id]))
+			right.Objects[1].(*widget.Label).SetText(patchSize(patches[id]))
+			right.Objects[2].(*ratingWidget).SetRating(patches[id].Rating)
 		},
 	)
 
@@ -187,160 +386,318 @@ func createPatchList(patches []Patch, onSelect func(patch Patch)) *widget.List {
 	return list
 }
 
-func showPatchDetails(patch Patch, parent fyne.Window, onInstall func(patch Patch)) {
-	content := container.NewVBox(
-		widget.NewLabelWithStyle(patch.Name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+func newPatchManager() *PatchManager {
+	m := &PatchManager{npkCache: npk.NewCache(), hashes: fsutil.NewHashCache()}
+	
+	if ex, err := os.Executable(); err == nil {
+		m.exeDir = filepath.Dir(ex)
+	}
+	m.resolveDataDir()
+	return m
+}
+
+func newPatchApp() *PatchApp {
+	a := app.New()
+	a.Settings().SetTheme(&brandTheme{font: bundledFont()})
+	win := a.NewWindow(i18n.T("app.windowTitle"))
+	
+	p := &PatchApp{
+		PatchManager: newPatchManager(),
+		window:       win,
+		statusText:   binding.NewString(),
+		progress:     binding.NewFloat(),
+		operations:   newOperations(),
+	}
+	p.operations.changed = p.updateLaunchButton
+	p.previews = p.newPreviewPool()
+	p.askZipPassword = p.promptZipPassword
+	p.askZipEntries = p.promptZipEntries
+
+	// The UI is built once the config has selected the language; until
+	// then messages follow the system language
+	i18n.SetLanguage("auto")
+	return p
+}
+
+func createCard(title string, content fyne.CanvasObject) *fyne.Container {
+	titleLabel := canvas.NewText(title, primaryColor)
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+	titleLabel.TextSize = 16
+
+	card := container.NewVBox(
+		titleLabel,
 		widget.NewSeparator(),
-		widget.NewLabel("Description: " + patch.Description),
-		widget.NewLabel("Version: " + patch.Version),
-		widget.NewLabel("Author: " + patch.Author),
-		widget.NewLabel("Tags: " + fmt.Sprintf("%v", patch.Tags)),
+		content,
 	)
 
-	installButton := widget.NewButtonWithIcon("Install Patch", theme.DownloadIcon(), func() {
-		onInstall(patch)
-		dialog.ShowInformation("Success", "Patch installation started!", parent)
-	})
-	installButton.Importance = widget.HighImportance
+	return container.NewPadded(
+		container.NewMax(
+			newThemedBackground(false),
+			container.NewPadded(card),
+		),
+	)
+}
+
+func (p *PatchManager) addToHistory(patch patchdb.Patch, status string) {
+	e, err := p.history.Add(patch.ID, patch.Name, patch.Version, status)
+	p.stats.Add(e)
+	if err != nil {
+		slog.Error("saving history failed", "patch", patch.ID, "err", err)
+	}
+}
 
-	content.Add(installButton)
+// reliabilityText sums up how installing patch went before, such as
+// "Installed 3 times, failed once, last failure ...", or is empty when it was
+// never installed.
+func (p *PatchApp) reliabilityText(patch patchdb.Patch) string {
+	stats, ok := p.stats.Get(patch.ID)
+	if !ok {
+		return ""
+	}
+	installs := i18n.Plural(stats.Installs, "reliability.installedOnce", "reliability.installed")
+	if stats.Failures == 0 {
+		return installs
+	}
+	return i18n.T("reliability.failures", installs,
+		i18n.Plural(stats.Failures, "reliability.failedOnce", "reliability.failed"),
+		p.formatTime(stats.LastFailure.Timestamp), stats.LastFailure.Error)
+}
 
-	dialog.ShowCustom("Patch Details", "Close", content, parent)
+// addFailureToHistory records that installing patch failed with cause.
+func (p *PatchManager) addFailureToHistory(patch patchdb.Patch, cause error) {
+	e, err := p.history.AddFailure(patch.ID, patch.Name, patch.Version, cause)
+	p.stats.Add(e)
+	if err != nil {
+		slog.Error("saving history failed", "patch", patch.ID, "err", err)
+	}
 }
 
-func findDNFPath() string {
-	// First check common paths
-	for _, path := range commonPaths {
-		if _, err := os.Stat(path); err == nil {
-			if isValidDNFPath(path) {
-				return path
-			}
-		}
+// setDNFPath makes path the active game directory and remembers it in the
+// config so it is restored on the next launch.
+func (p *PatchApp) setDNFPath(path string) {
+	p.dnfPath = path
+	if p.pathEntry.Text != path {
+		p.pathEntry.SetText(path)
 	}
+	p.pathBanner.Hide()
 
-	// For Windows, try to find through drive letters
-	if runtime.GOOS == "windows" {
-		for _, drive := range "CDEFGHIJKLMNOPQRSTUVWXYZ" {
-			root := string(drive) + ":\\"
-			path := findDNFInDirectory(root)
-			if path != "" {
-				return path
-			}
+	recent := []string{path}
+	for _, r := range p.config.RecentPaths {
+		if !strings.EqualFold(r, path) && len(recent) < maxRecentPaths {
+			recent = append(recent, r)
 		}
 	}
+	if profile := p.activeProfile(); profile != nil {
+		profile.Path = path
+	}
+	p.config.RecentPaths = recent
+	p.pathEntry.SetOptions(recent)
+	p.showPathValidation(path)
+	p.startBackupTimer()
+	if p.backupsInGame() {
+		p.warnBackupsInGame(nil)
+	}
 
-	return defaultDNFPath
+	if err := p.saveConfig(); err != nil {
+		slog.Error("saving config failed", "path", p.configPath(), "err", err)
+		p.updateStatus(i18n.T("status.saveConfigFailed", err))
+	}
 }
 
-func findDNFInDirectory(root string) string {
-	// Check if this directory contains DNF
-	if isValidDNFPath(filepath.Join(root, "Wegame", "WeGame", "games", "DNF")) {
-		return filepath.Join(root, "Wegame", "WeGame", "games", "DNF")
+// restoreDNFPath applies the game path saved in the active profile, asking
+// the user to select it again if the game has been moved or uninstalled since.
+func (p *PatchApp) restoreDNFPath() {
+	p.pathEntry.SetOptions(p.config.RecentPaths)
+	p.pathBanner.Hide()
+	profile := p.activeProfile()
+	if profile == nil || profile.Path == "" {
+		p.pathEntry.SetText("")
+		return
 	}
 
-	// Check Program Files
-	programFiles := []string{
-		filepath.Join(root, "Program Files", "Wegame", "WeGame", "games", "DNF"),
-		filepath.Join(root, "Program Files (x86)", "Wegame", "WeGame", "games", "DNF"),
+	if !gamepath.IsValid(profile.Path) {
+		p.pathEntry.SetText("")
+		p.pathBannerText.SetText(i18n.T("path.savedInvalid", profile.Path))
+		p.pathBanner.Show()
+		return
 	}
 
-	for _, path := range programFiles {
-		if isValidDNFPath(path) {
-			return path
+	p.dnfPath = profile.Path
+	p.pathEntry.SetText(p.dnfPath)
+	p.showPathValidation(p.dnfPath)
+}
+
+// detectDNFPath searches for game installations in the background and lets
+// the user choose when more than one is found.
+func (p *PatchApp) detectDNFPath() {
+	p.detectButton.Disable()
+	p.updateStatus(i18n.T("path.detecting"))
+
+	go func() {
+		defer p.recoverPanic(i18n.T("op.detect"))
+		ctx, end := p.operations.begin(i18n.T("op.detect"))
+		candidates := gamepath.Find(ctx)
+		end()
+		p.detectButton.Enable()
+
+		switch len(candidates) {
+		case 0:
+			p.updateStatus(i18n.T("path.notFound"))
+		case 1:
+			p.setDNFPath(candidates[0])
+			p.updateStatus(i18n.T("path.detectedAt", candidates[0]))
+		default:
+			p.updateStatus(i18n.T("path.foundSeveral", len(candidates)))
+			p.chooseDNFPath(candidates)
 		}
+	}()
+}
+
+func (p *PatchApp) chooseDNFPath(candidates []string) {
+	choice := widget.NewRadioGroup(candidates, nil)
+	choice.SetSelected(candidates[0])
+	choice.Required = true
+
+	dialog.ShowCustomConfirm(i18n.T("path.selectTitle"),
+		i18n.T("common.use"),
+		i18n.T("common.cancel"),
+		container.NewVBox(
+			widget.NewLabel(i18n.T("path.selectMessage")),
+			choice,
+		),
+		func(ok bool) {
+			if ok && choice.Selected != "" {
+				p.setDNFPath(choice.Selected)
+			}
+		},
+		p.window)
+}
+
+// schedulePathValidation validates the path entry once the user has stopped
+// typing for a moment.
+func (p *PatchApp) schedulePathValidation() {
+	if p.validateTimer != nil {
+		p.validateTimer.Stop()
 	}
+	p.validateTimer = time.AfterFunc(400*time.Millisecond, p.validatePathEntry)
+}
 
-	return ""
+// validatePathEntry updates the indicator for the text in pathEntry. The game
+// path only follows the entry when it points at a DNF installation.
+func (p *PatchApp) validatePathEntry() {
+	path := strings.TrimSpace(p.pathEntry.Text)
+	if p.showPathValidation(path) && path != p.dnfPath {
+		p.setDNFPath(path)
+	}
 }
 
-func isValidDNFPath(path string) bool {
-	// Check for specific DNF files/folders that should exist
-	indicators := []string{
-		"DNF.exe",
-		"imagepack2",
-		"Script.pvf",
+func (p *PatchApp) showPathValidation(path string) bool {
+	if path == "" {
+		p.pathIcon.Hide()
+		p.pathInfo.Hide()
+		p.setPathActionsEnabled(false)
+		return false
 	}
 
-	for _, indicator := range indicators {
-		indicatorPath := filepath.Join(path, indicator)
-		if _, err := os.Stat(indicatorPath); err == nil {
-			return true
+	check := gamepath.Inspect(path)
+	valid := check.Valid()
+	if valid {
+		p.pathIcon.SetResource(theme.NewSuccessThemedResource(theme.ConfirmIcon()))
+		info := i18n.T("path.detected")
+		if version := gamepath.DetectVersion(path); version != "" {
+			info = i18n.T("path.detectedVersion", version)
 		}
+		p.pathInfo.SetText(info)
+	} else {
+		p.pathIcon.SetResource(theme.NewErrorThemedResource(theme.CancelIcon()))
+		p.pathInfo.SetText(i18n.T("path.notGame", strings.Join(check.Missing(), " / ")))
 	}
+	p.pathIcon.Show()
+	p.pathInfo.Show()
 
-	return false
+	// A path the user explicitly confirmed is usable even if it looks wrong
+	p.setPathActionsEnabled(valid || path == p.dnfPath)
+	return valid
 }
 
-func newPatchApp() *PatchApp {
-	a := app.New()
-	win := a.NewWindow("DNF Patch Import Tool")
-	
-	p := &PatchApp{
-		window:      win,
-		status:      widget.NewLabel("Ready to import patches"),
-		progressBar: widget.NewProgressBar(),
+// setPathActionsEnabled toggles the actions that operate on the game
+// directory.
+func (p *PatchApp) setPathActionsEnabled(enabled bool) {
+	for _, button := range p.pathActions {
+		if enabled {
+			button.Enable()
+		} else {
+			button.Disable()
+		}
 	}
-
-	p.createUI()
-	return p
+	p.updateLaunchButton()
+	p.refreshDetails()
 }
 
-func createCard(title string, content fyne.CanvasObject) *fyne.Container {
-	titleLabel := canvas.NewText(title, primaryColor)
-	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
-	titleLabel.TextSize = 16
-
-	card := container.NewVBox(
-		titleLabel,
-		widget.NewSeparator(),
-		content,
-	)
-
-	return container.NewPadded(
-		container.NewMax(
-			canvas.NewRectangle(color.White),
-			container.NewPadded(card),
-		),
-	)
+// pathUsable reports whether actions on the game directory are allowed.
+func (p *PatchApp) pathUsable() bool {
+	return p.dnfPath != "" && strings.TrimSpace(p.pathEntry.Text) == p.dnfPath
 }
 
-func (p *PatchApp) loadHistory() error {
-	historyPath := filepath.Join(filepath.Dir(p.historyFile), "install_history.json")
-	data, err := ioutil.ReadFile(historyPath)
-	if os.IsNotExist(err) {
-		p.history = []InstallHistory{}
-		return nil
+func (p *PatchApp) confirmDNFPath(path string) {
+	path = strings.TrimSpace(path)
+	if path == "" || path == p.dnfPath {
+		return
 	}
-	if err != nil {
-		return err
+	check := gamepath.Inspect(path)
+	if check.Valid() {
+		p.setDNFPath(path)
+		return
 	}
-	return json.Unmarshal(data, &p.history)
+	dialog.ShowConfirm(i18n.T("path.useAnywayTitle"),
+		i18n.T("path.useAnyway", path, strings.Join(check.Missing(), ", ")),
+		func(ok bool) {
+			if ok {
+				p.setDNFPath(path)
+			}
+		},
+		p.window)
 }
 
-func (p *PatchApp) saveHistory() error {
-	historyPath := filepath.Join(filepath.Dir(p.historyFile), "install_history.json")
-	data, err := json.MarshalIndent(p.history, "", "    ")
-	if err != nil {
-		return err
+func (p *PatchApp) browseDNFPath() {
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if uri == nil {
+			return
+		}
+		p.setDNFPath(uri.Path())
+	}, p.window)
+
+	if start := p.browseStartDir(); start != "" {
+		if lister, err := storage.ListerForURI(storage.NewFileURI(start)); err == nil {
+			folderDialog.SetLocation(lister)
+		}
 	}
-	return ioutil.WriteFile(historyPath, data, 0644)
+	folderDialog.Show()
 }
 
-func (p *PatchApp) addToHistory(patch Patch, status string) {
-	history := InstallHistory{
-		PatchID:    patch.ID,
-		PatchName:  patch.Name,
-		Version:    patch.Version,
-		Timestamp:  time.Now(),
-		Status:     status,
+// browseStartDir picks the folder the Browse dialog opens in: the parent of
+// the current game path, or the first Wine prefix on non-Windows systems.
+func (p *PatchApp) browseStartDir() string {
+	if p.dnfPath != "" {
+		if info, err := os.Stat(filepath.Dir(p.dnfPath)); err == nil && info.IsDir() {
+			return filepath.Dir(p.dnfPath)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if drives := gamepath.WinePrefixes(); len(drives) > 0 {
+			return drives[0]
+		}
 	}
-	p.history = append(p.history, history)
-	p.saveHistory()
+	return ""
 }
 
 func (p *PatchApp) createSearchUI() fyne.CanvasObject {
 	p.searchEntry = widget.NewEntry()
-	p.searchEntry.SetPlaceHolder("Search patches...")
+	p.searchEntry.SetPlaceHolder(i18n.T("patch.search"))
 	
 	searchIcon := widget.NewIcon(theme.SearchIcon())
 	
@@ -351,92 +708,44 @@ func (p *PatchApp) createSearchUI() fyne.CanvasObject {
 	)
 }
 
-func (p *PatchApp) filterPatches(query string) []Patch {
-	if query == "" {
-		return nil
-	}
-	
-	query = strings.ToLower(query)
-	var results []Patch
-	
-	for _, category := range p.patches.Categories {
-		for _, patch := range category.Patches {
-			if strings.Contains(strings.ToLower(patch.Name), query) ||
-				strings.Contains(strings.ToLower(patch.Description), query) ||
-				containsTag(patch.Tags, query) {
-				results = append(results, patch)
-			}
-		}
-	}
-	
-	// Sort by rating and downloads
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Rating.Average == results[j].Rating.Average {
-			return results[i].Downloads > results[j].Downloads
-		}
-		return results[i].Rating.Average > results[j].Rating.Average
+// filterPatches searches the catalogue, the classes of the patches and the
+// user's own notes and tags, keeping the patches of the class chosen in the
+// class filter. With a class chosen an empty query matches every patch of
+// the class.
+func (p *PatchApp) filterPatches(query string) []patchdb.Patch {
+	results := p.patchIndex.Search(query, func(patch patchdb.Patch) []string {
+		return append(p.notes.Get(patch.ID).Texts(), p.patchClass(patch))
 	})
-	
-	return results
-}
-
-func containsTag(tags []string, query string) bool {
-	for _, tag := range tags {
-		if strings.Contains(strings.ToLower(tag), query) {
-			return true
-		}
+	class := p.selectedClass()
+	if class == "" {
+		return results
 	}
-	return false
-}
-
-func createRatingWidget(rating PatchRating) fyne.CanvasObject {
-	starsContainer := container.NewHBox()
-	
-	for i := 0; i < 5; i++ {
-		var star *widget.Icon
-		if float64(i) < rating.Average {
-			star = widget.NewIcon(theme.MailForwardIcon()) // Using a different icon for filled stars
-		} else {
-			star = widget.NewIcon(theme.MailReplyIcon()) // Using a different icon for empty stars
+	if query == "" {
+		results = p.patchIndex.Patches()
+	}
+	var filtered []patchdb.Patch
+	for _, patch := range results {
+		if p.patchClass(patch) == class {
+			filtered = append(filtered, patch)
 		}
-		starsContainer.Add(star)
 	}
-	
-	ratingLabel := widget.NewLabel(fmt.Sprintf("%.1f (%d ratings)", rating.Average, rating.Count))
-	
-	return container.NewHBox(starsContainer, ratingLabel)
+	return filtered
 }
 
-func (p *PatchApp) createPreviewUI(previews []PatchPreview) fyne.CanvasObject {
-	if len(previews) == 0 {
-		return widget.NewLabel("No previews available")
+func (p *PatchApp) checkForUpdates(patch patchdb.Patch) {
+	// The list may still show the patch from before a refresh
+	if current, ok := p.catalogPatch(patch.ID); ok {
+		patch = current
 	}
-
-	tabs := container.NewAppTabs()
-	for _, preview := range previews {
-		previewImage := canvas.NewImageFromFile(preview.URL)
-		previewImage.FillMode = canvas.ImageFillOriginal
-		previewImage.SetMinSize(fyne.NewSize(400, 300))
-		
-		description := widget.NewLabel(preview.Description)
-		content := container.NewVBox(previewImage, description)
-		
-		tabs.Append(container.NewTabItem("Preview", content))
-	}
-	
-	return tabs
-}
-
-func (p *PatchApp) checkForUpdates(patch Patch) {
-	if patch.Version != patch.UpdateInfo.LatestVersion {
-		dialog.ShowConfirm("Update Available",
-			fmt.Sprintf("A new version (%s) is available. Current version: %s\n\nChangelog:\n%s",
+	if latest := patch.UpdateInfo.LatestVersion; latest != "" && patch.Version != latest {
+		dialog.ShowConfirm(i18n.T("update.title"),
+			i18n.T("update.message",
 				patch.UpdateInfo.LatestVersion,
 				patch.Version,
 				patch.UpdateInfo.Changelog),
 			func(update bool) {
 				if update {
-					p.updateStatus(fmt.Sprintf("Downloading update for %s...", patch.Name))
+					p.updateStatus(i18n.T("update.downloading", patch.Name))
 					// TODO: Implement update download
 				}
 			},
@@ -445,433 +754,892 @@ func (p *PatchApp) checkForUpdates(patch Patch) {
 }
 
 func (p *PatchApp) createHistoryUI() fyne.CanvasObject {
-	list := widget.NewList(
-		func() int { return len(p.history) },
+	p.historyList = widget.NewList(
+		func() int { return len(p.history.Entries()) },
 		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.DocumentIcon()),
-				widget.NewLabel("Template"),
-				widget.NewLabel("Template"),
-			)
+			// The name gives way to the time when the row is too narrow
+			nameLabel := widget.NewLabel("Template")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil,
+				widget.NewIcon(theme.DocumentIcon()), widget.NewLabel("Template"), nameLabel)
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
 			box := item.(*fyne.Container)
-			nameLabel := box.Objects[1].(*widget.Label)
+			nameLabel := box.Objects[0].(*widget.Label)
 			timeLabel := box.Objects[2].(*widget.Label)
 			
-			history := p.history[len(p.history)-1-id] // Show newest first
+			entries := p.history.Entries()
+			if id >= len(entries) {
+				return
+			}
+			history := entries[len(entries)-1-id] // Show newest first
 			nameLabel.SetText(fmt.Sprintf("%s (%s)", history.PatchName, history.Version))
-			timeLabel.SetText(history.Timestamp.Format("2006-01-02 15:04:05"))
+			timeLabel.SetText(p.formatTime(history.Timestamp))
 		},
 	)
 	
+	checkButton := widget.NewButtonWithIcon(i18n.T("history.check"), theme.ViewRefreshIcon(), func() {
+		p.checkInstalledPatches(false)
+	})
+	checkButton.Disable()
+	p.pathActions = append(p.pathActions, checkButton)
+	
 	return container.NewBorder(
-		widget.NewLabel("Installation History"),
+		container.NewVBox(
+			container.NewHBox(
+				widget.NewLabel(i18n.T("history.title")),
+				checkButton,
+			),
+			p.createBlockedBanner(),
+		),
 		nil, nil, nil,
-		list,
+		p.historyList,
 	)
 }
 
-func (p *PatchApp) loadBackupDatabase() error {
-	backupPath := filepath.Join(filepath.Dir(p.historyFile), "backup", "backup.json")
-	data, err := ioutil.ReadFile(backupPath)
-	if os.IsNotExist(err) {
-		// Create default backup settings
-		p.backups = BackupDatabase{
-			Settings: BackupSettings{
-				AutoBackup:        true,
-				BackupInterval:    3600, // 1 hour
-				MaxBackups:        10,
-				BackupPath:        "backups",
-				CompressionEnabled: true,
-			},
-		}
-		return nil
-	}
+// createBackup backs up the NPK files of the game, recording the game
+// version with the backup.
+func (p *PatchManager) createBackup(opts backup.Options) (backup.Backup, error) {
+	return createJournaledBackup(p.journal, p.backups, p.dnfPath, opts)
+}
+
+// createJournaledBackup creates a backup of the game at gameDir in store,
+// keeping it in the journal while the files are copied.
+func createJournaledBackup(jr *journal.Journal, store *backup.Store, gameDir string, opts backup.Options) (backup.Backup, error) {
+	id, err := jr.Begin(journal.Entry{
+		Operation:   journal.Backup,
+		GameDir:     gameDir,
+		Description: opts.Description,
+		BackupType:  opts.Type,
+		State:       journal.Copying,
+	})
 	if err != nil {
-		return err
+		return backup.Backup{}, fmt.Errorf("writing journal failed: %v", err)
 	}
-	return json.Unmarshal(data, &p.backups)
+	
+	opts.GameVersion = gamepath.DetectVersion(gameDir)
+	b, err := store.Create(gameDir, opts)
+	jr.End(id)
+	return b, err
 }
 
-func (p *PatchApp) saveBackupDatabase() error {
-	backupPath := filepath.Join(filepath.Dir(p.historyFile), "backup", "backup.json")
-	data, err := json.MarshalIndent(p.backups, "", "    ")
+// restoreBackup copies the files of b back into the game, keeping the
+// restore in the journal until it is complete. A restore cancelled through
+// ctx stays in the journal so it can be resumed. The copies are verified
+// when VerifyWrites is set; files already matching b are skipped when
+// opts.CurrentHash is set.
+func (p *PatchManager) restoreBackup(ctx context.Context, b backup.Backup, opts backup.RestoreOptions) (backup.RestoreSummary, error) {
+	id, err := p.journal.Begin(journal.Entry{
+		Operation:   journal.Restore,
+		GameDir:     p.dnfPath,
+		Description: b.Description,
+		BackupID:    b.ID,
+		State:       journal.Copying,
+	})
 	if err != nil {
-		return err
+		return backup.RestoreSummary{}, fmt.Errorf("writing journal failed: %v", err)
+	}
+	
+	opts.Verify = p.config.VerifyWrites
+	summary, err := p.backups.Restore(ctx, p.dnfPath, b, opts)
+	if !cancelled(err) {
+		p.journal.End(id)
+	}
+	if err == nil {
+		slog.Info("restored backup", "backup", b.ID, "restored", summary.Restored, "unchanged", summary.Unchanged)
+	}
+	for _, linkErr := range summary.LinkErrors {
+		slog.Warn("restoring link failed", "backup", b.ID, "err", linkErr)
 	}
-	return ioutil.WriteFile(backupPath, data, 0644)
+	return summary, err
 }
 
-func (p *PatchApp) calculateFileHash(path string) (string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
+// showRestoreProgress shows how far a restore is in the progress bar and the
+// status line, and logs every file it completes.
+func (p *PatchApp) showRestoreProgress(s backup.RestoreStatus) {
+	var percent int
+	if s.Total > 0 {
+		p.progress.Set(float64(s.Done) / float64(s.Total))
+		percent = int(s.Done * 100 / s.Total)
 	}
-	defer f.Close()
+	key := "backup.restoringFile"
+	switch s.Phase {
+	case backup.Comparing:
+		key = "backup.comparingFile"
+	case backup.Verifying:
+		key = "backup.verifyingFile"
+	}
+	msg := i18n.T(key, percent, s.File, s.Files, filepath.Base(s.Path))
+	// Comparing mostly finds files to skip, which are not worth a log line
+	if s.FileDone && s.Phase != backup.Comparing {
+		p.updateStatus(msg)
+	} else {
+		p.statusText.Set(msg)
+	}
+}
 
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
+// restoreOptions are the options of restores started from the window: they
+// skip unchanged files, show their progress and offer to copy a file again
+// that failed verification.
+func (p *PatchApp) restoreOptions() backup.RestoreOptions {
+	return backup.RestoreOptions{
+		Progress:    p.showRestoreProgress,
+		Retry:       p.confirmRetryFile,
+		CurrentHash: p.hashes.Hash,
 	}
+}
 
-	return hex.EncodeToString(h.Sum(nil)), nil
+// confirmRetryFile asks whether to write path again after err, typically a
+// *fsutil.MismatchError, and waits for the answer. It must not be called
+// from the UI goroutine.
+func (p *PatchApp) confirmRetryFile(path string, err error) bool {
+	slog.Error("written file failed verification", "file", path, "err", err)
+	answer := make(chan bool, 1)
+	dialog.ShowConfirm(i18n.T("verifyWrite.title"), i18n.T("verifyWrite.retry", filepath.Base(path), err),
+		func(ok bool) { answer <- ok }, p.window)
+	return <-answer
 }
 
-func (p *PatchApp) createBackup(description string, backupType string) error {
-	// Create backup ID
-	backupID := fmt.Sprintf("backup_%s", time.Now().Format("20060102_150405"))
+// offerRetryWrite reports whether err is a written file failing
+// verification and, if so, offers to call retry in the background.
+func (p *PatchApp) offerRetryWrite(err error, retry func()) bool {
+	var mismatch *fsutil.MismatchError
+	if !errors.As(err, &mismatch) {
+		return false
+	}
+	dialog.ShowConfirm(i18n.T("verifyWrite.title"), i18n.T("verifyWrite.retry", filepath.Base(mismatch.Path), err),
+		func(ok bool) {
+			if ok {
+				go retry()
+			}
+		}, p.window)
+	return true
+}
+
+// backupRetryDelay is how long an auto backup put off while the game is
+// played waits before trying again.
+const backupRetryDelay = 5 * time.Minute
+
+// missedBackupDelay is how long after starting an auto backup missed while
+// the tool was closed runs, so that it does not slow the start down.
+const missedBackupDelay = 2 * time.Minute
+
+// failedBackupRetry is how long after failing with an error that may go
+// away by itself an auto backup is tried once more.
+const failedBackupRetry = 2 * time.Minute
+
+// scheduleRecheck is the longest the auto-backup goroutine waits before
+// looking at the clock again, so that waking from sleep or a changed clock
+// does not delay a backup much.
+const scheduleRecheck = 15 * time.Minute
+
+// startBackupTimer (re)starts the auto-backup goroutine with the current
+// profile, game path and settings, stopping the previous one. Backups run
+// by the schedule of the settings, counted from the last auto backup of
+// the profile, so one missed while the tool was closed runs shortly after
+// it starts. A backup due while the game runs, or before the user has been
+// idle as long as configured, is put off by backupRetryDelay until it can
+// run, so that it does not slow the game down. A backup failing with an
+// error that may be transient is tried once more after failedBackupRetry.
+func (p *PatchApp) startBackupTimer() {
+	p.stopBackupTimer()
 	
-	// Create backup directory
-	backupDir := filepath.Join(filepath.Dir(p.historyFile), p.backups.Settings.BackupPath, backupID)
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return err
+	settings := p.backups.Settings()
+	if !settings.Scheduled() || !p.pathUsable() || p.backupsPaused {
+		p.showNextBackup(time.Time{})
+		return
 	}
+	
+	// The goroutine keeps its own copies so switching profiles or paths
+	// never races with it
+	store, gameDir, jr := p.backups, p.dnfPath, p.journal
+	stop, now := make(chan struct{}), make(chan struct{}, 1)
+	p.stopBackups, p.backupNow = stop, now
+	go func() {
+		defer p.recoverPanic(i18n.T("op.autoBackup"))
+		started := time.Now()
+		// Set by backupNow, which skips the schedule and the deferral
+		forced := false
+		// When the failed backup is tried again, zero when it is not
+		var retryAt time.Time
+		for {
+			if !forced {
+				last := store.LastAutoBackup()
+				if last.IsZero() {
+					last = started
+				}
+				next := settings.NextRun(last, time.Now())
+				if !retryAt.IsZero() {
+					next = retryAt
+				}
+				if earliest := started.Add(missedBackupDelay); next.Before(earliest) {
+					next = earliest
+				}
+				p.showNextBackup(next)
+				if wait := time.Until(next); wait > 0 {
+					if wait > scheduleRecheck {
+						wait = scheduleRecheck
+					}
+					select {
+					case <-stop:
+						return
+					case <-now:
+						forced = true
+					case <-time.After(wait):
+					}
+					continue
+				}
 
-	// Collect files to backup
-	var files []BackupFile
-	err := filepath.Walk(filepath.Join(p.dnfPath, "imagepack2"), func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".npk") {
-			hash, err := p.calculateFileHash(path)
-			if err != nil {
-				return err
+				if reason := backupDeferral(gameDir, settings.IdleMinutes); reason != "" {
+					slog.Info("auto backup deferred", "path", gameDir, "reason", reason, "retry", backupRetryDelay)
+					p.showNextBackup(time.Now().Add(backupRetryDelay))
+					select {
+					case <-stop:
+						return
+					case <-now:
+						forced = true
+					case <-time.After(backupRetryDelay):
+					}
+					continue
+				}
 			}
-			
-			relPath, err := filepath.Rel(p.dnfPath, path)
-			if err != nil {
-				return err
+			forced = false
+			retrying := !retryAt.IsZero()
+			retryAt = time.Time{}
+			// The schedule counts from when the backup ran, however long
+			// it was put off, and whether or not it succeeds
+			if err := store.SetLastAutoBackup(time.Now()); err != nil {
+				slog.Error("saving the time of the auto backup failed", "err", err)
 			}
-			
-			files = append(files, BackupFile{
-				Path: relPath,
-				Hash: hash,
-				Size: info.Size(),
+			ctx, end := p.operations.begin(i18n.T("op.autoBackup"))
+			b, err := createJournaledBackup(jr, store, gameDir, backup.Options{
+				Description: i18n.T("backup.autoDescription"),
+				Type:        "auto",
+				Context:     ctx,
 			})
-			
-			// Copy file to backup directory
-			destPath := filepath.Join(backupDir, relPath)
-			if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-				return err
-			}
-			
-			src, err := os.Open(path)
-			if err != nil {
-				return err
+			end()
+			if cancelled(err) {
+				return
 			}
-			defer src.Close()
-			
-			dst, err := os.Create(destPath)
 			if err != nil {
-				return err
-			}
-			defer dst.Close()
-			
-			if _, err := io.Copy(dst, src); err != nil {
-				return err
+				slog.Error("auto backup failed", "path", gameDir, "retried", retrying, "err", err)
+				if !retrying && !fsutil.Permanent(err) {
+					retryAt = time.Now().Add(failedBackupRetry)
+					p.updateStatus(i18n.T("backup.autoRetrying", intervalName(int(failedBackupRetry/time.Second)), err))
+					continue
+				}
+				p.autoBackupFailed(err)
+				// Failures are announced whatever the notification settings
+				p.notify(true, nil, i18n.T("backup.autoFailed"), err.Error())
+				continue
 			}
+			p.backupList.Refresh()
+			p.backupSucceeded()
+			p.notify(p.config.Notify.Backups, p.backupTab, i18n.T("backup.autoFinished"), i18n.T("backup.autoFinishedMessage", len(b.Files)))
 		}
-		return nil
-	})
-	if err != nil {
-		return err
+	}()
+}
+
+// showNextBackup records when the next auto backup is due, zero when none
+// is scheduled, and shows it in the backup settings and the Backups tab.
+func (p *PatchApp) showNextBackup(next time.Time) {
+	p.nextBackup = next
+	p.refreshAutoBackupStatus()
+	if p.nextBackupLabel == nil {
+		return
+	}
+	if next.IsZero() {
+		p.nextBackupLabel.SetText(i18n.T("backup.nextNone"))
+		return
+	}
+	p.nextBackupLabel.SetText(i18n.T("backup.next", i18n.FormatDateTime(next)))
+}
+
+// backupDeferral returns why an auto backup of the game at gameDir should
+// wait, or "" when it can run: the game is running, or the user has been
+// idle for less than idleMinutes. Idleness is only known on Windows.
+func backupDeferral(gameDir string, idleMinutes int) string {
+	if gameRunning(gameDir) {
+		return "game running"
+	}
+	if idleMinutes > 0 {
+		if idle, ok := idleTime(); ok && idle < time.Duration(idleMinutes)*time.Minute {
+			return "user active"
+		}
+	}
+	return ""
+}
+
+func (p *PatchApp) stopBackupTimer() {
+	if p.stopBackups != nil {
+		close(p.stopBackups)
+		p.stopBackups, p.backupNow = nil, nil
+	}
+}
+
+// updateBackupSettings applies change to the backup settings of the active
+// profile and saves them.
+func (p *PatchApp) updateBackupSettings(change func(settings *backup.Settings)) {
+	if err := p.backups.UpdateSettings(change); err != nil {
+		slog.Error("saving backup settings failed", "profile", p.config.ActiveProfile, "err", err)
+		p.updateStatus(i18n.T("status.saveBackupSettingsFailed", err))
 	}
+}
 
-	// Create backup record
-	backup := Backup{
-		ID:          backupID,
-		Timestamp:   time.Now(),
-		Description: description,
-		Files:       files,
-		Type:        backupType,
-		GameVersion: "1.0.0", // TODO: Detect game version
+// parseMaxBackups reads the Max Backups entry, a whole number from
+// backup.MinMaxBackups to backup.MaxMaxBackups.
+func parseMaxBackups(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < backup.MinMaxBackups || n > backup.MaxMaxBackups {
+		return 0, errors.New(i18n.T("backup.maxInvalid", backup.MinMaxBackups, backup.MaxMaxBackups))
 	}
-	
-	// Add to database
-	p.backups.Backups = append(p.backups.Backups, backup)
-	
-	// Remove old backups if exceeding limit
-	if len(p.backups.Backups) > p.backups.Settings.MaxBackups {
-		// Sort backups by time
-		sort.Slice(p.backups.Backups, func(i, j int) bool {
-			return p.backups.Backups[i].Timestamp.After(p.backups.Backups[j].Timestamp)
-		})
-		
-		// Remove old backups
-		oldBackups := p.backups.Backups[p.backups.Settings.MaxBackups:]
-		p.backups.Backups = p.backups.Backups[:p.backups.Settings.MaxBackups]
-		
-		// Delete old backup files
-		for _, backup := range oldBackups {
-			backupPath := filepath.Join(filepath.Dir(p.historyFile), p.backups.Settings.BackupPath, backup.ID)
-			os.RemoveAll(backupPath)
-		}
+	return n, nil
+}
+
+// intervalName describes an auto backup interval given in seconds, in
+// minutes unless it is whole hours.
+func intervalName(seconds int) string {
+	switch {
+	case seconds < 3600 || seconds%3600 != 0:
+		return i18n.T("interval.minutes", seconds/60)
+	case seconds == 3600:
+		return i18n.T("interval.hour")
 	}
-	
-	// Save database
-	return p.saveBackupDatabase()
+	return i18n.T("interval.hours", seconds/3600)
 }
 
-func (p *PatchApp) restoreBackup(backup Backup) error {
-	backupDir := filepath.Join(filepath.Dir(p.historyFile), p.backups.Settings.BackupPath, backup.ID)
-	
-	// Verify backup files
-	for _, file := range backup.Files {
-		backupFile := filepath.Join(backupDir, file.Path)
-		hash, err := p.calculateFileHash(backupFile)
-		if err != nil {
-			return fmt.Errorf("backup verification failed: %v", err)
-		}
-		if hash != file.Hash {
-			return fmt.Errorf("backup file corrupted: %s", file.Path)
-		}
+// createIntervalSelect returns the select of the auto backup interval,
+// showing seconds as one of backup.Intervals or as the custom entry, which
+// asks for an interval in minutes when picked. An interval outside the
+// range of custom ones, from an edited backup.json, shows as the nearest of
+// backup.Intervals.
+func (p *PatchApp) createIntervalSelect(seconds int) *widget.Select {
+	custom := len(backup.Intervals)
+	names := make([]string, custom+1)
+	for i, interval := range backup.Intervals {
+		names[i] = intervalName(interval)
 	}
-	
-	// Restore files
-	for _, file := range backup.Files {
-		backupFile := filepath.Join(backupDir, file.Path)
-		destFile := filepath.Join(p.dnfPath, file.Path)
-		
-		// Create destination directory
-		if err := os.MkdirAll(filepath.Dir(destFile), 0755); err != nil {
-			return err
+	s := widget.NewSelect(names, nil)
+	current := seconds
+	show := func(seconds int) {
+		current = seconds
+		names[custom] = i18n.T("backup.intervalCustom")
+		i := backup.IntervalIndex(seconds)
+		switch {
+		case i >= 0:
+		case seconds >= backup.MinInterval && seconds <= backup.MaxInterval:
+			i = custom
+			names[custom] = i18n.T("backup.intervalCustomValue", intervalName(seconds))
+		default:
+			i = backup.NearestInterval(seconds)
 		}
-		
-		// Copy file
-		src, err := os.Open(backupFile)
-		if err != nil {
-			return err
-		}
-		defer src.Close()
-		
-		dst, err := os.Create(destFile)
-		if err != nil {
-			return err
-		}
-		defer dst.Close()
-		
-		if _, err := io.Copy(dst, src); err != nil {
-			return err
+		// Showing the interval is not picking it
+		onChanged := s.OnChanged
+		s.OnChanged = nil
+		s.SetSelectedIndex(i)
+		s.OnChanged = onChanged
+		s.Refresh()
+	}
+	save := func(seconds int) {
+		p.updateBackupSettings(func(s *backup.Settings) { s.BackupInterval = seconds })
+		p.startBackupTimer()
+		show(seconds)
+	}
+	show(seconds)
+	s.OnChanged = func(string) {
+		switch i := s.SelectedIndex(); {
+		case i < 0:
+		case i < custom:
+			save(backup.Intervals[i])
+		default:
+			p.askCustomInterval(current, save, func() { show(current) })
 		}
 	}
-	
-	return nil
+	return s
 }
 
-func (p *PatchApp) startBackupTimer() {
-	if p.backupTimer != nil {
-		p.backupTimer.Stop()
-	}
-	
-	if p.backups.Settings.AutoBackup {
-		p.backupTimer = time.NewTimer(time.Duration(p.backups.Settings.BackupInterval) * time.Second)
-		go func() {
-			for {
-				<-p.backupTimer.C
-				if err := p.createBackup("Auto backup", "auto"); err != nil {
-					fmt.Printf("Auto backup failed: %v\n", err)
-				}
-				p.backupTimer.Reset(time.Duration(p.backups.Settings.BackupInterval) * time.Second)
-			}
-		}()
+// askCustomInterval asks for an auto backup interval in minutes, starting
+// from current seconds, and calls onSaved with it in seconds, or onCancel.
+func (p *PatchApp) askCustomInterval(current int, onSaved func(seconds int), onCancel func()) {
+	rangeHint := i18n.T("backup.intervalRange", backup.MinInterval/60, backup.MaxInterval/60)
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(current / 60))
+	entry.Validator = func(s string) error {
+		if _, err := backup.ParseIntervalMinutes(s); err != nil {
+			return errors.New(rangeHint)
+		}
+		return nil
 	}
+	items := []*widget.FormItem{{Text: i18n.T("backup.intervalMinutes"), Widget: entry, HintText: rangeHint}}
+	d := dialog.NewForm(i18n.T("backup.intervalCustomTitle"), i18n.T("common.save"), i18n.T("common.cancel"), items, func(ok bool) {
+		seconds, err := backup.ParseIntervalMinutes(entry.Text)
+		if !ok || err != nil {
+			onCancel()
+			return
+		}
+		onSaved(seconds)
+	}, p.window)
+	d.Resize(fyne.NewSize(350, 0))
+	d.Show()
 }
 
 func (p *PatchApp) createBackupSettingsUI() fyne.CanvasObject {
-	autoBackup := widget.NewCheck("Enable Auto Backup", func(enabled bool) {
-		p.backups.Settings.AutoBackup = enabled
-		p.saveBackupDatabase()
+	settings := p.backups.Settings()
+	
+	autoBackup := widget.NewCheck(i18n.T("backup.enableAuto"), func(enabled bool) {
+		p.updateBackupSettings(func(s *backup.Settings) { s.AutoBackup = enabled })
 		p.startBackupTimer()
 	})
-	autoBackup.SetChecked(p.backups.Settings.AutoBackup)
+	autoBackup.SetChecked(settings.AutoBackup)
+	
+	intervalRow := container.NewHBox(widget.NewLabel(i18n.T("backup.interval")), p.createIntervalSelect(settings.BackupInterval))
 	
-	intervalSelect := widget.NewSelect([]string{
-		"30 minutes",
-		"1 hour",
-		"2 hours",
-		"4 hours",
-		"8 hours",
-		"12 hours",
-		"24 hours",
-	}, func(s string) {
-		var interval int
-		switch s {
-		case "30 minutes":
-			interval = 1800
-		case "1 hour":
-			interval = 3600
-		case "2 hours":
-			interval = 7200
-		case "4 hours":
-			interval = 14400
-		case "8 hours":
-			interval = 28800
-		case "12 hours":
-			interval = 43200
-		case "24 hours":
-			interval = 86400
+	// Daily and weekly backups run at a time of day instead
+	timeEntry := widget.NewEntry()
+	timeEntry.SetPlaceHolder(backup.DefaultScheduleTime)
+	timeEntry.SetText(settings.ScheduleTime)
+	timeEntry.Validator = func(s string) error {
+		_, err := backup.ParseScheduleTime(strings.TrimSpace(s))
+		return err
+	}
+	timeEntry.OnChanged = func(s string) {
+		if timeEntry.Validate() == nil {
+			p.updateBackupSettings(func(settings *backup.Settings) { settings.ScheduleTime = strings.TrimSpace(s) })
+			p.startBackupTimer()
 		}
-		p.backups.Settings.BackupInterval = interval
-		p.saveBackupDatabase()
+	}
+	timeRow := container.NewHBox(widget.NewLabel(i18n.T("backup.scheduleTime")), timeEntry)
+	weekdays := []string{"1", "2", "3", "4", "5", "6", "0"}
+	daySelect := newOptionSelect(weekdays, "backup.weekday.", strconv.Itoa(settings.ScheduleDay), func(day string) {
+		weekday, _ := strconv.Atoi(day)
+		p.updateBackupSettings(func(s *backup.Settings) { s.ScheduleDay = weekday })
+		p.startBackupTimer()
+	})
+	dayRow := container.NewHBox(widget.NewLabel(i18n.T("backup.scheduleDay")), daySelect)
+	showSchedule := func(schedule string) {
+		intervalRow.Show()
+		timeRow.Hide()
+		dayRow.Hide()
+		switch schedule {
+		case backup.ScheduleWeekly:
+			dayRow.Show()
+			fallthrough
+		case backup.ScheduleDaily:
+			intervalRow.Hide()
+			timeRow.Show()
+		}
+	}
+	schedule := settings.Schedule
+	if schedule == "" {
+		schedule = backup.ScheduleInterval
+	}
+	scheduleSelect := newOptionSelect(backup.Schedules, "backup.schedule.", schedule, func(schedule string) {
+		p.updateBackupSettings(func(s *backup.Settings) { s.Schedule = schedule })
+		showSchedule(schedule)
 		p.startBackupTimer()
 	})
+	showSchedule(schedule)
+	p.nextBackupLabel = widget.NewLabel("")
+	p.showNextBackup(p.nextBackup)
 	
+	// Only counts within range are saved; the buttons step through them
 	maxBackupsEntry := widget.NewEntry()
-	maxBackupsEntry.SetText(fmt.Sprintf("%d", p.backups.Settings.MaxBackups))
+	maxBackupsEntry.SetText(strconv.Itoa(backup.ClampMaxBackups(settings.MaxBackups)))
+	maxBackupsEntry.Validator = func(s string) error {
+		_, err := parseMaxBackups(s)
+		return err
+	}
+	maxBackupsError := widget.NewLabel("")
+	maxBackupsError.Importance = widget.DangerImportance
+	maxBackupsError.Hide()
+	maxBackupsEntry.SetOnValidationChanged(func(err error) {
+		if err == nil {
+			maxBackupsError.Hide()
+			return
+		}
+		maxBackupsError.SetText(err.Error())
+		maxBackupsError.Show()
+	})
 	maxBackupsEntry.OnChanged = func(s string) {
-		var maxBackups int
-		if _, err := fmt.Sscanf(s, "%d", &maxBackups); err == nil {
-			p.backups.Settings.MaxBackups = maxBackups
-			p.saveBackupDatabase()
+		if maxBackups, err := parseMaxBackups(s); err == nil {
+			p.updateBackupSettings(func(s *backup.Settings) { s.MaxBackups = maxBackups })
+		}
+	}
+	stepMaxBackups := func(delta int) func() {
+		return func() {
+			n, err := parseMaxBackups(maxBackupsEntry.Text)
+			if err != nil {
+				n = backup.ClampMaxBackups(p.backups.Settings().MaxBackups)
+			}
+			n += delta
+			if n < backup.MinMaxBackups || n > backup.MaxMaxBackups {
+				n -= delta
+			}
+			maxBackupsEntry.SetText(strconv.Itoa(n))
+		}
+	}
+	maxBackupsRow := container.NewHBox(
+		widget.NewLabel(i18n.T("backup.max")),
+		widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), stepMaxBackups(-1)),
+		maxBackupsEntry,
+		widget.NewButtonWithIcon("", theme.ContentAddIcon(), stepMaxBackups(1)),
+		maxBackupsError,
+	)
+	
+	// Auto backups can wait until the computer is left alone, where that
+	// is known
+	idleOptions := []int{0, 5, 10, 15, 30}
+	idleNames := make([]string, len(idleOptions))
+	for i, minutes := range idleOptions {
+		idleNames[i] = i18n.T("backup.idleOff")
+		if minutes > 0 {
+			idleNames[i] = intervalName(minutes * 60)
+		}
+	}
+	idleSelect := widget.NewSelect(idleNames, func(s string) {
+		for i, name := range idleNames {
+			if name == s {
+				minutes := idleOptions[i]
+				p.updateBackupSettings(func(s *backup.Settings) { s.IdleMinutes = minutes })
+				p.startBackupTimer()
+			}
 		}
+	})
+	idleSelect.Selected = idleNames[0]
+	for i, minutes := range idleOptions {
+		if minutes == settings.IdleMinutes {
+			idleSelect.Selected = idleNames[i]
+		}
+	}
+	idleRow := container.NewHBox(widget.NewLabel(i18n.T("backup.idle")), idleSelect)
+	if _, ok := idleTime(); !ok {
+		idleRow.Hide()
+	}
+	
+	templateEntry := widget.NewEntry()
+	templateEntry.SetPlaceHolder(i18n.T("backup.defaultTemplate"))
+	templateEntry.SetText(settings.DefaultDescription)
+	templateEntry.OnChanged = func(s string) {
+		p.updateBackupSettings(func(settings *backup.Settings) { settings.DefaultDescription = strings.TrimSpace(s) })
 	}
 	
-	compression := widget.NewCheck("Enable Compression", func(enabled bool) {
-		p.backups.Settings.CompressionEnabled = enabled
-		p.saveBackupDatabase()
+	preset := settings.Compression
+	if preset == "" {
+		preset = backup.CompressionStore
+	}
+	archive := settings.Archive
+	if archive == "" {
+		archive = backup.FormatZip
+	}
+	archiveSelect := newOptionSelect(backup.Archives, "backup.archive.", archive, func(archive string) {
+		p.updateBackupSettings(func(s *backup.Settings) { s.Archive = archive })
+	})
+	// The archive format only matters to compressed backups
+	showArchive := func(preset string) {
+		if preset == backup.CompressionStore {
+			archiveSelect.Disable()
+		} else {
+			archiveSelect.Enable()
+		}
+	}
+	compression := newOptionSelect(backup.Compressions, "backup.compression.", preset, func(preset string) {
+		p.updateBackupSettings(func(s *backup.Settings) { s.Compression = preset })
+		showArchive(preset)
 	})
-	compression.SetChecked(p.backups.Settings.CompressionEnabled)
+	showArchive(preset)
+	
+	p.backupUsage = widget.NewLabel("")
+	p.refreshBackupUsage()
 	
 	return container.NewVBox(
-		widget.NewLabel("Backup Settings"),
+		widget.NewLabel(i18n.T("backup.settings")),
+		p.backupUsage,
 		autoBackup,
-		container.NewHBox(widget.NewLabel("Backup Interval:"), intervalSelect),
-		container.NewHBox(widget.NewLabel("Max Backups:"), maxBackupsEntry),
-		compression,
+		container.NewHBox(widget.NewLabel(i18n.T("backup.schedule")), scheduleSelect),
+		intervalRow,
+		timeRow,
+		dayRow,
+		p.nextBackupLabel,
+		wrappedLabel(i18n.T("backup.scheduleHint")),
+		maxBackupsRow,
+		idleRow,
+		container.NewBorder(nil, nil, widget.NewLabel(i18n.T("backup.template")), nil, templateEntry),
+		wrappedLabel(i18n.T("backup.templateHint")),
+		container.NewHBox(widget.NewLabel(i18n.T("backup.compression")), compression,
+			widget.NewLabel(i18n.T("backup.archive")), archiveSelect),
+		wrappedLabel(i18n.T("backup.compressionHint")),
 	)
 }
 
+// refreshBackupUsage shows how much space the backups of the active profile
+// take and how much is free on their drive, in the warning colour when that
+// is below minFreeSpace.
+func (p *PatchApp) refreshBackupUsage() {
+	if p.backupUsage == nil {
+		return
+	}
+	used := formatSize(p.backups.TotalSize())
+	dir := p.backups.Dir("")
+	free, err := fsutil.FreeSpace(dir)
+	if err != nil {
+		// The folder is only created with the first backup
+		free, err = fsutil.FreeSpace(filepath.Dir(dir))
+	}
+	if err != nil {
+		slog.Warn("checking free space failed", "path", dir, "err", err)
+		p.backupUsage.Importance = widget.MediumImportance
+		p.backupUsage.SetText(i18n.T("backup.usage", used))
+		return
+	}
+	p.backupUsage.Importance = widget.MediumImportance
+	if free < minFreeSpace {
+		p.backupUsage.Importance = widget.WarningImportance
+	}
+	p.backupUsage.SetText(i18n.T("backup.usageFree", used, formatSize(int64(free))))
+}
+
+// showCreateBackup asks for a description, filled in from the template of
+// the profile, and backs up the game. Backups kept in the game folder are
+// warned about first.
+func (p *PatchApp) showCreateBackup() {
+	if p.backupsInGame() {
+		p.warnBackupsInGame(p.askCreateBackup)
+		return
+	}
+	p.askCreateBackup()
+}
+
+// askCreateBackup is showCreateBackup past the warning.
+func (p *PatchApp) askCreateBackup() {
+	input := widget.NewEntry()
+	input.SetPlaceHolder(i18n.T("backup.descriptionPlaceholder"))
+	input.SetText(p.backupDescription())
+	
+	dialog.ShowCustomConfirm(i18n.T("backup.create"),
+		i18n.T("common.create"),
+		i18n.T("common.cancel"),
+		container.NewVBox(
+			widget.NewLabel(i18n.T("backup.descriptionPrompt")),
+			input,
+		),
+		func(create bool) {
+			if create {
+				description := input.Text
+				if description == "" {
+					description = i18n.T("backup.manualDescription")
+				}
+				
+				var create func()
+				create = func() {
+					ctx, end := p.operations.begin(i18n.T("op.backup"))
+					defer end()
+					p.updateStatus(i18n.T("backup.creating"))
+					p.progress.Set(0)
+					if _, err := p.createBackup(backup.Options{
+						Description: description,
+						Type:        "manual",
+						Progress:    p.setProgress,
+						Context:     ctx,
+					}); err != nil {
+						slog.Error("manual backup failed", "path", p.dnfPath, "err", err)
+						resume := journal.Entry{Operation: journal.Backup, Description: description, BackupType: "manual"}
+						if !p.handlePermissionError(err, create, resume) {
+							p.showError(err)
+						}
+						p.updateStatus(i18n.T("backup.createFailed"))
+					} else {
+						dialog.ShowInformation(i18n.T("common.success"), i18n.T("backup.created"), p.window)
+						p.updateStatus(i18n.T("backup.created"))
+						p.backupSucceeded()
+					}
+				}
+				create()
+			}
+		},
+		p.window)
+}
+
 func (p *PatchApp) createBackupListUI() fyne.CanvasObject {
-	list := widget.NewList(
-		func() int { return len(p.backups.Backups) },
+	p.backupList = widget.NewList(
+		func() int { return len(p.shownBackups()) },
 		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.DocumentIcon()),
-				widget.NewLabel("Template"),
-				widget.NewLabel("Template"),
-			)
+			// The name gives way to the tags and the time when the row is
+			// too narrow
+			nameLabel := widget.NewLabel("Template")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil,
+				widget.NewIcon(theme.DocumentIcon()), widget.NewLabel("Template"),
+				container.NewBorder(nil, nil, nil, container.NewHBox(), nameLabel))
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
 			box := item.(*fyne.Container)
-			nameLabel := box.Objects[1].(*widget.Label)
+			name := box.Objects[0].(*fyne.Container)
+			nameLabel := name.Objects[0].(*widget.Label)
 			timeLabel := box.Objects[2].(*widget.Label)
 			
-			backup := p.backups.Backups[len(p.backups.Backups)-1-id] // Show newest first
-			nameLabel.SetText(fmt.Sprintf("%s (%s)", backup.Description, backup.Type))
-			timeLabel.SetText(backup.Timestamp.Format("2006-01-02 15:04:05"))
+			backups := p.shownBackups()
+			if id >= len(backups) {
+				return
+			}
+			backup := backups[id]
+			nameLabel.SetText(fmt.Sprintf("%s (%s)", backup.Description, i18n.T("backup.type."+backup.Type)))
+			setTagChips(name.Objects[1].(*fyne.Container), backup.Tags)
+			timeLabel.SetText(fmt.Sprintf("%s  %s", formatSize(backup.TotalSize), p.formatTime(backup.Timestamp)))
 		},
 	)
 	
-	list.OnSelected = func(id widget.ListItemID) {
-		backup := p.backups.Backups[len(p.backups.Backups)-1-id]
+	p.backupList.OnSelected = func(id widget.ListItemID) {
+		backups := p.shownBackups()
+		if id >= len(backups) {
+			return
+		}
+		description := wrappedLabel("")
+		tags := wrappedLabel("")
+		showDetails := func(b backup.Backup) {
+			description.SetText(b.Description)
+			tags.SetText(i18n.T("backup.tags", strings.Join(b.Tags, i18n.T("common.listSeparator"))))
+			tags.Hidden = len(b.Tags) == 0
+			tags.Refresh()
+		}
+		backup := backups[id]
+		showDetails(backup)
+		editButton := widget.NewButtonWithIcon(i18n.T("backup.editDescription"), theme.DocumentCreateIcon(), func() {
+			if b, ok := p.backups.Find(backup.ID); ok {
+				p.editBackupDetails(b, showDetails)
+			}
+		})
+		editButton.Importance = widget.LowImportance
 		content := container.NewVBox(
-			widget.NewLabel(fmt.Sprintf("Backup ID: %s", backup.ID)),
-			widget.NewLabel(fmt.Sprintf("Type: %s", backup.Type)),
-			widget.NewLabel(fmt.Sprintf("Time: %s", backup.Timestamp.Format("2006-01-02 15:04:05"))),
-			widget.NewLabel(fmt.Sprintf("Files: %d", len(backup.Files))),
+			container.NewBorder(nil, nil, nil, editButton, description),
+			tags,
+			widget.NewLabel(i18n.T("backup.id", backup.ID)),
+			widget.NewLabel(i18n.T("backup.type", i18n.T("backup.type."+backup.Type))),
+			widget.NewLabel(i18n.T("backup.time", p.formatTimeDetail(backup.Timestamp))),
+			widget.NewLabel(i18n.T("backup.files", len(backup.Files))),
+			widget.NewLabel(i18n.T("backup.size", formatSize(backup.TotalSize))),
 		)
+		forceFull := widget.NewCheck(i18n.T("backup.forceFull"), nil)
 		
-		restoreButton := widget.NewButtonWithIcon("Restore", theme.HistoryIcon(), func() {
-			dialog.ShowConfirm("Restore Backup",
-				"Are you sure you want to restore this backup? Current files will be overwritten.",
-				func(restore bool) {
-					if restore {
-						p.updateStatus("Restoring backup...")
-						if err := p.restoreBackup(backup); err != nil {
-							dialog.ShowError(err, p.window)
-							p.updateStatus("Backup restoration failed!")
-						} else {
-							dialog.ShowInformation("Success", "Backup restored successfully!", p.window)
-							p.updateStatus("Backup restored successfully!")
-						}
+		var restore func()
+		restore = func() {
+			defer p.recoverPanic(i18n.T("op.restore"))
+			ctx, end := p.operations.begin(i18n.T("op.restore"))
+			defer end()
+			p.updateStatus(i18n.T("backup.restoring"))
+			p.progress.Set(0)
+			opts := p.restoreOptions()
+			if forceFull.Checked {
+				opts.CurrentHash = nil
+			}
+			summary, err := p.restoreBackup(ctx, backup, opts)
+			if cancelled(err) {
+				p.updateStatus(i18n.T("backup.restoreCancelled"))
+				return
+			}
+			if err != nil {
+				slog.Error("restoring backup failed", "backup", backup.ID, "path", p.dnfPath, "err", err)
+				resume := journal.Entry{Operation: journal.Restore, Description: backup.Description, BackupID: backup.ID}
+				if !p.handlePermissionError(err, func() { go restore() }, resume) {
+					p.showError(err)
+				}
+				p.updateStatus(i18n.T("backup.restoreFailed"))
+			} else {
+				msg := i18n.T("backup.restoredSummary", summary.Restored, summary.Unchanged)
+				p.updateStatus(msg)
+				if len(summary.LinkErrors) > 0 {
+					msg += "\n\n" + i18n.T("backup.linkErrors", errors.Join(summary.LinkErrors...))
+				}
+				dialog.ShowInformation(i18n.T("common.success"), msg, p.window)
+			}
+		}
+		
+		restoreButton := widget.NewButtonWithIcon(i18n.T("backup.restore"), theme.HistoryIcon(), func() {
+			if !p.config.ConfirmRestore {
+				go restore()
+				return
+			}
+			dialog.ShowConfirm(i18n.T("backup.restoreTitle"),
+				i18n.T("backup.restoreConfirm"),
+				func(ok bool) {
+					if ok {
+						go restore()
 					}
 				},
 				p.window)
 		})
 		restoreButton.Importance = widget.HighImportance
+		if !p.pathUsable() {
+			restoreButton.Disable()
+		}
 		
+		content.Add(forceFull)
 		content.Add(restoreButton)
 		
-		dialog.ShowCustom("Backup Details", "Close", content, p.window)
+		dialog.ShowCustom(i18n.T("backup.details"), i18n.T("common.close"), content, p.window)
 	}
 	
-	createButton := widget.NewButtonWithIcon("Create Backup", theme.DocumentCreateIcon(), func() {
-		input := widget.NewEntry()
-		input.SetPlaceHolder("Backup description")
-		
-		dialog.ShowCustomConfirm("Create Backup",
-			"Create",
-			"Cancel",
-			container.NewVBox(
-				widget.NewLabel("Enter backup description:"),
-				input,
-			),
-			func(create bool) {
-				if create {
-					description := input.Text
-					if description == "" {
-						description = "Manual backup"
-					}
-					
-					p.updateStatus("Creating backup...")
-					if err := p.createBackup(description, "manual"); err != nil {
-						dialog.ShowError(err, p.window)
-						p.updateStatus("Backup creation failed!")
-					} else {
-						dialog.ShowInformation("Success", "Backup created successfully!", p.window)
-						p.updateStatus("Backup created successfully!")
-					}
-				}
-			},
-			p.window)
-	})
+	createButton := widget.NewButtonWithIcon(i18n.T("backup.create"), theme.DocumentCreateIcon(), p.showCreateBackup)
+	createButton.Disable()
+	p.pathActions = append(p.pathActions, createButton)
+	
+	p.backupFilter = widget.NewEntry()
+	p.backupFilter.SetPlaceHolder(i18n.T("backup.filter"))
+	p.backupFilter.OnChanged = func(string) {
+		p.backupList.UnselectAll()
+		p.backupList.Refresh()
+	}
 	
 	return container.NewBorder(
-		container.NewHBox(
-			widget.NewLabel("Backups"),
-			createButton,
+		container.NewVBox(
+			p.createAutoBackupStatus(),
+			container.NewBorder(nil, nil,
+				container.NewHBox(
+					widget.NewLabel(i18n.T("tab.backups")),
+					createButton,
+				),
+				nil,
+				p.backupFilter,
+			),
 		),
 		nil, nil, nil,
-		list,
+		p.backupList,
 	)
 }
 
 func (p *PatchApp) createPatchesUI() fyne.CanvasObject {
-	list := widget.NewList(
-		func() int {
-			return len(p.patches.Categories)
-		},
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.DocumentIcon()),
-				widget.NewLabel("Template"),
-			)
-		},
-		func(id widget.ListItemID, item fyne.CanvasObject) {
-			category := p.patches.Categories[id]
-			label := item.(*fyne.Container).Objects[1].(*widget.Label)
-			label.SetText(category.Name)
-		},
-	)
-	
-	return list
+	p.categoriesView = p.createCategoriesUI()
+	p.patchesView = container.NewMax(p.categoriesView)
+	top := container.NewVBox(p.createCatalogStatusUI(), p.createFeaturedUI(), p.createWhatsNewUI())
+	return container.NewBorder(top, nil, nil, nil, p.patchesView)
 }
 
+// updatePatchList shows the patches matching query in place of the
+// categories, or the categories again when query is empty.
 func (p *PatchApp) updatePatchList(query string) {
-	// TODO: Implement patch filtering based on search query
+	query = strings.TrimSpace(query)
+	switch results := p.filterPatches(query); {
+	case query == "" && p.selectedClass() == "":
+		p.openCategory = ""
+		p.patchesView.Objects = []fyne.CanvasObject{p.categoriesView}
+	case len(results) == 0 && query == "":
+		p.patchesView.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel(i18n.T("class.none", p.selectedClass())))}
+	case len(results) == 0:
+		p.patchesView.Objects = []fyne.CanvasObject{container.NewCenter(widget.NewLabel(i18n.T("patch.noResults", query)))}
+	default:
+		p.patchesView.Objects = []fyne.CanvasObject{createPatchList(results, p.patchClass, p.showPatchDetails)}
+	}
+	p.patchesView.Refresh()
+	p.tabs.Select(p.patchesTab)
 }
 
 func (p *PatchApp) createUI() {
 	// 背景渐变
-	bg := canvas.NewLinearGradient(bgColor, color.NRGBA{R: 45, G: 52, B: 54, A: 200}, 270)
-	bg.Resize(fyne.NewSize(800, 600))
+	bg := newThemedBackground(true)
 	
 	// Logo
-	logoURI, err := storage.ParseURI("file://" + filepath.Join(filepath.Dir(p.historyFile), "assets", "logo.svg"))
+	logoURI, err := storage.ParseURI("file://" + filepath.Join(p.exeDir, "assets", "logo.svg"))
 	if err != nil {
-		fmt.Printf("Error loading logo: %v\n", err)
+		slog.Error("loading logo failed", "err", err)
 	}
 	
 	var logo *canvas.Image
@@ -886,12 +1654,12 @@ func (p *PatchApp) createUI() {
 	}
 	
 	// 标题
-	title := canvas.NewText("DNF Patch Manager", primaryColor)
+	title := canvas.NewText(i18n.T("app.title"), primaryColor)
 	title.TextSize = 28
 	title.TextStyle = fyne.TextStyle{Bold: true}
 	
 	// 副标题
-	subtitle := canvas.NewText("Manage your DNF patches with ease", secondaryColor)
+	subtitle := canvas.NewText(i18n.T("app.subtitle"), secondaryColor)
 	subtitle.TextSize = 16
 	
 	// 头部容器
@@ -910,41 +1678,65 @@ func (p *PatchApp) createUI() {
 			container.NewCenter(subtitle),
 		)
 	}
+	header = container.NewBorder(nil, nil, nil, container.NewCenter(p.createProfileUI()), header)
 
 	// 路径选择
-	p.pathEntry = widget.NewEntry()
-	p.pathEntry.SetPlaceHolder("Enter DNF directory path")
+	p.pathEntry = widget.NewSelectEntry(nil)
+	examplePath := gamepath.DefaultPath
+	if runtime.GOOS != "windows" {
+		examplePath = gamepath.WinePath
+	}
+	p.pathEntry.SetPlaceHolder(i18n.T("path.placeholder", examplePath))
 	if p.dnfPath != "" {
 		p.pathEntry.SetText(p.dnfPath)
 	}
+	p.pathEntry.OnChanged = func(string) {
+		p.schedulePathValidation()
+	}
+	p.pathEntry.OnSubmitted = p.confirmDNFPath
 
-	browseButton := widget.NewButtonWithIcon("Browse", theme.FolderOpenIcon(), func() {
-		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
-			if err != nil {
-				dialog.ShowError(err, p.window)
-				return
-			}
-			if uri == nil {
-				return
-			}
-			p.dnfPath = uri.Path()
-			p.pathEntry.SetText(p.dnfPath)
-		}, p.window)
-	})
+	p.pathIcon = widget.NewIcon(nil)
+	p.pathIcon.Hide()
+	p.pathInfo = widget.NewLabel("")
+	p.pathInfo.Hide()
+
+	browseButton := widget.NewButtonWithIcon(i18n.T("common.browse"), theme.FolderOpenIcon(), p.browseDNFPath)
 	browseButton.Importance = widget.HighImportance
 
+	p.detectButton = widget.NewButtonWithIcon(i18n.T("path.detectAgain"), theme.SearchIcon(), p.detectDNFPath)
+
+	// 路径失效提示
+	p.pathBannerText = widget.NewLabel("")
+	p.pathBannerText.Wrapping = fyne.TextWrapWord
+	p.pathBanner = container.NewBorder(
+		nil, nil,
+		widget.NewIcon(theme.WarningIcon()),
+		container.NewHBox(
+			widget.NewButton(i18n.T("common.select"), p.browseDNFPath),
+			widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+				p.pathBanner.Hide()
+			}),
+		),
+		p.pathBannerText,
+	)
+	p.pathBanner.Hide()
+
 	pathContainer := container.NewBorder(
-		nil, nil, nil, browseButton,
+		nil, nil, nil, container.NewHBox(p.detectButton, browseButton),
 		container.NewVBox(
-			widget.NewLabel("DNF Installation Directory:"),
+			widget.NewLabel(i18n.T("path.label")),
 			p.pathEntry,
+			container.NewHBox(p.pathIcon, p.pathInfo),
 		),
 	)
 
 	// 状态和进度条
-	p.status = widget.NewLabel("Ready")
+	// Background work reports through the bindings, which update the
+	// widgets from Fyne's own goroutine
+	p.statusText.Set(i18n.T("status.ready"))
+	p.status = widget.NewLabelWithData(p.statusText)
 	p.status.Alignment = fyne.TextAlignCenter
-	p.progressBar = widget.NewProgressBar()
+	p.progressBar = widget.NewProgressBarWithData(p.progress)
 	p.progressBar.Hide()
 
 	statusContainer := container.NewVBox(
@@ -954,35 +1746,61 @@ func (p *PatchApp) createUI() {
 
 	// 搜索框
 	p.searchEntry = widget.NewEntry()
-	p.searchEntry.SetPlaceHolder("Search patches...")
+	p.searchEntry.SetPlaceHolder(i18n.T("patch.search"))
 	p.searchEntry.OnChanged = p.updatePatchList
 
 	// 分类标签页
 	var categoryTabs []*container.TabItem
 	
 	// 添加补丁标签页
-	patchesTab := container.NewTabItem("Patches", p.createPatchesUI())
-	categoryTabs = append(categoryTabs, patchesTab)
+	p.patchesTab = container.NewTabItem(i18n.T("tab.patches"), p.createPatchesUI())
+	categoryTabs = append(categoryTabs, p.patchesTab)
 	
 	// 添加历史标签页
-	historyTab := container.NewTabItem("History", p.createHistoryUI())
-	categoryTabs = append(categoryTabs, historyTab)
+	p.historyTab = container.NewTabItem(i18n.T("tab.history"), p.createHistoryUI())
+	categoryTabs = append(categoryTabs, p.historyTab)
 	
 	// 添加备份标签页
-	backupTab := container.NewTabItem("Backups", p.createBackupListUI())
-	categoryTabs = append(categoryTabs, backupTab)
+	p.backupTab = container.NewTabItem(i18n.T("tab.backups"), p.createBackupListUI())
+	categoryTabs = append(categoryTabs, p.backupTab)
+	
+	p.downloadsTab = container.NewTabItem(i18n.T("tab.downloads"), p.createDownloadsUI())
+	categoryTabs = append(categoryTabs, p.downloadsTab)
+	
+	// 添加日志标签页
+	p.logTab = container.NewTabItem(i18n.T("tab.log"), p.createLogUI())
+	categoryTabs = append(categoryTabs, p.logTab)
+	
+	// 添加设置标签页
+	p.settingsTab = container.NewTabItem(i18n.T("tab.settings"), widget.NewLabel(""))
+	categoryTabs = append(categoryTabs, p.settingsTab)
 	
 	tabs := container.NewAppTabs(categoryTabs...)
 	tabs.SetTabLocation(container.TabLocationTop)
+	tabs.OnSelected = func(tab *container.TabItem) {
+		p.clearTabBadge(tab)
+		if tab == p.backupTab {
+			p.clearBackupFailures()
+		}
+		if tab == p.settingsTab {
+			p.refreshBackupUsage()
+		}
+	}
+	p.tabs = tabs
 	
 	// 主布局
 	mainContent := container.NewBorder(
 		container.NewVBox(
+			p.createToolbar(),
 			header,
 			widget.NewSeparator(),
+			p.pathBanner,
+			p.createHealthBanner(),
+			p.createBackupFailureBanner(),
+			p.createClipboardBar(),
 			container.NewPadded(pathContainer),
 			widget.NewSeparator(),
-			container.NewPadded(p.searchEntry),
+			container.NewPadded(container.NewBorder(nil, nil, nil, p.createClassFilter(), p.searchEntry)),
 		),
 		container.NewPadded(statusContainer),
 		nil, nil,
@@ -991,138 +1809,359 @@ func (p *PatchApp) createUI() {
 
 	// 设置内容
 	p.window.SetContent(container.NewMax(bg, mainContent))
-	p.window.Resize(fyne.NewSize(900, 600))
 }
 
+// importPatch installs the patch file the user picked, first pointing out
+// installed patches that change the same IMG files.
 func (p *PatchApp) importPatch(reader fyne.URIReadCloser) {
-	defer reader.Close()
-	
-	// Check imagepack2 directory
-	imagepackPath := filepath.Join(p.dnfPath, imagePack2Dir)
-	if _, err := os.Stat(imagepackPath); os.IsNotExist(err) {
-		os.MkdirAll(imagepackPath, 0755)
-	}
-
-	// Create backup directory
-	backupDir := filepath.Join(p.dnfPath, "backup_"+time.Now().Format("20060102_150405"))
-	os.MkdirAll(backupDir, 0755)
+	source := reader.URI().Path()
+	reader.Close()
+	p.importPath(source)
+}
 
-	// Get patch filename
-	patchName := filepath.Base(reader.URI().Path())
-	targetPath := filepath.Join(imagepackPath, patchName)
+// importName returns the name a patch file called name is installed under,
+// see install.NormalizeName.
+func (p *PatchManager) importName(name string) (string, error) {
+	normalized := install.NormalizeName(name, p.config.Transliterate)
+	if normalized == "" {
+		return "", fmt.Errorf("%q cannot be used as a file name", name)
+	}
+	return normalized, nil
+}
 
-	// Backup existing file if it exists
-	if _, err := os.Stat(targetPath); err == nil {
-		backupPath := filepath.Join(backupDir, patchName)
-		if err := copyFile(targetPath, backupPath); err != nil {
-			p.updateStatus(fmt.Sprintf("⚠️ Backup failed: %v", err))
-			return
-		}
-		p.updateStatus("📦 Created backup successfully")
+// importPath installs the patch file at source, first asking to install it
+// under a normalized name when its own cannot be used as is, and pointing out
+// installed patches that change the same IMG files.
+func (p *PatchApp) importPath(source string) {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("patch.install"),
+			i18n.T("path.selectFirst", filepath.Base(source)), p.window)
+		return
+	}
+	if bundle.IsBundle(source) {
+		p.importBundle(source)
+		return
+	}
+	if strings.EqualFold(filepath.Ext(source), ".zip") {
+		go p.installDropped(source)
+		return
 	}
+	
+	original := filepath.Base(source)
+	patchName, err := p.importName(original)
+	if err != nil {
+		p.showError(err)
+		return
+	}
+	if patchName != original {
+		dialog.ShowConfirm(i18n.T("import.renameTitle"), i18n.T("import.renameConfirm", original, patchName),
+			func(ok bool) {
+				if ok {
+					p.importRenamed(source, patchName)
+				}
+			}, p.window)
+		return
+	}
+	p.importRenamed(source, patchName)
+}
 
-	// Create target file
-	target, err := os.Create(targetPath)
+// importRenamed installs the patch file at source as patchName, first
+// pointing out installed patches that change the same IMG files.
+func (p *PatchApp) importRenamed(source, patchName string) {
+	overlaps, err := p.findOverlaps(source, patchName)
 	if err != nil {
-		p.updateStatus(fmt.Sprintf("❌ Failed to create file: %v", err))
+		slog.Warn("checking for overlapping patches failed", "patch", source, "err", err)
+	}
+	if len(overlaps) > 0 {
+		p.showOverlaps(patchName, overlaps, func() { p.importFile(source, patchName) })
 		return
 	}
-	defer target.Close()
+	p.importFile(source, patchName)
+}
 
-	// Copy file contents with progress updates
-	p.progressBar.SetValue(0)
-	p.updateStatus("📥 Importing patch...")
+// importFile installs the patch file at source as patchName, recording the
+// name of the file when it differs.
+func (p *PatchApp) importFile(source, patchName string) {
+	ctx, end := p.operations.begin(i18n.T("op.install"))
+	defer end()
+	p.progress.Set(0)
 	
-	_, err = io.Copy(target, reader)
+	f, err := os.Open(source)
+	if err == nil {
+		var rec install.Record
+		rec, err = p.installPatch(ctx, source, f, patchName, p.updateStatus)
+		f.Close()
+		if original := filepath.Base(source); err == nil && original != patchName {
+			rec.SourceName = original
+			err = p.installed.Add(rec)
+		}
+	}
 	if err != nil {
-		p.updateStatus(fmt.Sprintf("❌ Import failed: %v", err))
+		slog.Error("importing patch failed", "patch", patchName, "path", p.dnfPath, "err", err)
+		p.updateStatus(i18n.T("import.failed", err))
+		retry := func() { p.importFile(source, patchName) }
+		if !p.offerRetryWrite(err, retry) && !p.handlePermissionError(err, retry, journal.Entry{Operation: journal.Install, Description: patchName, Source: source}) {
+			p.showError(err)
+		}
 		return
 	}
 
-	p.progressBar.SetValue(1)
-	p.updateStatus("✨ Patch imported successfully!")
-}
-
-func (p *PatchApp) updateStatus(msg string) {
-	p.status.SetText(msg)
+	p.progress.Set(1)
+	p.updateStatus(i18n.T("import.done"))
 }
 
-func copyFile(src, dst string) error {
-	source, err := os.Open(src)
+// installPatch writes the patch read from src into imagepack2 as patchName,
+// backing up any file it replaces, and records it in the installed-patches
+// registry. progress receives status messages as the install proceeds.
+// source is the patch file src reads, kept in the journal so an interrupted
+// install can be resumed; it may be empty. Cancelling ctx stops the install
+// and rolls it back.
+func (p *PatchManager) installPatch(ctx context.Context, source string, src io.Reader, patchName string, progress func(string)) (install.Record, error) {
+	pending, err := install.Prepare(p.dnfPath, patchName, progress)
 	if err != nil {
-		return err
+		return install.Record{}, err
 	}
-	defer source.Close()
-
-	destination, err := os.Create(dst)
+	pending.Verify = p.config.VerifyWrites
+	
+	id, err := p.journal.Begin(journal.Entry{
+		Operation:   journal.Install,
+		GameDir:     p.dnfPath,
+		Description: patchName,
+		Source:      source,
+		Target:      pending.Target,
+		Saved:       pending.Saved,
+		State:       journal.Saved,
+	})
 	if err != nil {
-		return err
+		return install.Record{}, fmt.Errorf("writing journal failed: %v", err)
+	}
+	
+	if err := p.journal.Step(id, journal.Writing); err != nil {
+		slog.Error("writing journal failed", "err", err)
 	}
-	defer destination.Close()
+	entry, err := pending.Write(ctx, src, progress)
+	if err != nil {
+		if rollbackErr := pending.Rollback(); rollbackErr != nil {
+			slog.Error("rolling back failed install failed", "patch", patchName, "file", pending.Target, "err", rollbackErr)
+			return install.Record{}, err
+		}
+		p.journal.End(id)
+		return install.Record{}, err
+	}
+	if err := p.journal.Step(id, journal.Written); err != nil {
+		slog.Error("writing journal failed", "err", err)
+	}
+
+	// Record the file in the installed-patches registry
+	targetPath := filepath.Join(gamepath.ImagePackPath(p.dnfPath), patchName)
+	if err := p.patchCache().Store(targetPath, entry.Hash); err != nil {
+		slog.Error("caching installed patch failed", "patch", patchName, "file", targetPath, "err", err)
+	}
+	err = p.installed.Add(entry)
+	p.journal.End(id)
+	return entry, err
+}
+
+// updateStatus shows msg in the status line and logs it, which adds it to
+// the log panel.
+func (p *PatchApp) updateStatus(msg string) {
+	p.statusText.Set(msg)
+	slog.Log(context.Background(), statusLevel(msg), msg)
+}
+
+// showLoadProgress shows how many patches of the catalogue have been read.
+// It only sets the status line, as logging every report would flood the log.
+func (p *PatchApp) showLoadProgress(patches int) {
+	p.statusText.Set(i18n.T("database.loading", formatCount(patches)))
+}
 
-	_, err = io.Copy(destination, source)
-	return err
+// setProgress shows the progress of a file operation in the progress bar.
+func (p *PatchApp) setProgress(done, total int) {
+	p.progress.Set(float64(done) / float64(total))
 }
 
-func (p *PatchApp) showPatchDetails(patch Patch) {
+// detailsSize is the largest the patch details open at; they are smaller in a
+// smaller window and scroll when they do not fit.
+var detailsSize = fyne.NewSize(600, 760)
+
+// showPatchDetails shows everything about patch in a dialog filling most of
+// the window, scrolling its contents while the install button stays at the
+// bottom.
+func (p *PatchApp) showPatchDetails(patch patchdb.Patch) {
 	// Check for updates
 	p.checkForUpdates(patch)
 	
+	path, archive, installed := p.installedNPK(patch.Filename)
+	// Loading the previews stops when the details are closed
+	ctx, cancel := context.WithCancel(context.Background())
+	previews := p.createPreviewUI(ctx, patch.Previews)
+	if len(patch.Previews) == 0 && installed {
+		// Render the sprites themselves when there are no screenshots
+		if generated, ok := createNPKPreview(path, archive); ok {
+			previews = generated
+		}
+	}
+	
 	content := container.NewVBox(
 		widget.NewLabelWithStyle(patch.Name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewSeparator(),
-		widget.NewLabel("Description: " + patch.Description),
-		widget.NewLabel("Version: " + patch.Version),
-		widget.NewLabel("Author: " + patch.Author),
-		createRatingWidget(patch.Rating),
-		widget.NewLabel(fmt.Sprintf("Downloads: %d", patch.Downloads)),
-		p.createPreviewUI(patch.Previews),
 	)
+	reliability := wrappedLabel(p.reliabilityText(patch))
+	if reliability.Text == "" {
+		reliability.Hide()
+	}
+	if warning := p.createBlockedWarning(patch); warning != nil {
+		content.Add(warning)
+	}
+	tags := wrappedLabel(i18n.T("patch.tags", strings.Join(patch.Tags, i18n.T("common.listSeparator"))))
+	if len(patch.Tags) == 0 {
+		tags.Hide()
+	}
+	class, updateClass := p.createClassUI(patch)
+	content.Objects = append(content.Objects,
+		wrappedLabel(i18n.T("patch.description", patch.Description)),
+		wrappedLabel(i18n.T("patch.version", patch.Version)),
+		wrappedLabel(i18n.T("patch.author", patch.Author)),
+		class,
+		tags,
+		newRatingWidget(patch.Rating, theme.IconInlineSize(), true),
+		widget.NewLabel(i18n.T("patch.downloads", patch.Downloads)),
+		widget.NewLabel(i18n.T("patch.size", patchSize(patch))),
+		p.createDownloadedLabel(patch),
+		reliability,
+		p.createCategorySelect(patch),
+		p.createNotesUI(patch),
+		previews,
+	)
+	if installed {
+		contents := widget.NewAccordion(widget.NewAccordionItem(
+			i18n.T("patch.contents", len(archive.Entries)), p.createNPKContents(path, archive)))
+		content.Add(contents)
+	}
 
-	installButton := widget.NewButtonWithIcon("Install Patch", theme.DownloadIcon(), func() {
-		p.updateStatus(fmt.Sprintf("Installing patch: %s", patch.Name))
-		// TODO: Implement actual patch installation
-		p.addToHistory(patch, "Installed")
-		dialog.ShowInformation("Success", "Patch installation completed!", p.window)
+	install := func() {
+		p.installCatalogPatch(patch)
+	}
+	
+	installButton := widget.NewButtonWithIcon(i18n.T("patch.install"), theme.DownloadIcon(), func() {
+		if !p.config.ConfirmInstall {
+			install()
+			return
+		}
+		dialog.ShowConfirm(i18n.T("patch.install"),
+			i18n.T("patch.installConfirm", patch.Name, patch.Version, p.dnfPath),
+			func(ok bool) {
+				if ok {
+					install()
+				}
+			},
+			p.window)
 	})
 	installButton.Importance = widget.HighImportance
-
-	content.Add(installButton)
-
-	dialog.ShowCustom("Patch Details", "Close", content, p.window)
+	reason := wrappedLabel("")
+	reason.Importance = widget.LowImportance
+	p.updateInstallButton(patch, installButton, reason)
+
+	var d dialog.Dialog
+	blockButton := p.createBlockButton(patch, func() {
+		// Show or drop the warning
+		d.Hide()
+		p.showPatchDetails(patch)
+	})
+	buttons := container.NewVBox(container.NewBorder(nil, nil, nil, blockButton, installButton), reason)
+
+	d = dialog.NewCustom(i18n.T("patch.details"), i18n.T("common.close"),
+		container.NewBorder(nil, buttons, nil, nil, container.NewVScroll(content)), p.window)
+	window := p.window.Canvas().Size()
+	d.Resize(fyne.NewSize(fyne.Min(detailsSize.Width, window.Width*0.9), fyne.Min(detailsSize.Height, window.Height*0.9)))
+	p.detailsRefresh = func() {
+		p.updateInstallButton(patch, installButton, reason)
+		updateClass()
+	}
+	d.SetOnClosed(func() {
+		p.detailsRefresh = nil
+		cancel()
+	})
+	d.Show()
 }
 
 func (p *PatchApp) Run() {
-	p.window.Resize(fyne.NewSize(600, 500))
-	p.window.CenterOnScreen()
+	p.restoreWindowState()
+	p.window.SetCloseIntercept(func() {
+		if p.config.MinimizeToTray && trayAvailable() {
+			p.hideToTray()
+			return
+		}
+		p.quit()
+	})
+	fyne.CurrentApp().Lifecycle().SetOnStopped(p.saveWindowState)
 	p.window.ShowAndRun()
 }
 
 func main() {
+	// Any arguments select the headless command-line mode, except for patch
+	// files opened from Explorer and dnfpatch:// links
+	files := openArgs(os.Args[1:])
+	if len(os.Args) > 1 && files == nil {
+		attachConsole()
+		os.Exit(runCLI(os.Args[1:]))
+	}
+	
 	app := newPatchApp()
+	defer app.recoverCrash()
 	
-	// Set history file path
-	ex, err := os.Executable()
+	// Only one instance may write the profile files at a time; a running
+	// one is handed the files to open
+	lock, err := app.acquireLock(files...)
+	var running *instance.RunningError
+	if errors.As(err, &running) {
+		if !running.Activated {
+			app.showAlreadyRunning(err)
+		}
+		return
+	}
 	if err == nil {
-		app.historyFile = filepath.Join(filepath.Dir(ex), "install_history.json")
-		app.loadHistory()
+		app.lock = lock
+		app.raiseOnActivate(lock)
 	}
+	// The lock is handed over when relaunching elevated, so release
+	// whichever one is held at exit
+	defer func() {
+		if app.lock != nil {
+			app.lock.Release()
+		}
+	}()
 	
-	// Load backup database
-	if err := app.loadBackupDatabase(); err != nil {
-		fmt.Printf("Error loading backup database: %v\n", err)
+	// Log to a rotating file since the GUI has no console
+	if err := app.initLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening log file: %v\n", err)
 	}
-	
-	// Start backup timer
-	app.startBackupTimer()
-	
-	// Load patch database
-	patches, err := loadPatchDatabase()
+	slog.Info("starting", "dataDir", app.dataDir)
 	if err != nil {
-		fmt.Printf("Error loading patches: %v\n", err)
-		patches = PatchDatabase{} // Use empty database if loading fails
+		slog.Error("taking the instance lock failed", "path", app.lockPath(), "err", err)
+	}
+	
+	// Load config and migrate it to game profiles
+	if err := app.loadConfig(); err != nil {
+		slog.Error("loading config failed", "path", app.configPath(), "err", err)
+	}
+	i18n.SetLanguage(app.config.Language)
+	app.applyTheme()
+	app.startDownloads()
+	app.createUI()
+	app.window.SetMainMenu(app.createMainMenu())
+	app.setupTray()
+	if err := app.migrateExeData(); err != nil {
+		slog.Error("moving data out of the executable directory failed", "err", err)
+	}
+	if err := app.migrateProfiles(); err != nil {
+		slog.Error("migrating profiles failed", "err", err)
 	}
-	app.patches = patches
+	app.refreshProfileSelect()
 	
+	// Show the window at once and fill in the profile and patch catalogue
+	// as they are read
+	app.startLoading(files)
+	app.refreshRelativeTimes()
 	app.Run()
 }
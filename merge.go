@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/npk"
+)
+
+// disabledSuffix is appended to NPK files taken out of the game, which only
+// loads files ending in .npk.
+const disabledSuffix = ".disabled"
+
+// showMergeNPKs lets the user pick NPK files and merge them into one pack.
+// Files further down the list win when they contain the same IMG.
+func (p *PatchApp) showMergeNPKs() {
+	var files []string
+	selected := -1
+
+	list := widget.NewList(
+		func() int { return len(files) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(fmt.Sprintf("%d. %s", id+1, files[id]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) { selected = id }
+	list.OnUnselected = func(widget.ListItemID) { selected = -1 }
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(500, 200))
+
+	installCheck := widget.NewCheck(i18n.T("merge.install"), nil)
+	if !p.pathUsable() {
+		installCheck.Disable()
+	}
+	mergeButton := widget.NewButtonWithIcon(i18n.T("merge.merge"), theme.ContentCopyIcon(), nil)
+	mergeButton.Importance = widget.HighImportance
+	mergeButton.Disable()
+	changed := func() {
+		list.UnselectAll()
+		list.Refresh()
+		if len(files) >= 2 {
+			mergeButton.Enable()
+		} else {
+			mergeButton.Disable()
+		}
+	}
+
+	addButton := widget.NewButtonWithIcon(i18n.T("common.add"), theme.ContentAddIcon(), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				p.showError(err)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			files = append(files, reader.URI().Path())
+			changed()
+		}, p.window)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".npk", ".NPK"}))
+		open.Show()
+	})
+	removeButton := widget.NewButtonWithIcon(i18n.T("common.remove"), theme.ContentRemoveIcon(), func() {
+		if selected >= 0 && selected < len(files) {
+			files = append(files[:selected], files[selected+1:]...)
+			changed()
+		}
+	})
+	move := func(delta int) func() {
+		return func() {
+			to := selected + delta
+			if selected < 0 || to < 0 || to >= len(files) {
+				return
+			}
+			files[selected], files[to] = files[to], files[selected]
+			changed()
+			list.Select(to)
+		}
+	}
+	upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), move(-1))
+	downButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), move(1))
+
+	hint := widget.NewLabel(i18n.T("merge.hint"))
+	hint.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(
+		hint,
+		container.NewVBox(installCheck, container.NewHBox(addButton, removeButton, upButton, downButton, mergeButton)),
+		nil, nil, scroll)
+
+	d := dialog.NewCustom(i18n.T("merge.title"), i18n.T("common.close"), content, p.window)
+	mergeButton.OnTapped = func() {
+		d.Hide()
+		p.mergeNPKs(append([]string(nil), files...), installCheck.Checked)
+	}
+	d.Resize(fyne.NewSize(600, 450))
+	d.Show()
+}
+
+// mergeNPKs asks where to save the merged pack, writes it and, when install
+// is set, installs it in place of the originals.
+func (p *PatchApp) mergeNPKs(files []string, install bool) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		dst := writer.URI().Path()
+		writer.Close()
+		if install && strings.EqualFold(filepath.Dir(dst), gamepath.ImagePackPath(p.dnfPath)) {
+			p.showError(errors.New(i18n.T("merge.outsideGame")))
+			return
+		}
+
+		p.updateStatus(i18n.T("merge.merging", len(files)))
+		p.progress.Set(0)
+		go func() {
+			defer p.recoverPanic(i18n.T("op.merge"))
+			ctx, end := p.operations.begin(i18n.T("op.merge"))
+			defer end()
+			conflicts, err := npk.Merge(dst, files, p.setProgress)
+			if err != nil {
+				slog.Error("merging NPKs failed", "dst", dst, "err", err)
+				p.updateStatus(i18n.T("merge.failed"))
+				p.showError(err)
+				return
+			}
+			if install {
+				if err := p.installMerged(ctx, dst, files); err != nil {
+					slog.Error("installing merged NPK failed", "path", dst, "err", err)
+					p.updateStatus(i18n.T("merge.installFailed"))
+					p.showError(err)
+					return
+				}
+				p.historyList.Refresh()
+			}
+			p.updateStatus(i18n.T("merge.done", len(files), filepath.Base(dst)))
+			p.showMergeReport(dst, len(files), conflicts)
+		}()
+	}, p.window)
+	save.SetFileName("merged.npk")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".npk"}))
+	save.Show()
+}
+
+// installMerged installs the merged pack at path and disables the originals
+// that were installed in the game, renaming them so the game skips them.
+func (p *PatchApp) installMerged(ctx context.Context, path string, originals []string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	_, err = p.installPatch(ctx, path, f, filepath.Base(path), p.updateStatus)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	imagepackPath := gamepath.ImagePackPath(p.dnfPath)
+	for _, original := range originals {
+		if !strings.EqualFold(filepath.Dir(original), imagepackPath) {
+			continue
+		}
+		if err := fsutil.Rename(original, original+disabledSuffix); err != nil {
+			return fmt.Errorf("disabling %s failed: %w", filepath.Base(original), err)
+		}
+		if err := p.installed.Remove(filepath.Base(original)); err != nil {
+			slog.Error("updating installed patches failed", "patch", original, "err", err)
+		}
+		slog.Info("disabled merged patch", "path", original)
+	}
+	return nil
+}
+
+// showMergeReport summarises a merge and lists the IMGs that were found in
+// more than one file.
+func (p *PatchApp) showMergeReport(dst string, files int, conflicts []npk.Conflict) {
+	content := container.NewVBox(widget.NewLabel(i18n.T("merge.report", files, dst)))
+	if len(conflicts) > 0 {
+		var lines []string
+		for i, c := range conflicts {
+			if i == maxListedOverlaps {
+				lines = append(lines, i18n.T("common.andMore", len(conflicts)-i))
+				break
+			}
+			lines = append(lines, i18n.T("merge.conflict", c.Name, filepath.Base(c.Kept), filepath.Base(c.Replaced)))
+		}
+		label := widget.NewLabel(strings.Join(lines, "\n"))
+		label.Wrapping = fyne.TextWrapBreak
+		content.Add(widget.NewAccordion(widget.NewAccordionItem(
+			i18n.T("merge.conflicts", len(conflicts)), container.NewVScroll(label))))
+	}
+	d := dialog.NewCustom(i18n.T("merge.completeTitle"), i18n.T("common.close"), content, p.window)
+	d.Resize(fyne.NewSize(550, 350))
+	d.Show()
+}
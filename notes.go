@@ -0,0 +1,102 @@
+package main
+
+import (
+	"image/color"
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/notes"
+	"dnf_patch/internal/patchdb"
+)
+
+// noteBackground tints the user's notes like a sticky note, so they are not
+// mistaken for what the patch author wrote.
+var noteBackground = color.NRGBA{R: accentColor.R, G: accentColor.G, B: accentColor.B, A: 40}
+
+// noteText returns the paragraphs showing n: the text in italics and the
+// tags as #tag, both in the primary colour.
+func noteText(n notes.Note) []widget.RichTextSegment {
+	style := widget.RichTextStyle{ColorName: theme.ColorNamePrimary, TextStyle: fyne.TextStyle{Italic: true}}
+	if n.Empty() {
+		style.ColorName = theme.ColorNameDisabled
+		return []widget.RichTextSegment{&widget.TextSegment{Text: i18n.T("notes.empty"), Style: style}}
+	}
+	var segments []widget.RichTextSegment
+	if n.Text != "" {
+		segments = append(segments, &widget.TextSegment{Text: n.Text, Style: style})
+	}
+	if len(n.Tags) > 0 {
+		style.TextStyle.Bold = true
+		segments = append(segments, &widget.TextSegment{Text: "#" + strings.Join(n.Tags, "  #"), Style: style})
+	}
+	return segments
+}
+
+// createNotesUI shows the user's own note and tags on patch with a button to
+// edit them.
+func (p *PatchApp) createNotesUI(patch patchdb.Patch) fyne.CanvasObject {
+	text := widget.NewRichText(noteText(p.notes.Get(patch.ID))...)
+	text.Wrapping = fyne.TextWrapWord
+	edit := widget.NewButtonWithIcon(i18n.T("notes.edit"), theme.DocumentCreateIcon(), func() {
+		p.editNotes(patch, func(n notes.Note) {
+			text.Segments = noteText(n)
+			text.Refresh()
+		})
+	})
+	edit.Importance = widget.LowImportance
+
+	background := canvas.NewRectangle(noteBackground)
+	background.CornerRadius = theme.InputRadiusSize()
+	return container.NewMax(background, container.NewPadded(container.NewBorder(
+		container.NewBorder(nil, nil,
+			widget.NewIcon(theme.AccountIcon()), edit,
+			widget.NewLabelWithStyle(i18n.T("notes.title"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+		nil, nil, nil,
+		text,
+	)))
+}
+
+// editNotes lets the user change their note and tags on patch and passes
+// the saved note to onSaved.
+func (p *PatchApp) editNotes(patch patchdb.Patch, onSaved func(notes.Note)) {
+	current := p.notes.Get(patch.ID)
+	textEntry := widget.NewMultiLineEntry()
+	textEntry.Wrapping = fyne.TextWrapWord
+	textEntry.SetMinRowsVisible(4)
+	textEntry.SetPlaceHolder(i18n.T("notes.textHint"))
+	textEntry.SetText(current.Text)
+
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetText(strings.Join(current.Tags, ", "))
+	tagsEntry.SetPlaceHolder(i18n.T("notes.tagsHint"))
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T("notes.text"), textEntry),
+		widget.NewFormItem(i18n.T("notes.tags"), tagsEntry),
+	}
+	if tags := p.notes.Tags(); len(tags) > 0 {
+		items[1].HintText = i18n.T("notes.tagsInUse", strings.Join(tags, ", "))
+	}
+
+	d := dialog.NewForm(i18n.T("notes.editTitle", patch.Name), i18n.T("common.save"), i18n.T("common.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		n := notes.Note{Text: textEntry.Text, Tags: notes.ParseTags(tagsEntry.Text)}
+		if err := p.notes.Set(patch.ID, n); err != nil {
+			slog.Error("saving notes failed", "patch", patch.ID, "err", err)
+			p.showError(err)
+			return
+		}
+		onSaved(p.notes.Get(patch.ID))
+	}, p.window)
+	d.Resize(fyne.NewSize(450, 0))
+	d.Show()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+
+	"dnf_patch/internal/i18n"
+)
+
+// NotifySettings selects the background events announced with a desktop
+// notification.
+type NotifySettings struct {
+	Backups   bool `json:"backups"`   // auto backups finished; failures are always announced
+	Downloads bool `json:"downloads"` // patch files appeared in the watch folder
+	Installs  bool `json:"installs"`  // unattended and batch installs finished
+	Updates   bool `json:"updates"`   // updates of installed patches available
+}
+
+// tabBadge marks a tab with news from a background operation until it is
+// opened.
+const tabBadge = " ●"
+
+// notify reports a finished background operation. The status line always
+// shows it and tab, when not nil, gets a badge unless it is open; a desktop
+// notification is only sent when enabled. Fyne cannot tell which
+// notification was clicked, so the badge shows where to look.
+func (p *PatchApp) notify(enabled bool, tab *container.TabItem, title, message string) {
+	p.updateStatus(fmt.Sprintf("%s: %s", title, message))
+	if tab != nil && p.tabs.Selected() != tab && !strings.HasSuffix(tab.Text, tabBadge) {
+		tab.Text += tabBadge
+		p.tabs.Refresh()
+	}
+	if enabled {
+		fyne.CurrentApp().SendNotification(fyne.NewNotification(title, message))
+	}
+}
+
+// clearTabBadge removes the badge of tab once it is opened.
+func (p *PatchApp) clearTabBadge(tab *container.TabItem) {
+	if strings.HasSuffix(tab.Text, tabBadge) {
+		tab.Text = strings.TrimSuffix(tab.Text, tabBadge)
+		p.tabs.Refresh()
+	}
+}
+
+// checkPatchUpdates announces catalogue updates of installed patches and
+// reports whether there were any.
+func (p *PatchApp) checkPatchUpdates() bool {
+	var names []string
+	for _, rec := range p.installed.Patches() {
+		patch, ok := p.catalogPatch(rec.PatchID)
+		latest := patch.UpdateInfo.LatestVersion
+		if ok && rec.Version != "" && latest != "" && latest != rec.Version {
+			names = append(names, fmt.Sprintf("%s %s", patch.Name, latest))
+		}
+	}
+	if len(names) == 0 {
+		return false
+	}
+	p.notify(p.config.Notify.Updates, p.patchesTab, i18n.T("update.available"), strings.Join(names, ", "))
+	return true
+}
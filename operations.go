@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// operations tracks the background operations that change files, so quitting
+// can wait for them or cancel them. It is safe for concurrent use.
+type operations struct {
+	mu      sync.Mutex
+	idle    *sync.Cond // signalled when the last operation ends
+	running map[int]runningOperation
+	seq     int
+	// changed, when set, is called after an operation begins or ends. It
+	// must be set before the first operation begins.
+	changed func()
+}
+
+type runningOperation struct {
+	name   string
+	cancel context.CancelFunc
+}
+
+func newOperations() *operations {
+	o := &operations{running: make(map[int]runningOperation)}
+	o.idle = sync.NewCond(&o.mu)
+	return o
+}
+
+// begin records the start of the operation name. The returned context is
+// cancelled by cancelAll; end must be called once the operation finishes.
+func (o *operations) begin(name string) (ctx context.Context, end func()) {
+	o.mu.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	o.seq++
+	id := o.seq
+	o.running[id] = runningOperation{name: name, cancel: cancel}
+	o.mu.Unlock()
+	o.notifyChanged()
+
+	return ctx, func() {
+		o.mu.Lock()
+		cancel()
+		delete(o.running, id)
+		if len(o.running) == 0 {
+			o.idle.Broadcast()
+		}
+		o.mu.Unlock()
+		o.notifyChanged()
+	}
+}
+
+func (o *operations) notifyChanged() {
+	if o.changed != nil {
+		o.changed()
+	}
+}
+
+// names returns the names of the running operations.
+func (o *operations) names() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var names []string
+	for _, op := range o.running {
+		names = append(names, op.name)
+	}
+	return names
+}
+
+// cancelAll asks the running operations to stop. Only those that watch their
+// context stop early; the others run to completion.
+func (o *operations) cancelAll() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, op := range o.running {
+		op.cancel()
+	}
+}
+
+// wait blocks until no operation is running.
+func (o *operations) wait() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for len(o.running) > 0 {
+		o.idle.Wait()
+	}
+}
+
+// cancelled reports whether err means the operation was cancelled by quitting
+// rather than failed.
+func cancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// quit exits DNF Patch, first asking what to do about operations still
+// running.
+func (p *PatchApp) quit() {
+	running := p.operations.names()
+	if len(running) == 0 {
+		p.recordWindowPosition()
+		fyne.CurrentApp().Quit()
+		return
+	}
+	p.showWindow()
+
+	var d *dialog.CustomDialog
+	wait := widget.NewButton(i18n.T("quit.wait"), func() { d.Hide() })
+	cancelQuit := widget.NewButton(i18n.T("quit.cancelAndQuit"), func() {
+		d.Hide()
+		p.cancelAndQuit()
+	})
+	quitAnyway := widget.NewButton(i18n.T("quit.anyway"), func() {
+		slog.Warn("quitting with operations running", "operations", running)
+		d.Hide()
+		p.recordWindowPosition()
+		fyne.CurrentApp().Quit()
+	})
+	quitAnyway.Importance = widget.DangerImportance
+
+	d = dialog.NewCustomWithoutButtons(i18n.T("quit.title"),
+		wrappedLabel(i18n.T("quit.running", strings.Join(running, i18n.T("common.listSeparator")))), p.window)
+	d.SetButtons([]fyne.CanvasObject{wait, cancelQuit, quitAnyway})
+	d.Resize(fyne.NewSize(450, 0))
+	d.Show()
+}
+
+// cancelAndQuit cancels the running operations and quits once they have
+// cleaned up.
+func (p *PatchApp) cancelAndQuit() {
+	p.stopBackupTimer()
+	p.operations.cancelAll()
+	p.updateStatus(i18n.T("quit.waiting"))
+	progress := dialog.NewCustomWithoutButtons(i18n.T("quit.title"),
+		container.NewVBox(widget.NewLabel(i18n.T("quit.waiting")), widget.NewProgressBarInfinite()), p.window)
+	progress.Show()
+	go func() {
+		p.operations.wait()
+		p.recordWindowPosition()
+		fyne.CurrentApp().Quit()
+	}()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/npk"
+	"dnf_patch/internal/relpath"
+)
+
+// maxListedOverlaps bounds the IMG paths listed per patch in the dialog.
+const maxListedOverlaps = 200
+
+// overlap is an installed patch changing some of the same IMG files as a
+// patch about to be installed.
+type overlap struct {
+	Patch install.Record
+	Paths []string
+}
+
+// findOverlaps compares the entries of the NPK at path with those of every
+// installed patch except the one filename replaces. Installed patches are
+// read from the patch cache when possible and their entry tables are kept by
+// hash, so repeated checks do not parse them again.
+func (p *PatchManager) findOverlaps(path, filename string) ([]overlap, error) {
+	patch, err := npk.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := p.patchCache()
+	var overlaps []overlap
+	for _, rec := range p.installed.Patches() {
+		if rec.Key() == relpath.Canonical(filename) {
+			continue
+		}
+		installedPath := cache.Path(rec.Hash, rec.Filename)
+		if !cache.Has(rec) {
+			installedPath = filepath.Join(gamepath.ImagePackPath(p.dnfPath), rec.Filename)
+		}
+		installed, err := p.npkCache.Open(installedPath, rec.Hash)
+		if err != nil {
+			slog.Warn("reading installed patch failed", "patch", rec.Filename, "path", installedPath, "err", err)
+			continue
+		}
+		if paths := npk.Overlap(patch, installed); len(paths) > 0 {
+			overlaps = append(overlaps, overlap{Patch: rec, Paths: paths})
+		}
+	}
+	return overlaps, nil
+}
+
+// showOverlaps lists the installed patches the patch name overlaps with and
+// calls onContinue if the user installs it anyway.
+func (p *PatchApp) showOverlaps(name string, overlaps []overlap, onContinue func()) {
+	total := 0
+	items := make([]*widget.AccordionItem, 0, len(overlaps))
+	for _, o := range overlaps {
+		total += len(o.Paths)
+		paths := o.Paths
+		more := ""
+		if len(paths) > maxListedOverlaps {
+			more = "\n" + i18n.T("common.andMore", len(paths)-maxListedOverlaps)
+			paths = paths[:maxListedOverlaps]
+		}
+		label := widget.NewLabel(strings.Join(paths, "\n") + more)
+		label.Wrapping = fyne.TextWrapBreak
+		items = append(items, widget.NewAccordionItem(
+			i18n.T("overlap.item", o.Patch.PatchName, len(o.Paths)), label))
+	}
+
+	message := widget.NewLabel(i18n.T("overlap.message", name, total, len(overlaps)))
+	message.Wrapping = fyne.TextWrapWord
+	list := container.NewVScroll(widget.NewAccordion(items...))
+	list.SetMinSize(fyne.NewSize(450, 250))
+
+	d := dialog.NewCustomConfirm(i18n.T("overlap.title"), i18n.T("overlap.installAnyway"), i18n.T("common.cancel"),
+		container.NewBorder(message, nil, nil, nil, list),
+		func(ok bool) {
+			if ok {
+				onContinue()
+			}
+		},
+		p.window)
+	d.Resize(fyne.NewSize(550, 450))
+	d.Show()
+}
+
+// chooseNPKToInstall lets the user pick an NPK file to install.
+func (p *PatchApp) chooseNPKToInstall() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("overlap.installNPK"), i18n.T("path.selectFirstShort"), p.window)
+		return
+	}
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if reader != nil {
+			p.importPatch(reader)
+		}
+	}, p.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".npk", ".NPK", ".zip"}))
+	open.Show()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/journal"
+)
+
+// deniedPath returns the file whose access was denied when err is a
+// permission error.
+func deniedPath(err error) (string, bool) {
+	var pathErr *fs.PathError
+	if !errors.Is(err, fs.ErrPermission) || !errors.As(err, &pathErr) {
+		return "", false
+	}
+	return pathErr.Path, true
+}
+
+// permissionHint explains how to get past a permission error on the command
+// line, or returns "" for other errors.
+func permissionHint(err error) string {
+	path, ok := deniedPath(err)
+	if !ok {
+		return ""
+	}
+	if fsutil.IsReadOnly(path) {
+		return fmt.Sprintf("%s is read-only; clear its read-only attribute and try again", path)
+	}
+	return "writing to the game directory needs administrator rights; run the command from an elevated prompt"
+}
+
+// clearReadOnlyIn clears the read-only attribute of the files in dir, since
+// a game installer marking one file usually marked them all.
+func clearReadOnlyIn(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if fsutil.IsReadOnly(path) {
+			if err := fsutil.ClearReadOnly(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handlePermissionError explains a permission error and offers a way past
+// it: clearing read-only attributes and calling retry, or relaunching as
+// administrator, which resumes the operation described by resume. It reports
+// whether err was a permission error; other errors are left to the caller.
+func (p *PatchApp) handlePermissionError(err error, retry func(), resume journal.Entry) bool {
+	path, ok := deniedPath(err)
+	if !ok {
+		return false
+	}
+	slog.Warn("access denied", "path", path, "err", err)
+
+	if fsutil.IsReadOnly(path) {
+		dir := filepath.Dir(path)
+		dialog.ShowConfirm(i18n.T("permission.readOnlyTitle"),
+			i18n.T("permission.readOnly", filepath.Base(path), dir),
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := clearReadOnlyIn(dir); err != nil {
+					slog.Error("clearing read-only attributes failed", "dir", dir, "err", err)
+					p.showError(err)
+					return
+				}
+				retry()
+			},
+			p.window)
+		return true
+	}
+
+	message := i18n.T("permission.denied", path)
+	if !canElevate {
+		p.showError(errors.New(message))
+		return true
+	}
+	dialog.ShowConfirm(i18n.T("permission.adminTitle"),
+		message+"\n\n"+i18n.T("permission.elevate"),
+		func(ok bool) {
+			if ok {
+				p.relaunchElevated(resume)
+			}
+		},
+		p.window)
+	return true
+}
+
+// relaunchElevated hands the operation in resume over to a new instance
+// running as administrator through the journal, then quits.
+func (p *PatchApp) relaunchElevated(resume journal.Entry) {
+	resume.GameDir = p.dnfPath
+	resume.Deferred = true
+	id, err := p.journal.Begin(resume)
+	if err != nil {
+		p.showError(fmt.Errorf("writing journal failed: %w", err))
+		return
+	}
+
+	// The new instance has to take the lock
+	if p.lock != nil {
+		p.lock.Release()
+		p.lock = nil
+	}
+	if err := relaunchElevated(); err != nil {
+		slog.Error("relaunching elevated failed", "err", err)
+		p.journal.End(id)
+		if lock, err := p.acquireLock(); err == nil {
+			p.lock = lock
+			p.raiseOnActivate(lock)
+		}
+		p.showError(err)
+		return
+	}
+	slog.Info("relaunched elevated", "operation", resume.Operation)
+	fyne.CurrentApp().Quit()
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/collection"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/journal"
+)
+
+// GameProfile is one DNF installation managed by the tool. History, backups
+// and the installed-patches registry are kept per profile.
+type GameProfile struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Notes string `json:"notes"`
+}
+
+const defaultProfileID = "default"
+
+// profileDir returns the directory holding the data of the active profile.
+func (p *PatchManager) profileDir() string {
+	return filepath.Join(p.dataDir, "profiles", p.config.ActiveProfile)
+}
+
+func (p *PatchManager) activeProfile() *GameProfile {
+	for i := range p.config.Profiles {
+		if p.config.Profiles[i].ID == p.config.ActiveProfile {
+			return &p.config.Profiles[i]
+		}
+	}
+	return nil
+}
+
+func (p *PatchManager) findProfileByName(name string) *GameProfile {
+	for i := range p.config.Profiles {
+		if p.config.Profiles[i].Name == name {
+			return &p.config.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// migrateProfiles creates the default profile from the single game path of
+// older versions and moves the history and backups into its directory.
+func (p *PatchManager) migrateProfiles() error {
+	if len(p.config.Profiles) > 0 {
+		if p.activeProfile() == nil {
+			p.config.ActiveProfile = p.config.Profiles[0].ID
+		}
+		return nil
+	}
+
+	p.config.Profiles = []GameProfile{{
+		ID:   defaultProfileID,
+		Name: i18n.T("profile.defaultName"),
+		Path: p.config.DNFPath,
+	}}
+	p.config.ActiveProfile = defaultProfileID
+	p.config.DNFPath = ""
+
+	dir := p.profileDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, name := range []string{"install_history.json", "backup"} {
+		if err := moveIfExists(filepath.Join(p.exeDir, name), filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	// Backup files live under the configured backup path
+	backups := backup.NewStore(dir)
+	if err := backups.Load(); err != nil {
+		return err
+	}
+	backupPath := backups.Settings().BackupPath
+	if backupPath != "" && !filepath.IsAbs(backupPath) {
+		if err := moveIfExists(filepath.Join(p.exeDir, backupPath), filepath.Join(dir, backupPath)); err != nil {
+			return err
+		}
+	}
+
+	return p.saveConfig()
+}
+
+func moveIfExists(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("cannot migrate %s: %s already exists", src, dst)
+	}
+	return os.Rename(src, dst)
+}
+
+// useProfile makes the profile with the given ID active with empty stores
+// for its history, backups, installed-patches registry and collections,
+// without reading anything from disk.
+func (p *PatchManager) useProfile(id string) {
+	p.config.ActiveProfile = id
+	p.history = install.NewHistory(filepath.Join(p.profileDir(), "install_history.json"))
+	p.stats = install.NewStats(nil)
+	p.backups = backup.NewStore(p.profileDir())
+	p.backups.Delete = p.deletePath
+	p.installed = install.NewRegistry(filepath.Join(p.profileDir(), "installed.json"))
+	p.installed.OnChange = p.installedChanged
+	p.collections = collection.NewStore(filepath.Join(p.profileDir(), "collections.json"))
+}
+
+// loadProfile makes the profile with the given ID active and loads its
+// history, backups and installed-patches registry.
+func (p *PatchManager) loadProfile(id string) error {
+	p.useProfile(id)
+	if err := os.MkdirAll(p.profileDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %v", err)
+	}
+
+	if err := p.history.Load(); err != nil {
+		return fmt.Errorf("failed to load history: %v", err)
+	}
+	p.stats = install.NewStats(p.history.Entries())
+	if err := p.backups.Load(); err != nil {
+		return fmt.Errorf("failed to load backups: %v", err)
+	}
+	if err := p.installed.Load(); err != nil {
+		return fmt.Errorf("failed to load installed patches: %v", err)
+	}
+	if err := p.collections.Load(); err != nil {
+		return fmt.Errorf("failed to load collections: %v", err)
+	}
+	jr, err := journal.Open(filepath.Join(p.profileDir(), "journal.json"))
+	p.journal = jr
+	if err != nil {
+		return fmt.Errorf("failed to load journal: %v", err)
+	}
+
+	p.dnfPath = ""
+	if profile := p.activeProfile(); profile != nil {
+		p.dnfPath = profile.Path
+	}
+	return nil
+}
+
+// activateProfile switches to the profile with the given ID and refreshes
+// the UI with its data.
+func (p *PatchApp) activateProfile(id string) {
+	p.stopBackupTimer()
+	p.profileErr = p.loadProfile(id)
+	if p.profileErr != nil {
+		slog.Error("loading profile failed", "profile", id, "err", p.profileErr)
+		p.updateStatus(fmt.Sprintf("⚠️ %v", p.profileErr))
+	}
+
+	p.dnfPath = ""
+	p.restoreDNFPath()
+	p.startBackupTimer()
+
+	if profile := p.activeProfile(); profile != nil && p.profileSelect.Selected != profile.Name {
+		p.profileSelect.SetSelected(profile.Name)
+	}
+	p.historyList.Refresh()
+	p.backupList.Refresh()
+	p.refreshBlockedBanner()
+	p.refreshSettingsUI()
+
+	if err := p.saveConfig(); err != nil {
+		slog.Error("saving config failed", "path", p.configPath(), "err", err)
+		p.updateStatus(i18n.T("status.saveConfigFailed", err))
+	}
+	p.checkJournal()
+}
+
+func (p *PatchApp) profileNames() []string {
+	names := make([]string, len(p.config.Profiles))
+	for i, profile := range p.config.Profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+func (p *PatchApp) createProfileUI() fyne.CanvasObject {
+	p.profileSelect = widget.NewSelect(nil, func(name string) {
+		profile := p.findProfileByName(name)
+		if profile != nil && profile.ID != p.config.ActiveProfile {
+			p.activateProfile(profile.ID)
+		}
+	})
+
+	manageButton := widget.NewButtonWithIcon("", theme.SettingsIcon(), p.showProfileManager)
+
+	return container.NewHBox(
+		widget.NewLabel(i18n.T("profile.label")),
+		p.profileSelect,
+		manageButton,
+	)
+}
+
+func (p *PatchApp) refreshProfileSelect() {
+	p.profileSelect.Options = p.profileNames()
+	if profile := p.activeProfile(); profile != nil {
+		p.profileSelect.SetSelected(profile.Name)
+	}
+	p.profileSelect.Refresh()
+}
+
+func (p *PatchApp) showProfileManager() {
+	profile := p.activeProfile()
+	if profile == nil {
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(profile.Name)
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetText(profile.Notes)
+	notesEntry.SetMinRowsVisible(3)
+
+	var manager dialog.Dialog
+
+	newButton := widget.NewButtonWithIcon(i18n.T("profile.new"), theme.ContentAddIcon(), func() {
+		manager.Hide()
+		p.showNewProfileDialog()
+	})
+	deleteButton := widget.NewButtonWithIcon(i18n.T("common.delete"), theme.DeleteIcon(), func() {
+		manager.Hide()
+		p.confirmDeleteProfile(profile.ID)
+	})
+	if len(p.config.Profiles) < 2 {
+		deleteButton.Disable()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("common.name"), nameEntry),
+		widget.NewFormItem(i18n.T("profile.path"), widget.NewLabel(profile.Path)),
+		widget.NewFormItem(i18n.T("profile.notes"), notesEntry),
+	)
+
+	manager = dialog.NewCustomConfirm(i18n.T("profile.title"),
+		i18n.T("common.save"),
+		i18n.T("common.close"),
+		container.NewVBox(form, container.NewHBox(newButton, deleteButton)),
+		func(save bool) {
+			if !save {
+				return
+			}
+			name := strings.TrimSpace(nameEntry.Text)
+			if name == "" {
+				dialog.ShowInformation(i18n.T("profile.invalidName"), i18n.T("profile.nameEmpty"), p.window)
+				return
+			}
+			if other := p.findProfileByName(name); other != nil && other.ID != profile.ID {
+				dialog.ShowInformation(i18n.T("profile.invalidName"), i18n.T("profile.nameTaken", name), p.window)
+				return
+			}
+			profile.Name = name
+			profile.Notes = notesEntry.Text
+			p.refreshProfileSelect()
+			if err := p.saveConfig(); err != nil {
+				slog.Error("saving config failed", "path", p.configPath(), "err", err)
+				p.showError(err)
+			}
+		},
+		p.window)
+	manager.Resize(fyne.NewSize(420, 0))
+	manager.Show()
+}
+
+func (p *PatchApp) showNewProfileDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(i18n.T("profile.namePlaceholder"))
+
+	dialog.ShowCustomConfirm(i18n.T("profile.new"),
+		i18n.T("common.create"),
+		i18n.T("common.cancel"),
+		widget.NewForm(widget.NewFormItem(i18n.T("common.name"), nameEntry)),
+		func(create bool) {
+			name := strings.TrimSpace(nameEntry.Text)
+			if !create || name == "" {
+				return
+			}
+			if p.findProfileByName(name) != nil {
+				dialog.ShowInformation(i18n.T("profile.invalidName"), i18n.T("profile.nameTaken", name), p.window)
+				return
+			}
+			profile := GameProfile{
+				ID:   fmt.Sprintf("profile_%s", time.Now().Format("20060102_150405")),
+				Name: name,
+			}
+			p.config.Profiles = append(p.config.Profiles, profile)
+			p.refreshProfileSelect()
+			p.activateProfile(profile.ID)
+			p.browseDNFPath()
+		},
+		p.window)
+}
+
+func (p *PatchApp) confirmDeleteProfile(id string) {
+	dialog.ShowConfirm(i18n.T("profile.deleteTitle"),
+		i18n.T("profile.deleteConfirm"),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			for i, profile := range p.config.Profiles {
+				if profile.ID == id {
+					p.config.Profiles = append(p.config.Profiles[:i], p.config.Profiles[i+1:]...)
+					break
+				}
+			}
+			p.refreshProfileSelect()
+			p.activateProfile(p.config.Profiles[0].ID)
+		},
+		p.window)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+)
+
+// The stars are gold on both the light and the dark theme. The empty star is
+// an outline so it still reads as a star on either background.
+const (
+	starPath   = "M12 2l2.81 6.63 7.19.61-5.46 4.73 1.64 7.03L12 17.27 5.82 21l1.64-7.03L2 9.24l7.19-.61z"
+	starColour = "#f5a623"
+)
+
+var (
+	starFull = fyne.NewStaticResource("star-full.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24">`+
+			`<path d="`+starPath+`" fill="`+starColour+`"/></svg>`))
+	starHalf = fyne.NewStaticResource("star-half.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24">`+
+			`<path d="M12 2v15.27L5.82 21l1.64-7.03L2 9.24l7.19-.61z" fill="`+starColour+`"/>`+
+			`<path d="`+starPath+`" fill="none" stroke="`+starColour+`" stroke-width="1.5" stroke-linejoin="round"/></svg>`))
+	starEmpty = fyne.NewStaticResource("star-empty.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24">`+
+			`<path d="`+starPath+`" fill="none" stroke="`+starColour+`" stroke-width="1.5" stroke-linejoin="round"/></svg>`))
+)
+
+// starResource returns the star at position i (0-4) for the given average,
+// rounded to the nearest half star.
+func starResource(i int, average float64) fyne.Resource {
+	halves := int(math.Round(average * 2))
+	switch {
+	case halves >= 2*(i+1):
+		return starFull
+	case halves == 2*i+1:
+		return starHalf
+	}
+	return starEmpty
+}
+
+// ratingWidget shows a rating as five stars followed by the average and the
+// number of ratings. It can be reused in list rows through SetRating.
+type ratingWidget struct {
+	widget.BaseWidget
+	stars [5]*canvas.Image
+	label *widget.Label
+	box   *fyne.Container
+}
+
+// newRatingWidget returns a rating widget drawing stars of the given size.
+// Without label only the stars are shown, for compact rows.
+func newRatingWidget(rating patchdb.Rating, size float32, label bool) *ratingWidget {
+	r := &ratingWidget{label: widget.NewLabel("")}
+	stars := make([]fyne.CanvasObject, len(r.stars))
+	for i := range r.stars {
+		r.stars[i] = canvas.NewImageFromResource(starEmpty)
+		r.stars[i].FillMode = canvas.ImageFillContain
+		r.stars[i].SetMinSize(fyne.NewSize(size, size))
+		stars[i] = r.stars[i]
+	}
+	// Keep the stars close together however large the theme padding is
+	r.box = container.NewHBox(container.New(&starLayout{}, stars...))
+	if label {
+		r.box.Add(r.label)
+	}
+	r.ExtendBaseWidget(r)
+	r.SetRating(rating)
+	return r
+}
+
+// SetRating shows rating.
+func (r *ratingWidget) SetRating(rating patchdb.Rating) {
+	for i, star := range r.stars {
+		star.Resource = starResource(i, rating.Average)
+		star.Refresh()
+	}
+	r.label.SetText(i18n.T("patch.rating", rating.Average, rating.Count))
+}
+
+func (r *ratingWidget) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(r.box)
+}
+
+// starLayout places its objects in a row, one pixel apart, centred
+// vertically.
+type starLayout struct{}
+
+func (*starLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	var size fyne.Size
+	for _, o := range objects {
+		min := o.MinSize()
+		size.Width += min.Width + 1
+		size.Height = max(size.Height, min.Height)
+	}
+	return size
+}
+
+func (*starLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	x := float32(0)
+	for _, o := range objects {
+		min := o.MinSize()
+		o.Resize(min)
+		o.Move(fyne.NewPos(x, (size.Height-min.Height)/2))
+		x += min.Width + 1
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/install"
+	"dnf_patch/internal/journal"
+)
+
+// checkJournal offers to roll back or resume the operations the journal of
+// the active profile still lists, one dialog at a time.
+func (p *PatchApp) checkJournal() {
+	if p.journal == nil {
+		return
+	}
+	entries := p.journal.Entries()
+	if len(entries) == 0 {
+		return
+	}
+	e := entries[0]
+	if e.Deferred && p.canResume(e) {
+		// Handed over by an instance without the rights to finish it
+		slog.Info("resuming deferred operation", "operation", e.Operation)
+		p.recoverEntry(e, i18n.T("recovery.resuming"), p.resumeOperation, p.checkJournal)
+		return
+	}
+	slog.Warn("found interrupted operations", "count", len(entries))
+	p.showInterrupted(e, p.checkJournal)
+}
+
+// showInterrupted asks what to do about the interrupted operation e and calls
+// next once it has been dealt with.
+func (p *PatchApp) showInterrupted(e journal.Entry, next func()) {
+	gamePath := widget.NewLabel(i18n.T("recovery.gamePath", e.GameDir))
+	gamePath.Wrapping = fyne.TextWrapBreak
+	content := container.NewVBox(
+		wrappedLabel(i18n.T("recovery.interrupted",
+			i18n.T("operation."+string(e.Operation)), e.Description, p.formatTimeDetail(e.Started))),
+		gamePath,
+	)
+	if e.State != "" {
+		content.Add(wrappedLabel(i18n.T("journal.state." + string(e.State))))
+	}
+
+	d := dialog.NewCustomWithoutButtons(i18n.T("recovery.title"), content, p.window)
+	act := func(name string, action func(context.Context, journal.Entry) error) func() {
+		return func() {
+			d.Hide()
+			p.recoverEntry(e, name, action, next)
+		}
+	}
+
+	rollback := widget.NewButton(i18n.T("recovery.rollBack"), act(i18n.T("recovery.rollingBack"), p.rollbackOperation))
+	if !p.canRollBack(e) {
+		rollback.Disable()
+	}
+	resume := widget.NewButton(i18n.T("recovery.resume"), act(i18n.T("recovery.resuming"), p.resumeOperation))
+	if !p.canResume(e) {
+		resume.Disable()
+	}
+	ignore := widget.NewButton(i18n.T("recovery.ignore"), act(i18n.T("recovery.ignoring"), func(context.Context, journal.Entry) error { return nil }))
+	d.SetButtons([]fyne.CanvasObject{rollback, resume, ignore})
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
+
+// recoverEntry runs action on the interrupted operation e in the background,
+// removes e from the journal when it succeeds and then calls next.
+func (p *PatchApp) recoverEntry(e journal.Entry, name string, action func(context.Context, journal.Entry) error, next func()) {
+	go func() {
+		defer p.recoverPanic(name)
+		ctx, end := p.operations.begin(name)
+		defer end()
+		err := action(ctx, e)
+		if cancelled(err) {
+			return
+		}
+		if err != nil {
+			slog.Error("recovering interrupted operation failed", "operation", e.Operation, "action", name, "err", err)
+			p.showError(errors.New(i18n.T("recovery.failedError", name, err)))
+			p.updateStatus(i18n.T("recovery.failed", name))
+			if e.Deferred {
+				// Deferred operations are only tried once
+				p.journal.End(e.ID)
+			}
+			return
+		}
+		if err := p.journal.End(e.ID); err != nil {
+			slog.Error("writing journal failed", "err", err)
+		}
+		p.backupList.Refresh()
+		p.historyList.Refresh()
+		next()
+	}()
+}
+
+func (p *PatchApp) canRollBack(e journal.Entry) bool {
+	if e.Deferred {
+		// Nothing was changed before it was handed over
+		return false
+	}
+	switch e.Operation {
+	case journal.Install, journal.Backup:
+		return true
+	case journal.Reapply:
+		_, ok := p.backups.Find(e.BackupID)
+		return ok && e.GameDir == p.dnfPath
+	}
+	return false
+}
+
+func (p *PatchApp) canResume(e journal.Entry) bool {
+	if e.GameDir != p.dnfPath || !p.pathUsable() {
+		return false
+	}
+	switch e.Operation {
+	case journal.Install:
+		_, err := os.Stat(e.Source)
+		return e.Source != "" && err == nil
+	case journal.Restore:
+		_, ok := p.backups.Find(e.BackupID)
+		return ok
+	}
+	return true
+}
+
+// rollbackOperation undoes what the interrupted operation e may have changed.
+func (p *PatchApp) rollbackOperation(ctx context.Context, e journal.Entry) error {
+	switch e.Operation {
+	case journal.Install:
+		p.updateStatus(i18n.T("recovery.rollingBackInstall", e.Description))
+		if err := (install.Pending{Target: e.Target, Saved: e.Saved}).Rollback(); err != nil {
+			return err
+		}
+	case journal.Backup:
+		p.updateStatus(i18n.T("recovery.removingBackup"))
+		if err := p.backups.RemoveIncomplete(); err != nil {
+			return err
+		}
+	case journal.Reapply:
+		b, ok := p.backups.Find(e.BackupID)
+		if !ok {
+			return fmt.Errorf("backup %s not found", e.BackupID)
+		}
+		p.updateStatus(i18n.T("recovery.restoringReapply"))
+		if _, err := p.restoreBackup(ctx, b, p.restoreOptions()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("a %s cannot be rolled back", e.Operation)
+	}
+	p.updateStatus(i18n.T("recovery.rolledBack"))
+	return nil
+}
+
+// resumeOperation runs the interrupted operation e again.
+func (p *PatchApp) resumeOperation(ctx context.Context, e journal.Entry) error {
+	switch e.Operation {
+	case journal.Install:
+		f, err := os.Open(e.Source)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := p.installPatch(ctx, e.Source, f, e.Description, p.updateStatus); err != nil {
+			return err
+		}
+	case journal.Backup:
+		if err := p.backups.RemoveIncomplete(); err != nil {
+			return err
+		}
+		p.updateStatus(i18n.T("backup.creating"))
+		_, err := p.createBackup(backup.Options{
+			Description: e.Description,
+			Type:        e.BackupType,
+			Progress:    p.setProgress,
+			Context:     ctx,
+		})
+		if err != nil {
+			return err
+		}
+	case journal.Restore:
+		b, ok := p.backups.Find(e.BackupID)
+		if !ok {
+			return fmt.Errorf("backup %s not found", e.BackupID)
+		}
+		p.updateStatus(i18n.T("backup.restoring"))
+		if _, err := p.restoreBackup(ctx, b, p.restoreOptions()); err != nil {
+			return err
+		}
+	case journal.Reapply:
+		p.checkInstalledPatches(false)
+		return nil
+	}
+	p.updateStatus(i18n.T("recovery.finished"))
+	return nil
+}
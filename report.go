@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/report"
+	"dnf_patch/internal/vanilla"
+)
+
+// reportFormats are the formats offered in the report dialog, the first
+// being the default.
+var reportFormats = []report.Format{report.Markdown, report.Text}
+
+// buildReport lists the installed patches, with the authors from the
+// catalogue and the install dates from the history, and the NPK files of
+// the game that match neither the vanilla client nor an installed patch.
+// progress is called while the game files are hashed, which cancelling ctx
+// stops.
+func (p *PatchManager) buildReport(ctx context.Context, progress func(done, total int)) report.Report {
+	r := report.Report{
+		AppVersion:  appVersion,
+		GameDir:     p.dnfPath,
+		GameVersion: gamepath.DetectVersion(p.dnfPath),
+		Created:     time.Now(),
+	}
+
+	installedAt := make(map[string]time.Time)
+	for _, e := range p.history.Entries() {
+		if e.PatchID != "" && (e.Status == "Installed" || e.Status == "Reapplied") {
+			installedAt[e.PatchID] = e.Timestamp
+		}
+	}
+	for _, rec := range p.installed.Patches() {
+		patch := report.Patch{
+			Name:        rec.PatchName,
+			Version:     rec.Version,
+			Filename:    rec.Filename,
+			Hash:        rec.Hash,
+			InstalledAt: rec.InstalledAt,
+		}
+		if c, ok := p.catalogPatch(rec.PatchID); ok {
+			patch.Author = c.Author
+		}
+		if t, ok := installedAt[rec.PatchID]; ok {
+			patch.InstalledAt = t
+		}
+		r.Patches = append(r.Patches, patch)
+	}
+
+	results, err := p.verifyGameFiles(ctx, progress)
+	if err != nil {
+		slog.Warn("finding unknown game files failed", "path", p.dnfPath, "err", err)
+		r.UnknownError = err.Error()
+		return r
+	}
+	dir := gamepath.ImagePackPath(p.dnfPath)
+	for _, res := range results {
+		if res.Status != vanilla.Modified {
+			continue
+		}
+		path := filepath.Join(dir, res.Name)
+		f := report.File{Name: res.Name}
+		if info, err := os.Stat(fsutil.LongPath(path)); err == nil {
+			f.Size = info.Size()
+		}
+		f.Hash, _ = p.hashes.Hash(path)
+		r.Unknown = append(r.Unknown, f)
+	}
+	return r
+}
+
+// showExportReport builds the report of installed patches in the background
+// and shows it.
+func (p *PatchApp) showExportReport() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("report.title"), i18n.T("path.chooseFirst"), p.window)
+		return
+	}
+
+	p.updateStatus(i18n.T("report.building"))
+	p.progress.Set(0)
+	go func() {
+		defer p.recoverPanic(i18n.T("op.report"))
+		ctx, end := p.operations.begin(i18n.T("op.report"))
+		defer end()
+		r := p.buildReport(ctx, p.setProgress)
+		if ctx.Err() != nil {
+			return
+		}
+		p.progress.Set(1)
+		slog.Info("built installed patches report", "path", p.dnfPath, "patches", len(r.Patches), "unknown", len(r.Unknown))
+		p.updateStatus(i18n.T("report.built", len(r.Patches), len(r.Unknown)))
+		p.showReport(r)
+	}()
+}
+
+// showReport previews r in the chosen format, with buttons to copy it to
+// the clipboard or save it to a file.
+func (p *PatchApp) showReport(r report.Report) {
+	format := reportFormats[0]
+	preview := widget.NewLabel("")
+	preview.TextStyle = fyne.TextStyle{Monospace: true}
+	render := func() string {
+		var b bytes.Buffer
+		r.Write(&b, format)
+		return b.String()
+	}
+
+	var names []string
+	for _, f := range reportFormats {
+		names = append(names, i18n.T("report."+string(f)))
+	}
+	formatSelect := widget.NewSelect(names, func(name string) {
+		for i, n := range names {
+			if n == name {
+				format = reportFormats[i]
+			}
+		}
+		preview.SetText(render())
+	})
+	formatSelect.SetSelectedIndex(0)
+
+	copyButton := widget.NewButtonWithIcon(i18n.T("report.copy"), theme.ContentCopyIcon(), func() {
+		p.window.Clipboard().SetContent(render())
+		p.updateStatus(i18n.T("report.copied"))
+	})
+	saveButton := widget.NewButtonWithIcon(i18n.T("report.save"), theme.DocumentSaveIcon(), func() {
+		p.saveReport(r, format)
+	})
+
+	top := widget.NewForm(widget.NewFormItem(i18n.T("report.format"), formatSelect))
+	d := dialog.NewCustom(i18n.T("report.title"), i18n.T("common.close"), container.NewBorder(
+		container.NewVBox(wrappedLabel(i18n.T("report.hint")), top),
+		container.NewHBox(copyButton, saveButton), nil, nil,
+		container.NewScroll(preview)), p.window)
+	d.Resize(fyne.NewSize(700, 550))
+	d.Show()
+}
+
+// saveReport asks where to save r and writes it in format.
+func (p *PatchApp) saveReport(r report.Report, format report.Format) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		err = r.Write(writer, format)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			slog.Error("saving installed patches report failed", "path", writer.URI().Path(), "err", err)
+			p.showError(err)
+			return
+		}
+		p.updateStatus(i18n.T("report.saved", writer.URI().Path()))
+	}, p.window)
+	save.SetFileName("dnf-patch-report" + format.Extension())
+	save.SetFilter(storage.NewExtensionFileFilter([]string{format.Extension()}))
+	save.Show()
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/config"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+	"dnf_patch/internal/schema"
+)
+
+const appConfigDirName = "DNFPatch"
+
+var (
+	languageOptions    = []string{"auto", "zh-CN", "en"}
+	themeOptions       = []string{"system", "light", "dark"}
+	watchActionOptions = []string{"ask", "install"}
+)
+
+// defaultConfig returns the settings used on first launch and restored by
+// "Reset to defaults".
+func defaultConfig() AppConfig {
+	return AppConfig{
+		Language:       "auto",
+		Theme:          "system",
+		TimeFormat:     "absolute",
+		ConfirmInstall: true,
+		ConfirmRestore: true,
+		DeleteToTrash:  true,
+		VerifyWrites:   true,
+		Transliterate:  true,
+		WatchAction:    "ask",
+		MaxDownloads:   defaultMaxDownloads,
+		CacheLimit:     defaultCacheLimit,
+		StaleDays:      defaultStaleDays,
+		Notify:         NotifySettings{Backups: true, Downloads: true, Installs: true, Updates: true},
+	}
+}
+
+// configPath returns the location of config.json in the data directory.
+func (p *PatchManager) configPath() string {
+	return filepath.Join(p.dataDir, "config.json")
+}
+
+// loadConfig reads config.json, or the one older versions kept next to the
+// executable, upgrading it from older formats.
+func (p *PatchManager) loadConfig() error {
+	p.config = defaultConfig()
+	p.configNewer = nil
+	err := config.Load(p.configPath(), filepath.Join(p.exeDir, "config.json"), &p.config)
+	if errors.As(err, new(*schema.NewerError)) {
+		p.configNewer = err
+	}
+	return err
+}
+
+// saveConfig writes the config, unless it was read from a newer version.
+func (p *PatchManager) saveConfig() error {
+	if p.configNewer != nil {
+		return p.configNewer
+	}
+	p.config.SchemaVersion = config.File.Version
+	return config.Save(p.configPath(), p.config)
+}
+
+// cacheDir returns the download cache location.
+func (p *PatchManager) cacheDir() string {
+	if p.config.CacheDir != "" {
+		return p.config.CacheDir
+	}
+	return filepath.Join(p.dataDir, "cache")
+}
+
+// updateConfig applies change to the config and saves it.
+func (p *PatchApp) updateConfig(change func(c *AppConfig)) {
+	change(&p.config)
+	if err := p.saveConfig(); err != nil {
+		slog.Error("saving config failed", "path", p.configPath(), "err", err)
+		p.updateStatus(i18n.T("status.saveConfigFailed", err))
+	}
+}
+
+// resetSettings restores the default settings, keeping game profiles,
+// recently used paths and the window state.
+func (p *PatchApp) resetSettings() {
+	defaults := defaultConfig()
+	defaults.RecentPaths = p.config.RecentPaths
+	defaults.Profiles = p.config.Profiles
+	defaults.ActiveProfile = p.config.ActiveProfile
+	defaults.Window = p.config.Window
+	p.config = defaults
+
+	if err := p.saveConfig(); err != nil {
+		slog.Error("saving config failed", "path", p.configPath(), "err", err)
+		p.showError(err)
+	}
+	p.refreshSettingsUI()
+	p.applyTheme()
+	p.startWatcher()
+	p.applyDownloadSettings()
+}
+
+// refreshSettingsUI rebuilds the settings tab from the current config and the
+// backup settings of the active profile.
+func (p *PatchApp) refreshSettingsUI() {
+	if p.settingsTab == nil {
+		return
+	}
+	p.settingsTab.Content = p.createSettingsUI()
+	p.tabs.Refresh()
+}
+
+// newOptionSelect returns a select offering options under their translated
+// names, the message IDs being prefix followed by the option. onChanged
+// receives the option rather than its name.
+func newOptionSelect(options []string, prefix, selected string, onChanged func(string)) *widget.Select {
+	names := make([]string, len(options))
+	for i, option := range options {
+		names[i] = i18n.T(prefix + option)
+	}
+	s := widget.NewSelect(names, nil)
+	for i, option := range options {
+		if option == selected {
+			s.SetSelectedIndex(i)
+		}
+	}
+	s.OnChanged = func(string) {
+		if i := s.SelectedIndex(); i >= 0 {
+			onChanged(options[i])
+		}
+	}
+	return s
+}
+
+func (p *PatchApp) createSettingsUI() fyne.CanvasObject {
+	// Repository
+	repoEntry := widget.NewEntry()
+	repoEntry.SetPlaceHolder("https://example.com/patches.json")
+	repoEntry.SetText(p.config.RepositoryURL)
+	repoEntry.OnChanged = func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.RepositoryURL = strings.TrimSpace(s) })
+	}
+
+	proxyEntry := widget.NewEntry()
+	proxyEntry.SetPlaceHolder(i18n.T("settings.proxyPlaceholder"))
+	proxyEntry.SetText(p.config.Proxy)
+	proxyEntry.OnChanged = func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.Proxy = strings.TrimSpace(s) })
+	}
+
+	keysEntry := widget.NewMultiLineEntry()
+	keysEntry.SetPlaceHolder(i18n.T("settings.keysHint"))
+	keysEntry.SetText(strings.Join(p.config.RepositoryKeys, "\n"))
+	keysEntry.Wrapping = fyne.TextWrapOff
+	keysEntry.SetMinRowsVisible(2)
+	keysEntry.Validator = func(s string) error {
+		_, err := patchdb.ParseKeys(s)
+		return err
+	}
+	keysEntry.OnChanged = func(s string) {
+		if keysEntry.Validate() != nil {
+			return
+		}
+		var keys []string
+		for _, line := range strings.Split(s, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				keys = append(keys, line)
+			}
+		}
+		p.updateConfig(func(c *AppConfig) { c.RepositoryKeys = keys })
+	}
+
+	staleNames := make([]string, len(staleOptions))
+	for i, days := range staleOptions {
+		staleNames[i] = i18n.Plural(days, "settings.staleDay", "settings.staleDays")
+	}
+	staleSelect := widget.NewSelect(staleNames, nil)
+	for i, days := range staleOptions {
+		if days == p.config.StaleDays {
+			staleSelect.SetSelectedIndex(i)
+		}
+	}
+	staleSelect.OnChanged = func(string) {
+		if i := staleSelect.SelectedIndex(); i >= 0 {
+			p.updateConfig(func(c *AppConfig) { c.StaleDays = staleOptions[i] })
+			p.refreshCatalogStatus()
+		}
+	}
+
+	repository := widget.NewForm(
+		widget.NewFormItem(i18n.T("settings.repositoryURL"), repoEntry),
+		widget.NewFormItem(i18n.T("settings.repositoryKeys"), keysEntry),
+		widget.NewFormItem(i18n.T("settings.proxy"), proxyEntry),
+		widget.NewFormItem(i18n.T("settings.staleAfter"), staleSelect),
+	)
+
+	// Interface
+	languageSelect := newOptionSelect(languageOptions, "language.", p.config.Language, func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.Language = s })
+		i18n.SetLanguage(s)
+		dialog.ShowInformation(i18n.T("settings.language"), i18n.T("settings.languageRestart"), p.window)
+	})
+
+	themeSelect := newOptionSelect(themeOptions, "theme.", p.config.Theme, func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.Theme = s })
+		p.applyTheme()
+	})
+
+	timeFormatSelect := newOptionSelect(timeFormatOptions, "timeFormat.", p.config.TimeFormat, func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.TimeFormat = s })
+		p.historyList.Refresh()
+		p.backupList.Refresh()
+		p.completedList.Refresh()
+	})
+
+	fontEntry := widget.NewEntry()
+	fontEntry.SetPlaceHolder(i18n.T("settings.fontPlaceholder"))
+	fontEntry.SetText(p.config.FontPath)
+	fontEntry.OnSubmitted = func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.FontPath = strings.TrimSpace(s) })
+		p.applyTheme()
+	}
+	fontBrowse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if err != nil {
+				p.showError(err)
+				return
+			}
+			if r != nil {
+				r.Close()
+				fontEntry.SetText(r.URI().Path())
+				fontEntry.OnSubmitted(r.URI().Path())
+			}
+		}, p.window)
+		open.SetFilter(storage.NewExtensionFileFilter(fontExtensions))
+		open.Show()
+	})
+
+	interfaceForm := widget.NewForm(
+		widget.NewFormItem(i18n.T("settings.language"), languageSelect),
+		widget.NewFormItem(i18n.T("settings.theme"), themeSelect),
+		widget.NewFormItem(i18n.T("settings.timeFormat"), timeFormatSelect),
+		widget.NewFormItem(i18n.T("settings.font"), container.NewBorder(nil, nil, nil, fontBrowse, fontEntry)),
+	)
+	if trayAvailable() {
+		minimizeToTray := widget.NewCheck(i18n.T("settings.minimizeToTray"), nil)
+		minimizeToTray.SetChecked(p.config.MinimizeToTray)
+		minimizeToTray.OnChanged = func(b bool) {
+			p.updateConfig(func(c *AppConfig) { c.MinimizeToTray = b })
+		}
+		interfaceForm.Append("", minimizeToTray)
+	}
+
+	// Confirmations
+	confirmInstall := widget.NewCheck(i18n.T("settings.confirmInstall"), nil)
+	confirmInstall.SetChecked(p.config.ConfirmInstall)
+	confirmInstall.OnChanged = func(b bool) {
+		p.updateConfig(func(c *AppConfig) { c.ConfirmInstall = b })
+	}
+
+	confirmRestore := widget.NewCheck(i18n.T("settings.confirmRestore"), nil)
+	confirmRestore.SetChecked(p.config.ConfirmRestore)
+	confirmRestore.OnChanged = func(b bool) {
+		p.updateConfig(func(c *AppConfig) { c.ConfirmRestore = b })
+	}
+
+	// Storage
+	cacheEntry := widget.NewEntry()
+	cacheEntry.SetPlaceHolder(filepath.Join(p.dataDir, "cache"))
+	cacheEntry.SetText(p.config.CacheDir)
+	cacheEntry.OnChanged = func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.CacheDir = strings.TrimSpace(s) })
+	}
+	cacheBrowse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				p.showError(err)
+				return
+			}
+			if uri != nil {
+				cacheEntry.SetText(uri.Path())
+			}
+		}, p.window)
+	})
+
+	mode, switchLabel := i18n.T("settings.modeInstalled"), i18n.T("settings.toPortable")
+	if p.portable() {
+		mode, switchLabel = i18n.T("settings.modePortable"), i18n.T("settings.toInstalled")
+	}
+	dataLabel := widget.NewLabel(fmt.Sprintf("%s (%s)", p.dataDir, mode))
+	dataLabel.Wrapping = fyne.TextWrapBreak
+	switchButton := widget.NewButton(switchLabel, func() { p.confirmSwitchDataMode(!p.portable()) })
+
+	deleteToTrash := widget.NewCheck(i18n.T("settings.deleteToTrash"), nil)
+	deleteToTrash.SetChecked(p.config.DeleteToTrash)
+	deleteToTrash.OnChanged = func(b bool) {
+		p.updateConfig(func(c *AppConfig) { c.DeleteToTrash = b })
+	}
+
+	verifyWrites := widget.NewCheck(i18n.T("settings.verifyWrites"), nil)
+	verifyWrites.SetChecked(p.config.VerifyWrites)
+	verifyWrites.OnChanged = func(b bool) {
+		p.updateConfig(func(c *AppConfig) { c.VerifyWrites = b })
+	}
+
+	transliterate := widget.NewCheck(i18n.T("settings.transliterate"), nil)
+	transliterate.SetChecked(p.config.Transliterate)
+	transliterate.OnChanged = func(b bool) {
+		p.updateConfig(func(c *AppConfig) { c.Transliterate = b })
+	}
+
+	storageForm := widget.NewForm(
+		widget.NewFormItem(i18n.T("settings.dataFolder"), container.NewVBox(dataLabel, container.NewHBox(switchButton))),
+		widget.NewFormItem(i18n.T("settings.downloadCache"), container.NewBorder(nil, nil, nil, cacheBrowse, cacheEntry)),
+		widget.NewFormItem(i18n.T("settings.cacheUsage"), p.createCacheUI()),
+		widget.NewFormItem(i18n.T("settings.zipPasswords"), container.NewHBox(p.createZipPasswordsUI())),
+		widget.NewFormItem("", deleteToTrash),
+		widget.NewFormItem("", verifyWrites),
+		widget.NewFormItem("", transliterate),
+	)
+
+	// Watch folder
+	watchEntry := widget.NewEntry()
+	watchEntry.SetPlaceHolder(i18n.T("settings.watchPlaceholder"))
+	watchEntry.SetText(p.config.WatchDir)
+	watchEntry.OnSubmitted = func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.WatchDir = strings.TrimSpace(s) })
+		p.startWatcher()
+	}
+	watchBrowse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				p.showError(err)
+				return
+			}
+			if uri != nil {
+				watchEntry.SetText(uri.Path())
+				watchEntry.OnSubmitted(uri.Path())
+			}
+		}, p.window)
+	})
+	watchActionSelect := newOptionSelect(watchActionOptions, "watchAction.", p.config.WatchAction, func(s string) {
+		p.updateConfig(func(c *AppConfig) { c.WatchAction = s })
+	})
+
+	watchForm := widget.NewForm(
+		widget.NewFormItem(i18n.T("settings.watchFolder"), container.NewBorder(nil, nil, nil, watchBrowse, watchEntry)),
+		widget.NewFormItem(i18n.T("settings.watchNewFiles"), watchActionSelect),
+	)
+
+	watchClipboard := widget.NewCheck(i18n.T("settings.watchClipboard"), nil)
+	watchClipboard.SetChecked(p.config.WatchClipboard)
+	watchClipboard.OnChanged = func(b bool) {
+		p.updateConfig(func(c *AppConfig) { c.WatchClipboard = b })
+		if !b {
+			p.clipboardBar.Hide()
+		}
+	}
+
+	// Notifications
+	notifyCheck := func(label string, value *bool) *widget.Check {
+		check := widget.NewCheck(label, func(b bool) {
+			p.updateConfig(func(*AppConfig) { *value = b })
+		})
+		check.Checked = *value
+		return check
+	}
+	notifications := container.NewVBox(
+		widget.NewLabel(i18n.T("settings.notifyWhen")),
+		notifyCheck(i18n.T("settings.notifyBackups"), &p.config.Notify.Backups),
+		notifyCheck(i18n.T("settings.notifyDownloads"), &p.config.Notify.Downloads),
+		notifyCheck(i18n.T("settings.notifyInstalls"), &p.config.Notify.Installs),
+		notifyCheck(i18n.T("settings.notifyUpdates"), &p.config.Notify.Updates),
+	)
+
+	resetButton := widget.NewButtonWithIcon(i18n.T("settings.reset"), theme.ContentUndoIcon(), func() {
+		dialog.ShowConfirm(i18n.T("settings.resetTitle"),
+			i18n.T("settings.resetConfirm"),
+			func(ok bool) {
+				if ok {
+					p.resetSettings()
+				}
+			},
+			p.window)
+	})
+
+	cards := container.NewVBox(
+		createCard(i18n.T("settings.cardRepository"), repository),
+		createCard(i18n.T("settings.cardInterface"), interfaceForm),
+		createCard(i18n.T("settings.cardConfirmations"), container.NewVBox(confirmInstall, confirmRestore)),
+		createCard(i18n.T("settings.cardStorage"), storageForm),
+		createCard(i18n.T("settings.cardWatchFolder"), container.NewVBox(watchForm, watchClipboard)),
+		createCard(i18n.T("settings.cardNotifications"), notifications),
+	)
+	if canAssociate {
+		cards.Add(createCard(i18n.T("settings.cardAssociation"), p.createAssociationUI()))
+	}
+	cards.Add(createCard(i18n.T("tab.backups"), p.createBackupSettingsUI()))
+	cards.Add(container.NewHBox(resetButton))
+	return container.NewVScroll(cards)
+}
+
+// createAssociationUI offers to open .npk files with this tool, for the
+// current user only so no administrator rights are needed.
+func (p *PatchApp) createAssociationUI() fyne.CanvasObject {
+	state := widget.NewLabel("")
+	button := widget.NewButton("", nil)
+	refresh := func() {
+		if fileAssociationRegistered() {
+			state.SetText(i18n.T("association.registered"))
+			button.SetText(i18n.T("association.remove"))
+		} else {
+			state.SetText(i18n.T("association.notRegistered"))
+			button.SetText(i18n.T("association.add"))
+		}
+	}
+	button.OnTapped = func() {
+		register := !fileAssociationRegistered()
+		var err error
+		if register {
+			err = registerFileAssociation()
+		} else {
+			err = unregisterFileAssociation()
+		}
+		if err != nil {
+			slog.Error("changing the file association failed", "register", register, "err", err)
+			p.showError(err)
+		}
+		refresh()
+	}
+	refresh()
+
+	hint := widget.NewLabel(i18n.T("association.hint", i18n.T("association.verb")))
+	hint.Wrapping = fyne.TextWrapWord
+	return container.NewVBox(state, hint, container.NewHBox(button))
+}
+
+// confirmSwitchDataMode moves the data between the executable directory and
+// the user config directory, then closes the tool so it restarts with the
+// new location.
+func (p *PatchApp) confirmSwitchDataMode(portable bool) {
+	message := i18n.T("dataMode.toInstalled", p.userDataDir())
+	if portable {
+		message = i18n.T("dataMode.toPortable", p.exeDir)
+	}
+	dialog.ShowConfirm(i18n.T("dataMode.title"), message+"\n\n"+i18n.T("dataMode.restart"), func(ok bool) {
+		if !ok {
+			return
+		}
+		p.stopBackupTimer()
+		p.stopWatcher()
+		if err := p.switchDataMode(portable); err != nil {
+			slog.Error("moving data failed", "portable", portable, "err", err)
+			p.showError(err)
+			p.startBackupTimer()
+			p.startWatcher()
+			return
+		}
+		d := dialog.NewInformation(i18n.T("dataMode.movedTitle"), i18n.T("dataMode.moved", p.dataDir), p.window)
+		d.SetOnClosed(fyne.CurrentApp().Quit)
+		d.Show()
+	}, p.window)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/notes"
+	"dnf_patch/internal/patchdb"
+	"dnf_patch/internal/whatsnew"
+)
+
+// startLoading loads the active profile and the patch catalogue in the
+// background, so the window appears at once even when the game, the data
+// folder or the repository is slow to reach. Until then the tabs showing
+// their data hold placeholders and the profile and game path cannot be
+// changed. The files given on the command line are opened once both have
+// loaded.
+//
+// Fyne 2.4 has no way to queue work onto its event loop; its widgets and the
+// status bindings are safe to update from other goroutines, which is what the
+// rest of the app relies on as well. The steps after loading run one after
+// the other on a single goroutine, so they never race each other.
+func (p *PatchApp) startLoading(files []string) {
+	patchesShown := p.showLoading(p.patchesTab, i18n.T("startup.loadingPatches"))
+	historyShown := p.showLoading(p.historyTab, i18n.T("startup.loadingProfile"))
+	backupsShown := p.showLoading(p.backupTab, i18n.T("startup.loadingProfile"))
+	p.profileSelect.Disable()
+	p.pathEntry.Disable()
+
+	// Empty stores stand in for the profile's until it has been read; no
+	// game path is set before then, so nothing can write to them
+	p.useProfile(p.config.ActiveProfile)
+	p.notes = notes.NewStore(filepath.Join(p.dataDir, "notes.json"))
+	p.whatsNew = whatsnew.New(filepath.Join(p.dataDir, "whatsnew.json"))
+
+	var wg sync.WaitGroup
+	var patches patchdb.Database
+	var databaseErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer p.recoverPanic(i18n.T("op.loadDatabase"))
+		ctx, end := p.operations.begin(i18n.T("op.loadDatabase"))
+		defer end()
+		p.loadLayout()
+		if err := p.whatsNew.Load(); err != nil {
+			slog.Error("loading what's new failed", "err", err)
+		}
+		patches, databaseErr = p.loadPatchDatabase(ctx, p.showLoadProgress)
+		if databaseErr != nil {
+			slog.Error("loading patch database failed", "err", databaseErr)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer p.recoverPanic(i18n.T("op.loadProfile"))
+		p.loadProfileData()
+		historyShown()
+		backupsShown()
+		p.profileSelect.Enable()
+		p.pathEntry.Enable()
+	}()
+
+	go func() {
+		defer p.recoverPanic(i18n.T("op.loadDatabase"))
+		wg.Wait()
+		p.setPatches(patches)
+		p.databaseErr = databaseErr
+		p.categoryList.Refresh()
+		p.refreshFeatured()
+		p.refreshWhatsNew()
+		p.refreshCatalogStatus()
+		p.updateClassFilter()
+		p.deriveClasses()
+		if p.catalogFetchErr != nil && p.metadataErr == nil {
+			p.retryCatalog()
+		}
+		patchesShown()
+		if databaseErr == nil {
+			p.updateStatus(i18n.T("database.loaded", formatCount(p.patchIndex.Len())))
+		}
+		p.checkPatchUpdates()
+		p.refreshBlockedBanner()
+		p.runHealthCheck(false)
+		p.resumeDownloads()
+		for _, file := range files {
+			p.openArg(file)
+		}
+	}()
+}
+
+// loadProfileData reads the notes, the local blocklist and the active
+// profile, restores its game path or looks for one, and then checks the
+// installed patches and starts watching for new ones.
+func (p *PatchApp) loadProfileData() {
+	// Notes are the user's own, shared by all profiles
+	if err := p.notes.Load(); err != nil {
+		slog.Error("loading notes failed", "err", err)
+	}
+	p.loadLocalBlocklist()
+
+	p.activateProfile(p.config.ActiveProfile)
+	if profile := p.activeProfile(); profile != nil && profile.Path == "" {
+		p.detectDNFPath()
+	}
+
+	// Point out a crash of the last run; activateProfile already offered
+	// to finish what it interrupted
+	p.showPreviousCrash()
+
+	// Look for patches a client update has reverted
+	p.checkInstalledPatches(true)
+	p.startWatcher()
+}
+
+// reloadProfile reads the active profile again after it failed to load.
+func (p *PatchApp) reloadProfile() {
+	go func() {
+		defer p.recoverPanic(i18n.T("op.loadProfile"))
+		p.activateProfile(p.config.ActiveProfile)
+		p.runHealthCheck(false)
+	}()
+}
+
+// showLoading shows a placeholder with text in place of the content of tab
+// and returns the function putting the content back.
+func (p *PatchApp) showLoading(tab *container.TabItem, text string) (done func()) {
+	content := tab.Content
+	bar := widget.NewProgressBarInfinite()
+	tab.Content = container.NewCenter(container.NewVBox(
+		widget.NewLabelWithStyle(text, fyne.TextAlignCenter, fyne.TextStyle{}),
+		bar,
+	))
+	return func() {
+		bar.Stop()
+		tab.Content = content
+		p.tabs.Refresh()
+	}
+}
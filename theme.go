@@ -0,0 +1,153 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Light variant counterparts of bgColor and textColor.
+var (
+	lightBgColor   = color.NRGBA{R: 245, G: 246, B: 247, A: 255}
+	lightTextColor = color.NRGBA{R: 45, G: 52, B: 54, A: 255}
+)
+
+// brandTheme maps the brand colors onto the standard theme colors and uses
+// font for text when set. Sizes and icons are those of the default theme.
+type brandTheme struct {
+	variant string // system, light or dark, as in AppConfig.Theme
+	font    fyne.Resource
+}
+
+var _ fyne.Theme = (*brandTheme)(nil)
+
+func (t *brandTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch t.variant {
+	case "light":
+		variant = theme.VariantLight
+	case "dark":
+		variant = theme.VariantDark
+	}
+	dark := variant == theme.VariantDark
+	pick := func(darkColor, lightColor color.Color) color.Color {
+		if dark {
+			return darkColor
+		}
+		return lightColor
+	}
+
+	switch name {
+	case theme.ColorNamePrimary:
+		return primaryColor
+	case theme.ColorNameFocus:
+		return color.NRGBA{R: primaryColor.R, G: primaryColor.G, B: primaryColor.B, A: 127}
+	case theme.ColorNameSelection:
+		return color.NRGBA{R: secondaryColor.R, G: secondaryColor.G, B: secondaryColor.B, A: 96}
+	case theme.ColorNameHyperlink:
+		return pick(secondaryColor, color.NRGBA{R: 32, G: 140, B: 132, A: 255})
+	case theme.ColorNameWarning:
+		return pick(accentColor, color.NRGBA{R: 214, G: 160, B: 0, A: 255})
+	case theme.ColorNameBackground:
+		return pick(bgColor, lightBgColor)
+	case theme.ColorNameForeground:
+		return pick(textColor, lightTextColor)
+	case theme.ColorNameButton:
+		return pick(color.NRGBA{R: 66, G: 75, B: 78, A: 255}, color.NRGBA{R: 226, G: 229, B: 231, A: 255})
+	case theme.ColorNameInputBackground:
+		return pick(color.NRGBA{R: 58, G: 66, B: 68, A: 255}, color.White)
+	case theme.ColorNameHeaderBackground, theme.ColorNameMenuBackground, theme.ColorNameOverlayBackground:
+		return pick(color.NRGBA{R: 54, G: 62, B: 64, A: 255}, color.White)
+	}
+	return theme.DefaultTheme().Color(name, variant)
+}
+
+func (t *brandTheme) Font(style fyne.TextStyle) fyne.Resource {
+	// Monospace text, mostly paths and hashes, keeps its fixed width
+	if t.font != nil && !style.Monospace && !style.Symbol {
+		return t.font
+	}
+	return theme.DefaultTheme().Font(style)
+}
+
+func (t *brandTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return theme.DefaultTheme().Icon(name)
+}
+
+func (t *brandTheme) Size(name fyne.ThemeSizeName) float32 {
+	return theme.DefaultTheme().Size(name)
+}
+
+// applyTheme switches the app to the theme variant and font chosen in the
+// config. Widgets repaint with the new colors straight away.
+func (p *PatchApp) applyTheme() {
+	fyne.CurrentApp().Settings().SetTheme(&brandTheme{variant: p.config.Theme, font: p.loadFont()})
+}
+
+// themedBackground fills its area with the current theme colors, repainting
+// when the theme changes: a gradient of the background color for the window,
+// or the overlay background color for cards.
+type themedBackground struct {
+	widget.BaseWidget
+	gradient bool
+}
+
+func newThemedBackground(gradient bool) *themedBackground {
+	b := &themedBackground{gradient: gradient}
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+func (b *themedBackground) CreateRenderer() fyne.WidgetRenderer {
+	r := &themedBackgroundRenderer{
+		rect:     canvas.NewRectangle(color.Transparent),
+		gradient: canvas.NewLinearGradient(color.Transparent, color.Transparent, 270),
+		b:        b,
+	}
+	r.Refresh()
+	return r
+}
+
+type themedBackgroundRenderer struct {
+	rect     *canvas.Rectangle
+	gradient *canvas.LinearGradient
+	b        *themedBackground
+}
+
+func (r *themedBackgroundRenderer) object() fyne.CanvasObject {
+	if r.b.gradient {
+		return r.gradient
+	}
+	return r.rect
+}
+
+func (r *themedBackgroundRenderer) Layout(size fyne.Size) {
+	r.object().Resize(size)
+}
+
+func (r *themedBackgroundRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func (r *themedBackgroundRenderer) Refresh() {
+	if r.b.gradient {
+		r.gradient.StartColor = theme.BackgroundColor()
+		c := color.NRGBAModel.Convert(theme.BackgroundColor()).(color.NRGBA)
+		c.A = 200
+		r.gradient.EndColor = c
+		r.gradient.Refresh()
+		return
+	}
+	r.rect.FillColor = theme.OverlayBackgroundColor()
+	r.rect.StrokeColor = theme.SeparatorColor()
+	r.rect.StrokeWidth = 1
+	r.rect.Refresh()
+}
+
+func (r *themedBackgroundRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.object()}
+}
+
+func (r *themedBackgroundRenderer) Destroy() {}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"time"
+
+	"dnf_patch/internal/i18n"
+)
+
+// relativeRefresh is how often lists showing relative times are redrawn.
+const relativeRefresh = time.Minute
+
+var timeFormatOptions = []string{"absolute", "relative"}
+
+// formatTime formats t for the history, backup and download lists, as a date
+// and time or as how long ago it was, depending on the settings.
+func (p *PatchApp) formatTime(t time.Time) string {
+	if p.config.TimeFormat == "relative" {
+		return i18n.FormatRelative(t, time.Now())
+	}
+	return i18n.FormatDateTime(t)
+}
+
+// formatTimeDetail formats t for detail dialogs, which show the date and
+// time after a relative time.
+func (p *PatchApp) formatTimeDetail(t time.Time) string {
+	if p.config.TimeFormat == "relative" {
+		return i18n.FormatRelative(t, time.Now()) + " (" + i18n.FormatDateTime(t) + ")"
+	}
+	return i18n.FormatDateTime(t)
+}
+
+// refreshRelativeTimes redraws the visible list every relativeRefresh while
+// relative times are shown, so that "3 minutes ago" does not stay put. The
+// age of the catalogue is always relative and always redrawn.
+func (p *PatchApp) refreshRelativeTimes() {
+	go func() {
+		defer p.recoverPanic(i18n.T("op.refreshTimes"))
+		for range time.Tick(relativeRefresh) {
+			p.refreshCatalogStatus()
+			p.refreshAutoBackupStatus()
+			if p.config.TimeFormat != "relative" {
+				continue
+			}
+			switch p.tabs.Selected() {
+			case p.historyTab:
+				p.historyList.Refresh()
+			case p.backupTab:
+				p.backupList.Refresh()
+			case p.downloadsTab:
+				p.completedList.Refresh()
+			}
+		}
+	}()
+}
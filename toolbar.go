@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log/slog"
+	"runtime/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+)
+
+// appVersion and buildDate are set when building a release:
+//
+//	go build -ldflags "-X main.appVersion=1.2.0 -X main.buildDate=2024-05-01"
+var (
+	appVersion = "dev"
+	buildDate  = ""
+)
+
+// toolbarButton is a toolbar item that, unlike widget.ToolbarAction, can be
+// disabled and shows a label next to its icon.
+type toolbarButton struct {
+	*widget.Button
+}
+
+func newToolbarButton(label string, icon fyne.Resource, tapped func()) toolbarButton {
+	b := widget.NewButtonWithIcon(label, icon, tapped)
+	b.Importance = widget.LowImportance
+	return toolbarButton{b}
+}
+
+func (t toolbarButton) ToolbarObject() fyne.CanvasObject {
+	return t.Button
+}
+
+// createToolbar returns the toolbar with the common actions. The actions on
+// the game are disabled along with the other path actions.
+func (p *PatchApp) createToolbar() *widget.Toolbar {
+	importButton := newToolbarButton(i18n.T("toolbar.import"), theme.FileIcon(), p.chooseNPKToInstall)
+	importFolderButton := newToolbarButton(i18n.T("toolbar.importFolder"), theme.FolderOpenIcon(), p.chooseFolderToImport)
+	backupButton := newToolbarButton(i18n.T("toolbar.backup"), theme.DocumentCreateIcon(), p.showCreateBackup)
+	importButton.Disable()
+	importFolderButton.Disable()
+	backupButton.Disable()
+	p.pathActions = append(p.pathActions, importButton.Button, importFolderButton.Button, backupButton.Button)
+	launchButton := newToolbarButton(i18n.T("toolbar.launch"), theme.MediaPlayIcon(), p.launchGame)
+	launchButton.Disable()
+	p.launchButton = launchButton.Button
+
+	return widget.NewToolbar(
+		importButton,
+		importFolderButton,
+		backupButton,
+		launchButton,
+		widget.NewToolbarSeparator(),
+		newToolbarButton(i18n.T("toolbar.refresh"), theme.ViewRefreshIcon(), p.refreshDatabase),
+		newToolbarButton(i18n.T("toolbar.checkUpdates"), theme.DownloadIcon(), func() {
+			if !p.checkPatchUpdates() {
+				p.updateStatus(i18n.T("update.none"))
+			}
+		}),
+		widget.NewToolbarSpacer(),
+		newToolbarButton(i18n.T("toolbar.settings"), theme.SettingsIcon(), func() { p.tabs.Select(p.settingsTab) }),
+		newToolbarButton(i18n.T("toolbar.about"), theme.HelpIcon(), p.showAbout),
+	)
+}
+
+// refreshDatabase fetches the patch database again in the background.
+func (p *PatchApp) refreshDatabase() {
+	p.updateStatus(i18n.T("database.refreshing"))
+	go func() {
+		defer p.recoverPanic(i18n.T("op.refreshDatabase"))
+		p.reloadDatabase()
+	}()
+}
+
+// reloadDatabase fetches the patch database again and shows it, reporting
+// whether it could be read at all.
+func (p *PatchApp) reloadDatabase() bool {
+	ctx, end := p.operations.begin(i18n.T("op.refreshDatabase"))
+	defer end()
+	patches, err := p.loadPatchDatabase(ctx, p.showLoadProgress)
+	p.databaseErr = err
+	defer p.refreshCatalogStatus()
+	if p.healthBanner.Visible() || p.metadataErr != nil {
+		defer p.runHealthCheck(false)
+	}
+	if err != nil {
+		slog.Error("loading patch database failed", "err", err)
+		p.updateStatus(i18n.T("database.refreshFailed", err))
+		return false
+	}
+	p.setPatches(patches)
+	p.categoryList.Refresh()
+	p.refreshFeatured()
+	p.refreshWhatsNew()
+	p.updateClassFilter()
+	p.deriveClasses()
+	p.refreshBlockedBanner()
+	p.updateStatus(i18n.T("database.refreshed", len(patches.Categories)))
+	p.checkPatchUpdates()
+	return true
+}
+
+// buildTime returns the build date set at link time, or the commit time
+// recorded by the Go toolchain.
+func buildTime() string {
+	if buildDate != "" {
+		return buildDate
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.time" {
+				return s.Value
+			}
+		}
+	}
+	return i18n.T("about.unknown")
+}
+
+func (p *PatchApp) showAbout() {
+	dataDir := widget.NewLabel(p.dataDir)
+	dataDir.Wrapping = fyne.TextWrapBreak
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("about.version"), widget.NewLabel(appVersion)),
+		widget.NewFormItem(i18n.T("about.built"), widget.NewLabel(buildTime())),
+		widget.NewFormItem(i18n.T("settings.dataFolder"), dataDir),
+	)
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(i18n.T("app.title"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(i18n.T("app.subtitle"), fyne.TextAlignCenter, fyne.TextStyle{}),
+		form,
+	)
+
+	d := dialog.NewCustom(i18n.T("about.title"), i18n.T("common.close"), content, p.window)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton(i18n.T("log.openFolder"), p.openLogFolder),
+		widget.NewButton(i18n.T("common.close"), d.Hide),
+	})
+	d.Resize(fyne.NewSize(450, 0))
+	d.Show()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log/slog"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/i18n"
+)
+
+// deletePath removes the file or folder at path for good or, with
+// DeleteToTrash set, moves it to the recycle bin where the volume has one.
+func (p *PatchManager) deletePath(path string) error {
+	trashed, err := fsutil.Delete(path, p.config.DeleteToTrash)
+	if err == nil {
+		slog.Info("deleted", "path", path, "recycleBin", trashed)
+	}
+	return err
+}
+
+// deletionNote tells in a confirmation whether deletePath moves the files at
+// paths to the recycle bin or deletes them for good.
+func (p *PatchManager) deletionNote(paths []string) string {
+	if !p.config.DeleteToTrash {
+		return i18n.T("trash.permanent")
+	}
+	for _, path := range paths {
+		if !fsutil.CanTrash(path) {
+			return i18n.T("trash.unavailable")
+		}
+	}
+	return i18n.T("trash.recycle")
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"log/slog"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/driver/software"
+	"fyne.io/fyne/v2/theme"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/i18n"
+)
+
+// trayIconSize is the size in pixels the logo is rendered at for the tray.
+const trayIconSize = 64
+
+// trayAvailable reports whether the platform can show a tray icon. Without
+// one, closing the window always quits.
+func trayAvailable() bool {
+	_, ok := fyne.CurrentApp().(desktop.App)
+	return ok
+}
+
+// setupTray adds the tray icon and its menu, if the platform has a tray.
+func (p *PatchApp) setupTray() {
+	desk, ok := fyne.CurrentApp().(desktop.App)
+	if !ok {
+		return
+	}
+	p.refreshTrayMenu()
+	desk.SetSystemTrayIcon(p.trayIcon())
+}
+
+// refreshTrayMenu sets the tray menu, which shows whether auto backup is
+// paused.
+func (p *PatchApp) refreshTrayMenu() {
+	desk, ok := fyne.CurrentApp().(desktop.App)
+	if !ok {
+		return
+	}
+	pause := fyne.NewMenuItem(i18n.T("tray.pauseBackups"), p.toggleBackupPause)
+	pause.Checked = p.backupsPaused
+	quit := fyne.NewMenuItem(i18n.T("tray.quit"), p.quit)
+	quit.IsQuit = true
+	desk.SetSystemTrayMenu(fyne.NewMenu("DNF Patch",
+		fyne.NewMenuItem(i18n.T("tray.open"), p.showWindow),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(i18n.T("tray.backupNow"), p.backupFromTray),
+		pause,
+		fyne.NewMenuItemSeparator(),
+		quit,
+	))
+}
+
+// trayIcon renders the logo into a PNG, since the Windows tray cannot show
+// SVG images.
+func (p *PatchApp) trayIcon() fyne.Resource {
+	res, err := fyne.LoadResourceFromPath(filepath.Join(p.exeDir, "assets", "logo.svg"))
+	if err != nil {
+		slog.Error("loading logo failed", "err", err)
+		res = theme.StorageIcon()
+	}
+	img := canvas.NewImageFromResource(res)
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(trayIconSize, trayIconSize))
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, software.Render(img, fyne.CurrentApp().Settings().Theme())); err != nil {
+		slog.Error("rendering tray icon failed", "err", err)
+		return res
+	}
+	return fyne.NewStaticResource("tray.png", buf.Bytes())
+}
+
+// showWindow brings the main window back from the tray.
+func (p *PatchApp) showWindow() {
+	p.window.Show()
+	p.window.RequestFocus()
+}
+
+// hideToTray hides the main window, leaving the tray icon and the backup
+// timer running.
+func (p *PatchApp) hideToTray() {
+	p.recordWindowPosition()
+	p.window.Hide()
+}
+
+// toggleBackupPause pauses or resumes auto backup until DNF Patch quits. The
+// backup settings are not changed.
+func (p *PatchApp) toggleBackupPause() {
+	p.backupsPaused = !p.backupsPaused
+	if p.backupsPaused {
+		p.stopBackupTimer()
+		p.updateStatus(i18n.T("tray.backupsPaused"))
+	} else {
+		p.startBackupTimer()
+		p.updateStatus(i18n.T("tray.backupsResumed"))
+	}
+	p.refreshTrayMenu()
+}
+
+// backupFromTray creates a manual backup in the background. The window may
+// be hidden, so the result is always sent as a notification.
+func (p *PatchApp) backupFromTray() {
+	if !p.pathUsable() {
+		p.notify(true, nil, i18n.T("backup.createFailed"), i18n.T("tray.noGamePath"))
+		return
+	}
+	go func() {
+		defer p.recoverPanic(i18n.T("op.backup"))
+		ctx, end := p.operations.begin(i18n.T("op.backup"))
+		defer end()
+		p.updateStatus(i18n.T("backup.creating"))
+		p.progress.Set(0)
+		b, err := p.createBackup(backup.Options{
+			Description: p.backupDescription(),
+			Type:        "manual",
+			Progress:    p.setProgress,
+			Context:     ctx,
+		})
+		if cancelled(err) {
+			return
+		}
+		if err != nil {
+			slog.Error("manual backup failed", "path", p.dnfPath, "err", err)
+			p.notify(true, p.backupTab, i18n.T("backup.createFailed"), err.Error())
+			return
+		}
+		p.backupList.Refresh()
+		p.backupSucceeded()
+		p.notify(true, p.backupTab, i18n.T("backup.created"), i18n.T("backup.autoFinishedMessage", len(b.Files)))
+	}()
+}
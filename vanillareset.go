@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/backup"
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+	"dnf_patch/internal/relpath"
+	"dnf_patch/internal/vanilla"
+)
+
+var errGameRunning = errors.New("the game is running; close it first")
+
+// vanillaReport is the outcome of removeAllPatches.
+type vanillaReport struct {
+	Time         time.Time
+	GameDir      string
+	Version      string
+	SafetyBackup string // empty when nothing had to be changed
+	Steps        []vanilla.Step
+	Left         []vanilla.Result // files that could not be brought back
+	Path         string           // where the report was written
+}
+
+// npkPath returns the path relative to the game folder gameDir of the file
+// name in its NPK folder npkDir, the form backups record files by.
+func npkPath(gameDir, npkDir, name string) relpath.Path {
+	rel, err := relpath.Rel(gameDir, filepath.Join(npkDir, name))
+	if err != nil {
+		return relpath.Canonical(name)
+	}
+	return rel
+}
+
+// vanillaFinder returns the find function of vanilla.Plan. Saved copies are
+// looked up in the backups of the profile, whose hashes are recorded, and in
+// the backup_* folders installs leave in the game directory.
+func (p *PatchManager) vanillaFinder() func(name, hash string) string {
+	type key struct {
+		path relpath.Path
+		hash string
+	}
+	known := make(map[key]string)
+	for _, b := range p.backups.Backups() {
+		if b.Format != backup.FormatFolder {
+			// Archived files would have to be extracted first
+			continue
+		}
+		dir := p.backups.Dir(b.ID)
+		for _, f := range b.Files {
+			known[key{f.Path, strings.ToLower(f.Hash)}] = f.Path.In(dir)
+		}
+	}
+	installBackups, _ := filepath.Glob(filepath.Join(p.dnfPath, "backup_*"))
+	gameDir, npkDir := p.dnfPath, gamepath.ImagePackPath(p.dnfPath)
+
+	return func(name, hash string) string {
+		if path, ok := known[key{npkPath(gameDir, npkDir, name), hash}]; ok {
+			return path
+		}
+		for _, dir := range installBackups {
+			path := gamepath.JoinFold(dir, name)
+			if h, err := fsutil.HashFile(path); err == nil && h == hash {
+				return path
+			}
+		}
+		return ""
+	}
+}
+
+// removeAllPatches brings every file changed by an installed patch back to
+// the vanilla client, after backing up the files it changes, and forgets the
+// patches. Modified files without a saved vanilla copy are left alone and
+// listed in the report, which is also written to the data directory.
+// Cancelling ctx stops it before any file is put back.
+func (p *PatchManager) removeAllPatches(ctx context.Context, status func(string), progress func(done, total int)) (vanillaReport, error) {
+	report := vanillaReport{Time: time.Now(), GameDir: p.dnfPath}
+	if gamepath.Running(p.dnfPath) {
+		return report, errGameRunning
+	}
+	m, err := p.gameManifest(ctx)
+	if err != nil {
+		return report, err
+	}
+	report.Version = m.Version
+
+	status(i18n.T("vanilla.checking"))
+	dir := gamepath.ImagePackPath(p.dnfPath)
+	results, err := vanilla.Verify(ctx, dir, m, p.installed.Patches(), progress)
+	if err != nil {
+		return report, err
+	}
+	steps, _ := vanilla.Plan(results, m, p.vanillaFinder())
+
+	if len(steps) > 0 {
+		changed := make(map[relpath.Path]bool, len(steps))
+		for _, step := range steps {
+			changed[npkPath(p.dnfPath, dir, step.Name)] = true
+		}
+		gameDir := p.dnfPath
+		include := func(path string) bool {
+			rel, err := relpath.Rel(gameDir, path)
+			return err == nil && changed[rel]
+		}
+		status(i18n.T("vanilla.backingUp"))
+		b, err := p.createBackup(backup.Options{
+			Description: p.vanillaBackupDescription(),
+			Type:        "manual",
+			Include:     include,
+			Progress:    progress,
+			Context:     ctx,
+		})
+		if err != nil {
+			return report, fmt.Errorf("safety backup failed: %w", err)
+		}
+		report.SafetyBackup = b.ID
+	}
+
+	// The safety backup may have pruned backups holding vanilla copies
+	steps, report.Left = vanilla.Plan(results, m, p.vanillaFinder())
+	status(i18n.T("vanilla.restoring"))
+	if err := vanilla.Apply(dir, steps, progress); err != nil {
+		return report, fmt.Errorf("%w (backup %s holds the files from before)", err, report.SafetyBackup)
+	}
+	report.Steps = steps
+
+	left := make(map[relpath.Path]bool, len(report.Left))
+	for _, r := range report.Left {
+		left[relpath.Canonical(r.Name)] = true
+	}
+	for _, rec := range p.installed.Patches() {
+		if left[rec.Key()] {
+			continue
+		}
+		if err := p.installed.Remove(rec.Filename); err != nil {
+			slog.Error("updating installed patches failed", "patch", rec.Filename, "err", err)
+		}
+		p.addToHistory(patchdb.Patch{ID: rec.PatchID, Name: rec.PatchName, Version: rec.Version}, "Uninstalled")
+	}
+
+	report.Path, err = p.writeVanillaReport(report)
+	if err != nil {
+		slog.Error("writing report failed", "err", err)
+	}
+	slog.Info("removed all patches", "path", p.dnfPath, "changed", len(report.Steps), "left", len(report.Left))
+	return report, nil
+}
+
+// writeVanillaReport writes r as text into the reports folder of the data
+// directory and returns its path.
+func (p *PatchManager) writeVanillaReport(r vanillaReport) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Remove all patches, %s\n", r.Time.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Game: %s (version %s)\n", r.GameDir, r.Version)
+	if r.SafetyBackup != "" {
+		fmt.Fprintf(&b, "Safety backup: %s\n", r.SafetyBackup)
+	}
+
+	var restored, removed []string
+	for _, step := range r.Steps {
+		line := step.Name
+		if step.Patch != "" {
+			line += " (" + step.Patch + ")"
+		}
+		if step.Original == "" {
+			removed = append(removed, line)
+		} else {
+			restored = append(restored, line)
+		}
+	}
+	section := func(title string, lines []string) {
+		fmt.Fprintf(&b, "\n%s: %d\n", title, len(lines))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	section("Restored", restored)
+	section("Removed", removed)
+	var left []string
+	for _, res := range r.Left {
+		left = append(left, fmt.Sprintf("%s [%s]", res.Name, res.Status))
+	}
+	section("Not reverted, repair the game to fix", left)
+
+	path := filepath.Join(p.dataDir, "reports", fmt.Sprintf("remove-all-patches-%s.txt", r.Time.Format("20060102_150405")))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, fsutil.WriteFileAtomic(path, []byte(b.String()), 0644)
+}
+
+// showRemoveAllPatches asks for confirmation and then runs removeAllPatches
+// in the background.
+func (p *PatchApp) showRemoveAllPatches() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("vanilla.title"), i18n.T("path.chooseFirst"), p.window)
+		return
+	}
+	if gamepath.Running(p.dnfPath) {
+		p.showError(errGameRunning)
+		return
+	}
+
+	dialog.ShowConfirm(i18n.T("vanilla.title"), i18n.T("vanilla.confirm"),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			p.progress.Set(0)
+			go func() {
+				defer p.recoverPanic(i18n.T("op.removeAll"))
+				ctx, end := p.operations.begin(i18n.T("op.removeAll"))
+				defer end()
+				report, err := p.removeAllPatches(ctx, p.updateStatus, p.setProgress)
+				p.historyList.Refresh()
+				p.backupList.Refresh()
+				if err != nil {
+					slog.Error("removing all patches failed", "path", p.dnfPath, "err", err)
+					p.updateStatus(i18n.T("vanilla.failed"))
+					p.showError(err)
+					return
+				}
+				p.updateStatus(i18n.T("vanilla.done", len(report.Steps)))
+				p.showVanillaReport(report)
+			}()
+		},
+		p.window)
+}
+
+func (p *PatchApp) showVanillaReport(r vanillaReport) {
+	content := container.NewVBox(widget.NewLabel(i18n.T("vanilla.report", len(r.Steps))))
+	if r.SafetyBackup != "" {
+		content.Add(widget.NewLabel(i18n.T("vanilla.safetyBackup", r.SafetyBackup)))
+	}
+	if len(r.Left) > 0 {
+		var lines []string
+		for _, res := range r.Left {
+			lines = append(lines, fmt.Sprintf("%s (%s)", res.Name, vanillaStatusText(res.Status)))
+		}
+		warning := widget.NewLabel(i18n.T("vanilla.left", len(r.Left)))
+		warning.Wrapping = fyne.TextWrapWord
+		list := widget.NewLabel(strings.Join(lines, "\n"))
+		scroll := container.NewVScroll(list)
+		scroll.SetMinSize(fyne.NewSize(0, 150))
+		content.Add(warning)
+		content.Add(scroll)
+	}
+
+	d := dialog.NewCustom(i18n.T("vanilla.title"), i18n.T("common.close"), content, p.window)
+	if r.Path != "" {
+		d.SetButtons([]fyne.CanvasObject{
+			widget.NewButton(i18n.T("vanilla.openReport"), func() {
+				u, err := url.Parse(storage.NewFileURI(r.Path).String())
+				if err == nil {
+					err = fyne.CurrentApp().OpenURL(u)
+				}
+				if err != nil {
+					slog.Error("opening report failed", "path", r.Path, "err", err)
+				}
+			}),
+			widget.NewButton(i18n.T("common.close"), d.Hide),
+		})
+	}
+	d.Resize(fyne.NewSize(550, 0))
+	d.Show()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/fsutil"
+	"dnf_patch/internal/gamepath"
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/vanilla"
+)
+
+// vanillaStatusIDs names the message describing each result status in the
+// verify dialog.
+var vanillaStatusIDs = map[vanilla.Status]string{
+	vanilla.Vanilla:  "verify.vanilla",
+	vanilla.Patched:  "verify.patched",
+	vanilla.Modified: "verify.modified",
+	vanilla.Missing:  "verify.missing",
+}
+
+func vanillaStatusText(status vanilla.Status) string {
+	return i18n.T(vanillaStatusIDs[status])
+}
+
+// loadVanillaManifest fetches the vanilla hashes of the given game version
+// from vanilla/<version>.json next to the repository's patches.json, falling
+// back to the last fetched copy and then to the bundled file.
+func (p *PatchManager) loadVanillaManifest(ctx context.Context, version string) (vanilla.Manifest, error) {
+	name := version + ".json"
+	cachedPath := filepath.Join(p.cacheDir(), "vanilla", name)
+	if p.config.RepositoryURL != "" {
+		var m vanilla.Manifest
+		base, err := url.Parse(p.config.RepositoryURL)
+		var data []byte
+		if err == nil {
+			data, err = p.fetchRepository(ctx, base.ResolveReference(&url.URL{Path: "vanilla/" + name}).String())
+		}
+		if err == nil {
+			m, err = vanilla.Parse(data)
+		}
+		if err == nil {
+			if err := os.MkdirAll(filepath.Dir(cachedPath), 0755); err == nil {
+				fsutil.WriteFileAtomic(cachedPath, data, 0644)
+			}
+			return m, nil
+		}
+		slog.Error("fetching vanilla hashes failed", "version", version, "err", err)
+
+		if m, err := vanilla.Load(cachedPath); err == nil {
+			return m, nil
+		}
+	}
+
+	m, err := vanilla.Load(filepath.Join(p.exeDir, "patches", "vanilla", name))
+	if os.IsNotExist(err) {
+		return m, fmt.Errorf("no vanilla hashes are available for game version %s", version)
+	}
+	return m, err
+}
+
+// gameManifest returns the vanilla hashes of the version of the game.
+func (p *PatchManager) gameManifest(ctx context.Context) (vanilla.Manifest, error) {
+	version := gamepath.DetectVersion(p.dnfPath)
+	if version == "" {
+		return vanilla.Manifest{}, fmt.Errorf("cannot determine the version of the game at %s", p.dnfPath)
+	}
+	return p.loadVanillaManifest(ctx, version)
+}
+
+// verifyGameFiles compares the NPK files of the game with the vanilla client
+// of the same version, stopping once ctx is cancelled.
+func (p *PatchManager) verifyGameFiles(ctx context.Context, progress func(done, total int)) ([]vanilla.Result, error) {
+	m, err := p.gameManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vanilla.Verify(ctx, gamepath.ImagePackPath(p.dnfPath), m, p.installed.Patches(), progress)
+}
+
+// showVerifyGameFiles runs verifyGameFiles in the background and shows the
+// result.
+func (p *PatchApp) showVerifyGameFiles() {
+	if !p.pathUsable() {
+		dialog.ShowInformation(i18n.T("verify.title"), i18n.T("path.chooseFirst"), p.window)
+		return
+	}
+
+	p.updateStatus(i18n.T("verify.running"))
+	p.progress.Set(0)
+	go func() {
+		defer p.recoverPanic(i18n.T("op.verify"))
+		ctx, end := p.operations.begin(i18n.T("op.verify"))
+		defer end()
+		results, err := p.verifyGameFiles(ctx, p.setProgress)
+		if cancelled(err) {
+			return
+		}
+		if err != nil {
+			slog.Error("verifying game files failed", "path", p.dnfPath, "err", err)
+			p.updateStatus(i18n.T("verify.failed"))
+			p.showError(err)
+			return
+		}
+		counts := vanilla.Count(results)
+		slog.Info("verified game files", "path", p.dnfPath, "modified", counts[vanilla.Modified], "missing", counts[vanilla.Missing])
+		p.updateStatus(i18n.T("verify.done", len(results)))
+		p.showVerifyResults(results)
+	}()
+}
+
+func (p *PatchApp) showVerifyResults(results []vanilla.Result) {
+	counts := vanilla.Count(results)
+	summary := widget.NewLabel(i18n.T("verify.summary",
+		counts[vanilla.Vanilla], counts[vanilla.Patched], counts[vanilla.Modified], counts[vanilla.Missing]))
+	summary.Wrapping = fyne.TextWrapWord
+
+	// Files in their vanilla state are listed last
+	var shown []vanilla.Result
+	for _, status := range []vanilla.Status{vanilla.Modified, vanilla.Missing, vanilla.Patched, vanilla.Vanilla} {
+		for _, r := range results {
+			if r.Status == status {
+				shown = append(shown, r)
+			}
+		}
+	}
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(widget.NewLabel("Template"), widget.NewLabel("Template"))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			box := item.(*fyne.Container)
+			r := shown[id]
+			box.Objects[0].(*widget.Label).SetText(vanillaStatusText(r.Status))
+			name := r.Name
+			if r.Patch != "" {
+				name = fmt.Sprintf("%s (%s)", r.Name, r.Patch)
+			}
+			box.Objects[1].(*widget.Label).SetText(name)
+		},
+	)
+
+	d := dialog.NewCustom(i18n.T("verify.title"), i18n.T("common.close"), container.NewBorder(summary, nil, nil, nil, list), p.window)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton(i18n.T("common.export"), func() { p.exportVerifyResults(results) }),
+		widget.NewButton(i18n.T("common.close"), d.Hide),
+	})
+	d.Resize(fyne.NewSize(600, 500))
+	d.Show()
+}
+
+func (p *PatchApp) exportVerifyResults(results []vanilla.Result) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			p.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		err = vanilla.WriteCSV(writer, results)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			slog.Error("exporting verify results failed", "path", writer.URI().Path(), "err", err)
+			p.showError(err)
+		}
+	}, p.window)
+	save.SetFileName("verify-game-files.csv")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
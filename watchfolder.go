@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+	"dnf_patch/internal/patchzip"
+	"dnf_patch/internal/watch"
+	"dnf_patch/internal/zipcrypt"
+)
+
+// startWatcher (re)starts watching the configured watch folder for new patch
+// files, stopping the previous watcher.
+func (p *PatchApp) startWatcher() {
+	p.stopWatcher()
+	if p.config.WatchDir == "" {
+		return
+	}
+
+	seenPath := filepath.Join(p.dataDir, "watched.json")
+	seen, err := watch.LoadSeen(seenPath)
+	if err != nil {
+		slog.Error("loading watched files failed", "path", seenPath, "err", err)
+	}
+	dir := p.config.WatchDir
+	p.watcher = watch.New(dir, seen, p.patchDropped, func(err error) {
+		slog.Warn("watch folder unavailable, retrying", "dir", dir, "err", err)
+	})
+	p.watcher.Start()
+	slog.Info("watching for patch files", "dir", dir)
+}
+
+func (p *PatchApp) stopWatcher() {
+	if p.watcher != nil {
+		p.watcher.Stop()
+		p.watcher = nil
+	}
+}
+
+// patchDropped is called by the watcher for each new patch file. Depending
+// on the settings it is installed right away, one file at a time, or offered
+// for install.
+func (p *PatchApp) patchDropped(path string) {
+	defer p.recoverPanic(i18n.T("op.importDownloaded"))
+	name := filepath.Base(path)
+	slog.Info("new patch file in watch folder", "path", path)
+
+	message := i18n.T("watch.newPatch", name, filepath.Dir(path))
+	sizeErr := p.checkDownloadSize(path)
+	if sizeErr != nil {
+		// Possibly a truncated download or a different file, so always ask
+		slog.Warn("downloaded patch has an unexpected size", "path", path, "err", sizeErr)
+		message += "\n\n" + i18n.T("watch.sizeMismatch")
+	}
+	if p.config.WatchAction != "install" || !p.pathUsable() || sizeErr != nil {
+		p.notify(p.config.Notify.Downloads, nil, i18n.T("watch.downloaded"), name)
+		dialog.ShowConfirm(i18n.T("watch.newPatchTitle"), message,
+			func(ok bool) {
+				if ok {
+					go p.installDropped(path)
+				}
+			},
+			p.window)
+		return
+	}
+	p.installDropped(path)
+}
+
+// checkDownloadSize compares the size of the downloaded file at path with the
+// catalogue patches of the same file name, if there are any. It passes when
+// the size matches one of them.
+func (p *PatchManager) checkDownloadSize(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	var sizeErr error
+	for _, patch := range p.patchIndex.ByFilename(filepath.Base(path)) {
+		if err := patchdb.CheckSize(patch, info.Size()); err == nil {
+			return nil
+		} else if sizeErr == nil {
+			sizeErr = err
+		}
+	}
+	return sizeErr
+}
+
+// installDropped installs the patch file at path, or every NPK file in it
+// when it is a ZIP archive, and tells the user how it went.
+func (p *PatchApp) installDropped(path string) {
+	defer p.recoverPanic(i18n.T("op.importDownloaded"))
+	ctx, end := p.operations.begin(i18n.T("op.importDownloaded"))
+	defer end()
+	name := filepath.Base(path)
+	p.progress.Set(0)
+
+	installed, err := p.installPatchFile(ctx, path, p.updateStatus)
+	p.historyList.Refresh()
+	if errors.Is(err, context.Canceled) {
+		p.updateStatus(i18n.T("zipPassword.cancelled", name))
+		return
+	}
+	if err != nil {
+		slog.Error("importing downloaded patch failed", "path", path, "err", err)
+		p.notify(p.config.Notify.Installs, p.historyTab, i18n.T("watch.installFailed"), fmt.Sprintf("%s: %v", name, installError(err)))
+		return
+	}
+	p.progress.Set(1)
+	p.notify(p.config.Notify.Installs, p.historyTab, i18n.T("watch.installed"), strings.Join(installed, ", "))
+}
+
+// installError returns err, or a plainer error when it is down to a wrong
+// archive password.
+func installError(err error) error {
+	if errors.Is(err, zipcrypt.ErrPassword) {
+		return errors.New(i18n.T("zipPassword.failed"))
+	}
+	return err
+}
+
+// installPatchFile installs the .npk file at path, or the .npk files inside
+// it when it is a .zip archive, and returns the names installed. The user
+// chooses which files of an archive to install when it has several or a
+// readme, unless askZipEntries is not set.
+func (p *PatchManager) installPatchFile(ctx context.Context, path string, progress func(string)) ([]string, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		name := filepath.Base(path)
+		if err := p.installTracked(ctx, path, f, name, progress); err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	archive := filepath.Base(path)
+	entries := patchzip.Entries(&r.Reader)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s contains no NPK files", archive)
+	}
+	if p.askZipEntries != nil {
+		// Archives often hold variants of a patch that must not all be
+		// installed, which their readme explains
+		readme := patchzip.Readme(&r.Reader, p.knownZipPasswords())
+		if len(entries) > 1 || readme != "" {
+			chosen, ok := p.askZipEntries(ctx, archive, entries, readme)
+			if !ok {
+				return nil, errChoiceCancelled
+			}
+			entries = chosen
+		}
+	}
+
+	// The passwords are all asked for and the files all extracted and
+	// checked first, so that giving up on a password or a file that is not
+	// an NPK leaves nothing half installed
+	files := make([]*zip.File, len(entries))
+	for i, e := range entries {
+		files[i] = e.File
+	}
+	passwords, err := p.unlockZip(ctx, archive, files)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(p.cacheDir(), 0755); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(p.cacheDir(), "extract-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	extracted := make([]string, len(entries))
+	for i, e := range entries {
+		progress(i18n.T("zipEntries.extracting", e.Name))
+		if extracted[i], err = patchzip.Extract(ctx, e, passwords[e.File], dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var installed []string
+	for i, e := range entries {
+		f, err := os.Open(extracted[i])
+		if err != nil {
+			return installed, err
+		}
+		err = p.installTracked(ctx, "", f, e.Name, progress)
+		f.Close()
+		if err != nil {
+			return installed, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		installed = append(installed, e.Name)
+	}
+	return installed, nil
+}
+
+// installTracked is installPatch recording the outcome in the history. The
+// file is installed under the normalized form of name.
+func (p *PatchManager) installTracked(ctx context.Context, source string, src io.Reader, name string, progress func(string)) error {
+	patch, known := p.patchForFile(name)
+	target, err := p.importName(name)
+	if err != nil {
+		p.addFailureToHistory(patch, err)
+		return err
+	}
+	rec, err := p.installPatch(ctx, source, src, target, progress)
+	if err != nil {
+		p.addFailureToHistory(patch, err)
+		return err
+	}
+	if target != name {
+		slog.Info("installed patch file under a normalized name", "file", name, "as", target)
+		rec.SourceName = name
+	}
+	if known {
+		rec.PatchID, rec.PatchName, rec.Version = patch.ID, patch.Name, patch.Version
+	}
+	if known || target != name {
+		if err := p.installed.Add(rec); err != nil {
+			slog.Error("saving installed patches failed", "patch", patch.ID, "err", err)
+		}
+	}
+	p.addToHistory(patch, "Installed")
+	return nil
+}
+
+// patchForFile returns the catalogue patch installed as the file name, and
+// whether there is exactly one. Otherwise it returns a patch named after the
+// file.
+func (p *PatchManager) patchForFile(name string) (patchdb.Patch, bool) {
+	if matches := p.patchIndex.ByFilename(name); len(matches) == 1 {
+		return matches[0], true
+	}
+	return patchdb.Patch{ID: strings.TrimSuffix(name, filepath.Ext(name)), Name: name}, false
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchdb"
+	"dnf_patch/internal/whatsnew"
+)
+
+// maxWhatsNew is how many patches the New & updated section lists; the rest
+// are counted.
+const maxWhatsNew = 8
+
+// whatsNewItem is a change of the catalogue whose patch is still in it.
+type whatsNewItem struct {
+	whatsnew.Change
+	patch patchdb.Patch
+}
+
+// recordCatalog compares the versions of the patches in db, a catalogue just
+// fetched from the repository, with those of the fetch before, see
+// whatsnew.Feed.Update.
+func (p *PatchManager) recordCatalog(db patchdb.Database) {
+	if p.whatsNew == nil {
+		return
+	}
+	versions := make(map[string]string)
+	for _, c := range db.Categories {
+		for _, patch := range c.Patches {
+			if _, ok := versions[patch.ID]; !ok {
+				versions[patch.ID] = patch.Version
+			}
+		}
+	}
+	found, err := p.whatsNew.Update(versions, time.Now())
+	if err != nil {
+		slog.Error("recording the catalogue for what's new failed", "err", err)
+		return
+	}
+	if len(found) > 0 {
+		slog.Info("catalogue has new and updated patches", "count", len(found))
+	}
+}
+
+// createWhatsNewUI creates the space at the top of the Patches tab filled by
+// refreshWhatsNew, hidden until there is something to show.
+func (p *PatchApp) createWhatsNewUI() fyne.CanvasObject {
+	p.whatsNewView = container.NewMax()
+	p.whatsNewView.Hide()
+	return p.whatsNewView
+}
+
+// refreshWhatsNew lists the patches added or updated since the list was
+// last cleared, most recently updated first by their LastUpdated, or hides
+// the section when there are none. Changes of patches no longer in the
+// catalogue are left out.
+func (p *PatchApp) refreshWhatsNew() {
+	if p.whatsNewView == nil || p.whatsNew == nil {
+		return
+	}
+	var items []whatsNewItem
+	for _, c := range p.whatsNew.Changes() {
+		if patch, ok := p.catalogPatch(c.PatchID); ok {
+			items = append(items, whatsNewItem{c, patch})
+		}
+	}
+	if len(items) == 0 {
+		p.whatsNewView.Objects = nil
+		p.whatsNewView.Hide()
+		return
+	}
+	sortWhatsNew(items)
+	p.whatsNewView.Objects = []fyne.CanvasObject{p.createWhatsNewList(items)}
+	p.whatsNewView.Show()
+	p.whatsNewView.Refresh()
+}
+
+// sortWhatsNew sorts items by when their patches were updated, newest
+// first and those without a date last, then by when the change was found.
+func sortWhatsNew(items []whatsNewItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, aok := items[i].patch.Updated()
+		b, bok := items[j].patch.Updated()
+		if aok != bok {
+			return aok
+		}
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return items[i].Found.After(items[j].Found)
+	})
+}
+
+// createWhatsNewList shows items, each with its new version and the version
+// before, and a button clearing them. Clicking a patch opens its details.
+func (p *PatchApp) createWhatsNewList(items []whatsNewItem) fyne.CanvasObject {
+	rows := container.NewVBox()
+	for i, item := range items {
+		if i == maxWhatsNew {
+			rows.Add(widget.NewLabel(i18n.T("common.andMore", len(items)-maxWhatsNew)))
+			break
+		}
+		patch := item.patch
+		name := widget.NewButton(patch.Name, func() { p.showPatchDetails(patch) })
+		name.Alignment = widget.ButtonAlignLeading
+		name.Importance = widget.LowImportance
+		change := widget.NewLabel(i18n.T("whatsNew.updated", item.Previous, item.Version))
+		if item.Added() {
+			change.SetText(i18n.T("whatsNew.added"))
+			change.Importance = widget.SuccessImportance
+		}
+		date := widget.NewLabel("")
+		if t, ok := patch.Updated(); ok {
+			date.SetText(i18n.FormatDate(t))
+		}
+		rows.Add(container.NewBorder(nil, nil, name, date, change))
+	}
+
+	heading := widget.NewLabelWithStyle(i18n.T("whatsNew.title", len(items)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	clear := widget.NewButtonWithIcon(i18n.T("whatsNew.clear"), theme.ConfirmIcon(), func() {
+		if err := p.whatsNew.Clear(); err != nil {
+			slog.Error("clearing what's new failed", "err", err)
+			p.showError(err)
+			return
+		}
+		p.refreshWhatsNew()
+	})
+	clear.Importance = widget.LowImportance
+	background := canvas.NewRectangle(badgeBackground)
+	background.CornerRadius = theme.InputRadiusSize()
+	return container.NewMax(background, container.NewPadded(container.NewVBox(
+		container.NewBorder(nil, nil, nil, clear, heading),
+		rows,
+	)))
+}
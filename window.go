@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// The size the main window opens at on first launch, and the smallest size
+// restored from the config.
+var (
+	defaultWindowSize = fyne.NewSize(900, 600)
+	minWindowSize     = fyne.NewSize(480, 360)
+)
+
+// WindowState is the main window as it was when DNF Patch last quit.
+type WindowState struct {
+	Width  float32 `json:"width,omitempty"`
+	Height float32 `json:"height,omitempty"`
+	// X and Y are the screen position in pixels. Only Windows records it.
+	X           int  `json:"x,omitempty"`
+	Y           int  `json:"y,omitempty"`
+	HasPosition bool `json:"hasPosition,omitempty"`
+	Tab         int  `json:"tab"`
+}
+
+// restoreWindowState sizes the main window and selects the tab as they were
+// last time. The window is centered, or on Windows moved back to where it
+// was as long as that is still on a screen, and then fitted into the screen.
+func (p *PatchApp) restoreWindowState() {
+	state := p.config.Window
+	size := defaultWindowSize
+	if state.Width > 0 && state.Height > 0 {
+		size = fyne.NewSize(state.Width, state.Height).Max(minWindowSize)
+	}
+	p.window.Resize(size)
+	p.window.CenterOnScreen()
+	if state.Tab > 0 && state.Tab < len(p.tabs.Items) {
+		p.tabs.SelectIndex(state.Tab)
+	}
+
+	go func() {
+		// The window only appears once the event loop runs
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			if placeWindow(p.window.Title(), state.X, state.Y, state.HasPosition) {
+				return
+			}
+		}
+	}()
+}
+
+// recordWindowPosition notes where the main window is before it closes.
+func (p *PatchApp) recordWindowPosition() {
+	if x, y, ok := windowPosition(p.window.Title()); ok {
+		p.config.Window.X, p.config.Window.Y = x, y
+		p.config.Window.HasPosition = true
+	}
+}
+
+// saveWindowState saves the size of the main window, its position recorded
+// by recordWindowPosition and the selected tab.
+func (p *PatchApp) saveWindowState() {
+	size := p.window.Canvas().Size()
+	p.updateConfig(func(c *AppConfig) {
+		if size.Width > 0 && size.Height > 0 {
+			c.Window.Width, c.Window.Height = size.Width, size.Height
+		}
+		c.Window.Tab = p.tabs.SelectedIndex()
+	})
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+// placeWindow is only implemented for Windows; elsewhere the window stays
+// centered.
+func placeWindow(title string, x, y int, move bool) bool {
+	return true
+}
+
+// windowPosition is only implemented for Windows.
+func windowPosition(title string) (x, y int, ok bool) {
+	return 0, 0, false
+}
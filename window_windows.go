@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32                 = syscall.NewLazyDLL("user32.dll")
+	procEnumWindows        = user32.NewProc("EnumWindows")
+	procGetClassName       = user32.NewProc("GetClassNameW")
+	procGetWindowText      = user32.NewProc("GetWindowTextW")
+	procGetWindowThreadPID = user32.NewProc("GetWindowThreadProcessId")
+	procGetWindowRect      = user32.NewProc("GetWindowRect")
+	procIsWindowVisible    = user32.NewProc("IsWindowVisible")
+	procIsIconic           = user32.NewProc("IsIconic")
+	procMonitorFromRect    = user32.NewProc("MonitorFromRect")
+	procGetMonitorInfo     = user32.NewProc("GetMonitorInfoW")
+	procSetWindowPos       = user32.NewProc("SetWindowPos")
+	enumWindowsCallback    = syscall.NewCallback(enumWindowsProc)
+)
+
+// The search of findWindow, which enumWindowsProc fills in.
+var (
+	enumWindowsMu    sync.Mutex
+	enumWindowsTitle string
+	enumWindowsFound uintptr
+)
+
+const (
+	monitorDefaultToNull    = 0
+	monitorDefaultToNearest = 2
+	swpNoZOrder             = 0x0004
+	swpNoActivate           = 0x0010
+)
+
+type rect struct {
+	Left, Top, Right, Bottom int32
+}
+
+type monitorInfo struct {
+	Size    uint32
+	Monitor rect
+	Work    rect
+	Flags   uint32
+}
+
+func enumWindowsProc(hwnd, _ uintptr) uintptr {
+	var pid uint32
+	procGetWindowThreadPID.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if int(pid) != os.Getpid() {
+		return 1
+	}
+	buf := make([]uint16, 256)
+	procGetClassName.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if syscall.UTF16ToString(buf) != "GLFW30" {
+		return 1
+	}
+	procGetWindowText.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if syscall.UTF16ToString(buf) != enumWindowsTitle {
+		return 1
+	}
+	enumWindowsFound = hwnd
+	return 0
+}
+
+// findWindow returns the handle of the visible window of this process with
+// the given title, or 0. Fyne does not expose the handles of its windows.
+func findWindow(title string) uintptr {
+	enumWindowsMu.Lock()
+	defer enumWindowsMu.Unlock()
+
+	enumWindowsTitle, enumWindowsFound = title, 0
+	procEnumWindows.Call(enumWindowsCallback, 0)
+	if enumWindowsFound == 0 {
+		return 0
+	}
+	if visible, _, _ := procIsWindowVisible.Call(enumWindowsFound); visible == 0 {
+		return 0
+	}
+	return enumWindowsFound
+}
+
+// placeWindow moves the window with the given title to x, y when move is set
+// and that position is still on a monitor, then fits it into the work area
+// of its monitor. It reports false when the window is not shown yet.
+func placeWindow(title string, x, y int, move bool) bool {
+	hwnd := findWindow(title)
+	if hwnd == 0 {
+		return false
+	}
+	var r rect
+	procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
+	if move {
+		moved := rect{int32(x), int32(y), int32(x) + r.Right - r.Left, int32(y) + r.Bottom - r.Top}
+		// A monitor may have been unplugged since
+		if m, _, _ := procMonitorFromRect.Call(uintptr(unsafe.Pointer(&moved)), monitorDefaultToNull); m != 0 {
+			r = moved
+		}
+	}
+
+	m, _, _ := procMonitorFromRect.Call(uintptr(unsafe.Pointer(&r)), monitorDefaultToNearest)
+	info := monitorInfo{Size: uint32(unsafe.Sizeof(monitorInfo{}))}
+	if ok, _, _ := procGetMonitorInfo.Call(m, uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return true
+	}
+	work := info.Work
+	w, h := min(r.Right-r.Left, work.Right-work.Left), min(r.Bottom-r.Top, work.Bottom-work.Top)
+	left := max(work.Left, min(r.Left, work.Right-w))
+	top := max(work.Top, min(r.Top, work.Bottom-h))
+	procSetWindowPos.Call(hwnd, 0, uintptr(left), uintptr(top), uintptr(w), uintptr(h), swpNoZOrder|swpNoActivate)
+	return true
+}
+
+// windowPosition returns the screen position of the window with the given
+// title. It reports false when there is no such window or it is minimized.
+func windowPosition(title string) (x, y int, ok bool) {
+	hwnd := findWindow(title)
+	if hwnd == 0 {
+		return 0, 0, false
+	}
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		return 0, 0, false
+	}
+	var r rect
+	procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&r)))
+	return int(r.Left), int(r.Top), true
+}
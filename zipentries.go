@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/patchzip"
+)
+
+// errChoiceCancelled is returned when the user cancels choosing the files
+// of an archive to install. Like errPasswordCancelled it wraps
+// context.Canceled.
+var errChoiceCancelled = fmt.Errorf("choosing files cancelled: %w", context.Canceled)
+
+// promptZipEntries lists the NPK files in archive with their sizes for the
+// user to choose the ones to install, next to the archive's readme when it
+// has one. It returns the chosen entries, and false when the user cancels,
+// chooses none or ctx is done first.
+func (p *PatchApp) promptZipEntries(ctx context.Context, archive string, entries []patchzip.Entry, readme string) ([]patchzip.Entry, bool) {
+	answers := make(chan []patchzip.Entry, 1)
+
+	checks := make([]*widget.Check, len(entries))
+	summary := widget.NewLabel("")
+	update := func() {
+		n, size := 0, int64(0)
+		for i, check := range checks {
+			if check != nil && check.Checked {
+				n++
+				size += entries[i].Size
+			}
+		}
+		summary.SetText(i18n.T("zipEntries.selected", n, len(entries), formatSize(size)))
+	}
+	list := container.NewVBox()
+	for i, e := range entries {
+		text := fmt.Sprintf("%s (%s)", e.Name, formatSize(e.Size))
+		if e.Encrypted {
+			text = i18n.T("zipEntries.encrypted", text)
+		}
+		checks[i] = widget.NewCheck(text, func(bool) { update() })
+		checks[i].SetChecked(true)
+		list.Add(checks[i])
+	}
+	update()
+	checkAll := func(checked bool) {
+		for _, check := range checks {
+			check.SetChecked(checked)
+		}
+	}
+	buttons := container.NewHBox(
+		widget.NewButton(i18n.T("common.selectAll"), func() { checkAll(true) }),
+		widget.NewButton(i18n.T("common.selectNone"), func() { checkAll(false) }),
+	)
+	header := container.NewVBox(wrappedLabel(i18n.T("zipEntries.message", archive, len(entries))), buttons)
+	var content fyne.CanvasObject = container.NewBorder(header, summary, nil, nil, container.NewVScroll(list))
+	size := fyne.NewSize(500, 450)
+	if readme != "" {
+		text := widget.NewLabel(readme)
+		text.Wrapping = fyne.TextWrapWord
+		side := container.NewBorder(
+			widget.NewLabelWithStyle(i18n.T("zipEntries.readme"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			nil, nil, nil, container.NewVScroll(text))
+		split := container.NewHSplit(content, side)
+		split.Offset = 0.5
+		content, size = split, fyne.NewSize(800, 500)
+	}
+
+	d := dialog.NewCustomConfirm(i18n.T("zipEntries.title"), i18n.T("patch.install"), i18n.T("common.cancel"), content,
+		func(ok bool) {
+			var chosen []patchzip.Entry
+			for i, check := range checks {
+				if ok && check.Checked {
+					chosen = append(chosen, entries[i])
+				}
+			}
+			answers <- chosen
+		}, p.window)
+	d.Resize(size)
+	d.Show()
+
+	select {
+	case chosen := <-answers:
+		return chosen, len(chosen) > 0
+	case <-ctx.Done():
+		d.Hide()
+		return nil, false
+	}
+}
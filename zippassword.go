@@ -0,0 +1,195 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"dnf_patch/internal/i18n"
+	"dnf_patch/internal/zipcrypt"
+)
+
+// maxZipPasswords is how many passwords are kept to try on new archives.
+const maxZipPasswords = 10
+
+// errPasswordCancelled is returned when the user gives up entering the
+// password of an archive. It wraps context.Canceled so the rest of an import
+// stops too.
+var errPasswordCancelled = fmt.Errorf("password entry cancelled: %w", context.Canceled)
+
+// zipPasswords are the passwords that opened archives this session, most
+// recent first. It is safe for concurrent use.
+type zipPasswords struct {
+	mu   sync.Mutex
+	list []string
+}
+
+// List returns the passwords, most recent first.
+func (z *zipPasswords) List() []string {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return slices.Clone(z.list)
+}
+
+// Add puts password first, dropping the oldest beyond maxZipPasswords.
+func (z *zipPasswords) Add(password string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.list = addRecentPassword(z.list, password)
+}
+
+// addRecentPassword returns list with password moved or added to the front,
+// at most maxZipPasswords long.
+func addRecentPassword(list []string, password string) []string {
+	list = slices.DeleteFunc(slices.Clone(list), func(s string) bool { return s == password })
+	list = append([]string{password}, list...)
+	if len(list) > maxZipPasswords {
+		list = list[:maxZipPasswords]
+	}
+	return list
+}
+
+// knownZipPasswords returns the passwords to try before asking: those used
+// this session, then those the user chose to remember.
+func (p *PatchManager) knownZipPasswords() []string {
+	known := p.sessionPasswords.List()
+	for _, pw := range p.config.ZipPasswords {
+		if !slices.Contains(known, pw) {
+			known = append(known, pw)
+		}
+	}
+	return known
+}
+
+// unlockZip finds the passwords of the encrypted files, trying the known
+// passwords first and asking the user for the others. Unencrypted files are
+// not in the result. It returns errPasswordCancelled when the user gives up.
+func (p *PatchManager) unlockZip(ctx context.Context, archive string, files []*zip.File) (map[*zip.File]string, error) {
+	passwords := make(map[*zip.File]string)
+	for _, f := range files {
+		if !zipcrypt.Encrypted(f) {
+			continue
+		}
+		pw, err := p.zipPassword(ctx, archive, f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		passwords[f] = pw
+	}
+	return passwords, nil
+}
+
+// zipPassword finds the password of f, an encrypted file in archive.
+func (p *PatchManager) zipPassword(ctx context.Context, archive string, f *zip.File) (string, error) {
+	for _, pw := range p.knownZipPasswords() {
+		err := zipcrypt.Check(f, pw)
+		if err == nil {
+			p.sessionPasswords.Add(pw)
+			return pw, nil
+		}
+		if !errors.Is(err, zipcrypt.ErrPassword) {
+			return "", err
+		}
+	}
+	if p.askZipPassword == nil {
+		return "", fmt.Errorf("%s is protected with a password", archive)
+	}
+
+	wrong := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		pw, remember, ok := p.askZipPassword(ctx, archive, wrong)
+		if !ok {
+			return "", errPasswordCancelled
+		}
+		err := zipcrypt.Check(f, pw)
+		if errors.Is(err, zipcrypt.ErrPassword) {
+			slog.Info("wrong password for archive", "archive", archive)
+			wrong = true
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		p.sessionPasswords.Add(pw)
+		if remember {
+			p.rememberZipPassword(pw)
+		}
+		return pw, nil
+	}
+}
+
+// rememberZipPassword keeps password in the settings for later sessions.
+func (p *PatchManager) rememberZipPassword(password string) {
+	p.config.ZipPasswords = addRecentPassword(p.config.ZipPasswords, password)
+	if err := p.saveConfig(); err != nil {
+		slog.Error("saving config failed", "path", p.configPath(), "err", err)
+	}
+}
+
+// promptZipPassword asks for the password of archive, saying so when the
+// last one entered was wrong. It returns the password, whether to remember
+// it, and false when the user cancels or ctx is done first.
+func (p *PatchApp) promptZipPassword(ctx context.Context, archive string, wrong bool) (string, bool, bool) {
+	type answer struct {
+		password string
+		remember bool
+		ok       bool
+	}
+	answers := make(chan answer, 1)
+
+	message := i18n.T("zipPassword.message", archive)
+	if wrong {
+		message = i18n.T("zipPassword.wrong", archive)
+	}
+	password := widget.NewPasswordEntry()
+	remember := widget.NewCheck(i18n.T("zipPassword.remember"), nil)
+	d := dialog.NewForm(i18n.T("zipPassword.title"), i18n.T("zipPassword.open"), i18n.T("common.cancel"),
+		[]*widget.FormItem{
+			widget.NewFormItem("", wrappedLabel(message)),
+			widget.NewFormItem(i18n.T("zipPassword.password"), password),
+			widget.NewFormItem("", remember),
+		},
+		func(ok bool) {
+			answers <- answer{password.Text, remember.Checked, ok && password.Text != ""}
+		}, p.window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+	p.window.Canvas().Focus(password)
+
+	select {
+	case a := <-answers:
+		return a.password, a.remember, a.ok
+	case <-ctx.Done():
+		d.Hide()
+		return "", false, false
+	}
+}
+
+// createZipPasswordsUI creates the button forgetting the remembered ZIP
+// passwords, disabled when there are none.
+func (p *PatchApp) createZipPasswordsUI() fyne.CanvasObject {
+	var forget *widget.Button
+	forget = widget.NewButton(i18n.T("zipPassword.forget", len(p.config.ZipPasswords)), func() {
+		dialog.ShowConfirm(i18n.T("zipPassword.forgetTitle"), i18n.T("zipPassword.forgetConfirm"), func(ok bool) {
+			if !ok {
+				return
+			}
+			p.updateConfig(func(c *AppConfig) { c.ZipPasswords = nil })
+			forget.SetText(i18n.T("zipPassword.forget", 0))
+			forget.Disable()
+		}, p.window)
+	})
+	setEnabled(forget, len(p.config.ZipPasswords) > 0)
+	return forget
+}